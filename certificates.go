@@ -0,0 +1,164 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	grpcapi "github.com/attestantio/dirk/services/api/grpc"
+	restapi "github.com/attestantio/dirk/services/api/rest"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/go-majordomo"
+)
+
+// certificateKeys are the configuration keys of the certificates.
+var certificateKeys = []string{
+	"certificates.server-cert",
+	"certificates.server-key",
+	"certificates.ca-cert",
+}
+
+// certificateWatchDelay is the time to wait after a change to the certificate files before reloading
+// them, so that a certificate and key written one after the other are reloaded together.
+const certificateWatchDelay = time.Second
+
+// reloadCertificatesMu ensures that reloads triggered by a signal and by a change to the files do not overlap.
+var reloadCertificatesMu sync.Mutex
+
+// fetchCertificates fetches the server certificate, key and client CA certificate.
+func fetchCertificates(ctx context.Context, majordomo majordomo.Service) ([]byte, []byte, []byte, error) {
+	certPEMBlock, err := majordomo.Fetch(ctx, viper.GetString("certificates.server-cert"))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to obtain server certificate")
+	}
+	keyPEMBlock, err := majordomo.Fetch(ctx, viper.GetString("certificates.server-key"))
+	if err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to obtain server key")
+	}
+	var caPEMBlock []byte
+	if viper.GetString("certificates.ca-cert") != "" {
+		caPEMBlock, err = majordomo.Fetch(ctx, viper.GetString("certificates.ca-cert"))
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to obtain client CA certificate")
+		}
+	}
+	return certPEMBlock, keyPEMBlock, caPEMBlock, nil
+}
+
+// reloadCertificates fetches the certificates again and supplies them to the API services.
+// Failure to reload leaves the existing certificates in place.
+func reloadCertificates(ctx context.Context, majordomo majordomo.Service, api *grpcapi.Service, restAPI *restapi.Service) {
+	reloadCertificatesMu.Lock()
+	defer reloadCertificatesMu.Unlock()
+
+	log.Info().Msg("Reloading certificates")
+	certPEMBlock, keyPEMBlock, caPEMBlock, err := fetchCertificates(ctx, majordomo)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch certificates; retaining existing certificates")
+		return
+	}
+	if err := api.ReloadCertificates(ctx, certPEMBlock, keyPEMBlock, caPEMBlock); err != nil {
+		log.Error().Err(err).Msg("Failed to reload certificates")
+	}
+	if restAPI != nil {
+		if err := restAPI.ReloadCertificates(ctx, certPEMBlock, keyPEMBlock, caPEMBlock); err != nil {
+			log.Error().Err(err).Msg("Failed to reload REST API certificates")
+		}
+	}
+}
+
+// watchCertificates reloads the certificates when the files from which they are obtained change.
+// Only certificates obtained with file URLs are watched.
+func watchCertificates(ctx context.Context, majordomo majordomo.Service, api *grpcapi.Service, restAPI *restapi.Service) error {
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, key := range certificateKeys {
+		location := viper.GetString(key)
+		if location == "" {
+			continue
+		}
+		fileURL, err := url.Parse(location)
+		if err != nil || fileURL.Scheme != "file" {
+			log.Debug().Str("key", key).Msg("Certificate not obtained from a file; not watching")
+			continue
+		}
+		file := filepath.Clean(fileURL.Path)
+		files[file] = true
+		dirs[filepath.Dir(file)] = true
+	}
+	if len(dirs) == 0 {
+		log.Warn().Msg("No certificates obtained from files; not watching certificates")
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create watcher")
+	}
+	// The directories are watched, rather than the files, as files are commonly replaced rather than rewritten.
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return errors.Wrapf(err, "failed to watch %s", dir)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+		var reload <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if certificateFileEvent(files, event) {
+					log.Trace().Str("path", event.Name).Msg("Certificate file changed")
+					reload = time.After(certificateWatchDelay)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warn().Err(err).Msg("Error watching certificates")
+			case <-reload:
+				reload = nil
+				reloadCertificates(ctx, majordomo, api, restAPI)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// certificateFileEvent returns true if the event could change the contents of a certificate file.
+func certificateFileEvent(files map[string]bool, event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	if files[filepath.Clean(event.Name)] {
+		return true
+	}
+	// Kubernetes updates mounted secrets by replacing a hidden directory that the files link to.
+	return strings.HasPrefix(filepath.Base(event.Name), "..")
+}
@@ -16,7 +16,13 @@ package core
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
 
+	"github.com/attestantio/dirk/util/s3store"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	filesystem "github.com/wealdtech/go-eth2-wallet-store-filesystem"
@@ -36,6 +42,8 @@ type Store struct {
 	Type       string `mapstructure:"type"`
 	Location   string `mapstructure:"location"`
 	Passphrase string `mapstructure:"passphrase"`
+	Region     string `mapstructure:"region"`
+	Endpoint   string `mapstructure:"endpoint"`
 }
 
 // InitStores initialises the stores from a configuration.
@@ -64,8 +72,8 @@ func InitStores(ctx context.Context, stores []*Store) ([]e2wtypes.Store, error)
 			}
 			res = append(res, filesystem.New(opts...))
 		case "s3":
-			log.Trace().Str("name", store.Name).Msg("Adding S3 store")
-			s3Store, err := s3.New(s3.WithPassphrase([]byte(store.Passphrase)))
+			log.Trace().Str("name", store.Name).Str("location", store.Location).Msg("Adding S3 store")
+			s3Store, err := initS3Store(store)
 			if err != nil {
 				return nil, errors.Wrap(err, fmt.Sprintf("failed to access store %d", i))
 			}
@@ -80,6 +88,50 @@ func InitStores(ctx context.Context, stores []*Store) ([]e2wtypes.Store, error)
 	return res, nil
 }
 
+// initS3Store initialises an S3 store.
+// If the store has a location of the form s3://bucket/prefix the wallets are held in that bucket, which can be in an
+// S3-compatible object store; otherwise they are held in a bucket in Amazon S3 named after the access credentials.
+func initS3Store(store *Store) (e2wtypes.Store, error) {
+	if !strings.HasPrefix(store.Location, "s3://") {
+		opts := []s3.Option{s3.WithPassphrase([]byte(store.Passphrase))}
+		if store.Region != "" {
+			opts = append(opts, s3.WithRegion(store.Region))
+		}
+		return s3.New(opts...)
+	}
+
+	u, err := url.Parse(store.Location)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid S3 URL")
+	}
+	if u.Host == "" {
+		return nil, errors.New("no bucket in S3 URL")
+	}
+
+	cfg := aws.NewConfig()
+	if store.Region != "" {
+		cfg = cfg.WithRegion(store.Region)
+	}
+	if store.Endpoint != "" {
+		// S3-compatible store.
+		cfg = cfg.WithEndpoint(store.Endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create AWS session")
+	}
+
+	return s3store.New(
+		s3store.WithClient(awss3.New(sess)),
+		s3store.WithBucket(u.Host),
+		s3store.WithPrefix(u.Path),
+		s3store.WithPassphrase([]byte(store.Passphrase)),
+	)
+}
+
 // initDefaultStores initialises the default stores.
 func initDefaultStores() []e2wtypes.Store {
 	res := make([]e2wtypes.Store, 1)
@@ -3,23 +3,24 @@ module github.com/attestantio/dirk
 go 1.14
 
 require (
-	cloud.google.com/go v0.70.0 // indirect
+	cloud.google.com/go v0.70.0
 	github.com/DataDog/zstd v1.4.5 // indirect
-	github.com/HdrHistogram/hdrhistogram-go v0.9.0 // indirect
 	github.com/attestantio/go-eth2-client v0.6.9
+	github.com/aws/aws-sdk-go v1.35.26
 	github.com/dgraph-io/badger/v2 v2.2007.2
 	github.com/dgryski/go-farm v0.0.0-20200201041132-a6ae2369ad13 // indirect
 	github.com/ferranbt/fastssz v0.0.0-20201030134205-9b9624098321
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
-	github.com/golang/protobuf v1.4.3
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/golang/protobuf v1.5.0
 	github.com/golang/snappy v0.0.2 // indirect
 	github.com/google/uuid v1.1.2
+	github.com/googleapis/gax-go/v2 v2.0.5
 	github.com/grpc-ecosystem/go-grpc-middleware v1.2.2
 	github.com/herumi/bls-eth-go-binary v0.0.0-20201019012252-4b463a10c225
 	github.com/jackc/puddle v1.1.2
 	github.com/magiconair/properties v1.8.4 // indirect
+	github.com/miekg/pkcs11 v1.1.1
 	github.com/mitchellh/go-homedir v1.1.0
-	github.com/opentracing/opentracing-go v1.2.0
 	github.com/pelletier/go-toml v1.8.1 // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.8.0
@@ -27,15 +28,15 @@ require (
 	github.com/sirupsen/logrus v1.6.0
 	github.com/smartystreets/assertions v1.0.0 // indirect
 	github.com/spf13/afero v1.4.1 // indirect
-	github.com/spf13/cast v1.3.1 // indirect
+	github.com/spf13/cast v1.3.1
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.1
-	github.com/stretchr/testify v1.6.1
-	github.com/uber/jaeger-client-go v2.25.0+incompatible
-	github.com/uber/jaeger-lib v2.4.0+incompatible // indirect
+	github.com/stretchr/testify v1.7.0
+	github.com/tetratelabs/wazero v1.2.1
 	github.com/wealdtech/eth2-signer-api v1.6.0
 	github.com/wealdtech/go-bytesutil v1.1.1
+	github.com/wealdtech/go-ecodec v1.1.1
 	github.com/wealdtech/go-eth2-types/v2 v2.5.1
 	github.com/wealdtech/go-eth2-wallet v1.14.3
 	github.com/wealdtech/go-eth2-wallet-distributed v1.1.2
@@ -47,10 +48,15 @@ require (
 	github.com/wealdtech/go-eth2-wallet-store-scratch v1.6.1
 	github.com/wealdtech/go-eth2-wallet-types/v2 v2.8.1
 	github.com/wealdtech/go-majordomo v1.0.1
-	go.uber.org/atomic v1.7.0 // indirect
+	go.opentelemetry.io/otel v0.20.0
+	go.opentelemetry.io/otel/exporters/otlp v0.20.0
+	go.opentelemetry.io/otel/sdk v0.20.0
+	go.opentelemetry.io/otel/trace v0.20.0
+	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
 	golang.org/x/net v0.0.0-20201024042810-be3efd7ff127 // indirect
+	google.golang.org/api v0.33.0
 	google.golang.org/appengine v1.6.7 // indirect
-	google.golang.org/genproto v0.0.0-20201022181438-0ff5f38871d5 // indirect
-	google.golang.org/grpc v1.33.1
+	google.golang.org/genproto v0.0.0-20201022181438-0ff5f38871d5
+	google.golang.org/grpc v1.37.0
 	gopkg.in/ini.v1 v1.62.0 // indirect
 )
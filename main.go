@@ -15,9 +15,11 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 
 	// #nosec G108
 	_ "net/http/pprof"
@@ -28,42 +30,71 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/attestantio/dirk/cmd"
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/rules"
+	chainrules "github.com/attestantio/dirk/rules/chain"
+	remoterules "github.com/attestantio/dirk/rules/remote"
 	standardrules "github.com/attestantio/dirk/rules/standard"
+	wasmrules "github.com/attestantio/dirk/rules/wasm"
+	webhookrules "github.com/attestantio/dirk/rules/webhook"
 	standardaccountmanager "github.com/attestantio/dirk/services/accountmanager/standard"
 	grpcapi "github.com/attestantio/dirk/services/api/grpc"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	restapi "github.com/attestantio/dirk/services/api/rest"
+	"github.com/attestantio/dirk/services/auditor"
+	fileauditor "github.com/attestantio/dirk/services/auditor/file"
+	"github.com/attestantio/dirk/services/backup"
+	standardbackup "github.com/attestantio/dirk/services/backup/standard"
 	"github.com/attestantio/dirk/services/checker"
 	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	"github.com/attestantio/dirk/services/consensus"
+	standardconsensus "github.com/attestantio/dirk/services/consensus/standard"
 	"github.com/attestantio/dirk/services/fetcher"
 	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
 	"github.com/attestantio/dirk/services/lister"
 	standardlister "github.com/attestantio/dirk/services/lister/standard"
 	"github.com/attestantio/dirk/services/locker"
+	redislocker "github.com/attestantio/dirk/services/locker/redis"
 	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
 	"github.com/attestantio/dirk/services/metrics"
 	prometheusmetrics "github.com/attestantio/dirk/services/metrics/prometheus"
 	"github.com/attestantio/dirk/services/peers"
+	dnspeers "github.com/attestantio/dirk/services/peers/dns"
 	staticpeers "github.com/attestantio/dirk/services/peers/static"
 	standardprocess "github.com/attestantio/dirk/services/process/standard"
+	"github.com/attestantio/dirk/services/revocationchecker"
+	standardrevocationchecker "github.com/attestantio/dirk/services/revocationchecker/standard"
 	"github.com/attestantio/dirk/services/ruler"
 	goruler "github.com/attestantio/dirk/services/ruler/golang"
+	remoteruler "github.com/attestantio/dirk/services/ruler/remote"
+	"github.com/attestantio/dirk/services/sender"
 	sendergrpc "github.com/attestantio/dirk/services/sender/grpc"
 	standardsigner "github.com/attestantio/dirk/services/signer/standard"
+	"github.com/attestantio/dirk/services/signingbackend"
+	localsigningbackend "github.com/attestantio/dirk/services/signingbackend/local"
+	pkcs11signingbackend "github.com/attestantio/dirk/services/signingbackend/pkcs11"
+	"github.com/attestantio/dirk/services/tokenverifier"
+	oidctokenverifier "github.com/attestantio/dirk/services/tokenverifier/oidc"
 	"github.com/attestantio/dirk/services/unlocker"
 	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	vaultunlocker "github.com/attestantio/dirk/services/unlocker/vault"
 	standardwalletmanager "github.com/attestantio/dirk/services/walletmanager/standard"
+	awskmsconfidant "github.com/attestantio/dirk/util/confidants/awskms"
+	gcpkmsconfidant "github.com/attestantio/dirk/util/confidants/gcpkms"
 	"github.com/attestantio/dirk/util/loggers"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/mitchellh/go-homedir"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
-	jaegerconfig "github.com/uber/jaeger-client-go/config"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 	majordomo "github.com/wealdtech/go-majordomo"
@@ -71,6 +102,14 @@ import (
 	fileconfidant "github.com/wealdtech/go-majordomo/confidants/file"
 	gsmconfidant "github.com/wealdtech/go-majordomo/confidants/gsm"
 	standardmajordomo "github.com/wealdtech/go-majordomo/standard"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlphttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
 )
 
 // ReleaseVersion is the release version for the code.
@@ -105,13 +144,17 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to initialise profiling")
 	}
 
-	closer, err := initTracing()
+	shutdownTracing, err := initTracing(ctx)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to initialise tracing")
 		return
 	}
-	if closer != nil {
-		defer closer.Close()
+	if shutdownTracing != nil {
+		defer func() {
+			if err := shutdownTracing(context.Background()); err != nil {
+				log.Warn().Err(err).Msg("Failed to flush traces")
+			}
+		}()
 	}
 
 	runtime.GOMAXPROCS(runtime.NumCPU() * 8)
@@ -135,28 +178,85 @@ func main() {
 	}
 	readyMonitor.Ready(false)
 
-	err = startServices(ctx, majordomo, monitor)
+	api, restAPI, rulesSvc, checkerSvc, backupSvc, err := startServices(ctx, majordomo, monitor)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to initialise services")
 		return
 	}
-	readyMonitor.Ready(true)
 
-	log.Info().Msg("All services operational")
+	if viper.GetBool("certificates.watch") {
+		if err := watchCertificates(ctx, majordomo, api, restAPI); err != nil {
+			log.Error().Err(err).Msg("Failed to watch certificates")
+			return
+		}
+	}
+
+	// Readiness waits for verification of the slashing protection store, which runs in the background.
+	go func() {
+		if standardRules, isStandard := rulesSvc.(*standardrules.Service); isStandard {
+			<-standardRules.IntegrityChecked()
+		}
+		readyMonitor.Ready(true)
+		log.Info().Msg("All services operational")
+	}()
 
 	// Wait for signal.
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt, syscall.SIGHUP, syscall.SIGUSR1)
 	for {
 		sig := <-sigCh
+		if sig == syscall.SIGHUP {
+			reloadCertificates(ctx, majordomo, api, restAPI)
+			reloadRulesPolicy(ctx, rulesSvc)
+			reloadPermissions(ctx, checkerSvc)
+			continue
+		}
+		if sig == syscall.SIGUSR1 {
+			backupSlashingProtection(ctx, rulesSvc)
+			continue
+		}
 		if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill {
-			cancel()
 			break
 		}
 	}
 
 	log.Info().Msg("Stopping dirk")
 	readyMonitor.Ready(false)
+	shutdown(ctx, api, restAPI, backupSvc, rulesSvc)
+	cancel()
+}
+
+// shutdown drains in-flight requests before closing the slashing protection store, so that a
+// request holding an account lock is not interrupted part way through updating the store.
+func shutdown(ctx context.Context, api *grpcapi.Service, restAPI *restapi.Service, backupSvc *standardbackup.Service, rulesSvc rules.Service) {
+	// The APIs drain concurrently so that both complete within the same grace period.  An error
+	// draining is logged by the API service; the store is closed regardless.
+	gracePeriod := viper.GetDuration("server.shutdown-grace-period")
+	var wg sync.WaitGroup
+	if restAPI != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = restAPI.Drain(ctx, gracePeriod)
+		}()
+	}
+	_ = api.Drain(ctx, gracePeriod)
+	wg.Wait()
+
+	// Scheduled backups read the store, so are stopped before it is closed.
+	if backupSvc != nil {
+		if err := backupSvc.Close(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to close backup service")
+		}
+	}
+
+	if closer, isCloser := rulesSvc.(interface {
+		Close(ctx context.Context) error
+	}); isCloser {
+		if err := closer.Close(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to close rules")
+		}
+	}
 }
 
 // fetchConfig fetches configuration from various sources.
@@ -166,13 +266,18 @@ func fetchConfig() error {
 	pflag.String("log-file", "", "redirect log output to a file")
 	pflag.String("profile-address", "", "Address on which to run Go profile server")
 	pflag.String("tracing-address", "", "Address to which to send tracing data")
+	pflag.String("tracing-protocol", "grpc", "Protocol with which to send tracing data (grpc or http)")
+	pflag.Bool("tracing-insecure", false, "Send tracing data without TLS")
+	pflag.Float64("tracing-sampling-ratio", 1, "Ratio of traces to sample, between 0 and 1")
 	pflag.Bool("show-certificates", false, "show server certificates and exit")
 	pflag.Bool("show-permissions", false, "show client permissions and exit")
 	pflag.Bool("version", false, "show Dirk version exit")
 	pflag.Bool("export-slashing-protection", false, "export slashing protection data and exit")
 	pflag.Bool("import-slashing-protection", false, "import slashing protection data and exit")
 	pflag.String("genesis-validators-root", "", "genesis validators root required for slashing protection import or export")
-	pflag.String("slashing-protection-file", "", "location of slashing protection file for import or export")
+	pflag.Bool("restore-slashing-protection", false, "restore slashing protection data from a backup and exit")
+	pflag.String("slashing-protection-file", "", "location of slashing protection file for import, export or restore")
+	pflag.String("instance", "", "address of a running instance against which to run commands, rather than the local stores")
 	pflag.Parse()
 	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
 		return errors.Wrap(err, "failed to bind pflags to viper")
@@ -199,6 +304,46 @@ func fetchConfig() error {
 
 	// Defaults.
 	viper.Set("server.storage-path", "storage")
+	viper.SetDefault("audit.max-backups", 10)
+	viper.SetDefault("peer-discovery.refresh-interval", time.Minute)
+	viper.SetDefault("server.health-check-interval", 10*time.Second)
+	viper.SetDefault("process.prepare-timeout", 2*time.Second)
+	viper.SetDefault("process.execute-timeout", 5*time.Second)
+	viper.SetDefault("process.commit-timeout", 3*time.Second)
+	viper.SetDefault("process.max-attempts", 1)
+	viper.SetDefault("process.retry-backoff", time.Second)
+	viper.SetDefault("process.checkpoint-lifetime", time.Hour)
+	viper.SetDefault("server.rules.slot-duration", 12*time.Second)
+	viper.SetDefault("server.rules.slots-per-epoch", 32)
+	viper.SetDefault("server.rules.store-max-attempts", 3)
+	viper.SetDefault("server.rules.store-retry-backoff", 50*time.Millisecond)
+	viper.SetDefault("server.rules.store-breaker.cooldown", 30*time.Second)
+	viper.SetDefault("server.rules.voluntary-exit-approval-timeout", 5*time.Minute)
+	viper.SetDefault("server.rules.prune.finality-lag", 2)
+	viper.SetDefault("server.rules.remote.timeout", time.Second)
+	viper.SetDefault("server.rules.webhook.timeout", time.Minute)
+	viper.SetDefault("server.rules.wasm.timeout", time.Second)
+	viper.SetDefault("server.rules.wasm.memory-limit", 64*1024*1024)
+	viper.SetDefault("server.rules.webhook.actions", []string{"SignVoluntaryExit", "CreateAccount", "UnlockWallet"})
+	viper.SetDefault("server.ruler.remote.timeout", time.Second)
+	viper.SetDefault("peer-consensus.storage-path", "consensus")
+	viper.SetDefault("metrics.max-client-labels", 100)
+	viper.SetDefault("metrics.client-label-overflow", "other")
+	viper.SetDefault("peer-consensus.timeout", 2*time.Second)
+	viper.SetDefault("server.shutdown-grace-period", 10*time.Second)
+	viper.SetDefault("signing-backend.type", "local")
+	viper.SetDefault("server.max-batch-size", 4096)
+	viper.SetDefault("locker.redis.key-prefix", "dirk:lock:")
+	viper.SetDefault("locker.redis.ttl", 30*time.Second)
+	viper.SetDefault("locker.redis.timeout", time.Second)
+	viper.SetDefault("unlocker.vault.approle.mount", "approle")
+	viper.SetDefault("unlocker.vault.timeout", 5*time.Second)
+	viper.SetDefault("unlocker.vault.cache-duration", time.Minute)
+	viper.SetDefault("server.token-authentication.identity-claim", "sub")
+	viper.SetDefault("server.token-authentication.timeout", 5*time.Second)
+	viper.SetDefault("server.token-authentication.require-client-cert", true)
+	viper.SetDefault("server.revocation.refresh-interval", time.Hour)
+	viper.SetDefault("server.revocation.timeout", 5*time.Second)
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -229,32 +374,53 @@ func initProfiling() error {
 	return nil
 }
 
-// initTracing initialises the tracing.
-func initTracing() (io.Closer, error) {
+// initTracing initialises the tracing, returning a function to flush and shut down the tracer.
+func initTracing(ctx context.Context) (func(context.Context) error, error) {
 	tracingAddress := viper.GetString("tracing-address")
 	if tracingAddress == "" {
 		return nil, nil
 	}
-	cfg := &jaegerconfig.Configuration{
-		ServiceName: "dirk",
-		Sampler: &jaegerconfig.SamplerConfig{
-			Type:  "const",
-			Param: 1,
-		},
-		Reporter: &jaegerconfig.ReporterConfig{
-			LogSpans:           true,
-			LocalAgentHostPort: tracingAddress,
-		},
-	}
-	tracer, closer, err := cfg.NewTracer(jaegerconfig.Logger(loggers.NewJaegerLogger(log)))
-	if err != nil {
-		return nil, err
+
+	samplingRatio := viper.GetFloat64("tracing-sampling-ratio")
+	if samplingRatio < 0 || samplingRatio > 1 {
+		return nil, errors.New("tracing sampling ratio must be between 0 and 1")
 	}
-	if tracer != nil {
-		opentracing.SetGlobalTracer(tracer)
+
+	var driver otlp.ProtocolDriver
+	switch viper.GetString("tracing-protocol") {
+	case "grpc":
+		opts := []otlpgrpc.Option{otlpgrpc.WithEndpoint(tracingAddress)}
+		if viper.GetBool("tracing-insecure") {
+			opts = append(opts, otlpgrpc.WithInsecure())
+		}
+		driver = otlpgrpc.NewDriver(opts...)
+	case "http":
+		opts := []otlphttp.Option{otlphttp.WithEndpoint(tracingAddress)}
+		if viper.GetBool("tracing-insecure") {
+			opts = append(opts, otlphttp.WithInsecure())
+		}
+		driver = otlphttp.NewDriver(opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing protocol %q", viper.GetString("tracing-protocol"))
 	}
 
-	return closer, nil
+	otel.SetErrorHandler(loggers.NewOTelErrorHandler(log.With().Str("service", "tracing").Logger()))
+	exporter, err := otlp.NewExporter(ctx, driver)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create tracing exporter")
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(samplingRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.ServiceNameKey.String("dirk"),
+			semconv.ServiceVersionKey.String(ReleaseVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
 }
 
 func runCommands(ctx context.Context, majordomo majordomo.Service) {
@@ -272,19 +438,7 @@ func runCommands(ctx context.Context, majordomo majordomo.Service) {
 	}
 
 	if viper.GetBool("show-permissions") {
-		permissionsCfg := viper.GetStringMap("permissions")
-		permissions := make(map[string][]*checker.Permissions)
-		for client := range permissionsCfg {
-			perms := viper.GetStringMapStringSlice(fmt.Sprintf("permissions.%s", client))
-			permissions[client] = make([]*checker.Permissions, 0, len(perms))
-			for path, operations := range perms {
-				permissions[client] = append(permissions[client], &checker.Permissions{
-					Path:       path,
-					Operations: operations,
-				})
-			}
-		}
-		checker.DumpPermissions(permissions)
+		checker.DumpPermissions(configuredPermissions(viper.GetViper()))
 		os.Exit(0)
 	}
 
@@ -295,6 +449,14 @@ func runCommands(ctx context.Context, majordomo majordomo.Service) {
 	if viper.GetBool("import-slashing-protection") {
 		importSlashingProtection(ctx)
 	}
+
+	if viper.GetBool("restore-slashing-protection") {
+		restoreSlashingProtection(ctx)
+	}
+
+	if len(pflag.Args()) > 0 {
+		runSubcommand(ctx, majordomo, pflag.Args())
+	}
 }
 
 func setBuildVersion(ctx context.Context, monitor metrics.Service) {
@@ -303,88 +465,54 @@ func setBuildVersion(ctx context.Context, monitor metrics.Service) {
 	}
 }
 
-func startServices(ctx context.Context, majordomo majordomo.Service, monitor metrics.Service) error {
+func startServices(ctx context.Context, majordomo majordomo.Service, monitor metrics.Service) (*grpcapi.Service, *restapi.Service, rules.Service, checker.Service, *standardbackup.Service, error) {
 	var err error
 
 	stores, err := initStores(ctx)
 	if err != nil {
-		return err
+		return nil, nil, nil, nil, nil, err
 	}
 
 	unlocker, err := startUnlocker(ctx, majordomo, monitor)
 	if err != nil {
-		return errors.Wrap(err, "failed to initialise local unlocker")
-	}
-
-	checker, err := startChecker(ctx, monitor)
-	if err != nil {
-		return errors.Wrap(err, "failed to start permissions checker")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to initialise local unlocker")
 	}
 
 	// Set up the fetcher.
-	fetcher, err := startFetcher(ctx, stores, monitor)
-	if err != nil {
-		return errors.Wrap(err, "failed to initialise account fetcher")
-	}
-
-	// Set up the locker.
-	locker, err := startLocker(ctx, monitor)
+	fetcher, err := startFetcher(ctx, stores, unlocker, monitor)
 	if err != nil {
-		return errors.Wrap(err, "failed to set up locker service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to initialise account fetcher")
 	}
 
-	// Set up the ruler.
-	ruler, err := startRuler(ctx, locker, monitor)
+	checker, err := startChecker(ctx, monitor, fetcher)
 	if err != nil {
-		return errors.Wrap(err, "failed to set up ruler service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to start permissions checker")
 	}
 
-	// Set up the lister.
-	lister, err := startLister(ctx, monitor, fetcher, checker, ruler)
+	// Set up the locker.
+	locker, err := startLocker(ctx, majordomo, monitor)
 	if err != nil {
-		return errors.Wrap(err, "failed to initialise lister")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up locker service")
 	}
 
-	// Set up the signer.
-	var signerMonitor metrics.SignerMonitor
-	if monitor, isMonitor := monitor.(metrics.SignerMonitor); isMonitor {
-		signerMonitor = monitor
-	}
-	signer, err := standardsigner.New(ctx,
-		standardsigner.WithLogLevel(logLevel(viper.GetString("log-levels.signer"))),
-		standardsigner.WithMonitor(signerMonitor),
-		standardsigner.WithUnlocker(unlocker),
-		standardsigner.WithChecker(checker),
-		standardsigner.WithFetcher(fetcher),
-		standardsigner.WithRuler(ruler),
-	)
+	// Set up the auditor.
+	auditor, err := startAuditor(ctx, monitor)
 	if err != nil {
-		return errors.Wrap(err, "failed to create signer service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up auditor service")
 	}
 
 	peers, err := startPeers(ctx, monitor)
 	if err != nil {
-		return errors.Wrap(err, "failed to start peers service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to start peers service")
 	}
 
 	var senderMonitor metrics.SenderMonitor
 	if monitor, isMonitor := monitor.(metrics.SenderMonitor); isMonitor {
 		senderMonitor = monitor
 	}
-	certPEMBlock, err := majordomo.Fetch(ctx, viper.GetString("certificates.server-cert"))
-	if err != nil {
-		return errors.Wrap(err, "failed to obtain server certificate")
-	}
-	keyPEMBlock, err := majordomo.Fetch(ctx, viper.GetString("certificates.server-key"))
+	certPEMBlock, keyPEMBlock, caPEMBlock, err := fetchCertificates(ctx, majordomo)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain server key")
-	}
-	var caPEMBlock []byte
-	if viper.GetString("certificates.ca-cert") != "" {
-		caPEMBlock, err = majordomo.Fetch(ctx, viper.GetString("certificates.ca-cert"))
-		if err != nil {
-			return errors.Wrap(err, "failed to obtain client CA certificate")
-		}
+		return nil, nil, nil, nil, nil, err
 	}
 	sender, err := sendergrpc.New(ctx,
 		sendergrpc.WithLogLevel(logLevel(viper.GetString("log-levels.sender"))),
@@ -395,12 +523,67 @@ func startServices(ctx context.Context, majordomo majordomo.Service, monitor met
 		sendergrpc.WithCACert(caPEMBlock),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create sender service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create sender service")
 	}
 
 	serverID, err := strconv.ParseUint(viper.GetString("server.id"), 10, 64)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain server ID")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to obtain server ID")
+	}
+
+	consensus, err := startConsensus(ctx, monitor, peers, sender, serverID)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up consensus service")
+	}
+
+	// Set up the ruler.
+	rulesSvc, err := initRules(ctx, monitor, locker)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up rules")
+	}
+
+	rulerRules, err := initRulesChain(ctx, majordomo, rulesSvc, certPEMBlock, keyPEMBlock, caPEMBlock)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up rules chain")
+	}
+
+	ruler, err := startRuler(ctx, locker, auditor, rulerRules, consensus, checker, monitor)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up ruler service")
+	}
+	ruler, err = initRemoteRuler(ctx, ruler, certPEMBlock, keyPEMBlock, caPEMBlock)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up remote ruler")
+	}
+
+	// Set up the lister.
+	lister, err := startLister(ctx, monitor, fetcher, checker, ruler)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to initialise lister")
+	}
+
+	// Set up the signer.
+	var signerMonitor metrics.SignerMonitor
+	if monitor, isMonitor := monitor.(metrics.SignerMonitor); isMonitor {
+		signerMonitor = monitor
+	}
+	signingBackend, err := initSigningBackend(ctx, majordomo)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to set up signing backend")
+	}
+	signer, err := standardsigner.New(ctx,
+		standardsigner.WithLogLevel(logLevel(viper.GetString("log-levels.signer"))),
+		standardsigner.WithMonitor(signerMonitor),
+		standardsigner.WithUnlocker(unlocker),
+		standardsigner.WithChecker(checker),
+		standardsigner.WithFetcher(fetcher),
+		standardsigner.WithRuler(ruler),
+		standardsigner.WithSigningBackend(signingBackend),
+		standardsigner.WithGenericDelegation(viper.GetBool("server.rules.delegate-generic")),
+		standardsigner.WithAuditor(auditor),
+	)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create signer service")
 	}
 
 	endpoints := make(map[uint64]string)
@@ -421,22 +604,36 @@ func startServices(ctx context.Context, majordomo majordomo.Service, monitor met
 	if viper.GetString("process.generation-passphrase") != "" {
 		generationPassphrase, err = majordomo.Fetch(ctx, viper.GetString("process.generation-passphrase"))
 		if err != nil {
-			return errors.Wrap(err, "failed to obtain account generation passphrase for process")
+			return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to obtain account generation passphrase for process")
 		}
 	}
+	var checkpointPath string
+	if viper.GetString("process.checkpoint-path") != "" {
+		checkpointPath = resolvePath(viper.GetString("process.checkpoint-path"))
+	}
 	process, err := standardprocess.New(ctx,
 		standardprocess.WithLogLevel(logLevel(viper.GetString("log-levels.process"))),
 		standardprocess.WithMonitor(processMonitor),
 		standardprocess.WithChecker(checker),
+		standardprocess.WithRuler(ruler),
 		standardprocess.WithUnlocker(unlocker),
+		standardprocess.WithFetcher(fetcher),
 		standardprocess.WithSender(sender),
 		standardprocess.WithPeers(peers),
 		standardprocess.WithID(serverID),
 		standardprocess.WithStores(stores),
 		standardprocess.WithGenerationPassphrase(generationPassphrase),
+		standardprocess.WithRefreshInterval(viper.GetDuration("process.refresh-interval")),
+		standardprocess.WithPrepareTimeout(viper.GetDuration("process.prepare-timeout")),
+		standardprocess.WithExecuteTimeout(viper.GetDuration("process.execute-timeout")),
+		standardprocess.WithCommitTimeout(viper.GetDuration("process.commit-timeout")),
+		standardprocess.WithMaxAttempts(viper.GetInt("process.max-attempts")),
+		standardprocess.WithRetryBackoff(viper.GetDuration("process.retry-backoff")),
+		standardprocess.WithCheckpointPath(checkpointPath),
+		standardprocess.WithCheckpointLifetime(viper.GetDuration("process.checkpoint-lifetime")),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create process service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create process service")
 	}
 
 	var accountManagerMonitor metrics.AccountManagerMonitor
@@ -453,7 +650,7 @@ func startServices(ctx context.Context, majordomo majordomo.Service, monitor met
 		standardaccountmanager.WithProcess(process),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create account manager service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create account manager service")
 	}
 
 	var walletManagerMonitor metrics.WalletManagerMonitor
@@ -469,7 +666,32 @@ func startServices(ctx context.Context, majordomo majordomo.Service, monitor met
 		standardwalletmanager.WithRuler(ruler),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create wallet manager service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create wallet manager service")
+	}
+
+	genesisValidatorsRoot, err := configuredGenesisValidatorsRoot("server.rules.genesis-validators-root")
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	backupSvc, err := initBackup(ctx, rulesSvc)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create backup service")
+	}
+	// The admin API checks for the presence of a backup service, so it must not be a typed nil.
+	var adminBackup backup.Service
+	if backupSvc != nil {
+		adminBackup = backupSvc
+	}
+
+	tokenVerifier, err := initTokenVerifier(ctx)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create token verifier")
+	}
+
+	revocationChecker, err := initRevocationChecker(ctx)
+	if err != nil {
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create revocation checker")
 	}
 
 	// Initialise the API service.
@@ -477,7 +699,9 @@ func startServices(ctx context.Context, majordomo majordomo.Service, monitor met
 	if monitor, isMonitor := monitor.(metrics.APIMonitor); isMonitor {
 		apiMonitor = monitor
 	}
-	_, err = grpcapi.New(ctx,
+	// The rate limiter is shared by the gRPC and REST APIs, so a client's limit applies across both.
+	rateLimiter := initRateLimiter()
+	api, err := grpcapi.New(ctx,
 		grpcapi.WithLogLevel(logLevel(viper.GetString("log-levels.api"))),
 		grpcapi.WithMonitor(apiMonitor),
 		grpcapi.WithSigner(signer),
@@ -486,18 +710,70 @@ func startServices(ctx context.Context, majordomo majordomo.Service, monitor met
 		grpcapi.WithAccountManager(accountManager),
 		grpcapi.WithWalletManager(walletManager),
 		grpcapi.WithPeers(peers),
+		grpcapi.WithConsensus(consensus),
+		grpcapi.WithLocker(locker),
+		grpcapi.WithChecker(checker),
+		grpcapi.WithRules(rulesSvc),
+		grpcapi.WithBackup(adminBackup),
+		grpcapi.WithFetcher(fetcher),
+		grpcapi.WithSender(sender),
+		grpcapi.WithHealthCheckInterval(viper.GetDuration("server.health-check-interval")),
+		grpcapi.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+		grpcapi.WithAdminClients(viper.GetStringSlice("admin.clients")),
 		grpcapi.WithName(viper.GetString("server.name")),
 		grpcapi.WithID(serverID),
 		grpcapi.WithServerCert(certPEMBlock),
 		grpcapi.WithServerKey(keyPEMBlock),
 		grpcapi.WithCACert(caPEMBlock),
+		grpcapi.WithClientIdentitySAN(viper.GetString("server.client-identity-san")),
+		grpcapi.WithTokenVerifier(tokenVerifier),
+		grpcapi.WithRevocationChecker(revocationChecker),
+		grpcapi.WithOptionalClientCert(tokenVerifier != nil && !viper.GetBool("server.token-authentication.require-client-cert")),
+		grpcapi.WithMaxBatchSize(viper.GetInt("server.max-batch-size")),
+		grpcapi.WithRateLimiter(rateLimiter),
+		grpcapi.WithStructuredErrors(viper.GetBool("server.structured-errors")),
+		grpcapi.WithInterceptorOrder(viper.GetStringSlice("server.interceptors")),
 		grpcapi.WithListenAddress(viper.GetString("server.listen-address")),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create API service")
+		return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create API service")
+	}
+
+	var restAPI *restapi.Service
+	if viper.GetString("rest.listen-address") != "" {
+		restParams := []restapi.Parameter{
+			restapi.WithLogLevel(logLevel(viper.GetString("log-levels.api"))),
+			restapi.WithMonitor(apiMonitor),
+			restapi.WithSigner(signer),
+			restapi.WithLister(lister),
+			restapi.WithFetcher(fetcher),
+			restapi.WithChecker(checker),
+			restapi.WithWallets(viper.GetStringSlice("rest.wallets")),
+			restapi.WithSlotsPerEpoch(viper.GetUint64("server.rules.slots-per-epoch")),
+			restapi.WithServerCert(certPEMBlock),
+			restapi.WithServerKey(keyPEMBlock),
+			restapi.WithCACert(caPEMBlock),
+			restapi.WithClientIdentitySAN(viper.GetString("server.client-identity-san")),
+			restapi.WithRevocationChecker(revocationChecker),
+			restapi.WithRateLimiter(rateLimiter),
+			restapi.WithListenAddress(viper.GetString("rest.listen-address")),
+		}
+		if viper.GetString("rest.keymanager.import-wallet") != "" {
+			restParams = append(restParams,
+				restapi.WithAccountManager(accountManager),
+				restapi.WithRules(rulesSvc),
+				restapi.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+				restapi.WithImportWallet(viper.GetString("rest.keymanager.import-wallet")),
+				restapi.WithAdminClients(viper.GetStringSlice("admin.clients")),
+			)
+		}
+		restAPI, err = restapi.New(ctx, restParams...)
+		if err != nil {
+			return nil, nil, nil, nil, nil, errors.Wrap(err, "failed to create REST API service")
+		}
 	}
 
-	return nil
+	return api, restAPI, rulesSvc, checker, backupSvc, nil
 }
 
 func initMajordomo(ctx context.Context) (majordomo.Service, error) {
@@ -528,6 +804,33 @@ func initMajordomo(ctx context.Context) (majordomo.Service, error) {
 		return nil, errors.Wrap(err, "failed to register file confidant")
 	}
 
+	awsKMSConfidant, err := awskmsconfidant.New(ctx,
+		awskmsconfidant.WithLogLevel(logLevel(viper.GetString("log-levels.confidants.awskms"))),
+		awskmsconfidant.WithRegion(viper.GetString("majordomo.awskms.region")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Amazon KMS confidant")
+	}
+	if err := majordomo.RegisterConfidant(ctx, awsKMSConfidant); err != nil {
+		return nil, errors.Wrap(err, "failed to register Amazon KMS confidant")
+	}
+
+	gcpKMSCredentialsPath := viper.GetString("majordomo.gcpkms.credentials")
+	if gcpKMSCredentialsPath != "" {
+		gcpKMSCredentialsPath = resolvePath(gcpKMSCredentialsPath)
+	}
+	gcpKMSConfidant, err := gcpkmsconfidant.New(ctx,
+		gcpkmsconfidant.WithLogLevel(logLevel(viper.GetString("log-levels.confidants.gcpkms"))),
+		gcpkmsconfidant.WithCredentialsPath(gcpKMSCredentialsPath),
+		gcpkmsconfidant.WithKey(viper.GetString("majordomo.gcpkms.key")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Google Cloud KMS confidant")
+	}
+	if err := majordomo.RegisterConfidant(ctx, gcpKMSConfidant); err != nil {
+		return nil, errors.Wrap(err, "failed to register Google Cloud KMS confidant")
+	}
+
 	if viper.GetString("majordomo.gsm.credentials") != "" {
 		gsmConfidant, err := gsmconfidant.New(ctx,
 			gsmconfidant.WithLogLevel(logLevel(viper.GetString("log-levels.confidants.gsm"))),
@@ -556,6 +859,8 @@ func startMonitor(ctx context.Context) (metrics.Service, error) {
 	monitor, err = prometheusmetrics.New(ctx,
 		prometheusmetrics.WithLogLevel(logLevel(viper.GetString("log-levels.metrics"))),
 		prometheusmetrics.WithAddress(viper.GetString("metrics.listen-address")),
+		prometheusmetrics.WithMaxClientLabels(viper.GetInt("metrics.max-client-labels")),
+		prometheusmetrics.WithClientLabelOverflow(viper.GetString("metrics.client-label-overflow")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start metrics service")
@@ -580,14 +885,401 @@ func logModules() {
 }
 
 // initRules initialises a rules service.
-func initRules(ctx context.Context) (rules.Service, error) {
-	return standardrules.New(ctx,
+func initRules(ctx context.Context, monitor metrics.Service, locker locker.Service) (rules.Service, error) {
+	var genesisValidatorsRoot []byte
+	var forkVersions [][]byte
+	if viper.GetString("server.rules.genesis-validators-root") != "" {
+		var err error
+		genesisValidatorsRoot, err = hex.DecodeString(strings.TrimPrefix(viper.GetString("server.rules.genesis-validators-root"), "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid genesis validators root")
+		}
+		for _, forkVersionStr := range viper.GetStringSlice("server.rules.fork-versions") {
+			forkVersion, err := hex.DecodeString(strings.TrimPrefix(forkVersionStr, "0x"))
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid fork version")
+			}
+			forkVersions = append(forkVersions, forkVersion)
+		}
+	}
+	forkSchedule, err := forkScheduleFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	var genesisForkVersion []byte
+	if viper.GetString("server.rules.genesis-fork-version") != "" {
+		genesisForkVersion, err = hex.DecodeString(strings.TrimPrefix(viper.GetString("server.rules.genesis-fork-version"), "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid genesis fork version")
+		}
+	}
+
+	var genesisTime time.Time
+	if viper.GetInt64("server.rules.genesis-time") != 0 {
+		genesisTime = time.Unix(viper.GetInt64("server.rules.genesis-time"), 0)
+	}
+
+	var rulesMonitor metrics.RulesMonitor
+	if monitor, isMonitor := monitor.(metrics.RulesMonitor); isMonitor {
+		rulesMonitor = monitor
+	}
+
+	// A failure to obtain the policy is fatal, rather than running with an empty policy.
+	policyParams, err := rulesPolicyParameters(ctx, viper.GetViper())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain rules policy")
+	}
+
+	params := []standardrules.Parameter{
 		standardrules.WithLogLevel(logLevel(viper.GetString("log-levels.rules"))),
+		standardrules.WithMonitor(rulesMonitor),
 		standardrules.WithStoragePath(resolvePath(viper.GetString("server.storage-path"))),
-		standardrules.WithAdminIPs(viper.GetStringSlice("server.rules.admin-ips")),
+		standardrules.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+		standardrules.WithForkVersions(forkVersions),
+		standardrules.WithForkSchedule(forkSchedule),
+		standardrules.WithGenesisForkVersion(genesisForkVersion),
+		standardrules.WithGenesisTime(genesisTime),
+		standardrules.WithSlotDuration(viper.GetDuration("server.rules.slot-duration")),
+		standardrules.WithSlotsPerEpoch(viper.GetUint64("server.rules.slots-per-epoch")),
+		standardrules.WithMaxFutureEpochs(viper.GetUint64("server.rules.max-future-epochs")),
+		standardrules.WithMaxFutureSlots(viper.GetUint64("server.rules.max-future-slots")),
+		standardrules.WithRANDAORevealWindow(viper.GetUint64("server.rules.randao-reveal-window")),
+		standardrules.WithAggregateSlotWindow(viper.GetUint64("server.rules.aggregate-slot-window")),
+		standardrules.WithSyncCommitteeSlotWindow(viper.GetUint64("server.rules.sync-committee-slot-window")),
+		standardrules.WithVoluntaryExitApprovalTimeout(viper.GetDuration("server.rules.voluntary-exit-approval-timeout")),
+		standardrules.WithValidatorRegistrationWindow(viper.GetDuration("server.rules.validator-registration-window")),
+		standardrules.WithMinProposalSlotGap(viper.GetUint64("server.rules.min-proposal-slot-gap")),
+		standardrules.WithMaxProposalsPerEpoch(viper.GetUint64("server.rules.max-proposals-per-epoch")),
+		standardrules.WithSigningFloorSlot(viper.GetUint64("server.rules.signing-floor-slot")),
+		standardrules.WithMinimumSourceEpoch(viper.GetUint64("server.rules.minimum-watermark.source-epoch")),
+		standardrules.WithMinimumTargetEpoch(viper.GetUint64("server.rules.minimum-watermark.target-epoch")),
+		standardrules.WithMinimumSlot(viper.GetUint64("server.rules.minimum-watermark.slot")),
+		standardrules.WithStoreMaxAttempts(viper.GetInt("server.rules.store-max-attempts")),
+		standardrules.WithStoreRetryBackoff(viper.GetDuration("server.rules.store-retry-backoff")),
+		standardrules.WithStoreBreakerThreshold(viper.GetInt("server.rules.store-breaker.threshold")),
+		standardrules.WithStoreBreakerCooldown(viper.GetDuration("server.rules.store-breaker.cooldown")),
+		standardrules.WithVerifyIntegrity(viper.GetBool("server.rules.verify-integrity")),
+		standardrules.WithStrictIntegrity(viper.GetBool("server.rules.strict-integrity")),
+	}
+	params = append(params, policyParams...)
+	if locker != nil {
+		// Scheduled pruning only runs alongside signing, which requires the locker.
+		params = append(params,
+			standardrules.WithLocker(locker),
+			standardrules.WithPruneInterval(viper.GetDuration("server.rules.prune.interval")),
+			standardrules.WithPruneFinalityLag(viper.GetUint64("server.rules.prune.finality-lag")),
+			standardrules.WithPruneRetainEpochs(viper.GetUint64("server.rules.prune.retain-epochs")),
+		)
+	}
+
+	return standardrules.New(ctx, params...)
+}
+
+// forkScheduleConfig is the configuration for a fork in the fork schedule.
+type forkScheduleConfig struct {
+	Version string `mapstructure:"version"`
+	Epoch   uint64 `mapstructure:"epoch"`
+}
+
+// forkScheduleFromConfig obtains the fork schedule from the configuration.
+func forkScheduleFromConfig() ([]*standardrules.Fork, error) {
+	configs := make([]*forkScheduleConfig, 0)
+	if err := viper.UnmarshalKey("server.rules.fork-schedule", &configs); err != nil {
+		return nil, errors.Wrap(err, "invalid fork schedule")
+	}
+	forks := make([]*standardrules.Fork, len(configs))
+	for i := range configs {
+		version, err := hex.DecodeString(strings.TrimPrefix(configs[i].Version, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid fork schedule version")
+		}
+		forks[i] = &standardrules.Fork{
+			Version: version,
+			Epoch:   configs[i].Epoch,
+		}
+	}
+	return forks, nil
+}
+
+// initRulesChain composes the rules run by the ruler from server.rules.chain.  If this is not present
+// the rules are the standard rules, wrapped with a remote rule evaluator if configured.
+func initRulesChain(ctx context.Context,
+	majordomo majordomo.Service,
+	rulesSvc rules.Service,
+	certPEMBlock []byte,
+	keyPEMBlock []byte,
+	caPEMBlock []byte,
+) (
+	rules.Service,
+	error,
+) {
+	names := viper.GetStringSlice("server.rules.chain")
+	if len(names) == 0 {
+		return initRemoteRules(ctx, rulesSvc, certPEMBlock, keyPEMBlock, caPEMBlock)
+	}
+
+	// The chain is built from the end, as a remote rule evaluator runs the rules that follow it.
+	links := make([]rules.Service, 0, len(names))
+	standardLinks := 0
+	for i := len(names) - 1; i >= 0; i-- {
+		switch names[i] {
+		case "standard":
+			standardLinks++
+			links = append([]rules.Service{rulesSvc}, links...)
+		case "remote":
+			if viper.GetString("server.rules.remote.address") == "" {
+				return nil, errors.New("remote rules in chain but no remote address specified")
+			}
+			following, err := chainrules.New(ctx,
+				chainrules.WithLogLevel(logLevel(viper.GetString("log-levels.rules"))),
+				chainrules.WithRules(links),
+				chainrules.WithSlashingProtection(rulesSvc),
+			)
+			if err != nil {
+				return nil, err
+			}
+			remoteRules, err := initRemoteRules(ctx, following, certPEMBlock, keyPEMBlock, caPEMBlock)
+			if err != nil {
+				return nil, err
+			}
+			links = []rules.Service{remoteRules}
+		case "webhook":
+			webhookRules, err := initWebhookRules(ctx, majordomo)
+			if err != nil {
+				return nil, err
+			}
+			links = append([]rules.Service{webhookRules}, links...)
+		case "wasm":
+			wasmRules, err := initWasmRules(ctx)
+			if err != nil {
+				return nil, err
+			}
+			links = append([]rules.Service{wasmRules}, links...)
+		default:
+			return nil, fmt.Errorf("unknown rules %q in chain", names[i])
+		}
+	}
+	if standardLinks != 1 {
+		return nil, errors.New("rules chain must contain the standard rules exactly once")
+	}
+
+	return chainrules.New(ctx,
+		chainrules.WithLogLevel(logLevel(viper.GetString("log-levels.rules"))),
+		chainrules.WithRules(links),
+		chainrules.WithSlashingProtection(rulesSvc),
+	)
+}
+
+// initWebhookRules initialises rules that require approval from a webhook for selected actions.
+func initWebhookRules(ctx context.Context, majordomo majordomo.Service) (rules.Service, error) {
+	if viper.GetString("server.rules.webhook.url") == "" {
+		return nil, errors.New("webhook rules in chain but no webhook URL specified")
+	}
+
+	// Secrets are fetched through majordomo, so need not be held in the configuration file.
+	secrets := make(map[string][]byte)
+	for _, key := range []string{"server.rules.webhook.token", "server.rules.webhook.ca-cert"} {
+		if viper.GetString(key) == "" {
+			continue
+		}
+		value, err := majordomo.Fetch(ctx, viper.GetString(key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain %s", key)
+		}
+		secrets[key] = value
+	}
+
+	return webhookrules.New(ctx,
+		webhookrules.WithLogLevel(logLevel(viper.GetString("log-levels.rules"))),
+		webhookrules.WithURL(viper.GetString("server.rules.webhook.url")),
+		webhookrules.WithTimeout(viper.GetDuration("server.rules.webhook.timeout")),
+		webhookrules.WithActions(viper.GetStringSlice("server.rules.webhook.actions")),
+		webhookrules.WithToken(strings.TrimSpace(string(secrets["server.rules.webhook.token"]))),
+		webhookrules.WithCACert(secrets["server.rules.webhook.ca-cert"]),
+	)
+}
+
+// initWasmRules initialises rules provided by a WebAssembly module.
+func initWasmRules(ctx context.Context) (rules.Service, error) {
+	if viper.GetString("server.rules.wasm.module") == "" {
+		return nil, errors.New("WebAssembly rules in chain but no module specified")
+	}
+	module, err := ioutil.ReadFile(resolvePath(viper.GetString("server.rules.wasm.module")))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read WebAssembly rules module")
+	}
+
+	return wasmrules.New(ctx,
+		wasmrules.WithLogLevel(logLevel(viper.GetString("log-levels.rules"))),
+		wasmrules.WithModule(module),
+		wasmrules.WithTimeout(viper.GetDuration("server.rules.wasm.timeout")),
+		wasmrules.WithMemoryLimit(viper.GetUint32("server.rules.wasm.memory-limit")),
+	)
+}
+
+// initRemoteRules wraps the rules with a remote rule evaluator, if configured.
+func initRemoteRules(ctx context.Context, rulesSvc rules.Service, certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) (rules.Service, error) {
+	if viper.GetString("server.rules.remote.address") == "" {
+		return rulesSvc, nil
+	}
+
+	return remoterules.New(ctx,
+		remoterules.WithLogLevel(logLevel(viper.GetString("log-levels.rules"))),
+		remoterules.WithAddress(viper.GetString("server.rules.remote.address")),
+		remoterules.WithTimeout(viper.GetDuration("server.rules.remote.timeout")),
+		remoterules.WithRules(rulesSvc),
+		remoterules.WithClientCert(certPEMBlock),
+		remoterules.WithClientKey(keyPEMBlock),
+		remoterules.WithCACert(caPEMBlock),
+	)
+}
+
+// initRemoteRuler wraps the ruler with a remote policy service, if configured.
+func initRemoteRuler(ctx context.Context, rulerSvc ruler.Service, certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) (ruler.Service, error) {
+	if viper.GetString("server.ruler.remote.address") == "" {
+		return rulerSvc, nil
+	}
+
+	return remoteruler.New(ctx,
+		remoteruler.WithLogLevel(logLevel(viper.GetString("log-levels.ruler"))),
+		remoteruler.WithAddress(viper.GetString("server.ruler.remote.address")),
+		remoteruler.WithTimeout(viper.GetDuration("server.ruler.remote.timeout")),
+		remoteruler.WithFailOpen(viper.GetBool("server.ruler.remote.fail-open")),
+		remoteruler.WithRuler(rulerSvc),
+		remoteruler.WithClientCert(certPEMBlock),
+		remoteruler.WithClientKey(keyPEMBlock),
+		remoteruler.WithCACert(caPEMBlock),
+	)
+}
+
+// initBackup creates the service that backs up slashing protection, if configured.
+func initBackup(ctx context.Context, rulesSvc rules.Service) (*standardbackup.Service, error) {
+	location := viper.GetString("server.slashing-protection-backups.location")
+	if location == "" {
+		return nil, nil
+	}
+
+	var destination backup.Destination
+	if strings.HasPrefix(location, "s3://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid S3 URL")
+		}
+		if u.Host == "" {
+			return nil, errors.New("no bucket in S3 URL")
+		}
+		cfg := aws.NewConfig()
+		if region := viper.GetString("server.slashing-protection-backups.region"); region != "" {
+			cfg = cfg.WithRegion(region)
+		}
+		if endpoint := viper.GetString("server.slashing-protection-backups.endpoint"); endpoint != "" {
+			// S3-compatible store.
+			cfg = cfg.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+		}
+		sess, err := session.NewSessionWithOptions(session.Options{
+			Config:            *cfg,
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create AWS session")
+		}
+		destination, err = standardbackup.NewS3Destination(s3.New(sess), u.Host, u.Path)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		destination, err = standardbackup.NewFileDestination(resolvePath(location))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return standardbackup.New(ctx,
+		standardbackup.WithLogLevel(logLevel(viper.GetString("log-levels.backup"))),
+		standardbackup.WithRules(rulesSvc),
+		standardbackup.WithDestination(destination),
+		standardbackup.WithInterval(viper.GetDuration("server.slashing-protection-backups.interval")),
+		standardbackup.WithRetain(viper.GetInt("server.slashing-protection-backups.retain")),
+	)
+}
+
+// initTokenVerifier creates the verifier for client bearer tokens, if configured.
+func initTokenVerifier(ctx context.Context) (tokenverifier.Service, error) {
+	if viper.GetString("server.token-authentication.issuer") == "" {
+		return nil, nil
+	}
+
+	return oidctokenverifier.New(ctx,
+		oidctokenverifier.WithLogLevel(logLevel(viper.GetString("log-levels.tokenverifier"))),
+		oidctokenverifier.WithIssuer(viper.GetString("server.token-authentication.issuer")),
+		oidctokenverifier.WithAudience(viper.GetString("server.token-authentication.audience")),
+		oidctokenverifier.WithIdentityClaim(viper.GetString("server.token-authentication.identity-claim")),
+		oidctokenverifier.WithJWKSURL(viper.GetString("server.token-authentication.jwks-url")),
+		oidctokenverifier.WithTimeout(viper.GetDuration("server.token-authentication.timeout")),
+	)
+}
+
+// initRevocationChecker creates the checker for revocation of client certificates, if configured.
+func initRevocationChecker(ctx context.Context) (revocationchecker.Service, error) {
+	if len(viper.GetStringSlice("server.revocation.crls")) == 0 && !viper.GetBool("server.revocation.ocsp") {
+		return nil, nil
+	}
+
+	return standardrevocationchecker.New(ctx,
+		standardrevocationchecker.WithLogLevel(logLevel(viper.GetString("log-levels.revocationchecker"))),
+		standardrevocationchecker.WithCRLs(viper.GetStringSlice("server.revocation.crls")),
+		standardrevocationchecker.WithRefreshInterval(viper.GetDuration("server.revocation.refresh-interval")),
+		standardrevocationchecker.WithOCSP(viper.GetBool("server.revocation.ocsp")),
+		standardrevocationchecker.WithOCSPSoftFail(viper.GetBool("server.revocation.ocsp-soft-fail")),
+		standardrevocationchecker.WithTimeout(viper.GetDuration("server.revocation.timeout")),
 	)
 }
 
+// initRateLimiter creates the per-client request rate limiter, if configured.
+func initRateLimiter() *interceptors.RateLimiter {
+	if !viper.IsSet("server.rate-limit") {
+		return nil
+	}
+
+	overrides := make(map[string]*interceptors.RateLimit)
+	for client := range viper.GetStringMap("server.rate-limit.clients") {
+		overrides[client] = &interceptors.RateLimit{
+			RequestsPerSecond: viper.GetFloat64(fmt.Sprintf("server.rate-limit.clients.%s.requests-per-second", client)),
+			Burst:             viper.GetInt(fmt.Sprintf("server.rate-limit.clients.%s.burst", client)),
+		}
+	}
+
+	return interceptors.NewRateLimiter(&interceptors.RateLimit{
+		RequestsPerSecond: viper.GetFloat64("server.rate-limit.requests-per-second"),
+		Burst:             viper.GetInt("server.rate-limit.burst"),
+	}, overrides)
+}
+
+// initSigningBackend creates the backend that generates signatures once the rules have approved a request.
+func initSigningBackend(ctx context.Context, majordomo majordomo.Service) (signingbackend.Service, error) {
+	switch viper.GetString("signing-backend.type") {
+	case "local":
+		return localsigningbackend.New(ctx,
+			localsigningbackend.WithLogLevel(logLevel(viper.GetString("log-levels.signingbackend"))),
+		)
+	case "pkcs11":
+		pin, err := majordomo.Fetch(ctx, viper.GetString("signing-backend.pkcs11.pin"))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain PKCS#11 PIN")
+		}
+		return pkcs11signingbackend.New(ctx,
+			pkcs11signingbackend.WithLogLevel(logLevel(viper.GetString("log-levels.signingbackend"))),
+			pkcs11signingbackend.WithLibrary(viper.GetString("signing-backend.pkcs11.library")),
+			pkcs11signingbackend.WithTokenLabel(viper.GetString("signing-backend.pkcs11.token-label")),
+			pkcs11signingbackend.WithPIN(strings.TrimSpace(string(pin))),
+			pkcs11signingbackend.WithMechanism(viper.GetUint("signing-backend.pkcs11.mechanism")),
+		)
+	default:
+		return nil, fmt.Errorf("unsupported signing backend %q", viper.GetString("signing-backend.type"))
+	}
+}
+
 func initStores(ctx context.Context) ([]e2wtypes.Store, error) {
 	storesCfg := &core.Stores{}
 	if err := viper.Unmarshal(&storesCfg); err != nil {
@@ -625,6 +1317,9 @@ func startUnlocker(ctx context.Context, majordomo majordomo.Service, monitor met
 	if monitor, isMonitor := monitor.(metrics.UnlockerMonitor); isMonitor {
 		unlockerMonitor = monitor
 	}
+	if viper.GetString("unlocker.vault.address") != "" {
+		return startVaultUnlocker(ctx, majordomo, unlockerMonitor, walletPassphrases, accountPassphrases)
+	}
 	return localunlocker.New(ctx,
 		localunlocker.WithLogLevel(logLevel(viper.GetString("log-levels.unlocker"))),
 		localunlocker.WithMonitor(unlockerMonitor),
@@ -633,59 +1328,176 @@ func startUnlocker(ctx context.Context, majordomo majordomo.Service, monitor met
 	)
 }
 
-func startChecker(ctx context.Context, monitor metrics.Service) (checker.Service, error) {
-	// Set up the checker.
-	permissionsCfg := viper.GetStringMap("permissions")
-	permissions := make(map[string][]*checker.Permissions)
-	for client := range permissionsCfg {
-		perms := viper.GetStringMapStringSlice(fmt.Sprintf("permissions.%s", client))
-		permissions[client] = make([]*checker.Permissions, 0, len(perms))
-		for path, operations := range perms {
-			permissions[client] = append(permissions[client], &checker.Permissions{
-				Path:       path,
-				Operations: operations,
-			})
+// startVaultUnlocker starts an unlocker that fetches passphrases from Vault, in addition to those in the configuration.
+func startVaultUnlocker(ctx context.Context,
+	majordomo majordomo.Service,
+	monitor metrics.UnlockerMonitor,
+	walletPassphrases []string,
+	accountPassphrases []string,
+) (
+	unlocker.Service,
+	error,
+) {
+	// Secrets are fetched through majordomo, so need not be held in the configuration file.
+	secrets := make(map[string][]byte)
+	for _, key := range []string{"unlocker.vault.token", "unlocker.vault.approle.secret-id", "unlocker.vault.ca-cert"} {
+		if viper.GetString(key) == "" {
+			continue
 		}
-	}
+		value, err := majordomo.Fetch(ctx, viper.GetString(key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain %s", key)
+		}
+		secrets[key] = value
+	}
+
+	return vaultunlocker.New(ctx,
+		vaultunlocker.WithLogLevel(logLevel(viper.GetString("log-levels.unlocker"))),
+		vaultunlocker.WithMonitor(monitor),
+		vaultunlocker.WithAddress(viper.GetString("unlocker.vault.address")),
+		vaultunlocker.WithToken(string(secrets["unlocker.vault.token"])),
+		vaultunlocker.WithTokenFile(viper.GetString("unlocker.vault.token-file")),
+		vaultunlocker.WithAppRoleMount(viper.GetString("unlocker.vault.approle.mount")),
+		vaultunlocker.WithAppRoleID(viper.GetString("unlocker.vault.approle.role-id")),
+		vaultunlocker.WithAppRoleSecretID(string(secrets["unlocker.vault.approle.secret-id"])),
+		vaultunlocker.WithCACert(secrets["unlocker.vault.ca-cert"]),
+		vaultunlocker.WithTimeout(viper.GetDuration("unlocker.vault.timeout")),
+		vaultunlocker.WithCacheDuration(viper.GetDuration("unlocker.vault.cache-duration")),
+		vaultunlocker.WithWalletPaths(viper.GetStringSlice("unlocker.vault.wallet-paths")),
+		vaultunlocker.WithAccountPaths(viper.GetStringSlice("unlocker.vault.account-paths")),
+		vaultunlocker.WithWalletPassphrases(walletPassphrases),
+		vaultunlocker.WithAccountPassphrases(accountPassphrases),
+	)
+}
+
+func startChecker(ctx context.Context, monitor metrics.Service, fetcher fetcher.Service) (checker.Service, error) {
+	// Set up the checker.
 	var checkerMonitor metrics.CheckerMonitor
 	if monitor, isMonitor := monitor.(metrics.CheckerMonitor); isMonitor {
 		checkerMonitor = monitor
 	}
 	return staticchecker.New(ctx,
-		staticchecker.WithLogLevel(logLevel(viper.GetString("log-levels.checker"))),
-		staticchecker.WithMonitor(checkerMonitor),
-		staticchecker.WithPermissions(permissions),
+		append([]staticchecker.Parameter{
+			staticchecker.WithLogLevel(logLevel(viper.GetString("log-levels.checker"))),
+			staticchecker.WithMonitor(checkerMonitor),
+			staticchecker.WithFetcher(fetcher),
+		}, checkerPermissionParameters(viper.GetViper())...)...,
 	)
 }
 
-func startFetcher(ctx context.Context, stores []e2wtypes.Store, monitor metrics.Service) (fetcher.Service, error) {
+func startFetcher(ctx context.Context, stores []e2wtypes.Store, unlocker unlocker.Service, monitor metrics.Service) (fetcher.Service, error) {
 	var fetcherMonitor metrics.FetcherMonitor
 	if monitor, isMonitor := monitor.(metrics.FetcherMonitor); isMonitor {
 		fetcherMonitor = monitor
 	}
-	return memfetcher.New(ctx,
+	params := []memfetcher.Parameter{
 		memfetcher.WithLogLevel(logLevel(viper.GetString("log-levels.fetcher"))),
 		memfetcher.WithMonitor(fetcherMonitor),
 		memfetcher.WithStores(stores),
-	)
+		memfetcher.WithWatch(viper.GetBool("watch-stores")),
+	}
+	// Derived accounts require wallets to be unlocked, so are only available with an unlocker.
+	if unlocker != nil {
+		derivedAccounts, err := derivedAccountsFromConfig()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params,
+			memfetcher.WithUnlocker(unlocker),
+			memfetcher.WithDerivedAccounts(derivedAccounts),
+		)
+	}
+	return memfetcher.New(ctx, params...)
+}
+
+// derivedAccountsConfig is the configuration for the accounts derived from the seed of a wallet.
+type derivedAccountsConfig struct {
+	Wallet   string `mapstructure:"wallet"`
+	Accounts uint32 `mapstructure:"accounts"`
+}
+
+// derivedAccountsFromConfig obtains the number of accounts derived from the seed of each wallet from the configuration.
+func derivedAccountsFromConfig() (map[string]uint32, error) {
+	configs := make([]*derivedAccountsConfig, 0)
+	if err := viper.UnmarshalKey("derived-accounts", &configs); err != nil {
+		return nil, errors.Wrap(err, "invalid derived accounts")
+	}
+	derivedAccounts := make(map[string]uint32, len(configs))
+	for i := range configs {
+		if configs[i].Wallet == "" {
+			return nil, errors.New("derived accounts missing wallet")
+		}
+		if _, exists := derivedAccounts[configs[i].Wallet]; exists {
+			return nil, fmt.Errorf("duplicate derived accounts for wallet %s", configs[i].Wallet)
+		}
+		derivedAccounts[configs[i].Wallet] = configs[i].Accounts
+	}
+	return derivedAccounts, nil
 }
 
-func startLocker(ctx context.Context, monitor metrics.Service) (locker.Service, error) {
+func startLocker(ctx context.Context, majordomo majordomo.Service, monitor metrics.Service) (locker.Service, error) {
 	var lockerMonitor metrics.LockerMonitor
 	if monitor, isMonitor := monitor.(metrics.LockerMonitor); isMonitor {
 		lockerMonitor = monitor
 	}
+	if viper.GetString("locker.redis.address") != "" {
+		return startRedisLocker(ctx, majordomo, lockerMonitor)
+	}
 	return syncmaplocker.New(ctx,
 		syncmaplocker.WithLogLevel(logLevel(viper.GetString("log-levels.locker"))),
 		syncmaplocker.WithMonitor(lockerMonitor),
+		syncmaplocker.WithTrackHolders(viper.GetBool("locker.track-holders")),
 	)
 }
 
-func startRuler(ctx context.Context, locker locker.Service, monitor metrics.Service) (ruler.Service, error) {
-	rules, err := initRules(ctx)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to set up rules")
+// startRedisLocker starts a locker that holds locks in Redis, so that they are shared between instances.
+func startRedisLocker(ctx context.Context, majordomo majordomo.Service, monitor metrics.LockerMonitor) (locker.Service, error) {
+	// Secrets are fetched through majordomo, so need not be held in the configuration file.
+	secrets := make(map[string][]byte)
+	for _, key := range []string{"locker.redis.password", "locker.redis.ca-cert"} {
+		if viper.GetString(key) == "" {
+			continue
+		}
+		value, err := majordomo.Fetch(ctx, viper.GetString(key))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to obtain %s", key)
+		}
+		secrets[key] = value
+	}
+
+	return redislocker.New(ctx,
+		redislocker.WithLogLevel(logLevel(viper.GetString("log-levels.locker"))),
+		redislocker.WithMonitor(monitor),
+		redislocker.WithTrackHolders(viper.GetBool("locker.track-holders")),
+		redislocker.WithAddress(viper.GetString("locker.redis.address")),
+		redislocker.WithPassword(strings.TrimSpace(string(secrets["locker.redis.password"]))),
+		redislocker.WithDB(viper.GetInt("locker.redis.db")),
+		redislocker.WithCACert(secrets["locker.redis.ca-cert"]),
+		redislocker.WithKeyPrefix(viper.GetString("locker.redis.key-prefix")),
+		redislocker.WithTTL(viper.GetDuration("locker.redis.ttl")),
+		redislocker.WithTimeout(viper.GetDuration("locker.redis.timeout")),
+	)
+}
+
+func startAuditor(ctx context.Context, monitor metrics.Service) (auditor.Service, error) {
+	if viper.GetString("audit.path") == "" {
+		log.Debug().Msg("No audit path supplied; auditor not starting")
+		return nil, nil
 	}
+	var auditorMonitor metrics.AuditorMonitor
+	if monitor, isMonitor := monitor.(metrics.AuditorMonitor); isMonitor {
+		auditorMonitor = monitor
+	}
+	return fileauditor.New(ctx,
+		fileauditor.WithLogLevel(logLevel(viper.GetString("log-levels.auditor"))),
+		fileauditor.WithMonitor(auditorMonitor),
+		fileauditor.WithPath(resolvePath(viper.GetString("audit.path"))),
+		fileauditor.WithMaxSize(viper.GetInt64("audit.max-size")),
+		fileauditor.WithMaxBackups(viper.GetInt("audit.max-backups")),
+	)
+}
+
+func startRuler(ctx context.Context, locker locker.Service, auditor auditor.Service, rules rules.Service, consensus consensus.Service, checker checker.Service, monitor metrics.Service) (ruler.Service, error) {
 	var rulerMonitor metrics.RulerMonitor
 	if monitor, isMonitor := monitor.(metrics.RulerMonitor); isMonitor {
 		rulerMonitor = monitor
@@ -695,10 +1507,54 @@ func startRuler(ctx context.Context, locker locker.Service, monitor metrics.Serv
 		goruler.WithMonitor(rulerMonitor),
 		goruler.WithLocker(locker),
 		goruler.WithRules(rules),
+		goruler.WithAuditor(auditor),
+		goruler.WithConsensus(consensus),
+		goruler.WithChecker(checker),
+		goruler.WithTimeout(viper.GetDuration("server.rules.timeout")),
+		goruler.WithLenientData(viper.GetBool("server.rules.lenient-data")),
+		goruler.WithMaxBatchSize(viper.GetInt("server.max-batch-size")),
+	)
+}
+
+// startConsensus starts the slashing protection consensus service, if configured.
+func startConsensus(ctx context.Context, monitor metrics.Service, peers peers.Service, sender sender.Service, serverID uint64) (consensus.Service, error) {
+	if viper.GetUint32("peer-consensus.quorum") == 0 {
+		// Not configured.
+		return nil, nil
+	}
+
+	var consensusMonitor metrics.ConsensusMonitor
+	if monitor, isMonitor := monitor.(metrics.ConsensusMonitor); isMonitor {
+		consensusMonitor = monitor
+	}
+	return standardconsensus.New(ctx,
+		standardconsensus.WithLogLevel(logLevel(viper.GetString("log-levels.consensus"))),
+		standardconsensus.WithMonitor(consensusMonitor),
+		standardconsensus.WithPeers(peers),
+		standardconsensus.WithTransport(sender),
+		standardconsensus.WithID(serverID),
+		standardconsensus.WithStoragePath(resolvePath(viper.GetString("peer-consensus.storage-path"))),
+		standardconsensus.WithQuorum(viper.GetUint32("peer-consensus.quorum")),
+		standardconsensus.WithTimeout(viper.GetDuration("peer-consensus.timeout")),
 	)
 }
 
 func startPeers(ctx context.Context, monitor metrics.Service) (peers.Service, error) {
+	var peersMonitor metrics.PeersMonitor
+	if monitor, isMonitor := monitor.(metrics.PeersMonitor); isMonitor {
+		peersMonitor = monitor
+	}
+
+	if viper.GetString("peer-discovery.srv") != "" {
+		return dnspeers.New(ctx,
+			dnspeers.WithLogLevel(logLevel(viper.GetString("log-levels.peers"))),
+			dnspeers.WithMonitor(peersMonitor),
+			dnspeers.WithName(viper.GetString("peer-discovery.srv")),
+			dnspeers.WithRefreshInterval(viper.GetDuration("peer-discovery.refresh-interval")),
+			dnspeers.WithIDOffset(viper.GetUint64("peer-discovery.id-offset")),
+		)
+	}
+
 	// Keys are strings.
 	peersInfo := viper.GetStringMapString("peers")
 	peersMap := make(map[uint64]string)
@@ -709,10 +1565,6 @@ func startPeers(ctx context.Context, monitor metrics.Service) (peers.Service, er
 		}
 		peersMap[id] = v
 	}
-	var peersMonitor metrics.PeersMonitor
-	if monitor, isMonitor := monitor.(metrics.PeersMonitor); isMonitor {
-		peersMonitor = monitor
-	}
 	return staticpeers.New(ctx,
 		staticpeers.WithLogLevel(logLevel(viper.GetString("log-levels.peers"))),
 		staticpeers.WithMonitor(peersMonitor),
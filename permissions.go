@@ -0,0 +1,82 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// configuredPermissions obtains the client permissions from the configuration.
+func configuredPermissions(cfg *viper.Viper) map[string][]*checker.Permissions {
+	permissionsCfg := cfg.GetStringMap("permissions")
+	permissions := make(map[string][]*checker.Permissions)
+	for client := range permissionsCfg {
+		// Client identities such as SPIFFE IDs can contain the key delimiter, so obtain permissions directly.
+		perms := cast.ToStringMapStringSlice(permissionsCfg[client])
+		permissions[client] = make([]*checker.Permissions, 0, len(perms))
+		for path, operations := range perms {
+			permissions[client] = append(permissions[client], &checker.Permissions{
+				Path:       path,
+				Operations: operations,
+			})
+		}
+	}
+	return permissions
+}
+
+// checkerPermissionParameters obtains the permission parameters for the checker from the configuration.
+func checkerPermissionParameters(cfg *viper.Viper) []staticchecker.Parameter {
+	return []staticchecker.Parameter{
+		staticchecker.WithPermissions(configuredPermissions(cfg)),
+		staticchecker.WithReadOnlyClients(cfg.GetStringSlice("read-only.clients")),
+		staticchecker.WithClientActions(cfg.GetStringMapStringSlice("client-actions")),
+		staticchecker.WithSequenceClients(cfg.GetStringSlice("sequence-numbers.clients")),
+	}
+}
+
+// reloadPermissions reads the configuration file again and supplies its permissions to the checker.
+// The running configuration is not changed, and failure to reload leaves the existing permissions in place.
+func reloadPermissions(ctx context.Context, checkerSvc checker.Service) {
+	updater, isUpdater := checkerSvc.(*staticchecker.Service)
+	if !isUpdater {
+		log.Warn().Msg("Checker does not support permissions reload")
+		return
+	}
+
+	log.Info().Msg("Reloading permissions")
+	cfg, err := rereadConfig()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read configuration; retaining existing permissions")
+		return
+	}
+	if err := updater.UpdatePermissions(ctx, checkerPermissionParameters(cfg)...); err != nil {
+		log.Error().Err(err).Msg("Failed to update permissions; retaining existing permissions")
+	}
+}
+
+// rereadConfig reads the configuration file in use in to a new configuration.
+func rereadConfig() (*viper.Viper, error) {
+	cfg := viper.New()
+	cfg.SetConfigFile(viper.ConfigFileUsed())
+	if err := cfg.ReadInConfig(); err != nil {
+		return nil, errors.Wrap(err, "failed to read configuration file")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,32 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import "context"
+
+// atomicBatchKey is the context key marking an atomic batch.
+type atomicBatchKey struct{}
+
+// WithAtomicBatch returns a context that marks rules run with it as part of an atomic batch.
+// Rules run as part of an atomic batch that cannot approve every entry do not change any
+// persistent state for any entry, as none of the entries will be signed.
+func WithAtomicBatch(ctx context.Context) context.Context {
+	return context.WithValue(ctx, &atomicBatchKey{}, true)
+}
+
+// IsAtomicBatch returns true if the context marks an atomic batch.
+func IsAtomicBatch(ctx context.Context) bool {
+	atomicBatch, ok := ctx.Value(&atomicBatchKey{}).(bool)
+	return ok && atomicBatch
+}
@@ -0,0 +1,82 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"github.com/attestantio/dirk/rules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel           zerolog.Level
+	rules              []rules.Service
+	slashingProtection rules.Service
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithRules sets the rules in the chain, in the order in which they are run.
+func WithRules(rules []rules.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rules = rules
+	})
+}
+
+// WithSlashingProtection sets the rules that hold the slashing protection data.  Requests to export,
+// back up, import or merge slashing protection data are passed to these rules.
+func WithSlashingProtection(rules rules.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slashingProtection = rules
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	for i := range parameters.rules {
+		if parameters.rules[i] == nil {
+			return nil, errors.New("nil rules in chain")
+		}
+	}
+	if parameters.slashingProtection == nil {
+		return nil, errors.New("no slashing protection specified")
+	}
+
+	return &parameters, nil
+}
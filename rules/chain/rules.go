@@ -0,0 +1,242 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"context"
+	"io"
+
+	"github.com/attestantio/dirk/rules"
+)
+
+// OnListAccounts is called when a request to list accounts needs to be approved.
+func (s *Service) OnListAccounts(ctx context.Context, metadata *rules.ReqMetadata, req *rules.AccessAccountData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnListAccounts(ctx, metadata, req)
+	})
+}
+
+// OnSign is called when a request to sign generic data needs to be approved.
+func (s *Service) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSign(ctx, metadata, req)
+	})
+}
+
+// OnSignBeaconAttestation is called when a request to sign a beacon block attestation needs to be approved.
+func (s *Service) OnSignBeaconAttestation(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconAttestationData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignBeaconAttestation(ctx, metadata, req)
+	})
+}
+
+// OnSignBeaconAttestations is called when a request to sign multiple beacon block attestations needs to be approved.
+// Each rules service in the chain is passed only those attestations approved by the rules services before it.
+func (s *Service) OnSignBeaconAttestations(ctx context.Context, metadata []*rules.ReqMetadata, req []*rules.SignBeaconAttestationData) []rules.Result {
+	results := make([]rules.Result, len(req))
+	indices := make([]int, len(req))
+	for i := range req {
+		results[i] = rules.APPROVED
+		indices[i] = i
+	}
+	for i := range s.rules {
+		if len(indices) == 0 {
+			break
+		}
+		linkMetadata := make([]*rules.ReqMetadata, len(indices))
+		linkReq := make([]*rules.SignBeaconAttestationData, len(indices))
+		for j, index := range indices {
+			linkMetadata[j] = metadata[index]
+			linkReq[j] = req[index]
+		}
+		linkResults := s.rules[i].OnSignBeaconAttestations(ctx, linkMetadata, linkReq)
+		approved := indices[:0]
+		for j, index := range indices {
+			results[index] = linkResults[j]
+			if linkResults[j] == rules.APPROVED {
+				approved = append(approved, index)
+			}
+		}
+		indices = approved
+	}
+	return results
+}
+
+// OnSignBeaconProposal is called when a request to sign a beacon block proposal needs to be approved.
+func (s *Service) OnSignBeaconProposal(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconProposalData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignBeaconProposal(ctx, metadata, req)
+	})
+}
+
+// OnSignRANDAOReveal is called when a request to sign a RANDAO reveal needs to be approved.
+func (s *Service) OnSignRANDAOReveal(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignRANDAORevealData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignRANDAOReveal(ctx, metadata, req)
+	})
+}
+
+// OnSignAggregateAndProof is called when a request to sign an aggregate and proof needs to be approved.
+func (s *Service) OnSignAggregateAndProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignAggregateAndProofData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignAggregateAndProof(ctx, metadata, req)
+	})
+}
+
+// OnSignAggregationSlot is called when a request to sign an aggregation slot selection proof needs to be approved.
+func (s *Service) OnSignAggregationSlot(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignAggregationSlotData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignAggregationSlot(ctx, metadata, req)
+	})
+}
+
+// OnSignSyncCommitteeMessage is called when a request to sign a sync committee message needs to be approved.
+func (s *Service) OnSignSyncCommitteeMessage(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeMessageData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignSyncCommitteeMessage(ctx, metadata, req)
+	})
+}
+
+// OnSignSyncCommitteeSelectionProof is called when a request to sign a sync committee selection proof needs to be approved.
+func (s *Service) OnSignSyncCommitteeSelectionProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeSelectionProofData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignSyncCommitteeSelectionProof(ctx, metadata, req)
+	})
+}
+
+// OnSignSyncCommitteeContributionAndProof is called when a request to sign a sync committee contribution and proof
+// needs to be approved.
+func (s *Service) OnSignSyncCommitteeContributionAndProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeContributionAndProofData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignSyncCommitteeContributionAndProof(ctx, metadata, req)
+	})
+}
+
+// OnSignVoluntaryExit is called when a request to sign a voluntary exit needs to be approved.
+func (s *Service) OnSignVoluntaryExit(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignVoluntaryExitData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignVoluntaryExit(ctx, metadata, req)
+	})
+}
+
+// OnSignBLSToExecutionChange is called when a request to sign a BLS to execution change needs to be approved.
+func (s *Service) OnSignBLSToExecutionChange(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBLSToExecutionChangeData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignBLSToExecutionChange(ctx, metadata, req)
+	})
+}
+
+// OnSignValidatorRegistration is called when a request to sign a validator registration needs to be approved.
+func (s *Service) OnSignValidatorRegistration(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignValidatorRegistrationData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignValidatorRegistration(ctx, metadata, req)
+	})
+}
+
+// OnSignDeposit is called when a request to sign deposit data needs to be approved.
+func (s *Service) OnSignDeposit(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignDepositData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnSignDeposit(ctx, metadata, req)
+	})
+}
+
+// OnLockWallet is called when a request to lock a wallet needs to be approved.
+func (s *Service) OnLockWallet(ctx context.Context, metadata *rules.ReqMetadata, req *rules.LockWalletData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnLockWallet(ctx, metadata, req)
+	})
+}
+
+// OnUnlockWallet is called when a request to unlock a wallet needs to be approved.
+func (s *Service) OnUnlockWallet(ctx context.Context, metadata *rules.ReqMetadata, req *rules.UnlockWalletData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnUnlockWallet(ctx, metadata, req)
+	})
+}
+
+// OnLockAccount is called when a request to lock an account needs to be approved.
+func (s *Service) OnLockAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.LockAccountData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnLockAccount(ctx, metadata, req)
+	})
+}
+
+// OnUnlockAccount is called when a request to unlock an account needs to be approved.
+func (s *Service) OnUnlockAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.UnlockAccountData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnUnlockAccount(ctx, metadata, req)
+	})
+}
+
+// OnPauseSigning is called when a request to pause signing for an account needs to be approved.
+func (s *Service) OnPauseSigning(ctx context.Context, metadata *rules.ReqMetadata, req *rules.PauseSigningData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnPauseSigning(ctx, metadata, req)
+	})
+}
+
+// OnResumeSigning is called when a request to resume signing for an account needs to be approved.
+func (s *Service) OnResumeSigning(ctx context.Context, metadata *rules.ReqMetadata, req *rules.ResumeSigningData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnResumeSigning(ctx, metadata, req)
+	})
+}
+
+// OnExportAccount is called when a request to export an account needs to be approved.
+func (s *Service) OnExportAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.ExportAccountData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnExportAccount(ctx, metadata, req)
+	})
+}
+
+// OnRecoverAccount is called when a request to release a share of a distributed account for recovery needs to be approved.
+func (s *Service) OnRecoverAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.RecoverAccountData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnRecoverAccount(ctx, metadata, req)
+	})
+}
+
+// OnCreateAccount is called when a request to create an account needs to be approved.
+func (s *Service) OnCreateAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.CreateAccountData) rules.Result {
+	return s.run(metadata, func(r rules.Service) rules.Result {
+		return r.OnCreateAccount(ctx, metadata, req)
+	})
+}
+
+// ExportSlashingProtection exports the slashing protection data.
+func (s *Service) ExportSlashingProtection(ctx context.Context) (map[[48]byte]*rules.SlashingProtection, error) {
+	return s.slashingProtection.ExportSlashingProtection(ctx)
+}
+
+// BackupSlashingProtection writes a consistent snapshot of the slashing protection data.
+func (s *Service) BackupSlashingProtection(ctx context.Context, w io.Writer) error {
+	return s.slashingProtection.BackupSlashingProtection(ctx, w)
+}
+
+// ParseSlashingProtectionBackup parses a snapshot written by BackupSlashingProtection.
+func (s *Service) ParseSlashingProtectionBackup(ctx context.Context, r io.Reader) (map[[48]byte]*rules.SlashingProtection, error) {
+	return s.slashingProtection.ParseSlashingProtectionBackup(ctx, r)
+}
+
+// ImportSlashingProtection imports the slashing protection data.
+func (s *Service) ImportSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) error {
+	return s.slashingProtection.ImportSlashingProtection(ctx, protection)
+}
+
+// MergeSlashingProtection merges the slashing protection data in to the existing data, and is safe to call
+// while signing.  Existing data for a key is only replaced by data that is at least as high for all values,
+// so high-water marks are never lowered.  It returns the keys whose existing data was retained.
+func (s *Service) MergeSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) ([][48]byte, error) {
+	return s.slashingProtection.MergeSlashingProtection(ctx, protection)
+}
@@ -0,0 +1,67 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service is a rules service that runs an ordered chain of rules.  A request is approved only if every
+// rules service in the chain approves it; the first result other than approved is returned, and the
+// remaining rules are not run.  Rules that hold state, such as slashing protection, should be placed
+// after rules that can refuse requests outright, so that their state is only updated for requests that
+// the earlier rules have approved.  A chain without any rules approves all requests.
+type Service struct {
+	rules              []rules.Service
+	slashingProtection rules.Service
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new chain rules service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "rules").Str("impl", "chain").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	return &Service{
+		rules:              parameters.rules,
+		slashingProtection: parameters.slashingProtection,
+	}, nil
+}
+
+// run runs the rule against each rules service in the chain in turn, stopping at the first
+// rules service that does not approve the request.
+func (s *Service) run(metadata *rules.ReqMetadata, rule func(rules.Service) rules.Result) rules.Result {
+	for i := range s.rules {
+		if res := rule(s.rules[i]); res != rules.APPROVED {
+			log.Trace().Str("request_id", metadata.RequestID).Int("link", i).Stringer("result", res).Msg("Chain stopped")
+			return res
+		}
+	}
+	return rules.APPROVED
+}
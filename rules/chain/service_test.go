@@ -0,0 +1,220 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/rules/chain"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRules are rules that return a fixed result for generic signing, and deny attestations
+// for the listed slots, recording the requests they see.
+type recordingRules struct {
+	*mockrules.Service
+	result      rules.Result
+	deniedSlots map[uint64]bool
+	signs       int
+	slots       []uint64
+	protection  map[[48]byte]*rules.SlashingProtection
+}
+
+func (r *recordingRules) OnSign(_ context.Context, _ *rules.ReqMetadata, _ *rules.SignData) rules.Result {
+	r.signs++
+	return r.result
+}
+
+func (r *recordingRules) OnSignBeaconAttestations(_ context.Context,
+	_ []*rules.ReqMetadata,
+	req []*rules.SignBeaconAttestationData,
+) []rules.Result {
+	results := make([]rules.Result, len(req))
+	for i := range req {
+		r.slots = append(r.slots, req[i].Slot)
+		results[i] = rules.APPROVED
+		if r.deniedSlots[req[i].Slot] {
+			results[i] = rules.DENIED
+		}
+	}
+	return results
+}
+
+func (r *recordingRules) ExportSlashingProtection(_ context.Context) (map[[48]byte]*rules.SlashingProtection, error) {
+	return r.protection, nil
+}
+
+func newRecordingRules(result rules.Result) *recordingRules {
+	return &recordingRules{
+		Service:     mockrules.New(),
+		result:      result,
+		deniedSlots: make(map[uint64]bool),
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []chain.Parameter
+		err    string
+	}{
+		{
+			name: "SlashingProtectionMissing",
+			params: []chain.Parameter{
+				chain.WithRules([]rules.Service{mockrules.New()}),
+			},
+			err: "problem with parameters: no slashing protection specified",
+		},
+		{
+			name: "RulesNil",
+			params: []chain.Parameter{
+				chain.WithRules([]rules.Service{mockrules.New(), nil}),
+				chain.WithSlashingProtection(mockrules.New()),
+			},
+			err: "problem with parameters: nil rules in chain",
+		},
+		{
+			name: "Empty",
+			params: []chain.Parameter{
+				chain.WithSlashingProtection(mockrules.New()),
+			},
+		},
+		{
+			name: "Good",
+			params: []chain.Parameter{
+				chain.WithRules([]rules.Service{mockrules.New(), mockrules.New()}),
+				chain.WithSlashingProtection(mockrules.New()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := chain.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	ctx := context.Background()
+	metadata := &rules.ReqMetadata{Account: "Test wallet/Test account"}
+
+	tests := []struct {
+		name    string
+		results []rules.Result
+		res     rules.Result
+		signs   []int
+	}{
+		{
+			name: "Empty",
+			res:  rules.APPROVED,
+		},
+		{
+			name:    "AllApprove",
+			results: []rules.Result{rules.APPROVED, rules.APPROVED, rules.APPROVED},
+			res:     rules.APPROVED,
+			signs:   []int{1, 1, 1},
+		},
+		{
+			name:    "FirstDenies",
+			results: []rules.Result{rules.DENIED, rules.APPROVED, rules.APPROVED},
+			res:     rules.DENIED,
+			signs:   []int{1, 0, 0},
+		},
+		{
+			name:    "SecondFails",
+			results: []rules.Result{rules.APPROVED, rules.FAILED, rules.DENIED},
+			res:     rules.FAILED,
+			signs:   []int{1, 1, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			links := make([]*recordingRules, len(test.results))
+			chainRules := make([]rules.Service, len(test.results))
+			for i := range test.results {
+				links[i] = newRecordingRules(test.results[i])
+				chainRules[i] = links[i]
+			}
+			s, err := chain.New(ctx,
+				chain.WithRules(chainRules),
+				chain.WithSlashingProtection(mockrules.New()),
+			)
+			require.NoError(t, err)
+			require.Equal(t, test.res, s.OnSign(ctx, metadata, &rules.SignData{}))
+			for i := range links {
+				require.Equal(t, test.signs[i], links[i].signs)
+			}
+		})
+	}
+}
+
+func TestSignBeaconAttestations(t *testing.T) {
+	ctx := context.Background()
+
+	first := newRecordingRules(rules.APPROVED)
+	first.deniedSlots[2] = true
+	second := newRecordingRules(rules.APPROVED)
+	second.deniedSlots[3] = true
+	third := newRecordingRules(rules.APPROVED)
+
+	s, err := chain.New(ctx,
+		chain.WithRules([]rules.Service{first, second, third}),
+		chain.WithSlashingProtection(mockrules.New()),
+	)
+	require.NoError(t, err)
+
+	metadata := make([]*rules.ReqMetadata, 4)
+	req := make([]*rules.SignBeaconAttestationData, 4)
+	for i := range req {
+		metadata[i] = &rules.ReqMetadata{}
+		req[i] = &rules.SignBeaconAttestationData{Slot: uint64(i + 1)}
+	}
+
+	require.Equal(t, []rules.Result{rules.APPROVED, rules.DENIED, rules.DENIED, rules.APPROVED},
+		s.OnSignBeaconAttestations(ctx, metadata, req))
+	require.Equal(t, []uint64{1, 2, 3, 4}, first.slots)
+	require.Equal(t, []uint64{1, 3, 4}, second.slots)
+	require.Equal(t, []uint64{1, 4}, third.slots)
+}
+
+func TestSlashingProtection(t *testing.T) {
+	ctx := context.Background()
+
+	protection := newRecordingRules(rules.APPROVED)
+	protection.protection = map[[48]byte]*rules.SlashingProtection{
+		{0x01}: {HighestProposedSlot: 1},
+	}
+
+	s, err := chain.New(ctx,
+		chain.WithRules([]rules.Service{newRecordingRules(rules.APPROVED), protection}),
+		chain.WithSlashingProtection(protection),
+	)
+	require.NoError(t, err)
+
+	exported, err := s.ExportSlashingProtection(ctx)
+	require.NoError(t, err)
+	require.Equal(t, protection.protection, exported)
+}
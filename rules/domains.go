@@ -0,0 +1,32 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// Domain types introduced in later forks that the types library does not supply.
+var (
+	// DomainSyncCommittee is the domain type of a sync committee message.
+	DomainSyncCommittee = e2types.DomainType{0x07, 0x00, 0x00, 0x00}
+	// DomainSyncCommitteeSelectionProof is the domain type of a sync committee selection proof.
+	DomainSyncCommitteeSelectionProof = e2types.DomainType{0x08, 0x00, 0x00, 0x00}
+	// DomainContributionAndProof is the domain type of a sync committee contribution and proof.
+	DomainContributionAndProof = e2types.DomainType{0x09, 0x00, 0x00, 0x00}
+	// DomainBLSToExecutionChange is the domain type of a change of withdrawal credentials to an execution address.
+	DomainBLSToExecutionChange = e2types.DomainType{0x0a, 0x00, 0x00, 0x00}
+	// DomainApplicationBuilder is the domain type of a validator registration with an external block builder.
+	DomainApplicationBuilder = e2types.DomainType{0x00, 0x00, 0x00, 0x01}
+)
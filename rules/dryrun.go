@@ -0,0 +1,32 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import "context"
+
+// dryRunKey is the context key marking a dry run.
+type dryRunKey struct{}
+
+// WithDryRun returns a context that marks rules run with it as a dry run.
+// Rules run as a dry run return the result that they would otherwise return, but do not
+// change any persistent state, such as slashing protection.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, &dryRunKey{}, true)
+}
+
+// IsDryRun returns true if the context marks a dry run.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, ok := ctx.Value(&dryRunKey{}).(bool)
+	return ok && dryRun
+}
@@ -0,0 +1,184 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// interchangeFormat is the format of interchange data supported by Dirk.
+	interchangeFormat = "complete"
+	// interchangeFormatVersion is the version of interchange data supported by Dirk.
+	interchangeFormatVersion = "4"
+)
+
+// Interchange is the top-level structure for slashing protection data in the EIP-3076 interchange format.
+type Interchange struct {
+	Metadata *InterchangeMetadata `json:"metadata"`
+	Data     []*InterchangeData   `json:"data"`
+}
+
+// InterchangeMetadata is the structure for slashing protection interchange metadata.
+type InterchangeMetadata struct {
+	InterchangeFormat        string `json:"interchange_format"`
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+	GenesisValidatorsRoot    string `json:"genesis_validators_root"`
+}
+
+// InterchangeData is the structure for slashing protection interchange data for a single key.
+type InterchangeData struct {
+	PublicKey          string                    `json:"pubkey"`
+	SignedBlocks       []*InterchangeProposal    `json:"signed_blocks,omitempty"`
+	SignedAttestations []*InterchangeAttestation `json:"signed_attestations,omitempty"`
+}
+
+// InterchangeProposal is the structure for slashing protection interchange proposal information.
+type InterchangeProposal struct {
+	Slot string `json:"slot"`
+}
+
+// InterchangeAttestation is the structure for slashing protection interchange attestation information.
+type InterchangeAttestation struct {
+	SourceEpoch string `json:"source_epoch"`
+	TargetEpoch string `json:"target_epoch"`
+}
+
+// NewInterchange creates interchange data from slashing protection data.
+// Keys are ordered by public key, so the same slashing protection data always generates the same interchange data.
+func NewInterchange(genesisValidatorsRoot []byte, protection map[[48]byte]*SlashingProtection) (*Interchange, error) {
+	if len(genesisValidatorsRoot) != 32 {
+		return nil, errors.New("genesis validators root must be 32 bytes")
+	}
+
+	keys := make([][48]byte, 0, len(protection))
+	for key := range protection {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i][:], keys[j][:]) < 0
+	})
+
+	res := &Interchange{
+		Metadata: &InterchangeMetadata{
+			InterchangeFormat:        interchangeFormat,
+			InterchangeFormatVersion: interchangeFormatVersion,
+			GenesisValidatorsRoot:    fmt.Sprintf("%#x", genesisValidatorsRoot),
+		},
+		Data: make([]*InterchangeData, 0, len(keys)),
+	}
+	for _, key := range keys {
+		v := protection[key]
+		data := &InterchangeData{
+			PublicKey: fmt.Sprintf("%#x", key),
+		}
+		if v.HighestProposedSlot != -1 {
+			data.SignedBlocks = []*InterchangeProposal{
+				{
+					Slot: fmt.Sprintf("%d", v.HighestProposedSlot),
+				},
+			}
+		}
+		if v.HighestAttestedSourceEpoch != -1 {
+			data.SignedAttestations = []*InterchangeAttestation{
+				{
+					SourceEpoch: fmt.Sprintf("%d", v.HighestAttestedSourceEpoch),
+					TargetEpoch: fmt.Sprintf("%d", v.HighestAttestedTargetEpoch),
+				},
+			}
+		}
+		res.Data = append(res.Data, data)
+	}
+
+	return res, nil
+}
+
+// SlashingProtection obtains slashing protection data from interchange data.
+// The interchange data must be for the given genesis validators root.  The highest slot, source epoch and
+// target epoch across all entries for a key are used as the high-water marks for the key.
+func (i *Interchange) SlashingProtection(genesisValidatorsRoot []byte) (map[[48]byte]*SlashingProtection, error) {
+	if i.Metadata == nil {
+		return nil, errors.New("no metadata in interchange data")
+	}
+	if i.Metadata.InterchangeFormat != interchangeFormat {
+		return nil, fmt.Errorf("interchange format incorrect; expected %s, found %s", interchangeFormat, i.Metadata.InterchangeFormat)
+	}
+	if i.Metadata.InterchangeFormatVersion != interchangeFormatVersion {
+		return nil, fmt.Errorf("interchange format incorrect; expected %s, found %s", interchangeFormatVersion, i.Metadata.InterchangeFormatVersion)
+	}
+	dataGenesisValidatorsRoot, err := hex.DecodeString(strings.TrimPrefix(i.Metadata.GenesisValidatorsRoot, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid genesis validators root in interchange data")
+	}
+	if !bytes.Equal(dataGenesisValidatorsRoot, genesisValidatorsRoot) {
+		return nil, fmt.Errorf("genesis validators root incorrect; expected %#x, found %s", genesisValidatorsRoot, i.Metadata.GenesisValidatorsRoot)
+	}
+
+	res := make(map[[48]byte]*SlashingProtection)
+	for _, data := range i.Data {
+		pubKey, err := hex.DecodeString(strings.TrimPrefix(data.PublicKey, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid public key")
+		}
+		if len(pubKey) != 48 {
+			return nil, fmt.Errorf("public key %s must be 48 bytes", data.PublicKey)
+		}
+		var key [48]byte
+		copy(key[:], pubKey)
+		keyProtection, exists := res[key]
+		if !exists {
+			keyProtection = &SlashingProtection{
+				PubKey:                     key[:],
+				HighestAttestedSourceEpoch: -1,
+				HighestAttestedTargetEpoch: -1,
+				HighestProposedSlot:        -1,
+			}
+			res[key] = keyProtection
+		}
+		for _, attestation := range data.SignedAttestations {
+			sourceEpoch, err := strconv.ParseInt(attestation.SourceEpoch, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid attestation source epoch")
+			}
+			if sourceEpoch > keyProtection.HighestAttestedSourceEpoch {
+				keyProtection.HighestAttestedSourceEpoch = sourceEpoch
+			}
+			targetEpoch, err := strconv.ParseInt(attestation.TargetEpoch, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid attestation target epoch")
+			}
+			if targetEpoch > keyProtection.HighestAttestedTargetEpoch {
+				keyProtection.HighestAttestedTargetEpoch = targetEpoch
+			}
+		}
+		for _, proposal := range data.SignedBlocks {
+			slot, err := strconv.ParseInt(proposal.Slot, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid proposal slot")
+			}
+			if slot > keyProtection.HighestProposedSlot {
+				keyProtection.HighestProposedSlot = slot
+			}
+		}
+	}
+
+	return res, nil
+}
@@ -15,6 +15,7 @@ package mock
 
 import (
 	"context"
+	"io"
 
 	"github.com/attestantio/dirk/rules"
 )
@@ -28,3 +29,18 @@ func (s *Service) ExportSlashingProtection(ctx context.Context) (map[[48]byte]*r
 func (s *Service) ImportSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) error {
 	return nil
 }
+
+// MergeSlashingProtection merges the slashing protection data in to the existing data.
+func (s *Service) MergeSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) ([][48]byte, error) {
+	return nil, nil
+}
+
+// BackupSlashingProtection writes a consistent snapshot of the slashing protection data.
+func (s *Service) BackupSlashingProtection(ctx context.Context, w io.Writer) error {
+	return nil
+}
+
+// ParseSlashingProtectionBackup parses a snapshot written by BackupSlashingProtection.
+func (s *Service) ParseSlashingProtectionBackup(ctx context.Context, r io.Reader) (map[[48]byte]*rules.SlashingProtection, error) {
+	return nil, nil
+}
@@ -0,0 +1,33 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+// GlobalPolicyKey is the key of the global policy, which applies to requests for which there
+// is no account or wallet policy.
+const GlobalPolicyKey = "*"
+
+// PolicyKeys returns the keys under which a policy for the request may be held, from the most
+// to the least specific: the account name, then the wallet name followed by "/", then
+// GlobalPolicyKey.  Keys for an account or wallet that is not present are omitted.
+// A policy is resolved by taking the first key for which a policy exists.
+func (m *ReqMetadata) PolicyKeys() []string {
+	keys := make([]string, 0, 3)
+	if m.Account != "" {
+		keys = append(keys, m.Account)
+	}
+	if m.Wallet != "" {
+		keys = append(keys, m.Wallet+"/")
+	}
+	return append(keys, GlobalPolicyKey)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"context"
+	"sync"
+)
+
+// Reason is the reason that a rule did not approve a request.
+type Reason string
+
+// Reasons that a rule did not approve a request.
+const (
+	// ReasonNone is used when no reason has been recorded.
+	ReasonNone Reason = ""
+	// ReasonSlashing is used when approving the request could result in a slashable signature.
+	ReasonSlashing Reason = "slashing"
+	// ReasonLimitExceeded is used when the request exceeds a limit on the size or rate of requests.
+	ReasonLimitExceeded Reason = "limit_exceeded"
+	// ReasonPolicy is used when the request is refused by the permissions or policy for the client or account.
+	ReasonPolicy Reason = "policy"
+	// ReasonStoreUnavailable is used when the slashing protection store cannot be used.
+	ReasonStoreUnavailable Reason = "store_unavailable"
+	// ReasonInvalidRequest is used when the request is malformed, or its data does not match its action.
+	ReasonInvalidRequest Reason = "invalid_request"
+)
+
+// reasonKey is the context key for the reason recorder.
+type reasonKey struct{}
+
+// reasonRecorder records the reason that a request was not approved.
+type reasonRecorder struct {
+	mu     sync.Mutex
+	reason Reason
+}
+
+// WithReasonRecorder returns a context in which the reason that rules run with it did not approve a
+// request is recorded, for later retrieval with RecordedReason.
+func WithReasonRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, &reasonKey{}, &reasonRecorder{})
+}
+
+// RecordReason records the reason that a request was not approved, if the context has a reason recorder.
+// Only the first reason is kept, as that is the one that decided the result; for batches this is the
+// reason for the first entry that was not approved.
+func RecordReason(ctx context.Context, reason Reason) {
+	recorder, ok := ctx.Value(&reasonKey{}).(*reasonRecorder)
+	if !ok {
+		return
+	}
+	recorder.mu.Lock()
+	if recorder.reason == ReasonNone {
+		recorder.reason = reason
+	}
+	recorder.mu.Unlock()
+}
+
+// RecordedReason returns the reason recorded in the context, or ReasonNone if there is none.
+func RecordedReason(ctx context.Context) Reason {
+	recorder, ok := ctx.Value(&reasonKey{}).(*reasonRecorder)
+	if !ok {
+		return ReasonNone
+	}
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.reason
+}
@@ -0,0 +1,151 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/pkg/errors"
+)
+
+// EvaluateMethod is the full name of the method called on the remote rule evaluator.
+// There is no protobuf definition for this service; requests and responses carry JSON
+// documents in well-known wrapper types, so evaluators require no generated code.
+const EvaluateMethod = "/dirk.rules.v1.RuleEvaluator/Evaluate"
+
+// Actions sent to the remote rule evaluator.
+const (
+	ActionListAccounts          = "ListAccounts"
+	ActionSign                  = "Sign"
+	ActionSignBeaconAttestation = "SignBeaconAttestation"
+	ActionSignBeaconProposal    = "SignBeaconProposal"
+	ActionSignRANDAOReveal      = "SignRANDAOReveal"
+	ActionSignAggregateAndProof = "SignAggregateAndProof"
+	ActionSignAggregationSlot   = "SignAggregationSlot"
+	ActionSignDeposit           = "SignDeposit"
+
+	ActionSignSyncCommitteeMessage              = "SignSyncCommitteeMessage"
+	ActionSignSyncCommitteeSelectionProof       = "SignSyncCommitteeSelectionProof"
+	ActionSignSyncCommitteeContributionAndProof = "SignSyncCommitteeContributionAndProof"
+	ActionSignVoluntaryExit                     = "SignVoluntaryExit"
+	ActionSignBLSToExecutionChange              = "SignBLSToExecutionChange"
+	ActionSignValidatorRegistration             = "SignValidatorRegistration"
+	ActionLockWallet                            = "LockWallet"
+	ActionUnlockWallet                          = "UnlockWallet"
+	ActionLockAccount                           = "LockAccount"
+	ActionUnlockAccount                         = "UnlockAccount"
+	ActionPauseSigning                          = "PauseSigning"
+	ActionResumeSigning                         = "ResumeSigning"
+	ActionExportAccount                         = "ExportAccount"
+	ActionRecoverAccount                        = "RecoverAccount"
+	ActionCreateAccount                         = "CreateAccount"
+)
+
+// Results returned by the remote rule evaluator.
+const (
+	ResultApprove = "approve"
+	ResultDeny    = "deny"
+	ResultFail    = "fail"
+)
+
+// EvaluateRequest is the request sent to the remote rule evaluator.
+type EvaluateRequest struct {
+	// Action is the action to evaluate.
+	Action string `json:"action"`
+	// Metadata is the metadata of the request.
+	Metadata *rules.ReqMetadata `json:"metadata"`
+	// Data is the action-specific data of the request.
+	Data interface{} `json:"data"`
+	// DryRun is true if the request is a dry run, and will not be signed.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// EvaluateResponse is the response returned by the remote rule evaluator.
+type EvaluateResponse struct {
+	// Result is the result of the evaluation.
+	Result string `json:"result"`
+	// Reason is the reason for the result.
+	Reason string `json:"reason,omitempty"`
+}
+
+// evaluate asks the remote rule evaluator for a result.
+// Any failure to obtain a valid response, including a timeout, results in FAILED.
+func (s *Service) evaluate(ctx context.Context, action string, metadata *rules.ReqMetadata, data interface{}) rules.Result {
+	res, err := s.call(ctx, &EvaluateRequest{
+		Action:   action,
+		Metadata: metadata,
+		Data:     data,
+		DryRun:   rules.IsDryRun(ctx),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to evaluate request with remote rule evaluator")
+		return rules.FAILED
+	}
+
+	switch res.Result {
+	case ResultApprove:
+		return rules.APPROVED
+	case ResultDeny:
+		log.Debug().Str("action", action).Str("reason", res.Reason).Msg("Remote rule evaluator denied request")
+		return rules.DENIED
+	default:
+		log.Warn().Str("action", action).Str("reason", res.Reason).Msg("Remote rule evaluator failed request")
+		return rules.FAILED
+	}
+}
+
+// call calls the remote rule evaluator, returning its validated response.
+func (s *Service) call(ctx context.Context, req *EvaluateRequest) (*EvaluateResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode request")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	out := &wrappers.BytesValue{}
+	if err := s.conn.Invoke(ctx, EvaluateMethod, &wrappers.BytesValue{Value: data}, out); err != nil {
+		return nil, errors.Wrap(err, "failed to call remote rule evaluator")
+	}
+
+	return parseEvaluateResponse(out.Value)
+}
+
+// parseEvaluateResponse parses a response from the remote rule evaluator, rejecting any
+// response that does not match the documented schema.
+func parseEvaluateResponse(data []byte) (*EvaluateResponse, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	res := &EvaluateResponse{}
+	if err := decoder.Decode(res); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+	if decoder.More() {
+		return nil, errors.New("invalid response: trailing data")
+	}
+	switch res.Result {
+	case ResultApprove, ResultDeny, ResultFail:
+	case "":
+		return nil, errors.New("invalid response: result missing")
+	default:
+		return nil, fmt.Errorf("invalid response: unknown result %q", res.Result)
+	}
+
+	return res, nil
+}
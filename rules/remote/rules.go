@@ -0,0 +1,265 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"io"
+
+	"github.com/attestantio/dirk/rules"
+)
+
+// OnListAccounts is called when a request to list accounts needs to be approved.
+func (s *Service) OnListAccounts(ctx context.Context, metadata *rules.ReqMetadata, req *rules.AccessAccountData) rules.Result {
+	if res := s.evaluate(ctx, ActionListAccounts, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnListAccounts(ctx, metadata, req)
+}
+
+// OnSign is called when a request to sign generic data needs to be approved.
+func (s *Service) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
+	if res := s.evaluate(ctx, ActionSign, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSign(ctx, metadata, req)
+}
+
+// OnSignBeaconAttestation is called when a request to sign a beacon block attestation needs to be approved.
+func (s *Service) OnSignBeaconAttestation(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconAttestationData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignBeaconAttestation, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignBeaconAttestation(ctx, metadata, req)
+}
+
+// OnSignBeaconAttestations is called when a request to sign multiple beacon block attestations needs to be approved.
+// Each attestation is evaluated separately; only those approved by the remote rule evaluator are passed to the local rules.
+func (s *Service) OnSignBeaconAttestations(ctx context.Context, metadata []*rules.ReqMetadata, req []*rules.SignBeaconAttestationData) []rules.Result {
+	results := make([]rules.Result, len(req))
+	approvedMetadata := make([]*rules.ReqMetadata, 0, len(req))
+	approvedReq := make([]*rules.SignBeaconAttestationData, 0, len(req))
+	approvedIndices := make([]int, 0, len(req))
+	for i := range req {
+		results[i] = s.evaluate(ctx, ActionSignBeaconAttestation, metadata[i], req[i])
+		if results[i] == rules.APPROVED {
+			approvedMetadata = append(approvedMetadata, metadata[i])
+			approvedReq = append(approvedReq, req[i])
+			approvedIndices = append(approvedIndices, i)
+		}
+	}
+	if len(approvedReq) == 0 {
+		return results
+	}
+	if len(approvedReq) != len(req) && rules.IsAtomicBatch(ctx) {
+		// An atomic batch is only signed if every entry is approved, so the local rules are not run as
+		// they would advance slashing protection for entries that will not be signed.
+		for _, i := range approvedIndices {
+			results[i] = rules.DENIED
+		}
+		return results
+	}
+
+	approvedResults := s.rules.OnSignBeaconAttestations(ctx, approvedMetadata, approvedReq)
+	for i := range approvedIndices {
+		results[approvedIndices[i]] = approvedResults[i]
+	}
+	return results
+}
+
+// OnSignBeaconProposal is called when a request to sign a beacon block proposal needs to be approved.
+func (s *Service) OnSignBeaconProposal(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconProposalData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignBeaconProposal, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignBeaconProposal(ctx, metadata, req)
+}
+
+// OnSignRANDAOReveal is called when a request to sign a RANDAO reveal needs to be approved.
+func (s *Service) OnSignRANDAOReveal(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignRANDAORevealData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignRANDAOReveal, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignRANDAOReveal(ctx, metadata, req)
+}
+
+// OnSignAggregateAndProof is called when a request to sign an aggregate and proof needs to be approved.
+func (s *Service) OnSignAggregateAndProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignAggregateAndProofData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignAggregateAndProof, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignAggregateAndProof(ctx, metadata, req)
+}
+
+// OnSignAggregationSlot is called when a request to sign an aggregation slot selection proof needs to be approved.
+func (s *Service) OnSignAggregationSlot(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignAggregationSlotData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignAggregationSlot, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignAggregationSlot(ctx, metadata, req)
+}
+
+// OnSignSyncCommitteeMessage is called when a request to sign a sync committee message needs to be approved.
+func (s *Service) OnSignSyncCommitteeMessage(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeMessageData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignSyncCommitteeMessage, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignSyncCommitteeMessage(ctx, metadata, req)
+}
+
+// OnSignSyncCommitteeSelectionProof is called when a request to sign a sync committee selection proof needs to be approved.
+func (s *Service) OnSignSyncCommitteeSelectionProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeSelectionProofData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignSyncCommitteeSelectionProof, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignSyncCommitteeSelectionProof(ctx, metadata, req)
+}
+
+// OnSignSyncCommitteeContributionAndProof is called when a request to sign a sync committee contribution and proof
+// needs to be approved.
+func (s *Service) OnSignSyncCommitteeContributionAndProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeContributionAndProofData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignSyncCommitteeContributionAndProof, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignSyncCommitteeContributionAndProof(ctx, metadata, req)
+}
+
+// OnSignVoluntaryExit is called when a request to sign a voluntary exit needs to be approved.
+func (s *Service) OnSignVoluntaryExit(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignVoluntaryExitData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignVoluntaryExit, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignVoluntaryExit(ctx, metadata, req)
+}
+
+// OnSignBLSToExecutionChange is called when a request to sign a BLS to execution change needs to be approved.
+func (s *Service) OnSignBLSToExecutionChange(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBLSToExecutionChangeData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignBLSToExecutionChange, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignBLSToExecutionChange(ctx, metadata, req)
+}
+
+// OnSignValidatorRegistration is called when a request to sign a validator registration needs to be approved.
+func (s *Service) OnSignValidatorRegistration(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignValidatorRegistrationData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignValidatorRegistration, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignValidatorRegistration(ctx, metadata, req)
+}
+
+// OnSignDeposit is called when a request to sign deposit data needs to be approved.
+func (s *Service) OnSignDeposit(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignDepositData) rules.Result {
+	if res := s.evaluate(ctx, ActionSignDeposit, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnSignDeposit(ctx, metadata, req)
+}
+
+// OnLockWallet is called when a request to lock a wallet needs to be approved.
+func (s *Service) OnLockWallet(ctx context.Context, metadata *rules.ReqMetadata, req *rules.LockWalletData) rules.Result {
+	if res := s.evaluate(ctx, ActionLockWallet, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnLockWallet(ctx, metadata, req)
+}
+
+// OnUnlockWallet is called when a request to unlock a wallet needs to be approved.
+func (s *Service) OnUnlockWallet(ctx context.Context, metadata *rules.ReqMetadata, req *rules.UnlockWalletData) rules.Result {
+	if res := s.evaluate(ctx, ActionUnlockWallet, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnUnlockWallet(ctx, metadata, req)
+}
+
+// OnLockAccount is called when a request to lock an account needs to be approved.
+func (s *Service) OnLockAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.LockAccountData) rules.Result {
+	if res := s.evaluate(ctx, ActionLockAccount, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnLockAccount(ctx, metadata, req)
+}
+
+// OnUnlockAccount is called when a request to unlock an account needs to be approved.
+func (s *Service) OnUnlockAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.UnlockAccountData) rules.Result {
+	if res := s.evaluate(ctx, ActionUnlockAccount, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnUnlockAccount(ctx, metadata, req)
+}
+
+// OnPauseSigning is called when a request to pause signing for an account needs to be approved.
+func (s *Service) OnPauseSigning(ctx context.Context, metadata *rules.ReqMetadata, req *rules.PauseSigningData) rules.Result {
+	if res := s.evaluate(ctx, ActionPauseSigning, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnPauseSigning(ctx, metadata, req)
+}
+
+// OnResumeSigning is called when a request to resume signing for an account needs to be approved.
+func (s *Service) OnResumeSigning(ctx context.Context, metadata *rules.ReqMetadata, req *rules.ResumeSigningData) rules.Result {
+	if res := s.evaluate(ctx, ActionResumeSigning, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnResumeSigning(ctx, metadata, req)
+}
+
+// OnExportAccount is called when a request to export an account needs to be approved.
+func (s *Service) OnExportAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.ExportAccountData) rules.Result {
+	if res := s.evaluate(ctx, ActionExportAccount, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnExportAccount(ctx, metadata, req)
+}
+
+// OnRecoverAccount is called when a request to release a share of a distributed account for recovery needs to be approved.
+func (s *Service) OnRecoverAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.RecoverAccountData) rules.Result {
+	if res := s.evaluate(ctx, ActionRecoverAccount, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnRecoverAccount(ctx, metadata, req)
+}
+
+// OnCreateAccount is called when a request to create an account needs to be approved.
+func (s *Service) OnCreateAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.CreateAccountData) rules.Result {
+	if res := s.evaluate(ctx, ActionCreateAccount, metadata, req); res != rules.APPROVED {
+		return res
+	}
+	return s.rules.OnCreateAccount(ctx, metadata, req)
+}
+
+// ExportSlashingProtection exports the slashing protection data.
+func (s *Service) ExportSlashingProtection(ctx context.Context) (map[[48]byte]*rules.SlashingProtection, error) {
+	return s.rules.ExportSlashingProtection(ctx)
+}
+
+// BackupSlashingProtection writes a consistent snapshot of the slashing protection data.
+func (s *Service) BackupSlashingProtection(ctx context.Context, w io.Writer) error {
+	return s.rules.BackupSlashingProtection(ctx, w)
+}
+
+// ParseSlashingProtectionBackup parses a snapshot written by BackupSlashingProtection.
+func (s *Service) ParseSlashingProtectionBackup(ctx context.Context, r io.Reader) (map[[48]byte]*rules.SlashingProtection, error) {
+	return s.rules.ParseSlashingProtectionBackup(ctx, r)
+}
+
+// ImportSlashingProtection imports the slashing protection data.
+func (s *Service) ImportSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) error {
+	return s.rules.ImportSlashingProtection(ctx, protection)
+}
+
+// MergeSlashingProtection merges the slashing protection data in to the existing data.
+func (s *Service) MergeSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) ([][48]byte, error) {
+	return s.rules.MergeSlashingProtection(ctx, protection)
+}
@@ -0,0 +1,321 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	"github.com/attestantio/dirk/rules/remote"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/go-bytesutil"
+	"google.golang.org/grpc"
+)
+
+// evaluatorServer is the interface for the fake evaluator.
+type evaluatorServer interface {
+	Evaluate(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+}
+
+// fakeEvaluator is an in-process remote rule evaluator.
+type fakeEvaluator struct {
+	mu       sync.Mutex
+	requests []*remote.EvaluateRequest
+	respond  func(req *remote.EvaluateRequest) string
+	delay    time.Duration
+}
+
+func (e *fakeEvaluator) Evaluate(ctx context.Context, in *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	req := &remote.EvaluateRequest{}
+	if err := json.Unmarshal(in.Value, req); err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.requests = append(e.requests, req)
+	e.mu.Unlock()
+	time.Sleep(e.delay)
+	return &wrappers.BytesValue{Value: []byte(e.respond(req))}, nil
+}
+
+var evaluatorDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.rules.v1.RuleEvaluator",
+	HandlerType: (*evaluatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Evaluate",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(wrappers.BytesValue)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return srv.(evaluatorServer).Evaluate(ctx, in)
+			},
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "rules",
+}
+
+// startEvaluator starts a fake evaluator, returning its address.
+func startEvaluator(t *testing.T, evaluator *fakeEvaluator) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	server.RegisterService(&evaluatorDesc, evaluator)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []remote.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			params: []remote.Parameter{
+				remote.WithRules(mockrules.New()),
+			},
+			err: "problem with parameters: no address specified",
+		},
+		{
+			name: "RulesMissing",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+			},
+			err: "problem with parameters: no rules specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+				remote.WithRules(mockrules.New()),
+				remote.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "ClientKeyMissing",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+				remote.WithRules(mockrules.New()),
+				remote.WithClientCert([]byte("cert")),
+			},
+			err: "problem with parameters: client certificate and key must be specified together",
+		},
+		{
+			name: "Good",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+				remote.WithRules(mockrules.New()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := remote.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, s.Close(ctx))
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		response string
+		delay    time.Duration
+		res      rules.Result
+	}{
+		{
+			name:     "Approve",
+			response: `{"result":"approve"}`,
+			res:      rules.APPROVED,
+		},
+		{
+			name:     "Deny",
+			response: `{"result":"deny","reason":"outside permitted hours"}`,
+			res:      rules.DENIED,
+		},
+		{
+			name:     "Fail",
+			response: `{"result":"fail","reason":"database unavailable"}`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "ResultMissing",
+			response: `{"reason":"none"}`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "ResultUnknown",
+			response: `{"result":"approved"}`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "UnknownField",
+			response: `{"result":"approve","extra":true}`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "TrailingData",
+			response: `{"result":"approve"}{"result":"deny"}`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "Invalid",
+			response: `approve`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "Slow",
+			response: `{"result":"approve"}`,
+			delay:    200 * time.Millisecond,
+			res:      rules.FAILED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evaluator := &fakeEvaluator{
+				respond: func(req *remote.EvaluateRequest) string { return test.response },
+				delay:   test.delay,
+			}
+			s, err := remote.New(ctx,
+				remote.WithAddress(startEvaluator(t, evaluator)),
+				remote.WithRules(mockrules.New()),
+				remote.WithTimeout(100*time.Millisecond),
+			)
+			require.NoError(t, err)
+			defer s.Close(ctx)
+
+			res := s.OnSignRANDAOReveal(ctx, &rules.ReqMetadata{Account: "Wallet/Account", Client: "client1"}, &rules.SignRANDAORevealData{Epoch: 5})
+			require.Equal(t, test.res, res)
+			require.Len(t, evaluator.requests, 1)
+			require.Equal(t, remote.ActionSignRANDAOReveal, evaluator.requests[0].Action)
+			require.Equal(t, "Wallet/Account", evaluator.requests[0].Metadata.Account)
+			require.Equal(t, "client1", evaluator.requests[0].Metadata.Client)
+			require.Equal(t, map[string]interface{}{"Domain": nil, "Epoch": float64(5)}, evaluator.requests[0].Data)
+		})
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	ctx := context.Background()
+
+	// Obtain an address on which nothing is listening.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	s, err := remote.New(ctx,
+		remote.WithAddress(address),
+		remote.WithRules(mockrules.New()),
+		remote.WithTimeout(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer s.Close(ctx)
+
+	require.Equal(t, rules.FAILED, s.OnLockWallet(ctx, &rules.ReqMetadata{}, &rules.LockWalletData{}))
+}
+
+func TestComposesWithLocalRules(t *testing.T) {
+	ctx := context.Background()
+
+	// Deny attestations with odd target epochs.
+	evaluator := &fakeEvaluator{
+		respond: func(req *remote.EvaluateRequest) string {
+			target := req.Data.(map[string]interface{})["Target"].(map[string]interface{})["Epoch"].(float64)
+			if int(target)%2 == 1 {
+				return `{"result":"deny"}`
+			}
+			return `{"result":"approve"}`
+		},
+	}
+	localRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+	)
+	require.NoError(t, err)
+	defer localRules.Close(ctx)
+	s, err := remote.New(ctx,
+		remote.WithAddress(startEvaluator(t, evaluator)),
+		remote.WithRules(localRules),
+	)
+	require.NoError(t, err)
+	defer s.Close(ctx)
+
+	domain := make([]byte, 32)
+	domain[0] = 0x01
+	attestation := func(source uint64, target uint64) *rules.SignBeaconAttestationData {
+		return &rules.SignBeaconAttestationData{
+			Domain: domain,
+			Source: &rules.Checkpoint{Epoch: source},
+			Target: &rules.Checkpoint{Epoch: target},
+		}
+	}
+	pubKey1 := make([]byte, 48)
+	pubKey1[0] = 0x01
+	pubKey2 := make([]byte, 48)
+	pubKey2[0] = 0x02
+
+	// A request denied remotely does not reach the local rules, so does not advance slashing protection.
+	require.Equal(t, rules.DENIED, s.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey1}, attestation(2, 3)))
+	require.Equal(t, rules.APPROVED, s.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey1}, attestation(1, 2)))
+	// A request approved remotely is still subject to the local rules.
+	require.Equal(t, rules.DENIED, s.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey1}, attestation(1, 2)))
+
+	// Multiple attestations are evaluated individually.
+	results := s.OnSignBeaconAttestations(ctx,
+		[]*rules.ReqMetadata{{PubKey: pubKey1}, {PubKey: pubKey2}, {PubKey: pubKey2}},
+		[]*rules.SignBeaconAttestationData{attestation(2, 4), attestation(2, 5), attestation(0, 2)},
+	)
+	require.Equal(t, []rules.Result{rules.APPROVED, rules.DENIED, rules.APPROVED}, results)
+
+	// An atomic batch with an entry denied remotely is denied without running the local rules, so does not
+	// advance slashing protection for the entries approved remotely.
+	pubKey3 := make([]byte, 48)
+	pubKey3[0] = 0x03
+	results = s.OnSignBeaconAttestations(rules.WithAtomicBatch(ctx),
+		[]*rules.ReqMetadata{{PubKey: pubKey3}, {PubKey: pubKey2}},
+		[]*rules.SignBeaconAttestationData{attestation(4, 6), attestation(4, 7)},
+	)
+	require.Equal(t, []rules.Result{rules.DENIED, rules.DENIED}, results)
+	protection, err := localRules.ExportSlashingProtection(ctx)
+	require.NoError(t, err)
+	_, exists := protection[bytesutil.ToBytes48(pubKey3)]
+	require.False(t, exists)
+	require.Equal(t, int64(2), protection[bytesutil.ToBytes48(pubKey2)].HighestAttestedTargetEpoch)
+}
@@ -13,21 +13,29 @@
 
 package rules
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 // ReqMetadata contains request-specific metadata that can be used by the rules to help decide if a request should
 // succeed or be denied.
 type ReqMetadata struct {
-	Account string
-	PubKey  []byte
-	IP      string
-	Client  string
+	// Wallet is the name of the wallet to which the request refers, if any.
+	Wallet    string
+	Account   string
+	PubKey    []byte
+	IP        string
+	Client    string
+	RequestID string
 }
 
 // SignData is passed to 'Sign' rules.
 type SignData struct {
 	Domain []byte
 	Data   []byte
+	// Epoch is the epoch to which the data refers, if supplied by the client.
+	Epoch *uint64
 }
 
 // SignBeaconAttestationData is passed to 'OnSignBeaconAttestation' rules.
@@ -54,11 +62,98 @@ type SignBeaconProposalData struct {
 	ParentRoot    []byte
 	StateRoot     []byte
 	BodyRoot      []byte
+	// Graffiti is the graffiti of the proposed block, if supplied by the client.
+	// It is not part of the signed data so cannot be verified against the body root.
+	Graffiti []byte
+}
+
+// SignRANDAORevealData is passed to 'OnSignRANDAOReveal' rules.
+type SignRANDAORevealData struct {
+	Domain []byte
+	Epoch  uint64
+}
+
+// SignAggregateAndProofData is passed to 'OnSignAggregateAndProof' rules.
+type SignAggregateAndProofData struct {
+	Domain          []byte
+	AggregatorIndex uint64
+	Slot            uint64
+	AggregateRoot   []byte
+}
+
+// SignAggregationSlotData is passed to 'OnSignAggregationSlot' rules.
+type SignAggregationSlotData struct {
+	Domain []byte
+	Slot   uint64
+}
+
+// SignSyncCommitteeMessageData is passed to 'OnSignSyncCommitteeMessage' rules.
+type SignSyncCommitteeMessageData struct {
+	Domain          []byte
+	Slot            uint64
+	BeaconBlockRoot []byte
+}
+
+// SignSyncCommitteeSelectionProofData is passed to 'OnSignSyncCommitteeSelectionProof' rules.
+type SignSyncCommitteeSelectionProofData struct {
+	Domain            []byte
+	Slot              uint64
+	SubcommitteeIndex uint64
+}
+
+// SignSyncCommitteeContributionAndProofData is passed to 'OnSignSyncCommitteeContributionAndProof' rules.
+type SignSyncCommitteeContributionAndProofData struct {
+	Domain            []byte
+	AggregatorIndex   uint64
+	Slot              uint64
+	BeaconBlockRoot   []byte
+	SubcommitteeIndex uint64
+	// AggregationBits is the bitvector of the subcommittee members included in the contribution.
+	AggregationBits []byte
+	// Signature is the aggregate signature of the contribution.
+	Signature []byte
+	// SelectionProof is the sync committee selection proof of the aggregator.
+	SelectionProof []byte
+}
+
+// SignVoluntaryExitData is passed to 'OnSignVoluntaryExit' rules.
+type SignVoluntaryExitData struct {
+	Domain         []byte
+	Epoch          uint64
+	ValidatorIndex uint64
+}
+
+// SignBLSToExecutionChangeData is passed to 'OnSignBLSToExecutionChange' rules.
+type SignBLSToExecutionChangeData struct {
+	Domain             []byte
+	ValidatorIndex     uint64
+	ToExecutionAddress []byte
+}
+
+// SignValidatorRegistrationData is passed to 'OnSignValidatorRegistration' rules.
+type SignValidatorRegistrationData struct {
+	Domain       []byte
+	FeeRecipient []byte
+	GasLimit     uint64
+	// Timestamp is the time of the registration, in seconds since the Unix epoch.
+	Timestamp uint64
+}
+
+// SignDepositData is passed to 'OnSignDeposit' rules.
+type SignDepositData struct {
+	Domain                []byte
+	WithdrawalCredentials []byte
+	// Amount is the amount of the deposit, in Gwei.
+	Amount uint64
 }
 
 // AccessAccountData is passed to 'OnAccessAccount' rules.
 type AccessAccountData struct {
 	Paths []string
+	// PageToken is the token of the page of accounts being listed, or empty for the first page.
+	PageToken string
+	// PageSize is the maximum number of accounts in the page being listed, or 0 if not paginated.
+	PageSize uint32
 }
 
 // LockWalletData is passed to 'OnLockWallet' rules.
@@ -73,17 +168,49 @@ type LockAccountData struct{}
 // UnlockAccountData is passed to 'OnUnlockAccount' rules.
 type UnlockAccountData struct{}
 
+// PauseSigningData is passed to 'OnPauseSigning' rules.
+type PauseSigningData struct{}
+
+// ResumeSigningData is passed to 'OnResumeSigning' rules.
+type ResumeSigningData struct{}
+
+// ExportAccountData is passed to 'OnExportAccount' rules.
+type ExportAccountData struct{}
+
+// RecoverAccountData is passed to 'OnRecoverAccount' rules.
+type RecoverAccountData struct {
+	// Coordinator is the ID of the participant that is recovering the private key of the distributed account.
+	Coordinator uint64
+}
+
 // CreateAccountData is passed to 'OnCreateAccount' rules.
-type CreateAccountData struct{}
+type CreateAccountData struct {
+	// WalletName is the name of the wallet in which the account will be created.
+	WalletName string
+	// Path is the derivation path of the account, if supplied by the client.
+	// If not supplied the wallet will select the path itself.
+	Path string
+	// ExistingPaths are the derivation paths of the accounts already in the wallet, if a path is supplied.
+	ExistingPaths []string
+	// Participants is the number of participants in a distributed account, or 0 or 1 for a standard account.
+	Participants     uint32
+	SigningThreshold uint32
+}
 
 // Result represents the result of running a set of rules.
 type Result int
 
 // Possible results of running a set of rules.
 const (
+	// UNKNOWN is the zero value, and is never a valid result for a rule; it is treated as FAILED.
 	UNKNOWN Result = iota
+	// APPROVED means that the request is allowed.
 	APPROVED
+	// DENIED means that the request was refused by policy, for example because it is slashable.
+	// Retrying the same request will not help.
 	DENIED
+	// FAILED means that the request could not be evaluated, for example because the slashing
+	// protection store was unavailable.  Retrying the same request may succeed.
 	FAILED
 )
 
@@ -117,6 +244,27 @@ type Service interface {
 	OnSignBeaconAttestations(ctx context.Context, metadata []*ReqMetadata, req []*SignBeaconAttestationData) []Result
 	// OnSignBeaconProposal is called when a request to sign a beacon block proposal needs to be approved.
 	OnSignBeaconProposal(ctx context.Context, metadata *ReqMetadata, req *SignBeaconProposalData) Result
+	// OnSignRANDAOReveal is called when a request to sign a RANDAO reveal needs to be approved.
+	OnSignRANDAOReveal(ctx context.Context, metadata *ReqMetadata, req *SignRANDAORevealData) Result
+	// OnSignAggregateAndProof is called when a request to sign an aggregate and proof needs to be approved.
+	OnSignAggregateAndProof(ctx context.Context, metadata *ReqMetadata, req *SignAggregateAndProofData) Result
+	// OnSignAggregationSlot is called when a request to sign an aggregation slot selection proof needs to be approved.
+	OnSignAggregationSlot(ctx context.Context, metadata *ReqMetadata, req *SignAggregationSlotData) Result
+	// OnSignSyncCommitteeMessage is called when a request to sign a sync committee message needs to be approved.
+	OnSignSyncCommitteeMessage(ctx context.Context, metadata *ReqMetadata, req *SignSyncCommitteeMessageData) Result
+	// OnSignSyncCommitteeSelectionProof is called when a request to sign a sync committee selection proof needs to be approved.
+	OnSignSyncCommitteeSelectionProof(ctx context.Context, metadata *ReqMetadata, req *SignSyncCommitteeSelectionProofData) Result
+	// OnSignSyncCommitteeContributionAndProof is called when a request to sign a sync committee contribution and proof
+	// needs to be approved.
+	OnSignSyncCommitteeContributionAndProof(ctx context.Context, metadata *ReqMetadata, req *SignSyncCommitteeContributionAndProofData) Result
+	// OnSignVoluntaryExit is called when a request to sign a voluntary exit needs to be approved.
+	OnSignVoluntaryExit(ctx context.Context, metadata *ReqMetadata, req *SignVoluntaryExitData) Result
+	// OnSignBLSToExecutionChange is called when a request to sign a BLS to execution change needs to be approved.
+	OnSignBLSToExecutionChange(ctx context.Context, metadata *ReqMetadata, req *SignBLSToExecutionChangeData) Result
+	// OnSignValidatorRegistration is called when a request to sign a validator registration needs to be approved.
+	OnSignValidatorRegistration(ctx context.Context, metadata *ReqMetadata, req *SignValidatorRegistrationData) Result
+	// OnSignDeposit is called when a request to sign deposit data needs to be approved.
+	OnSignDeposit(ctx context.Context, metadata *ReqMetadata, req *SignDepositData) Result
 	// OnLockWallet is called when a request to lock a wallet needs to be approved.
 	OnLockWallet(ctx context.Context, metadata *ReqMetadata, req *LockWalletData) Result
 	// OnUnlockWallet is called when a request to unlock a wallet needs to be approved.
@@ -125,10 +273,27 @@ type Service interface {
 	OnLockAccount(ctx context.Context, metadata *ReqMetadata, req *LockAccountData) Result
 	// OnUnlockAccount is called when a request to unlock an account needs to be approved.
 	OnUnlockAccount(ctx context.Context, metadata *ReqMetadata, req *UnlockAccountData) Result
+	// OnPauseSigning is called when a request to pause signing for an account needs to be approved.
+	OnPauseSigning(ctx context.Context, metadata *ReqMetadata, req *PauseSigningData) Result
+	// OnResumeSigning is called when a request to resume signing for an account needs to be approved.
+	OnResumeSigning(ctx context.Context, metadata *ReqMetadata, req *ResumeSigningData) Result
+	// OnExportAccount is called when a request to export an account needs to be approved.
+	OnExportAccount(ctx context.Context, metadata *ReqMetadata, req *ExportAccountData) Result
+	// OnRecoverAccount is called when a request to release a share of a distributed account for the recovery of its
+	// private key needs to be approved.
+	OnRecoverAccount(ctx context.Context, metadata *ReqMetadata, req *RecoverAccountData) Result
 	// OnCreateAccount is called when a request to create an account needs to be approved.
 	OnCreateAccount(ctx context.Context, metadata *ReqMetadata, req *CreateAccountData) Result
 	// ExportSlashingProtection exports the slashing protection data.
 	ExportSlashingProtection(ctx context.Context) (map[[48]byte]*SlashingProtection, error)
+	// BackupSlashingProtection writes a consistent snapshot of the slashing protection data.
+	BackupSlashingProtection(ctx context.Context, w io.Writer) error
+	// ParseSlashingProtectionBackup parses a snapshot written by BackupSlashingProtection.
+	ParseSlashingProtectionBackup(ctx context.Context, r io.Reader) (map[[48]byte]*SlashingProtection, error)
 	// ImportSlashingProtection impports the slashing protection data.
 	ImportSlashingProtection(ctx context.Context, protection map[[48]byte]*SlashingProtection) error
+	// MergeSlashingProtection merges the slashing protection data in to the existing data, and is safe to call
+	// while signing.  Existing data for a key is only replaced by data that is at least as high for all values,
+	// so high-water marks are never lowered.  It returns the keys whose existing data was retained.
+	MergeSlashingProtection(ctx context.Context, protection map[[48]byte]*SlashingProtection) ([][48]byte, error)
 }
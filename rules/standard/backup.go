@@ -0,0 +1,59 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/pkg/errors"
+)
+
+// BackupSlashingProtection writes a consistent snapshot of the slashing protection data.
+// The snapshot is taken from a single read transaction, so does not block signing.
+func (s *Service) BackupSlashingProtection(ctx context.Context, w io.Writer) error {
+	if err := s.store.Backup(ctx, w); err != nil {
+		return errors.Wrap(err, "failed to back up store")
+	}
+	return nil
+}
+
+// ParseSlashingProtectionBackup parses a snapshot written by BackupSlashingProtection.
+// The snapshot is loaded in to a temporary store, so does not affect the service's own data.
+func (s *Service) ParseSlashingProtectionBackup(ctx context.Context, r io.Reader) (map[[48]byte]*rules.SlashingProtection, error) {
+	base, err := ioutil.TempDir("", "dirk-backup")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(base)
+
+	store, err := NewStore(base)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary store")
+	}
+	defer store.Close(ctx)
+
+	if err := store.Load(ctx, r); err != nil {
+		return nil, errors.Wrap(err, "failed to load backup")
+	}
+	entries, err := store.FetchAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain data from backup")
+	}
+
+	return slashingProtectionFromEntries(entries)
+}
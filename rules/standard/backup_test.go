@@ -0,0 +1,99 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupSlashingProtection(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	service, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+	)
+	require.NoError(t, err)
+
+	var pubKey [48]byte
+	copy(pubKey[:], _byteStr(t, "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f"))
+	metadata := &rules.ReqMetadata{PubKey: pubKey[:]}
+	attestationDomain := _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000")
+	proposalDomain := _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000")
+
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{
+		Domain: attestationDomain,
+		Source: &rules.Checkpoint{Epoch: 1},
+		Target: &rules.Checkpoint{Epoch: 2},
+	}))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{
+		Domain: proposalDomain,
+		Slot:   10,
+	}))
+
+	// Snapshot.
+	backup := new(bytes.Buffer)
+	require.NoError(t, service.BackupSlashingProtection(ctx, backup))
+
+	// Mutate.
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{
+		Domain: attestationDomain,
+		Source: &rules.Checkpoint{Epoch: 2},
+		Target: &rules.Checkpoint{Epoch: 3},
+	}))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{
+		Domain: proposalDomain,
+		Slot:   20,
+	}))
+
+	// Snapshot is unaffected by the mutation.
+	snapshot, err := service.ParseSlashingProtectionBackup(ctx, bytes.NewReader(backup.Bytes()))
+	require.NoError(t, err)
+	require.Contains(t, snapshot, pubKey)
+	assert.Equal(t, int64(1), snapshot[pubKey].HighestAttestedSourceEpoch)
+	assert.Equal(t, int64(2), snapshot[pubKey].HighestAttestedTargetEpoch)
+	assert.Equal(t, int64(10), snapshot[pubKey].HighestProposedSlot)
+
+	// Live data reflects the mutation.
+	current, err := service.ExportSlashingProtection(ctx)
+	require.NoError(t, err)
+	require.Contains(t, current, pubKey)
+	assert.Equal(t, int64(2), current[pubKey].HighestAttestedSourceEpoch)
+	assert.Equal(t, int64(3), current[pubKey].HighestAttestedTargetEpoch)
+	assert.Equal(t, int64(20), current[pubKey].HighestProposedSlot)
+}
+
+func TestParseSlashingProtectionBackupInvalid(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	service, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+	)
+	require.NoError(t, err)
+
+	_, err = service.ParseSlashingProtectionBackup(ctx, bytes.NewReader([]byte("not a backup")))
+	require.Error(t, err)
+}
@@ -0,0 +1,144 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/pkg/errors"
+)
+
+// errStoreBreakerOpen is returned in place of calling the store when the breaker is open.
+var errStoreBreakerOpen = errors.New("store circuit breaker is open")
+
+// Breaker states.
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// storeBreaker is a circuit breaker around operations against the store.
+// It opens after a number of consecutive failures, refusing operations until the cool-down
+// has passed, after which a single probe operation is allowed through.  A successful probe
+// closes the breaker; a failed probe opens it again.
+type storeBreaker struct {
+	mutex     sync.Mutex
+	monitor   metrics.RulesMonitor
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+	state     string
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// newStoreBreaker creates a new store breaker in the closed state.
+func newStoreBreaker(monitor metrics.RulesMonitor, threshold int, cooldown time.Duration) *storeBreaker {
+	b := &storeBreaker{
+		monitor:   monitor,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     breakerClosed,
+	}
+	monitor.StoreBreakerState(breakerClosed)
+	return b
+}
+
+// allow returns an error if the operation should not be attempted.
+func (b *storeBreaker) allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return errStoreBreakerOpen
+		}
+		b.transition(breakerHalfOpen)
+		b.probing = true
+		return nil
+	case breakerHalfOpen:
+		if b.probing {
+			// Only a single probe is allowed through at a time.
+			return errStoreBreakerOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// record records the result of an operation that was allowed.
+func (b *storeBreaker) record(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		if b.state == breakerHalfOpen {
+			b.probing = false
+			b.transition(breakerClosed)
+		}
+		return
+	}
+
+	switch b.state {
+	case breakerHalfOpen:
+		b.probing = false
+		b.openedAt = b.now()
+		b.transition(breakerOpen)
+	case breakerClosed:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openedAt = b.now()
+			b.transition(breakerOpen)
+		}
+	}
+}
+
+// transition moves the breaker to a new state.  The mutex must be held.
+func (b *storeBreaker) transition(state string) {
+	log.Warn().Str("from", b.state).Str("to", state).Int("failures", b.failures).Msg("Store circuit breaker changed state")
+	b.state = state
+	b.failures = 0
+	b.monitor.StoreBreakerState(state)
+}
+
+// withStoreBreaker carries out an operation against the store through the circuit breaker, if
+// configured, retrying transient errors as per withStoreRetry.
+func (s *Service) withStoreBreaker(ctx context.Context, operation string, f func() error) error {
+	var err error
+	switch {
+	case s.storeBreaker == nil:
+		err = s.withStoreRetry(ctx, operation, f)
+	case s.storeBreaker.allow() != nil:
+		log.Debug().Str("operation", operation).Msg("Store circuit breaker open; refusing operation")
+		err = errStoreBreakerOpen
+	default:
+		err = s.withStoreRetry(ctx, operation, f)
+		s.storeBreaker.record(err)
+	}
+	if err != nil {
+		rules.RecordReason(ctx, rules.ReasonStoreUnavailable)
+	}
+	return err
+}
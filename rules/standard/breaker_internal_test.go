@@ -0,0 +1,103 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// breakerMonitor records store breaker states.
+type breakerMonitor struct {
+	states []string
+}
+
+func (m *breakerMonitor) StoreRetried(operation string) {}
+
+func (m *breakerMonitor) SlashingPrevented(action string) {}
+
+func (m *breakerMonitor) StoreBreakerState(state string) {
+	m.states = append(m.states, state)
+}
+
+func TestStoreBreaker(t *testing.T) {
+	ctx := context.Background()
+	monitor := &breakerMonitor{}
+	now := time.Unix(1600000000, 0)
+	s := &Service{
+		monitor:          monitor,
+		storeMaxAttempts: 1,
+		storeBreaker:     newStoreBreaker(monitor, 2, time.Minute),
+	}
+	s.storeBreaker.now = func() time.Time { return now }
+
+	calls := 0
+	failing := func() error {
+		calls++
+		return syscall.ECONNREFUSED
+	}
+	succeeding := func() error {
+		calls++
+		return nil
+	}
+
+	// Closed; failures below the threshold reach the store.
+	require.EqualError(t, s.withStoreBreaker(ctx, "test", failing), "connection refused")
+	require.Equal(t, breakerClosed, s.storeBreaker.state)
+	// A success resets the failure count.
+	require.NoError(t, s.withStoreBreaker(ctx, "test", succeeding))
+	require.EqualError(t, s.withStoreBreaker(ctx, "test", failing), "connection refused")
+	require.Equal(t, breakerClosed, s.storeBreaker.state)
+
+	// Closed -> open once the threshold is reached.
+	require.EqualError(t, s.withStoreBreaker(ctx, "test", failing), "connection refused")
+	require.Equal(t, breakerOpen, s.storeBreaker.state)
+	require.Equal(t, 4, calls)
+
+	// Open; operations fail without reaching the store.
+	require.Equal(t, errStoreBreakerOpen, s.withStoreBreaker(ctx, "test", succeeding))
+	require.Equal(t, 4, calls)
+
+	// Open -> half-open after the cool-down; a failed probe opens the breaker again.
+	now = now.Add(time.Minute)
+	require.EqualError(t, s.withStoreBreaker(ctx, "test", failing), "connection refused")
+	require.Equal(t, breakerOpen, s.storeBreaker.state)
+	require.Equal(t, errStoreBreakerOpen, s.withStoreBreaker(ctx, "test", succeeding))
+	require.Equal(t, 5, calls)
+
+	// Only a single probe is allowed through while half-open.
+	now = now.Add(time.Minute)
+	require.NoError(t, s.storeBreaker.allow())
+	require.Equal(t, breakerHalfOpen, s.storeBreaker.state)
+	require.Equal(t, errStoreBreakerOpen, s.storeBreaker.allow())
+
+	// Half-open -> closed on a successful probe.
+	s.storeBreaker.record(nil)
+	require.Equal(t, breakerClosed, s.storeBreaker.state)
+	require.NoError(t, s.withStoreBreaker(ctx, "test", succeeding))
+	require.Equal(t, 6, calls)
+
+	require.Equal(t, []string{
+		breakerClosed,
+		breakerOpen,
+		breakerHalfOpen,
+		breakerOpen,
+		breakerHalfOpen,
+		breakerClosed,
+	}, monitor.states)
+}
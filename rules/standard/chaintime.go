@@ -0,0 +1,79 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+// currentSlot returns the current slot, based on the clock.
+func (s *Service) currentSlot() uint64 {
+	return s.clock.CurrentSlot()
+}
+
+// currentEpoch returns the current epoch, based on the clock.
+func (s *Service) currentEpoch() uint64 {
+	return s.clock.CurrentEpoch()
+}
+
+// finalizedEpoch returns the most recent epoch considered finalized, being the configured finality lag behind
+// the current epoch.  It returns 0 if the genesis time is not known.
+func (s *Service) finalizedEpoch() uint64 {
+	if s.genesisTime.IsZero() {
+		return 0
+	}
+	currentEpoch := s.currentEpoch()
+	if currentEpoch <= s.pruneFinalityLag {
+		return 0
+	}
+	return currentEpoch - s.pruneFinalityLag
+}
+
+// slotTooFarInFuture returns true if the slot is more than maxFutureSlots beyond the current slot.
+func (s *Service) slotTooFarInFuture(slot uint64, maxFutureSlots uint64) bool {
+	if maxFutureSlots == 0 {
+		return false
+	}
+	currentSlot := s.currentSlot()
+	return slot > currentSlot && slot-currentSlot > maxFutureSlots
+}
+
+// epochTooFarInFuture returns true if the epoch is more than maxFutureEpochs beyond the current epoch.
+func (s *Service) epochTooFarInFuture(epoch uint64, maxFutureEpochs uint64) bool {
+	if maxFutureEpochs == 0 {
+		return false
+	}
+	currentEpoch := s.currentEpoch()
+	return epoch > currentEpoch && epoch-currentEpoch > maxFutureEpochs
+}
+
+// epochOutsideWindow returns true if the epoch is more than window epochs either side of the current epoch.
+func (s *Service) epochOutsideWindow(epoch uint64, window uint64) bool {
+	if window == 0 {
+		return false
+	}
+	currentEpoch := s.currentEpoch()
+	if epoch > currentEpoch {
+		return epoch-currentEpoch > window
+	}
+	return currentEpoch-epoch > window
+}
+
+// slotOutsideWindow returns true if the slot is more than window slots either side of the current slot.
+func (s *Service) slotOutsideWindow(slot uint64, window uint64) bool {
+	if window == 0 {
+		return false
+	}
+	currentSlot := s.currentSlot()
+	if slot > currentSlot {
+		return slot-currentSlot > window
+	}
+	return currentSlot-slot > window
+}
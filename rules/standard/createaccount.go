@@ -15,15 +15,109 @@ package standard
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
 )
 
 // OnCreateAccount is called when a request to create an account needs to be approved.
 func (s *Service) OnCreateAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.CreateAccountData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnCreateAccount")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnCreateAccount")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "create account").Logger()
+
+	// Requests without participants are for standard accounts.
+	participants := req.Participants
+	if participants == 0 {
+		participants = 1
+	}
+	if req.SigningThreshold > participants {
+		log.Warn().Uint32("participants", participants).Uint32("signing_threshold", req.SigningThreshold).Msg("Not creating account with signing threshold above participants")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	if req.Path == "" {
+		// Wallet will select the path itself.
+		return rules.APPROVED
+	}
+	log = log.With().Str("path", req.Path).Logger()
+
+	if participants != 1 {
+		log.Warn().Msg("Not creating distributed account with explicit path")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	path, err := parsePath(req.Path)
+	if err != nil {
+		log.Warn().Err(err).Msg("Not creating account with invalid path")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The client may be restricted to a set of paths.
+	if permittedPaths, exists := s.currentPolicy().createAccountPaths[metadata.Client]; exists {
+		permitted := false
+		for i := range permittedPaths {
+			if pathHasPrefix(path, permittedPaths[i]) {
+				permitted = true
+				break
+			}
+		}
+		if !permitted {
+			log.Warn().Msg("Not creating account with path not permitted for client")
+			rules.RecordReason(ctx, rules.ReasonPolicy)
+			return rules.DENIED
+		}
+	}
+
+	// The path must not already be used by an account in the wallet.
+	for i := range req.ExistingPaths {
+		existingPath, err := parsePath(req.ExistingPaths[i])
+		if err != nil {
+			continue
+		}
+		if len(existingPath) == len(path) && pathHasPrefix(path, existingPath) {
+			log.Warn().Msg("Not creating account with path already in use")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.DENIED
+		}
+	}
 
 	return rules.APPROVED
 }
+
+// parsePath parses a derivation path of the form m/12381/3600/0/0 in to its components.
+func parsePath(input string) ([]uint32, error) {
+	parts := strings.Split(input, "/")
+	if parts[0] != "m" {
+		return nil, errors.New("path must start with m")
+	}
+	path := make([]uint32, len(parts)-1)
+	for i := 1; i < len(parts); i++ {
+		component, err := strconv.ParseUint(parts[i], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path component %q", parts[i])
+		}
+		path[i-1] = uint32(component)
+	}
+	return path, nil
+}
+
+// pathHasPrefix returns true if the path is at or below the prefix.
+func pathHasPrefix(path []uint32, prefix []uint32) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
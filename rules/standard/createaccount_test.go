@@ -34,6 +34,9 @@ func TestCreateAccount(t *testing.T) {
 	testRules, err := standardrules.New(ctx,
 		standardrules.WithStoragePath(base),
 		standardrules.WithAdminIPs([]string{"1.2.3.4", "5.6.7.8"}),
+		standardrules.WithCreateAccountPaths(map[string][]string{
+			"client1": {"m/12381/3600/1"},
+		}),
 	)
 	require.NoError(t, err)
 
@@ -49,6 +52,121 @@ func TestCreateAccount(t *testing.T) {
 			req:      &rules.CreateAccountData{},
 			res:      rules.APPROVED,
 		},
+		{
+			name:     "ThresholdTooHigh",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.CreateAccountData{
+				Participants:     3,
+				SigningThreshold: 4,
+			},
+			res: rules.DENIED,
+		},
+		{
+			name:     "GoodSingleParticipant",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.CreateAccountData{
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name:     "GoodDistributed",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.CreateAccountData{
+				Participants:     3,
+				SigningThreshold: 2,
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name:     "PathInvalid",
+			metadata: &rules.ReqMetadata{Client: "client2"},
+			req: &rules.CreateAccountData{
+				WalletName:       "Wallet 1",
+				Path:             "m/12381/x/0/0",
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+			res: rules.DENIED,
+		},
+		{
+			name:     "PathDistributed",
+			metadata: &rules.ReqMetadata{Client: "client2"},
+			req: &rules.CreateAccountData{
+				WalletName:       "Wallet 1",
+				Path:             "m/12381/3600/0/0",
+				Participants:     3,
+				SigningThreshold: 2,
+			},
+			res: rules.DENIED,
+		},
+		{
+			name:     "Path",
+			metadata: &rules.ReqMetadata{Client: "client2"},
+			req: &rules.CreateAccountData{
+				WalletName:       "Wallet 1",
+				Path:             "m/12381/3600/0/0",
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name:     "PathNoParticipants",
+			metadata: &rules.ReqMetadata{Client: "client2"},
+			req: &rules.CreateAccountData{
+				WalletName: "Wallet 1",
+				Path:       "m/12381/3600/0/0",
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name:     "PathDuplicate",
+			metadata: &rules.ReqMetadata{Client: "client2"},
+			req: &rules.CreateAccountData{
+				WalletName:       "Wallet 1",
+				Path:             "m/12381/3600/0/0",
+				ExistingPaths:    []string{"m/12381/3600/1/0", "m/12381/3600/0/0"},
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+			res: rules.DENIED,
+		},
+		{
+			name:     "PathUnused",
+			metadata: &rules.ReqMetadata{Client: "client2"},
+			req: &rules.CreateAccountData{
+				WalletName:       "Wallet 1",
+				Path:             "m/12381/3600/0/0",
+				ExistingPaths:    []string{"m/12381/3600/0", "m/12381/3600/0/0/0"},
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name:     "PathOutOfPolicy",
+			metadata: &rules.ReqMetadata{Client: "client1"},
+			req: &rules.CreateAccountData{
+				WalletName:       "Wallet 1",
+				Path:             "m/12381/3600/2/0",
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+			res: rules.DENIED,
+		},
+		{
+			name:     "PathInPolicy",
+			metadata: &rules.ReqMetadata{Client: "client1"},
+			req: &rules.CreateAccountData{
+				WalletName:       "Wallet 1",
+				Path:             "m/12381/3600/1/0",
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+			res: rules.APPROVED,
+		},
 	}
 
 	for _, test := range tests {
@@ -58,3 +176,18 @@ func TestCreateAccount(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateAccountPathsParameter(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithCreateAccountPaths(map[string][]string{
+			"client1": {"12381/3600"},
+		}),
+	)
+	assert.EqualError(t, err, `problem with parameters: invalid create account path "12381/3600" for client client1: path must start with m`)
+}
@@ -0,0 +1,51 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"fmt"
+)
+
+// newDeniedPubKeys indexes checked denied public keys.
+func newDeniedPubKeys(pubKeys []string) map[[48]byte]bool {
+	res := make(map[[48]byte]bool, len(pubKeys))
+	for _, pubKeyStr := range pubKeys {
+		// Public keys have already been checked in checkPolicyParameters.
+		pubKey, _ := parseOverridePubKey(pubKeyStr)
+		var key [48]byte
+		copy(key[:], pubKey)
+		res[key] = true
+	}
+	return res
+}
+
+// checkDeniedPubKeys checks denied public keys.
+func checkDeniedPubKeys(pubKeys []string) error {
+	for _, pubKeyStr := range pubKeys {
+		if _, isPubKey := parseOverridePubKey(pubKeyStr); !isPubKey {
+			return fmt.Errorf("invalid denied public key %q", pubKeyStr)
+		}
+	}
+	return nil
+}
+
+// pubKeyDenied returns true if all signing is refused for the given public key.
+func (s *Service) pubKeyDenied(pubKey []byte) bool {
+	if len(pubKey) != 48 {
+		return false
+	}
+	var key [48]byte
+	copy(key[:], pubKey)
+	return s.currentPolicy().deniedPubKeys[key]
+}
@@ -0,0 +1,64 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeniedPubKeys(t *testing.T) {
+	ctx := context.Background()
+
+	pubKeyStr := "a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c"
+	otherPubKeyStr := "b89bebc699769726a318c8e9971bd3171297c61aea4a6578a7a4f94b547dcba5bac16a89108b6b6a1fe3695d1a874a0b"
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithDeniedPubKeys([]string{"0x01"}),
+	)
+	require.EqualError(t, err, `problem with parameters: invalid denied public key "0x01"`)
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithDeniedPubKeys([]string{"0x" + pubKeyStr}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := _byteStr(t, "0200000000000000000000000000000000000000000000000000000000000000")
+	signRANDAOReveal := func(pubKey []byte, epoch uint64) rules.Result {
+		return testRules.OnSignRANDAOReveal(ctx, &rules.ReqMetadata{Account: "account", PubKey: pubKey}, &rules.SignRANDAORevealData{
+			Domain: domain,
+			Epoch:  epoch,
+		})
+	}
+
+	require.Equal(t, rules.DENIED, signRANDAOReveal(_byteStr(t, pubKeyStr), 1))
+	require.Equal(t, rules.APPROVED, signRANDAOReveal(_byteStr(t, otherPubKeyStr), 1))
+
+	// An invalid update leaves the existing deny-list in force.
+	require.EqualError(t, testRules.UpdatePolicy(ctx, standardrules.WithDeniedPubKeys([]string{pubKeyStr})),
+		`problem with policy parameters: invalid denied public key "`+pubKeyStr+`"`)
+	require.Equal(t, rules.DENIED, signRANDAOReveal(_byteStr(t, pubKeyStr), 2))
+
+	// Updating the policy replaces the deny-list.
+	require.NoError(t, testRules.UpdatePolicy(ctx, standardrules.WithDeniedPubKeys([]string{"0x" + otherPubKeyStr})))
+	require.Equal(t, rules.APPROVED, signRANDAOReveal(_byteStr(t, pubKeyStr), 3))
+	require.Equal(t, rules.DENIED, signRANDAOReveal(_byteStr(t, otherPubKeyStr), 4))
+}
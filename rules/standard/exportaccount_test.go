@@ -0,0 +1,48 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAccount(t *testing.T) {
+	ctx := context.Background()
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	pubKey := make([]byte, 48)
+	pubKey[0] = 0x01
+	metadata := &rules.ReqMetadata{PubKey: pubKey}
+
+	// Export is refused while signing is active.
+	require.Equal(t, rules.DENIED, testRules.OnExportAccount(ctx, metadata, &rules.ExportAccountData{}))
+
+	// Export is allowed once signing is paused.
+	require.Equal(t, rules.APPROVED, testRules.OnPauseSigning(ctx, metadata, &rules.PauseSigningData{}))
+	require.Equal(t, rules.APPROVED, testRules.OnExportAccount(ctx, metadata, &rules.ExportAccountData{}))
+
+	// Export is refused again once signing is resumed.
+	require.Equal(t, rules.APPROVED, testRules.OnResumeSigning(ctx, metadata, &rules.ResumeSigningData{}))
+	require.Equal(t, rules.DENIED, testRules.OnExportAccount(ctx, metadata, &rules.ExportAccountData{}))
+}
@@ -0,0 +1,104 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Fork is a fork in the schedule of the network.
+type Fork struct {
+	// Version is the fork version.
+	Version []byte
+	// Epoch is the epoch at which the fork activates.
+	Epoch uint64
+}
+
+// forkSchedule is the schedule of forks, ordered by activation epoch.
+type forkSchedule struct {
+	epochs        []uint64
+	forkDataRoots [][]byte
+}
+
+// newForkSchedule creates a fork schedule from checked forks.  It returns nil if there are no forks.
+func newForkSchedule(genesisValidatorsRoot []byte, forks []*Fork) (*forkSchedule, error) {
+	if len(forks) == 0 {
+		return nil, nil
+	}
+
+	sorted := make([]*Fork, len(forks))
+	copy(sorted, forks)
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i].Epoch < sorted[j].Epoch
+	})
+
+	versions := make([][]byte, len(sorted))
+	schedule := &forkSchedule{
+		epochs: make([]uint64, len(sorted)),
+	}
+	for i := range sorted {
+		versions[i] = sorted[i].Version
+		schedule.epochs[i] = sorted[i].Epoch
+	}
+	forkDataRoots, err := calculateForkDataRoots(genesisValidatorsRoot, versions)
+	if err != nil {
+		return nil, err
+	}
+	schedule.forkDataRoots = forkDataRoots
+
+	return schedule, nil
+}
+
+// checkForkSchedule checks the fork schedule parameters.
+func checkForkSchedule(parameters *parameters) error {
+	if len(parameters.forkSchedule) == 0 {
+		return nil
+	}
+	if parameters.genesisValidatorsRoot == nil {
+		return errors.New("no genesis validators root specified for fork schedule")
+	}
+	epochs := make(map[uint64]bool)
+	for _, fork := range parameters.forkSchedule {
+		if fork == nil {
+			return errors.New("nil fork in fork schedule")
+		}
+		if len(fork.Version) != 4 {
+			return fmt.Errorf("fork schedule version %#x must be 4 bytes", fork.Version)
+		}
+		if epochs[fork.Epoch] {
+			return fmt.Errorf("multiple forks in fork schedule at epoch %d", fork.Epoch)
+		}
+		epochs[fork.Epoch] = true
+	}
+	return nil
+}
+
+// checkForkDomain checks that the domain is for the fork that is active at the given epoch.
+func (s *Service) checkForkDomain(domain []byte, epoch uint64) bool {
+	if len(domain) != 32 {
+		return false
+	}
+	// Find the last fork that activated at or before the epoch.
+	active := sort.Search(len(s.forkSchedule.epochs), func(i int) bool {
+		return s.forkSchedule.epochs[i] > epoch
+	}) - 1
+	if active < 0 {
+		// No fork is active at the epoch.
+		return false
+	}
+	return bytes.Equal(domain[4:], s.forkSchedule.forkDataRoots[active][:28])
+}
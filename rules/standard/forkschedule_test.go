@@ -0,0 +1,196 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestForkScheduleParameters(t *testing.T) {
+	ctx := context.Background()
+	genesisValidatorsRoot := _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95")
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithForkSchedule([]*standardrules.Fork{{Version: _byteStr(t, "00000000")}}),
+	)
+	require.EqualError(t, err, "problem with parameters: no genesis validators root specified for fork schedule")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+		standardrules.WithForkVersions([][]byte{_byteStr(t, "00000000")}),
+		standardrules.WithForkSchedule([]*standardrules.Fork{{Version: _byteStr(t, "0000")}}),
+	)
+	require.EqualError(t, err, "problem with parameters: fork schedule version 0x0000 must be 4 bytes")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+		standardrules.WithForkVersions([][]byte{_byteStr(t, "00000000")}),
+		standardrules.WithForkSchedule([]*standardrules.Fork{
+			{Version: _byteStr(t, "00000000"), Epoch: 5},
+			{Version: _byteStr(t, "01000000"), Epoch: 5},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: multiple forks in fork schedule at epoch 5")
+}
+
+func TestForkSchedule(t *testing.T) {
+	ctx := context.Background()
+	genesisValidatorsRoot := _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95")
+	phase0Version := _byteStr(t, "00000000")
+	altairVersion := _byteStr(t, "01000000")
+	otherVersion := _byteStr(t, "02000000")
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+		standardrules.WithForkVersions([][]byte{phase0Version, altairVersion, otherVersion}),
+		standardrules.WithForkSchedule([]*standardrules.Fork{
+			// Out of order, to check that the schedule is sorted.
+			{Version: altairVersion, Epoch: 10},
+			{Version: phase0Version, Epoch: 2},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := func(forkVersion []byte) []byte {
+		// Use a domain type that does not require further checks.
+		res, err := e2types.ComputeDomain(e2types.DomainType{0x05, 0x00, 0x00, 0x00}, forkVersion, genesisValidatorsRoot)
+		require.NoError(t, err)
+		return res
+	}
+	epoch := func(epoch uint64) *uint64 {
+		return &epoch
+	}
+
+	tests := []struct {
+		name   string
+		domain []byte
+		epoch  *uint64
+		res    rules.Result
+		reason rules.Reason
+	}{
+		{
+			name:   "NoEpoch",
+			domain: domain(phase0Version),
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:   "BeforeFirstFork",
+			domain: domain(phase0Version),
+			epoch:  epoch(1),
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:   "FirstForkStart",
+			domain: domain(phase0Version),
+			epoch:  epoch(2),
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FirstForkEnd",
+			domain: domain(phase0Version),
+			epoch:  epoch(9),
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FutureFork",
+			domain: domain(altairVersion),
+			epoch:  epoch(9),
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:   "SecondForkStart",
+			domain: domain(altairVersion),
+			epoch:  epoch(10),
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "StaleFork",
+			domain: domain(phase0Version),
+			epoch:  epoch(10),
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:   "SecondForkLater",
+			domain: domain(altairVersion),
+			epoch:  epoch(100000),
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "UnscheduledFork",
+			domain: domain(otherVersion),
+			epoch:  epoch(100000),
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			res := testRules.OnSign(ctx, &rules.ReqMetadata{Client: "client1"}, &rules.SignData{
+				Domain: test.domain,
+				Data:   make([]byte, 32),
+				Epoch:  test.epoch,
+			})
+			require.Equal(t, test.res, res)
+			require.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
+
+func TestForkScheduleCurrentEpoch(t *testing.T) {
+	ctx := context.Background()
+	genesisValidatorsRoot := _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95")
+	phase0Version := _byteStr(t, "00000000")
+	altairVersion := _byteStr(t, "01000000")
+
+	// Genesis was 20 epochs ago, so the second fork is active.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+		standardrules.WithForkVersions([][]byte{phase0Version, altairVersion}),
+		standardrules.WithGenesisTime(time.Now().Add(-20*32*12*time.Second)),
+		standardrules.WithForkSchedule([]*standardrules.Fork{
+			{Version: phase0Version, Epoch: 0},
+			{Version: altairVersion, Epoch: 10},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := func(forkVersion []byte) []byte {
+		res, err := e2types.ComputeDomain(e2types.DomainType{0x05, 0x00, 0x00, 0x00}, forkVersion, genesisValidatorsRoot)
+		require.NoError(t, err)
+		return res
+	}
+
+	require.Equal(t, rules.DENIED, testRules.OnSign(ctx, &rules.ReqMetadata{}, &rules.SignData{Domain: domain(phase0Version), Data: make([]byte, 32)}))
+	require.Equal(t, rules.APPROVED, testRules.OnSign(ctx, &rules.ReqMetadata{}, &rules.SignData{Domain: domain(altairVersion), Data: make([]byte, 32)}))
+}
@@ -0,0 +1,75 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/attestantio/dirk/rules"
+)
+
+// GraffitiPolicy is a policy for the graffiti of proposed blocks.
+type GraffitiPolicy struct {
+	// Allowed is a list of permitted graffiti.  If present, graffiti must be one of the entries.
+	Allowed []string
+	// Denied is a list of forbidden strings.  If present, graffiti must not contain any of the entries.
+	Denied []string
+	// Pattern is a regular expression.  If present, graffiti must match it.
+	Pattern *regexp.Regexp
+}
+
+// checkGraffiti checks that the graffiti meets the policy for the account, or failing that its wallet,
+// or failing that the global policy.
+func (s *Service) checkGraffiti(metadata *rules.ReqMetadata, graffiti []byte) bool {
+	graffitiPolicies := s.currentPolicy().graffitiPolicies
+	var policy *GraffitiPolicy
+	for _, key := range metadata.PolicyKeys() {
+		if policy = graffitiPolicies[key]; policy != nil {
+			break
+		}
+	}
+	if policy == nil {
+		return true
+	}
+
+	// Graffiti is a fixed 32-byte field, so trailing zeros are padding.
+	str := string(bytes.TrimRight(graffiti, "\x00"))
+
+	for _, denied := range policy.Denied {
+		if denied != "" && strings.Contains(str, denied) {
+			return false
+		}
+	}
+
+	if len(policy.Allowed) > 0 {
+		allowed := false
+		for i := range policy.Allowed {
+			if str == policy.Allowed[i] {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	if policy.Pattern != nil && !policy.Pattern.MatchString(str) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,29 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Healthy returns an error if the store for the persistent rules information cannot be read.
+func (s *Service) Healthy(ctx context.Context) error {
+	// Any key will do; the prune horizon is a single small value.
+	if _, err := s.store.Fetch(ctx, actionPruneHorizon); err != nil && !errors.Is(err, ErrNotFound) {
+		return errors.Wrap(err, "failed to read from store")
+	}
+	return nil
+}
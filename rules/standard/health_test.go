@@ -0,0 +1,36 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthy(t *testing.T) {
+	ctx := context.Background()
+	service, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, service.Healthy(ctx))
+
+	// The store cannot be read once closed.
+	require.NoError(t, service.Close(ctx))
+	require.Error(t, service.Healthy(ctx))
+}
@@ -0,0 +1,125 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IntegrityAnomaly is an anomaly found in the slashing protection store.
+type IntegrityAnomaly struct {
+	// Key is the key of the anomalous entry.
+	Key []byte
+	// Reason is the reason the entry is anomalous.
+	Reason string
+}
+
+// VerifyIntegrity scans the slashing protection store, returning any anomalies found.
+// The rules hold no in-memory copy of the slashing protection state, so the store is the only
+// source of state that requires verification.
+func (s *Service) VerifyIntegrity(ctx context.Context) ([]*IntegrityAnomaly, error) {
+	entries, err := s.store.FetchAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain data from store")
+	}
+
+	anomalies := make([]*IntegrityAnomaly, 0)
+	for key, value := range entries {
+		if reason := entryAnomaly(key, value); reason != "" {
+			entryKey := key
+			anomalies = append(anomalies, &IntegrityAnomaly{
+				Key:    entryKey[:],
+				Reason: reason,
+			})
+		}
+	}
+	sort.Slice(anomalies, func(i int, j int) bool {
+		return bytes.Compare(anomalies[i].Key, anomalies[j].Key) < 0
+	})
+
+	return anomalies, nil
+}
+
+// entryAnomaly returns the reason that a store entry is anomalous, or an empty string if it is not.
+func entryAnomaly(key [49]byte, value []byte) string {
+	switch key[48] {
+	case actionSignBeaconAttestation[0]:
+		state := &signBeaconAttestationState{}
+		if err := state.Decode(value); err != nil {
+			return fmt.Sprintf("invalid attestation state: %v", err)
+		}
+		if state.SourceEpoch < 0 {
+			return fmt.Sprintf("negative attestation source epoch %d", state.SourceEpoch)
+		}
+		if state.TargetEpoch < 0 {
+			return fmt.Sprintf("negative attestation target epoch %d", state.TargetEpoch)
+		}
+		if state.SourceEpoch > state.TargetEpoch {
+			return fmt.Sprintf("attestation source epoch %d greater than target epoch %d", state.SourceEpoch, state.TargetEpoch)
+		}
+	case actionSignBeaconProposal[0]:
+		state := &signBeaconProposalState{}
+		if err := state.Decode(value); err != nil {
+			return fmt.Sprintf("invalid proposal state: %v", err)
+		}
+		if state.Slot < 0 {
+			return fmt.Sprintf("negative proposal slot %d", state.Slot)
+		}
+	default:
+		return fmt.Sprintf("unknown action %#x", key[48])
+	}
+	return ""
+}
+
+// checkIntegrity verifies the integrity of the slashing protection store on startup.
+// If strict, signing of slashable requests remains halted unless the store is verified without anomalies.
+func (s *Service) checkIntegrity(ctx context.Context) {
+	defer close(s.integrityChecked)
+
+	started := time.Now()
+	anomalies, err := s.VerifyIntegrity(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to verify slashing protection integrity")
+		return
+	}
+	for _, anomaly := range anomalies {
+		log.Error().Str("key", fmt.Sprintf("%#x", anomaly.Key)).Str("reason", anomaly.Reason).Msg("Slashing protection integrity anomaly")
+	}
+	if len(anomalies) > 0 && s.strictIntegrity {
+		log.Error().Int("anomalies", len(anomalies)).Msg("Slashing protection store failed integrity verification; refusing to sign slashable requests")
+		return
+	}
+
+	atomic.StoreInt32(&s.signingHalted, 0)
+	log.Info().Dur("duration", time.Since(started)).Int("anomalies", len(anomalies)).Msg("Verified slashing protection integrity")
+}
+
+// IntegrityChecked returns a channel that is closed when the startup integrity verification of the
+// slashing protection store has completed.  If verification is not enabled the channel is already closed.
+func (s *Service) IntegrityChecked() <-chan struct{} {
+	return s.integrityChecked
+}
+
+// slashableSigningHalted returns true if signing of slashable requests is halted pending, or as a
+// result of, strict integrity verification of the slashing protection store.
+func (s *Service) slashableSigningHalted() bool {
+	return atomic.LoadInt32(&s.signingHalted) == 1
+}
@@ -0,0 +1,204 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+// attestationStateValue returns an encoded attestation state.
+func attestationStateValue(sourceEpoch uint64, targetEpoch uint64) []byte {
+	value := make([]byte, 17)
+	value[0] = 0x01
+	binary.LittleEndian.PutUint64(value[1:9], sourceEpoch)
+	binary.LittleEndian.PutUint64(value[9:17], targetEpoch)
+	return value
+}
+
+// proposalStateValue returns an encoded proposal state.
+func proposalStateValue(slot uint64) []byte {
+	value := make([]byte, 9)
+	value[0] = 0x01
+	binary.LittleEndian.PutUint64(value[1:9], slot)
+	return value
+}
+
+func storeKey(pubKeyByte byte, action byte) []byte {
+	key := make([]byte, 49)
+	key[0] = pubKeyByte
+	key[48] = action
+	return key
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		keys      [][]byte
+		values    [][]byte
+		anomalies []string
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Good",
+			keys: [][]byte{
+				storeKey(0x01, 0x02),
+				storeKey(0x01, 0x03),
+			},
+			values: [][]byte{
+				attestationStateValue(1, 2),
+				proposalStateValue(5),
+			},
+		},
+		{
+			name: "SourceAfterTarget",
+			keys: [][]byte{
+				storeKey(0x01, 0x02),
+				storeKey(0x02, 0x02),
+			},
+			values: [][]byte{
+				attestationStateValue(1, 2),
+				attestationStateValue(3, 2),
+			},
+			anomalies: []string{"attestation source epoch 3 greater than target epoch 2"},
+		},
+		{
+			name: "NegativeTarget",
+			keys: [][]byte{
+				storeKey(0x01, 0x02),
+			},
+			values: [][]byte{
+				attestationStateValue(0, 0xffffffffffffffff),
+			},
+			anomalies: []string{"negative attestation target epoch -1"},
+		},
+		{
+			name: "NegativeSlot",
+			keys: [][]byte{
+				storeKey(0x01, 0x03),
+			},
+			values: [][]byte{
+				proposalStateValue(0x8000000000000000),
+			},
+			anomalies: []string{"negative proposal slot -9223372036854775808"},
+		},
+		{
+			name: "Truncated",
+			keys: [][]byte{
+				storeKey(0x01, 0x03),
+			},
+			values: [][]byte{
+				{0x01, 0x02},
+			},
+			anomalies: []string{"invalid proposal state: invalid version 1 data size 2"},
+		},
+		{
+			name: "UnknownAction",
+			keys: [][]byte{
+				storeKey(0x01, 0x7f),
+			},
+			values: [][]byte{
+				{0x01},
+			},
+			anomalies: []string{"unknown action 0x7f"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base := t.TempDir()
+			store, err := standardrules.NewStore(base)
+			require.NoError(t, err)
+			if len(test.keys) > 0 {
+				require.NoError(t, store.BatchStore(ctx, test.keys, test.values))
+			}
+			require.NoError(t, store.Close(ctx))
+
+			testRules, err := standardrules.New(ctx,
+				standardrules.WithStoragePath(base),
+			)
+			require.NoError(t, err)
+			defer testRules.Close(ctx)
+
+			anomalies, err := testRules.VerifyIntegrity(ctx)
+			require.NoError(t, err)
+			reasons := make([]string, len(anomalies))
+			for i := range anomalies {
+				reasons[i] = anomalies[i].Reason
+			}
+			if test.anomalies == nil {
+				require.Empty(t, reasons)
+			} else {
+				require.Equal(t, test.anomalies, reasons)
+			}
+		})
+	}
+}
+
+func TestStrictIntegrity(t *testing.T) {
+	ctx := context.Background()
+
+	pubKey := make([]byte, 48)
+	pubKey[0] = 0x01
+	proposal := &rules.SignBeaconProposalData{
+		Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+		Slot:   10,
+	}
+
+	// A corrupt record halts signing.
+	base := t.TempDir()
+	store, err := standardrules.NewStore(base)
+	require.NoError(t, err)
+	require.NoError(t, store.Store(ctx, storeKey(0x02, 0x02), attestationStateValue(3, 2)))
+	require.NoError(t, store.Close(ctx))
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithStrictIntegrity(true),
+	)
+	require.NoError(t, err)
+	<-testRules.IntegrityChecked()
+	require.Equal(t, rules.FAILED, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: pubKey}, proposal))
+	require.NoError(t, testRules.Close(ctx))
+
+	// Without strict mode the corrupt record is reported but signing continues.
+	testRules, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithVerifyIntegrity(true),
+	)
+	require.NoError(t, err)
+	<-testRules.IntegrityChecked()
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: pubKey}, proposal))
+	require.NoError(t, testRules.Close(ctx))
+
+	// A clean store allows signing in strict mode once verified.
+	base = t.TempDir()
+	testRules, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithStrictIntegrity(true),
+	)
+	require.NoError(t, err)
+	<-testRules.IntegrityChecked()
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: pubKey}, proposal))
+	require.NoError(t, testRules.Close(ctx))
+}
@@ -17,13 +17,13 @@ import (
 	"context"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
 )
 
 // OnListAccounts is called when a request to list accounts needs to be approved.
 func (s *Service) OnListAccounts(ctx context.Context, metadata *rules.ReqMetadata, req *rules.AccessAccountData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnListAccounts")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnListAccounts")
+	defer span.End()
 
 	return rules.APPROVED
 }
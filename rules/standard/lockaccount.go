@@ -17,13 +17,13 @@ import (
 	"context"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
 )
 
 // OnLockAccount is called when a request to lock an account needs to be approved.
 func (s *Service) OnLockAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.LockAccountData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnLockAccount")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnLockAccount")
+	defer span.End()
 
 	return rules.APPROVED
 }
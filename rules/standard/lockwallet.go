@@ -17,13 +17,13 @@ import (
 	"context"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
 )
 
 // OnLockWallet is called when a request to lock a wallet needs to be approved.
 func (s *Service) OnLockWallet(ctx context.Context, metadata *rules.ReqMetadata, req *rules.LockWalletData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnLockWallet")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnLockWallet")
+	defer span.End()
 
 	return rules.APPROVED
 }
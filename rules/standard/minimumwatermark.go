@@ -0,0 +1,113 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// minimumWatermark is the lowest source epoch, target epoch and slot for which anything is signed
+// for any key.  A value of 0 is no minimum.
+type minimumWatermark struct {
+	sourceEpoch uint64
+	targetEpoch uint64
+	slot        uint64
+}
+
+// loadMinimumWatermark returns the minimum watermark.  Each value is the higher of the configured
+// value and that already held in the store, and the result is stored so that the watermark persists
+// even if it is later removed from the configuration.
+func (s *Service) loadMinimumWatermark(ctx context.Context, configured *minimumWatermark) (*minimumWatermark, error) {
+	stored, err := s.fetchMinimumWatermark(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch minimum watermark")
+	}
+	watermark := &minimumWatermark{
+		sourceEpoch: stored.sourceEpoch,
+		targetEpoch: stored.targetEpoch,
+		slot:        stored.slot,
+	}
+	if configured.sourceEpoch > watermark.sourceEpoch {
+		watermark.sourceEpoch = configured.sourceEpoch
+	}
+	if configured.targetEpoch > watermark.targetEpoch {
+		watermark.targetEpoch = configured.targetEpoch
+	}
+	if configured.slot > watermark.slot {
+		watermark.slot = configured.slot
+	}
+	if *watermark == *stored {
+		return stored, nil
+	}
+	if err := s.storeMinimumWatermark(ctx, watermark); err != nil {
+		return nil, errors.Wrap(err, "failed to store minimum watermark")
+	}
+	log.Info().
+		Uint64("source_epoch", watermark.sourceEpoch).
+		Uint64("target_epoch", watermark.targetEpoch).
+		Uint64("slot", watermark.slot).
+		Msg("Raised minimum watermark")
+	return watermark, nil
+}
+
+// proposalBelowMinimumWatermark returns true if a proposal for the slot is below the minimum watermark.
+func (s *Service) proposalBelowMinimumWatermark(slot uint64) bool {
+	return slot < s.minimumWatermark.slot
+}
+
+// attestationBelowMinimumWatermark returns true if an attestation with the given slot, source epoch
+// and target epoch is below the minimum watermark.
+func (s *Service) attestationBelowMinimumWatermark(slot uint64, sourceEpoch uint64, targetEpoch uint64) bool {
+	return slot < s.minimumWatermark.slot ||
+		sourceEpoch < s.minimumWatermark.sourceEpoch ||
+		targetEpoch < s.minimumWatermark.targetEpoch
+}
+
+// fetchMinimumWatermark fetches the minimum watermark from the store, returning an empty watermark if
+// there is none.  It is held as a version byte followed by the source epoch, target epoch and slot.
+func (s *Service) fetchMinimumWatermark(ctx context.Context) (*minimumWatermark, error) {
+	data, err := s.store.Fetch(ctx, actionMinimumWatermark)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return &minimumWatermark{}, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 || data[0] != 0x01 {
+		return nil, errors.New("invalid version")
+	}
+	if len(data) != 25 {
+		return nil, errors.New("invalid data length")
+	}
+	return &minimumWatermark{
+		sourceEpoch: binary.LittleEndian.Uint64(data[1:9]),
+		targetEpoch: binary.LittleEndian.Uint64(data[9:17]),
+		slot:        binary.LittleEndian.Uint64(data[17:25]),
+	}, nil
+}
+
+// storeMinimumWatermark stores the minimum watermark.
+func (s *Service) storeMinimumWatermark(ctx context.Context, watermark *minimumWatermark) error {
+	data := make([]byte, 25)
+	data[0] = 0x01
+	binary.LittleEndian.PutUint64(data[1:9], watermark.sourceEpoch)
+	binary.LittleEndian.PutUint64(data[9:17], watermark.targetEpoch)
+	binary.LittleEndian.PutUint64(data[17:25], watermark.slot)
+	return s.withStoreRetry(ctx, "store", func() error {
+		return s.store.Store(ctx, actionMinimumWatermark, data)
+	})
+}
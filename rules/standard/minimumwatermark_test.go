@@ -0,0 +1,92 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinimumWatermark(t *testing.T) {
+	ctx := context.Background()
+	base := t.TempDir()
+
+	proposal := func(slot uint64) *rules.SignBeaconProposalData {
+		return &rules.SignBeaconProposalData{
+			Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+			Slot:   slot,
+		}
+	}
+	attestation := func(slot uint64, sourceEpoch uint64, targetEpoch uint64) *rules.SignBeaconAttestationData {
+		return &rules.SignBeaconAttestationData{
+			Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+			Slot:   slot,
+			Source: &rules.Checkpoint{Epoch: sourceEpoch},
+			Target: &rules.Checkpoint{Epoch: targetEpoch},
+		}
+	}
+	metadata := func(key byte) *rules.ReqMetadata {
+		pubKey := make([]byte, 48)
+		pubKey[0] = key
+		return &rules.ReqMetadata{PubKey: pubKey}
+	}
+
+	service, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithMinimumSourceEpoch(5),
+		standardrules.WithMinimumTargetEpoch(10),
+		standardrules.WithMinimumSlot(320),
+	)
+	require.NoError(t, err)
+
+	// The watermark applies to keys without any slashing protection history.
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x01), proposal(319)))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconProposal(ctx, metadata(0x02), proposal(320)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconAttestation(ctx, metadata(0x03), attestation(352, 4, 11)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconAttestation(ctx, metadata(0x04), attestation(352, 5, 9)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconAttestation(ctx, metadata(0x05), attestation(319, 5, 10)))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconAttestation(ctx, metadata(0x06), attestation(320, 5, 10)))
+	require.Equal(t, []rules.Result{rules.DENIED, rules.APPROVED},
+		service.OnSignBeaconAttestations(ctx,
+			[]*rules.ReqMetadata{metadata(0x07), metadata(0x08)},
+			[]*rules.SignBeaconAttestationData{attestation(352, 4, 11), attestation(352, 10, 11)},
+		))
+	require.NoError(t, service.Close(ctx))
+
+	// The watermark persists when it is no longer configured.
+	service, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+	)
+	require.NoError(t, err)
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x09), proposal(300)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconAttestation(ctx, metadata(0x0a), attestation(352, 4, 11)))
+	require.NoError(t, service.Close(ctx))
+
+	// Individual values can be raised but not lowered.
+	service, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithMinimumSourceEpoch(1),
+		standardrules.WithMinimumTargetEpoch(20),
+	)
+	require.NoError(t, err)
+	require.Equal(t, rules.DENIED, service.OnSignBeaconAttestation(ctx, metadata(0x0b), attestation(352, 4, 21)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconAttestation(ctx, metadata(0x0c), attestation(352, 5, 19)))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconAttestation(ctx, metadata(0x0d), attestation(672, 5, 20)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x0e), proposal(300)))
+	require.NoError(t, service.Close(ctx))
+}
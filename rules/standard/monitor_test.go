@@ -0,0 +1,93 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+// slashingMonitor counts prevented slashings by action.
+type slashingMonitor struct {
+	prevented map[string]int
+}
+
+func (m *slashingMonitor) StoreRetried(operation string) {}
+
+func (m *slashingMonitor) StoreBreakerState(state string) {}
+
+func (m *slashingMonitor) SlashingPrevented(action string) {
+	m.prevented[action]++
+}
+
+func TestSlashingPrevented(t *testing.T) {
+	ctx := context.Background()
+	monitor := &slashingMonitor{prevented: make(map[string]int)}
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithMonitor(monitor),
+		standardrules.WithMaxProposalsPerEpoch(1),
+		standardrules.WithGraffitiPolicies(map[string]*standardrules.GraffitiPolicy{
+			"restricted": {
+				Pattern: regexp.MustCompile("^ok$"),
+			},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	proposalDomain := _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000")
+	attestationDomain := _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000")
+	metadata := &rules.ReqMetadata{Account: "restricted", PubKey: _byteStr(t, "01")}
+
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: proposalDomain, Slot: 10, Graffiti: []byte("ok")}))
+	// Policy denials are not prevented slashings.
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: proposalDomain, Slot: 11, Graffiti: []byte("bad")}))
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: proposalDomain, Slot: 12, Graffiti: []byte("ok")}))
+	require.Equal(t, 0, monitor.prevented["beacon proposal"])
+	// A double proposal is a prevented slashing.
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: proposalDomain, Slot: 10, Graffiti: []byte("ok")}))
+	require.Equal(t, 1, monitor.prevented["beacon proposal"])
+
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{
+		Domain: attestationDomain,
+		Source: &rules.Checkpoint{Epoch: 2},
+		Target: &rules.Checkpoint{Epoch: 3},
+	}))
+	// An invalid request is not a prevented slashing.
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{
+		Domain: attestationDomain,
+		Source: &rules.Checkpoint{Epoch: 5},
+		Target: &rules.Checkpoint{Epoch: 4},
+	}))
+	require.Equal(t, 0, monitor.prevented["beacon attestation"])
+	// A double vote is a prevented slashing.
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{
+		Domain: attestationDomain,
+		Source: &rules.Checkpoint{Epoch: 2},
+		Target: &rules.Checkpoint{Epoch: 3},
+	}))
+	// A surround vote is a prevented slashing.
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{
+		Domain: attestationDomain,
+		Source: &rules.Checkpoint{Epoch: 1},
+		Target: &rules.Checkpoint{Epoch: 4},
+	}))
+	require.Equal(t, 2, monitor.prevented["beacon attestation"])
+}
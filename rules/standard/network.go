@@ -0,0 +1,61 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+
+	spec "github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// calculateForkDataRoots calculates the fork data roots for the given genesis validators root
+// and fork versions.  It returns nil if no genesis validators root is supplied.
+func calculateForkDataRoots(genesisValidatorsRoot []byte, forkVersions [][]byte) ([][]byte, error) {
+	if genesisValidatorsRoot == nil {
+		return nil, nil
+	}
+
+	res := make([][]byte, len(forkVersions))
+	for i := range forkVersions {
+		forkData := &spec.ForkData{}
+		copy(forkData.CurrentVersion[:], forkVersions[i])
+		copy(forkData.GenesisValidatorsRoot[:], genesisValidatorsRoot)
+		root, err := forkData.HashTreeRoot()
+		if err != nil {
+			return nil, err
+		}
+		res[i] = root[:]
+	}
+
+	return res, nil
+}
+
+// checkNetwork checks that the domain is for the network for which we are configured.
+// A domain is made up of a 4-byte domain type followed by the first 28 bytes of the
+// fork data root, which commits to the genesis validators root of the network.
+func (s *Service) checkNetwork(domain []byte) bool {
+	if s.forkDataRoots == nil {
+		// Not configured to check the network.
+		return true
+	}
+	if len(domain) != 32 {
+		return false
+	}
+	for _, forkDataRoot := range s.forkDataRoots {
+		if bytes.Equal(domain[4:], forkDataRoot[:28]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestNetworkParameters(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	tests := []struct {
+		name                  string
+		genesisValidatorsRoot []byte
+		forkVersions          [][]byte
+		err                   string
+	}{
+		{
+			name: "NotConfigured",
+		},
+		{
+			name:                  "GenesisValidatorsRootShort",
+			genesisValidatorsRoot: _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe"),
+			forkVersions:          [][]byte{_byteStr(t, "00000000")},
+			err:                   "problem with parameters: genesis validators root must be 32 bytes",
+		},
+		{
+			name:                  "ForkVersionsMissing",
+			genesisValidatorsRoot: _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+			err:                   "problem with parameters: no fork versions specified",
+		},
+		{
+			name:                  "ForkVersionInvalid",
+			genesisValidatorsRoot: _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+			forkVersions:          [][]byte{_byteStr(t, "000000")},
+			err:                   "problem with parameters: fork version 0x000000 must be 4 bytes",
+		},
+		{
+			name:                  "Good",
+			genesisValidatorsRoot: _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+			forkVersions:          [][]byte{_byteStr(t, "00000000")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := standardrules.New(ctx,
+				standardrules.WithStoragePath(base),
+				standardrules.WithGenesisValidatorsRoot(test.genesisValidatorsRoot),
+				standardrules.WithForkVersions(test.forkVersions),
+			)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				assert.NoError(t, res.Close(ctx))
+			}
+		})
+	}
+}
+
+func TestNetwork(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	genesisValidatorsRoot := _byteStr(t, "4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95")
+	otherGenesisValidatorsRoot := _byteStr(t, "9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1")
+	forkVersion := _byteStr(t, "00000000")
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGenesisValidatorsRoot(genesisValidatorsRoot),
+		standardrules.WithForkVersions([][]byte{forkVersion}),
+	)
+	require.NoError(t, err)
+
+	domain := func(domainType e2types.DomainType, genesisValidatorsRoot []byte) []byte {
+		res, err := e2types.ComputeDomain(domainType, forkVersion, genesisValidatorsRoot)
+		require.NoError(t, err)
+		return res
+	}
+
+	t.Run("SignGood", func(t *testing.T) {
+		res := testRules.OnSign(ctx, &rules.ReqMetadata{}, &rules.SignData{
+			Domain: domain(e2types.DomainRANDAO, genesisValidatorsRoot),
+			Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+		})
+		assert.Equal(t, rules.APPROVED, res)
+	})
+
+	t.Run("SignOtherNetwork", func(t *testing.T) {
+		res := testRules.OnSign(ctx, &rules.ReqMetadata{}, &rules.SignData{
+			Domain: domain(e2types.DomainRANDAO, otherGenesisValidatorsRoot),
+			Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+		})
+		assert.Equal(t, rules.DENIED, res)
+	})
+
+	t.Run("ProposalOtherNetwork", func(t *testing.T) {
+		res := testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{}, &rules.SignBeaconProposalData{
+			Domain: domain(e2types.DomainBeaconProposer, otherGenesisValidatorsRoot),
+			Slot:   1,
+		})
+		assert.Equal(t, rules.DENIED, res)
+	})
+
+	t.Run("ProposalGood", func(t *testing.T) {
+		res := testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{}, &rules.SignBeaconProposalData{
+			Domain: domain(e2types.DomainBeaconProposer, genesisValidatorsRoot),
+			Slot:   1,
+		})
+		assert.Equal(t, rules.APPROVED, res)
+	})
+
+	t.Run("AttestationOtherNetwork", func(t *testing.T) {
+		res := testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{}, &rules.SignBeaconAttestationData{
+			Domain: domain(e2types.DomainBeaconAttester, otherGenesisValidatorsRoot),
+			Source: &rules.Checkpoint{Epoch: 1},
+			Target: &rules.Checkpoint{Epoch: 2},
+		})
+		assert.Equal(t, rules.DENIED, res)
+	})
+
+	t.Run("AttestationGood", func(t *testing.T) {
+		res := testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{}, &rules.SignBeaconAttestationData{
+			Domain: domain(e2types.DomainBeaconAttester, genesisValidatorsRoot),
+			Source: &rules.Checkpoint{Epoch: 1},
+			Target: &rules.Checkpoint{Epoch: 2},
+		})
+		assert.Equal(t, rules.APPROVED, res)
+	})
+}
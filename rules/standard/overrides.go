@@ -0,0 +1,180 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/attestantio/dirk/rules"
+)
+
+// AccountOverride overrides global rule parameters for a set of accounts.
+type AccountOverride struct {
+	// Accounts are the accounts to which the override applies.  Each entry is either an account name,
+	// which may contain glob patterns as per path.Match, or a 0x-prefixed hex public key.
+	Accounts []string
+	// MaxFutureEpochs overrides the maximum number of future epochs, if set.  0 disables the check.
+	MaxFutureEpochs *uint64
+	// MaxFutureSlots overrides the maximum number of future slots, if set.  0 disables the check.
+	MaxFutureSlots *uint64
+}
+
+// limits are the limits applied to an account.
+type limits struct {
+	maxFutureEpochs uint64
+	maxFutureSlots  uint64
+}
+
+// accountOverrides are the account overrides, indexed for efficient resolution.
+// Overrides by public key take precedence over those by exact account name, which
+// in turn take precedence over the first matching glob pattern.
+type accountOverrides struct {
+	pubKeys map[[48]byte]*AccountOverride
+	names   map[string]*AccountOverride
+	globs   []*accountOverrideGlob
+	// globMatches caches the result of matching account names against globs.
+	globMatches sync.Map
+}
+
+type accountOverrideGlob struct {
+	pattern  string
+	override *AccountOverride
+}
+
+// newAccountOverrides indexes checked account overrides.
+func newAccountOverrides(overrides []*AccountOverride) *accountOverrides {
+	res := &accountOverrides{
+		pubKeys: make(map[[48]byte]*AccountOverride),
+		names:   make(map[string]*AccountOverride),
+	}
+	for _, override := range overrides {
+		for _, account := range override.Accounts {
+			if pubKey, isPubKey := parseOverridePubKey(account); isPubKey {
+				var key [48]byte
+				copy(key[:], pubKey)
+				if _, exists := res.pubKeys[key]; !exists {
+					res.pubKeys[key] = override
+				}
+				continue
+			}
+			if strings.ContainsAny(account, "*?[\\") {
+				res.globs = append(res.globs, &accountOverrideGlob{pattern: account, override: override})
+				continue
+			}
+			if _, exists := res.names[account]; !exists {
+				res.names[account] = override
+			}
+		}
+	}
+	return res
+}
+
+// find returns the override for the account, or nil if there is none.
+func (o *accountOverrides) find(account string, pubKey []byte) *AccountOverride {
+	if len(pubKey) == 48 && len(o.pubKeys) > 0 {
+		var key [48]byte
+		copy(key[:], pubKey)
+		if override, exists := o.pubKeys[key]; exists {
+			return override
+		}
+	}
+	if override, exists := o.names[account]; exists {
+		return override
+	}
+	if len(o.globs) == 0 {
+		return nil
+	}
+	if override, exists := o.globMatches.Load(account); exists {
+		return override.(*AccountOverride)
+	}
+	var match *AccountOverride
+	for _, glob := range o.globs {
+		// Patterns have already been checked, so cannot error.
+		if matched, _ := path.Match(glob.pattern, account); matched {
+			match = glob.override
+			break
+		}
+	}
+	o.globMatches.Store(account, match)
+	return match
+}
+
+// limits returns the limits for the account in the request.
+func (s *Service) limits(metadata *rules.ReqMetadata) limits {
+	res := limits{
+		maxFutureEpochs: s.maxFutureEpochs,
+		maxFutureSlots:  s.maxFutureSlots,
+	}
+	override := s.currentPolicy().accountOverrides.find(metadata.Account, metadata.PubKey)
+	if override != nil {
+		if override.MaxFutureEpochs != nil {
+			res.maxFutureEpochs = *override.MaxFutureEpochs
+		}
+		if override.MaxFutureSlots != nil {
+			res.maxFutureSlots = *override.MaxFutureSlots
+		}
+	}
+	return res
+}
+
+// parseOverridePubKey parses an override account entry as a public key.
+func parseOverridePubKey(account string) ([]byte, bool) {
+	if !strings.HasPrefix(account, "0x") {
+		return nil, false
+	}
+	pubKey, err := hex.DecodeString(strings.TrimPrefix(account, "0x"))
+	if err != nil || len(pubKey) != 48 {
+		return nil, false
+	}
+	return pubKey, true
+}
+
+// checkAccountOverrides checks account overrides.
+func checkAccountOverrides(overrides []*AccountOverride) error {
+	for i, override := range overrides {
+		if override == nil {
+			return fmt.Errorf("account override %d is nil", i)
+		}
+		if len(override.Accounts) == 0 {
+			return fmt.Errorf("account override %d has no accounts", i)
+		}
+		for _, account := range override.Accounts {
+			if account == "" {
+				return fmt.Errorf("account override %d has an empty account", i)
+			}
+			if _, isPubKey := parseOverridePubKey(account); isPubKey {
+				continue
+			}
+			if _, err := path.Match(account, ""); err != nil {
+				return fmt.Errorf("account override %d has invalid pattern %q: %v", i, account, err)
+			}
+		}
+	}
+	return nil
+}
+
+// accountOverridesHaveLimits returns true if any account override sets a non-zero limit.
+func accountOverridesHaveLimits(overrides []*AccountOverride) bool {
+	for _, override := range overrides {
+		if (override.MaxFutureEpochs != nil && *override.MaxFutureEpochs != 0) ||
+			(override.MaxFutureSlots != nil && *override.MaxFutureSlots != 0) {
+			return true
+		}
+	}
+	return false
+}
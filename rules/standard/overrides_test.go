@@ -0,0 +1,214 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func TestAccountOverridesParameter(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	tests := []struct {
+		name      string
+		overrides []*standardrules.AccountOverride
+		genesis   bool
+		err       string
+	}{
+		{
+			name: "Good",
+			overrides: []*standardrules.AccountOverride{
+				{Accounts: []string{"valuable", "bulk-*"}, MaxFutureEpochs: uint64Ptr(1)},
+			},
+			genesis: true,
+		},
+		{
+			name:      "NoAccounts",
+			overrides: []*standardrules.AccountOverride{{MaxFutureEpochs: uint64Ptr(1)}},
+			genesis:   true,
+			err:       "problem with parameters: account override 0 has no accounts",
+		},
+		{
+			name: "BadPattern",
+			overrides: []*standardrules.AccountOverride{
+				{Accounts: []string{"bulk-["}, MaxFutureEpochs: uint64Ptr(1)},
+			},
+			genesis: true,
+			err:     "problem with parameters: account override 0 has invalid pattern \"bulk-[\": syntax error in pattern",
+		},
+		{
+			name: "NoGenesisTime",
+			overrides: []*standardrules.AccountOverride{
+				{Accounts: []string{"valuable"}, MaxFutureSlots: uint64Ptr(1)},
+			},
+			err: "problem with parameters: no genesis time specified",
+		},
+		{
+			name: "ZeroLimitsNoGenesisTime",
+			overrides: []*standardrules.AccountOverride{
+				{Accounts: []string{"valuable"}, MaxFutureSlots: uint64Ptr(0)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := []standardrules.Parameter{
+				standardrules.WithStoragePath(fmt.Sprintf("%s/%s", base, test.name)),
+				standardrules.WithAccountOverrides(test.overrides),
+			}
+			if test.genesis {
+				params = append(params, standardrules.WithGenesisTime(time.Now()))
+			}
+			testRules, err := standardrules.New(ctx, params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, testRules.Close(ctx))
+			}
+		})
+	}
+}
+
+func TestAccountOverridesFutureEpoch(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	overridePubKey := _byteStr(t, "a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c")
+
+	// Genesis is set such that we are half way through epoch 10.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGenesisTime(time.Now().Add(-(10*32+16)*12*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithSlotsPerEpoch(32),
+		standardrules.WithMaxFutureEpochs(2),
+		standardrules.WithAccountOverrides([]*standardrules.AccountOverride{
+			{
+				Accounts:        []string{"valuable", fmt.Sprintf("%#x", overridePubKey)},
+				MaxFutureEpochs: uint64Ptr(1),
+			},
+			{
+				Accounts:        []string{"bulk-*"},
+				MaxFutureEpochs: uint64Ptr(4),
+			},
+			{
+				Accounts:       []string{"bulk-1"},
+				MaxFutureSlots: uint64Ptr(1),
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		account     string
+		pubKey      []byte
+		targetEpoch uint64
+		res         rules.Result
+	}{
+		{
+			name:        "DefaultAtLimit",
+			account:     "standard",
+			pubKey:      _byteStr(t, "01"),
+			targetEpoch: 12,
+			res:         rules.APPROVED,
+		},
+		{
+			name:        "DefaultBeyondLimit",
+			account:     "standard",
+			pubKey:      _byteStr(t, "02"),
+			targetEpoch: 13,
+			res:         rules.DENIED,
+		},
+		{
+			name:        "ExactAtLimit",
+			account:     "valuable",
+			pubKey:      _byteStr(t, "03"),
+			targetEpoch: 11,
+			res:         rules.APPROVED,
+		},
+		{
+			name:        "ExactBeyondLimit",
+			account:     "valuable",
+			pubKey:      _byteStr(t, "04"),
+			targetEpoch: 12,
+			res:         rules.DENIED,
+		},
+		{
+			name:        "GlobAtLimit",
+			account:     "bulk-2",
+			pubKey:      _byteStr(t, "05"),
+			targetEpoch: 14,
+			res:         rules.APPROVED,
+		},
+		{
+			name:        "GlobBeyondLimit",
+			account:     "bulk-2",
+			pubKey:      _byteStr(t, "06"),
+			targetEpoch: 15,
+			res:         rules.DENIED,
+		},
+		{
+			// Exact name match takes precedence over glob, and does not override epochs.
+			name:        "ExactOverGlob",
+			account:     "bulk-1",
+			pubKey:      _byteStr(t, "07"),
+			targetEpoch: 13,
+			res:         rules.DENIED,
+		},
+		{
+			// Public key match takes precedence over glob.
+			name:        "PubKeyOverGlob",
+			account:     "bulk-3",
+			pubKey:      overridePubKey,
+			targetEpoch: 12,
+			res:         rules.DENIED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignBeaconAttestation(ctx,
+				&rules.ReqMetadata{Account: test.account, PubKey: test.pubKey},
+				&rules.SignBeaconAttestationData{
+					Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+					Source: &rules.Checkpoint{Epoch: 9},
+					Target: &rules.Checkpoint{Epoch: test.targetEpoch},
+				},
+			)
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
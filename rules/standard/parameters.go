@@ -15,14 +15,61 @@ package standard
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/attestantio/dirk/services/locker"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/util/clock"
 	"github.com/rs/zerolog"
 )
 
 type parameters struct {
-	logLevel    zerolog.Level
-	storagePath string
-	adminIPs    []string
+	logLevel                      zerolog.Level
+	monitor                       metrics.RulesMonitor
+	storagePath                   string
+	slashingProtection            SlashingProtection
+	genesisValidatorsRoot         []byte
+	forkVersions                  [][]byte
+	forkSchedule                  []*Fork
+	genesisForkVersion            []byte
+	genesisTime                   time.Time
+	clock                         clock.Clock
+	slotDuration                  time.Duration
+	slotsPerEpoch                 uint64
+	maxFutureEpochs               uint64
+	maxFutureSlots                uint64
+	randaoRevealWindow            uint64
+	aggregateSlotWindow           uint64
+	syncCommitteeWindow           uint64
+	minProposalSlotGap            uint64
+	maxProposalsPerEpoch          uint64
+	signingFloorSlot              uint64
+	minimumSourceEpoch            uint64
+	minimumTargetEpoch            uint64
+	minimumSlot                   uint64
+	signDomainTypes               map[string][][]byte
+	graffitiPolicies              map[string]*GraffitiPolicy
+	depositPolicies               map[string]*DepositPolicy
+	voluntaryExitPolicies         map[string]*VoluntaryExitPolicy
+	blsToExecutionChangePolicies  map[string]*BLSToExecutionChangePolicy
+	validatorRegistrationPolicies map[string]*ValidatorRegistrationPolicy
+	validatorRegistrationWindow   time.Duration
+	voluntaryExitApprovalTimeout  time.Duration
+	validatorIndices              map[string]uint64
+	deniedPubKeys                 []string
+	createAccountPaths            map[string][]string
+	accountOverrides              []*AccountOverride
+	storeMaxAttempts              int
+	storeRetryBackoff             time.Duration
+	storeBreakerThreshold         int
+	storeBreakerCooldown          time.Duration
+	verifyIntegrity               bool
+	strictIntegrity               bool
+	locker                        locker.Service
+	pruneInterval                 time.Duration
+	pruneFinalityLag              uint64
+	pruneRetainEpochs             uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -43,6 +90,13 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.RulesMonitor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
 // WithStoragePath sets the storage path for the module.
 func WithStoragePath(storagePath string) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -50,17 +104,368 @@ func WithStoragePath(storagePath string) Parameter {
 	})
 }
 
-// WithAdminIPs sets the administration IP addreses for the module.
-func WithAdminIPs(adminIPs []string) Parameter {
+// WithSlashingProtection sets the storage for slashing protection high-water marks.  If this is not supplied
+// the marks are held in the store at the storage path.  Export, backup, integrity verification and pruning
+// operate on the store at the storage path regardless.
+func WithSlashingProtection(slashingProtection SlashingProtection) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slashingProtection = slashingProtection
+	})
+}
+
+// WithAdminIPs previously set the IP addresses from which generic voluntary exits were accepted.
+//
+// Deprecated: generic voluntary exits are always refused, so this has no effect.
+func WithAdminIPs(_ []string) Parameter {
+	return parameterFunc(func(p *parameters) {})
+}
+
+// WithGenesisValidatorsRoot sets the genesis validators root of the network for which requests will be signed.
+// If this is not supplied then requests are not checked against a network.
+func WithGenesisValidatorsRoot(genesisValidatorsRoot []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisValidatorsRoot = genesisValidatorsRoot
+	})
+}
+
+// WithForkVersions sets the fork versions of the network for which requests will be signed.
+func WithForkVersions(forkVersions [][]byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.forkVersions = forkVersions
+	})
+}
+
+// WithGenesisForkVersion sets the genesis fork version of the network.  If supplied, deposits must have the
+// deposit domain for the network.
+func WithGenesisForkVersion(genesisForkVersion []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisForkVersion = genesisForkVersion
+	})
+}
+
+// WithForkSchedule sets the schedule of forks for the network.  If supplied, generic signing requests must
+// have a domain for the fork that is active at the epoch of the request.  This requires the genesis
+// validators root.
+func WithForkSchedule(forkSchedule []*Fork) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.forkSchedule = forkSchedule
+	})
+}
+
+// WithGenesisTime sets the genesis time of the network for which requests will be signed.
+func WithGenesisTime(genesisTime time.Time) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisTime = genesisTime
+	})
+}
+
+// WithSlotDuration sets the duration of a slot for the network for which requests will be signed.
+func WithSlotDuration(slotDuration time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotDuration = slotDuration
+	})
+}
+
+// WithSlotsPerEpoch sets the number of slots in an epoch for the network for which requests will be signed.
+func WithSlotsPerEpoch(slotsPerEpoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotsPerEpoch = slotsPerEpoch
+	})
+}
+
+// WithClock sets the clock used to obtain the current time, slot and epoch.  The clock should use the same
+// genesis time, slot duration and slots per epoch as supplied to this module.  If not supplied, the system
+// time is used.
+func WithClock(clock clock.Clock) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clock = clock
+	})
+}
+
+// WithMaxFutureEpochs sets the maximum number of epochs beyond the current epoch for which an attestation
+// can be signed.  If this is 0 then attestations are not checked against the current epoch.
+func WithMaxFutureEpochs(maxFutureEpochs uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxFutureEpochs = maxFutureEpochs
+	})
+}
+
+// WithRANDAORevealWindow sets the maximum number of epochs either side of the current epoch for which a
+// RANDAO reveal can be signed.  If this is 0 then RANDAO reveals are not checked against the current epoch.
+func WithRANDAORevealWindow(randaoRevealWindow uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.randaoRevealWindow = randaoRevealWindow
+	})
+}
+
+// WithAggregateSlotWindow sets the maximum number of slots either side of the current slot for which an
+// aggregation slot selection proof or aggregate and proof can be signed.  If this is 0 then they are only
+// checked against the maximum future slots.
+func WithAggregateSlotWindow(aggregateSlotWindow uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.aggregateSlotWindow = aggregateSlotWindow
+	})
+}
+
+// WithSyncCommitteeSlotWindow sets the maximum number of slots either side of the current slot for which a
+// sync committee message, selection proof or contribution and proof can be signed.  If this is 0 then they
+// are only checked against the maximum future slots.
+func WithSyncCommitteeSlotWindow(syncCommitteeWindow uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.syncCommitteeWindow = syncCommitteeWindow
+	})
+}
+
+// WithMinProposalSlotGap sets the minimum number of slots between consecutive proposals for an account.
+// If this is 0 then proposals are only required to be for a slot higher than the previous proposal.
+func WithMinProposalSlotGap(minProposalSlotGap uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.minProposalSlotGap = minProposalSlotGap
+	})
+}
+
+// WithMaxProposalsPerEpoch sets the maximum number of proposals that can be signed for an account in a
+// single epoch.  If this is 0 then the number of proposals in an epoch is not limited.
+func WithMaxProposalsPerEpoch(maxProposalsPerEpoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxProposalsPerEpoch = maxProposalsPerEpoch
+	})
+}
+
+// WithMaxFutureSlots sets the maximum number of slots beyond the current slot for which a proposal or
+// attestation can be signed.  If this is 0 then requests are not checked against the current slot.
+func WithMaxFutureSlots(maxFutureSlots uint64) Parameter {
 	return parameterFunc(func(p *parameters) {
-		p.adminIPs = adminIPs
+		p.maxFutureSlots = maxFutureSlots
+	})
+}
+
+// WithSignDomainTypes sets the domain types that each client may request through generic signing.
+// Clients without an entry may request any domain type that is not otherwise refused.
+func WithSignDomainTypes(signDomainTypes map[string][][]byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.signDomainTypes = signDomainTypes
+	})
+}
+
+// WithGraffitiPolicies sets the graffiti policies for proposals, by account name, wallet name followed
+// by "/", or "*" for all accounts.  The most specific policy applies, as per rules.ReqMetadata.PolicyKeys.
+// Accounts without a policy may propose blocks with any graffiti.
+func WithGraffitiPolicies(graffitiPolicies map[string]*GraffitiPolicy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.graffitiPolicies = graffitiPolicies
+	})
+}
+
+// WithDepositPolicies sets the deposit policies, by account name, wallet name followed by "/", or "*"
+// for all accounts.  The most specific policy applies, as per rules.ReqMetadata.PolicyKeys.
+// Deposits are not signed for accounts without a policy.
+func WithDepositPolicies(depositPolicies map[string]*DepositPolicy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.depositPolicies = depositPolicies
+	})
+}
+
+// WithVoluntaryExitPolicies sets the voluntary exit policies, by account name, wallet name followed
+// by "/", or "*" for all accounts.  The most specific policy applies, as per rules.ReqMetadata.PolicyKeys.
+// Voluntary exits are not signed for accounts without a policy that allows them.
+func WithVoluntaryExitPolicies(voluntaryExitPolicies map[string]*VoluntaryExitPolicy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.voluntaryExitPolicies = voluntaryExitPolicies
+	})
+}
+
+// WithVoluntaryExitApprovalTimeout sets the time within which a second client must request a voluntary
+// exit that requires a second approval.
+func WithVoluntaryExitApprovalTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.voluntaryExitApprovalTimeout = timeout
+	})
+}
+
+// WithBLSToExecutionChangePolicies sets the BLS to execution change policies, by account name, wallet name
+// followed by "/", or "*" for all accounts.  The most specific policy applies, as per rules.ReqMetadata.PolicyKeys.
+// Accounts without a policy may change to any execution address.
+func WithBLSToExecutionChangePolicies(blsToExecutionChangePolicies map[string]*BLSToExecutionChangePolicy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blsToExecutionChangePolicies = blsToExecutionChangePolicies
+	})
+}
+
+// WithValidatorRegistrationPolicies sets the validator registration policies, by account name, wallet name
+// followed by "/", or "*" for all accounts.  The most specific policy applies, as per rules.ReqMetadata.PolicyKeys.
+// Accounts without a policy may register with any fee recipient.
+func WithValidatorRegistrationPolicies(validatorRegistrationPolicies map[string]*ValidatorRegistrationPolicy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorRegistrationPolicies = validatorRegistrationPolicies
+	})
+}
+
+// WithValidatorRegistrationWindow sets the maximum difference between the current time and the timestamp of
+// a validator registration.  If this is 0 then registration timestamps are not checked.
+func WithValidatorRegistrationWindow(window time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorRegistrationWindow = window
+	})
+}
+
+// WithValidatorIndices sets the expected validator indices, by 0x-prefixed hex public key.
+// Proposals and aggregates that supply an index different from that expected for the public
+// key are refused.  Public keys without an entry are not checked.
+func WithValidatorIndices(validatorIndices map[string]uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorIndices = validatorIndices
+	})
+}
+
+// WithDeniedPubKeys sets the public keys, as 0x-prefixed hex strings, for which all signing
+// requests are refused regardless of any other rules.
+func WithDeniedPubKeys(pubKeys []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.deniedPubKeys = pubKeys
+	})
+}
+
+// WithCreateAccountPaths sets the derivation paths under which each client may create accounts.
+// Clients without an entry may create accounts with any derivation path.
+func WithCreateAccountPaths(createAccountPaths map[string][]string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.createAccountPaths = createAccountPaths
+	})
+}
+
+// WithAccountOverrides sets overrides of global rule parameters for specific accounts.
+func WithAccountOverrides(accountOverrides []*AccountOverride) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.accountOverrides = accountOverrides
+	})
+}
+
+// WithStoreMaxAttempts sets the maximum number of attempts for an operation against the store
+// that fails with a transient error.
+func WithStoreMaxAttempts(storeMaxAttempts int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storeMaxAttempts = storeMaxAttempts
+	})
+}
+
+// WithStoreRetryBackoff sets the initial delay before retrying an operation against the store.
+// The delay doubles with each subsequent attempt.
+func WithStoreRetryBackoff(storeRetryBackoff time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storeRetryBackoff = storeRetryBackoff
+	})
+}
+
+// WithStoreBreakerThreshold sets the number of consecutive failed operations against the store after which
+// the circuit breaker opens, refusing further operations until the cool-down has passed.
+// A value of 0 disables the circuit breaker.
+func WithStoreBreakerThreshold(storeBreakerThreshold int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storeBreakerThreshold = storeBreakerThreshold
+	})
+}
+
+// WithStoreBreakerCooldown sets the time for which the circuit breaker stays open before allowing a
+// single operation through to probe the store.
+func WithStoreBreakerCooldown(storeBreakerCooldown time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storeBreakerCooldown = storeBreakerCooldown
+	})
+}
+
+// WithVerifyIntegrity verifies the integrity of the slashing protection store in the background on startup.
+func WithVerifyIntegrity(verifyIntegrity bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.verifyIntegrity = verifyIntegrity
+	})
+}
+
+// WithStrictIntegrity refuses to sign slashable requests until the integrity of the slashing protection
+// store has been verified, and refuses to sign them at all if anomalies are found.  This implies
+// WithVerifyIntegrity.
+func WithStrictIntegrity(strictIntegrity bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.strictIntegrity = strictIntegrity
+	})
+}
+
+// WithLocker sets the account locker, used to serialise pruning with signing.
+func WithLocker(locker locker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.locker = locker
+	})
+}
+
+// WithPruneInterval sets the interval at which the slashing protection store is pruned.
+// A value of 0 disables scheduled pruning.
+func WithPruneInterval(pruneInterval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.pruneInterval = pruneInterval
+	})
+}
+
+// WithPruneFinalityLag sets the number of epochs behind the current epoch at which epochs are considered
+// finalized when pruning the slashing protection store.  Marks are never removed for finalized epochs or later.
+func WithPruneFinalityLag(pruneFinalityLag uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.pruneFinalityLag = pruneFinalityLag
+	})
+}
+
+// WithPruneRetainEpochs sets the number of epochs behind the highest epoch in the slashing protection store
+// for which marks are retained when pruning.  Older marks are removed.  A value of 0 retains all marks.
+func WithPruneRetainEpochs(pruneRetainEpochs uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.pruneRetainEpochs = pruneRetainEpochs
+	})
+}
+
+// WithSigningFloorSlot sets a slot at or below which nothing is signed for any key, regardless of the
+// slashing protection held for the key.  Proposals for slots at or below the floor, and attestations for
+// target epochs at or below the epoch of the floor, are refused.  The floor is persisted, and can be raised
+// but not lowered.  If this is 0 then no further floor is set.
+func WithSigningFloorSlot(signingFloorSlot uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.signingFloorSlot = signingFloorSlot
+	})
+}
+
+// WithMinimumSourceEpoch sets a source epoch below which no attestation is signed for any key, regardless
+// of the slashing protection held for the key.  The minimum is persisted, and can be raised but not lowered.
+func WithMinimumSourceEpoch(epoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.minimumSourceEpoch = epoch
+	})
+}
+
+// WithMinimumTargetEpoch sets a target epoch below which no attestation is signed for any key, regardless
+// of the slashing protection held for the key.  The minimum is persisted, and can be raised but not lowered.
+func WithMinimumTargetEpoch(epoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.minimumTargetEpoch = epoch
+	})
+}
+
+// WithMinimumSlot sets a slot below which no proposal or attestation is signed for any key, regardless
+// of the slashing protection held for the key.  The minimum is persisted, and can be raised but not lowered.
+func WithMinimumSlot(slot uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.minimumSlot = slot
 	})
 }
 
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:                     zerolog.GlobalLevel(),
+		slotDuration:                 12 * time.Second,
+		slotsPerEpoch:                32,
+		storeMaxAttempts:             3,
+		storeRetryBackoff:            50 * time.Millisecond,
+		storeBreakerCooldown:         30 * time.Second,
+		voluntaryExitApprovalTimeout: 5 * time.Minute,
+		pruneFinalityLag:             2,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -68,9 +473,132 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		}
 	}
 
+	if parameters.monitor == nil {
+		// Use no-op monitor.
+		parameters.monitor = &noopMonitor{}
+	}
 	if parameters.storagePath == "" {
 		return nil, errors.New("no storage path specified")
 	}
+	if parameters.storeMaxAttempts < 1 {
+		return nil, errors.New("store max attempts must be at least 1")
+	}
+	if parameters.storeBreakerThreshold < 0 {
+		return nil, errors.New("store breaker threshold cannot be negative")
+	}
+	if parameters.storeBreakerThreshold > 0 && parameters.storeBreakerCooldown <= 0 {
+		return nil, errors.New("store breaker cooldown must be positive")
+	}
+	if parameters.validatorRegistrationWindow < 0 {
+		return nil, errors.New("validator registration window cannot be negative")
+	}
+	if parameters.voluntaryExitApprovalTimeout <= 0 {
+		return nil, errors.New("voluntary exit approval timeout must be positive")
+	}
+	if parameters.pruneInterval < 0 {
+		return nil, errors.New("prune interval cannot be negative")
+	}
+	if parameters.pruneInterval > 0 && parameters.locker == nil {
+		return nil, errors.New("no locker specified for scheduled pruning")
+	}
+	if parameters.pruneRetainEpochs > 0 && parameters.slashingProtection != nil {
+		return nil, errors.New("marks cannot be removed from external slashing protection")
+	}
+	if parameters.pruneRetainEpochs > 0 && parameters.genesisTime.IsZero() {
+		return nil, errors.New("no genesis time specified for removal of marks")
+	}
+	if parameters.genesisValidatorsRoot != nil {
+		if len(parameters.genesisValidatorsRoot) != 32 {
+			return nil, errors.New("genesis validators root must be 32 bytes")
+		}
+		if len(parameters.forkVersions) == 0 {
+			return nil, errors.New("no fork versions specified")
+		}
+		for i := range parameters.forkVersions {
+			if len(parameters.forkVersions[i]) != 4 {
+				return nil, fmt.Errorf("fork version %#x must be 4 bytes", parameters.forkVersions[i])
+			}
+		}
+	}
+
+	if parameters.genesisForkVersion != nil && len(parameters.genesisForkVersion) != 4 {
+		return nil, errors.New("genesis fork version must be 4 bytes")
+	}
+
+	if err := checkForkSchedule(&parameters); err != nil {
+		return nil, err
+	}
+
+	if parameters.slotDuration == 0 {
+		return nil, errors.New("no slot duration specified")
+	}
+	if parameters.slotsPerEpoch == 0 {
+		return nil, errors.New("no slots per epoch specified")
+	}
+	if parameters.clock == nil {
+		parameters.clock = clock.New(clock.ChainTime{
+			GenesisTime:   parameters.genesisTime,
+			SlotDuration:  parameters.slotDuration,
+			SlotsPerEpoch: parameters.slotsPerEpoch,
+		})
+	}
+	if err := checkPolicyParameters(&parameters); err != nil {
+		return nil, err
+	}
+	if (parameters.maxFutureEpochs != 0 ||
+		parameters.maxFutureSlots != 0 ||
+		parameters.randaoRevealWindow != 0 ||
+		parameters.aggregateSlotWindow != 0 ||
+		parameters.syncCommitteeWindow != 0 ||
+		accountOverridesHaveLimits(parameters.accountOverrides)) &&
+		parameters.genesisTime.IsZero() {
+		return nil, errors.New("no genesis time specified")
+	}
 
 	return &parameters, nil
 }
+
+// checkPolicyParameters checks the policy parameters.
+func checkPolicyParameters(parameters *parameters) error {
+	for client, domainTypes := range parameters.signDomainTypes {
+		for i := range domainTypes {
+			if len(domainTypes[i]) != 4 {
+				return fmt.Errorf("sign domain type %#x for client %s must be 4 bytes", domainTypes[i], client)
+			}
+		}
+	}
+	for account, policy := range parameters.depositPolicies {
+		if err := checkDepositPolicy(policy); err != nil {
+			return fmt.Errorf("invalid deposit policy for %s: %v", account, err)
+		}
+	}
+	for account, policy := range parameters.voluntaryExitPolicies {
+		if err := checkVoluntaryExitPolicy(policy); err != nil {
+			return fmt.Errorf("invalid voluntary exit policy for %s: %v", account, err)
+		}
+	}
+	for account, policy := range parameters.blsToExecutionChangePolicies {
+		if err := checkBLSToExecutionChangePolicy(policy); err != nil {
+			return fmt.Errorf("invalid BLS to execution change policy for %s: %v", account, err)
+		}
+	}
+	for account, policy := range parameters.validatorRegistrationPolicies {
+		if err := checkValidatorRegistrationPolicy(policy); err != nil {
+			return fmt.Errorf("invalid validator registration policy for %s: %v", account, err)
+		}
+	}
+	if err := checkValidatorIndices(parameters.validatorIndices); err != nil {
+		return err
+	}
+	if err := checkDeniedPubKeys(parameters.deniedPubKeys); err != nil {
+		return err
+	}
+	for client, paths := range parameters.createAccountPaths {
+		for i := range paths {
+			if _, err := parsePath(paths[i]); err != nil {
+				return fmt.Errorf("invalid create account path %q for client %s: %v", paths[i], client, err)
+			}
+		}
+	}
+	return checkAccountOverrides(parameters.accountOverrides)
+}
@@ -0,0 +1,127 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+)
+
+// OnPauseSigning is called when a request to pause signing for an account needs to be approved.
+// Approval pauses signing for the account until signing is resumed.
+func (s *Service) OnPauseSigning(ctx context.Context, metadata *rules.ReqMetadata, req *rules.PauseSigningData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnPauseSigning")
+	defer span.End()
+
+	return s.setSigningPaused(ctx, metadata, true)
+}
+
+// OnResumeSigning is called when a request to resume signing for an account needs to be approved.
+// Approval resumes signing for the account.
+func (s *Service) OnResumeSigning(ctx context.Context, metadata *rules.ReqMetadata, req *rules.ResumeSigningData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnResumeSigning")
+	defer span.End()
+
+	return s.setSigningPaused(ctx, metadata, false)
+}
+
+// setSigningPaused sets whether signing is paused for an account, persisting the change.
+func (s *Service) setSigningPaused(ctx context.Context, metadata *rules.ReqMetadata, paused bool) rules.Result {
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Bool("paused", paused).Logger()
+
+	if len(metadata.PubKey) != 48 {
+		log.Warn().Int("pubkey_len", len(metadata.PubKey)).Msg("Invalid public key")
+		return rules.FAILED
+	}
+	var pubKey [48]byte
+	copy(pubKey[:], metadata.PubKey)
+
+	s.pausedMu.Lock()
+	defer s.pausedMu.Unlock()
+
+	if s.paused[pubKey] == paused || rules.IsDryRun(ctx) {
+		return rules.APPROVED
+	}
+
+	updated := make(map[[48]byte]bool, len(s.paused)+1)
+	for key := range s.paused {
+		updated[key] = true
+	}
+	if paused {
+		updated[pubKey] = true
+	} else {
+		delete(updated, pubKey)
+	}
+	if err := s.storePausedSigning(ctx, updated); err != nil {
+		log.Error().Err(err).Msg("Failed to store accounts for which signing is paused")
+		return rules.FAILED
+	}
+	s.paused = updated
+	log.Info().Msg("Signing pause state changed")
+
+	return rules.APPROVED
+}
+
+// signingPaused returns true if signing is paused for the account with the given public key.
+func (s *Service) signingPaused(pubKey []byte) bool {
+	if len(pubKey) != 48 {
+		return false
+	}
+	var key [48]byte
+	copy(key[:], pubKey)
+
+	s.pausedMu.RLock()
+	defer s.pausedMu.RUnlock()
+	return s.paused[key]
+}
+
+// fetchPausedSigning fetches the accounts for which signing is paused.
+// They are held in a single entry as a version byte followed by a concatenation of public keys.
+func (s *Service) fetchPausedSigning(ctx context.Context) (map[[48]byte]bool, error) {
+	paused := make(map[[48]byte]bool)
+	data, err := s.store.Fetch(ctx, actionPauseSigning)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return paused, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 || data[0] != 0x01 {
+		return nil, errors.New("invalid version")
+	}
+	if (len(data)-1)%48 != 0 {
+		return nil, errors.New("invalid data length")
+	}
+	for i := 1; i < len(data); i += 48 {
+		var key [48]byte
+		copy(key[:], data[i:i+48])
+		paused[key] = true
+	}
+	return paused, nil
+}
+
+// storePausedSigning stores the accounts for which signing is paused.
+func (s *Service) storePausedSigning(ctx context.Context, paused map[[48]byte]bool) error {
+	data := make([]byte, 1, 1+len(paused)*48)
+	data[0] = 0x01
+	for key := range paused {
+		data = append(data, key[:]...)
+	}
+	return s.withStoreRetry(ctx, "store", func() error {
+		return s.store.Store(ctx, actionPauseSigning, data)
+	})
+}
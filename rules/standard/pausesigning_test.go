@@ -0,0 +1,77 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseSigning(t *testing.T) {
+	ctx := context.Background()
+	storagePath := t.TempDir()
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+	)
+	require.NoError(t, err)
+
+	randaoDomain := _byteStr(t, "0200000000000000000000000000000000000000000000000000000000000000")
+	pubKey := make([]byte, 48)
+	pubKey[0] = 0x01
+	otherPubKey := make([]byte, 48)
+	otherPubKey[0] = 0x02
+	metadata := &rules.ReqMetadata{PubKey: pubKey}
+	otherMetadata := &rules.ReqMetadata{PubKey: otherPubKey}
+	randaoData := func(epoch uint64) *rules.SignRANDAORevealData {
+		return &rules.SignRANDAORevealData{Domain: randaoDomain, Epoch: epoch}
+	}
+
+	// Bad public key.
+	require.Equal(t, rules.FAILED, testRules.OnPauseSigning(ctx, &rules.ReqMetadata{PubKey: []byte{0x01}}, &rules.PauseSigningData{}))
+
+	// A dry run does not pause signing.
+	require.Equal(t, rules.APPROVED, testRules.OnPauseSigning(rules.WithDryRun(ctx), metadata, &rules.PauseSigningData{}))
+	require.Equal(t, rules.APPROVED, testRules.OnSignRANDAOReveal(ctx, metadata, randaoData(1)))
+
+	// Pause signing.
+	require.Equal(t, rules.APPROVED, testRules.OnPauseSigning(ctx, metadata, &rules.PauseSigningData{}))
+	require.Equal(t, rules.DENIED, testRules.OnSignRANDAOReveal(ctx, metadata, randaoData(2)))
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{
+		Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+		Source: &rules.Checkpoint{Epoch: 1},
+		Target: &rules.Checkpoint{Epoch: 2},
+	}))
+	// Other accounts are unaffected.
+	require.Equal(t, rules.APPROVED, testRules.OnSignRANDAOReveal(ctx, otherMetadata, randaoData(2)))
+	// Non-signing operations are unaffected.
+	require.Equal(t, rules.APPROVED, testRules.OnListAccounts(ctx, metadata, &rules.AccessAccountData{}))
+
+	// Pause persists across restarts.
+	require.NoError(t, testRules.Close(ctx))
+	testRules, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	require.Equal(t, rules.DENIED, testRules.OnSignRANDAOReveal(ctx, metadata, randaoData(3)))
+
+	// Resume signing.
+	require.Equal(t, rules.APPROVED, testRules.OnResumeSigning(ctx, metadata, &rules.ResumeSigningData{}))
+	require.Equal(t, rules.APPROVED, testRules.OnSignRANDAOReveal(ctx, metadata, randaoData(4)))
+}
@@ -0,0 +1,105 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// policy is the per-client and per-account policy applied by the rules.
+// It can be replaced whilst the service is running.
+type policy struct {
+	// signDomainTypes are the domain types permitted for generic signing, by client.
+	signDomainTypes map[string][][]byte
+	// graffitiPolicies are the graffiti policies for proposals, by account, wallet or globally.
+	graffitiPolicies map[string]*GraffitiPolicy
+	// depositPolicies are the deposit policies, by account, wallet or globally.
+	depositPolicies map[string]*DepositPolicy
+	// voluntaryExitPolicies are the voluntary exit policies, by account, wallet or globally.
+	voluntaryExitPolicies map[string]*VoluntaryExitPolicy
+	// blsToExecutionChangePolicies are the BLS to execution change policies, by account, wallet or globally.
+	blsToExecutionChangePolicies map[string]*BLSToExecutionChangePolicy
+	// validatorRegistrationPolicies are the validator registration policies, by account, wallet or globally.
+	validatorRegistrationPolicies map[string]*ValidatorRegistrationPolicy
+	// validatorIndices are the expected validator indices, by public key.
+	validatorIndices map[[48]byte]uint64
+	// deniedPubKeys are the public keys for which all signing is refused.
+	deniedPubKeys map[[48]byte]bool
+	// createAccountPaths are the derivation paths under which accounts may be created, by client.
+	createAccountPaths map[string][][]uint32
+	// accountOverrides are the overrides of global rule parameters, by account.
+	accountOverrides *accountOverrides
+}
+
+// newPolicy creates a policy from checked parameters.
+func newPolicy(parameters *parameters) *policy {
+	createAccountPaths := make(map[string][][]uint32, len(parameters.createAccountPaths))
+	for client, paths := range parameters.createAccountPaths {
+		createAccountPaths[client] = make([][]uint32, len(paths))
+		for i := range paths {
+			// Paths have already been checked in checkPolicyParameters.
+			createAccountPaths[client][i], _ = parsePath(paths[i])
+		}
+	}
+
+	return &policy{
+		signDomainTypes:               parameters.signDomainTypes,
+		graffitiPolicies:              parameters.graffitiPolicies,
+		depositPolicies:               parameters.depositPolicies,
+		voluntaryExitPolicies:         parameters.voluntaryExitPolicies,
+		blsToExecutionChangePolicies:  parameters.blsToExecutionChangePolicies,
+		validatorRegistrationPolicies: parameters.validatorRegistrationPolicies,
+		validatorIndices:              newValidatorIndices(parameters.validatorIndices),
+		deniedPubKeys:                 newDeniedPubKeys(parameters.deniedPubKeys),
+		createAccountPaths:            createAccountPaths,
+		accountOverrides:              newAccountOverrides(parameters.accountOverrides),
+	}
+}
+
+// currentPolicy returns the policy currently in force.
+func (s *Service) currentPolicy() *policy {
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	return s.policy
+}
+
+// UpdatePolicy replaces the policy applied by the rules.
+// Only the policy parameters (admin IPs, sign domain types, graffiti policies, deposit policies, voluntary exit
+// policies, BLS to execution change policies, validator registration policies, validator indices, denied public
+// keys, create account paths and account overrides) are used; all other parameters are ignored.  The parameters are checked before the
+// policy is applied, so on error the existing policy remains in force.
+func (s *Service) UpdatePolicy(ctx context.Context, params ...Parameter) error {
+	var parameters parameters
+	for _, p := range params {
+		if p != nil {
+			p.apply(&parameters)
+		}
+	}
+	if err := checkPolicyParameters(&parameters); err != nil {
+		return errors.Wrap(err, "problem with policy parameters")
+	}
+	if accountOverridesHaveLimits(parameters.accountOverrides) && s.genesisTime.IsZero() {
+		return errors.New("problem with policy parameters: no genesis time specified")
+	}
+
+	policy := newPolicy(&parameters)
+	s.policyMu.Lock()
+	s.policy = policy
+	s.policyMu.Unlock()
+	log.Info().Msg("Updated policy")
+
+	return nil
+}
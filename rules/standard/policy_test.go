@@ -0,0 +1,70 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatePolicy(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithSignDomainTypes(map[string][][]byte{
+			"restricted": {_byteStr(t, "02000000")},
+		}),
+	)
+	require.NoError(t, err)
+
+	metadata := &rules.ReqMetadata{
+		Client: "restricted",
+	}
+	req := &rules.SignData{
+		Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+		Domain: _byteStr(t, "0500000000000000000000000000000000000000000000000000000000000000"),
+	}
+	require.Equal(t, rules.DENIED, testRules.OnSign(ctx, metadata, req))
+
+	// Invalid policy should be rejected and leave the existing policy in place.
+	require.EqualError(t, testRules.UpdatePolicy(ctx,
+		standardrules.WithSignDomainTypes(map[string][][]byte{
+			"restricted": {_byteStr(t, "05")},
+		}),
+	), "problem with policy parameters: sign domain type 0x05 for client restricted must be 4 bytes")
+	require.Equal(t, rules.DENIED, testRules.OnSign(ctx, metadata, req))
+
+	// Valid policy should be applied.
+	require.NoError(t, testRules.UpdatePolicy(ctx,
+		standardrules.WithSignDomainTypes(map[string][][]byte{
+			"restricted": {_byteStr(t, "05000000")},
+		}),
+	))
+	require.Equal(t, rules.APPROVED, testRules.OnSign(ctx, metadata, req))
+
+	// Empty policy should remove the restriction.
+	require.NoError(t, testRules.UpdatePolicy(ctx))
+	req.Domain = _byteStr(t, "0200000000000000000000000000000000000000000000000000000000000000")
+	require.Equal(t, rules.APPROVED, testRules.OnSign(ctx, metadata, req))
+}
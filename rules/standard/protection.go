@@ -0,0 +1,208 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/attestantio/dirk/services/locker"
+	badger "github.com/dgraph-io/badger/v2"
+	"github.com/pkg/errors"
+)
+
+// ErrFenced is returned when marks are set under a lock that has since been acquired by another holder.
+var ErrFenced = errors.New("lock acquired by another holder")
+
+// ProposalMark is the high-water mark for proposals signed by a key.
+type ProposalMark struct {
+	// Slot is the highest slot for which a proposal has been signed, or -1 if none has been signed.
+	Slot int64
+	// EpochProposals is the number of proposals signed in the epoch of Slot.
+	// A value of 0 is treated as 1, as a proposal was signed at Slot.
+	EpochProposals uint64
+}
+
+// AttestationMark is the high-water mark for attestations signed by a key.
+type AttestationMark struct {
+	// SourceEpoch is the highest source epoch of a signed attestation, or -1 if none has been signed.
+	SourceEpoch int64
+	// TargetEpoch is the highest target epoch of a signed attestation, or -1 if none has been signed.
+	TargetEpoch int64
+}
+
+// SlashingProtection stores the high-water marks used to protect keys from slashing.
+//
+// Implementations must honour the following contract:
+//   - all methods are safe for concurrent use;
+//   - fetching the marks for a key without any returns marks of -1, rather than an error;
+//   - marks are stored as supplied; it is the caller that ensures they only move forward;
+//   - a set is durable once it returns without error;
+//   - a set is atomic: a concurrent fetch returns either the previous or the new marks, never a mix of the two;
+//   - setting attestation marks for multiple keys is atomic across all of the keys;
+//   - errors are returned only for failures of the underlying storage, and may be retried if transient;
+//   - if the context carries a fencing token for a key (see locker.FencingToken), a set fails with ErrFenced
+//     if marks for the key have been set with a higher fencing token, and otherwise records the token
+//     atomically with the marks.
+//
+// Implementations do not need to serialise updates to the marks for a key.  Dirk holds the account lock
+// for a key from fetching its marks until the updated marks are set, so there is never more than one
+// update for a key in progress.  Fencing tokens guard against a holder that has lost its lock, for example
+// because it was held in an external service, setting marks after another holder has acquired the lock.
+type SlashingProtection interface {
+	// ProposalSlot fetches the proposal mark for a key.
+	ProposalSlot(ctx context.Context, pubKey []byte) (*ProposalMark, error)
+	// SetProposalSlot sets the proposal mark for a key.
+	SetProposalSlot(ctx context.Context, pubKey []byte, mark *ProposalMark) error
+	// AttestationEpochs fetches the attestation mark for a key.
+	AttestationEpochs(ctx context.Context, pubKey []byte) (*AttestationMark, error)
+	// SetAttestationEpochs sets the attestation marks for one or more keys.
+	SetAttestationEpochs(ctx context.Context, pubKeys [][]byte, marks []*AttestationMark) error
+}
+
+// slashingKey returns the store key for an action of a public key.
+func slashingKey(pubKey []byte, action []byte) []byte {
+	key := make([]byte, len(pubKey)+len(action))
+	copy(key, pubKey)
+	copy(key[len(pubKey):], action)
+	return key
+}
+
+// fencingKey returns the store key for the highest fencing token used to set the marks of a public key.
+// It is not 49 bytes in length, so is not treated as slashing protection data.
+func fencingKey(pubKey []byte) []byte {
+	return append([]byte("fence"), pubKey...)
+}
+
+// ProposalSlot fetches the proposal mark for a key.
+func (s *Store) ProposalSlot(ctx context.Context, pubKey []byte) (*ProposalMark, error) {
+	data, err := s.Fetch(ctx, slashingKey(pubKey, actionSignBeaconProposal))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return &ProposalMark{Slot: -1}, nil
+		}
+		return nil, err
+	}
+	state := &signBeaconProposalState{}
+	if err := state.Decode(data); err != nil {
+		return nil, errors.Wrap(err, "failed to decode state")
+	}
+	return &ProposalMark{
+		Slot:           state.Slot,
+		EpochProposals: state.EpochProposals,
+	}, nil
+}
+
+// SetProposalSlot sets the proposal mark for a key.
+func (s *Store) SetProposalSlot(ctx context.Context, pubKey []byte, mark *ProposalMark) error {
+	if mark == nil {
+		return errors.New("no mark supplied")
+	}
+	state := &signBeaconProposalState{
+		Slot:           mark.Slot,
+		EpochProposals: mark.EpochProposals,
+	}
+	return s.storeMarks(ctx, [][]byte{pubKey}, [][]byte{slashingKey(pubKey, actionSignBeaconProposal)}, [][]byte{state.Encode()})
+}
+
+// AttestationEpochs fetches the attestation mark for a key.
+func (s *Store) AttestationEpochs(ctx context.Context, pubKey []byte) (*AttestationMark, error) {
+	data, err := s.Fetch(ctx, slashingKey(pubKey, actionSignBeaconAttestation))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return &AttestationMark{SourceEpoch: -1, TargetEpoch: -1}, nil
+		}
+		return nil, err
+	}
+	state := &signBeaconAttestationState{}
+	if err := state.Decode(data); err != nil {
+		return nil, errors.Wrap(err, "failed to decode state")
+	}
+	return &AttestationMark{
+		SourceEpoch: state.SourceEpoch,
+		TargetEpoch: state.TargetEpoch,
+	}, nil
+}
+
+// SetAttestationEpochs sets the attestation marks for one or more keys.
+func (s *Store) SetAttestationEpochs(ctx context.Context, pubKeys [][]byte, marks []*AttestationMark) error {
+	if len(pubKeys) != len(marks) {
+		return errors.New("mismatch between number of pubkeys and number of marks")
+	}
+	keys := make([][]byte, len(pubKeys))
+	values := make([][]byte, len(marks))
+	for i := range pubKeys {
+		if marks[i] == nil {
+			return errors.New("no mark supplied")
+		}
+		keys[i] = slashingKey(pubKeys[i], actionSignBeaconAttestation)
+		state := &signBeaconAttestationState{
+			SourceEpoch: marks[i].SourceEpoch,
+			TargetEpoch: marks[i].TargetEpoch,
+		}
+		values[i] = state.Encode()
+	}
+	return s.storeMarks(ctx, pubKeys, keys, values)
+}
+
+// storeMarks stores the marks for public keys, checking the fencing tokens for the keys carried by the context.
+func (s *Store) storeMarks(ctx context.Context, pubKeys [][]byte, keys [][]byte, values [][]byte) error {
+	tokens := make([]uint64, len(pubKeys))
+	fenced := false
+	for i := range pubKeys {
+		var key [48]byte
+		copy(key[:], pubKeys[i])
+		tokens[i] = locker.FencingToken(ctx, key)
+		fenced = fenced || tokens[i] != 0
+	}
+	if !fenced {
+		if len(keys) == 1 {
+			return s.Store(ctx, keys[0], values[0])
+		}
+		return s.BatchStore(ctx, keys, values)
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		for i := range pubKeys {
+			if tokens[i] == 0 {
+				continue
+			}
+			tokenKey := fencingKey(pubKeys[i])
+			item, err := txn.Get(tokenKey)
+			switch {
+			case err == nil:
+				data, err := item.ValueCopy(nil)
+				if err != nil {
+					return err
+				}
+				if len(data) == 8 && binary.LittleEndian.Uint64(data) > tokens[i] {
+					return ErrFenced
+				}
+			case err != badger.ErrKeyNotFound:
+				return err
+			}
+			data := make([]byte, 8)
+			binary.LittleEndian.PutUint64(data, tokens[i])
+			if err := txn.Set(tokenKey, data); err != nil {
+				return err
+			}
+		}
+		for i := range keys {
+			if err := txn.Set(keys[i], values[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
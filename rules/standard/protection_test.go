@@ -0,0 +1,32 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/rules/standard/protectiontest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSlashingProtection(t *testing.T) {
+	protectiontest.Run(t, func(t *testing.T) standardrules.SlashingProtection {
+		store, err := standardrules.NewStore(t.TempDir())
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, store.Close(context.Background())) })
+		return store
+	})
+}
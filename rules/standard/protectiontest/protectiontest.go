@@ -0,0 +1,248 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package protectiontest provides a suite of tests that implementations of slashing protection storage
+// can run to confirm that they honour the contract of standard.SlashingProtection.
+package protectiontest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/locker"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the compliance tests against slashing protection storage.
+// newProtection is called to create empty storage for each test.
+func Run(t *testing.T, newProtection func(t *testing.T) standardrules.SlashingProtection) {
+	t.Run("Empty", func(t *testing.T) { testEmpty(t, newProtection(t)) })
+	t.Run("Proposal", func(t *testing.T) { testProposal(t, newProtection(t)) })
+	t.Run("Attestations", func(t *testing.T) { testAttestations(t, newProtection(t)) })
+	t.Run("AttestationsMismatch", func(t *testing.T) { testAttestationsMismatch(t, newProtection(t)) })
+	t.Run("Race", func(t *testing.T) { testRace(t, newProtection(t)) })
+	t.Run("Fencing", func(t *testing.T) { testFencing(t, newProtection(t)) })
+}
+
+// pubKey returns a public key for the given index.
+func pubKey(i int) []byte {
+	key := make([]byte, 48)
+	key[0] = byte(i >> 8)
+	key[1] = byte(i)
+	return key
+}
+
+func testEmpty(t *testing.T, protection standardrules.SlashingProtection) {
+	ctx := context.Background()
+
+	proposal, err := protection.ProposalSlot(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), proposal.Slot)
+
+	attestation, err := protection.AttestationEpochs(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), attestation.SourceEpoch)
+	require.Equal(t, int64(-1), attestation.TargetEpoch)
+}
+
+func testProposal(t *testing.T, protection standardrules.SlashingProtection) {
+	ctx := context.Background()
+
+	require.NoError(t, protection.SetProposalSlot(ctx, pubKey(1), &standardrules.ProposalMark{Slot: 10}))
+	mark, err := protection.ProposalSlot(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), mark.Slot)
+
+	require.NoError(t, protection.SetProposalSlot(ctx, pubKey(1), &standardrules.ProposalMark{Slot: 11, EpochProposals: 2}))
+	mark, err = protection.ProposalSlot(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(11), mark.Slot)
+	require.Equal(t, uint64(2), mark.EpochProposals)
+
+	// Marks are stored as supplied, even if lower.
+	require.NoError(t, protection.SetProposalSlot(ctx, pubKey(1), &standardrules.ProposalMark{Slot: 5}))
+	mark, err = protection.ProposalSlot(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(5), mark.Slot)
+
+	// Other keys and actions are unaffected.
+	mark, err = protection.ProposalSlot(ctx, pubKey(2))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), mark.Slot)
+	attestation, err := protection.AttestationEpochs(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), attestation.TargetEpoch)
+}
+
+func testAttestations(t *testing.T, protection standardrules.SlashingProtection) {
+	ctx := context.Background()
+
+	require.NoError(t, protection.SetAttestationEpochs(ctx,
+		[][]byte{pubKey(1), pubKey(2)},
+		[]*standardrules.AttestationMark{
+			{SourceEpoch: 1, TargetEpoch: 2},
+			{SourceEpoch: 3, TargetEpoch: 4},
+		},
+	))
+	mark, err := protection.AttestationEpochs(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, &standardrules.AttestationMark{SourceEpoch: 1, TargetEpoch: 2}, mark)
+	mark, err = protection.AttestationEpochs(ctx, pubKey(2))
+	require.NoError(t, err)
+	require.Equal(t, &standardrules.AttestationMark{SourceEpoch: 3, TargetEpoch: 4}, mark)
+
+	// Other keys and actions are unaffected.
+	mark, err = protection.AttestationEpochs(ctx, pubKey(3))
+	require.NoError(t, err)
+	require.Equal(t, &standardrules.AttestationMark{SourceEpoch: -1, TargetEpoch: -1}, mark)
+	proposal, err := protection.ProposalSlot(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), proposal.Slot)
+}
+
+func testAttestationsMismatch(t *testing.T, protection standardrules.SlashingProtection) {
+	ctx := context.Background()
+
+	require.Error(t, protection.SetAttestationEpochs(ctx,
+		[][]byte{pubKey(1), pubKey(2)},
+		[]*standardrules.AttestationMark{
+			{SourceEpoch: 1, TargetEpoch: 2},
+		},
+	))
+	mark, err := protection.AttestationEpochs(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), mark.TargetEpoch)
+}
+
+// testFencing sets marks under fencing tokens, as when holding locks that can be lost.  Marks set with a
+// token lower than one already used for the key must be refused, and leave the marks unchanged.
+func testFencing(t *testing.T, protection standardrules.SlashingProtection) {
+	ctx := context.Background()
+	var key1 [48]byte
+	copy(key1[:], pubKey(1))
+	var key2 [48]byte
+	copy(key2[:], pubKey(2))
+	fenced := func(token1 uint64, token2 uint64) context.Context {
+		return locker.WithFencingTokens(ctx, map[[48]byte]uint64{key1: token1, key2: token2})
+	}
+
+	require.NoError(t, protection.SetProposalSlot(fenced(2, 0), pubKey(1), &standardrules.ProposalMark{Slot: 10}))
+	// The same token may be used for multiple sets, as while a lock is held.
+	require.NoError(t, protection.SetProposalSlot(fenced(2, 0), pubKey(1), &standardrules.ProposalMark{Slot: 11}))
+
+	// A lower token is refused.
+	err := protection.SetProposalSlot(fenced(1, 0), pubKey(1), &standardrules.ProposalMark{Slot: 12})
+	require.True(t, errors.Is(err, standardrules.ErrFenced))
+	mark, err := protection.ProposalSlot(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(11), mark.Slot)
+
+	// Tokens apply to all marks for the key, and a refused batch leaves all of its keys unchanged.
+	err = protection.SetAttestationEpochs(fenced(1, 1),
+		[][]byte{pubKey(2), pubKey(1)},
+		[]*standardrules.AttestationMark{
+			{SourceEpoch: 1, TargetEpoch: 2},
+			{SourceEpoch: 1, TargetEpoch: 2},
+		},
+	)
+	require.True(t, errors.Is(err, standardrules.ErrFenced))
+	attestation, err := protection.AttestationEpochs(ctx, pubKey(2))
+	require.NoError(t, err)
+	require.Equal(t, int64(-1), attestation.TargetEpoch)
+
+	// A higher token is accepted, and supersedes the previous token.
+	require.NoError(t, protection.SetProposalSlot(fenced(3, 0), pubKey(1), &standardrules.ProposalMark{Slot: 12}))
+	err = protection.SetProposalSlot(fenced(2, 0), pubKey(1), &standardrules.ProposalMark{Slot: 13})
+	require.True(t, errors.Is(err, standardrules.ErrFenced))
+	mark, err = protection.ProposalSlot(ctx, pubKey(1))
+	require.NoError(t, err)
+	require.Equal(t, int64(12), mark.Slot)
+}
+
+// testRace updates the marks for a number of keys concurrently, serialising updates for each key as Dirk
+// does with its account lock, while other goroutines read the marks.  Readers must never see a partial
+// update, and no update may be lost.
+func testRace(t *testing.T, protection standardrules.SlashingProtection) {
+	ctx := context.Background()
+
+	const keys = 8
+	const updates = 50
+	locks := make([]sync.Mutex, keys)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, keys*4)
+	for i := 0; i < keys; i++ {
+		for w := 0; w < 2; w++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for u := 0; u < updates; u++ {
+					locks[i].Lock()
+					mark, err := protection.AttestationEpochs(ctx, pubKey(i))
+					if err == nil {
+						err = protection.SetAttestationEpochs(ctx,
+							[][]byte{pubKey(i)},
+							[]*standardrules.AttestationMark{{SourceEpoch: mark.SourceEpoch + 1, TargetEpoch: mark.TargetEpoch + 1}},
+						)
+					}
+					if err == nil {
+						var proposal *standardrules.ProposalMark
+						proposal, err = protection.ProposalSlot(ctx, pubKey(i))
+						if err == nil {
+							err = protection.SetProposalSlot(ctx, pubKey(i), &standardrules.ProposalMark{Slot: proposal.Slot + 1})
+						}
+					}
+					locks[i].Unlock()
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}(i)
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for u := 0; u < updates; u++ {
+				mark, err := protection.AttestationEpochs(ctx, pubKey(i))
+				if err != nil {
+					errs <- err
+					return
+				}
+				// Source and target are always updated together.
+				if mark.SourceEpoch != mark.TargetEpoch {
+					errs <- fmt.Errorf("partial update observed for key %d: %d/%d", i, mark.SourceEpoch, mark.TargetEpoch)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	for i := 0; i < keys; i++ {
+		mark, err := protection.AttestationEpochs(ctx, pubKey(i))
+		require.NoError(t, err)
+		require.Equal(t, int64(2*updates-1), mark.TargetEpoch)
+		proposal, err := protection.ProposalSlot(ctx, pubKey(i))
+		require.NoError(t, err)
+		require.Equal(t, int64(2*updates-1), proposal.Slot)
+	}
+}
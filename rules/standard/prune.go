@@ -0,0 +1,259 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PruneSummary summarises a pruning of the slashing protection store.
+type PruneSummary struct {
+	// Records is the number of slashing protection records examined.
+	Records int
+	// Finalized is the number of records whose mark is older than the finalized epoch.
+	Finalized int
+	// Removed is the number of records removed as their mark is older than the prune horizon.
+	Removed int
+	// FinalizedEpoch is the finalized epoch against which the store was pruned.
+	FinalizedEpoch uint64
+	// HorizonEpoch is the epoch before which marks have been removed, or 0 if no marks have been removed.
+	HorizonEpoch uint64
+	// Duration is the time taken to prune the store.
+	Duration time.Duration
+}
+
+// Prune prunes the slashing protection store of marks older than the finalized epoch.
+//
+// The store holds a single mark for each public key and action, being the highest signed.  Older marks are
+// superseded rather than deleted when signing, so pruning discards them by compacting the store.
+//
+// If marks are retained for a limited number of epochs, the mark for a key that has not signed within that
+// many epochs of the highest epoch in the store, and before the finalized epoch, is also removed.  The epoch
+// before which marks are removed, the prune horizon, is held in the store; a key without a mark cannot sign an
+// attestation with a source epoch, or a proposal in an epoch, before the horizon, so removing a mark never
+// allows a slashable signature.
+func (s *Service) Prune(ctx context.Context, finalizedEpoch uint64) (*PruneSummary, error) {
+	started := time.Now()
+
+	entries, err := s.store.FetchAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch slashing protection entries")
+	}
+
+	horizon, err := s.raisePruneHorizon(ctx, entries, finalizedEpoch)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &PruneSummary{
+		FinalizedEpoch: finalizedEpoch,
+		HorizonEpoch:   horizon,
+	}
+	for key := range entries {
+		summary.Records++
+		finalized, removed, err := s.pruneMark(ctx, key, finalizedEpoch, horizon)
+		if err != nil {
+			return nil, err
+		}
+		if finalized {
+			summary.Finalized++
+		}
+		if removed {
+			summary.Removed++
+		}
+	}
+
+	if err := s.store.Compact(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to compact store")
+	}
+	summary.Duration = time.Since(started)
+
+	return summary, nil
+}
+
+// PruneNow prunes the slashing protection store with the finalized epoch obtained from the chain time.
+func (s *Service) PruneNow(ctx context.Context) (*PruneSummary, error) {
+	return s.Prune(ctx, s.finalizedEpoch())
+}
+
+// raisePruneHorizon raises the prune horizon to the configured number of epochs behind the highest epoch
+// of the marks, but no further than the finalized epoch, if this is above the current horizon, returning
+// the resultant horizon.
+// The horizon is stored before any marks are removed, so that it is in place should pruning be interrupted.
+func (s *Service) raisePruneHorizon(ctx context.Context, entries map[[49]byte][]byte, finalizedEpoch uint64) (uint64, error) {
+	current := atomic.LoadUint64(&s.pruneHorizon)
+	if s.pruneRetainEpochs == 0 {
+		return current, nil
+	}
+
+	highest := uint64(0)
+	for key := range entries {
+		epoch, exists, err := s.markEpoch(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		if exists && epoch > highest {
+			highest = epoch
+		}
+	}
+	if highest <= s.pruneRetainEpochs {
+		return current, nil
+	}
+	horizon := highest - s.pruneRetainEpochs
+	if horizon > finalizedEpoch {
+		horizon = finalizedEpoch
+	}
+	if horizon <= current {
+		return current, nil
+	}
+
+	if err := s.storePruneHorizon(ctx, horizon); err != nil {
+		return 0, errors.Wrap(err, "failed to store prune horizon")
+	}
+	atomic.StoreUint64(&s.pruneHorizon, horizon)
+	log.Info().Uint64("previous_epoch", current).Uint64("epoch", horizon).Msg("Raised prune horizon")
+
+	return horizon, nil
+}
+
+// pruneMark returns true if the current mark for the given key is older than the finalized epoch, and removes
+// the mark if it is older than the prune horizon.  The mark is read and removed under the account lock, so that
+// it is not observed or removed part-way through a signing.
+func (s *Service) pruneMark(ctx context.Context, key [49]byte, finalizedEpoch uint64, horizon uint64) (bool, bool, error) {
+	if s.locker != nil {
+		var pubKey [48]byte
+		copy(pubKey[:], key[:48])
+		if err := s.locker.Lock(ctx, pubKey); err != nil {
+			return false, false, errors.Wrap(err, "failed to lock account")
+		}
+		defer s.locker.Unlock(pubKey)
+	}
+
+	epoch, exists, err := s.markEpoch(ctx, key)
+	if err != nil {
+		return false, false, err
+	}
+	if !exists {
+		return false, false, nil
+	}
+	finalized := epoch < finalizedEpoch
+	if epoch >= horizon {
+		return finalized, false, nil
+	}
+
+	err = s.withStoreRetry(ctx, "delete", func() error {
+		return s.store.Delete(ctx, key[:])
+	})
+	if err != nil {
+		return false, false, errors.Wrap(err, "failed to remove mark")
+	}
+	return finalized, true, nil
+}
+
+// markEpoch returns the epoch of the current mark for the given key, and false if the key does not hold a mark.
+// Marks that cannot be decoded are left for integrity verification to report.
+func (s *Service) markEpoch(ctx context.Context, key [49]byte) (uint64, bool, error) {
+	switch key[48] {
+	case actionSignBeaconAttestation[0]:
+		state, err := s.fetchSignBeaconAttestationState(ctx, key[:48])
+		if err != nil || state.TargetEpoch < 0 {
+			return 0, false, nil
+		}
+		return uint64(state.TargetEpoch), true, nil
+	case actionSignBeaconProposal[0]:
+		state, err := s.fetchSignBeaconProposalState(ctx, key[:48])
+		if err != nil || state.Slot < 0 {
+			return 0, false, nil
+		}
+		return uint64(state.Slot) / s.slotsPerEpoch, true, nil
+	default:
+		return 0, false, nil
+	}
+}
+
+// fetchPruneHorizon fetches the prune horizon from the store, returning 0 if there is none.
+// It is held as a version byte followed by the epoch.
+func (s *Service) fetchPruneHorizon(ctx context.Context) (uint64, error) {
+	data, err := s.store.Fetch(ctx, actionPruneHorizon)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(data) == 0 || data[0] != 0x01 {
+		return 0, errors.New("invalid version")
+	}
+	if len(data) != 9 {
+		return 0, errors.New("invalid data length")
+	}
+	return binary.LittleEndian.Uint64(data[1:9]), nil
+}
+
+// storePruneHorizon stores the prune horizon.
+func (s *Service) storePruneHorizon(ctx context.Context, epoch uint64) error {
+	data := make([]byte, 9)
+	data[0] = 0x01
+	binary.LittleEndian.PutUint64(data[1:9], epoch)
+	return s.withStoreRetry(ctx, "store", func() error {
+		return s.store.Store(ctx, actionPruneHorizon, data)
+	})
+}
+
+// attestationBeforePruneHorizon returns true if an attestation with the source epoch could surround or
+// duplicate a mark that has been removed by pruning.
+func (s *Service) attestationBeforePruneHorizon(sourceEpoch uint64) bool {
+	horizon := atomic.LoadUint64(&s.pruneHorizon)
+	return horizon != 0 && sourceEpoch < horizon
+}
+
+// proposalBeforePruneHorizon returns true if a proposal for the slot could duplicate a mark that has been
+// removed by pruning.
+func (s *Service) proposalBeforePruneHorizon(slot uint64) bool {
+	horizon := atomic.LoadUint64(&s.pruneHorizon)
+	return horizon != 0 && slot/s.slotsPerEpoch < horizon
+}
+
+// schedulePruning prunes the slashing protection store at the configured interval until the context is cancelled.
+func (s *Service) schedulePruning(ctx context.Context) {
+	defer close(s.pruneDone)
+
+	ticker := time.NewTicker(s.pruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			summary, err := s.Prune(ctx, s.finalizedEpoch())
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to prune slashing protection store")
+				continue
+			}
+			log.Info().
+				Int("records", summary.Records).
+				Int("finalized", summary.Finalized).
+				Int("removed", summary.Removed).
+				Uint64("finalized_epoch", summary.FinalizedEpoch).
+				Uint64("horizon_epoch", summary.HorizonEpoch).
+				Dur("duration", summary.Duration).
+				Msg("Pruned slashing protection store")
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneParameters(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithPruneInterval(-1*time.Second),
+	)
+	require.EqualError(t, err, "problem with parameters: prune interval cannot be negative")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithPruneInterval(time.Minute),
+	)
+	require.EqualError(t, err, "problem with parameters: no locker specified for scheduled pruning")
+
+	protection, err := standardrules.NewStore(t.TempDir())
+	require.NoError(t, err)
+	defer protection.Close(ctx)
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithSlashingProtection(protection),
+		standardrules.WithPruneRetainEpochs(10),
+	)
+	require.EqualError(t, err, "problem with parameters: marks cannot be removed from external slashing protection")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithPruneRetainEpochs(10),
+	)
+	require.EqualError(t, err, "problem with parameters: no genesis time specified for removal of marks")
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithLocker(locker),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	attestationDomain := _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000")
+	proposalDomain := _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000")
+	oldPubKey := make([]byte, 48)
+	oldPubKey[0] = 0x01
+	newPubKey := make([]byte, 48)
+	newPubKey[0] = 0x02
+
+	// Build up a history of marks for both keys.
+	for epoch := uint64(1); epoch <= 10; epoch++ {
+		for _, pubKey := range [][]byte{oldPubKey, newPubKey} {
+			require.Equal(t, rules.APPROVED, testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey}, &rules.SignBeaconAttestationData{
+				Domain: attestationDomain,
+				Source: &rules.Checkpoint{Epoch: epoch - 1},
+				Target: &rules.Checkpoint{Epoch: epoch},
+			}))
+			require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: pubKey}, &rules.SignBeaconProposalData{
+				Domain: proposalDomain,
+				Slot:   epoch * 32,
+			}))
+		}
+	}
+	// Only the new key continues signing.
+	for epoch := uint64(11); epoch <= 20; epoch++ {
+		require.Equal(t, rules.APPROVED, testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: newPubKey}, &rules.SignBeaconAttestationData{
+			Domain: attestationDomain,
+			Source: &rules.Checkpoint{Epoch: epoch - 1},
+			Target: &rules.Checkpoint{Epoch: epoch},
+		}))
+		require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: newPubKey}, &rules.SignBeaconProposalData{
+			Domain: proposalDomain,
+			Slot:   epoch * 32,
+		}))
+	}
+
+	summary, err := testRules.Prune(ctx, 15)
+	require.NoError(t, err)
+	require.Equal(t, 4, summary.Records)
+	require.Equal(t, 2, summary.Finalized)
+
+	// Protection is unaffected for both keys.
+	for _, test := range []struct {
+		pubKey []byte
+		epoch  uint64
+	}{
+		{pubKey: oldPubKey, epoch: 10},
+		{pubKey: newPubKey, epoch: 20},
+	} {
+		require.Equal(t, rules.DENIED, testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, &rules.SignBeaconAttestationData{
+			Domain: attestationDomain,
+			Source: &rules.Checkpoint{Epoch: test.epoch - 1},
+			Target: &rules.Checkpoint{Epoch: test.epoch},
+		}))
+		require.Equal(t, rules.DENIED, testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, &rules.SignBeaconAttestationData{
+			Domain: attestationDomain,
+			Source: &rules.Checkpoint{Epoch: 0},
+			Target: &rules.Checkpoint{Epoch: 1},
+		}))
+		require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, &rules.SignBeaconProposalData{
+			Domain: proposalDomain,
+			Slot:   test.epoch * 32,
+		}))
+		require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, &rules.SignBeaconProposalData{
+			Domain: proposalDomain,
+			Slot:   32,
+		}))
+		require.Equal(t, rules.APPROVED, testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, &rules.SignBeaconAttestationData{
+			Domain: attestationDomain,
+			Source: &rules.Checkpoint{Epoch: test.epoch},
+			Target: &rules.Checkpoint{Epoch: test.epoch + 1},
+		}))
+	}
+}
+
+func TestScheduledPrune(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithLocker(locker),
+		standardrules.WithPruneInterval(10*time.Millisecond),
+		standardrules.WithGenesisTime(time.Now().Add(-100*32*12*time.Second)),
+	)
+	require.NoError(t, err)
+
+	pubKey := make([]byte, 48)
+	attestationDomain := _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000")
+	for epoch := uint64(1); epoch <= 5; epoch++ {
+		require.Equal(t, rules.APPROVED, testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey}, &rules.SignBeaconAttestationData{
+			Domain: attestationDomain,
+			Source: &rules.Checkpoint{Epoch: epoch - 1},
+			Target: &rules.Checkpoint{Epoch: epoch},
+		}))
+		time.Sleep(15 * time.Millisecond)
+	}
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey}, &rules.SignBeaconAttestationData{
+		Domain: attestationDomain,
+		Source: &rules.Checkpoint{Epoch: 4},
+		Target: &rules.Checkpoint{Epoch: 5},
+	}))
+
+	// Close stops scheduled pruning.
+	require.NoError(t, testRules.Close(ctx))
+}
+
+func TestPruneRetainEpochs(t *testing.T) {
+	ctx := context.Background()
+	storagePath := t.TempDir()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+		standardrules.WithLocker(locker),
+		standardrules.WithGenesisTime(time.Now().Add(-100*32*12*time.Second)),
+		standardrules.WithPruneRetainEpochs(5),
+	)
+	require.NoError(t, err)
+
+	attestationDomain := _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000")
+	proposalDomain := _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000")
+	oldPubKey := make([]byte, 48)
+	oldPubKey[0] = 0x01
+	newPubKey := make([]byte, 48)
+	newPubKey[0] = 0x02
+	unusedPubKey := make([]byte, 48)
+	unusedPubKey[0] = 0x03
+	attestation := func(pubKey []byte, sourceEpoch uint64, targetEpoch uint64) rules.Result {
+		return testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey}, &rules.SignBeaconAttestationData{
+			Domain: attestationDomain,
+			Source: &rules.Checkpoint{Epoch: sourceEpoch},
+			Target: &rules.Checkpoint{Epoch: targetEpoch},
+		})
+	}
+	proposal := func(pubKey []byte, slot uint64) rules.Result {
+		return testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: pubKey}, &rules.SignBeaconProposalData{
+			Domain: proposalDomain,
+			Slot:   slot,
+		})
+	}
+
+	// The old key stops signing at epoch 10, the new key continues to epoch 20.
+	for epoch := uint64(1); epoch <= 20; epoch++ {
+		if epoch <= 10 {
+			require.Equal(t, rules.APPROVED, attestation(oldPubKey, epoch-1, epoch))
+			require.Equal(t, rules.APPROVED, proposal(oldPubKey, epoch*32))
+		}
+		require.Equal(t, rules.APPROVED, attestation(newPubKey, epoch-1, epoch))
+		require.Equal(t, rules.APPROVED, proposal(newPubKey, epoch*32))
+	}
+
+	// The horizon does not pass the finalized epoch.
+	summary, err := testRules.Prune(ctx, 10)
+	require.NoError(t, err)
+	require.Equal(t, 4, summary.Records)
+	require.Equal(t, 0, summary.Removed)
+	require.Equal(t, uint64(10), summary.HorizonEpoch)
+
+	summary, err = testRules.PruneNow(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, summary.Records)
+	require.Equal(t, 2, summary.Removed)
+	require.Equal(t, uint64(98), summary.FinalizedEpoch)
+	require.Equal(t, uint64(15), summary.HorizonEpoch)
+
+	// Pruning again does not remove anything further.
+	summary, err = testRules.PruneNow(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, summary.Records)
+	require.Equal(t, 0, summary.Removed)
+	require.Equal(t, uint64(15), summary.HorizonEpoch)
+	require.NoError(t, testRules.Close(ctx))
+
+	// The horizon persists across restarts.
+	testRules, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+		standardrules.WithLocker(locker),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	// Keys without marks cannot sign before the horizon.
+	require.Equal(t, rules.DENIED, attestation(oldPubKey, 9, 11))
+	require.Equal(t, rules.DENIED, attestation(oldPubKey, 14, 16))
+	require.Equal(t, rules.DENIED, proposal(oldPubKey, 11*32))
+	require.Equal(t, rules.DENIED, proposal(oldPubKey, 15*32-1))
+	require.Equal(t, rules.DENIED, attestation(unusedPubKey, 14, 21))
+	require.Equal(t, rules.APPROVED, attestation(oldPubKey, 15, 16))
+	require.Equal(t, rules.APPROVED, proposal(oldPubKey, 15*32))
+	require.Equal(t, rules.APPROVED, attestation(unusedPubKey, 15, 21))
+
+	// Keys with marks are unaffected.
+	require.Equal(t, rules.DENIED, attestation(newPubKey, 19, 20))
+	require.Equal(t, rules.APPROVED, attestation(newPubKey, 20, 21))
+	require.Equal(t, rules.DENIED, proposal(newPubKey, 20*32))
+	require.Equal(t, rules.APPROVED, proposal(newPubKey, 21*32))
+}
@@ -0,0 +1,38 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	"go.opentelemetry.io/otel"
+)
+
+// OnRecoverAccount is called when a request to release a share of a distributed account for recovery needs to be approved.
+// As with export, signing must be paused for the account, so that the recovered key is not used to sign both by the
+// participants and elsewhere.
+func (s *Service) OnRecoverAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.RecoverAccountData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnRecoverAccount")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Uint64("coordinator", req.Coordinator).Str("rule", "recover account").Logger()
+
+	if !s.signingPaused(metadata.PubKey) {
+		log.Debug().Msg("Signing not paused for account; not releasing share")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	return rules.APPROVED
+}
@@ -0,0 +1,60 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// withStoreRetry carries out an operation against the store, retrying with exponential backoff
+// if the operation fails with a transient error.  Retries stop when the maximum number of attempts
+// is reached, or if the next attempt would fall beyond the context's deadline.
+func (s *Service) withStoreRetry(ctx context.Context, operation string, f func() error) error {
+	backoff := s.storeRetryBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = f()
+		if err == nil || !isTransientStoreError(err) || attempt >= s.storeMaxAttempts {
+			return err
+		}
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Now().Add(backoff).After(deadline) {
+			return err
+		}
+		log.Debug().Err(err).Str("operation", operation).Int("attempt", attempt).Dur("backoff", backoff).Msg("Transient store error; retrying")
+		s.monitor.StoreRetried(operation)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientStoreError returns true if the error is one that may succeed if retried.
+func isTransientStoreError(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutError is a network error that has timed out.
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "i/o timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+// countingMonitor counts store retries.
+type countingMonitor struct {
+	retries int
+}
+
+func (m *countingMonitor) StoreRetried(operation string) {
+	m.retries++
+}
+
+func (m *countingMonitor) SlashingPrevented(action string) {}
+
+func (m *countingMonitor) StoreBreakerState(state string) {}
+
+func TestIsTransientStoreError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		res  bool
+	}{
+		{
+			name: "NotFound",
+			err:  ErrNotFound,
+			res:  false,
+		},
+		{
+			name: "ConnectionRefused",
+			err:  fmt.Errorf("dial: %w", syscall.ECONNREFUSED),
+			res:  true,
+		},
+		{
+			name: "ConnectionReset",
+			err:  fmt.Errorf("read: %w", syscall.ECONNRESET),
+			res:  true,
+		},
+		{
+			name: "Timeout",
+			err:  fmt.Errorf("read: %w", &timeoutError{}),
+			res:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.res, isTransientStoreError(test.err))
+		})
+	}
+}
+
+func TestWithStoreRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		errs     []error
+		err      string
+		attempts int
+	}{
+		{
+			name:     "Success",
+			errs:     []error{nil},
+			attempts: 1,
+		},
+		{
+			name:     "LogicalError",
+			errs:     []error{ErrNotFound},
+			err:      "not found",
+			attempts: 1,
+		},
+		{
+			name:     "TransientThenSuccess",
+			errs:     []error{syscall.ECONNREFUSED, &timeoutError{}, nil},
+			attempts: 3,
+		},
+		{
+			name:     "TransientExhausted",
+			errs:     []error{syscall.ECONNREFUSED, syscall.ECONNREFUSED, syscall.ECONNREFUSED, nil},
+			err:      "connection refused",
+			attempts: 3,
+		},
+		{
+			name:     "Deadline",
+			timeout:  5 * time.Millisecond,
+			errs:     []error{syscall.ECONNREFUSED, nil},
+			err:      "connection refused",
+			attempts: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+			monitor := &countingMonitor{}
+			s := &Service{
+				monitor:           monitor,
+				storeMaxAttempts:  3,
+				storeRetryBackoff: 10 * time.Millisecond,
+			}
+			attempts := 0
+			err := s.withStoreRetry(ctx, "test", func() error {
+				err := test.errs[attempts]
+				attempts++
+				return err
+			})
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, test.attempts, attempts)
+			assert.Equal(t, test.attempts-1, monitor.retries)
+		})
+	}
+}
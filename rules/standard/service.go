@@ -15,16 +15,82 @@ package standard
 
 import (
 	"context"
+	"sync"
+	"time"
 
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/locker"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/util/clock"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
 )
 
 // Service is the structure that keeps track of rules.
 type Service struct {
-	store    *Store
-	adminIPs []string
+	monitor    metrics.RulesMonitor
+	store      *Store
+	protection SlashingProtection
+	// policy is the per-client and per-account policy.
+	policyMu sync.RWMutex
+	policy   *policy
+	// forkDataRoots are the fork data roots for the network, if configured.
+	forkDataRoots [][]byte
+	// forkSchedule is the schedule of forks for the network, if configured.
+	forkSchedule *forkSchedule
+	// depositDomain is the deposit domain for the network, if configured.
+	depositDomain []byte
+	// builderDomain is the validator registration domain for the network, if configured.
+	builderDomain []byte
+	// Chain time information.
+	genesisTime   time.Time
+	slotsPerEpoch uint64
+	clock         clock.Clock
+	// Limits on requests relative to the current chain time.
+	maxFutureEpochs uint64
+	maxFutureSlots  uint64
+	// randaoRevealWindow is the number of epochs either side of the current epoch for which RANDAO reveals are signed.
+	randaoRevealWindow uint64
+	// aggregateSlotWindow is the number of slots either side of the current slot for which aggregation duties are signed.
+	aggregateSlotWindow uint64
+	// syncCommitteeWindow is the number of slots either side of the current slot for which sync committee duties are signed.
+	syncCommitteeWindow uint64
+	// Guards against proposals that are too close together.
+	minProposalSlotGap   uint64
+	maxProposalsPerEpoch uint64
+	// Retry of transient store errors.
+	storeMaxAttempts  int
+	storeRetryBackoff time.Duration
+	// Circuit breaker around the store, if configured.
+	storeBreaker *storeBreaker
+	// Integrity verification of the store.
+	strictIntegrity  bool
+	signingHalted    int32
+	integrityChecked chan struct{}
+	// Pruning of the store.
+	locker            locker.Service
+	pruneInterval     time.Duration
+	pruneFinalityLag  uint64
+	pruneRetainEpochs uint64
+	pruneCancel       context.CancelFunc
+	pruneDone         chan struct{}
+	// Epoch before which marks have been removed from the store; accessed atomically.
+	pruneHorizon uint64
+	// Accounts for which signing is paused.
+	pausedMu sync.RWMutex
+	paused   map[[48]byte]bool
+	// Slot at or below which nothing is signed for any key.
+	signingFloor uint64
+	// Source epoch, target epoch and slot below which nothing is signed for any key.
+	minimumWatermark *minimumWatermark
+	// validatorRegistrationWindow is the time either side of now within which validator registration timestamps must fall.
+	validatorRegistrationWindow time.Duration
+	// Voluntary exits awaiting a second approval, by public key.
+	voluntaryExitApprovalTimeout time.Duration
+	pendingExitsMu               sync.Mutex
+	pendingExits                 map[[48]byte]*pendingVoluntaryExit
 }
 
 // log is a module-wide log.
@@ -48,14 +114,123 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, err
 	}
 
-	return &Service{
-		store:    store,
-		adminIPs: parameters.adminIPs,
-	}, nil
+	forkDataRoots, err := calculateForkDataRoots(parameters.genesisValidatorsRoot, parameters.forkVersions)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate fork data roots")
+	}
+	forkSchedule, err := newForkSchedule(parameters.genesisValidatorsRoot, parameters.forkSchedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate fork schedule")
+	}
+
+	var depositDomain []byte
+	var builderDomain []byte
+	if parameters.genesisForkVersion != nil {
+		// The deposit domain does not commit to the genesis validators root, as deposits can be made before genesis.
+		depositDomain, err = e2types.ComputeDomain(e2types.DomainDeposit, parameters.genesisForkVersion, make([]byte, 32))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate deposit domain")
+		}
+		// The builder domain is similarly independent of the genesis validators root.
+		builderDomain, err = e2types.ComputeDomain(rules.DomainApplicationBuilder, parameters.genesisForkVersion, make([]byte, 32))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to calculate builder domain")
+		}
+	}
+
+	protection := parameters.slashingProtection
+	if protection == nil {
+		protection = store
+	}
+
+	s := &Service{
+		monitor:                      parameters.monitor,
+		store:                        store,
+		protection:                   protection,
+		policy:                       newPolicy(parameters),
+		forkDataRoots:                forkDataRoots,
+		forkSchedule:                 forkSchedule,
+		depositDomain:                depositDomain,
+		builderDomain:                builderDomain,
+		validatorRegistrationWindow:  parameters.validatorRegistrationWindow,
+		genesisTime:                  parameters.genesisTime,
+		slotsPerEpoch:                parameters.slotsPerEpoch,
+		clock:                        parameters.clock,
+		maxFutureEpochs:              parameters.maxFutureEpochs,
+		maxFutureSlots:               parameters.maxFutureSlots,
+		randaoRevealWindow:           parameters.randaoRevealWindow,
+		aggregateSlotWindow:          parameters.aggregateSlotWindow,
+		syncCommitteeWindow:          parameters.syncCommitteeWindow,
+		minProposalSlotGap:           parameters.minProposalSlotGap,
+		maxProposalsPerEpoch:         parameters.maxProposalsPerEpoch,
+		storeMaxAttempts:             parameters.storeMaxAttempts,
+		storeRetryBackoff:            parameters.storeRetryBackoff,
+		strictIntegrity:              parameters.strictIntegrity,
+		integrityChecked:             make(chan struct{}),
+		locker:                       parameters.locker,
+		pruneInterval:                parameters.pruneInterval,
+		pruneFinalityLag:             parameters.pruneFinalityLag,
+		pruneRetainEpochs:            parameters.pruneRetainEpochs,
+		pruneDone:                    make(chan struct{}),
+		voluntaryExitApprovalTimeout: parameters.voluntaryExitApprovalTimeout,
+		pendingExits:                 make(map[[48]byte]*pendingVoluntaryExit),
+	}
+
+	if parameters.storeBreakerThreshold > 0 {
+		s.storeBreaker = newStoreBreaker(parameters.monitor, parameters.storeBreakerThreshold, parameters.storeBreakerCooldown)
+		s.storeBreaker.now = s.clock.Now
+	}
+
+	s.paused, err = s.fetchPausedSigning(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain accounts for which signing is paused")
+	}
+
+	s.signingFloor, err = s.loadSigningFloor(ctx, parameters.signingFloorSlot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain signing floor")
+	}
+
+	s.minimumWatermark, err = s.loadMinimumWatermark(ctx, &minimumWatermark{
+		sourceEpoch: parameters.minimumSourceEpoch,
+		targetEpoch: parameters.minimumTargetEpoch,
+		slot:        parameters.minimumSlot,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain minimum watermark")
+	}
+
+	s.pruneHorizon, err = s.fetchPruneHorizon(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain prune horizon")
+	}
+
+	if parameters.verifyIntegrity || parameters.strictIntegrity {
+		if parameters.strictIntegrity {
+			s.signingHalted = 1
+		}
+		go s.checkIntegrity(ctx)
+	} else {
+		close(s.integrityChecked)
+	}
+
+	var pruneCtx context.Context
+	pruneCtx, s.pruneCancel = context.WithCancel(ctx)
+	if s.pruneInterval > 0 {
+		go s.schedulePruning(pruneCtx)
+	} else {
+		close(s.pruneDone)
+	}
+
+	return s, nil
 }
 
 // Close closes the database for the persistent rules information.
+// It waits for any integrity verification of the store to complete, and stops scheduled pruning.
 func (s *Service) Close(ctx context.Context) error {
+	<-s.integrityChecked
+	s.pruneCancel()
+	<-s.pruneDone
 	return s.store.Close(ctx)
 }
 
@@ -70,4 +245,15 @@ var (
 	// actionAccessAccount is the action of accessing an account.
 	// currently unused as accesing an account requires no slashing protection.
 	// actionAccessAccount = []byte{0x04}
+	// actionCreateAccount is the action of creating an account.
+	// currently unused as the paths in use are obtained from the wallet.
+	// actionCreateAccount = []byte{0x05}
+	// actionPauseSigning is the action of pausing signing for accounts.
+	actionPauseSigning = []byte{0x06}
+	// actionSigningFloor is the action of setting the slot at or below which nothing is signed.
+	actionSigningFloor = []byte{0x07}
+	// actionPruneHorizon is the action of recording the epoch before which marks have been removed.
+	actionPruneHorizon = []byte{0x08}
+	// actionMinimumWatermark is the action of setting the source epoch, target epoch and slot below which nothing is signed.
+	actionMinimumWatermark = []byte{0x09}
 )
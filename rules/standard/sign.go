@@ -16,38 +16,110 @@ package standard
 import (
 	"bytes"
 	"context"
+	"fmt"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
 )
 
 // OnSign is called when a request to sign generic data needs to be approved.
 func (s *Service) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnSign")
-	defer span.Finish()
-	log := log.With().Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign").Logger()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSign")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving generic data as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving generic data as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	if len(req.Domain) != 32 {
+		log.Warn().Int("length", len(req.Domain)).Msg("Not signing request with incorrect domain length")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not signing request for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The domain must be for the fork active at the epoch of the request, if configured.
+	if s.forkSchedule != nil {
+		var epoch uint64
+		switch {
+		case req.Epoch != nil:
+			epoch = *req.Epoch
+		case !s.genesisTime.IsZero():
+			epoch = s.currentEpoch()
+		default:
+			log.Warn().Msg("Not signing request without an epoch to check against the fork schedule")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.DENIED
+		}
+		if !s.checkForkDomain(req.Domain, epoch) {
+			log.Warn().Uint64("epoch", epoch).Msg("Not signing request for a fork not active at its epoch")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.DENIED
+		}
+	}
 
 	if bytes.Equal(req.Domain[0:4], e2types.DomainBeaconAttester[:]) {
 		log.Warn().Msg("Not signing beacon attestation request with generic signer")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
 		return rules.DENIED
 	}
 	if bytes.Equal(req.Domain[0:4], e2types.DomainBeaconProposer[:]) {
 		log.Warn().Msg("Not signing beacon proposal request with generic signer")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
 		return rules.DENIED
 	}
-
-	// Voluntary exit requests must come from an approved IP address.
+	// Voluntary exits are only signed by their own rules, which apply the voluntary exit policies.  Generic
+	// requests that are delegated to those rules never reach here, so any that do supply only a root.
 	if bytes.Equal(req.Domain[0:4], e2types.DomainVoluntaryExit[:]) {
-		validIP := false
-		for i := range s.adminIPs {
-			if metadata.IP == s.adminIPs[i] {
-				validIP = true
+		log.Warn().Msg("Not signing voluntary exit request with generic signer")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	// Deposits are only signed by their own rules, which apply the deposit policies.
+	if bytes.Equal(req.Domain[0:4], e2types.DomainDeposit[:]) {
+		log.Warn().Msg("Not signing deposit request with generic signer")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	// BLS to execution changes are only signed by their own rules, which apply the execution address policies.
+	if bytes.Equal(req.Domain[0:4], rules.DomainBLSToExecutionChange[:]) {
+		log.Warn().Msg("Not signing BLS to execution change request with generic signer")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	// Validator registrations are only signed by their own rules, which apply the fee recipient policies.
+	if bytes.Equal(req.Domain[0:4], rules.DomainApplicationBuilder[:]) {
+		log.Warn().Msg("Not signing validator registration request with generic signer")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The client may be restricted to a set of domain types.
+	if domainTypes, exists := s.currentPolicy().signDomainTypes[metadata.Client]; exists {
+		permitted := false
+		for i := range domainTypes {
+			if bytes.Equal(req.Domain[0:4], domainTypes[i]) {
+				permitted = true
 				break
 			}
 		}
-		if !validIP {
-			log.Warn().Msg("Not signing voluntary exit request from unapproved IP address")
+		if !permitted {
+			log.Warn().Str("domain_type", fmt.Sprintf("%#x", req.Domain[0:4])).Msg("Not signing request with domain type not permitted for client")
+			rules.RecordReason(ctx, rules.ReasonPolicy)
 			return rules.DENIED
 		}
 	}
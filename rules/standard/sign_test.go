@@ -50,6 +50,7 @@ func TestSign(t *testing.T) {
 		metadata *rules.ReqMetadata
 		req      *rules.SignData
 		res      rules.Result
+		reason   rules.Reason
 	}{
 		{
 			name:     "AttestationDomain",
@@ -58,7 +59,8 @@ func TestSign(t *testing.T) {
 				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
 				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
 		},
 		{
 			name:     "ProposalDomain",
@@ -67,7 +69,8 @@ func TestSign(t *testing.T) {
 				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
 				Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
 		},
 		{
 			name:     "Good",
@@ -79,27 +82,37 @@ func TestSign(t *testing.T) {
 			res: rules.APPROVED,
 		},
 		{
-			name:     "NoVEIP",
+			name:     "DepositDomain",
 			metadata: &rules.ReqMetadata{},
 			req: &rules.SignData{
 				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
-				Domain: _byteStr(t, "0400000000000000000000000000000000000000000000000000000000000000"),
+				Domain: _byteStr(t, "0300000000000000000000000000000000000000000000000000000000000000"),
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
 		},
 		{
-			name: "InvalidVEIP",
-			metadata: &rules.ReqMetadata{
-				IP: "2.3.4.5",
+			name:     "DomainShort",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.SignData{
+				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Domain: _byteStr(t, "01"),
 			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:     "VoluntaryExitDomain",
+			metadata: &rules.ReqMetadata{},
 			req: &rules.SignData{
 				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
 				Domain: _byteStr(t, "0400000000000000000000000000000000000000000000000000000000000000"),
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
 		},
 		{
-			name: "GoodVEIP",
+			name: "VoluntaryExitDomainAdminIP",
 			metadata: &rules.ReqMetadata{
 				IP: "5.6.7.8",
 			},
@@ -107,14 +120,104 @@ func TestSign(t *testing.T) {
 				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
 				Domain: _byteStr(t, "0400000000000000000000000000000000000000000000000000000000000000"),
 			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:     "BLSToExecutionChangeDomain",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.SignData{
+				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Domain: _byteStr(t, "0a00000000000000000000000000000000000000000000000000000000000000"),
+			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:     "ApplicationBuilderDomain",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.SignData{
+				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Domain: _byteStr(t, "0000000100000000000000000000000000000000000000000000000000000000"),
+			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			res := testRules.OnSign(ctx, test.metadata, test.req)
+			assert.Equal(t, test.res, res)
+			assert.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
+
+func TestSignDomainTypes(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithSignDomainTypes(map[string][][]byte{
+			"restricted": {_byteStr(t, "02000000")},
+		}),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		metadata *rules.ReqMetadata
+		req      *rules.SignData
+		res      rules.Result
+		reason   rules.Reason
+	}{
+		{
+			name: "Permitted",
+			metadata: &rules.ReqMetadata{
+				Client: "restricted",
+			},
+			req: &rules.SignData{
+				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Domain: _byteStr(t, "0200000000000000000000000000000000000000000000000000000000000000"),
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name: "NotPermitted",
+			metadata: &rules.ReqMetadata{
+				Client: "restricted",
+			},
+			req: &rules.SignData{
+				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Domain: _byteStr(t, "0500000000000000000000000000000000000000000000000000000000000000"),
+			},
+			res:    rules.DENIED,
+			reason: rules.ReasonPolicy,
+		},
+		{
+			name: "Unrestricted",
+			metadata: &rules.ReqMetadata{
+				Client: "unrestricted",
+			},
+			req: &rules.SignData{
+				Data:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Domain: _byteStr(t, "0500000000000000000000000000000000000000000000000000000000000000"),
+			},
 			res: rules.APPROVED,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
 			res := testRules.OnSign(ctx, test.metadata, test.req)
 			assert.Equal(t, test.res, res)
+			assert.Equal(t, test.reason, rules.RecordedReason(ctx))
 		})
 	}
 }
@@ -0,0 +1,87 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// OnSignAggregateAndProof is called when a request to sign an aggregate and proof needs to be approved.
+func (s *Service) OnSignAggregateAndProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignAggregateAndProofData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignAggregateAndProof")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign aggregate and proof").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving aggregate and proof as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving aggregate and proof as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request must have the appropriate domain.
+	if len(req.Domain) < 4 || !bytes.Equal(req.Domain[0:4], e2types.DomainAggregateAndProof) {
+		log.Warn().Msg("Not approving non-aggregate and proof due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving aggregate and proof for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if len(req.AggregateRoot) != 32 {
+		log.Warn().Int("length", len(req.AggregateRoot)).Msg("Not approving aggregate and proof with invalid aggregate root")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request aggregator index must match that expected for the public key, if known.
+	if !s.checkValidatorIndex(metadata.PubKey, req.AggregatorIndex) {
+		log.Warn().Uint64("aggregatorIndex", req.AggregatorIndex).Msg("Not approving aggregate and proof with aggregator index that does not match the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request slot must not be too far in the future.
+	if s.slotTooFarInFuture(req.Slot, s.limits(metadata).maxFutureSlots) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", req.Slot).
+			Msg("Request slot too far in the future")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// Aggregation is a duty for a single slot, so the request slot must be close to the current slot.
+	if s.slotOutsideWindow(req.Slot, s.aggregateSlotWindow) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", req.Slot).
+			Msg("Request slot outside of aggregation window")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	return rules.APPROVED
+}
@@ -0,0 +1,159 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAggregateAndProof(t *testing.T) {
+	ctx := context.Background()
+
+	// Genesis is set such that we are half way through slot 100.
+	genesisTime := time.Now().Add(-(100*12 + 6) * time.Second)
+	windowRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(genesisTime),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+		standardrules.WithAggregateSlotWindow(2),
+	)
+	require.NoError(t, err)
+	noWindowRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(genesisTime),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+	)
+	require.NoError(t, err)
+
+	domain := _byteStr(t, "0600000000000000000000000000000000000000000000000000000000000000")
+	root := _byteStr(t, "0101010101010101010101010101010101010101010101010101010101010101")
+
+	tests := []struct {
+		name   string
+		rules  *standardrules.Service
+		domain []byte
+		slot   uint64
+		root   []byte
+		res    rules.Result
+	}{
+		{
+			name:   "BadDomain",
+			rules:  windowRules,
+			domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+			slot:   100,
+			root:   root,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "RootMissing",
+			rules:  windowRules,
+			domain: domain,
+			slot:   100,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "Current",
+			rules:  windowRules,
+			domain: domain,
+			slot:   100,
+			root:   root,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "PastAtLimit",
+			rules:  windowRules,
+			domain: domain,
+			slot:   98,
+			root:   root,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "PastBeyondLimit",
+			rules:  windowRules,
+			domain: domain,
+			slot:   97,
+			root:   root,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "FutureAtLimit",
+			rules:  windowRules,
+			domain: domain,
+			slot:   102,
+			root:   root,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FutureBeyondLimit",
+			rules:  windowRules,
+			domain: domain,
+			slot:   103,
+			root:   root,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "NoWindowPast",
+			rules:  noWindowRules,
+			domain: domain,
+			slot:   1,
+			root:   root,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "NoWindowFutureAtLimit",
+			rules:  noWindowRules,
+			domain: domain,
+			slot:   110,
+			root:   root,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "NoWindowFutureBeyondLimit",
+			rules:  noWindowRules,
+			domain: domain,
+			slot:   111,
+			root:   root,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "FarFuture",
+			rules:  noWindowRules,
+			domain: domain,
+			slot:   0x8000000000000000,
+			root:   root,
+			res:    rules.DENIED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := test.rules.OnSignAggregateAndProof(ctx, &rules.ReqMetadata{}, &rules.SignAggregateAndProofData{
+				Domain:          test.domain,
+				AggregatorIndex: 1,
+				Slot:            test.slot,
+				AggregateRoot:   test.root,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// OnSignAggregationSlot is called when a request to sign an aggregation slot selection proof needs to be approved.
+func (s *Service) OnSignAggregationSlot(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignAggregationSlotData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignAggregationSlot")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign aggregation slot").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving aggregation slot as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving aggregation slot as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request must have the appropriate domain.
+	if len(req.Domain) < 4 || !bytes.Equal(req.Domain[0:4], e2types.DomainSelectionProof) {
+		log.Warn().Msg("Not approving non-aggregation slot due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving aggregation slot for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request slot must not be too far in the future.
+	if s.slotTooFarInFuture(req.Slot, s.limits(metadata).maxFutureSlots) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", req.Slot).
+			Msg("Request slot too far in the future")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// Aggregation is a duty for a single slot, so the request slot must be close to the current slot.
+	if s.slotOutsideWindow(req.Slot, s.aggregateSlotWindow) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", req.Slot).
+			Msg("Request slot outside of aggregation window")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	return rules.APPROVED
+}
@@ -0,0 +1,107 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAggregationSlot(t *testing.T) {
+	ctx := context.Background()
+
+	// Genesis is set such that we are half way through slot 100.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(time.Now().Add(-(100*12+6)*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+		standardrules.WithAggregateSlotWindow(2),
+	)
+	require.NoError(t, err)
+
+	domain := _byteStr(t, "0500000000000000000000000000000000000000000000000000000000000000")
+
+	tests := []struct {
+		name   string
+		domain []byte
+		slot   uint64
+		res    rules.Result
+	}{
+		{
+			name:   "AttesterDomain",
+			domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+			slot:   100,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "AggregateAndProofDomain",
+			domain: _byteStr(t, "0600000000000000000000000000000000000000000000000000000000000000"),
+			slot:   100,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "ShortDomain",
+			domain: _byteStr(t, "05"),
+			slot:   100,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "Current",
+			domain: domain,
+			slot:   100,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FutureAtLimit",
+			domain: domain,
+			slot:   102,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FutureBeyondLimit",
+			domain: domain,
+			slot:   103,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "PastBeyondLimit",
+			domain: domain,
+			slot:   97,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "FarFuture",
+			domain: domain,
+			slot:   0x8000000000000000,
+			res:    rules.DENIED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignAggregationSlot(ctx, &rules.ReqMetadata{}, &rules.SignAggregationSlotData{
+				Domain: test.domain,
+				Slot:   test.slot,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
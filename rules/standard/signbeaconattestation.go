@@ -21,8 +21,8 @@ import (
 	"fmt"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
 )
 
 type signBeaconAttestationState struct {
@@ -66,9 +66,31 @@ func (s *signBeaconAttestationState) Decode(data []byte) error {
 
 // OnSignBeaconAttestation is called when a request to sign a beacon block attestation needs to be approved.
 func (s *Service) OnSignBeaconAttestation(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconAttestationData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnSignBeaconAttestation")
-	defer span.Finish()
-	log := log.With().Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign beacon attestation").Logger()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignBeaconAttestation")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign beacon attestation").Logger()
+
+	if s.slashableSigningHalted() {
+		log.Error().Msg("Not approving beacon attestation as slashing protection integrity is not verified")
+		rules.RecordReason(ctx, rules.ReasonStoreUnavailable)
+		return rules.FAILED
+	}
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving beacon attestation as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving beacon attestation as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// Check the request is well-formed before consulting the slashing protection state.
+	res := s.runSignBeaconAttestationValidityChecks(ctx, metadata, req)
+	if res != rules.APPROVED {
+		return res
+	}
 
 	// Fetch state from previous signings.
 	state, err := s.fetchSignBeaconAttestationState(ctx, metadata.PubKey)
@@ -77,7 +99,7 @@ func (s *Service) OnSignBeaconAttestation(ctx context.Context, metadata *rules.R
 		return rules.FAILED
 	}
 
-	res := s.runSignBeaconAttestationChecks(ctx, req, state)
+	res = s.runSignBeaconAttestationChecks(ctx, metadata, req, state)
 	if res != rules.APPROVED {
 		return res
 	}
@@ -92,40 +114,32 @@ func (s *Service) OnSignBeaconAttestation(ctx context.Context, metadata *rules.R
 	return res
 }
 
-func (s *Service) fetchSignBeaconAttestationState(ctx context.Context, pubKey []byte) (*signBeaconAttestationState, error) {
-	state := &signBeaconAttestationState{}
-	key := make([]byte, len(pubKey)+len(actionSignBeaconAttestation))
-	copy(key, pubKey)
-	copy(key[len(pubKey):], actionSignBeaconAttestation)
-	data, err := s.store.Fetch(ctx, key)
+func (s *Service) fetchSignBeaconAttestationState(ctx context.Context, pubKey []byte) (*AttestationMark, error) {
+	var mark *AttestationMark
+	err := s.withStoreBreaker(ctx, "fetch", func() error {
+		var err error
+		mark, err = s.protection.AttestationEpochs(ctx, pubKey)
+		return err
+	})
 	if err != nil {
-		if err.Error() == "not found" {
-			// No values; set them to -1.
-			state.SourceEpoch = -1
-			state.TargetEpoch = -1
-		} else {
-			return nil, err
-		}
-	} else {
-		err := state.Decode(data)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to decode state")
-		}
+		return nil, err
 	}
-	log.Trace().Int64("source_epoch", state.SourceEpoch).Int64("target_epoch", state.TargetEpoch).Msg("Returning attestation state from store")
-	return state, nil
+	log.Trace().Int64("source_epoch", mark.SourceEpoch).Int64("target_epoch", mark.TargetEpoch).Msg("Returning attestation state from store")
+	return mark, nil
 }
 
-func (s *Service) storeSignBeaconAttestationState(ctx context.Context, pubKey []byte, state *signBeaconAttestationState) error {
-	key := make([]byte, len(pubKey)+len(actionSignBeaconAttestation))
-	copy(key, pubKey)
-	copy(key[len(pubKey):], actionSignBeaconAttestation)
+func (s *Service) storeSignBeaconAttestationState(ctx context.Context, pubKey []byte, mark *AttestationMark) error {
+	if rules.IsDryRun(ctx) {
+		return nil
+	}
 
-	err := s.store.Store(ctx, key, state.Encode())
+	err := s.withStoreBreaker(ctx, "store", func() error {
+		return s.protection.SetAttestationEpochs(ctx, [][]byte{pubKey}, []*AttestationMark{mark})
+	})
 	if err != nil {
 		return err
 	}
 
-	log.Trace().Int64("source_epoch", state.SourceEpoch).Int64("target_epoch", state.TargetEpoch).Msg("Stored attestation state to store")
+	log.Trace().Int64("source_epoch", mark.SourceEpoch).Int64("target_epoch", mark.TargetEpoch).Msg("Stored attestation state to store")
 	return nil
 }
@@ -16,8 +16,10 @@ package standard_test
 import (
 	"context"
 	"io/ioutil"
+	"math"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/attestantio/dirk/rules"
 	standardrules "github.com/attestantio/dirk/rules/standard"
@@ -40,6 +42,7 @@ func TestSignBeaconAttestation(t *testing.T) {
 		metadata *rules.ReqMetadata
 		req      *rules.SignBeaconAttestationData
 		res      rules.Result
+		reason   rules.Reason
 	}{
 		{
 			name:     "BadDomain",
@@ -47,7 +50,8 @@ func TestSignBeaconAttestation(t *testing.T) {
 			req: &rules.SignBeaconAttestationData{
 				Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
 		},
 		{
 			name:     "EqualEpochs",
@@ -61,7 +65,35 @@ func TestSignBeaconAttestation(t *testing.T) {
 					Epoch: 5,
 				},
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:     "SourceGreaterThanTarget",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.SignBeaconAttestationData{
+				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+				Source: &rules.Checkpoint{
+					Epoch: 6,
+				},
+				Target: &rules.Checkpoint{
+					Epoch: 5,
+				},
+			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:     "MissingTarget",
+			metadata: &rules.ReqMetadata{},
+			req: &rules.SignBeaconAttestationData{
+				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+				Source: &rules.Checkpoint{
+					Epoch: 4,
+				},
+			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
 		},
 		{
 			name:     "Good",
@@ -89,7 +121,8 @@ func TestSignBeaconAttestation(t *testing.T) {
 					Epoch: 5,
 				},
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonSlashing,
 		},
 		{
 			name:     "EarlierSourceThanStored",
@@ -103,14 +136,167 @@ func TestSignBeaconAttestation(t *testing.T) {
 					Epoch: 6,
 				},
 			},
-			res: rules.DENIED,
+			res:    rules.DENIED,
+			reason: rules.ReasonSlashing,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
 			res := testRules.OnSignBeaconAttestation(ctx, test.metadata, test.req)
 			assert.Equal(t, test.res, res)
+			assert.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
+
+func TestSignBeaconAttestationFutureEpoch(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	// Genesis is set such that we are half way through epoch 10.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGenesisTime(time.Now().Add(-(10*32+16)*12*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithSlotsPerEpoch(32),
+		standardrules.WithMaxFutureEpochs(2),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		pubKey []byte
+		req    *rules.SignBeaconAttestationData
+		res    rules.Result
+		reason rules.Reason
+	}{
+		{
+			name:   "Current",
+			pubKey: _byteStr(t, "01"),
+			req: &rules.SignBeaconAttestationData{
+				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+				Source: &rules.Checkpoint{Epoch: 9},
+				Target: &rules.Checkpoint{Epoch: 10},
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name:   "AtLimit",
+			pubKey: _byteStr(t, "02"),
+			req: &rules.SignBeaconAttestationData{
+				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+				Source: &rules.Checkpoint{Epoch: 9},
+				Target: &rules.Checkpoint{Epoch: 12},
+			},
+			res: rules.APPROVED,
+		},
+		{
+			name:   "BeyondLimit",
+			pubKey: _byteStr(t, "03"),
+			req: &rules.SignBeaconAttestationData{
+				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+				Source: &rules.Checkpoint{Epoch: 9},
+				Target: &rules.Checkpoint{Epoch: 13},
+			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:   "FarFuture",
+			pubKey: _byteStr(t, "04"),
+			req: &rules.SignBeaconAttestationData{
+				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+				Source: &rules.Checkpoint{Epoch: 9},
+				Target: &rules.Checkpoint{Epoch: 0x7fffffffffffffff},
+			},
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			res := testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, test.req)
+			assert.Equal(t, test.res, res)
+			assert.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
+
+func TestSignBeaconAttestationFutureEpochLargeLimit(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	// A limit that would overflow if added to the current epoch must not refuse current epochs.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGenesisTime(time.Now().Add(-(10*32+16)*12*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithSlotsPerEpoch(32),
+		standardrules.WithMaxFutureEpochs(math.MaxUint64),
+	)
+	require.NoError(t, err)
+
+	res := testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: _byteStr(t, "01")}, &rules.SignBeaconAttestationData{
+		Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+		Source: &rules.Checkpoint{Epoch: 9},
+		Target: &rules.Checkpoint{Epoch: 10},
+	})
+	assert.Equal(t, rules.APPROVED, res)
+}
+
+func TestSignBeaconAttestationFutureSlot(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	// Genesis is set such that we are half way through slot 100.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGenesisTime(time.Now().Add(-(100*12+6)*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		pubKey []byte
+		slot   uint64
+		res    rules.Result
+		reason rules.Reason
+	}{
+		{
+			name:   "AtLimit",
+			pubKey: _byteStr(t, "01"),
+			slot:   110,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "BeyondLimit",
+			pubKey: _byteStr(t, "02"),
+			slot:   111,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			res := testRules.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, &rules.SignBeaconAttestationData{
+				Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+				Slot:   test.slot,
+				Source: &rules.Checkpoint{Epoch: 2},
+				Target: &rules.Checkpoint{Epoch: 3},
+			})
+			assert.Equal(t, test.res, res)
+			assert.Equal(t, test.reason, rules.RecordedReason(ctx))
 		})
 	}
 }
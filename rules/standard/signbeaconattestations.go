@@ -17,10 +17,11 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"sync/atomic"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
 )
 
 // OnSignBeaconAttestations is called when a request to sign multiple beacon block attestations needs to be approved.
@@ -28,14 +29,23 @@ func (s *Service) OnSignBeaconAttestations(ctx context.Context,
 	metadata []*rules.ReqMetadata,
 	req []*rules.SignBeaconAttestationData,
 ) []rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnSignBeaconAttestations")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignBeaconAttestations")
+	defer span.End()
 
 	res := make([]rules.Result, len(req))
 	for i := range res {
 		res[i] = rules.UNKNOWN
 	}
 
+	if s.slashableSigningHalted() {
+		log.Error().Msg("Not approving beacon attestations as slashing protection integrity is not verified")
+		rules.RecordReason(ctx, rules.ReasonStoreUnavailable)
+		for i := range res {
+			res[i] = rules.FAILED
+		}
+		return res
+	}
+
 	if len(req) != len(metadata) {
 		log.Error().Int("reqs", len(req)).Int("metadatas", len(metadata)).Msg("Mismatch between number of requests and number of metadata entries")
 		for i := range res {
@@ -77,11 +87,43 @@ func (s *Service) OnSignBeaconAttestations(ctx context.Context,
 
 	// Run the rules.
 	for i := range req {
-		res[i] = s.runSignBeaconAttestationChecks(ctx, req[i], states[i])
+		if s.pubKeyDenied(metadata[i].PubKey) {
+			log.Warn().Str("account", metadata[i].Account).Msg("Not approving beacon attestation as the public key is denied")
+			rules.RecordReason(ctx, rules.ReasonPolicy)
+			res[i] = rules.DENIED
+			continue
+		}
+		if s.signingPaused(metadata[i].PubKey) {
+			log.Warn().Str("account", metadata[i].Account).Msg("Not approving beacon attestation as signing is paused for the account")
+			rules.RecordReason(ctx, rules.ReasonPolicy)
+			res[i] = rules.DENIED
+			continue
+		}
+		res[i] = s.runSignBeaconAttestationValidityChecks(ctx, metadata[i], req[i])
+		if res[i] != rules.APPROVED {
+			continue
+		}
+		res[i] = s.runSignBeaconAttestationChecks(ctx, metadata[i], req[i], states[i])
 	}
 
-	// Update the state
-	if err = s.storeSignBeaconAttestationStates(ctx, pubKeys, states); err != nil {
+	// Update the state for the approved entries in a single write.
+	approvedPubKeys := make([][]byte, 0, len(res))
+	approvedStates := make([]*AttestationMark, 0, len(res))
+	for i := range res {
+		if res[i] == rules.APPROVED {
+			approvedPubKeys = append(approvedPubKeys, pubKeys[i])
+			approvedStates = append(approvedStates, states[i])
+		}
+	}
+	if len(approvedPubKeys) != len(res) && rules.IsAtomicBatch(ctx) {
+		// None of the entries will be signed, so none of the marks should advance.
+		log.Debug().Int("approved", len(approvedPubKeys)).Int("entries", len(res)).Msg("Not storing state for partially approved atomic batch")
+		return res
+	}
+	if len(approvedPubKeys) == 0 {
+		return res
+	}
+	if err = s.storeSignBeaconAttestationStates(ctx, approvedPubKeys, approvedStates); err != nil {
 		log.Error().Err(err).Msg("Failed to store state for beacon attestations")
 		for i := range res {
 			res[i] = rules.FAILED
@@ -92,8 +134,8 @@ func (s *Service) OnSignBeaconAttestations(ctx context.Context,
 	return res
 }
 
-func (s *Service) fetchSignBeaconAttestationStates(ctx context.Context, pubKeys [][]byte) ([]*signBeaconAttestationState, error) {
-	states := make([]*signBeaconAttestationState, len(pubKeys))
+func (s *Service) fetchSignBeaconAttestationStates(ctx context.Context, pubKeys [][]byte) ([]*AttestationMark, error) {
+	states := make([]*AttestationMark, len(pubKeys))
 	var err error
 	for i := range pubKeys {
 		states[i], err = s.fetchSignBeaconAttestationState(ctx, pubKeys[i])
@@ -105,13 +147,28 @@ func (s *Service) fetchSignBeaconAttestationStates(ctx context.Context, pubKeys
 	return states, nil
 }
 
-func (s *Service) runSignBeaconAttestationChecks(ctx context.Context, req *rules.SignBeaconAttestationData, state *signBeaconAttestationState) rules.Result {
+// runSignBeaconAttestationValidityChecks checks that the request is well-formed.
+// These checks do not require any state, so can be run before the state is fetched.
+func (s *Service) runSignBeaconAttestationValidityChecks(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconAttestationData) rules.Result {
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Logger()
+
 	// The request must have the appropriate domain.
 	if !bytes.Equal(req.Domain[0:4], e2types.DomainBeaconAttester[:]) {
 		log.Warn().Msg("Not approving non-beacon attestation due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving beacon attestation for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
 		return rules.DENIED
 	}
 
+	if req.Source == nil || req.Target == nil {
+		log.Warn().Msg("Request missing source or target checkpoint")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
 	sourceEpoch := req.Source.Epoch
 	targetEpoch := req.Target.Epoch
 
@@ -121,27 +178,100 @@ func (s *Service) runSignBeaconAttestationChecks(ctx context.Context, req *rules
 			Uint64("sourceEpoch", sourceEpoch).
 			Uint64("targetEpoch", targetEpoch).
 			Msg("Request target epoch equal to or lower than request source epoch")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request target epoch must be above the signing floor.
+	if s.attestationBelowSigningFloor(targetEpoch) {
+		log.Warn().
+			Uint64("signingFloorSlot", s.signingFloor).
+			Uint64("targetEpoch", targetEpoch).
+			Msg("Not approving beacon attestation at or below signing floor")
+		rules.RecordReason(ctx, rules.ReasonSlashing)
+		return rules.DENIED
+	}
+
+	// The request must be at or above the minimum watermark.
+	if s.attestationBelowMinimumWatermark(req.Slot, sourceEpoch, targetEpoch) {
+		log.Warn().
+			Uint64("minimumSlot", s.minimumWatermark.slot).
+			Uint64("minimumSourceEpoch", s.minimumWatermark.sourceEpoch).
+			Uint64("minimumTargetEpoch", s.minimumWatermark.targetEpoch).
+			Uint64("slot", req.Slot).
+			Uint64("sourceEpoch", sourceEpoch).
+			Uint64("targetEpoch", targetEpoch).
+			Msg("Not approving beacon attestation below minimum watermark")
+		rules.RecordReason(ctx, rules.ReasonSlashing)
+		return rules.DENIED
+	}
+
+	limits := s.limits(metadata)
+
+	// The request slot must not be too far in the future.
+	if s.slotTooFarInFuture(req.Slot, limits.maxFutureSlots) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", req.Slot).
+			Msg("Request slot too far in the future")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request target epoch must not be too far in the future.
+	if s.epochTooFarInFuture(targetEpoch, limits.maxFutureEpochs) {
+		log.Warn().
+			Uint64("currentEpoch", s.currentEpoch()).
+			Uint64("targetEpoch", targetEpoch).
+			Msg("Request target epoch too far in the future")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
 		return rules.DENIED
 	}
 
+	return rules.APPROVED
+}
+
+// runSignBeaconAttestationChecks checks the request against the slashing protection state.
+// It assumes that the request has already passed the validity checks.
+func (s *Service) runSignBeaconAttestationChecks(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconAttestationData, state *AttestationMark) rules.Result {
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Logger()
+
+	sourceEpoch := req.Source.Epoch
+	targetEpoch := req.Target.Epoch
+
 	if state.TargetEpoch != -1 {
 		// The request target epoch must be greater than the previous request target epoch.
 		if int64(targetEpoch) <= state.TargetEpoch {
-			log.Warn().
+			log.Error().
 				Int64("previousTargetEpoch", state.TargetEpoch).
 				Uint64("targetEpoch", targetEpoch).
-				Msg("Request target epoch equal to or lower than previous signed target epoch")
+				Msg("Slashing prevented: request target epoch equal to or lower than previous signed target epoch")
+			s.monitor.SlashingPrevented("beacon attestation")
+			rules.RecordReason(ctx, rules.ReasonSlashing)
 			return rules.DENIED
 		}
 	}
 
+	if state.TargetEpoch == -1 && s.attestationBeforePruneHorizon(sourceEpoch) {
+		// The mark for the key may have been removed by pruning, so the request must be after it.
+		log.Error().
+			Uint64("pruneHorizonEpoch", atomic.LoadUint64(&s.pruneHorizon)).
+			Uint64("sourceEpoch", sourceEpoch).
+			Msg("Slashing prevented: request source epoch before prune horizon for key without previous signed attestation")
+		s.monitor.SlashingPrevented("beacon attestation")
+		rules.RecordReason(ctx, rules.ReasonSlashing)
+		return rules.DENIED
+	}
+
 	if state.SourceEpoch != -1 {
 		// The request source epoch must be greater than or equal to the previous request source epoch.
 		if int64(sourceEpoch) < state.SourceEpoch {
-			log.Warn().
+			log.Error().
 				Int64("previousSourceEpoch", state.SourceEpoch).
 				Uint64("sourceEpoch", sourceEpoch).
-				Msg("Request source epoch lower than previous signed source epoch")
+				Msg("Slashing prevented: request source epoch lower than previous signed source epoch")
+			s.monitor.SlashingPrevented("beacon attestation")
+			rules.RecordReason(ctx, rules.ReasonSlashing)
 			return rules.DENIED
 		}
 	}
@@ -152,21 +282,17 @@ func (s *Service) runSignBeaconAttestationChecks(ctx context.Context, req *rules
 	return rules.APPROVED
 }
 
-func (s *Service) storeSignBeaconAttestationStates(ctx context.Context, pubKeys [][]byte, states []*signBeaconAttestationState) error {
+func (s *Service) storeSignBeaconAttestationStates(ctx context.Context, pubKeys [][]byte, states []*AttestationMark) error {
+	if rules.IsDryRun(ctx) {
+		return nil
+	}
 	if len(pubKeys) != len(states) {
 		return errors.New("mismatch between number of pubkeys and number of states")
 	}
 
-	keys := make([][]byte, len(pubKeys))
-	values := make([][]byte, len(states))
-	for i := range keys {
-		keys[i] = make([]byte, len(pubKeys[i])+len(actionSignBeaconAttestation))
-		copy(keys[i], pubKeys[i])
-		copy(keys[i][len(pubKeys[i]):], actionSignBeaconAttestation)
-		values[i] = states[i].Encode()
-	}
-
-	err := s.store.BatchStore(ctx, keys, values)
+	err := s.withStoreBreaker(ctx, "batch store", func() error {
+		return s.protection.SetAttestationEpochs(ctx, pubKeys, states)
+	})
 	if err != nil {
 		return err
 	}
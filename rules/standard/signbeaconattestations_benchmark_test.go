@@ -0,0 +1,92 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+const benchmarkBatchSize = 256
+
+func benchmarkAttestations(b *testing.B) (*standardrules.Service, []*rules.ReqMetadata, []*rules.SignBeaconAttestationData, func()) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(b, err)
+	service, err := standardrules.New(ctx,
+		standardrules.WithLogLevel(zerolog.Disabled),
+		standardrules.WithStoragePath(base),
+	)
+	require.NoError(b, err)
+
+	metadata := make([]*rules.ReqMetadata, benchmarkBatchSize)
+	req := make([]*rules.SignBeaconAttestationData, benchmarkBatchSize)
+	for i := range metadata {
+		pubKey := make([]byte, 48)
+		binary.LittleEndian.PutUint64(pubKey, uint64(i))
+		metadata[i] = &rules.ReqMetadata{PubKey: pubKey}
+		req[i] = &rules.SignBeaconAttestationData{
+			Domain: make([]byte, 32),
+			Source: &rules.Checkpoint{},
+			Target: &rules.Checkpoint{},
+		}
+		copy(req[i].Domain, []byte{0x01, 0x00, 0x00, 0x00})
+	}
+
+	return service, metadata, req, func() { os.RemoveAll(base) }
+}
+
+// BenchmarkSignBeaconAttestations approves a batch of attestations for distinct keys, with a single write.
+func BenchmarkSignBeaconAttestations(b *testing.B) {
+	ctx := context.Background()
+	service, metadata, req, cleanup := benchmarkAttestations(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range req {
+			req[j].Target.Epoch = uint64(i + 1)
+		}
+		for _, res := range service.OnSignBeaconAttestations(ctx, metadata, req) {
+			if res != rules.APPROVED {
+				b.Fatal("attestation not approved")
+			}
+		}
+	}
+}
+
+// BenchmarkSignBeaconAttestationsIndividually approves the same attestations one at a time, with a write for each.
+func BenchmarkSignBeaconAttestationsIndividually(b *testing.B) {
+	ctx := context.Background()
+	service, metadata, req, cleanup := benchmarkAttestations(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := range req {
+			req[j].Target.Epoch = uint64(i + 1)
+			if service.OnSignBeaconAttestation(ctx, metadata[j], req[j]) != rules.APPROVED {
+				b.Fatal("attestation not approved")
+			}
+		}
+	}
+}
@@ -143,3 +143,87 @@ func TestSignBeaconAttestations(t *testing.T) {
 		})
 	}
 }
+
+// countingProtection is slashing protection that counts the writes of attestation marks.
+type countingProtection struct {
+	standardrules.SlashingProtection
+	attestationWrites int
+}
+
+func (c *countingProtection) SetAttestationEpochs(ctx context.Context, pubKeys [][]byte, marks []*standardrules.AttestationMark) error {
+	c.attestationWrites++
+	return c.SlashingProtection.SetAttestationEpochs(ctx, pubKeys, marks)
+}
+
+func TestSignBeaconAttestationsCoalesced(t *testing.T) {
+	tests := []struct {
+		name    string
+		atomic  bool
+		writes  int
+		targets []int64
+	}{
+		{
+			name:    "NonAtomic",
+			writes:  1,
+			targets: []int64{5, -1, 5},
+		},
+		{
+			name:    "Atomic",
+			atomic:  true,
+			writes:  0,
+			targets: []int64{-1, -1, -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			base, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+			defer os.RemoveAll(base)
+			protectionBase, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+			defer os.RemoveAll(protectionBase)
+			store, err := standardrules.NewStore(protectionBase)
+			require.NoError(t, err)
+			protection := &countingProtection{SlashingProtection: store}
+			testRules, err := standardrules.New(ctx,
+				standardrules.WithStoragePath(base),
+				standardrules.WithSlashingProtection(protection),
+			)
+			require.NoError(t, err)
+
+			pubKeys := [][]byte{
+				_byteStr(t, "a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c"),
+				_byteStr(t, "b89bebc699769726a318c8e9971bd3171297c61aea4a6578a7a4f94b547dcba5bac16a89108b6b6a1fe3695d1a874a0b"),
+				_byteStr(t, "a3a32b0f8b4ddb83f1a0a853d81dd725dfe577d4f4c3db8ece52ce2b026eca84815c1a7e8e92a4de3d755733bf7e4a9b"),
+			}
+			metadata := make([]*rules.ReqMetadata, len(pubKeys))
+			for i := range pubKeys {
+				metadata[i] = &rules.ReqMetadata{PubKey: pubKeys[i]}
+			}
+			req := make([]*rules.SignBeaconAttestationData, len(pubKeys))
+			for i := range req {
+				req[i] = &rules.SignBeaconAttestationData{
+					Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+					Source: &rules.Checkpoint{Epoch: 4},
+					Target: &rules.Checkpoint{Epoch: 5},
+				}
+			}
+			// The middle entry fails its checks.
+			req[1].Target.Epoch = 3
+
+			if test.atomic {
+				ctx = rules.WithAtomicBatch(ctx)
+			}
+			res := testRules.OnSignBeaconAttestations(ctx, metadata, req)
+			require.Equal(t, []rules.Result{rules.APPROVED, rules.DENIED, rules.APPROVED}, res)
+			require.Equal(t, test.writes, protection.attestationWrites)
+			for i := range pubKeys {
+				mark, err := store.AttestationEpochs(ctx, pubKeys[i])
+				require.NoError(t, err)
+				require.Equal(t, test.targets[i], mark.TargetEpoch)
+			}
+		})
+	}
+}
@@ -19,19 +19,35 @@ import (
 	"encoding/binary"
 	"encoding/gob"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
 )
 
 type signBeaconProposalState struct {
 	Slot int64
+	// EpochProposals is the number of proposals signed in the epoch of Slot.
+	// A value of 0 is treated as 1, as a proposal was signed at Slot.
+	EpochProposals uint64
 }
 
 // Encode encodes the proposal state.
 func (s *signBeaconProposalState) Encode() []byte {
+	if s != nil && s.EpochProposals > 1 {
+		// Only use version 2 when required, to remain compatible with existing data.
+		data := make([]byte, 1+8+8)
+		// Version.
+		data[0] = 0x02
+		// Slot.
+		binary.LittleEndian.PutUint64(data[1:9], uint64(s.Slot))
+		// Proposals in the epoch.
+		binary.LittleEndian.PutUint64(data[9:17], s.EpochProposals)
+		return data
+	}
+
 	data := make([]byte, 1+8)
 	// Version.
 	data[0] = 0x01
@@ -55,6 +71,12 @@ func (s *signBeaconProposalState) Decode(data []byte) error {
 			return fmt.Errorf("invalid version 1 data size %d", len(data))
 		}
 		s.Slot = int64(binary.LittleEndian.Uint64(data[1:9]))
+	case 0x02:
+		if len(data) != 17 {
+			return fmt.Errorf("invalid version 2 data size %d", len(data))
+		}
+		s.Slot = int64(binary.LittleEndian.Uint64(data[1:9]))
+		s.EpochProposals = binary.LittleEndian.Uint64(data[9:17])
 	default:
 		err = gob.NewDecoder(bytes.NewBuffer(data)).Decode(s)
 	}
@@ -63,13 +85,79 @@ func (s *signBeaconProposalState) Decode(data []byte) error {
 
 // OnSignBeaconProposal is called when a request to sign a beacon block proposal needs to be approved.
 func (s *Service) OnSignBeaconProposal(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBeaconProposalData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnSignBeaconProposal")
-	defer span.Finish()
-	log := log.With().Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign beacon proposal").Logger()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignBeaconProposal")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign beacon proposal").Logger()
+
+	if s.slashableSigningHalted() {
+		log.Error().Msg("Not approving beacon proposal as slashing protection integrity is not verified")
+		rules.RecordReason(ctx, rules.ReasonStoreUnavailable)
+		return rules.FAILED
+	}
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving beacon proposal as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving beacon proposal as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
 
 	// The request must have the appropriate domain.
 	if !bytes.Equal(req.Domain[0:4], e2types.DomainBeaconProposer[:]) {
 		log.Warn().Msg("Not approving non-beacon proposal due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving beacon proposal for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request slot must not be too far in the future.
+	if s.slotTooFarInFuture(req.Slot, s.limits(metadata).maxFutureSlots) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", req.Slot).
+			Msg("Request slot too far in the future")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request slot must be above the signing floor.
+	if s.proposalBelowSigningFloor(req.Slot) {
+		log.Warn().
+			Uint64("signingFloorSlot", s.signingFloor).
+			Uint64("slot", req.Slot).
+			Msg("Not approving beacon proposal at or below signing floor")
+		rules.RecordReason(ctx, rules.ReasonSlashing)
+		return rules.DENIED
+	}
+
+	// The request slot must be at or above the minimum watermark.
+	if s.proposalBelowMinimumWatermark(req.Slot) {
+		log.Warn().
+			Uint64("minimumSlot", s.minimumWatermark.slot).
+			Uint64("slot", req.Slot).
+			Msg("Not approving beacon proposal below minimum watermark")
+		rules.RecordReason(ctx, rules.ReasonSlashing)
+		return rules.DENIED
+	}
+
+	// The request proposer index must match that expected for the public key, if known.
+	if !s.checkValidatorIndex(metadata.PubKey, req.ProposerIndex) {
+		log.Warn().Uint64("proposerIndex", req.ProposerIndex).Msg("Not approving beacon proposal with proposer index that does not match the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request graffiti must meet the policy for the account.
+	if !s.checkGraffiti(metadata, req.Graffiti) {
+		log.Warn().Str("graffiti", string(bytes.TrimRight(req.Graffiti, "\x00"))).Msg("Not approving beacon proposal with graffiti not permitted for account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
 		return rules.DENIED
 	}
 
@@ -84,14 +172,53 @@ func (s *Service) OnSignBeaconProposal(ctx context.Context, metadata *rules.ReqM
 	if state.Slot != -1 {
 		// The request slot must be greater than the previous request slot.
 		if int64(slot) <= state.Slot {
+			log.Error().
+				Int64("previousSlot", state.Slot).
+				Uint64("slot", slot).
+				Msg("Slashing prevented: request slot equal to or lower than previous signed slot")
+			s.monitor.SlashingPrevented("beacon proposal")
+			rules.RecordReason(ctx, rules.ReasonSlashing)
+			return rules.DENIED
+		}
+		// The request slot must not be too close to the previous request slot, if configured.
+		// This is an additional guard against misbehaving clients rather than part of slashing protection.
+		if s.minProposalSlotGap > 0 && slot-uint64(state.Slot) < s.minProposalSlotGap {
 			log.Warn().
 				Int64("previousSlot", state.Slot).
 				Uint64("slot", slot).
-				Msg("Request slot equal to or lower than previous signed slot")
+				Uint64("minSlotGap", s.minProposalSlotGap).
+				Msg("Request slot too close to previous signed slot")
+			rules.RecordReason(ctx, rules.ReasonLimitExceeded)
 			return rules.DENIED
 		}
 	}
 
+	if state.Slot == -1 && s.proposalBeforePruneHorizon(slot) {
+		// The mark for the key may have been removed by pruning, so the request must be after it.
+		log.Error().
+			Uint64("pruneHorizonEpoch", atomic.LoadUint64(&s.pruneHorizon)).
+			Uint64("slot", slot).
+			Msg("Slashing prevented: request slot before prune horizon for key without previous signed proposal")
+		s.monitor.SlashingPrevented("beacon proposal")
+		rules.RecordReason(ctx, rules.ReasonSlashing)
+		return rules.DENIED
+	}
+
+	// The request must not exceed the number of proposals in an epoch, if configured.
+	proposals := uint64(0)
+	if state.Slot != -1 && uint64(state.Slot)/s.slotsPerEpoch == slot/s.slotsPerEpoch {
+		proposals = epochProposals(state)
+	}
+	if s.maxProposalsPerEpoch > 0 && proposals >= s.maxProposalsPerEpoch {
+		log.Warn().
+			Uint64("epoch", slot/s.slotsPerEpoch).
+			Uint64("maxProposals", s.maxProposalsPerEpoch).
+			Msg("Request exceeds maximum proposals per epoch")
+		rules.RecordReason(ctx, rules.ReasonLimitExceeded)
+		return rules.DENIED
+	}
+
+	state.EpochProposals = proposals + 1
 	state.Slot = int64(slot)
 	if err = s.storeSignBeaconProposalState(ctx, metadata.PubKey, state); err != nil {
 		log.Error().Err(err).Msg("Failed to store state for beacon proposal")
@@ -101,39 +228,40 @@ func (s *Service) OnSignBeaconProposal(ctx context.Context, metadata *rules.ReqM
 	return rules.APPROVED
 }
 
-func (s *Service) fetchSignBeaconProposalState(ctx context.Context, pubKey []byte) (*signBeaconProposalState, error) {
-	state := &signBeaconProposalState{}
-	key := make([]byte, len(pubKey)+len(actionSignBeaconProposal))
-	copy(key, pubKey)
-	copy(key[len(pubKey):], actionSignBeaconProposal)
-	data, err := s.store.Fetch(ctx, key)
+// epochProposals returns the number of proposals signed in the epoch of the mark's slot.
+func epochProposals(state *ProposalMark) uint64 {
+	if state.EpochProposals == 0 {
+		return 1
+	}
+	return state.EpochProposals
+}
+
+func (s *Service) fetchSignBeaconProposalState(ctx context.Context, pubKey []byte) (*ProposalMark, error) {
+	var mark *ProposalMark
+	err := s.withStoreBreaker(ctx, "fetch", func() error {
+		var err error
+		mark, err = s.protection.ProposalSlot(ctx, pubKey)
+		return err
+	})
 	if err != nil {
-		if err.Error() == "not found" {
-			// No value; set it to -1.
-			state.Slot = -1
-		} else {
-			return nil, err
-		}
-	} else {
-		err = state.Decode(data)
-		if err != nil {
-			return nil, errors.Wrap(err, "failed to decode state")
-		}
+		return nil, err
 	}
-	log.Trace().Int64("slot", state.Slot).Msg("Returning proposal state from store")
-	return state, nil
+	log.Trace().Int64("slot", mark.Slot).Msg("Returning proposal state from store")
+	return mark, nil
 }
 
-func (s *Service) storeSignBeaconProposalState(ctx context.Context, pubKey []byte, state *signBeaconProposalState) error {
-	key := make([]byte, len(pubKey)+len(actionSignBeaconProposal))
-	copy(key, pubKey)
-	copy(key[len(pubKey):], actionSignBeaconProposal)
+func (s *Service) storeSignBeaconProposalState(ctx context.Context, pubKey []byte, mark *ProposalMark) error {
+	if rules.IsDryRun(ctx) {
+		return nil
+	}
 
-	err := s.store.Store(ctx, key, state.Encode())
+	err := s.withStoreBreaker(ctx, "store", func() error {
+		return s.protection.SetProposalSlot(ctx, pubKey, mark)
+	})
 	if err != nil {
 		return err
 	}
 
-	log.Trace().Int64("slot", state.Slot).Msg("Stored proposal state to store")
+	log.Trace().Int64("slot", mark.Slot).Msg("Stored proposal state to store")
 	return nil
 }
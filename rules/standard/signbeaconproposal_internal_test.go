@@ -40,6 +40,16 @@ func TestSignBeaconProposalStateEncode(t *testing.T) {
 			state: &signBeaconProposalState{Slot: 1000},
 			res:   []byte{0x01, 0xe8, 0x003, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
 		},
+		{
+			name:  "Slot1000OneProposal",
+			state: &signBeaconProposalState{Slot: 1000, EpochProposals: 1},
+			res:   []byte{0x01, 0xe8, 0x003, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:  "Slot1000TwoProposals",
+			state: &signBeaconProposalState{Slot: 1000, EpochProposals: 2},
+			res:   []byte{0x02, 0xe8, 0x003, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
 	}
 
 	for _, test := range tests {
@@ -67,7 +77,7 @@ func TestSignBeaconProposalStateDecode(t *testing.T) {
 		},
 		{
 			name:    "InvalidVersion",
-			encoded: []byte{0x02, 0x00, 0x000, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			encoded: []byte{0x03, 0x00, 0x000, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
 			err:     "gob: unknown type id or corrupted data",
 		},
 		{
@@ -85,6 +95,16 @@ func TestSignBeaconProposalStateDecode(t *testing.T) {
 			encoded: []byte{0x01, 0xe8, 0x003, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
 			res:     &signBeaconProposalState{Slot: 1000},
 		},
+		{
+			name:    "V2Short",
+			encoded: []byte{0x02, 0xe8, 0x003, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			err:     "invalid version 2 data size 9",
+		},
+		{
+			name:    "V2Slot1000TwoProposals",
+			encoded: []byte{0x02, 0xe8, 0x003, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			res:     &signBeaconProposalState{Slot: 1000, EpochProposals: 2},
+		},
 	}
 
 	for _, test := range tests {
@@ -16,8 +16,11 @@ package standard_test
 import (
 	"context"
 	"io/ioutil"
+	"math"
 	"os"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/attestantio/dirk/rules"
 	standardrules "github.com/attestantio/dirk/rules/standard"
@@ -86,3 +89,380 @@ func TestSignBeaconProposal(t *testing.T) {
 		})
 	}
 }
+
+func TestSignBeaconProposalFutureSlot(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	// Genesis is set such that we are half way through slot 100.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGenesisTime(time.Now().Add(-(100*12+6)*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		pubKey []byte
+		slot   uint64
+		res    rules.Result
+		reason rules.Reason
+	}{
+		{
+			name:   "Current",
+			pubKey: _byteStr(t, "01"),
+			slot:   100,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "AtLimit",
+			pubKey: _byteStr(t, "02"),
+			slot:   110,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "BeyondLimit",
+			pubKey: _byteStr(t, "03"),
+			slot:   111,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:   "FarFuture",
+			pubKey: _byteStr(t, "04"),
+			slot:   0x8000000000000000,
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			res := testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: test.pubKey}, &rules.SignBeaconProposalData{
+				Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Slot:   test.slot,
+			})
+			assert.Equal(t, test.res, res)
+			assert.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
+
+func TestSignBeaconProposalFutureSlotLargeLimit(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	// A limit that would overflow if added to the current slot must not refuse current slots.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGenesisTime(time.Now().Add(-(100*12+6)*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(math.MaxUint64),
+	)
+	require.NoError(t, err)
+
+	res := testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{PubKey: _byteStr(t, "01")}, &rules.SignBeaconProposalData{
+		Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+		Slot:   100,
+	})
+	assert.Equal(t, rules.APPROVED, res)
+}
+
+func TestSignBeaconProposalGraffiti(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithGraffitiPolicies(map[string]*standardrules.GraffitiPolicy{
+			"allowlist": {
+				Allowed: []string{"approved"},
+			},
+			"denylist": {
+				Denied: []string{"bad"},
+			},
+			"pattern": {
+				Pattern: regexp.MustCompile("^v[0-9]+$"),
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		account  string
+		pubKey   []byte
+		graffiti []byte
+		res      rules.Result
+	}{
+		{
+			name:     "Unrestricted",
+			account:  "other",
+			pubKey:   _byteStr(t, "01"),
+			graffiti: []byte("anything"),
+			res:      rules.APPROVED,
+		},
+		{
+			name:     "Allowed",
+			account:  "allowlist",
+			pubKey:   _byteStr(t, "02"),
+			graffiti: []byte("approved"),
+			res:      rules.APPROVED,
+		},
+		{
+			name:     "AllowedPadded",
+			account:  "allowlist",
+			pubKey:   _byteStr(t, "03"),
+			graffiti: append([]byte("approved"), make([]byte, 24)...),
+			res:      rules.APPROVED,
+		},
+		{
+			name:     "NotAllowed",
+			account:  "allowlist",
+			pubKey:   _byteStr(t, "04"),
+			graffiti: []byte("unapproved"),
+			res:      rules.DENIED,
+		},
+		{
+			name:    "AllowListEmpty",
+			account: "allowlist",
+			pubKey:  _byteStr(t, "05"),
+			res:     rules.DENIED,
+		},
+		{
+			name:     "Denied",
+			account:  "denylist",
+			pubKey:   _byteStr(t, "06"),
+			graffiti: []byte("a bad graffiti"),
+			res:      rules.DENIED,
+		},
+		{
+			name:     "NotDenied",
+			account:  "denylist",
+			pubKey:   _byteStr(t, "07"),
+			graffiti: []byte("a good graffiti"),
+			res:      rules.APPROVED,
+		},
+		{
+			name:    "DenyListEmpty",
+			account: "denylist",
+			pubKey:  _byteStr(t, "08"),
+			res:     rules.APPROVED,
+		},
+		{
+			name:     "PatternMatch",
+			account:  "pattern",
+			pubKey:   _byteStr(t, "09"),
+			graffiti: []byte("v123"),
+			res:      rules.APPROVED,
+		},
+		{
+			name:     "PatternMismatch",
+			account:  "pattern",
+			pubKey:   _byteStr(t, "0a"),
+			graffiti: []byte("version 123"),
+			res:      rules.DENIED,
+		},
+		{
+			name:    "PatternEmpty",
+			account: "pattern",
+			pubKey:  _byteStr(t, "0b"),
+			res:     rules.DENIED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{Account: test.account, PubKey: test.pubKey}, &rules.SignBeaconProposalData{
+				Domain:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Slot:     1,
+				Graffiti: test.graffiti,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestSignBeaconProposalGraffitiInheritance(t *testing.T) {
+	ctx := context.Background()
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGraffitiPolicies(map[string]*standardrules.GraffitiPolicy{
+			"account": {
+				Allowed: []string{"account"},
+			},
+			"Wallet/": {
+				Allowed: []string{"wallet"},
+			},
+			rules.GlobalPolicyKey: {
+				Allowed: []string{"global"},
+			},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	tests := []struct {
+		name     string
+		metadata *rules.ReqMetadata
+		allowed  string
+	}{
+		{
+			name:     "Account",
+			metadata: &rules.ReqMetadata{Wallet: "Wallet", Account: "account"},
+			allowed:  "account",
+		},
+		{
+			name:     "AccountOtherWallet",
+			metadata: &rules.ReqMetadata{Wallet: "Other wallet", Account: "account"},
+			allowed:  "account",
+		},
+		{
+			name:     "Wallet",
+			metadata: &rules.ReqMetadata{Wallet: "Wallet", Account: "other"},
+			allowed:  "wallet",
+		},
+		{
+			name:     "WalletNoAccount",
+			metadata: &rules.ReqMetadata{Wallet: "Wallet"},
+			allowed:  "wallet",
+		},
+		{
+			name:     "Global",
+			metadata: &rules.ReqMetadata{Wallet: "Other wallet", Account: "other"},
+			allowed:  "global",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.metadata.PubKey = []byte{byte(i)}
+			for j, graffiti := range []string{"account", "wallet", "global"} {
+				expected := rules.DENIED
+				if graffiti == test.allowed {
+					expected = rules.APPROVED
+				}
+				res := testRules.OnSignBeaconProposal(ctx, test.metadata, &rules.SignBeaconProposalData{
+					Domain:   _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+					Slot:     uint64(j + 1),
+					Graffiti: []byte(graffiti),
+				})
+				assert.Equal(t, expected, res, graffiti)
+			}
+		})
+	}
+}
+
+func TestSignBeaconProposalMaxPerEpoch(t *testing.T) {
+	ctx := context.Background()
+	storagePath := t.TempDir()
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+		standardrules.WithMaxProposalsPerEpoch(2),
+	)
+	require.NoError(t, err)
+
+	domain := _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000")
+	metadata := &rules.ReqMetadata{PubKey: _byteStr(t, "01")}
+
+	// Two proposals in epoch 1 are allowed.
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 32}))
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 33}))
+	// A third is not.
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 34}))
+
+	// The count persists across restarts.
+	require.NoError(t, testRules.Close(ctx))
+	testRules, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+		standardrules.WithMaxProposalsPerEpoch(2),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 63}))
+
+	// The count resets in the next epoch.
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 64}))
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 65}))
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 66}))
+}
+
+func TestSignBeaconProposalMinSlotGap(t *testing.T) {
+	ctx := context.Background()
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithMinProposalSlotGap(4),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000")
+	metadata := &rules.ReqMetadata{PubKey: _byteStr(t, "01")}
+
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 10}))
+	require.Equal(t, rules.DENIED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 13}))
+	require.Equal(t, rules.APPROVED, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Domain: domain, Slot: 14}))
+}
+
+func TestSignBeaconProposalReasons(t *testing.T) {
+	ctx := context.Background()
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithMaxProposalsPerEpoch(1),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	proposal := func(domain string, slot uint64) *rules.SignBeaconProposalData {
+		return &rules.SignBeaconProposalData{
+			Domain: _byteStr(t, domain),
+			Slot:   slot,
+		}
+	}
+	proposerDomain := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name   string
+		req    *rules.SignBeaconProposalData
+		res    rules.Result
+		reason rules.Reason
+	}{
+		{
+			name: "Good",
+			req:  proposal(proposerDomain, 64),
+			res:  rules.APPROVED,
+		},
+		{
+			name:   "BadDomain",
+			req:    proposal("0100000000000000000000000000000000000000000000000000000000000000", 65),
+			res:    rules.DENIED,
+			reason: rules.ReasonInvalidRequest,
+		},
+		{
+			name:   "Slashing",
+			req:    proposal(proposerDomain, 64),
+			res:    rules.DENIED,
+			reason: rules.ReasonSlashing,
+		},
+		{
+			name:   "LimitExceeded",
+			req:    proposal(proposerDomain, 65),
+			res:    rules.DENIED,
+			reason: rules.ReasonLimitExceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			require.Equal(t, test.res, testRules.OnSignBeaconProposal(ctx, &rules.ReqMetadata{}, test.req))
+			require.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
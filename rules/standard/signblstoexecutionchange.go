@@ -0,0 +1,109 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/attestantio/dirk/rules"
+	"go.opentelemetry.io/otel"
+)
+
+// BLSToExecutionChangePolicy is a policy for the BLS to execution changes of an account.
+type BLSToExecutionChangePolicy struct {
+	// ExecutionAddresses are the permitted execution addresses.  Changes must use one of the entries.
+	ExecutionAddresses [][]byte
+}
+
+// checkBLSToExecutionChangePolicy checks a BLS to execution change policy.
+func checkBLSToExecutionChangePolicy(policy *BLSToExecutionChangePolicy) error {
+	if policy == nil {
+		return errors.New("no policy")
+	}
+	if len(policy.ExecutionAddresses) == 0 {
+		return errors.New("no execution addresses")
+	}
+	for i := range policy.ExecutionAddresses {
+		if len(policy.ExecutionAddresses[i]) != 20 {
+			return fmt.Errorf("execution address %#x must be 20 bytes", policy.ExecutionAddresses[i])
+		}
+	}
+	return nil
+}
+
+// OnSignBLSToExecutionChange is called when a request to sign a BLS to execution change needs to be approved.
+func (s *Service) OnSignBLSToExecutionChange(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignBLSToExecutionChangeData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignBLSToExecutionChange")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign BLS to execution change").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving BLS to execution change as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving BLS to execution change as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// BLS to execution changes have a dedicated domain.  It must be used, so that this action cannot sign other types of message.
+	if len(req.Domain) != 32 || !bytes.Equal(req.Domain[0:4], rules.DomainBLSToExecutionChange[:]) {
+		log.Warn().Msg("Not approving non-BLS to execution change due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving BLS to execution change for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if len(req.ToExecutionAddress) != 20 {
+		log.Warn().Msg("Not approving BLS to execution change with invalid execution address")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request validator index must match that expected for the public key, if known.
+	if !s.checkValidatorIndex(metadata.PubKey, req.ValidatorIndex) {
+		log.Warn().Uint64("validatorIndex", req.ValidatorIndex).Msg("Not approving BLS to execution change with validator index that does not match the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	blsToExecutionChangePolicies := s.currentPolicy().blsToExecutionChangePolicies
+	var policy *BLSToExecutionChangePolicy
+	for _, key := range metadata.PolicyKeys() {
+		if policy = blsToExecutionChangePolicies[key]; policy != nil {
+			break
+		}
+	}
+	if policy == nil {
+		// No restriction on the execution address.
+		return rules.APPROVED
+	}
+
+	for i := range policy.ExecutionAddresses {
+		if bytes.Equal(req.ToExecutionAddress, policy.ExecutionAddresses[i]) {
+			return rules.APPROVED
+		}
+	}
+	log.Warn().Str("execution_address", fmt.Sprintf("%#x", req.ToExecutionAddress)).Msg("Not approving BLS to execution change with execution address not permitted for account")
+	rules.RecordReason(ctx, rules.ReasonPolicy)
+	return rules.DENIED
+}
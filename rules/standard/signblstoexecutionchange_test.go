@@ -0,0 +1,119 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignBLSToExecutionChangeParameters(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithBLSToExecutionChangePolicies(map[string]*standardrules.BLSToExecutionChangePolicy{
+			"validator1": {},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid BLS to execution change policy for validator1: no execution addresses")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithBLSToExecutionChangePolicies(map[string]*standardrules.BLSToExecutionChangePolicy{
+			"validator1": {ExecutionAddresses: [][]byte{{0x00}}},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid BLS to execution change policy for validator1: execution address 0x00 must be 20 bytes")
+}
+
+func TestSignBLSToExecutionChange(t *testing.T) {
+	ctx := context.Background()
+
+	permittedAddress := _byteStr(t, "8f0844fd51e31ff6bf5bab21dcfc5ecd9b8e8e4a")
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithBLSToExecutionChangePolicies(map[string]*standardrules.BLSToExecutionChangePolicy{
+			"Restricted": {ExecutionAddresses: [][]byte{permittedAddress}},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := _byteStr(t, "0a00000000000000000000000000000000000000000000000000000000000000")
+	otherAddress := _byteStr(t, "0000000000000000000000000000000000000001")
+
+	tests := []struct {
+		name    string
+		account string
+		domain  []byte
+		address []byte
+		res     rules.Result
+	}{
+		{
+			name:    "DomainIncorrect",
+			account: "Unrestricted",
+			domain:  _byteStr(t, "0400000000000000000000000000000000000000000000000000000000000000"),
+			address: otherAddress,
+			res:     rules.DENIED,
+		},
+		{
+			name:    "AddressShort",
+			account: "Unrestricted",
+			domain:  domain,
+			address: _byteStr(t, "01"),
+			res:     rules.DENIED,
+		},
+		{
+			name:    "Unrestricted",
+			account: "Unrestricted",
+			domain:  domain,
+			address: otherAddress,
+			res:     rules.APPROVED,
+		},
+		{
+			name:    "RestrictedNotPermitted",
+			account: "Restricted",
+			domain:  domain,
+			address: otherAddress,
+			res:     rules.DENIED,
+		},
+		{
+			name:    "RestrictedPermitted",
+			account: "Restricted",
+			domain:  domain,
+			address: permittedAddress,
+			res:     rules.APPROVED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignBLSToExecutionChange(ctx, &rules.ReqMetadata{
+				Account: test.account,
+				PubKey:  make([]byte, 48),
+			}, &rules.SignBLSToExecutionChangeData{
+				Domain:             test.domain,
+				ValidatorIndex:     1,
+				ToExecutionAddress: test.address,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
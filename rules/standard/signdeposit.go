@@ -0,0 +1,119 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/attestantio/dirk/rules"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// defaultDepositAmount is the amount of a deposit, in Gwei, if a deposit policy does not specify a range.
+const defaultDepositAmount = uint64(32000000000)
+
+// DepositPolicy is a policy for the deposits of an account.
+type DepositPolicy struct {
+	// WithdrawalCredentials are the permitted withdrawal credentials.  Deposits must use one of the entries.
+	WithdrawalCredentials [][]byte
+	// MinAmount and MaxAmount are the inclusive range of permitted deposit amounts, in Gwei.  If both are 0 then
+	// deposits must be exactly 32 Ether.
+	MinAmount uint64
+	MaxAmount uint64
+}
+
+// checkDepositPolicy checks a deposit policy.
+func checkDepositPolicy(policy *DepositPolicy) error {
+	if policy == nil {
+		return errors.New("no policy")
+	}
+	if len(policy.WithdrawalCredentials) == 0 {
+		return errors.New("no withdrawal credentials")
+	}
+	for i := range policy.WithdrawalCredentials {
+		if len(policy.WithdrawalCredentials[i]) != 32 {
+			return fmt.Errorf("withdrawal credentials %#x must be 32 bytes", policy.WithdrawalCredentials[i])
+		}
+	}
+	if policy.MinAmount > policy.MaxAmount {
+		return errors.New("minimum amount greater than maximum amount")
+	}
+	return nil
+}
+
+// OnSignDeposit is called when a request to sign deposit data needs to be approved.
+func (s *Service) OnSignDeposit(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignDepositData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignDeposit")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign deposit").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving deposit as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving deposit as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// Deposits have a dedicated domain.  It must be used, so that this action cannot sign other types of message.
+	if len(req.Domain) != 32 || !bytes.Equal(req.Domain[0:4], e2types.DomainDeposit[:]) {
+		log.Warn().Msg("Not approving non-deposit due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if s.depositDomain != nil && !bytes.Equal(req.Domain, s.depositDomain) {
+		log.Warn().Msg("Not approving deposit for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	depositPolicies := s.currentPolicy().depositPolicies
+	var policy *DepositPolicy
+	for _, key := range metadata.PolicyKeys() {
+		if policy = depositPolicies[key]; policy != nil {
+			break
+		}
+	}
+	if policy == nil {
+		log.Warn().Msg("Not approving deposit for account without a deposit policy")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	minAmount, maxAmount := policy.MinAmount, policy.MaxAmount
+	if minAmount == 0 && maxAmount == 0 {
+		minAmount, maxAmount = defaultDepositAmount, defaultDepositAmount
+	}
+	if req.Amount < minAmount || req.Amount > maxAmount {
+		log.Warn().Uint64("amount", req.Amount).Uint64("min_amount", minAmount).Uint64("max_amount", maxAmount).Msg("Not approving deposit with amount outside of permitted range")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	for i := range policy.WithdrawalCredentials {
+		if bytes.Equal(req.WithdrawalCredentials, policy.WithdrawalCredentials[i]) {
+			return rules.APPROVED
+		}
+	}
+	log.Warn().Str("withdrawal_credentials", fmt.Sprintf("%#x", req.WithdrawalCredentials)).Msg("Not approving deposit with withdrawal credentials not permitted for account")
+	rules.RecordReason(ctx, rules.ReasonPolicy)
+	return rules.DENIED
+}
@@ -0,0 +1,204 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestSignDepositParameters(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisForkVersion([]byte{0x00}),
+	)
+	require.EqualError(t, err, "problem with parameters: genesis fork version must be 4 bytes")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithDepositPolicies(map[string]*standardrules.DepositPolicy{
+			"validator1": {},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid deposit policy for validator1: no withdrawal credentials")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithDepositPolicies(map[string]*standardrules.DepositPolicy{
+			"validator1": {WithdrawalCredentials: [][]byte{{0x00}}},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid deposit policy for validator1: withdrawal credentials 0x00 must be 32 bytes")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithDepositPolicies(map[string]*standardrules.DepositPolicy{
+			"validator1": {
+				WithdrawalCredentials: [][]byte{make([]byte, 32)},
+				MinAmount:             2,
+				MaxAmount:             1,
+			},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid deposit policy for validator1: minimum amount greater than maximum amount")
+}
+
+func TestSignDeposit(t *testing.T) {
+	ctx := context.Background()
+
+	withdrawalCredentials := _byteStr(t, "00ec7ef7780c9d151597924036262dd28dc60e1228f4da6fecf9d402cb3f3594")
+	otherWithdrawalCredentials := _byteStr(t, "0100000000000000000000001111111111111111111111111111111111111111")
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisForkVersion([]byte{0x00, 0x00, 0x10, 0x20}),
+		standardrules.WithDepositPolicies(map[string]*standardrules.DepositPolicy{
+			"validator1": {
+				WithdrawalCredentials: [][]byte{withdrawalCredentials},
+			},
+			"validator2": {
+				WithdrawalCredentials: [][]byte{withdrawalCredentials, otherWithdrawalCredentials},
+				MinAmount:             1000000000,
+				MaxAmount:             32000000000,
+			},
+		}),
+	)
+	require.NoError(t, err)
+
+	domain, err := e2types.ComputeDomain(e2types.DomainDeposit, []byte{0x00, 0x00, 0x10, 0x20}, make([]byte, 32))
+	require.NoError(t, err)
+	otherNetworkDomain, err := e2types.ComputeDomain(e2types.DomainDeposit, []byte{0x00, 0x00, 0x00, 0x00}, make([]byte, 32))
+	require.NoError(t, err)
+	exitDomain, err := e2types.ComputeDomain(e2types.DomainVoluntaryExit, []byte{0x00, 0x00, 0x10, 0x20}, make([]byte, 32))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name                  string
+		account               string
+		domain                []byte
+		withdrawalCredentials []byte
+		amount                uint64
+		res                   rules.Result
+		reason                rules.Reason
+	}{
+		{
+			name:                  "Good",
+			account:               "validator1",
+			domain:                domain,
+			withdrawalCredentials: withdrawalCredentials,
+			amount:                32000000000,
+			res:                   rules.APPROVED,
+		},
+		{
+			name:                  "DomainShort",
+			account:               "validator1",
+			domain:                domain[:4],
+			withdrawalCredentials: withdrawalCredentials,
+			amount:                32000000000,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonInvalidRequest,
+		},
+		{
+			name:                  "DomainNotDeposit",
+			account:               "validator1",
+			domain:                exitDomain,
+			withdrawalCredentials: withdrawalCredentials,
+			amount:                32000000000,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonInvalidRequest,
+		},
+		{
+			name:                  "DomainOtherNetwork",
+			account:               "validator1",
+			domain:                otherNetworkDomain,
+			withdrawalCredentials: withdrawalCredentials,
+			amount:                32000000000,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonInvalidRequest,
+		},
+		{
+			name:                  "NoPolicy",
+			account:               "validator3",
+			domain:                domain,
+			withdrawalCredentials: withdrawalCredentials,
+			amount:                32000000000,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonPolicy,
+		},
+		{
+			name:                  "AmountNotDefault",
+			account:               "validator1",
+			domain:                domain,
+			withdrawalCredentials: withdrawalCredentials,
+			amount:                1000000000,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonPolicy,
+		},
+		{
+			name:                  "WithdrawalCredentialsNotPermitted",
+			account:               "validator1",
+			domain:                domain,
+			withdrawalCredentials: otherWithdrawalCredentials,
+			amount:                32000000000,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonPolicy,
+		},
+		{
+			name:                  "RangeMinimum",
+			account:               "validator2",
+			domain:                domain,
+			withdrawalCredentials: otherWithdrawalCredentials,
+			amount:                1000000000,
+			res:                   rules.APPROVED,
+		},
+		{
+			name:                  "RangeBelowMinimum",
+			account:               "validator2",
+			domain:                domain,
+			withdrawalCredentials: otherWithdrawalCredentials,
+			amount:                999999999,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonPolicy,
+		},
+		{
+			name:                  "RangeAboveMaximum",
+			account:               "validator2",
+			domain:                domain,
+			withdrawalCredentials: withdrawalCredentials,
+			amount:                32000000001,
+			res:                   rules.DENIED,
+			reason:                rules.ReasonPolicy,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			res := testRules.OnSignDeposit(ctx, &rules.ReqMetadata{Account: test.account}, &rules.SignDepositData{
+				Domain:                test.domain,
+				WithdrawalCredentials: test.withdrawalCredentials,
+				Amount:                test.amount,
+			})
+			assert.Equal(t, test.res, res)
+			assert.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
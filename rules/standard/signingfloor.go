@@ -0,0 +1,82 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// loadSigningFloor returns the slot at or below which nothing is signed for any key.  This is the
+// higher of the configured floor and the floor already held in the store, and is stored so that the
+// floor persists even if it is later removed from the configuration.  A floor of 0 is no floor.
+func (s *Service) loadSigningFloor(ctx context.Context, configured uint64) (uint64, error) {
+	stored, err := s.fetchSigningFloor(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to fetch signing floor")
+	}
+	if configured <= stored {
+		return stored, nil
+	}
+	if err := s.storeSigningFloor(ctx, configured); err != nil {
+		return 0, errors.Wrap(err, "failed to store signing floor")
+	}
+	if stored != 0 {
+		log.Info().Uint64("previous_slot", stored).Uint64("slot", configured).Msg("Raised signing floor")
+	}
+	return configured, nil
+}
+
+// proposalBelowSigningFloor returns true if a proposal for the slot is at or below the signing floor.
+func (s *Service) proposalBelowSigningFloor(slot uint64) bool {
+	return s.signingFloor != 0 && slot <= s.signingFloor
+}
+
+// attestationBelowSigningFloor returns true if an attestation for the target epoch is at or below the
+// epoch of the signing floor.  Attestations with a target in the epoch of the floor could have been
+// signed before the floor was set, so they are refused along with those for earlier epochs.
+func (s *Service) attestationBelowSigningFloor(targetEpoch uint64) bool {
+	return s.signingFloor != 0 && targetEpoch <= s.signingFloor/s.slotsPerEpoch
+}
+
+// fetchSigningFloor fetches the signing floor from the store, returning 0 if there is none.
+// It is held as a version byte followed by the slot.
+func (s *Service) fetchSigningFloor(ctx context.Context) (uint64, error) {
+	data, err := s.store.Fetch(ctx, actionSigningFloor)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(data) == 0 || data[0] != 0x01 {
+		return 0, errors.New("invalid version")
+	}
+	if len(data) != 9 {
+		return 0, errors.New("invalid data length")
+	}
+	return binary.LittleEndian.Uint64(data[1:9]), nil
+}
+
+// storeSigningFloor stores the signing floor.
+func (s *Service) storeSigningFloor(ctx context.Context, slot uint64) error {
+	data := make([]byte, 9)
+	data[0] = 0x01
+	binary.LittleEndian.PutUint64(data[1:9], slot)
+	return s.withStoreRetry(ctx, "store", func() error {
+		return s.store.Store(ctx, actionSigningFloor, data)
+	})
+}
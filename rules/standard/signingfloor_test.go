@@ -0,0 +1,95 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningFloor(t *testing.T) {
+	ctx := context.Background()
+	base := t.TempDir()
+
+	proposal := func(slot uint64) *rules.SignBeaconProposalData {
+		return &rules.SignBeaconProposalData{
+			Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+			Slot:   slot,
+		}
+	}
+	attestation := func(targetEpoch uint64) *rules.SignBeaconAttestationData {
+		return &rules.SignBeaconAttestationData{
+			Domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+			Slot:   targetEpoch * 32,
+			Source: &rules.Checkpoint{Epoch: targetEpoch - 1},
+			Target: &rules.Checkpoint{Epoch: targetEpoch},
+		}
+	}
+	metadata := func(key byte) *rules.ReqMetadata {
+		pubKey := make([]byte, 48)
+		pubKey[0] = key
+		return &rules.ReqMetadata{PubKey: pubKey}
+	}
+
+	// Floor at slot 100, in epoch 3.
+	service, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithSigningFloorSlot(100),
+	)
+	require.NoError(t, err)
+
+	// The floor applies to keys without any slashing protection history.
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x01), proposal(50)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x02), proposal(100)))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconProposal(ctx, metadata(0x03), proposal(101)))
+	require.Equal(t, rules.DENIED, service.OnSignBeaconAttestation(ctx, metadata(0x04), attestation(3)))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconAttestation(ctx, metadata(0x05), attestation(4)))
+	require.Equal(t, []rules.Result{rules.DENIED, rules.APPROVED},
+		service.OnSignBeaconAttestations(ctx,
+			[]*rules.ReqMetadata{metadata(0x06), metadata(0x07)},
+			[]*rules.SignBeaconAttestationData{attestation(2), attestation(5)},
+		))
+	require.NoError(t, service.Close(ctx))
+
+	// The floor persists when it is no longer configured.
+	service, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+	)
+	require.NoError(t, err)
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x08), proposal(90)))
+	require.NoError(t, service.Close(ctx))
+
+	// The floor cannot be lowered.
+	service, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithSigningFloorSlot(50),
+	)
+	require.NoError(t, err)
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x09), proposal(90)))
+	require.NoError(t, service.Close(ctx))
+
+	// The floor can be raised.
+	service, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithSigningFloorSlot(200),
+	)
+	require.NoError(t, err)
+	require.Equal(t, rules.DENIED, service.OnSignBeaconProposal(ctx, metadata(0x0a), proposal(150)))
+	require.Equal(t, rules.APPROVED, service.OnSignBeaconProposal(ctx, metadata(0x0b), proposal(201)))
+	require.NoError(t, service.Close(ctx))
+}
@@ -0,0 +1,65 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// OnSignRANDAOReveal is called when a request to sign a RANDAO reveal needs to be approved.
+func (s *Service) OnSignRANDAOReveal(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignRANDAORevealData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignRANDAOReveal")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign RANDAO reveal").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving RANDAO reveal as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving RANDAO reveal as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request must have the appropriate domain.
+	if len(req.Domain) < 4 || !bytes.Equal(req.Domain[0:4], e2types.DomainRANDAO[:]) {
+		log.Warn().Msg("Not approving non-RANDAO reveal due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving RANDAO reveal for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request epoch must be close to the current epoch, to stop reveals being harvested in advance.
+	if s.epochOutsideWindow(req.Epoch, s.randaoRevealWindow) {
+		log.Warn().
+			Uint64("currentEpoch", s.currentEpoch()).
+			Uint64("epoch", req.Epoch).
+			Msg("Request epoch too far from current epoch")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	return rules.APPROVED
+}
@@ -0,0 +1,190 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	fakeclock "github.com/attestantio/dirk/testing/clock"
+	"github.com/attestantio/dirk/util/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignRANDAORevealWindowParameters(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithRANDAORevealWindow(2),
+	)
+	require.EqualError(t, err, "problem with parameters: no genesis time specified")
+}
+
+func TestSignRANDAOReveal(t *testing.T) {
+	ctx := context.Background()
+
+	// Genesis is set such that we are half way through epoch 10.
+	windowRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(time.Now().Add(-(10*32+16)*12*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithSlotsPerEpoch(32),
+		standardrules.WithRANDAORevealWindow(2),
+	)
+	require.NoError(t, err)
+	noWindowRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	randaoDomain := _byteStr(t, "0200000000000000000000000000000000000000000000000000000000000000")
+
+	tests := []struct {
+		name   string
+		rules  *standardrules.Service
+		domain []byte
+		epoch  uint64
+		res    rules.Result
+	}{
+		{
+			name:   "BadDomain",
+			rules:  windowRules,
+			domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+			epoch:  10,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "ShortDomain",
+			rules:  windowRules,
+			domain: _byteStr(t, "02"),
+			epoch:  10,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "Current",
+			rules:  windowRules,
+			domain: randaoDomain,
+			epoch:  10,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "PastAtLimit",
+			rules:  windowRules,
+			domain: randaoDomain,
+			epoch:  8,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "PastBeyondLimit",
+			rules:  windowRules,
+			domain: randaoDomain,
+			epoch:  7,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "Genesis",
+			rules:  windowRules,
+			domain: randaoDomain,
+			epoch:  0,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "FutureAtLimit",
+			rules:  windowRules,
+			domain: randaoDomain,
+			epoch:  12,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FutureBeyondLimit",
+			rules:  windowRules,
+			domain: randaoDomain,
+			epoch:  13,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "FarFuture",
+			rules:  windowRules,
+			domain: randaoDomain,
+			epoch:  0xffffffffffffffff,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "FarFutureNoWindow",
+			rules:  noWindowRules,
+			domain: randaoDomain,
+			epoch:  0xffffffffffffffff,
+			res:    rules.APPROVED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := test.rules.OnSignRANDAOReveal(ctx, &rules.ReqMetadata{}, &rules.SignRANDAORevealData{
+				Domain: test.domain,
+				Epoch:  test.epoch,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestSignRANDAORevealWindowBoundary(t *testing.T) {
+	ctx := context.Background()
+
+	chainTime := clock.ChainTime{
+		GenesisTime:   time.Unix(1606824023, 0),
+		SlotDuration:  12 * time.Second,
+		SlotsPerEpoch: 32,
+	}
+	// Start at the first slot of epoch 10.
+	testClock := fakeclock.NewFakeAtSlot(chainTime, 10*32)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(chainTime.GenesisTime),
+		standardrules.WithSlotDuration(chainTime.SlotDuration),
+		standardrules.WithSlotsPerEpoch(chainTime.SlotsPerEpoch),
+		standardrules.WithRANDAORevealWindow(2),
+		standardrules.WithClock(testClock),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	randaoDomain := _byteStr(t, "0200000000000000000000000000000000000000000000000000000000000000")
+	sign := func(epoch uint64) rules.Result {
+		return testRules.OnSignRANDAOReveal(ctx, &rules.ReqMetadata{}, &rules.SignRANDAORevealData{
+			Domain: randaoDomain,
+			Epoch:  epoch,
+		})
+	}
+
+	require.Equal(t, rules.APPROVED, sign(8))
+	require.Equal(t, rules.APPROVED, sign(12))
+	require.Equal(t, rules.DENIED, sign(13))
+
+	// The last moment of epoch 10.
+	testClock.Advance(32*12*time.Second - time.Nanosecond)
+	require.Equal(t, rules.APPROVED, sign(8))
+	require.Equal(t, rules.DENIED, sign(13))
+
+	// The first moment of epoch 11.
+	testClock.Advance(time.Nanosecond)
+	require.Equal(t, rules.DENIED, sign(8))
+	require.Equal(t, rules.APPROVED, sign(13))
+}
@@ -0,0 +1,253 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignSyncCommitteeMessage(t *testing.T) {
+	ctx := context.Background()
+
+	// Genesis is set such that we are half way through slot 100.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(time.Now().Add(-(100*12+6)*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+		standardrules.WithSyncCommitteeSlotWindow(1),
+	)
+	require.NoError(t, err)
+
+	domain := _byteStr(t, "0700000000000000000000000000000000000000000000000000000000000000")
+	root := _byteStr(t, "0101010101010101010101010101010101010101010101010101010101010101")
+
+	tests := []struct {
+		name   string
+		domain []byte
+		slot   uint64
+		root   []byte
+		res    rules.Result
+	}{
+		{
+			name:   "AttesterDomain",
+			domain: _byteStr(t, "0100000000000000000000000000000000000000000000000000000000000000"),
+			slot:   100,
+			root:   root,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "ShortDomain",
+			domain: _byteStr(t, "07"),
+			slot:   100,
+			root:   root,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "ShortRoot",
+			domain: domain,
+			slot:   100,
+			root:   _byteStr(t, "01"),
+			res:    rules.DENIED,
+		},
+		{
+			name:   "Current",
+			domain: domain,
+			slot:   100,
+			root:   root,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FutureAtLimit",
+			domain: domain,
+			slot:   101,
+			root:   root,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "FutureBeyondLimit",
+			domain: domain,
+			slot:   102,
+			root:   root,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "PastBeyondLimit",
+			domain: domain,
+			slot:   98,
+			root:   root,
+			res:    rules.DENIED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignSyncCommitteeMessage(ctx, &rules.ReqMetadata{}, &rules.SignSyncCommitteeMessageData{
+				Domain:          test.domain,
+				Slot:            test.slot,
+				BeaconBlockRoot: test.root,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestSignSyncCommitteeSelectionProof(t *testing.T) {
+	ctx := context.Background()
+
+	// Genesis is set such that we are half way through slot 100.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(time.Now().Add(-(100*12+6)*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+		standardrules.WithSyncCommitteeSlotWindow(1),
+	)
+	require.NoError(t, err)
+
+	domain := _byteStr(t, "0800000000000000000000000000000000000000000000000000000000000000")
+
+	tests := []struct {
+		name              string
+		domain            []byte
+		slot              uint64
+		subcommitteeIndex uint64
+		res               rules.Result
+	}{
+		{
+			name:   "SyncCommitteeDomain",
+			domain: _byteStr(t, "0700000000000000000000000000000000000000000000000000000000000000"),
+			slot:   100,
+			res:    rules.DENIED,
+		},
+		{
+			name:              "SubcommitteeIndexInvalid",
+			domain:            domain,
+			slot:              100,
+			subcommitteeIndex: 4,
+			res:               rules.DENIED,
+		},
+		{
+			name:              "Current",
+			domain:            domain,
+			slot:              100,
+			subcommitteeIndex: 3,
+			res:               rules.APPROVED,
+		},
+		{
+			name:   "FutureBeyondLimit",
+			domain: domain,
+			slot:   102,
+			res:    rules.DENIED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignSyncCommitteeSelectionProof(ctx, &rules.ReqMetadata{}, &rules.SignSyncCommitteeSelectionProofData{
+				Domain:            test.domain,
+				Slot:              test.slot,
+				SubcommitteeIndex: test.subcommitteeIndex,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestSignSyncCommitteeContributionAndProof(t *testing.T) {
+	ctx := context.Background()
+
+	// Genesis is set such that we are half way through slot 100.
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithGenesisTime(time.Now().Add(-(100*12+6)*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithMaxFutureSlots(10),
+		standardrules.WithSyncCommitteeSlotWindow(1),
+	)
+	require.NoError(t, err)
+
+	domain := _byteStr(t, "0900000000000000000000000000000000000000000000000000000000000000")
+	root := _byteStr(t, "0101010101010101010101010101010101010101010101010101010101010101")
+	aggregationBits := _byteStr(t, "ffffffffffffffffffffffffffffffff")
+	signature := make([]byte, 96)
+
+	tests := []struct {
+		name              string
+		domain            []byte
+		slot              uint64
+		subcommitteeIndex uint64
+		aggregationBits   []byte
+		res               rules.Result
+	}{
+		{
+			name:            "AggregateAndProofDomain",
+			domain:          _byteStr(t, "0600000000000000000000000000000000000000000000000000000000000000"),
+			slot:            100,
+			aggregationBits: aggregationBits,
+			res:             rules.DENIED,
+		},
+		{
+			name:            "AggregationBitsShort",
+			domain:          domain,
+			slot:            100,
+			aggregationBits: _byteStr(t, "ff"),
+			res:             rules.DENIED,
+		},
+		{
+			name:              "SubcommitteeIndexInvalid",
+			domain:            domain,
+			slot:              100,
+			subcommitteeIndex: 4,
+			aggregationBits:   aggregationBits,
+			res:               rules.DENIED,
+		},
+		{
+			name:            "Current",
+			domain:          domain,
+			slot:            100,
+			aggregationBits: aggregationBits,
+			res:             rules.APPROVED,
+		},
+		{
+			name:            "PastBeyondLimit",
+			domain:          domain,
+			slot:            98,
+			aggregationBits: aggregationBits,
+			res:             rules.DENIED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignSyncCommitteeContributionAndProof(ctx, &rules.ReqMetadata{}, &rules.SignSyncCommitteeContributionAndProofData{
+				Domain:            test.domain,
+				Slot:              test.slot,
+				BeaconBlockRoot:   root,
+				SubcommitteeIndex: test.subcommitteeIndex,
+				AggregationBits:   test.aggregationBits,
+				Signature:         signature,
+				SelectionProof:    signature,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	"go.opentelemetry.io/otel"
+)
+
+// OnSignSyncCommitteeContributionAndProof is called when a request to sign a sync committee contribution and proof
+// needs to be approved.
+func (s *Service) OnSignSyncCommitteeContributionAndProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeContributionAndProofData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignSyncCommitteeContributionAndProof")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign sync committee contribution and proof").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving sync committee contribution and proof as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving sync committee contribution and proof as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request must have the appropriate domain.
+	if len(req.Domain) < 4 || !bytes.Equal(req.Domain[0:4], rules.DomainContributionAndProof[:]) {
+		log.Warn().Msg("Not approving non-sync committee contribution and proof due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving sync committee contribution and proof for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if len(req.BeaconBlockRoot) != 32 ||
+		len(req.AggregationBits) != syncCommitteeAggregationBitsLength ||
+		len(req.Signature) != 96 ||
+		len(req.SelectionProof) != 96 ||
+		req.SubcommitteeIndex >= syncCommitteeSubnetCount {
+		log.Warn().Msg("Not approving invalid sync committee contribution and proof")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request aggregator index must match that expected for the public key, if known.
+	if !s.checkValidatorIndex(metadata.PubKey, req.AggregatorIndex) {
+		log.Warn().Uint64("aggregatorIndex", req.AggregatorIndex).Msg("Not approving sync committee contribution and proof with aggregator index that does not match the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	return s.checkSyncCommitteeSlot(ctx, log, metadata, req.Slot)
+}
+
+// syncCommitteeAggregationBitsLength is the length in bytes of the aggregation bits of a sync committee
+// contribution, which has a bit for each member of the subcommittee.
+const syncCommitteeAggregationBitsLength = 16
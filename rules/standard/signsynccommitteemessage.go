@@ -0,0 +1,59 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	"go.opentelemetry.io/otel"
+)
+
+// OnSignSyncCommitteeMessage is called when a request to sign a sync committee message needs to be approved.
+func (s *Service) OnSignSyncCommitteeMessage(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeMessageData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignSyncCommitteeMessage")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign sync committee message").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving sync committee message as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving sync committee message as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request must have the appropriate domain.
+	if len(req.Domain) < 4 || !bytes.Equal(req.Domain[0:4], rules.DomainSyncCommittee[:]) {
+		log.Warn().Msg("Not approving non-sync committee message due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving sync committee message for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if len(req.BeaconBlockRoot) != 32 {
+		log.Warn().Int("length", len(req.BeaconBlockRoot)).Msg("Not approving sync committee message with invalid beacon block root")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	return s.checkSyncCommitteeSlot(ctx, log, metadata, req.Slot)
+}
@@ -0,0 +1,88 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+)
+
+// OnSignSyncCommitteeSelectionProof is called when a request to sign a sync committee selection proof needs to be approved.
+func (s *Service) OnSignSyncCommitteeSelectionProof(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignSyncCommitteeSelectionProofData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignSyncCommitteeSelectionProof")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign sync committee selection proof").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving sync committee selection proof as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving sync committee selection proof as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// The request must have the appropriate domain.
+	if len(req.Domain) < 4 || !bytes.Equal(req.Domain[0:4], rules.DomainSyncCommitteeSelectionProof[:]) {
+		log.Warn().Msg("Not approving non-sync committee selection proof due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving sync committee selection proof for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if req.SubcommitteeIndex >= syncCommitteeSubnetCount {
+		log.Warn().Uint64("subcommitteeIndex", req.SubcommitteeIndex).Msg("Not approving sync committee selection proof with invalid subcommittee index")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	return s.checkSyncCommitteeSlot(ctx, log, metadata, req.Slot)
+}
+
+// syncCommitteeSubnetCount is the number of subcommittees in a sync committee.
+const syncCommitteeSubnetCount = 4
+
+// checkSyncCommitteeSlot checks the slot of a sync committee duty against the current slot.
+func (s *Service) checkSyncCommitteeSlot(ctx context.Context, log zerolog.Logger, metadata *rules.ReqMetadata, slot uint64) rules.Result {
+	// The request slot must not be too far in the future.
+	if s.slotTooFarInFuture(slot, s.limits(metadata).maxFutureSlots) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", slot).
+			Msg("Request slot too far in the future")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// Sync committee duties are for a single slot, so the request slot must be close to the current slot.
+	if s.slotOutsideWindow(slot, s.syncCommitteeWindow) {
+		log.Warn().
+			Uint64("currentSlot", s.currentSlot()).
+			Uint64("slot", slot).
+			Msg("Request slot outside of sync committee window")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	return rules.APPROVED
+}
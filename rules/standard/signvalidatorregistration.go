@@ -0,0 +1,114 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"go.opentelemetry.io/otel"
+)
+
+// ValidatorRegistrationPolicy is a policy for the validator registrations of an account.
+type ValidatorRegistrationPolicy struct {
+	// FeeRecipients are the permitted fee recipients.  Registrations must use one of the entries.
+	FeeRecipients [][]byte
+}
+
+// checkValidatorRegistrationPolicy checks a validator registration policy.
+func checkValidatorRegistrationPolicy(policy *ValidatorRegistrationPolicy) error {
+	if policy == nil {
+		return errors.New("no policy")
+	}
+	if len(policy.FeeRecipients) == 0 {
+		return errors.New("no fee recipients")
+	}
+	for i := range policy.FeeRecipients {
+		if len(policy.FeeRecipients[i]) != 20 {
+			return fmt.Errorf("fee recipient %#x must be 20 bytes", policy.FeeRecipients[i])
+		}
+	}
+	return nil
+}
+
+// OnSignValidatorRegistration is called when a request to sign a validator registration needs to be approved.
+func (s *Service) OnSignValidatorRegistration(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignValidatorRegistrationData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignValidatorRegistration")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign validator registration").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving validator registration as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving validator registration as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// Validator registrations have a dedicated domain.  It must be used, so that this action cannot sign other types of message.
+	if len(req.Domain) != 32 || !bytes.Equal(req.Domain[0:4], rules.DomainApplicationBuilder[:]) {
+		log.Warn().Msg("Not approving non-validator registration due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if s.builderDomain != nil && !bytes.Equal(req.Domain, s.builderDomain) {
+		log.Warn().Msg("Not approving validator registration for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if len(req.FeeRecipient) != 20 {
+		log.Warn().Msg("Not approving validator registration with invalid fee recipient")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The registration timestamp must be close to the current time, if configured.
+	if s.validatorRegistrationWindow > 0 {
+		now := s.clock.Now()
+		timestamp := time.Unix(int64(req.Timestamp), 0)
+		if timestamp.Before(now.Add(-s.validatorRegistrationWindow)) || timestamp.After(now.Add(s.validatorRegistrationWindow)) {
+			log.Warn().Time("timestamp", timestamp).Msg("Not approving validator registration with timestamp outside of window")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.DENIED
+		}
+	}
+
+	validatorRegistrationPolicies := s.currentPolicy().validatorRegistrationPolicies
+	var policy *ValidatorRegistrationPolicy
+	for _, key := range metadata.PolicyKeys() {
+		if policy = validatorRegistrationPolicies[key]; policy != nil {
+			break
+		}
+	}
+	if policy == nil {
+		// No restriction on the fee recipient.
+		return rules.APPROVED
+	}
+
+	for i := range policy.FeeRecipients {
+		if bytes.Equal(req.FeeRecipient, policy.FeeRecipients[i]) {
+			return rules.APPROVED
+		}
+	}
+	log.Warn().Str("fee_recipient", fmt.Sprintf("%#x", req.FeeRecipient)).Msg("Not approving validator registration with fee recipient not permitted for account")
+	rules.RecordReason(ctx, rules.ReasonPolicy)
+	return rules.DENIED
+}
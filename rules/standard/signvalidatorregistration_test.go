@@ -0,0 +1,170 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	fakeclock "github.com/attestantio/dirk/testing/clock"
+	"github.com/attestantio/dirk/util/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignValidatorRegistrationParameters(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithValidatorRegistrationPolicies(map[string]*standardrules.ValidatorRegistrationPolicy{
+			"validator1": {},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid validator registration policy for validator1: no fee recipients")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithValidatorRegistrationPolicies(map[string]*standardrules.ValidatorRegistrationPolicy{
+			"validator1": {FeeRecipients: [][]byte{{0x00}}},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid validator registration policy for validator1: fee recipient 0x00 must be 20 bytes")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithValidatorRegistrationWindow(-time.Second),
+	)
+	require.EqualError(t, err, "problem with parameters: validator registration window cannot be negative")
+}
+
+func TestSignValidatorRegistration(t *testing.T) {
+	ctx := context.Background()
+
+	chainTime := clock.ChainTime{
+		GenesisTime:   time.Unix(1606824023, 0),
+		SlotDuration:  12 * time.Second,
+		SlotsPerEpoch: 32,
+	}
+	testClock := fakeclock.NewFakeAtSlot(chainTime, 1000)
+	now := uint64(testClock.Now().Unix())
+
+	permittedFeeRecipient := _byteStr(t, "8f0844fd51e31ff6bf5bab21dcfc5ecd9b8e8e4a")
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithClock(testClock),
+		standardrules.WithGenesisForkVersion([]byte{0x00, 0x00, 0x00, 0x00}),
+		standardrules.WithValidatorRegistrationWindow(time.Hour),
+		standardrules.WithValidatorRegistrationPolicies(map[string]*standardrules.ValidatorRegistrationPolicy{
+			"Restricted": {FeeRecipients: [][]byte{permittedFeeRecipient}},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := _byteStr(t, "00000001f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9")
+	otherFeeRecipient := _byteStr(t, "0000000000000000000000000000000000000001")
+
+	tests := []struct {
+		name         string
+		account      string
+		domain       []byte
+		feeRecipient []byte
+		timestamp    uint64
+		res          rules.Result
+	}{
+		{
+			name:         "DomainIncorrect",
+			account:      "Unrestricted",
+			domain:       _byteStr(t, "0300000000000000000000000000000000000000000000000000000000000000"),
+			feeRecipient: otherFeeRecipient,
+			timestamp:    now,
+			res:          rules.DENIED,
+		},
+		{
+			name:         "DomainOtherNetwork",
+			account:      "Unrestricted",
+			domain:       _byteStr(t, "0000000100000000000000000000000000000000000000000000000000000000"),
+			feeRecipient: otherFeeRecipient,
+			timestamp:    now,
+			res:          rules.DENIED,
+		},
+		{
+			name:         "FeeRecipientShort",
+			account:      "Unrestricted",
+			domain:       domain,
+			feeRecipient: _byteStr(t, "01"),
+			timestamp:    now,
+			res:          rules.DENIED,
+		},
+		{
+			name:         "TimestampStale",
+			account:      "Unrestricted",
+			domain:       domain,
+			feeRecipient: otherFeeRecipient,
+			timestamp:    now - 3601,
+			res:          rules.DENIED,
+		},
+		{
+			name:         "TimestampFuture",
+			account:      "Unrestricted",
+			domain:       domain,
+			feeRecipient: otherFeeRecipient,
+			timestamp:    now + 3601,
+			res:          rules.DENIED,
+		},
+		{
+			name:         "Unrestricted",
+			account:      "Unrestricted",
+			domain:       domain,
+			feeRecipient: otherFeeRecipient,
+			timestamp:    now - 3600,
+			res:          rules.APPROVED,
+		},
+		{
+			name:         "RestrictedNotPermitted",
+			account:      "Restricted",
+			domain:       domain,
+			feeRecipient: otherFeeRecipient,
+			timestamp:    now,
+			res:          rules.DENIED,
+		},
+		{
+			name:         "RestrictedPermitted",
+			account:      "Restricted",
+			domain:       domain,
+			feeRecipient: permittedFeeRecipient,
+			timestamp:    now,
+			res:          rules.APPROVED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignValidatorRegistration(ctx, &rules.ReqMetadata{
+				Account: test.account,
+				PubKey:  make([]byte, 48),
+			}, &rules.SignValidatorRegistrationData{
+				Domain:       test.domain,
+				FeeRecipient: test.feeRecipient,
+				GasLimit:     30000000,
+				Timestamp:    test.timestamp,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// VoluntaryExitPolicy is a policy for the voluntary exits of an account.
+type VoluntaryExitPolicy struct {
+	// Allowed permits voluntary exits to be signed for the account.
+	Allowed bool
+	// RequireSecondApproval requires the exit to be requested by a second client before it is signed.
+	// The first request is refused and held pending; a matching request from a different client
+	// within the approval timeout is approved.
+	RequireSecondApproval bool
+}
+
+// checkVoluntaryExitPolicy checks a voluntary exit policy.
+func checkVoluntaryExitPolicy(policy *VoluntaryExitPolicy) error {
+	if policy == nil {
+		return errors.New("no policy")
+	}
+	if policy.RequireSecondApproval && !policy.Allowed {
+		return errors.New("second approval required for exits that are not allowed")
+	}
+	return nil
+}
+
+// pendingVoluntaryExit is a voluntary exit awaiting a second approval.
+type pendingVoluntaryExit struct {
+	client         string
+	epoch          uint64
+	validatorIndex uint64
+	requested      time.Time
+}
+
+// OnSignVoluntaryExit is called when a request to sign a voluntary exit needs to be approved.
+func (s *Service) OnSignVoluntaryExit(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignVoluntaryExitData) rules.Result {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnSignVoluntaryExit")
+	defer span.End()
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("rule", "sign voluntary exit").Logger()
+
+	if s.pubKeyDenied(metadata.PubKey) {
+		log.Warn().Msg("Not approving voluntary exit as the public key is denied")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	if s.signingPaused(metadata.PubKey) {
+		log.Warn().Msg("Not approving voluntary exit as signing is paused for the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	// Voluntary exits have a dedicated domain.  It must be used, so that this action cannot sign other types of message.
+	if len(req.Domain) != 32 || !bytes.Equal(req.Domain[0:4], e2types.DomainVoluntaryExit[:]) {
+		log.Warn().Msg("Not approving non-voluntary exit due to incorrect domain")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+	if !s.checkNetwork(req.Domain) {
+		log.Warn().Msg("Not approving voluntary exit for a different network")
+		rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+		return rules.DENIED
+	}
+
+	// The request validator index must match that expected for the public key, if known.
+	if !s.checkValidatorIndex(metadata.PubKey, req.ValidatorIndex) {
+		log.Warn().Uint64("validatorIndex", req.ValidatorIndex).Msg("Not approving voluntary exit with validator index that does not match the account")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	voluntaryExitPolicies := s.currentPolicy().voluntaryExitPolicies
+	var policy *VoluntaryExitPolicy
+	for _, key := range metadata.PolicyKeys() {
+		if policy = voluntaryExitPolicies[key]; policy != nil {
+			break
+		}
+	}
+	if policy == nil || !policy.Allowed {
+		log.Warn().Msg("Not approving voluntary exit for account without voluntary exits allowed")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	if policy.RequireSecondApproval && !s.secondVoluntaryExitApproval(ctx, metadata, req) {
+		log.Warn().Msg("Not approving voluntary exit until it is requested by a second client")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+
+	return rules.APPROVED
+}
+
+// secondVoluntaryExitApproval returns true if a matching voluntary exit for the account has already been
+// requested by a different client within the approval timeout.  If not, this request is held as the
+// pending exit for the account.
+func (s *Service) secondVoluntaryExitApproval(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignVoluntaryExitData) bool {
+	var pubKey [48]byte
+	copy(pubKey[:], metadata.PubKey)
+	now := s.clock.Now()
+
+	s.pendingExitsMu.Lock()
+	defer s.pendingExitsMu.Unlock()
+
+	pending, exists := s.pendingExits[pubKey]
+	if exists &&
+		now.Sub(pending.requested) <= s.voluntaryExitApprovalTimeout &&
+		pending.epoch == req.Epoch &&
+		pending.validatorIndex == req.ValidatorIndex {
+		if pending.client == metadata.Client {
+			// A client cannot approve its own request.
+			return false
+		}
+		if !rules.IsDryRun(ctx) {
+			delete(s.pendingExits, pubKey)
+		}
+		return true
+	}
+
+	if !rules.IsDryRun(ctx) {
+		s.pendingExits[pubKey] = &pendingVoluntaryExit{
+			client:         metadata.Client,
+			epoch:          req.Epoch,
+			validatorIndex: req.ValidatorIndex,
+			requested:      now,
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	fakeclock "github.com/attestantio/dirk/testing/clock"
+	"github.com/attestantio/dirk/util/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignVoluntaryExitParameters(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithVoluntaryExitPolicies(map[string]*standardrules.VoluntaryExitPolicy{
+			"validator1": {RequireSecondApproval: true},
+		}),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid voluntary exit policy for validator1: second approval required for exits that are not allowed")
+
+	_, err = standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithVoluntaryExitApprovalTimeout(0),
+	)
+	require.EqualError(t, err, "problem with parameters: voluntary exit approval timeout must be positive")
+}
+
+func TestSignVoluntaryExit(t *testing.T) {
+	ctx := context.Background()
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithVoluntaryExitPolicies(map[string]*standardrules.VoluntaryExitPolicy{
+			"Allowed":    {Allowed: true},
+			"Disallowed": {Allowed: false},
+			"Wallet2/":   {Allowed: true},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := _byteStr(t, "0400000000000000000000000000000000000000000000000000000000000000")
+
+	tests := []struct {
+		name    string
+		account string
+		wallet  string
+		domain  []byte
+		res     rules.Result
+	}{
+		{
+			name:    "DomainIncorrect",
+			account: "Allowed",
+			wallet:  "Wallet1",
+			domain:  _byteStr(t, "0300000000000000000000000000000000000000000000000000000000000000"),
+			res:     rules.DENIED,
+		},
+		{
+			name:    "DomainShort",
+			account: "Allowed",
+			wallet:  "Wallet1",
+			domain:  _byteStr(t, "04"),
+			res:     rules.DENIED,
+		},
+		{
+			name:    "NoPolicy",
+			account: "Other",
+			wallet:  "Wallet1",
+			domain:  domain,
+			res:     rules.DENIED,
+		},
+		{
+			name:    "NotAllowed",
+			account: "Disallowed",
+			wallet:  "Wallet1",
+			domain:  domain,
+			res:     rules.DENIED,
+		},
+		{
+			name:    "Allowed",
+			account: "Allowed",
+			wallet:  "Wallet1",
+			domain:  domain,
+			res:     rules.APPROVED,
+		},
+		{
+			name:    "AllowedByWallet",
+			account: "Other",
+			wallet:  "Wallet2",
+			domain:  domain,
+			res:     rules.APPROVED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := testRules.OnSignVoluntaryExit(ctx, &rules.ReqMetadata{
+				Account: test.account,
+				Wallet:  test.wallet,
+				PubKey:  make([]byte, 48),
+			}, &rules.SignVoluntaryExitData{
+				Domain:         test.domain,
+				Epoch:          100,
+				ValidatorIndex: 1,
+			})
+			assert.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestSignVoluntaryExitSecondApproval(t *testing.T) {
+	ctx := context.Background()
+
+	chainTime := clock.ChainTime{
+		GenesisTime:   time.Unix(1606824023, 0),
+		SlotDuration:  12 * time.Second,
+		SlotsPerEpoch: 32,
+	}
+	testClock := fakeclock.NewFakeAtSlot(chainTime, 1000)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithClock(testClock),
+		standardrules.WithVoluntaryExitApprovalTimeout(time.Minute),
+		standardrules.WithVoluntaryExitPolicies(map[string]*standardrules.VoluntaryExitPolicy{
+			"Account1": {Allowed: true, RequireSecondApproval: true},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	domain := _byteStr(t, "0400000000000000000000000000000000000000000000000000000000000000")
+	exit := func(client string, epoch uint64) rules.Result {
+		return testRules.OnSignVoluntaryExit(ctx, &rules.ReqMetadata{
+			Account: "Account1",
+			Wallet:  "Wallet1",
+			Client:  client,
+			PubKey:  make([]byte, 48),
+		}, &rules.SignVoluntaryExitData{
+			Domain:         domain,
+			Epoch:          epoch,
+			ValidatorIndex: 1,
+		})
+	}
+
+	// The first request is held pending, and cannot be approved by the same client.
+	require.Equal(t, rules.DENIED, exit("client1", 100))
+	require.Equal(t, rules.DENIED, exit("client1", 100))
+	// A matching request from a second client is approved.
+	require.Equal(t, rules.APPROVED, exit("client2", 100))
+	// The approval is consumed, so a further request is held pending again.
+	require.Equal(t, rules.DENIED, exit("client2", 100))
+	require.Equal(t, rules.APPROVED, exit("client1", 100))
+
+	// A request for a different exit replaces the pending exit.
+	require.Equal(t, rules.DENIED, exit("client1", 100))
+	require.Equal(t, rules.DENIED, exit("client2", 101))
+	require.Equal(t, rules.APPROVED, exit("client1", 101))
+
+	// A pending exit expires after the approval timeout.
+	require.Equal(t, rules.DENIED, exit("client1", 100))
+	testClock.Advance(2 * time.Minute)
+	require.Equal(t, rules.DENIED, exit("client2", 100))
+
+	// A dry run does not hold or consume a pending exit.
+	require.Equal(t, rules.APPROVED, testRules.OnSignVoluntaryExit(rules.WithDryRun(ctx), &rules.ReqMetadata{
+		Account: "Account1",
+		Wallet:  "Wallet1",
+		Client:  "client1",
+		PubKey:  make([]byte, 48),
+	}, &rules.SignVoluntaryExitData{
+		Domain:         domain,
+		Epoch:          100,
+		ValidatorIndex: 1,
+	}))
+	require.Equal(t, rules.APPROVED, exit("client1", 100))
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 
 	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/locker"
 	"github.com/pkg/errors"
 )
 
@@ -28,6 +29,11 @@ func (s *Service) ExportSlashingProtection(ctx context.Context) (map[[48]byte]*r
 		return nil, errors.Wrap(err, "failed to obtain data from store")
 	}
 
+	return slashingProtectionFromEntries(entries)
+}
+
+// slashingProtectionFromEntries creates slashing protection data from store entries.
+func slashingProtectionFromEntries(entries map[[49]byte][]byte) (map[[48]byte]*rules.SlashingProtection, error) {
 	results := make(map[[48]byte]*rules.SlashingProtection)
 	for key, value := range entries {
 		var pubKey [48]byte
@@ -70,26 +76,95 @@ func (s *Service) ExportSlashingProtection(ctx context.Context) (map[[48]byte]*r
 // ImportSlashingProtection imports the slashing protection data.
 func (s *Service) ImportSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) error {
 	for k, v := range protection {
-		var key [49]byte
-		copy(key[:], k[:])
-		if v.HighestProposedSlot != -1 {
-			state := &signBeaconProposalState{
-				Slot: v.HighestProposedSlot,
-			}
-			key[48] = actionSignBeaconProposal[0]
-			if err := s.store.Store(ctx, key[:], state.Encode()); err != nil {
-				return errors.Wrap(err, "failed to store attestation state")
-			}
+		pubKey := k
+		if err := s.importKeySlashingProtection(ctx, pubKey[:], v); err != nil {
+			return err
 		}
-		if v.HighestAttestedSourceEpoch != -1 {
-			state := &signBeaconAttestationState{
-				SourceEpoch: v.HighestAttestedSourceEpoch,
-				TargetEpoch: v.HighestAttestedTargetEpoch,
-			}
-			key[48] = actionSignBeaconAttestation[0]
-			if err := s.store.Store(ctx, key[:], state.Encode()); err != nil {
-				return errors.Wrap(err, "failed to store attestation state")
-			}
+	}
+	return nil
+}
+
+// MergeSlashingProtection merges the slashing protection data in to the existing data.
+// The marks for each key are read and written under the account lock, so this is safe to call while signing.
+func (s *Service) MergeSlashingProtection(ctx context.Context, protection map[[48]byte]*rules.SlashingProtection) ([][48]byte, error) {
+	retained := make([][48]byte, 0)
+	for k, v := range protection {
+		merged, err := s.mergeKeySlashingProtection(ctx, k, v)
+		if err != nil {
+			return nil, err
+		}
+		if !merged {
+			retained = append(retained, k)
+		}
+	}
+	return retained, nil
+}
+
+// mergeKeySlashingProtection merges the slashing protection data for a single key, returning false if the
+// existing data was retained because it is higher for any value.
+func (s *Service) mergeKeySlashingProtection(ctx context.Context, pubKey [48]byte, protection *rules.SlashingProtection) (bool, error) {
+	if s.locker != nil {
+		token, err := s.locker.LockWithHolder(ctx, pubKey, "", "", nil)
+		if err != nil {
+			return false, errors.Wrap(err, "failed to lock account")
+		}
+		defer s.locker.Unlock(pubKey)
+		ctx = locker.WithFencingTokens(ctx, map[[48]byte]uint64{pubKey: token})
+	}
+
+	proposalMark, err := s.protection.ProposalSlot(ctx, pubKey[:])
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain proposal state")
+	}
+	attestationMark, err := s.protection.AttestationEpochs(ctx, pubKey[:])
+	if err != nil {
+		return false, errors.Wrap(err, "failed to obtain attestation state")
+	}
+	if proposalMark.Slot > protection.HighestProposedSlot ||
+		attestationMark.SourceEpoch > protection.HighestAttestedSourceEpoch ||
+		attestationMark.TargetEpoch > protection.HighestAttestedTargetEpoch {
+		return false, nil
+	}
+
+	// Only write marks that move forward, so that the proposals already signed in the epoch of an
+	// unchanged slot are retained.
+	update := &rules.SlashingProtection{
+		HighestProposedSlot:        -1,
+		HighestAttestedSourceEpoch: -1,
+		HighestAttestedTargetEpoch: -1,
+	}
+	if protection.HighestProposedSlot > proposalMark.Slot {
+		update.HighestProposedSlot = protection.HighestProposedSlot
+	}
+	if protection.HighestAttestedSourceEpoch > attestationMark.SourceEpoch ||
+		protection.HighestAttestedTargetEpoch > attestationMark.TargetEpoch {
+		update.HighestAttestedSourceEpoch = protection.HighestAttestedSourceEpoch
+		update.HighestAttestedTargetEpoch = protection.HighestAttestedTargetEpoch
+	}
+	if err := s.importKeySlashingProtection(ctx, pubKey[:], update); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// importKeySlashingProtection stores the slashing protection data for a single key.
+// Marks of -1 are not stored.
+func (s *Service) importKeySlashingProtection(ctx context.Context, pubKey []byte, v *rules.SlashingProtection) error {
+	if v.HighestProposedSlot != -1 {
+		mark := &ProposalMark{
+			Slot: v.HighestProposedSlot,
+		}
+		if err := s.protection.SetProposalSlot(ctx, pubKey, mark); err != nil {
+			return errors.Wrap(err, "failed to store proposal state")
+		}
+	}
+	if v.HighestAttestedSourceEpoch != -1 {
+		mark := &AttestationMark{
+			SourceEpoch: v.HighestAttestedSourceEpoch,
+			TargetEpoch: v.HighestAttestedTargetEpoch,
+		}
+		if err := s.protection.SetAttestationEpochs(ctx, [][]byte{pubKey}, []*AttestationMark{mark}); err != nil {
+			return errors.Wrap(err, "failed to store attestation state")
 		}
 	}
 	return nil
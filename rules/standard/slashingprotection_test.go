@@ -183,3 +183,66 @@ func TestImportSlashingProtection(t *testing.T) {
 	require.Equal(t, int64(0x0102030405060708), export[key2].HighestAttestedSourceEpoch)
 	require.Equal(t, int64(0x0203040506070809), export[key2].HighestAttestedTargetEpoch)
 }
+
+func TestMergeSlashingProtection(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	service, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+	)
+	require.NoError(t, err)
+
+	key1 := [48]byte{0x01}
+	key2 := [48]byte{0x02}
+	require.NoError(t, service.ImportSlashingProtection(ctx, map[[48]byte]*rules.SlashingProtection{
+		key1: {
+			HighestProposedSlot:        100,
+			HighestAttestedSourceEpoch: 2,
+			HighestAttestedTargetEpoch: 3,
+		},
+	}))
+
+	retained, err := service.MergeSlashingProtection(ctx, map[[48]byte]*rules.SlashingProtection{
+		// Lower target epoch than existing data; should be retained.
+		key1: {
+			HighestProposedSlot:        200,
+			HighestAttestedSourceEpoch: 2,
+			HighestAttestedTargetEpoch: 2,
+		},
+		// New key; should be imported.
+		key2: {
+			HighestProposedSlot:        50,
+			HighestAttestedSourceEpoch: -1,
+			HighestAttestedTargetEpoch: -1,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][48]byte{key1}, retained)
+
+	export, err := service.ExportSlashingProtection(ctx)
+	require.NoError(t, err)
+	require.Len(t, export, 2)
+	require.Equal(t, int64(100), export[key1].HighestProposedSlot)
+	require.Equal(t, int64(3), export[key1].HighestAttestedTargetEpoch)
+	require.Equal(t, int64(50), export[key2].HighestProposedSlot)
+	require.Equal(t, int64(-1), export[key2].HighestAttestedTargetEpoch)
+
+	// Higher data for all values is imported.
+	retained, err = service.MergeSlashingProtection(ctx, map[[48]byte]*rules.SlashingProtection{
+		key1: {
+			HighestProposedSlot:        100,
+			HighestAttestedSourceEpoch: 4,
+			HighestAttestedTargetEpoch: 5,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, retained, 0)
+
+	export, err = service.ExportSlashingProtection(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), export[key1].HighestProposedSlot)
+	require.Equal(t, int64(4), export[key1].HighestAttestedSourceEpoch)
+	require.Equal(t, int64(5), export[key1].HighestAttestedTargetEpoch)
+}
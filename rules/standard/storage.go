@@ -15,14 +15,19 @@ package standard
 
 import (
 	"context"
+	"fmt"
+	"io"
 
 	"github.com/attestantio/dirk/util/loggers"
 	badger "github.com/dgraph-io/badger/v2"
 	"github.com/dgraph-io/badger/v2/options"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
 )
 
+// ErrNotFound is returned when a key is not present in the store.
+var ErrNotFound = errors.New("not found")
+
 // Store holds key/value pairs in a badger database.
 type Store struct {
 	db *badger.DB
@@ -45,7 +50,8 @@ func NewStore(base string) (*Store, error) {
 	}, nil
 }
 
-// FetchAll fetches a map of all keys and values.
+// FetchAll fetches a map of all slashing protection keys and values.
+// Keys that are not 49 bytes in length do not hold slashing protection data and are ignored.
 func (s *Store) FetchAll(ctx context.Context) (map[[49]byte][]byte, error) {
 	items := make(map[[49]byte][]byte)
 	err := s.db.View(func(txn *badger.Txn) error {
@@ -53,15 +59,16 @@ func (s *Store) FetchAll(ctx context.Context) (map[[49]byte][]byte, error) {
 		defer it.Close()
 		for it.Rewind(); it.Valid(); it.Next() {
 			item := it.Item()
-			err := item.Value(func(v []byte) error {
-				var key [49]byte
-				copy(key[:], item.Key())
-				items[key] = v
-				return nil
-			})
+			if len(item.Key()) != 49 {
+				continue
+			}
+			value, err := item.ValueCopy(nil)
 			if err != nil {
 				return err
 			}
+			var key [49]byte
+			copy(key[:], item.Key())
+			items[key] = value
 		}
 		return nil
 	})
@@ -73,8 +80,8 @@ func (s *Store) FetchAll(ctx context.Context) (map[[49]byte][]byte, error) {
 
 // Fetch fetches a value for a given key.
 func (s *Store) Fetch(ctx context.Context, key []byte) ([]byte, error) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "storage.Fetch")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "storage.Fetch")
+	defer span.End()
 
 	if len(key) == 0 {
 		return nil, errors.New("no key provided")
@@ -85,17 +92,12 @@ func (s *Store) Fetch(ctx context.Context, key []byte) ([]byte, error) {
 		item, err := txn.Get(key)
 		if err != nil {
 			if err == badger.ErrKeyNotFound {
-				return errors.New("not found")
+				return ErrNotFound
 			}
-		}
-		err = item.Value(func(val []byte) error {
-			value = val
-			return nil
-		})
-		if err != nil {
 			return err
 		}
-
+		// The value is only valid within the transaction, so take a copy.
+		value, err = item.ValueCopy(nil)
 		return err
 	})
 	if err != nil {
@@ -106,8 +108,8 @@ func (s *Store) Fetch(ctx context.Context, key []byte) ([]byte, error) {
 
 // BatchStore stores multiple keys and values.
 func (s *Store) BatchStore(ctx context.Context, keys [][]byte, values [][]byte) error {
-	span, _ := opentracing.StartSpanFromContext(ctx, "storage.BatchStore")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "storage.BatchStore")
+	defer span.End()
 
 	if len(keys) == 0 {
 		return errors.New("no keys provided")
@@ -138,8 +140,8 @@ func (s *Store) BatchStore(ctx context.Context, keys [][]byte, values [][]byte)
 
 // Store stores the value for a given key.
 func (s *Store) Store(ctx context.Context, key []byte, value []byte) error {
-	span, _ := opentracing.StartSpanFromContext(ctx, "storage.Store")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "storage.Store")
+	defer span.End()
 
 	if len(key) == 0 {
 		return errors.New("no key provided")
@@ -154,6 +156,53 @@ func (s *Store) Store(ctx context.Context, key []byte, value []byte) error {
 	})
 }
 
+// Delete deletes the value for a given key.
+func (s *Store) Delete(ctx context.Context, key []byte) error {
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "storage.Delete")
+	defer span.End()
+
+	if len(key) == 0 {
+		return errors.New("no key provided")
+	}
+
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(key)
+	})
+}
+
+// Backup writes a consistent snapshot of the store.
+func (s *Store) Backup(ctx context.Context, w io.Writer) error {
+	_, err := s.db.Backup(w, 0)
+	return err
+}
+
+// Load loads a snapshot written by Backup in to the store.
+func (s *Store) Load(ctx context.Context, r io.Reader) (err error) {
+	// Badger can panic rather than return an error when given invalid data.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid snapshot: %v", r)
+		}
+	}()
+	return s.db.Load(r, 256)
+}
+
+// Compact discards superseded values in the store, reclaiming the space that they use.
+func (s *Store) Compact(ctx context.Context) error {
+	if err := s.db.Flatten(1); err != nil {
+		return errors.Wrap(err, "failed to flatten store")
+	}
+	for {
+		err := s.db.RunValueLogGC(0.5)
+		if err == badger.ErrNoRewrite || err == badger.ErrRejected {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to garbage collect value log")
+		}
+	}
+}
+
 // Close closes the store.
 func (s *Store) Close(ctx context.Context) error {
 	return s.db.Close()
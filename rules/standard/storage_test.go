@@ -15,6 +15,7 @@ package standard_test
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -218,6 +219,9 @@ func TestFetch(t *testing.T) {
 			}
 		})
 	}
+
+	_, err = service.Fetch(context.Background(), []byte("nokey"))
+	require.True(t, errors.Is(err, standardrules.ErrNotFound))
 }
 
 func TestFetchAll(t *testing.T) {
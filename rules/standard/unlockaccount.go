@@ -17,13 +17,13 @@ import (
 	"context"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
 )
 
 // OnUnlockAccount is called when a request to unlock an account needs to be approved.
 func (s *Service) OnUnlockAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.UnlockAccountData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnUnlockAccount")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnUnlockAccount")
+	defer span.End()
 
 	return rules.APPROVED
 }
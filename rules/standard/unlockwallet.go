@@ -17,13 +17,13 @@ import (
 	"context"
 
 	"github.com/attestantio/dirk/rules"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
 )
 
 // OnUnlockWallet is called when a request to unlock a wallet needs to be approved.
 func (s *Service) OnUnlockWallet(ctx context.Context, metadata *rules.ReqMetadata, req *rules.UnlockWalletData) rules.Result {
-	span, _ := opentracing.StartSpanFromContext(ctx, "rules.OnUnlockWallet")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.rules.standard").Start(ctx, "rules.OnUnlockWallet")
+	defer span.End()
 
 	return rules.APPROVED
 }
@@ -0,0 +1,53 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"fmt"
+)
+
+// newValidatorIndices indexes checked validator indices by public key.
+func newValidatorIndices(validatorIndices map[string]uint64) map[[48]byte]uint64 {
+	res := make(map[[48]byte]uint64, len(validatorIndices))
+	for pubKeyStr, index := range validatorIndices {
+		// Public keys have already been checked in checkPolicyParameters.
+		pubKey, _ := parseOverridePubKey(pubKeyStr)
+		var key [48]byte
+		copy(key[:], pubKey)
+		res[key] = index
+	}
+	return res
+}
+
+// checkValidatorIndices checks validator indices.
+func checkValidatorIndices(validatorIndices map[string]uint64) error {
+	for pubKeyStr := range validatorIndices {
+		if _, isPubKey := parseOverridePubKey(pubKeyStr); !isPubKey {
+			return fmt.Errorf("invalid public key %q for validator index", pubKeyStr)
+		}
+	}
+	return nil
+}
+
+// checkValidatorIndex checks that the index supplied with a request matches the expected index
+// for the public key.  Public keys without an expected index are not checked.
+func (s *Service) checkValidatorIndex(pubKey []byte, index uint64) bool {
+	var key [48]byte
+	copy(key[:], pubKey)
+	expected, exists := s.currentPolicy().validatorIndices[key]
+	if !exists {
+		return true
+	}
+	return index == expected
+}
@@ -0,0 +1,90 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorIndices(t *testing.T) {
+	ctx := context.Background()
+
+	pubKeyStr := "a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c"
+	otherPubKeyStr := "b89bebc699769726a318c8e9971bd3171297c61aea4a6578a7a4f94b547dcba5bac16a89108b6b6a1fe3695d1a874a0b"
+
+	_, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithValidatorIndices(map[string]uint64{"0x01": 1}),
+	)
+	require.EqualError(t, err, `problem with parameters: invalid public key "0x01" for validator index`)
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithValidatorIndices(map[string]uint64{"0x" + pubKeyStr: 1234}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	proposalDomain := _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000")
+	aggregateDomain := _byteStr(t, "0600000000000000000000000000000000000000000000000000000000000000")
+	root := _byteStr(t, "0101010101010101010101010101010101010101010101010101010101010101")
+
+	tests := []struct {
+		name   string
+		pubKey []byte
+		index  uint64
+		res    rules.Result
+	}{
+		{
+			name:   "Match",
+			pubKey: _byteStr(t, pubKeyStr),
+			index:  1234,
+			res:    rules.APPROVED,
+		},
+		{
+			name:   "Mismatch",
+			pubKey: _byteStr(t, pubKeyStr),
+			index:  1235,
+			res:    rules.DENIED,
+		},
+		{
+			name:   "Unknown",
+			pubKey: _byteStr(t, otherPubKeyStr),
+			index:  1235,
+			res:    rules.APPROVED,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			metadata := &rules.ReqMetadata{Account: "account", PubKey: test.pubKey}
+			require.Equal(t, test.res, testRules.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{
+				Domain:        proposalDomain,
+				Slot:          uint64(10 + i),
+				ProposerIndex: test.index,
+			}))
+			require.Equal(t, test.res, testRules.OnSignAggregateAndProof(ctx, metadata, &rules.SignAggregateAndProofData{
+				Domain:          aggregateDomain,
+				Slot:            uint64(10 + i),
+				AggregatorIndex: test.index,
+				AggregateRoot:   root,
+			}))
+		})
+	}
+}
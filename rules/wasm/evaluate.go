@@ -0,0 +1,161 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/pkg/errors"
+)
+
+// Hooks that the module can provide, named after the rules that they provide.
+const (
+	HookListAccounts          = "OnListAccounts"
+	HookSign                  = "OnSign"
+	HookSignBeaconAttestation = "OnSignBeaconAttestation"
+	HookSignBeaconProposal    = "OnSignBeaconProposal"
+	HookSignRANDAOReveal      = "OnSignRANDAOReveal"
+	HookSignAggregateAndProof = "OnSignAggregateAndProof"
+	HookSignAggregationSlot   = "OnSignAggregationSlot"
+	HookSignDeposit           = "OnSignDeposit"
+
+	HookSignSyncCommitteeMessage              = "OnSignSyncCommitteeMessage"
+	HookSignSyncCommitteeSelectionProof       = "OnSignSyncCommitteeSelectionProof"
+	HookSignSyncCommitteeContributionAndProof = "OnSignSyncCommitteeContributionAndProof"
+	HookSignVoluntaryExit                     = "OnSignVoluntaryExit"
+	HookSignBLSToExecutionChange              = "OnSignBLSToExecutionChange"
+	HookSignValidatorRegistration             = "OnSignValidatorRegistration"
+	HookLockWallet                            = "OnLockWallet"
+	HookUnlockWallet                          = "OnUnlockWallet"
+	HookLockAccount                           = "OnLockAccount"
+	HookUnlockAccount                         = "OnUnlockAccount"
+	HookPauseSigning                          = "OnPauseSigning"
+	HookResumeSigning                         = "OnResumeSigning"
+	HookExportAccount                         = "OnExportAccount"
+	HookRecoverAccount                        = "OnRecoverAccount"
+	HookCreateAccount                         = "OnCreateAccount"
+)
+
+var knownHooks = []string{
+	HookListAccounts,
+	HookSign,
+	HookSignBeaconAttestation,
+	HookSignBeaconProposal,
+	HookSignRANDAOReveal,
+	HookSignAggregateAndProof,
+	HookSignAggregationSlot,
+	HookSignDeposit,
+	HookSignSyncCommitteeMessage,
+	HookSignSyncCommitteeSelectionProof,
+	HookSignSyncCommitteeContributionAndProof,
+	HookSignVoluntaryExit,
+	HookSignBLSToExecutionChange,
+	HookSignValidatorRegistration,
+	HookLockWallet,
+	HookUnlockWallet,
+	HookLockAccount,
+	HookUnlockAccount,
+	HookPauseSigning,
+	HookResumeSigning,
+	HookExportAccount,
+	HookRecoverAccount,
+	HookCreateAccount,
+}
+
+// Results returned by hooks.  Any other result fails the request.
+const (
+	resultApproved = 0
+	resultDenied   = 1
+)
+
+// Request is the request passed to a hook, encoded as JSON.
+type Request struct {
+	// Metadata is the metadata of the request.
+	Metadata *rules.ReqMetadata `json:"metadata"`
+	// Data is the rule-specific data of the request.
+	Data interface{} `json:"data"`
+	// DryRun is true if the request is a dry run, and will not be signed.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// evaluate evaluates the request with the module's hook, if the module provides it.
+// Only a result of 0 results in APPROVED; a result of 1 results in DENIED, and any other result or a
+// failure to run the hook, including exceeding the timeout, results in FAILED.
+func (s *Service) evaluate(ctx context.Context, hook string, metadata *rules.ReqMetadata, data interface{}) rules.Result {
+	if !s.hooks[hook] {
+		return rules.APPROVED
+	}
+
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("hook", hook).Logger()
+	log.Trace().Msg("Evaluating request with module")
+	res, err := s.call(ctx, hook, &Request{
+		Metadata: metadata,
+		Data:     data,
+		DryRun:   rules.IsDryRun(ctx),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to evaluate request with module")
+		return rules.FAILED
+	}
+	switch res {
+	case resultApproved:
+		return rules.APPROVED
+	case resultDenied:
+		log.Warn().Msg("Module denied request")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	default:
+		log.Error().Uint32("result", res).Msg("Module returned unknown result")
+		return rules.FAILED
+	}
+}
+
+// call calls the hook in a new instance of the module, returning its result.
+func (s *Service) call(ctx context.Context, hook string, req *Request) (uint32, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to encode request")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	instance, err := s.runtime.InstantiateModule(ctx, s.module, s.config)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to instantiate module")
+	}
+	defer func() {
+		if err := instance.Close(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to close module instance")
+		}
+	}()
+
+	results, err := instance.ExportedFunction(allocateExport).Call(ctx, uint64(len(data)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to allocate memory for request")
+	}
+	ptr := uint32(results[0])
+	if !instance.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("allocated memory at %d is out of range", ptr)
+	}
+
+	results, err = instance.ExportedFunction(hook).Call(ctx, uint64(ptr), uint64(len(data)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to call hook")
+	}
+
+	return uint32(results[0]), nil
+}
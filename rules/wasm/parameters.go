@@ -0,0 +1,94 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel    zerolog.Level
+	module      []byte
+	timeout     time.Duration
+	memoryLimit uint32
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithModule sets the binary WebAssembly module that provides the rules.
+func WithModule(module []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.module = module
+	})
+}
+
+// WithTimeout sets the maximum time for the module to evaluate a request.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithMemoryLimit sets the maximum memory available to the module when evaluating a request, in bytes.
+// It is rounded down to a whole number of 64KiB WebAssembly pages.
+func WithMemoryLimit(memoryLimit uint32) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.memoryLimit = memoryLimit
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:    zerolog.GlobalLevel(),
+		timeout:     time.Second,
+		memoryLimit: 64 * 1024 * 1024,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if len(parameters.module) == 0 {
+		return nil, errors.New("no module specified")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	if parameters.memoryLimit < pageSize {
+		return nil, errors.New("memory limit must be at least 64KiB")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,156 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// pageSize is the size of a WebAssembly memory page.
+const pageSize = 64 * 1024
+
+// Exports that the module must provide in addition to its hooks.
+const (
+	// memoryExport is the memory in to which requests are written.
+	memoryExport = "memory"
+	// allocateExport is the function called to allocate memory for a request.
+	allocateExport = "allocate"
+)
+
+// Service is a rules service that evaluates requests with rules supplied as a WebAssembly module.
+// The module exports a function for each rule that it provides, named after the rule, such as
+// OnSignBeaconProposal; requests for rules that the module does not provide are approved without
+// calling the module, so this service is intended to be used as part of a rules chain alongside the
+// standard rules.  The module is instantiated afresh for each request, so it holds no state between
+// requests, and it has no access to the filesystem, network or keys.
+type Service struct {
+	runtime wazero.Runtime
+	module  wazero.CompiledModule
+	config  wazero.ModuleConfig
+	hooks   map[string]bool
+	timeout time.Duration
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new WebAssembly rules service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "rules").Str("impl", "wasm").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	// Closing the module when its context is done stops a module that does not return within the timeout.
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(parameters.memoryLimit/pageSize),
+	)
+	// WASI is provided so that modules built by standard toolchains can be loaded.  Modules are given no
+	// filesystem, environment or arguments, and their output is discarded.
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		_ = runtime.Close(ctx)
+		return nil, errors.Wrap(err, "failed to instantiate WASI")
+	}
+	module, err := runtime.CompileModule(ctx, parameters.module)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, errors.Wrap(err, "failed to compile module")
+	}
+	hooks, err := checkModule(module)
+	if err != nil {
+		_ = runtime.Close(ctx)
+		return nil, err
+	}
+	log.Trace().Int("hooks", len(hooks)).Msg("Compiled module")
+
+	return &Service{
+		runtime: runtime,
+		module:  module,
+		config: wazero.NewModuleConfig().
+			WithName("").
+			WithStartFunctions("_initialize").
+			WithSysWalltime(),
+		hooks:   hooks,
+		timeout: parameters.timeout,
+	}, nil
+}
+
+// Close releases the resources held by the module.
+func (s *Service) Close(ctx context.Context) error {
+	return s.runtime.Close(ctx)
+}
+
+// checkModule checks that the module only imports WASI and provides the required exports, returning the
+// hooks that it provides.
+func checkModule(module wazero.CompiledModule) (map[string]bool, error) {
+	for _, function := range module.ImportedFunctions() {
+		moduleName, name, _ := function.Import()
+		if moduleName != wasi_snapshot_preview1.ModuleName {
+			return nil, fmt.Errorf("module imports %s.%s; only WASI may be imported", moduleName, name)
+		}
+	}
+	if len(module.ImportedMemories()) > 0 {
+		return nil, errors.New("module imports memory")
+	}
+
+	exports := module.ExportedFunctions()
+	hooks := make(map[string]bool)
+	for _, hook := range knownHooks {
+		function, exists := exports[hook]
+		if !exists {
+			continue
+		}
+		if !hasSignature(function, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}) {
+			return nil, fmt.Errorf("hook %s must take two i32 parameters and return an i32", hook)
+		}
+		hooks[hook] = true
+	}
+	if len(hooks) == 0 {
+		return nil, errors.New("module does not export any hooks")
+	}
+
+	allocate, exists := exports[allocateExport]
+	if !exists {
+		return nil, fmt.Errorf("module does not export %s", allocateExport)
+	}
+	if !hasSignature(allocate, []api.ValueType{api.ValueTypeI32}, []api.ValueType{api.ValueTypeI32}) {
+		return nil, fmt.Errorf("%s must take an i32 parameter and return an i32", allocateExport)
+	}
+	if _, exists := module.ExportedMemories()[memoryExport]; !exists {
+		return nil, fmt.Errorf("module does not export %s", memoryExport)
+	}
+
+	return hooks, nil
+}
+
+// hasSignature returns true if the function has the given parameter and result types.
+func hasSignature(function api.FunctionDefinition, params []api.ValueType, results []api.ValueType) bool {
+	return string(function.ParamTypes()) == string(params) && string(function.ResultTypes()) == string(results)
+}
@@ -0,0 +1,241 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wasm_test
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/rules/wasm"
+	"github.com/stretchr/testify/require"
+)
+
+// module returns the contents of a test module.
+func module(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return data
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []wasm.Parameter
+		err    string
+	}{
+		{
+			name: "ModuleMissing",
+			err:  "problem with parameters: no module specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []wasm.Parameter{
+				wasm.WithModule(module(t, "rules.wasm")),
+				wasm.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "MemoryLimitTooLow",
+			params: []wasm.Parameter{
+				wasm.WithModule(module(t, "rules.wasm")),
+				wasm.WithMemoryLimit(1024),
+			},
+			err: "problem with parameters: memory limit must be at least 64KiB",
+		},
+		{
+			name: "ModuleInvalid",
+			params: []wasm.Parameter{
+				wasm.WithModule([]byte("not a module")),
+			},
+			err: "failed to compile module: invalid magic number",
+		},
+		{
+			name: "ModuleImports",
+			params: []wasm.Parameter{
+				wasm.WithModule(module(t, "imports.wasm")),
+			},
+			err: "module imports env.approve; only WASI may be imported",
+		},
+		{
+			name: "HookSignatureIncorrect",
+			params: []wasm.Parameter{
+				wasm.WithModule(module(t, "badhook.wasm")),
+			},
+			err: "hook OnSign must take two i32 parameters and return an i32",
+		},
+		{
+			name: "HooksMissing",
+			params: []wasm.Parameter{
+				wasm.WithModule(module(t, "nohooks.wasm")),
+			},
+			err: "module does not export any hooks",
+		},
+		{
+			name: "Good",
+			params: []wasm.Parameter{
+				wasm.WithModule(module(t, "rules.wasm")),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := wasm.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, s.Close(ctx))
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	ctx := context.Background()
+	s, err := wasm.New(ctx,
+		wasm.WithModule(module(t, "rules.wasm")),
+		wasm.WithTimeout(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer s.Close(ctx)
+
+	metadata := &rules.ReqMetadata{
+		Wallet:    "Wallet 1",
+		Account:   "Wallet 1/Account 1",
+		Client:    "client1",
+		RequestID: "request1",
+	}
+	deniedMetadata := &rules.ReqMetadata{
+		Wallet:    "Wallet 1",
+		Account:   "Wallet 1/deny",
+		Client:    "client1",
+		RequestID: "request2",
+	}
+
+	tests := []struct {
+		name   string
+		rule   func(ctx context.Context) rules.Result
+		result rules.Result
+		reason rules.Reason
+	}{
+		{
+			name: "ProposalDenied",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignBeaconProposal(ctx, metadata, &rules.SignBeaconProposalData{Slot: 1})
+			},
+			result: rules.DENIED,
+			reason: rules.ReasonPolicy,
+		},
+		{
+			name: "AttestationApproved",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignBeaconAttestation(ctx, metadata, &rules.SignBeaconAttestationData{Slot: 1})
+			},
+			result: rules.APPROVED,
+		},
+		{
+			name: "AttestationDenied",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignBeaconAttestation(ctx, deniedMetadata, &rules.SignBeaconAttestationData{Slot: 1})
+			},
+			result: rules.DENIED,
+			reason: rules.ReasonPolicy,
+		},
+		{
+			name: "Timeout",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSign(ctx, metadata, &rules.SignData{})
+			},
+			result: rules.FAILED,
+		},
+		{
+			name: "Trap",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignRANDAOReveal(ctx, metadata, &rules.SignRANDAORevealData{Epoch: 1})
+			},
+			result: rules.FAILED,
+		},
+		{
+			name: "UnknownResult",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignVoluntaryExit(ctx, metadata, &rules.SignVoluntaryExitData{Epoch: 1})
+			},
+			result: rules.FAILED,
+		},
+		{
+			// The module denies repeat requests to the same instance, so this checks that each request has
+			// a new instance.
+			name: "Stateless1",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignAggregationSlot(ctx, metadata, &rules.SignAggregationSlotData{Slot: 1})
+			},
+			result: rules.APPROVED,
+		},
+		{
+			name: "Stateless2",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignAggregationSlot(ctx, metadata, &rules.SignAggregationSlotData{Slot: 1})
+			},
+			result: rules.APPROVED,
+		},
+		{
+			name: "HookNotProvided",
+			rule: func(ctx context.Context) rules.Result {
+				return s.OnSignDeposit(ctx, deniedMetadata, &rules.SignDepositData{})
+			},
+			result: rules.APPROVED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := rules.WithReasonRecorder(ctx)
+			require.Equal(t, test.result, test.rule(ctx))
+			require.Equal(t, test.reason, rules.RecordedReason(ctx))
+		})
+	}
+}
+
+func TestSignBeaconAttestations(t *testing.T) {
+	ctx := context.Background()
+	s, err := wasm.New(ctx, wasm.WithModule(module(t, "rules.wasm")))
+	require.NoError(t, err)
+	defer s.Close(ctx)
+
+	metadata := []*rules.ReqMetadata{
+		{Account: "Wallet 1/Account 1"},
+		{Account: "Wallet 1/deny"},
+		{Account: "Wallet 1/Account 2"},
+	}
+	data := []*rules.SignBeaconAttestationData{
+		{Slot: 1},
+		{Slot: 1},
+		{Slot: 1},
+	}
+
+	require.Equal(t, []rules.Result{rules.APPROVED, rules.DENIED, rules.APPROVED},
+		s.OnSignBeaconAttestations(ctx, metadata, data))
+	// An atomic batch approves no attestations unless it approves them all.
+	require.Equal(t, []rules.Result{rules.DENIED, rules.DENIED, rules.DENIED},
+		s.OnSignBeaconAttestations(rules.WithAtomicBatch(ctx), metadata, data))
+	require.Equal(t, []rules.Result{rules.APPROVED, rules.APPROVED},
+		s.OnSignBeaconAttestations(rules.WithAtomicBatch(ctx), []*rules.ReqMetadata{metadata[0], metadata[2]}, []*rules.SignBeaconAttestationData{data[0], data[2]}))
+}
@@ -0,0 +1,14 @@
+;; imports.wat is the source of imports.wasm, a module that imports a function from a module other than WASI.
+(module
+  (import "env" "approve" (func))
+  (memory (export "memory") 1)
+  (global $next (mut i32) (i32.const 1024))
+
+  (func (export "allocate") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $next))
+    (global.set $next (i32.add (global.get $next) (local.get $size)))
+    (local.get $ptr))
+
+  (func (export "OnSign") (param $ptr i32) (param $len i32) (result i32)
+    (i32.const 0)))
@@ -0,0 +1,10 @@
+;; nohooks.wat is the source of nohooks.wasm, a module that does not export any hooks.
+(module
+  (memory (export "memory") 1)
+  (global $next (mut i32) (i32.const 1024))
+
+  (func (export "allocate") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $next))
+    (global.set $next (i32.add (global.get $next) (local.get $size)))
+    (local.get $ptr)))
@@ -0,0 +1,47 @@
+;; rules.wat is the source of rules.wasm, the module used to test WebAssembly rules.
+(module
+  (memory (export "memory") 1)
+  (global $next (mut i32) (i32.const 1024))
+  (global $calls (mut i32) (i32.const 0))
+
+  ;; allocate returns memory for a request of the given size.
+  (func (export "allocate") (param $size i32) (result i32)
+    (local $ptr i32)
+    (local.set $ptr (global.get $next))
+    (global.set $next (i32.add (global.get $next) (local.get $size)))
+    (local.get $ptr))
+
+  ;; OnSignBeaconProposal denies all proposals.
+  (func (export "OnSignBeaconProposal") (param $ptr i32) (param $len i32) (result i32)
+    (i32.const 1))
+
+  ;; OnSignBeaconAttestation denies attestations whose request contains "deny", and approves others.
+  (func (export "OnSignBeaconAttestation") (param $ptr i32) (param $len i32) (result i32)
+    (local $end i32)
+    (local.set $end (i32.sub (i32.add (local.get $ptr) (local.get $len)) (i32.const 4)))
+    (block $done
+      (loop $scan
+        (br_if $done (i32.gt_s (local.get $ptr) (local.get $end)))
+        (if (i32.eq (i32.load align=1 (local.get $ptr)) (i32.const 0x796e6564)) ;; "deny"
+          (then (return (i32.const 1))))
+        (local.set $ptr (i32.add (local.get $ptr) (i32.const 1)))
+        (br $scan)))
+    (i32.const 0))
+
+  ;; OnSign never returns.
+  (func (export "OnSign") (param $ptr i32) (param $len i32) (result i32)
+    (loop $forever (br $forever))
+    (i32.const 0))
+
+  ;; OnSignRANDAOReveal traps.
+  (func (export "OnSignRANDAOReveal") (param $ptr i32) (param $len i32) (result i32)
+    (unreachable))
+
+  ;; OnSignVoluntaryExit returns an unknown result.
+  (func (export "OnSignVoluntaryExit") (param $ptr i32) (param $len i32) (result i32)
+    (i32.const 2))
+
+  ;; OnSignAggregationSlot denies all but the first request made to an instance of the module.
+  (func (export "OnSignAggregationSlot") (param $ptr i32) (param $len i32) (result i32)
+    (global.set $calls (i32.add (global.get $calls) (i32.const 1)))
+    (i32.gt_u (global.get $calls) (i32.const 1))))
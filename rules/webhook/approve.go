@@ -0,0 +1,170 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/pkg/errors"
+)
+
+// Actions that can require approval from the webhook.
+const (
+	ActionListAccounts          = "ListAccounts"
+	ActionSign                  = "Sign"
+	ActionSignBeaconAttestation = "SignBeaconAttestation"
+	ActionSignBeaconProposal    = "SignBeaconProposal"
+	ActionSignRANDAOReveal      = "SignRANDAOReveal"
+	ActionSignAggregateAndProof = "SignAggregateAndProof"
+	ActionSignAggregationSlot   = "SignAggregationSlot"
+	ActionSignDeposit           = "SignDeposit"
+
+	ActionSignSyncCommitteeMessage              = "SignSyncCommitteeMessage"
+	ActionSignSyncCommitteeSelectionProof       = "SignSyncCommitteeSelectionProof"
+	ActionSignSyncCommitteeContributionAndProof = "SignSyncCommitteeContributionAndProof"
+	ActionSignVoluntaryExit                     = "SignVoluntaryExit"
+	ActionSignBLSToExecutionChange              = "SignBLSToExecutionChange"
+	ActionSignValidatorRegistration             = "SignValidatorRegistration"
+	ActionLockWallet                            = "LockWallet"
+	ActionUnlockWallet                          = "UnlockWallet"
+	ActionLockAccount                           = "LockAccount"
+	ActionUnlockAccount                         = "UnlockAccount"
+	ActionPauseSigning                          = "PauseSigning"
+	ActionResumeSigning                         = "ResumeSigning"
+	ActionExportAccount                         = "ExportAccount"
+	ActionRecoverAccount                        = "RecoverAccount"
+	ActionCreateAccount                         = "CreateAccount"
+)
+
+var knownActions = map[string]bool{
+	ActionListAccounts:                          true,
+	ActionSign:                                  true,
+	ActionSignBeaconAttestation:                 true,
+	ActionSignBeaconProposal:                    true,
+	ActionSignRANDAOReveal:                      true,
+	ActionSignAggregateAndProof:                 true,
+	ActionSignAggregationSlot:                   true,
+	ActionSignDeposit:                           true,
+	ActionSignSyncCommitteeMessage:              true,
+	ActionSignSyncCommitteeSelectionProof:       true,
+	ActionSignSyncCommitteeContributionAndProof: true,
+	ActionSignVoluntaryExit:                     true,
+	ActionSignBLSToExecutionChange:              true,
+	ActionSignValidatorRegistration:             true,
+	ActionLockWallet:                            true,
+	ActionUnlockWallet:                          true,
+	ActionLockAccount:                           true,
+	ActionUnlockAccount:                         true,
+	ActionPauseSigning:                          true,
+	ActionResumeSigning:                         true,
+	ActionExportAccount:                         true,
+	ActionRecoverAccount:                        true,
+	ActionCreateAccount:                         true,
+}
+
+// ApprovalRequest is the request sent to the webhook.
+type ApprovalRequest struct {
+	// Action is the action that requires approval.
+	Action string `json:"action"`
+	// Metadata is the metadata of the request.
+	Metadata *rules.ReqMetadata `json:"metadata"`
+	// Data is the action-specific data of the request.
+	Data interface{} `json:"data"`
+	// DryRun is true if the request is a dry run, and will not be signed.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// ApprovalResponse is the response returned by the webhook.
+type ApprovalResponse struct {
+	// Approved is true if the request is approved.
+	Approved bool `json:"approved"`
+	// Reason is the reason for the decision.
+	Reason string `json:"reason,omitempty"`
+}
+
+// approve asks the webhook to approve the request, if the action requires approval.
+// Only an affirmative response results in APPROVED; a negative response results in DENIED, and any
+// failure to obtain a valid response, including a timeout, results in FAILED.
+func (s *Service) approve(ctx context.Context, action string, metadata *rules.ReqMetadata, data interface{}) rules.Result {
+	if !s.actions[action] {
+		return rules.APPROVED
+	}
+
+	log := log.With().Str("request_id", metadata.RequestID).Str("client", metadata.Client).Str("account", metadata.Account).Str("action", action).Logger()
+	log.Trace().Msg("Requesting approval from webhook")
+	res, err := s.call(ctx, &ApprovalRequest{
+		Action:   action,
+		Metadata: metadata,
+		Data:     data,
+		DryRun:   rules.IsDryRun(ctx),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain approval from webhook")
+		return rules.FAILED
+	}
+	if !res.Approved {
+		log.Warn().Str("reason", res.Reason).Msg("Webhook did not approve request")
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		return rules.DENIED
+	}
+	log.Info().Str("reason", res.Reason).Msg("Webhook approved request")
+
+	return rules.APPROVED
+}
+
+// call calls the webhook, returning its response.
+func (s *Service) call(ctx context.Context, req *ApprovalRequest) (*ApprovalResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode request")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	httpRes, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call webhook")
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("webhook returned status %d", httpRes.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(httpRes.Body, 64*1024))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	res := &ApprovalResponse{}
+	if err := json.Unmarshal(data, res); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+
+	return res, nil
+}
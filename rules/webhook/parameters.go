@@ -0,0 +1,128 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel zerolog.Level
+	url      string
+	timeout  time.Duration
+	actions  []string
+	token    string
+	caCert   []byte
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithURL sets the HTTPS URL of the webhook.
+func WithURL(url string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.url = url
+	})
+}
+
+// WithTimeout sets the maximum time to wait for the webhook to respond.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithActions sets the actions that require approval from the webhook.
+// Requests for other actions are approved without calling the webhook.
+func WithActions(actions []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.actions = actions
+	})
+}
+
+// WithToken sets a bearer token sent to the webhook with each request.
+func WithToken(token string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.token = token
+	})
+}
+
+// WithCACert sets the CA certificate used to verify the webhook, in addition to the system CA certificates.
+func WithCACert(caCert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = caCert
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		timeout:  time.Minute,
+		actions: []string{
+			ActionSignVoluntaryExit,
+			ActionCreateAccount,
+			ActionUnlockWallet,
+		},
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.url == "" {
+		return nil, errors.New("no URL specified")
+	}
+	webhookURL, err := url.Parse(parameters.url)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+	if webhookURL.Scheme != "https" {
+		return nil, errors.New("URL must use https")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	if len(parameters.actions) == 0 {
+		return nil, errors.New("no actions specified")
+	}
+	for _, action := range parameters.actions {
+		if !knownActions[action] {
+			return nil, fmt.Errorf("unknown action %q", action)
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,85 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service is a rules service that requires approval from an external webhook for selected actions,
+// allowing rare and dangerous operations to be approved by a person or a ticketing system.
+// Requests for actions that are not selected are approved without calling the webhook, so this
+// service is intended to be used as part of a rules chain alongside the standard rules.
+type Service struct {
+	url     string
+	timeout time.Duration
+	actions map[string]bool
+	token   string
+	client  *http.Client
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new webhook rules service.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "rules").Str("impl", "webhook").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(parameters.caCert) > 0 {
+		cp, err := x509.SystemCertPool()
+		if err != nil {
+			cp = x509.NewCertPool()
+		}
+		if !cp.AppendCertsFromPEM(parameters.caCert) {
+			return nil, errors.New("failed to add CA certificate")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    cp,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	actions := make(map[string]bool, len(parameters.actions))
+	for _, action := range parameters.actions {
+		actions[action] = true
+	}
+
+	return &Service{
+		url:     parameters.url,
+		timeout: parameters.timeout,
+		actions: actions,
+		token:   parameters.token,
+		client: &http.Client{
+			Transport: transport,
+		},
+	}, nil
+}
@@ -0,0 +1,249 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/rules/webhook"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhook is an in-process webhook.
+type fakeWebhook struct {
+	mu       sync.Mutex
+	requests []*webhook.ApprovalRequest
+	tokens   []string
+	status   int
+	response string
+	delay    time.Duration
+}
+
+func (w *fakeWebhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	req := &webhook.ApprovalRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.mu.Lock()
+	w.requests = append(w.requests, req)
+	w.tokens = append(w.tokens, r.Header.Get("Authorization"))
+	w.mu.Unlock()
+	time.Sleep(w.delay)
+	rw.WriteHeader(w.status)
+	_, _ = rw.Write([]byte(w.response))
+}
+
+// startWebhook starts a fake webhook, returning its URL and CA certificate.
+func startWebhook(t *testing.T, hook *fakeWebhook) (string, []byte) {
+	server := httptest.NewTLSServer(hook)
+	t.Cleanup(server.Close)
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server.URL, caCert
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []webhook.Parameter
+		err    string
+	}{
+		{
+			name: "URLMissing",
+			err:  "problem with parameters: no URL specified",
+		},
+		{
+			name: "URLNotHTTPS",
+			params: []webhook.Parameter{
+				webhook.WithURL("http://localhost:8080/approve"),
+			},
+			err: "problem with parameters: URL must use https",
+		},
+		{
+			name: "TimeoutZero",
+			params: []webhook.Parameter{
+				webhook.WithURL("https://localhost:8080/approve"),
+				webhook.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "ActionsEmpty",
+			params: []webhook.Parameter{
+				webhook.WithURL("https://localhost:8080/approve"),
+				webhook.WithActions([]string{}),
+			},
+			err: "problem with parameters: no actions specified",
+		},
+		{
+			name: "ActionUnknown",
+			params: []webhook.Parameter{
+				webhook.WithURL("https://localhost:8080/approve"),
+				webhook.WithActions([]string{"SignVoluntaryExit", "Unknown"}),
+			},
+			err: `problem with parameters: unknown action "Unknown"`,
+		},
+		{
+			name: "CACertInvalid",
+			params: []webhook.Parameter{
+				webhook.WithURL("https://localhost:8080/approve"),
+				webhook.WithCACert([]byte("invalid")),
+			},
+			err: "failed to add CA certificate",
+		},
+		{
+			name: "Good",
+			params: []webhook.Parameter{
+				webhook.WithURL("https://localhost:8080/approve"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := webhook.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestApproval(t *testing.T) {
+	ctx := context.Background()
+	metadata := &rules.ReqMetadata{Account: "Test wallet/Test account", RequestID: "request1"}
+
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		delay    time.Duration
+		res      rules.Result
+	}{
+		{
+			name:     "Approved",
+			status:   http.StatusOK,
+			response: `{"approved":true,"reason":"ticket 1234"}`,
+			res:      rules.APPROVED,
+		},
+		{
+			name:     "NotApproved",
+			status:   http.StatusOK,
+			response: `{"approved":false,"reason":"rejected by operator"}`,
+			res:      rules.DENIED,
+		},
+		{
+			name:     "EmptyResponse",
+			status:   http.StatusOK,
+			response: `{}`,
+			res:      rules.DENIED,
+		},
+		{
+			name:     "InvalidResponse",
+			status:   http.StatusOK,
+			response: `approved`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "ErrorStatus",
+			status:   http.StatusInternalServerError,
+			response: `{"approved":true}`,
+			res:      rules.FAILED,
+		},
+		{
+			name:     "Timeout",
+			status:   http.StatusOK,
+			response: `{"approved":true}`,
+			delay:    200 * time.Millisecond,
+			res:      rules.FAILED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hook := &fakeWebhook{
+				status:   test.status,
+				response: test.response,
+				delay:    test.delay,
+			}
+			url, caCert := startWebhook(t, hook)
+			s, err := webhook.New(ctx,
+				webhook.WithURL(url),
+				webhook.WithCACert(caCert),
+				webhook.WithTimeout(100*time.Millisecond),
+				webhook.WithToken("secret"),
+			)
+			require.NoError(t, err)
+
+			require.Equal(t, test.res, s.OnSignVoluntaryExit(ctx, metadata, &rules.SignVoluntaryExitData{Epoch: 10, ValidatorIndex: 1}))
+			hook.mu.Lock()
+			defer hook.mu.Unlock()
+			require.Len(t, hook.requests, 1)
+			require.Equal(t, webhook.ActionSignVoluntaryExit, hook.requests[0].Action)
+			require.Equal(t, "request1", hook.requests[0].Metadata.RequestID)
+			require.Equal(t, "Bearer secret", hook.tokens[0])
+		})
+	}
+}
+
+func TestActions(t *testing.T) {
+	ctx := context.Background()
+	metadata := &rules.ReqMetadata{Account: "Test wallet/Test account"}
+
+	hook := &fakeWebhook{
+		status:   http.StatusOK,
+		response: `{"approved":false}`,
+	}
+	url, caCert := startWebhook(t, hook)
+	s, err := webhook.New(ctx,
+		webhook.WithURL(url),
+		webhook.WithCACert(caCert),
+		webhook.WithActions([]string{webhook.ActionCreateAccount, webhook.ActionSignBeaconAttestation}),
+	)
+	require.NoError(t, err)
+
+	// Actions that are not configured are approved without calling the webhook.
+	require.Equal(t, rules.APPROVED, s.OnSignVoluntaryExit(ctx, metadata, &rules.SignVoluntaryExitData{}))
+	require.Equal(t, rules.APPROVED, s.OnUnlockWallet(ctx, metadata, &rules.UnlockWalletData{}))
+	hook.mu.Lock()
+	require.Empty(t, hook.requests)
+	hook.mu.Unlock()
+
+	// Configured actions require approval.
+	require.Equal(t, rules.DENIED, s.OnCreateAccount(ctx, metadata, &rules.CreateAccountData{}))
+	require.Equal(t, []rules.Result{rules.DENIED, rules.DENIED},
+		s.OnSignBeaconAttestations(ctx,
+			[]*rules.ReqMetadata{metadata, metadata},
+			[]*rules.SignBeaconAttestationData{{}, {}},
+		))
+	hook.mu.Lock()
+	require.Len(t, hook.requests, 3)
+	hook.mu.Unlock()
+
+	// Webhook rules do not hold slashing protection.
+	_, err = s.ExportSlashingProtection(ctx)
+	require.EqualError(t, err, "webhook rules do not hold slashing protection")
+}
@@ -0,0 +1,271 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+// accountOverrideConfig is the configuration for an account override.
+type accountOverrideConfig struct {
+	Accounts        []string `mapstructure:"accounts"`
+	MaxFutureEpochs *uint64  `mapstructure:"max-future-epochs"`
+	MaxFutureSlots  *uint64  `mapstructure:"max-future-slots"`
+}
+
+// rulesPolicyParameters obtains the rules policy parameters.
+// If server.rules.policy is set the policy is fetched from that location, otherwise it is taken
+// from server.rules in the supplied configuration.
+func rulesPolicyParameters(ctx context.Context, cfg *viper.Viper) ([]standardrules.Parameter, error) {
+	location := cfg.GetString("server.rules.policy")
+	if location == "" {
+		return policyParameters(cfg, "server.rules.")
+	}
+
+	policyCfg, err := fetchRulesPolicy(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+	return policyParameters(policyCfg, "")
+}
+
+// reloadRulesPolicy reads the configuration file and fetches the rules policy again, and supplies
+// the policy to the rules service.  Failure to reload leaves the existing policy in place.
+func reloadRulesPolicy(ctx context.Context, rulesSvc rules.Service) {
+	updater, isUpdater := rulesSvc.(*standardrules.Service)
+	if !isUpdater {
+		log.Warn().Msg("Rules service does not support policy reload")
+		return
+	}
+
+	log.Info().Msg("Reloading rules policy")
+	cfg, err := rereadConfig()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to read configuration; retaining existing policy")
+		return
+	}
+	params, err := rulesPolicyParameters(ctx, cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain rules policy; retaining existing policy")
+		return
+	}
+	if err := updater.UpdatePolicy(ctx, params...); err != nil {
+		log.Error().Err(err).Msg("Failed to update rules policy; retaining existing policy")
+	}
+}
+
+// fetchRulesPolicy fetches and parses the rules policy from the given location.
+// The location can be an S3 URL of the form s3://bucket/key, or a path to a local file.
+func fetchRulesPolicy(ctx context.Context, location string) (*viper.Viper, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(location, "s3://") {
+		data, err = fetchS3Object(ctx, location)
+	} else {
+		data, err = ioutil.ReadFile(resolvePath(location))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch rules policy from %s", location))
+	}
+
+	cfg := viper.New()
+	cfg.SetConfigType(policyConfigType(location))
+	if err := cfg.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("failed to parse rules policy from %s", location))
+	}
+
+	return cfg, nil
+}
+
+// policyConfigType returns the configuration type of the policy, based on its extension.
+func policyConfigType(location string) string {
+	switch strings.ToLower(filepath.Ext(location)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	default:
+		return "yaml"
+	}
+}
+
+// fetchS3Object fetches an object given its S3 URL.
+// Credentials are obtained from the standard AWS environment and instance chain.
+func fetchS3Object(ctx context.Context, location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid S3 URL")
+	}
+	bucket := u.Host
+	key := strings.TrimPrefix(u.Path, "/")
+	if bucket == "" {
+		return nil, errors.New("no bucket in S3 URL")
+	}
+	if key == "" {
+		return nil, errors.New("no key in S3 URL")
+	}
+
+	cfg := aws.NewConfig()
+	if endpoint := viper.GetString("server.rules.policy-s3-endpoint"); endpoint != "" {
+		// S3-compatible store.
+		cfg = cfg.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *cfg,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create AWS session")
+	}
+
+	out, err := s3.New(sess).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain S3 object")
+	}
+	defer out.Body.Close()
+
+	return ioutil.ReadAll(out.Body)
+}
+
+// policyParameters creates rules policy parameters from configuration.
+func policyParameters(cfg *viper.Viper, prefix string) ([]standardrules.Parameter, error) {
+	signDomainTypes := make(map[string][][]byte)
+	for client, domainTypeStrs := range cfg.GetStringMapStringSlice(prefix + "sign-domain-types") {
+		signDomainTypes[client] = make([][]byte, 0, len(domainTypeStrs))
+		for _, domainTypeStr := range domainTypeStrs {
+			domainType, err := hex.DecodeString(strings.TrimPrefix(domainTypeStr, "0x"))
+			if err != nil {
+				return nil, errors.Wrap(err, "invalid sign domain type")
+			}
+			signDomainTypes[client] = append(signDomainTypes[client], domainType)
+		}
+	}
+
+	graffitiPolicies := make(map[string]*standardrules.GraffitiPolicy)
+	for account := range cfg.GetStringMap(prefix + "graffiti") {
+		policy := &standardrules.GraffitiPolicy{
+			Allowed: cfg.GetStringSlice(fmt.Sprintf("%sgraffiti.%s.allowed", prefix, account)),
+			Denied:  cfg.GetStringSlice(fmt.Sprintf("%sgraffiti.%s.denied", prefix, account)),
+		}
+		if pattern := cfg.GetString(fmt.Sprintf("%sgraffiti.%s.pattern", prefix, account)); pattern != "" {
+			var err error
+			policy.Pattern, err = regexp.Compile(pattern)
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("invalid graffiti pattern for %s", account))
+			}
+		}
+		graffitiPolicies[account] = policy
+	}
+
+	depositPolicies := make(map[string]*standardrules.DepositPolicy)
+	for account := range cfg.GetStringMap(prefix + "deposits") {
+		policy := &standardrules.DepositPolicy{
+			MinAmount: cfg.GetUint64(fmt.Sprintf("%sdeposits.%s.min-amount", prefix, account)),
+			MaxAmount: cfg.GetUint64(fmt.Sprintf("%sdeposits.%s.max-amount", prefix, account)),
+		}
+		for _, withdrawalCredentialsStr := range cfg.GetStringSlice(fmt.Sprintf("%sdeposits.%s.withdrawal-credentials", prefix, account)) {
+			withdrawalCredentials, err := hex.DecodeString(strings.TrimPrefix(withdrawalCredentialsStr, "0x"))
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("invalid deposit withdrawal credentials for %s", account))
+			}
+			policy.WithdrawalCredentials = append(policy.WithdrawalCredentials, withdrawalCredentials)
+		}
+		depositPolicies[account] = policy
+	}
+
+	voluntaryExitPolicies := make(map[string]*standardrules.VoluntaryExitPolicy)
+	for account := range cfg.GetStringMap(prefix + "voluntary-exits") {
+		voluntaryExitPolicies[account] = &standardrules.VoluntaryExitPolicy{
+			Allowed:               cfg.GetBool(fmt.Sprintf("%svoluntary-exits.%s.allowed", prefix, account)),
+			RequireSecondApproval: cfg.GetBool(fmt.Sprintf("%svoluntary-exits.%s.require-second-approval", prefix, account)),
+		}
+	}
+
+	blsToExecutionChangePolicies := make(map[string]*standardrules.BLSToExecutionChangePolicy)
+	for account := range cfg.GetStringMap(prefix + "bls-to-execution-changes") {
+		policy := &standardrules.BLSToExecutionChangePolicy{}
+		for _, executionAddressStr := range cfg.GetStringSlice(fmt.Sprintf("%sbls-to-execution-changes.%s.execution-addresses", prefix, account)) {
+			executionAddress, err := hex.DecodeString(strings.TrimPrefix(executionAddressStr, "0x"))
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("invalid BLS to execution change execution address for %s", account))
+			}
+			policy.ExecutionAddresses = append(policy.ExecutionAddresses, executionAddress)
+		}
+		blsToExecutionChangePolicies[account] = policy
+	}
+
+	validatorRegistrationPolicies := make(map[string]*standardrules.ValidatorRegistrationPolicy)
+	for account := range cfg.GetStringMap(prefix + "validator-registrations") {
+		policy := &standardrules.ValidatorRegistrationPolicy{}
+		for _, feeRecipientStr := range cfg.GetStringSlice(fmt.Sprintf("%svalidator-registrations.%s.fee-recipients", prefix, account)) {
+			feeRecipient, err := hex.DecodeString(strings.TrimPrefix(feeRecipientStr, "0x"))
+			if err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("invalid validator registration fee recipient for %s", account))
+			}
+			policy.FeeRecipients = append(policy.FeeRecipients, feeRecipient)
+		}
+		validatorRegistrationPolicies[account] = policy
+	}
+
+	validatorIndices := make(map[string]uint64)
+	for pubKey := range cfg.GetStringMap(prefix + "validator-indices") {
+		validatorIndices[pubKey] = cfg.GetUint64(fmt.Sprintf("%svalidator-indices.%s", prefix, pubKey))
+	}
+
+	var accountOverrides []*accountOverrideConfig
+	if err := cfg.UnmarshalKey(prefix+"account-overrides", &accountOverrides); err != nil {
+		return nil, errors.Wrap(err, "invalid account overrides")
+	}
+	overrides := make([]*standardrules.AccountOverride, len(accountOverrides))
+	for i := range accountOverrides {
+		overrides[i] = &standardrules.AccountOverride{
+			Accounts:        accountOverrides[i].Accounts,
+			MaxFutureEpochs: accountOverrides[i].MaxFutureEpochs,
+			MaxFutureSlots:  accountOverrides[i].MaxFutureSlots,
+		}
+	}
+
+	return []standardrules.Parameter{
+		standardrules.WithAdminIPs(cfg.GetStringSlice(prefix + "admin-ips")),
+		standardrules.WithSignDomainTypes(signDomainTypes),
+		standardrules.WithGraffitiPolicies(graffitiPolicies),
+		standardrules.WithDepositPolicies(depositPolicies),
+		standardrules.WithVoluntaryExitPolicies(voluntaryExitPolicies),
+		standardrules.WithBLSToExecutionChangePolicies(blsToExecutionChangePolicies),
+		standardrules.WithValidatorRegistrationPolicies(validatorRegistrationPolicies),
+		standardrules.WithValidatorIndices(validatorIndices),
+		standardrules.WithDeniedPubKeys(cfg.GetStringSlice(prefix + "denied-pubkeys")),
+		standardrules.WithCreateAccountPaths(cfg.GetStringMapStringSlice(prefix + "create-account-paths")),
+		standardrules.WithAccountOverrides(overrides),
+	}, nil
+}
@@ -33,8 +33,9 @@ func (s *Service) Generate(ctx context.Context,
 	credentials *checker.Credentials,
 	account string,
 	passphrase []byte,
-	participants uint32,
 	signingThreshold uint32,
+	participants uint32,
+	path string,
 ) (
 	core.Result,
 	[]byte,
@@ -57,6 +58,26 @@ func (s *Service) Generate(ctx context.Context,
 		nil
 }
 
+// Import imports an account from an EIP-2335 keystore in to a wallet.
+func (s *Service) Import(ctx context.Context,
+	credentials *checker.Credentials,
+	wallet string,
+	keystore []byte,
+	passphrase []byte,
+) (
+	core.Result,
+	[]byte,
+	error,
+) {
+	return core.ResultSucceeded,
+		[]byte{
+			0xb5, 0xdd, 0x37, 0x43, 0xf5, 0x7f, 0xcd, 0xf3, 0x9c, 0x6c, 0xf8, 0xdb, 0x4c, 0x4a, 0xbd, 0x0e,
+			0xb7, 0xda, 0x8d, 0x71, 0xb0, 0x6b, 0x5b, 0xdc, 0x2b, 0x3b, 0xc4, 0x37, 0x03, 0xc0, 0x0d, 0xdb,
+			0xb3, 0xef, 0xd3, 0x44, 0x86, 0x2c, 0xf9, 0x0a, 0x6b, 0xda, 0x60, 0xb2, 0x03, 0x78, 0x8e, 0x17,
+		},
+		nil
+}
+
 // Unlock unlocks an account.
 func (s *Service) Unlock(ctx context.Context,
 	credentials *checker.Credentials,
@@ -79,3 +100,69 @@ func (s *Service) Lock(ctx context.Context,
 ) {
 	return core.ResultSucceeded, nil
 }
+
+// UnlockAccounts unlocks multiple accounts.
+func (s *Service) UnlockAccounts(ctx context.Context,
+	credentials *checker.Credentials,
+	accounts []string,
+	passphrase []byte,
+) (
+	[]core.Result,
+	error,
+) {
+	results := make([]core.Result, len(accounts))
+	for i := range results {
+		results[i] = core.ResultSucceeded
+	}
+	return results, nil
+}
+
+// LockAccounts locks multiple accounts.
+func (s *Service) LockAccounts(ctx context.Context,
+	credentials *checker.Credentials,
+	accounts []string,
+) (
+	[]core.Result,
+	error,
+) {
+	results := make([]core.Result, len(accounts))
+	for i := range results {
+		results[i] = core.ResultSucceeded
+	}
+	return results, nil
+}
+
+// Export exports an account as an EIP-2335 keystore.
+func (s *Service) Export(ctx context.Context,
+	credentials *checker.Credentials,
+	account string,
+	passphrase []byte,
+) (
+	core.Result,
+	[]byte,
+	error,
+) {
+	return core.ResultSucceeded, []byte(`{"version":4}`), nil
+}
+
+// PauseSigning pauses signing for an account.
+func (s *Service) PauseSigning(ctx context.Context,
+	credentials *checker.Credentials,
+	account string,
+) (
+	core.Result,
+	error,
+) {
+	return core.ResultSucceeded, nil
+}
+
+// ResumeSigning resumes signing for an account.
+func (s *Service) ResumeSigning(ctx context.Context,
+	credentials *checker.Credentials,
+	account string,
+) (
+	core.Result,
+	error,
+) {
+	return core.ResultSucceeded, nil
+}
@@ -18,8 +18,16 @@ import (
 
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/services/checker"
+	"github.com/pkg/errors"
 )
 
+// PathEIP2334 is the derivation path supplied to Generate to derive the account at the next unused EIP-2334
+// validator signing key path of the wallet, m/12381/3600/i/0/0.
+const PathEIP2334 = "eip2334"
+
+// ErrAccountExists is returned when importing an account whose key is already present.
+var ErrAccountExists = errors.New("account already exists")
+
 // Service is the account manager service.
 type Service interface {
 	// Generate generates a new account.
@@ -27,8 +35,9 @@ type Service interface {
 		credentials *checker.Credentials,
 		account string,
 		passphrase []byte,
-		participants uint32,
 		signingThreshold uint32,
+		participants uint32,
+		path string,
 	) (
 		core.Result,
 		[]byte,
@@ -36,6 +45,29 @@ type Service interface {
 		error,
 	)
 
+	// Import imports an account from an EIP-2335 keystore in to a wallet, returning the public key of the account.
+	Import(ctx context.Context,
+		credentials *checker.Credentials,
+		wallet string,
+		keystore []byte,
+		passphrase []byte,
+	) (
+		core.Result,
+		[]byte,
+		error,
+	)
+
+	// Export exports an account as an EIP-2335 keystore encrypted with the supplied passphrase.
+	Export(ctx context.Context,
+		credentials *checker.Credentials,
+		account string,
+		passphrase []byte,
+	) (
+		core.Result,
+		[]byte,
+		error,
+	)
+
 	// Unlock unlocks an account.
 	Unlock(ctx context.Context,
 		credentials *checker.Credentials,
@@ -53,4 +85,39 @@ type Service interface {
 		core.Result,
 		error,
 	)
+
+	// UnlockAccounts unlocks multiple accounts, returning a result for each account.
+	UnlockAccounts(ctx context.Context,
+		credentials *checker.Credentials,
+		accounts []string,
+		passphrase []byte,
+	) (
+		[]core.Result,
+		error,
+	)
+	// LockAccounts locks multiple accounts, returning a result for each account.
+	LockAccounts(ctx context.Context,
+		credentials *checker.Credentials,
+		accounts []string,
+	) (
+		[]core.Result,
+		error,
+	)
+
+	// PauseSigning pauses signing for an account, leaving it otherwise available.
+	PauseSigning(ctx context.Context,
+		credentials *checker.Credentials,
+		account string,
+	) (
+		core.Result,
+		error,
+	)
+	// ResumeSigning resumes signing for an account.
+	ResumeSigning(ctx context.Context,
+		credentials *checker.Credentials,
+		account string,
+	) (
+		core.Result,
+		error,
+	)
 }
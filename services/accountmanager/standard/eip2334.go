@@ -0,0 +1,87 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// eip2334Prefix is the prefix of EIP-2334 paths for Ethereum 2 keys.
+const eip2334Prefix = "m/12381/3600/"
+
+// nextEIP2334Path returns the validator signing key path for the index after the highest index
+// used by an account in the wallet.
+func (s *Service) nextEIP2334Path(ctx context.Context, walletName string) (string, error) {
+	wallet, err := s.fetcher.FetchWallet(ctx, walletName)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain wallet")
+	}
+	if _, isCreator := wallet.(e2wtypes.WalletPathedAccountCreator); !isCreator || wallet.Type() == "distributed" {
+		return "", errors.New("wallet does not support account creation with path")
+	}
+
+	paths, err := s.accountPaths(ctx, wallet)
+	if err != nil {
+		return "", err
+	}
+
+	next := uint64(0)
+	for _, path := range paths {
+		index, exists := eip2334Index(path)
+		if exists && index >= next {
+			next = index + 1
+		}
+	}
+
+	return fmt.Sprintf("%s%d/0/0", eip2334Prefix, next), nil
+}
+
+// accountPaths returns the derivation paths of the accounts in the wallet.
+func (s *Service) accountPaths(ctx context.Context, wallet e2wtypes.Wallet) ([]string, error) {
+	// Accounts derived on demand are not held in the wallet's store, but their paths are in use.
+	accounts, err := s.fetcher.DerivedAccounts(ctx, wallet)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain derived accounts")
+	}
+	for account := range wallet.Accounts(ctx) {
+		accounts = append(accounts, account)
+	}
+
+	paths := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if pathProvider, isPathProvider := account.(e2wtypes.AccountPathProvider); isPathProvider {
+			paths = append(paths, pathProvider.Path())
+		}
+	}
+	return paths, nil
+}
+
+// eip2334Index returns the index of an EIP-2334 path, if it has one.
+func eip2334Index(path string) (uint64, bool) {
+	if !strings.HasPrefix(path, eip2334Prefix) {
+		return 0, false
+	}
+	component := strings.SplitN(strings.TrimPrefix(path, eip2334Prefix), "/", 2)[0]
+	index, err := strconv.ParseUint(component, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
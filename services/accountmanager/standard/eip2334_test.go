@@ -0,0 +1,142 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	"github.com/attestantio/dirk/services/accountmanager"
+	standardaccountmanager "github.com/attestantio/dirk/services/accountmanager/standard"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	mockprocess "github.com/attestantio/dirk/services/process/mock"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// pathRecordingProcess records the path with which accounts are generated.
+type pathRecordingProcess struct {
+	*mockprocess.Service
+	path string
+}
+
+func (p *pathRecordingProcess) OnGenerate(ctx context.Context, credentials *checker.Credentials, account string, passphrase []byte, threshold uint32, numParticipants uint32, path string) ([]byte, []*core.Endpoint, error) {
+	p.path = path
+	return p.Service.OnGenerate(ctx, credentials, account, passphrase, threshold, numParticipants, path)
+}
+
+func TestGenerateEIP2334(t *testing.T) {
+	ctx := context.Background()
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx,
+		memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	ruler, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(mockrules.New()))
+	require.NoError(t, err)
+	checkerSvc, err := staticchecker.New(ctx,
+		staticchecker.WithPermissions(map[string][]*checker.Permissions{
+			"client1": {
+				{
+					Path:       ".*",
+					Operations: []string{"All"},
+				},
+			},
+		}),
+	)
+	require.NoError(t, err)
+	mock, err := mockprocess.New()
+	require.NoError(t, err)
+	process := &pathRecordingProcess{Service: mock}
+	unlocker, err := localunlocker.New(ctx)
+	require.NoError(t, err)
+	accountManager, err := standardaccountmanager.New(ctx,
+		standardaccountmanager.WithLogLevel(zerolog.Disabled),
+		standardaccountmanager.WithUnlocker(unlocker),
+		standardaccountmanager.WithChecker(checkerSvc),
+		standardaccountmanager.WithFetcher(fetcher),
+		standardaccountmanager.WithRuler(ruler),
+		standardaccountmanager.WithProcess(process),
+	)
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name    string
+		account string
+		path    string
+		result  core.Result
+		err     string
+		derived string
+	}{
+		{
+			name:    "Explicit",
+			account: "Wallet 1/Explicit",
+			path:    "m/12381/3600/10/0/0",
+			result:  core.ResultSucceeded,
+			derived: "m/12381/3600/10/0/0",
+		},
+		{
+			// Wallet 1 has accounts at indices 0 to 5.
+			name:    "EIP2334",
+			account: "Wallet 1/Next",
+			path:    accountmanager.PathEIP2334,
+			result:  core.ResultSucceeded,
+			derived: "m/12381/3600/6/0/0",
+		},
+		{
+			name:    "EIP2334Distributed",
+			account: "Wallet 2/Next",
+			path:    accountmanager.PathEIP2334,
+			result:  core.ResultDenied,
+			err:     "wallet does not support account creation with path",
+		},
+		{
+			name:    "EIP2334UnknownWallet",
+			account: "Unknown/Next",
+			path:    accountmanager.PathEIP2334,
+			result:  core.ResultDenied,
+			err:     "failed to obtain wallet",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			process.path = ""
+			result, _, _, err := accountManager.Generate(ctx, credentials, test.account, []byte("secret"), 1, 1, test.path)
+			if test.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.result, result)
+			require.Equal(t, test.derived, process.path)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	context "context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// Export exports an account as an EIP-2335 keystore encrypted with the supplied passphrase.
+// Distributed accounts cannot be exported, as no single instance holds their private key.
+func (s *Service) Export(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	passphrase []byte,
+) (
+	core.Result,
+	[]byte,
+	error,
+) {
+	started := time.Now()
+
+	if credentials == nil {
+		log.Error().Msg("No credentials supplied")
+		return core.ResultFailed, nil, nil
+	}
+
+	log := log.With().
+		Str("request_id", credentials.RequestID).
+		Str("client", credentials.Client).
+		Str("account", accountName).
+		Str("action", "Export").
+		Logger()
+	log.Trace().Msg("Request received")
+
+	if len(passphrase) == 0 {
+		log.Debug().Str("result", "denied").Msg("No passphrase supplied")
+		s.monitor.AccountManagerCompleted(started, "export", core.ResultDenied)
+		return core.ResultDenied, nil, errors.New("no passphrase supplied")
+	}
+
+	wallet, account, checkRes := s.preCheck(ctx, credentials, accountName, nil, ruler.ActionExportAccount)
+	if checkRes != core.ResultSucceeded {
+		s.monitor.AccountManagerCompleted(started, "export", checkRes)
+		return checkRes, nil, nil
+	}
+	if _, isDistributed := account.(e2wtypes.DistributedAccount); isDistributed {
+		log.Debug().Str("result", "denied").Msg("Account is distributed")
+		s.monitor.AccountManagerCompleted(started, "export", core.ResultDenied)
+		return core.ResultDenied, nil, errors.New("distributed accounts cannot be exported")
+	}
+	keyProvider, isProvider := account.(e2wtypes.AccountPrivateKeyProvider)
+	if !isProvider {
+		log.Debug().Str("result", "denied").Msg("Account does not provide its private key")
+		s.monitor.AccountManagerCompleted(started, "export", core.ResultDenied)
+		return core.ResultDenied, nil, errors.New("account does not support export")
+	}
+
+	// Confirm approval via rules.
+	pubKey := account.PublicKey().Marshal()
+	rulesData := []*ruler.RulesData{
+		{
+			WalletName:  wallet.Name(),
+			AccountName: account.Name(),
+			PubKey:      pubKey,
+			Data:        &rules.ExportAccountData{},
+		},
+	}
+	results := s.ruler.RunRules(ctx, credentials, ruler.ActionExportAccount, rulesData)
+	switch results[0] {
+	case rules.DENIED:
+		log.Debug().Str("result", "denied").Msg("Denied by rules")
+		s.monitor.AccountManagerCompleted(started, "export", core.ResultDenied)
+		return core.ResultDenied, nil, nil
+	case rules.FAILED:
+		log.Error().Str("result", "failed").Msg("Rules check failed")
+		s.monitor.AccountManagerCompleted(started, "export", core.ResultFailed)
+		return core.ResultFailed, nil, errors.New("rules check failed")
+	}
+
+	if locker, isLocker := account.(e2wtypes.AccountLocker); isLocker {
+		unlocked, err := locker.IsUnlocked(ctx)
+		if err != nil {
+			s.monitor.AccountManagerCompleted(started, "export", core.ResultFailed)
+			return core.ResultFailed, nil, errors.Wrap(err, "failed to establish if account is unlocked")
+		}
+		if !unlocked {
+			unlocked, err = s.unlocker.UnlockAccount(ctx, wallet, account)
+			if err != nil {
+				s.monitor.AccountManagerCompleted(started, "export", core.ResultFailed)
+				return core.ResultFailed, nil, errors.Wrap(err, "failed to unlock account")
+			}
+			if !unlocked {
+				log.Debug().Str("result", "denied").Msg("Account is locked")
+				s.monitor.AccountManagerCompleted(started, "export", core.ResultDenied)
+				return core.ResultDenied, nil, errors.New("account is locked")
+			}
+		}
+	}
+
+	data, err := s.exportKeystore(ctx, account, keyProvider, passphrase)
+	if err != nil {
+		s.monitor.AccountManagerCompleted(started, "export", core.ResultFailed)
+		return core.ResultFailed, nil, err
+	}
+
+	log.Info().Msg("Exported account")
+	s.monitor.AccountManagerCompleted(started, "export", core.ResultSucceeded)
+	return core.ResultSucceeded, data, nil
+}
+
+// exportKeystore creates an EIP-2335 keystore for the account's private key.
+func (s *Service) exportKeystore(ctx context.Context,
+	account e2wtypes.Account,
+	keyProvider e2wtypes.AccountPrivateKeyProvider,
+	passphrase []byte,
+) (
+	[]byte,
+	error,
+) {
+	key, err := keyProvider.PrivateKey(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain private key")
+	}
+	crypto, err := keystorev4.New().Encrypt(key.Marshal(), string(passphrase))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt private key")
+	}
+	ks := &keystore{
+		Crypto:  crypto,
+		PubKey:  fmt.Sprintf("%x", account.PublicKey().Marshal()),
+		UUID:    uuid.New().String(),
+		Version: 4,
+	}
+	if pathProvider, isProvider := account.(e2wtypes.AccountPathProvider); isProvider {
+		ks.Path = pathProvider.Path()
+	}
+	data, err := json.Marshal(ks)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal keystore")
+	}
+
+	return data, nil
+}
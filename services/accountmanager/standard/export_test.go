@@ -0,0 +1,183 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	standardaccountmanager "github.com/attestantio/dirk/services/accountmanager/standard"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	mockprocess "github.com/attestantio/dirk/services/process/mock"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx,
+		memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	ruler, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(testRules))
+	require.NoError(t, err)
+	// client1 can carry out all operations; client2 cannot export accounts.
+	checkerSvc, err := staticchecker.New(ctx,
+		staticchecker.WithPermissions(map[string][]*checker.Permissions{
+			"client1": {
+				{
+					Path:       ".*",
+					Operations: []string{"All"},
+				},
+			},
+			"client2": {
+				{
+					Path:       ".*",
+					Operations: []string{"~Export account", "All"},
+				},
+			},
+		}),
+	)
+	require.NoError(t, err)
+	process, err := mockprocess.New()
+	require.NoError(t, err)
+	unlocker, err := localunlocker.New(ctx)
+	require.NoError(t, err)
+	accountManager, err := standardaccountmanager.New(ctx,
+		standardaccountmanager.WithLogLevel(zerolog.Disabled),
+		standardaccountmanager.WithUnlocker(unlocker),
+		standardaccountmanager.WithChecker(checkerSvc),
+		standardaccountmanager.WithFetcher(fetcher),
+		standardaccountmanager.WithRuler(ruler),
+		standardaccountmanager.WithProcess(process),
+	)
+	require.NoError(t, err)
+
+	_, account, err := fetcher.FetchAccount(ctx, "Wallet 1/Account 1")
+	require.NoError(t, err)
+	require.NoError(t, account.(e2wtypes.AccountLocker).Unlock(ctx, []byte("Account 1 passphrase")))
+	key, err := account.(e2wtypes.AccountPrivateKeyProvider).PrivateKey(ctx)
+	require.NoError(t, err)
+
+	client1 := &checker.Credentials{Client: "client1"}
+
+	// Export is denied until signing is paused.
+	result, _, err := accountManager.Export(ctx, client1, "Wallet 1/Account 1", []byte("export"))
+	require.NoError(t, err)
+	require.Equal(t, core.ResultDenied, result)
+	result, err = accountManager.PauseSigning(ctx, client1, "Wallet 1/Account 1")
+	require.NoError(t, err)
+	require.Equal(t, core.ResultSucceeded, result)
+	result, err = accountManager.PauseSigning(ctx, client1, "Wallet 2/Account 1")
+	require.NoError(t, err)
+	require.Equal(t, core.ResultSucceeded, result)
+
+	tests := []struct {
+		name        string
+		credentials *checker.Credentials
+		account     string
+		passphrase  string
+		result      core.Result
+		err         string
+	}{
+		{
+			name:       "NoCredentials",
+			account:    "Wallet 1/Account 1",
+			passphrase: "export",
+			result:     core.ResultFailed,
+		},
+		{
+			name:        "NoPassphrase",
+			credentials: client1,
+			account:     "Wallet 1/Account 1",
+			result:      core.ResultDenied,
+			err:         "no passphrase supplied",
+		},
+		{
+			name:        "UnknownAccount",
+			credentials: client1,
+			account:     "Wallet 1/Unknown",
+			passphrase:  "export",
+			result:      core.ResultDenied,
+		},
+		{
+			name:        "NotPermitted",
+			credentials: &checker.Credentials{Client: "client2"},
+			account:     "Wallet 1/Account 1",
+			passphrase:  "export",
+			result:      core.ResultDenied,
+		},
+		{
+			name:        "Distributed",
+			credentials: client1,
+			account:     "Wallet 2/Account 1",
+			passphrase:  "export",
+			result:      core.ResultDenied,
+			err:         "distributed accounts cannot be exported",
+		},
+		{
+			name:        "Good",
+			credentials: client1,
+			account:     "Wallet 1/Account 1",
+			passphrase:  "export",
+			result:      core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, data, err := accountManager.Export(ctx, test.credentials, test.account, []byte(test.passphrase))
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.result, result)
+			if result != core.ResultSucceeded {
+				require.Nil(t, data)
+				return
+			}
+
+			ks := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(data, &ks))
+			require.Equal(t, fmt.Sprintf("%x", account.PublicKey().Marshal()), ks["pubkey"])
+			require.Equal(t, float64(4), ks["version"])
+			exported, err := keystorev4.New().Decrypt(ks["crypto"].(map[string]interface{}), test.passphrase)
+			require.NoError(t, err)
+			require.Equal(t, key.Marshal(), exported)
+		})
+	}
+}
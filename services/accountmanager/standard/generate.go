@@ -19,6 +19,7 @@ import (
 
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/accountmanager"
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/ruler"
 	"github.com/pkg/errors"
@@ -32,6 +33,7 @@ func (s *Service) Generate(ctx context.Context,
 	passphrase []byte,
 	signingThreshold uint32,
 	participants uint32,
+	path string,
 ) (
 	core.Result,
 	[]byte,
@@ -49,6 +51,7 @@ func (s *Service) Generate(ctx context.Context,
 		Str("request_id", credentials.RequestID).
 		Str("client", credentials.Client).
 		Str("account", account).
+		Str("path", path).
 		Str("action", "Generate").
 		Logger()
 	log.Trace().Msg("Request received")
@@ -59,10 +62,12 @@ func (s *Service) Generate(ctx context.Context,
 		return checkRes, nil, nil, nil
 	}
 
-	// Check parameters.
+	// Check parameters.  Requests without participants are for standard accounts.
 	if participants == 0 {
-		s.monitor.AccountManagerCompleted(started, "generate", core.ResultDenied)
-		return core.ResultDenied, nil, nil, errors.New("invalid number of participants")
+		participants = 1
+		if signingThreshold == 0 {
+			signingThreshold = 1
+		}
 	}
 	if participants < signingThreshold {
 		s.monitor.AccountManagerCompleted(started, "generate", core.ResultDenied)
@@ -74,12 +79,42 @@ func (s *Service) Generate(ctx context.Context,
 		s.monitor.AccountManagerCompleted(started, "generate", core.ResultDenied)
 		return core.ResultDenied, nil, nil, errors.Wrap(err, "invalid account name")
 	}
+	if path == accountmanager.PathEIP2334 {
+		s.eip2334Mu.Lock()
+		defer s.eip2334Mu.Unlock()
+		path, err = s.nextEIP2334Path(ctx, walletName)
+		if err != nil {
+			s.monitor.AccountManagerCompleted(started, "generate", core.ResultDenied)
+			return core.ResultDenied, nil, nil, err
+		}
+		log = log.With().Str("derived_path", path).Logger()
+		log.Trace().Msg("Selected EIP-2334 path")
+	}
+	var existingPaths []string
+	if path != "" {
+		wallet, err := s.fetcher.FetchWallet(ctx, walletName)
+		if err != nil {
+			s.monitor.AccountManagerCompleted(started, "generate", core.ResultDenied)
+			return core.ResultDenied, nil, nil, errors.Wrap(err, "failed to obtain wallet")
+		}
+		existingPaths, err = s.accountPaths(ctx, wallet)
+		if err != nil {
+			s.monitor.AccountManagerCompleted(started, "generate", core.ResultFailed)
+			return core.ResultFailed, nil, nil, err
+		}
+	}
 	// Confirm approval via rules.
 	rulesData := []*ruler.RulesData{
 		{
 			WalletName:  walletName,
 			AccountName: accountName,
-			Data:        &rules.CreateAccountData{},
+			Data: &rules.CreateAccountData{
+				WalletName:       walletName,
+				Path:             path,
+				ExistingPaths:    existingPaths,
+				Participants:     participants,
+				SigningThreshold: signingThreshold,
+			},
 		},
 	}
 	results := s.ruler.RunRules(ctx, credentials, ruler.ActionCreateAccount, rulesData)
@@ -93,7 +128,7 @@ func (s *Service) Generate(ctx context.Context,
 	}
 
 	// Generate it.
-	pubKey, endpoints, err := s.process.OnGenerate(ctx, credentials, account, passphrase, signingThreshold, participants)
+	pubKey, endpoints, err := s.process.OnGenerate(ctx, credentials, account, passphrase, signingThreshold, participants, path)
 	if err != nil {
 		s.monitor.AccountManagerCompleted(started, "generate", core.ResultSucceeded)
 		return core.ResultFailed, nil, nil, errors.Wrap(err, "failed to generate account")
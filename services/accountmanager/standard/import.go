@@ -0,0 +1,184 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	context "context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/pkg/errors"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// keystore is the subset of an EIP-2335 keystore required to import or export it.
+type keystore struct {
+	Crypto  map[string]interface{} `json:"crypto"`
+	PubKey  string                 `json:"pubkey,omitempty"`
+	Path    string                 `json:"path"`
+	UUID    string                 `json:"uuid,omitempty"`
+	Version uint                   `json:"version"`
+}
+
+// Import imports an account from an EIP-2335 keystore in to a wallet, returning the public key of the account.
+// The account is named after its public key, is encrypted with the passphrase of the keystore, and is left unlocked.
+func (s *Service) Import(ctx context.Context,
+	credentials *checker.Credentials,
+	walletName string,
+	keystoreData []byte,
+	passphrase []byte,
+) (
+	core.Result,
+	[]byte,
+	error,
+) {
+	started := time.Now()
+
+	if credentials == nil {
+		log.Error().Msg("No credentials supplied")
+		return core.ResultFailed, nil, nil
+	}
+
+	log := log.With().
+		Str("request_id", credentials.RequestID).
+		Str("client", credentials.Client).
+		Str("wallet", walletName).
+		Str("action", "Import").
+		Logger()
+	log.Trace().Msg("Request received")
+
+	ks := &keystore{}
+	if err := json.Unmarshal(keystoreData, ks); err != nil {
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultDenied)
+		return core.ResultDenied, nil, errors.Wrap(err, "invalid keystore")
+	}
+	if ks.Version != 4 {
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultDenied)
+		return core.ResultDenied, nil, fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+	key, err := keystorev4.New().Decrypt(ks.Crypto, string(passphrase))
+	if err != nil {
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultDenied)
+		return core.ResultDenied, nil, errors.Wrap(err, "failed to decrypt keystore")
+	}
+	privKey, err := e2types.BLSPrivateKeyFromBytes(key)
+	if err != nil {
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultDenied)
+		return core.ResultDenied, nil, errors.Wrap(err, "invalid private key")
+	}
+	pubKey := privKey.PublicKey().Marshal()
+	accountName := fmt.Sprintf("%#x", pubKey)
+	account := fmt.Sprintf("%s/%s", walletName, accountName)
+	log = log.With().Str("account", account).Logger()
+
+	checkRes := s.checkAccess(ctx, credentials, account, ruler.ActionCreateAccount)
+	if checkRes != core.ResultSucceeded {
+		s.monitor.AccountManagerCompleted(started, "import", checkRes)
+		return checkRes, nil, nil
+	}
+
+	if _, _, err := s.fetcher.FetchAccountByKey(ctx, pubKey); err == nil {
+		log.Debug().Str("result", "denied").Msg("Account already exists")
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultDenied)
+		return core.ResultDenied, pubKey, accountmanager.ErrAccountExists
+	}
+
+	// Confirm approval via rules.
+	rulesData := []*ruler.RulesData{
+		{
+			WalletName:  walletName,
+			AccountName: accountName,
+			PubKey:      pubKey,
+			Data: &rules.CreateAccountData{
+				WalletName:       walletName,
+				Path:             ks.Path,
+				Participants:     1,
+				SigningThreshold: 1,
+			},
+		},
+	}
+	results := s.ruler.RunRules(ctx, credentials, ruler.ActionCreateAccount, rulesData)
+	switch results[0] {
+	case rules.DENIED:
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultDenied)
+		return core.ResultDenied, nil, nil
+	case rules.FAILED:
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultFailed)
+		return core.ResultFailed, nil, errors.New("rules check failed")
+	}
+
+	if err := s.importKey(ctx, walletName, accountName, key, passphrase); err != nil {
+		s.monitor.AccountManagerCompleted(started, "import", core.ResultFailed)
+		return core.ResultFailed, nil, err
+	}
+
+	log.Info().Msg("Imported account")
+	s.monitor.AccountManagerCompleted(started, "import", core.ResultSucceeded)
+	return core.ResultSucceeded, pubKey, nil
+}
+
+// importKey imports the key in to the wallet and unlocks the resultant account.
+// The wallet is fetched through the fetcher so that its cached copy knows of the new account.
+func (s *Service) importKey(ctx context.Context, walletName string, accountName string, key []byte, passphrase []byte) error {
+	wallet, err := s.fetcher.FetchWallet(ctx, walletName)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain wallet")
+	}
+	importer, isImporter := wallet.(e2wtypes.WalletAccountImporter)
+	if !isImporter {
+		return errors.New("wallet does not support importing accounts")
+	}
+	locker, isLocker := wallet.(e2wtypes.WalletLocker)
+	if !isLocker {
+		return errors.New("wallet does not support unlocking")
+	}
+
+	s.importMu.Lock()
+	defer s.importMu.Unlock()
+	unlocked, err := locker.IsUnlocked(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to establish if wallet is unlocked")
+	}
+	if !unlocked {
+		if err := locker.Unlock(ctx, nil); err != nil {
+			return errors.Wrap(err, "failed to unlock wallet")
+		}
+		defer func() {
+			if err := locker.Lock(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to relock wallet")
+			}
+		}()
+	}
+	if _, err := importer.ImportAccount(ctx, accountName, key, passphrase); err != nil {
+		return errors.Wrap(err, "failed to import account")
+	}
+
+	_, account, err := s.fetcher.FetchAccount(ctx, fmt.Sprintf("%s/%s", walletName, accountName))
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain imported account")
+	}
+	if err := account.(e2wtypes.AccountLocker).Unlock(ctx, passphrase); err != nil {
+		return errors.Wrap(err, "failed to unlock imported account")
+	}
+
+	return nil
+}
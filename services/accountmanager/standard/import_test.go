@@ -0,0 +1,206 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	"github.com/attestantio/dirk/services/accountmanager"
+	standardaccountmanager "github.com/attestantio/dirk/services/accountmanager/standard"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	mockprocess "github.com/attestantio/dirk/services/process/mock"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	nd "github.com/wealdtech/go-eth2-wallet-nd/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func newKeystore(t *testing.T, key *e2types.BLSPrivateKey, passphrase string) []byte {
+	crypto, err := keystorev4.New().Encrypt(key.Marshal(), passphrase)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]interface{}{
+		"crypto":  crypto,
+		"path":    "m/12381/3600/0/0/0",
+		"version": 4,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	_, err = nd.CreateWallet(ctx, "Imports", store, keystorev4.New())
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx,
+		memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	ruler, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(mockrules.New()))
+	require.NoError(t, err)
+	// client1 can create accounts in the Imports and Wallet 1 wallets.
+	checkerSvc, err := staticchecker.New(ctx,
+		staticchecker.WithPermissions(map[string][]*checker.Permissions{
+			"client1": {
+				{
+					Path:       "Imports/.*",
+					Operations: []string{"All"},
+				},
+				{
+					Path:       "Wallet 1/.*",
+					Operations: []string{"All"},
+				},
+			},
+		}),
+	)
+	require.NoError(t, err)
+	process, err := mockprocess.New()
+	require.NoError(t, err)
+	unlocker, err := localunlocker.New(ctx)
+	require.NoError(t, err)
+	accountManager, err := standardaccountmanager.New(ctx,
+		standardaccountmanager.WithLogLevel(zerolog.Disabled),
+		standardaccountmanager.WithUnlocker(unlocker),
+		standardaccountmanager.WithChecker(checkerSvc),
+		standardaccountmanager.WithFetcher(fetcher),
+		standardaccountmanager.WithRuler(ruler),
+		standardaccountmanager.WithProcess(process),
+	)
+	require.NoError(t, err)
+
+	key, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	keystore := newKeystore(t, key, "secret")
+	pubKey := key.PublicKey().Marshal()
+
+	_, existing, err := fetcher.FetchAccount(ctx, "Wallet 1/Account 1")
+	require.NoError(t, err)
+	require.NoError(t, existing.(e2wtypes.AccountLocker).Unlock(ctx, []byte("Account 1 passphrase")))
+	existingKey, err := existing.(e2wtypes.AccountPrivateKeyProvider).PrivateKey(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		credentials *checker.Credentials
+		wallet      string
+		keystore    []byte
+		passphrase  string
+		result      core.Result
+		err         string
+	}{
+		{
+			name:     "NoCredentials",
+			wallet:   "Imports",
+			keystore: keystore,
+			result:   core.ResultFailed,
+		},
+		{
+			name:        "BadKeystore",
+			credentials: &checker.Credentials{Client: "client1"},
+			wallet:      "Imports",
+			keystore:    []byte("bad"),
+			passphrase:  "secret",
+			result:      core.ResultDenied,
+			err:         "invalid keystore: invalid character 'b' looking for beginning of value",
+		},
+		{
+			name:        "BadPassphrase",
+			credentials: &checker.Credentials{Client: "client1"},
+			wallet:      "Imports",
+			keystore:    keystore,
+			passphrase:  "wrong",
+			result:      core.ResultDenied,
+			err:         "failed to decrypt keystore: invalid checksum",
+		},
+		{
+			name:        "NotPermitted",
+			credentials: &checker.Credentials{Client: "client2"},
+			wallet:      "Imports",
+			keystore:    keystore,
+			passphrase:  "secret",
+			result:      core.ResultDenied,
+		},
+		{
+			name:        "Existing",
+			credentials: &checker.Credentials{Client: "client1"},
+			wallet:      "Imports",
+			keystore:    newKeystore(t, existingKey.(*e2types.BLSPrivateKey), "secret"),
+			passphrase:  "secret",
+			result:      core.ResultDenied,
+			err:         accountmanager.ErrAccountExists.Error(),
+		},
+		{
+			name:        "WalletNotImporter",
+			credentials: &checker.Credentials{Client: "client1"},
+			wallet:      "Wallet 1",
+			keystore:    keystore,
+			passphrase:  "secret",
+			result:      core.ResultFailed,
+			err:         "wallet does not support importing accounts",
+		},
+		{
+			name:        "Good",
+			credentials: &checker.Credentials{Client: "client1"},
+			wallet:      "Imports",
+			keystore:    keystore,
+			passphrase:  "secret",
+			result:      core.ResultSucceeded,
+		},
+		{
+			name:        "Duplicate",
+			credentials: &checker.Credentials{Client: "client1"},
+			wallet:      "Imports",
+			keystore:    keystore,
+			passphrase:  "secret",
+			result:      core.ResultDenied,
+			err:         accountmanager.ErrAccountExists.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, _, err := accountManager.Import(ctx, test.credentials, test.wallet, test.keystore, []byte(test.passphrase))
+			require.Equal(t, test.result, result)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	// The imported account should be available and unlocked.
+	_, account, err := fetcher.FetchAccount(ctx, fmt.Sprintf("Imports/%#x", pubKey))
+	require.NoError(t, err)
+	unlocked, err := account.(e2wtypes.AccountLocker).IsUnlocked(ctx)
+	require.NoError(t, err)
+	require.True(t, unlocked)
+}
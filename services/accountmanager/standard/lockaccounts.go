@@ -0,0 +1,75 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	context "context"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// LockAccounts locks multiple accounts, returning a result for each account.
+func (s *Service) LockAccounts(ctx context.Context,
+	credentials *checker.Credentials,
+	accountNames []string,
+) (
+	[]core.Result,
+	error,
+) {
+	started := time.Now()
+
+	if credentials == nil {
+		log.Error().Msg("No credentials supplied")
+		results := make([]core.Result, len(accountNames))
+		for i := range results {
+			results[i] = core.ResultFailed
+		}
+		return results, nil
+	}
+
+	log := log.With().
+		Str("request_id", credentials.RequestID).
+		Str("client", credentials.Client).
+		Int("accounts", len(accountNames)).
+		Str("action", "LockAccounts").
+		Logger()
+	log.Trace().Msg("Request received")
+
+	results := s.runMultiple(ctx, credentials, accountNames, ruler.ActionLockAccount, ruler.ActionLockAccounts, &rules.LockAccountData{},
+		func(ctx context.Context, account e2wtypes.Account) core.Result {
+			locker, isLocker := account.(e2wtypes.AccountLocker)
+			if !isLocker {
+				// We cannot lock this account, it may be through external means
+				// (for example, a hardware key).  We return success to allow the
+				// control flow to proceed.
+				return core.ResultSucceeded
+			}
+			if err := locker.Lock(ctx); err != nil {
+				log.Warn().Err(err).Str("account", account.Name()).Msg("Failed to lock")
+				return core.ResultDenied
+			}
+			return core.ResultSucceeded
+		})
+
+	for i := range results {
+		s.monitor.AccountManagerCompleted(started, "lock", results[i])
+	}
+	log.Trace().Msg("Completed")
+	return results, nil
+}
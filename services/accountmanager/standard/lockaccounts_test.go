@@ -0,0 +1,173 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	standardaccountmanager "github.com/attestantio/dirk/services/accountmanager/standard"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	mockprocess "github.com/attestantio/dirk/services/process/mock"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestMain(m *testing.M) {
+	if err := e2types.InitBLS(); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func setup(t *testing.T) *standardaccountmanager.Service {
+	ctx := context.Background()
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx,
+		memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	ruler, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(mockrules.New()))
+	require.NoError(t, err)
+	// client1 can operate on accounts 1 and 2 of wallet 1 only.
+	checker, err := staticchecker.New(ctx,
+		staticchecker.WithPermissions(map[string][]*checker.Permissions{
+			"client1": {
+				{
+					Path:       "Wallet 1/Account [12]",
+					Operations: []string{"All"},
+				},
+			},
+		}),
+	)
+	require.NoError(t, err)
+	process, err := mockprocess.New()
+	require.NoError(t, err)
+	unlocker, err := localunlocker.New(ctx)
+	require.NoError(t, err)
+
+	accountManager, err := standardaccountmanager.New(ctx,
+		standardaccountmanager.WithLogLevel(zerolog.Disabled),
+		standardaccountmanager.WithUnlocker(unlocker),
+		standardaccountmanager.WithChecker(checker),
+		standardaccountmanager.WithFetcher(fetcher),
+		standardaccountmanager.WithRuler(ruler),
+		standardaccountmanager.WithProcess(process),
+	)
+	require.NoError(t, err)
+	return accountManager
+}
+
+func TestLockAccounts(t *testing.T) {
+	ctx := context.Background()
+	accountManager := setup(t)
+
+	tests := []struct {
+		name        string
+		credentials *checker.Credentials
+		accounts    []string
+		results     []core.Result
+	}{
+		{
+			name:     "NoCredentials",
+			accounts: []string{"Wallet 1/Account 1"},
+			results:  []core.Result{core.ResultFailed},
+		},
+		{
+			name:        "Empty",
+			credentials: &checker.Credentials{Client: "client1"},
+			accounts:    []string{},
+			results:     []core.Result{},
+		},
+		{
+			name:        "Mixed",
+			credentials: &checker.Credentials{Client: "client1"},
+			accounts: []string{
+				"Wallet 1/Account 1",
+				"Wallet 1/Account 3",
+				"Wallet 1/Account 2",
+				"Wallet 1/Unknown",
+			},
+			results: []core.Result{
+				core.ResultSucceeded,
+				core.ResultDenied,
+				core.ResultSucceeded,
+				core.ResultDenied,
+			},
+		},
+		{
+			name:        "Duplicate",
+			credentials: &checker.Credentials{Client: "client1"},
+			accounts: []string{
+				"Wallet 1/Account 1",
+				"Wallet 1/Account 1",
+			},
+			results: []core.Result{
+				core.ResultSucceeded,
+				core.ResultDenied,
+			},
+		},
+		{
+			name:        "DeniedClient",
+			credentials: &checker.Credentials{Client: "client2"},
+			accounts: []string{
+				"Wallet 1/Account 1",
+				"Wallet 1/Account 2",
+			},
+			results: []core.Result{
+				core.ResultDenied,
+				core.ResultDenied,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := accountManager.LockAccounts(ctx, test.credentials, test.accounts)
+			require.NoError(t, err)
+			assert.Equal(t, test.results, results)
+		})
+	}
+}
+
+func TestUnlockAccounts(t *testing.T) {
+	ctx := context.Background()
+	accountManager := setup(t)
+	credentials := &checker.Credentials{Client: "client1"}
+
+	results, err := accountManager.UnlockAccounts(ctx, credentials, []string{
+		"Wallet 1/Account 1",
+		"Wallet 1/Account 3",
+		"Wallet 1/Account 2",
+	}, []byte("Account 1 passphrase"))
+	require.NoError(t, err)
+	// Account 1 is unlocked, account 3 is not permitted and account 2 has a different passphrase.
+	assert.Equal(t, []core.Result{core.ResultSucceeded, core.ResultDenied, core.ResultDenied}, results)
+}
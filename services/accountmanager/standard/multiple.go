@@ -0,0 +1,83 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	context "context"
+	"fmt"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// runMultiple carries out an operation on multiple accounts, returning a result for each account.
+// Access is checked for each account with the single-account action, and the rules are run for all
+// permitted accounts in a single call with the multiple-account action.
+func (s *Service) runMultiple(ctx context.Context,
+	credentials *checker.Credentials,
+	accountNames []string,
+	action string,
+	multipleAction string,
+	data interface{},
+	operation func(context.Context, e2wtypes.Account) core.Result,
+) []core.Result {
+	results := make([]core.Result, len(accountNames))
+	accounts := make([]e2wtypes.Account, len(accountNames))
+	rulesData := make([]*ruler.RulesData, 0, len(accountNames))
+	rulesIndices := make([]int, 0, len(accountNames))
+	seen := make(map[string]bool)
+	for i := range accountNames {
+		wallet, account, checkRes := s.preCheck(ctx, credentials, accountNames[i], nil, action)
+		if checkRes != core.ResultSucceeded {
+			results[i] = checkRes
+			continue
+		}
+		name := fmt.Sprintf("%s/%s", wallet.Name(), account.Name())
+		if seen[name] {
+			log.Debug().Str("account", name).Str("result", "denied").Msg("Duplicate account in request")
+			results[i] = core.ResultDenied
+			continue
+		}
+		seen[name] = true
+		accounts[i] = account
+		rulesData = append(rulesData, &ruler.RulesData{
+			WalletName:  wallet.Name(),
+			AccountName: account.Name(),
+			PubKey:      account.PublicKey().Marshal(),
+			Data:        data,
+		})
+		rulesIndices = append(rulesIndices, i)
+	}
+	if len(rulesData) == 0 {
+		return results
+	}
+
+	// Confirm approval via rules.
+	rulesResults := s.ruler.RunRules(ctx, credentials, multipleAction, rulesData)
+	for j, i := range rulesIndices {
+		switch rulesResults[j] {
+		case rules.APPROVED:
+			results[i] = operation(ctx, accounts[i])
+		case rules.DENIED:
+			results[i] = core.ResultDenied
+		default:
+			results[i] = core.ResultFailed
+		}
+	}
+
+	return results
+}
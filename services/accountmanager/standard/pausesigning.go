@@ -0,0 +1,104 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	context "context"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+)
+
+// PauseSigning pauses signing for an account, leaving it otherwise available.
+func (s *Service) PauseSigning(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+) (
+	core.Result,
+	error,
+) {
+	return s.setSigningPaused(ctx, credentials, accountName, ruler.ActionPauseSigning, &rules.PauseSigningData{}, "pause signing")
+}
+
+// ResumeSigning resumes signing for an account.
+func (s *Service) ResumeSigning(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+) (
+	core.Result,
+	error,
+) {
+	return s.setSigningPaused(ctx, credentials, accountName, ruler.ActionResumeSigning, &rules.ResumeSigningData{}, "resume signing")
+}
+
+// setSigningPaused pauses or resumes signing for an account.
+// The pause state is held by the rules, so approval by the rules is all that is required.
+func (s *Service) setSigningPaused(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	action string,
+	data interface{},
+	operation string,
+) (
+	core.Result,
+	error,
+) {
+	started := time.Now()
+
+	if credentials == nil {
+		log.Error().Msg("No credentials supplied")
+		return core.ResultFailed, nil
+	}
+
+	log := log.With().
+		Str("request_id", credentials.RequestID).
+		Str("client", credentials.Client).
+		Str("account", accountName).
+		Str("action", action).
+		Logger()
+	log.Trace().Msg("Request received")
+
+	wallet, account, checkRes := s.preCheck(ctx, credentials, accountName, nil, action)
+	if checkRes != core.ResultSucceeded {
+		s.monitor.AccountManagerCompleted(started, operation, checkRes)
+		return checkRes, nil
+	}
+
+	rulesData := []*ruler.RulesData{
+		{
+			WalletName:  wallet.Name(),
+			AccountName: account.Name(),
+			PubKey:      account.PublicKey().Marshal(),
+			Data:        data,
+		},
+	}
+	results := s.ruler.RunRules(ctx, credentials, action, rulesData)
+	switch results[0] {
+	case rules.APPROVED:
+		log.Trace().Str("result", "succeeded").Msg("Success")
+		s.monitor.AccountManagerCompleted(started, operation, core.ResultSucceeded)
+		return core.ResultSucceeded, nil
+	case rules.DENIED:
+		log.Debug().Str("result", "denied").Msg("Denied by rules")
+		s.monitor.AccountManagerCompleted(started, operation, core.ResultDenied)
+		return core.ResultDenied, nil
+	default:
+		log.Error().Str("result", "failed").Msg("Rules check failed")
+		s.monitor.AccountManagerCompleted(started, operation, core.ResultFailed)
+		return core.ResultFailed, nil
+	}
+}
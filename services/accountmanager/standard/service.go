@@ -15,6 +15,7 @@ package standard
 
 import (
 	context "context"
+	"sync"
 
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/fetcher"
@@ -35,6 +36,10 @@ type Service struct {
 	ruler    ruler.Service
 	unlocker unlocker.Service
 	process  process.Service
+	// importMu serialises imports, as the target wallet is unlocked for the duration of the import.
+	importMu sync.Mutex
+	// eip2334Mu serialises generation at EIP-2334 paths, so that concurrent requests do not select the same path.
+	eip2334Mu sync.Mutex
 }
 
 // module-wide log.
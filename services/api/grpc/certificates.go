@@ -0,0 +1,131 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+
+	"github.com/attestantio/dirk/services/revocationchecker"
+	"github.com/pkg/errors"
+)
+
+// certificateManager holds the TLS material for the server.  The material can be replaced
+// whilst the server is running; existing connections are unaffected, and new connections
+// use the replacement material.
+type certificateManager struct {
+	mutex      sync.RWMutex
+	serverCert *tls.Certificate
+	clientCAs  *x509.CertPool
+	clientAuth tls.ClientAuthType
+	revocation revocationchecker.Service
+}
+
+// newCertificateManager creates a new certificate manager with the supplied material.
+// If optionalCert is true clients are not required to present a certificate, but any certificate
+// presented must still be verified.  If revocation is supplied client certificates are also checked for revocation.
+func newCertificateManager(certPEMBlock []byte,
+	keyPEMBlock []byte,
+	caPEMBlock []byte,
+	optionalCert bool,
+	revocation revocationchecker.Service,
+) (
+	*certificateManager,
+	error,
+) {
+	serverCert, clientCAs, err := parseCertificates(certPEMBlock, keyPEMBlock, caPEMBlock)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth := tls.RequireAndVerifyClientCert
+	if optionalCert {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	return &certificateManager{
+		serverCert: serverCert,
+		clientCAs:  clientCAs,
+		clientAuth: clientAuth,
+		revocation: revocation,
+	}, nil
+}
+
+// reload replaces the TLS material.  If the supplied material is invalid an error is returned
+// and the existing material is retained.
+func (m *certificateManager) reload(certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) error {
+	serverCert, clientCAs, err := parseCertificates(certPEMBlock, keyPEMBlock, caPEMBlock)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	m.serverCert = serverCert
+	m.clientCAs = clientCAs
+	m.mutex.Unlock()
+
+	return nil
+}
+
+// tlsConfig provides the TLS configuration for the server.  It defers to the certificate manager
+// on each handshake, so always uses the current material.
+func (m *certificateManager) tlsConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		GetConfigForClient: m.getConfigForClient,
+	}
+}
+
+// getConfigForClient provides the TLS configuration for an individual handshake.
+func (m *certificateManager) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	config := &tls.Config{
+		ClientAuth:   m.clientAuth,
+		Certificates: []tls.Certificate{*m.serverCert},
+		ClientCAs:    m.clientCAs,
+		MinVersion:   tls.VersionTLS13,
+		// This configuration replaces that supplied to the GRPC server, so must also negotiate HTTP/2.
+		NextProtos: []string{"h2"},
+	}
+	if m.revocation != nil {
+		config.VerifyPeerCertificate = m.verifyRevocation
+	}
+
+	return config, nil
+}
+
+// verifyRevocation checks that the verified client certificate has not been revoked.
+func (m *certificateManager) verifyRevocation(_ [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return m.revocation.CheckRevocation(context.Background(), verifiedChains)
+}
+
+// parseCertificates parses the server certificate, key and client CA certificate.
+func parseCertificates(certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) (*tls.Certificate, *x509.CertPool, error) {
+	serverCert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load server keypair")
+	}
+
+	certPool := x509.NewCertPool()
+	if len(caPEMBlock) > 0 {
+		// Read in the certificate authority certificate; this is required to validate client certificates on incoming connections.
+		if ok := certPool.AppendCertsFromPEM(caPEMBlock); !ok {
+			return nil, nil, errors.New("could not add CA certificate to pool")
+		}
+	}
+
+	return &serverCert, certPool, nil
+}
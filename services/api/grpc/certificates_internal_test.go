@@ -0,0 +1,149 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/attestantio/dirk/testing/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echo accepts connections on the listener and echoes back anything received.
+func echo(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			_, _ = io.Copy(conn, conn)
+		}(conn)
+	}
+}
+
+// dial connects to the server, returning the connection and the common name of the server's certificate.
+func dial(t *testing.T, address string) (*tls.Conn, string) {
+	clientCert, err := tls.X509KeyPair(resources.ClientTest01Crt, resources.ClientTest01Key)
+	require.NoError(t, err)
+	conn, err := tls.Dial("tcp", address, &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		MinVersion:   tls.VersionTLS13,
+		// Server certificates do not carry the test address.
+		InsecureSkipVerify: true,
+	})
+	require.NoError(t, err)
+	state := conn.ConnectionState()
+	require.NotEmpty(t, state.PeerCertificates)
+	return conn, state.PeerCertificates[0].Subject.CommonName
+}
+
+// roundTrip confirms that the connection is usable.
+func roundTrip(t *testing.T, conn *tls.Conn) {
+	_, err := conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ping"), buf)
+}
+
+func TestNewCertificateManager(t *testing.T) {
+	tests := []struct {
+		name string
+		cert []byte
+		key  []byte
+		ca   []byte
+		err  string
+	}{
+		{
+			name: "CertMissing",
+			key:  resources.SignerTest01Key,
+			ca:   resources.CACrt,
+			err:  "failed to load server keypair: tls: failed to find any PEM data in certificate input",
+		},
+		{
+			name: "KeyMismatch",
+			cert: resources.SignerTest01Crt,
+			key:  resources.SignerTest02Key,
+			ca:   resources.CACrt,
+			err:  "failed to load server keypair: tls: private key does not match public key",
+		},
+		{
+			name: "CABad",
+			cert: resources.SignerTest01Crt,
+			key:  resources.SignerTest01Key,
+			ca:   []byte("bad"),
+			err:  "could not add CA certificate to pool",
+		},
+		{
+			name: "Good",
+			cert: resources.SignerTest01Crt,
+			key:  resources.SignerTest01Key,
+			ca:   resources.CACrt,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newCertificateManager(test.cert, test.key, test.ca, false, nil)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCertificateReload(t *testing.T) {
+	manager, err := newCertificateManager(resources.SignerTest01Crt, resources.SignerTest01Key, resources.CACrt, false, nil)
+	require.NoError(t, err)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", manager.tlsConfig())
+	require.NoError(t, err)
+	defer listener.Close()
+	go echo(listener)
+	address := listener.Addr().String()
+
+	// Initial connection uses the initial certificate.
+	conn1, name := dial(t, address)
+	defer conn1.Close()
+	assert.Equal(t, "signer-test01", name)
+	roundTrip(t, conn1)
+
+	// Swap certificates mid-flight.
+	require.NoError(t, manager.reload(resources.SignerTest02Crt, resources.SignerTest02Key, resources.CACrt))
+
+	// New connection uses the new certificate.
+	conn2, name := dial(t, address)
+	defer conn2.Close()
+	assert.Equal(t, "signer-test02", name)
+	roundTrip(t, conn2)
+
+	// Existing connection is unaffected.
+	roundTrip(t, conn1)
+
+	// Bad material is rejected, and the existing material retained.
+	require.EqualError(t, manager.reload(resources.SignerTest03Crt, resources.SignerTest02Key, resources.CACrt), "failed to load server keypair: tls: private key does not match public key")
+	conn3, name := dial(t, address)
+	defer conn3.Close()
+	assert.Equal(t, "signer-test02", name)
+	roundTrip(t, conn3)
+}
@@ -0,0 +1,112 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"fmt"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"google.golang.org/grpc"
+)
+
+// builtinInterceptors are the names of the interceptors provided by the server.
+var builtinInterceptors = map[string]bool{
+	interceptors.NameDrain:       true,
+	interceptors.NameTracing:     true,
+	interceptors.NameTags:        true,
+	interceptors.NameRequestID:   true,
+	interceptors.NameCredentials: true,
+	interceptors.NameRateLimit:   true,
+	interceptors.NameSequence:    true,
+	interceptors.NameRecovery:    true,
+	interceptors.NameLogging:     true,
+}
+
+// defaultInterceptorOrder is the order of the interceptors if none is supplied.  Interceptors that
+// are not available are skipped, and additional interceptors follow in the order supplied.
+var defaultInterceptorOrder = []string{
+	interceptors.NameDrain,
+	interceptors.NameTracing,
+	interceptors.NameTags,
+	interceptors.NameRequestID,
+	interceptors.NameCredentials,
+	interceptors.NameRateLimit,
+	interceptors.NameSequence,
+}
+
+// requiredInterceptors are the interceptors that must be present in a supplied order.
+var requiredInterceptors = []string{
+	interceptors.NameDrain,
+	interceptors.NameCredentials,
+}
+
+// chainInterceptors returns the unary and stream interceptors in the order in which they run.
+func chainInterceptors(order []string,
+	available []*interceptors.Interceptor,
+	additional []*interceptors.Interceptor,
+) (
+	[]grpc.UnaryServerInterceptor,
+	[]grpc.StreamServerInterceptor,
+	error,
+) {
+	byName := make(map[string]*interceptors.Interceptor, len(available)+len(additional))
+	for _, interceptor := range available {
+		byName[interceptor.Name] = interceptor
+	}
+	for _, interceptor := range additional {
+		byName[interceptor.Name] = interceptor
+	}
+
+	if len(order) == 0 {
+		order = make([]string, 0, len(defaultInterceptorOrder)+len(additional))
+		for _, name := range defaultInterceptorOrder {
+			if _, exists := byName[name]; exists {
+				order = append(order, name)
+			}
+		}
+		for _, interceptor := range additional {
+			order = append(order, interceptor.Name)
+		}
+	}
+
+	seen := make(map[string]bool, len(order))
+	unaryInterceptors := make([]grpc.UnaryServerInterceptor, 0, len(order))
+	streamInterceptors := make([]grpc.StreamServerInterceptor, 0, len(order))
+	for _, name := range order {
+		interceptor, exists := byName[name]
+		if !exists {
+			if builtinInterceptors[name] {
+				return nil, nil, fmt.Errorf("interceptor %q is not available", name)
+			}
+			return nil, nil, fmt.Errorf("unknown interceptor %q", name)
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("duplicate interceptor %q", name)
+		}
+		seen[name] = true
+		if interceptor.Unary != nil {
+			unaryInterceptors = append(unaryInterceptors, interceptor.Unary)
+		}
+		if interceptor.Stream != nil {
+			streamInterceptors = append(streamInterceptors, interceptor.Stream)
+		}
+	}
+	for _, name := range requiredInterceptors {
+		if !seen[name] {
+			return nil, nil, fmt.Errorf("interceptor %q is required", name)
+		}
+	}
+
+	return unaryInterceptors, streamInterceptors, nil
+}
@@ -0,0 +1,109 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// recordingInterceptor returns an interceptor that records its name when called.
+func recordingInterceptor(name string, calls *[]string) *interceptors.Interceptor {
+	return &interceptors.Interceptor{
+		Name: name,
+		Unary: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			*calls = append(*calls, name)
+			return handler(ctx, req)
+		},
+	}
+}
+
+func TestChainInterceptors(t *testing.T) {
+	var calls []string
+	available := []*interceptors.Interceptor{
+		recordingInterceptor(interceptors.NameDrain, &calls),
+		recordingInterceptor(interceptors.NameTags, &calls),
+		recordingInterceptor(interceptors.NameCredentials, &calls),
+		recordingInterceptor(interceptors.NameRecovery, &calls),
+	}
+	additional := []*interceptors.Interceptor{
+		recordingInterceptor("audit", &calls),
+	}
+
+	tests := []struct {
+		name     string
+		order    []string
+		expected []string
+		err      string
+	}{
+		{
+			name:     "Default",
+			expected: []string{"drain", "tags", "credentials", "audit"},
+		},
+		{
+			name:     "Custom",
+			order:    []string{"recovery", "audit", "drain", "credentials"},
+			expected: []string{"recovery", "audit", "drain", "credentials"},
+		},
+		{
+			name:  "Unknown",
+			order: []string{"drain", "credentials", "metrics"},
+			err:   `unknown interceptor "metrics"`,
+		},
+		{
+			name:  "Unavailable",
+			order: []string{"drain", "credentials", "rate-limit"},
+			err:   `interceptor "rate-limit" is not available`,
+		},
+		{
+			name:  "Duplicate",
+			order: []string{"drain", "credentials", "drain"},
+			err:   `duplicate interceptor "drain"`,
+		},
+		{
+			name:  "CredentialsMissing",
+			order: []string{"drain", "tags"},
+			err:   `interceptor "credentials" is required`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls = nil
+			unary, _, err := chainInterceptors(test.order, available, additional)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			_, err = unary[0](context.Background(), nil, &grpc.UnaryServerInfo{}, chainUnary(unary[1:]))
+			require.NoError(t, err)
+			require.Equal(t, test.expected, calls)
+		})
+	}
+}
+
+// chainUnary returns a handler that runs the supplied interceptors in order.
+func chainUnary(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryHandler {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		if len(interceptors) == 0 {
+			return nil, nil
+		}
+		return interceptors[0](ctx, req, &grpc.UnaryServerInfo{}, chainUnary(interceptors[1:]))
+	}
+}
@@ -17,10 +17,15 @@ import (
 	context "context"
 	"errors"
 
+	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/services/api/grpc/handlers"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc/metadata"
 )
 
+// pathMetadataKey is the key in the request metadata in which clients can supply the derivation path of the account.
+const pathMetadataKey = "derivation-path"
+
 // Generate generates a new account.
 func (h *Handler) Generate(ctx context.Context, req *pb.GenerateRequest) (*pb.GenerateResponse, error) {
 	if req == nil {
@@ -31,15 +36,27 @@ func (h *Handler) Generate(ctx context.Context, req *pb.GenerateRequest) (*pb.Ge
 	log.Trace().Str("account", req.GetAccount()).Msg("Generate account received")
 	res := &pb.GenerateResponse{}
 
-	pubKey, participants, err := h.process.OnGenerate(ctx, handlers.GenerateCredentials(ctx), req.Account, req.Passphrase, req.SigningThreshold, req.Participants)
+	result, pubKey, participants, err := h.accountManager.Generate(ctx, handlers.GenerateCredentials(ctx), req.Account, req.Passphrase, req.SigningThreshold, req.Participants, pathFromContext(ctx))
 	if err != nil {
 		log.Error().Err(err).Msg("Generate attempt resulted in error")
 		res.State = pb.ResponseState_FAILED
 		res.Message = err.Error()
 		return res, nil
 	}
+	switch result {
+	case core.ResultSucceeded:
+		res.State = pb.ResponseState_SUCCEEDED
+	case core.ResultDenied:
+		res.State = pb.ResponseState_DENIED
+		return res, nil
+	case core.ResultFailed:
+		res.State = pb.ResponseState_FAILED
+		return res, nil
+	default:
+		res.State = pb.ResponseState_UNKNOWN
+		return res, nil
+	}
 
-	res.State = pb.ResponseState_SUCCEEDED
 	res.PublicKey = pubKey
 	res.Participants = make([]*pb.Endpoint, len(participants))
 	for i, participant := range participants {
@@ -52,3 +69,16 @@ func (h *Handler) Generate(ctx context.Context, req *pb.GenerateRequest) (*pb.Ge
 
 	return res, nil
 }
+
+// pathFromContext obtains the derivation path for a new account from the request metadata, if present.
+func pathFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(pathMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,65 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BackupSlashingProtectionMethod is the full name of the method to back up slashing protection.
+const BackupSlashingProtectionMethod = "/dirk.admin.v1.Admin/BackupSlashingProtection"
+
+func backupSlashingProtectionHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).BackupSlashingProtection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BackupSlashingProtectionMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).BackupSlashingProtection(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BackupSlashingProtection handles the BackupSlashingProtection() grpc call.
+func (h *Handler) BackupSlashingProtection(ctx context.Context, req *empty.Empty) (*wrappers.StringValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to back up slashing protection not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.backup == nil {
+		return nil, status.Error(codes.Unimplemented, "Slashing protection backups are not configured")
+	}
+
+	name, err := h.backup.Backup(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to back up slashing protection")
+		return nil, status.Error(codes.Internal, "Failed to back up slashing protection")
+	}
+
+	return &wrappers.StringValue{Value: name}, nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"testing"
+
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	standardbackup "github.com/attestantio/dirk/services/backup/standard"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestBackupSlashingProtection(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	rulesSvc, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	defer rulesSvc.Close(ctx)
+	destination, err := standardbackup.NewFileDestination(t.TempDir())
+	require.NoError(t, err)
+	backupSvc, err := standardbackup.New(ctx,
+		standardbackup.WithRules(rulesSvc),
+		standardbackup.WithDestination(destination),
+	)
+	require.NoError(t, err)
+
+	handler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+		admin.WithBackup(backupSvc),
+	)
+	require.NoError(t, err)
+	noBackupHandler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+
+	adminCtx := context.WithValue(ctx, &interceptors.ClientName{}, "admin1")
+	clientCtx := context.WithValue(ctx, &interceptors.ClientName{}, "client1")
+
+	_, err = handler.BackupSlashingProtection(clientCtx, &empty.Empty{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = noBackupHandler.BackupSlashingProtection(adminCtx, &empty.Empty{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	res, err := handler.BackupSlashingProtection(adminCtx, &empty.Empty{})
+	require.NoError(t, err)
+	names, err := destination.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{res.GetValue()}, names)
+}
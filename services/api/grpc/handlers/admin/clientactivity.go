@@ -0,0 +1,94 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ClientActivityMethod is the full name of the method to obtain client activity.
+const ClientActivityMethod = "/dirk.admin.v1.Admin/ClientActivity"
+
+func clientActivityHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).ClientActivity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ClientActivityMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).ClientActivity(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ClientActivity is the JSON representation of the activity of a client.
+type ClientActivity struct {
+	Client string `json:"client"`
+	// LastActivity is the time of the last request from the client, or nil if it has not made a request since startup.
+	LastActivity *time.Time `json:"last_activity"`
+	// Idle is the time since the last request from the client, if it has made one.
+	Idle string `json:"idle,omitempty"`
+}
+
+// ClientActivity handles the ClientActivity() grpc call.
+func (h *Handler) ClientActivity(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request for client activity not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.checker == nil {
+		return nil, status.Error(codes.FailedPrecondition, "Client activity is not available")
+	}
+
+	lastActivity := h.checker.LastActivity(ctx)
+	now := time.Now()
+	res := make([]*ClientActivity, 0, len(lastActivity))
+	for client, at := range lastActivity {
+		activity := &ClientActivity{
+			Client: client,
+		}
+		if !at.IsZero() {
+			at := at
+			activity.LastActivity = &at
+			activity.Idle = now.Sub(at).String()
+		}
+		res = append(res, activity)
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].Client < res[j].Client
+	})
+	data, err := json.Marshal(res)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode client activity")
+		return nil, status.Error(codes.Internal, "Failed to encode client activity")
+	}
+
+	return &wrappers.BytesValue{Value: data}, nil
+}
@@ -0,0 +1,101 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientActivity(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	checkerSvc, err := staticchecker.New(ctx, staticchecker.WithPermissions(map[string][]*checker.Permissions{
+		"client1": {{Path: "Wallet1", Operations: []string{"All"}}},
+		"client2": {{Path: "Wallet1", Operations: []string{"All"}}},
+	}))
+	require.NoError(t, err)
+	checkerSvc.Check(ctx, &checker.Credentials{Client: "client2"}, "Wallet1/Account1", "Sign")
+
+	handler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithChecker(checkerSvc),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+	noCheckerHandler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		handler *admin.Handler
+		client  string
+		code    codes.Code
+	}{
+		{
+			name:    "NotAdmin",
+			handler: handler,
+			client:  "client1",
+			code:    codes.PermissionDenied,
+		},
+		{
+			name:    "NoChecker",
+			handler: noCheckerHandler,
+			client:  "admin1",
+			code:    codes.FailedPrecondition,
+		},
+		{
+			name:    "Good",
+			handler: handler,
+			client:  "admin1",
+			code:    codes.OK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), &interceptors.ClientName{}, test.client)
+			res, err := test.handler.ClientActivity(ctx, &empty.Empty{})
+			require.Equal(t, test.code, status.Code(err))
+			if test.code == codes.OK {
+				activity := make([]*admin.ClientActivity, 0)
+				require.NoError(t, json.Unmarshal(res.Value, &activity))
+				require.Len(t, activity, 2)
+				require.Equal(t, "client1", activity[0].Client)
+				require.Nil(t, activity[0].LastActivity)
+				require.Equal(t, "client2", activity[1].Client)
+				require.NotNil(t, activity[1].LastActivity)
+				require.NotEmpty(t, activity[1].Idle)
+			}
+		})
+	}
+}
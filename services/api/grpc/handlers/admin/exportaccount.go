@@ -0,0 +1,92 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportAccountMethod is the full name of the method to export an account as a keystore.
+const ExportAccountMethod = "/dirk.admin.v1.Admin/ExportAccount"
+
+func exportAccountHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).ExportAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExportAccountMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).ExportAccount(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ExportAccountRequest is the JSON representation of a request to export an account.
+type ExportAccountRequest struct {
+	// Account is the account to export, in the form "wallet/account".
+	Account string `json:"account"`
+	// Passphrase is the passphrase with which to encrypt the exported keystore.
+	Passphrase string `json:"passphrase"`
+}
+
+// ExportAccount handles the ExportAccount() grpc call.
+// The response contains the EIP-2335 keystore of the account.
+func (h *Handler) ExportAccount(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to export account not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.accountManager == nil {
+		return nil, status.Error(codes.Unimplemented, "Accounts cannot be exported")
+	}
+
+	var exportReq ExportAccountRequest
+	if err := json.Unmarshal(req.GetValue(), &exportReq); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid export request: %v", err)
+	}
+	if exportReq.Passphrase == "" {
+		return nil, status.Error(codes.InvalidArgument, "No passphrase supplied")
+	}
+
+	result, keystore, err := h.accountManager.Export(ctx, handlers.GenerateCredentials(ctx), exportReq.Account, []byte(exportReq.Passphrase))
+	switch result {
+	case core.ResultSucceeded:
+		return &wrappers.BytesValue{Value: keystore}, nil
+	case core.ResultDenied:
+		if err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "Denied: %v", err)
+		}
+		return nil, status.Error(codes.PermissionDenied, "Denied")
+	default:
+		if err != nil {
+			log.Warn().Str("account", exportReq.Account).Err(err).Msg("Failed to export account")
+		}
+		return nil, status.Error(codes.Internal, "Failed")
+	}
+}
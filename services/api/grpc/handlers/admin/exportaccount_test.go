@@ -0,0 +1,113 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"testing"
+
+	mockaccountmanager "github.com/attestantio/dirk/services/accountmanager/mock"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExportAccount(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	handler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+		admin.WithAccountManager(mockaccountmanager.New()),
+	)
+	require.NoError(t, err)
+	noAccountManagerHandler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		handler *admin.Handler
+		client  string
+		request string
+		code    codes.Code
+	}{
+		{
+			name:    "NoClient",
+			handler: handler,
+			request: `{"account":"wallet/account","passphrase":"secret"}`,
+			code:    codes.PermissionDenied,
+		},
+		{
+			name:    "NotAdmin",
+			handler: handler,
+			client:  "client1",
+			request: `{"account":"wallet/account","passphrase":"secret"}`,
+			code:    codes.PermissionDenied,
+		},
+		{
+			name:    "NoAccountManager",
+			handler: noAccountManagerHandler,
+			client:  "admin1",
+			request: `{"account":"wallet/account","passphrase":"secret"}`,
+			code:    codes.Unimplemented,
+		},
+		{
+			name:    "BadRequest",
+			handler: handler,
+			client:  "admin1",
+			request: `bad`,
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "NoPassphrase",
+			handler: handler,
+			client:  "admin1",
+			request: `{"account":"wallet/account"}`,
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "Good",
+			handler: handler,
+			client:  "admin1",
+			request: `{"account":"wallet/account","passphrase":"secret"}`,
+			code:    codes.OK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.client != "" {
+				ctx = context.WithValue(ctx, &interceptors.ClientName{}, test.client)
+			}
+			res, err := test.handler.ExportAccount(ctx, &wrappers.BytesValue{Value: []byte(test.request)})
+			require.Equal(t, test.code, status.Code(err))
+			if err == nil {
+				require.NotEmpty(t, res.GetValue())
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/backup"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/locker"
+	"github.com/attestantio/dirk/services/process"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+)
+
+// Handler is the admin handler, providing operators with information about, and control of, the running instance.
+type Handler struct {
+	locker         locker.Service
+	accountManager accountmanager.Service
+	checker        checker.Service
+	rules          rules.Service
+	process        process.Service
+	backup         backup.Service
+	genesisRoot    []byte
+	clients        map[string]bool
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new admin handler.
+func New(ctx context.Context, params ...Parameter) (*Handler, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	log = zerologger.With().Str("handler", "admin").Str("impl", "grpc").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	clients := make(map[string]bool, len(parameters.clients))
+	for _, client := range parameters.clients {
+		clients[client] = true
+	}
+
+	h := &Handler{
+		locker:         parameters.locker,
+		accountManager: parameters.accountManager,
+		checker:        parameters.checker,
+		rules:          parameters.rules,
+		process:        parameters.process,
+		backup:         parameters.backup,
+		genesisRoot:    parameters.genesisRoot,
+		clients:        clients,
+	}
+
+	return h, nil
+}
+
+// Register registers the handler with the GRPC server.
+func Register(server *grpc.Server, h *Handler) {
+	server.RegisterService(&serviceDesc, h)
+}
+
+// fromAdmin returns true if the request is from an administrative client.
+func (h *Handler) fromAdmin(ctx context.Context) bool {
+	client, ok := ctx.Value(&interceptors.ClientName{}).(string)
+	if !ok {
+		return false
+	}
+	return h.clients[client]
+}
@@ -0,0 +1,171 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HeldLocksMethod is the full name of the method to obtain held locks.
+const HeldLocksMethod = "/dirk.admin.v1.Admin/HeldLocks"
+
+// adminServer is the interface for the admin GRPC service.
+// There is no protobuf definition for this service; responses carry JSON-encoded data in
+// well-known wrapper types, so no generated code is required.
+type adminServer interface {
+	HeldLocks(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error)
+	ClientActivity(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error)
+	PauseSigning(ctx context.Context, req *wrappers.StringValue) (*empty.Empty, error)
+	ResumeSigning(ctx context.Context, req *wrappers.StringValue) (*empty.Empty, error)
+	ExportSlashingProtection(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error)
+	ImportSlashingProtection(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+	Reshare(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+	RefreshShares(ctx context.Context, req *wrappers.StringValue) (*empty.Empty, error)
+	ExportAccount(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+	RecoverAccount(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+	ImportAccount(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+	Prune(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error)
+	BackupSlashingProtection(ctx context.Context, req *empty.Empty) (*wrappers.StringValue, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.admin.v1.Admin",
+	HandlerType: (*adminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "HeldLocks",
+			Handler:    heldLocksHandler,
+		},
+		{
+			MethodName: "ClientActivity",
+			Handler:    clientActivityHandler,
+		},
+		{
+			MethodName: "PauseSigning",
+			Handler:    pauseSigningHandler,
+		},
+		{
+			MethodName: "ResumeSigning",
+			Handler:    resumeSigningHandler,
+		},
+		{
+			MethodName: "ExportSlashingProtection",
+			Handler:    exportSlashingProtectionHandler,
+		},
+		{
+			MethodName: "ImportSlashingProtection",
+			Handler:    importSlashingProtectionHandler,
+		},
+		{
+			MethodName: "Reshare",
+			Handler:    reshareHandler,
+		},
+		{
+			MethodName: "RefreshShares",
+			Handler:    refreshSharesHandler,
+		},
+		{
+			MethodName: "ExportAccount",
+			Handler:    exportAccountHandler,
+		},
+		{
+			MethodName: "RecoverAccount",
+			Handler:    recoverAccountHandler,
+		},
+		{
+			MethodName: "ImportAccount",
+			Handler:    importAccountHandler,
+		},
+		{
+			MethodName: "Prune",
+			Handler:    pruneHandler,
+		},
+		{
+			MethodName: "BackupSlashingProtection",
+			Handler:    backupSlashingProtectionHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin",
+}
+
+func heldLocksHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).HeldLocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HeldLocksMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).HeldLocks(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HeldLock is the JSON representation of a held lock.
+type HeldLock struct {
+	PubKey   string    `json:"pubkey"`
+	Action   string    `json:"action"`
+	Client   string    `json:"client"`
+	Acquired time.Time `json:"acquired"`
+	Held     string    `json:"held"`
+}
+
+// HeldLocks handles the HeldLocks() grpc call.
+func (h *Handler) HeldLocks(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request for held locks not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+
+	heldLocks, err := h.locker.HeldLocks()
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain held locks")
+		return nil, status.Error(codes.FailedPrecondition, "Lock holder tracking is not enabled")
+	}
+
+	now := time.Now()
+	res := make([]*HeldLock, len(heldLocks))
+	for i := range heldLocks {
+		res[i] = &HeldLock{
+			PubKey:   fmt.Sprintf("%#x", heldLocks[i].PubKey),
+			Action:   heldLocks[i].Action,
+			Client:   heldLocks[i].Client,
+			Acquired: heldLocks[i].Acquired,
+			Held:     now.Sub(heldLocks[i].Acquired).String(),
+		}
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode held locks")
+		return nil, status.Error(codes.Internal, "Failed to encode held locks")
+	}
+
+	return &wrappers.BytesValue{Value: data}, nil
+}
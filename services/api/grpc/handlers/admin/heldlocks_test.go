@@ -0,0 +1,107 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHeldLocks(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+
+	tracking, err := syncmaplocker.New(ctx, syncmaplocker.WithTrackHolders(true))
+	require.NoError(t, err)
+	key := [48]byte{0x01}
+	_, err = tracking.LockWithHolder(ctx, key, "SignBeaconProposal", "client1", nil)
+	require.NoError(t, err)
+	defer tracking.Unlock(key)
+
+	notTracking, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	trackingHandler, err := admin.New(ctx,
+		admin.WithLocker(tracking),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+	notTrackingHandler, err := admin.New(ctx,
+		admin.WithLocker(notTracking),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		handler *admin.Handler
+		client  string
+		code    codes.Code
+		locks   int
+	}{
+		{
+			name:    "NoClient",
+			handler: trackingHandler,
+			code:    codes.PermissionDenied,
+		},
+		{
+			name:    "NotAdmin",
+			handler: trackingHandler,
+			client:  "client1",
+			code:    codes.PermissionDenied,
+		},
+		{
+			name:    "NotTracking",
+			handler: notTrackingHandler,
+			client:  "admin1",
+			code:    codes.FailedPrecondition,
+		},
+		{
+			name:    "Good",
+			handler: trackingHandler,
+			client:  "admin1",
+			code:    codes.OK,
+			locks:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.client != "" {
+				ctx = context.WithValue(ctx, &interceptors.ClientName{}, test.client)
+			}
+			res, err := test.handler.HeldLocks(ctx, &empty.Empty{})
+			require.Equal(t, test.code, status.Code(err))
+			if test.code == codes.OK {
+				heldLocks := make([]*admin.HeldLock, 0)
+				require.NoError(t, json.Unmarshal(res.Value, &heldLocks))
+				require.Len(t, heldLocks, test.locks)
+				require.Equal(t, "SignBeaconProposal", heldLocks[0].Action)
+				require.Equal(t, "client1", heldLocks[0].Client)
+				require.Equal(t, "0x010000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000", heldLocks[0].PubKey)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ImportAccountMethod is the full name of the method to import an account from a keystore.
+const ImportAccountMethod = "/dirk.admin.v1.Admin/ImportAccount"
+
+func importAccountHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).ImportAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImportAccountMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).ImportAccount(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ImportAccountRequest is the JSON representation of a request to import an account.
+type ImportAccountRequest struct {
+	// Wallet is the name of the wallet in to which to import the account.
+	Wallet string `json:"wallet"`
+	// Keystore is the EIP-2335 keystore of the account.
+	Keystore json.RawMessage `json:"keystore"`
+	// Passphrase is the passphrase with which to decrypt the keystore.
+	Passphrase string `json:"passphrase"`
+}
+
+// ImportAccountResult is the JSON representation of the result of importing an account.
+type ImportAccountResult struct {
+	// PubKey is the public key of the imported account.
+	PubKey string `json:"pubkey"`
+}
+
+// ImportAccount handles the ImportAccount() grpc call.
+func (h *Handler) ImportAccount(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to import account not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.accountManager == nil {
+		return nil, status.Error(codes.Unimplemented, "Accounts cannot be imported")
+	}
+
+	var importReq ImportAccountRequest
+	if err := json.Unmarshal(req.GetValue(), &importReq); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid import request: %v", err)
+	}
+	if importReq.Wallet == "" {
+		return nil, status.Error(codes.InvalidArgument, "No wallet supplied")
+	}
+	if len(importReq.Keystore) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "No keystore supplied")
+	}
+
+	result, pubKey, err := h.accountManager.Import(ctx, handlers.GenerateCredentials(ctx), importReq.Wallet, importReq.Keystore, []byte(importReq.Passphrase))
+	switch {
+	case result == core.ResultSucceeded:
+	case errors.Is(err, accountmanager.ErrAccountExists):
+		return nil, status.Errorf(codes.AlreadyExists, "Account %#x already exists", pubKey)
+	case result == core.ResultDenied && err != nil:
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid keystore: %v", err)
+	case result == core.ResultDenied:
+		return nil, status.Error(codes.PermissionDenied, "Denied")
+	default:
+		if err != nil {
+			log.Warn().Str("wallet", importReq.Wallet).Err(err).Msg("Failed to import account")
+		}
+		return nil, status.Error(codes.Internal, "Failed")
+	}
+
+	data, err := json.Marshal(&ImportAccountResult{PubKey: fmt.Sprintf("%#x", pubKey)})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode import result")
+		return nil, status.Error(codes.Internal, "Failed to encode import result")
+	}
+
+	return &wrappers.BytesValue{Value: data}, nil
+}
@@ -0,0 +1,143 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"errors"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/backup"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/locker"
+	"github.com/attestantio/dirk/services/process"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel       zerolog.Level
+	locker         locker.Service
+	accountManager accountmanager.Service
+	checker        checker.Service
+	rules          rules.Service
+	process        process.Service
+	backup         backup.Service
+	genesisRoot    []byte
+	clients        []string
+}
+
+// Parameter is the interface for handler parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the handler.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLocker sets the locker service for the handler.
+func WithLocker(locker locker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.locker = locker
+	})
+}
+
+// WithAccountManager sets the account manager service for the handler.
+// If this is not supplied signing cannot be paused or resumed.
+func WithAccountManager(accountManager accountmanager.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.accountManager = accountManager
+	})
+}
+
+// WithChecker sets the checker service for the handler.
+// If this is not supplied client activity is not available.
+func WithChecker(checker checker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checker = checker
+	})
+}
+
+// WithRules sets the rules service for the handler.
+// If this is not supplied slashing protection cannot be exported or imported.
+func WithRules(rules rules.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rules = rules
+	})
+}
+
+// WithProcess sets the process service for the handler.
+// If this is not supplied distributed accounts cannot be reshared.
+func WithProcess(process process.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.process = process
+	})
+}
+
+// WithBackup sets the backup service for the handler.
+// If this is not supplied slashing protection cannot be backed up.
+func WithBackup(backup backup.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backup = backup
+	})
+}
+
+// WithGenesisValidatorsRoot sets the genesis validators root of the chain, used to export and check
+// slashing protection interchange data.
+// If this is not supplied slashing protection cannot be exported or imported.
+func WithGenesisValidatorsRoot(root []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisRoot = root
+	})
+}
+
+// WithClients sets the clients that are allowed to make administrative requests.
+func WithClients(clients []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clients = clients
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.locker == nil {
+		return nil, errors.New("no locker specified")
+	}
+	if len(parameters.clients) == 0 {
+		return nil, errors.New("no clients specified")
+	}
+	if parameters.genesisRoot != nil && len(parameters.genesisRoot) != 32 {
+		return nil, errors.New("genesis validators root must be 32 bytes")
+	}
+
+	return &parameters, nil
+}
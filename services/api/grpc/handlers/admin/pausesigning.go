@@ -0,0 +1,113 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PauseSigningMethod is the full name of the method to pause signing for an account.
+const PauseSigningMethod = "/dirk.admin.v1.Admin/PauseSigning"
+
+// ResumeSigningMethod is the full name of the method to resume signing for an account.
+const ResumeSigningMethod = "/dirk.admin.v1.Admin/ResumeSigning"
+
+func pauseSigningHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).PauseSigning(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PauseSigningMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).PauseSigning(ctx, req.(*wrappers.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func resumeSigningHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).ResumeSigning(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ResumeSigningMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).ResumeSigning(ctx, req.(*wrappers.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PauseSigning handles the PauseSigning() grpc call.
+func (h *Handler) PauseSigning(ctx context.Context, req *wrappers.StringValue) (*empty.Empty, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to pause signing not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.accountManager == nil {
+		return nil, status.Error(codes.Unimplemented, "Signing cannot be paused")
+	}
+
+	result, err := h.accountManager.PauseSigning(ctx, handlers.GenerateCredentials(ctx), req.GetValue())
+	return signingPausedResponse(result, err)
+}
+
+// ResumeSigning handles the ResumeSigning() grpc call.
+func (h *Handler) ResumeSigning(ctx context.Context, req *wrappers.StringValue) (*empty.Empty, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to resume signing not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.accountManager == nil {
+		return nil, status.Error(codes.Unimplemented, "Signing cannot be resumed")
+	}
+
+	result, err := h.accountManager.ResumeSigning(ctx, handlers.GenerateCredentials(ctx), req.GetValue())
+	return signingPausedResponse(result, err)
+}
+
+// signingPausedResponse returns the response for a request to pause or resume signing.
+func signingPausedResponse(result core.Result, err error) (*empty.Empty, error) {
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to change signing pause state")
+		return nil, status.Error(codes.Internal, "Failed")
+	}
+	switch result {
+	case core.ResultSucceeded:
+		return &empty.Empty{}, nil
+	case core.ResultDenied:
+		return nil, status.Error(codes.PermissionDenied, "Denied")
+	default:
+		return nil, status.Error(codes.Internal, "Failed")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PruneMethod is the full name of the method to prune the slashing protection store.
+const PruneMethod = "/dirk.admin.v1.Admin/Prune"
+
+// pruner is implemented by rules that can prune their slashing protection store.
+type pruner interface {
+	PruneNow(ctx context.Context) (*standardrules.PruneSummary, error)
+}
+
+func pruneHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).Prune(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PruneMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).Prune(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PruneResult is the JSON representation of the result of pruning the slashing protection store.
+type PruneResult struct {
+	// Records is the number of slashing protection records examined.
+	Records int `json:"records"`
+	// Finalized is the number of records whose mark is older than the finalized epoch.
+	Finalized int `json:"finalized"`
+	// Removed is the number of records removed as their mark is older than the prune horizon.
+	Removed int `json:"removed"`
+	// FinalizedEpoch is the finalized epoch against which the store was pruned.
+	FinalizedEpoch uint64 `json:"finalized_epoch"`
+	// HorizonEpoch is the epoch before which marks have been removed.
+	HorizonEpoch uint64 `json:"horizon_epoch"`
+}
+
+// Prune handles the Prune() grpc call.
+func (h *Handler) Prune(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to prune slashing protection not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	pruner, isPruner := h.rules.(pruner)
+	if !isPruner {
+		return nil, status.Error(codes.Unimplemented, "Slashing protection cannot be pruned")
+	}
+
+	summary, err := pruner.PruneNow(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to prune slashing protection")
+		return nil, status.Error(codes.Internal, "Failed to prune slashing protection")
+	}
+	log.Info().
+		Int("records", summary.Records).
+		Int("finalized", summary.Finalized).
+		Int("removed", summary.Removed).
+		Uint64("finalized_epoch", summary.FinalizedEpoch).
+		Uint64("horizon_epoch", summary.HorizonEpoch).
+		Dur("duration", summary.Duration).
+		Msg("Pruned slashing protection store")
+	data, err := json.Marshal(&PruneResult{
+		Records:        summary.Records,
+		Finalized:      summary.Finalized,
+		Removed:        summary.Removed,
+		FinalizedEpoch: summary.FinalizedEpoch,
+		HorizonEpoch:   summary.HorizonEpoch,
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode prune result")
+		return nil, status.Error(codes.Internal, "Failed to encode prune result")
+	}
+
+	return &wrappers.BytesValue{Value: data}, nil
+}
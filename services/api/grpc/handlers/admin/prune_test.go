@@ -0,0 +1,102 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPrune(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	// Genesis is set such that we are half way through epoch 20.
+	rulesSvc, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithLocker(locker),
+		standardrules.WithGenesisTime(time.Now().Add(-(20*32+16)*12*time.Second)),
+		standardrules.WithSlotDuration(12*time.Second),
+		standardrules.WithSlotsPerEpoch(32),
+		standardrules.WithPruneRetainEpochs(5),
+	)
+	require.NoError(t, err)
+	defer rulesSvc.Close(ctx)
+
+	handler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+		admin.WithRules(rulesSvc),
+	)
+	require.NoError(t, err)
+	noRulesHandler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+
+	adminCtx := context.WithValue(ctx, &interceptors.ClientName{}, "admin1")
+	clientCtx := context.WithValue(ctx, &interceptors.ClientName{}, "client1")
+
+	_, err = handler.Prune(clientCtx, &empty.Empty{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = noRulesHandler.Prune(adminCtx, &empty.Empty{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// One key stops attesting at epoch 2, the other continues to epoch 10.
+	domain := make([]byte, 32)
+	domain[0] = 0x01
+	for _, key := range []struct {
+		id    byte
+		epoch uint64
+	}{
+		{id: 0x01, epoch: 2},
+		{id: 0x02, epoch: 10},
+	} {
+		pubKey := make([]byte, 48)
+		pubKey[0] = key.id
+		require.Equal(t, rules.APPROVED, rulesSvc.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: pubKey}, &rules.SignBeaconAttestationData{
+			Domain: domain,
+			Source: &rules.Checkpoint{Epoch: key.epoch - 1},
+			Target: &rules.Checkpoint{Epoch: key.epoch},
+		}))
+	}
+
+	res, err := handler.Prune(adminCtx, &empty.Empty{})
+	require.NoError(t, err)
+	var result admin.PruneResult
+	require.NoError(t, json.Unmarshal(res.GetValue(), &result))
+	require.Equal(t, admin.PruneResult{
+		Records:        2,
+		Finalized:      2,
+		Removed:        1,
+		FinalizedEpoch: 18,
+		HorizonEpoch:   5,
+	}, result)
+}
@@ -0,0 +1,83 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoverAccountMethod is the full name of the method to recover a distributed account as a keystore.
+const RecoverAccountMethod = "/dirk.admin.v1.Admin/RecoverAccount"
+
+func recoverAccountHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).RecoverAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecoverAccountMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).RecoverAccount(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RecoverAccountRequest is the JSON representation of a request to recover a distributed account.
+type RecoverAccountRequest struct {
+	// Account is the distributed account to recover, in the form "wallet/account".
+	Account string `json:"account"`
+	// Passphrase is the passphrase with which to encrypt the recovered keystore.
+	Passphrase string `json:"passphrase"`
+}
+
+// RecoverAccount handles the RecoverAccount() grpc call.
+// The response contains the EIP-2335 keystore of the account's full private key.
+func (h *Handler) RecoverAccount(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to recover account not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.process == nil {
+		return nil, status.Error(codes.Unimplemented, "Accounts cannot be recovered")
+	}
+
+	var recoverReq RecoverAccountRequest
+	if err := json.Unmarshal(req.GetValue(), &recoverReq); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid recover request: %v", err)
+	}
+	if recoverReq.Passphrase == "" {
+		return nil, status.Error(codes.InvalidArgument, "No passphrase supplied")
+	}
+
+	keystore, err := h.process.OnRecover(ctx, handlers.GenerateCredentials(ctx), recoverReq.Account, []byte(recoverReq.Passphrase))
+	if err != nil {
+		log.Warn().Str("account", recoverReq.Account).Err(err).Msg("Failed to recover account")
+		return nil, status.Errorf(codes.Internal, "Failed to recover account: %v", err)
+	}
+
+	return &wrappers.BytesValue{Value: keystore}, nil
+}
@@ -0,0 +1,149 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReshareMethod is the full name of the method to reshare a distributed account.
+const ReshareMethod = "/dirk.admin.v1.Admin/Reshare"
+
+// RefreshSharesMethod is the full name of the method to refresh the shares of a distributed account.
+const RefreshSharesMethod = "/dirk.admin.v1.Admin/RefreshShares"
+
+func reshareHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).Reshare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReshareMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).Reshare(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func refreshSharesHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).RefreshShares(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RefreshSharesMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).RefreshShares(ctx, req.(*wrappers.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ReshareRequest is the JSON representation of a request to reshare a distributed account.
+type ReshareRequest struct {
+	// Account is the account to reshare, in the form "wallet/account".
+	Account string `json:"account"`
+	// Passphrase is the passphrase with which to protect the reshared account.
+	// If not supplied the generation passphrase is used.
+	Passphrase string `json:"passphrase,omitempty"`
+	// SigningThreshold is the signing threshold for the reshared account.
+	SigningThreshold uint32 `json:"signing_threshold"`
+	// Participants are the IDs of the participants for the reshared account.
+	Participants []uint64 `json:"participants"`
+}
+
+// ReshareResult is the JSON representation of the result of resharing a distributed account.
+type ReshareResult struct {
+	// PubKey is the composite public key of the account, which is unchanged by resharing.
+	PubKey string `json:"pubkey"`
+	// Participants are the endpoints of the participants for the reshared account.
+	Participants []string `json:"participants"`
+}
+
+// Reshare handles the Reshare() grpc call.
+func (h *Handler) Reshare(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to reshare not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.process == nil {
+		return nil, status.Error(codes.Unimplemented, "Accounts cannot be reshared")
+	}
+
+	var reshareReq ReshareRequest
+	if err := json.Unmarshal(req.GetValue(), &reshareReq); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid reshare request: %v", err)
+	}
+	var passphrase []byte
+	if reshareReq.Passphrase != "" {
+		passphrase = []byte(reshareReq.Passphrase)
+	}
+
+	pubKey, participants, err := h.process.OnReshare(ctx, reshareReq.Account, passphrase, reshareReq.SigningThreshold, reshareReq.Participants)
+	if err != nil {
+		log.Warn().Str("account", reshareReq.Account).Err(err).Msg("Failed to reshare account")
+		return nil, status.Errorf(codes.Internal, "Failed to reshare account: %v", err)
+	}
+
+	res := &ReshareResult{
+		PubKey:       fmt.Sprintf("%#x", pubKey),
+		Participants: make([]string, len(participants)),
+	}
+	for i := range participants {
+		res.Participants[i] = participants[i].String()
+	}
+	data, err := json.Marshal(res)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode reshare result")
+		return nil, status.Error(codes.Internal, "Failed to encode reshare result")
+	}
+
+	return &wrappers.BytesValue{Value: data}, nil
+}
+
+// RefreshShares handles the RefreshShares() grpc call.
+func (h *Handler) RefreshShares(ctx context.Context, req *wrappers.StringValue) (*empty.Empty, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to refresh shares not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.process == nil {
+		return nil, status.Error(codes.Unimplemented, "Shares cannot be refreshed")
+	}
+
+	if _, err := h.process.OnRefresh(ctx, req.GetValue(), nil); err != nil {
+		log.Warn().Str("account", req.GetValue()).Err(err).Msg("Failed to refresh shares")
+		return nil, status.Errorf(codes.Internal, "Failed to refresh shares: %v", err)
+	}
+
+	return &empty.Empty{}, nil
+}
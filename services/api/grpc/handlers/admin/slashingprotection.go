@@ -0,0 +1,149 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportSlashingProtectionMethod is the full name of the method to export slashing protection.
+const ExportSlashingProtectionMethod = "/dirk.admin.v1.Admin/ExportSlashingProtection"
+
+// ImportSlashingProtectionMethod is the full name of the method to import slashing protection.
+const ImportSlashingProtectionMethod = "/dirk.admin.v1.Admin/ImportSlashingProtection"
+
+func exportSlashingProtectionHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).ExportSlashingProtection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExportSlashingProtectionMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).ExportSlashingProtection(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func importSlashingProtectionHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(adminServer).ImportSlashingProtection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImportSlashingProtectionMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(adminServer).ImportSlashingProtection(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ImportResult is the JSON representation of the result of a slashing protection import.
+type ImportResult struct {
+	// Imported is the number of keys whose data was imported.
+	Imported int `json:"imported"`
+	// Retained are the public keys for which existing data was retained, as it is newer than the imported data.
+	Retained []string `json:"retained"`
+}
+
+// ExportSlashingProtection handles the ExportSlashingProtection() grpc call.
+func (h *Handler) ExportSlashingProtection(ctx context.Context, req *empty.Empty) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to export slashing protection not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.rules == nil || h.genesisRoot == nil {
+		return nil, status.Error(codes.Unimplemented, "Slashing protection cannot be exported")
+	}
+
+	protection, err := h.rules.ExportSlashingProtection(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain slashing protection")
+		return nil, status.Error(codes.Internal, "Failed to obtain slashing protection")
+	}
+	interchange, err := rules.NewInterchange(h.genesisRoot, protection)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to create interchange data")
+		return nil, status.Error(codes.Internal, "Failed to create interchange data")
+	}
+	data, err := json.Marshal(interchange)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode interchange data")
+		return nil, status.Error(codes.Internal, "Failed to encode interchange data")
+	}
+
+	return &wrappers.BytesValue{Value: data}, nil
+}
+
+// ImportSlashingProtection handles the ImportSlashingProtection() grpc call.
+func (h *Handler) ImportSlashingProtection(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	if !h.fromAdmin(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Request to import slashing protection not from an administrative client")
+		return nil, status.Error(codes.PermissionDenied, "Not an administrative client")
+	}
+	if h.rules == nil || h.genesisRoot == nil {
+		return nil, status.Error(codes.Unimplemented, "Slashing protection cannot be imported")
+	}
+
+	var interchange rules.Interchange
+	if err := json.Unmarshal(req.GetValue(), &interchange); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid interchange data: %v", err)
+	}
+	protection, err := interchange.SlashingProtection(h.genesisRoot)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid interchange data: %v", err)
+	}
+
+	retained, err := h.rules.MergeSlashingProtection(ctx, protection)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to import slashing protection")
+		return nil, status.Error(codes.Internal, "Failed to import slashing protection")
+	}
+	res := &ImportResult{
+		Imported: len(protection) - len(retained),
+		Retained: make([]string, len(retained)),
+	}
+	for i := range retained {
+		res.Retained[i] = fmt.Sprintf("%#x", retained[i])
+		log.Warn().Str("pubkey", res.Retained[i]).Msg("Existing slashing protection contains newer data; not importing")
+	}
+	log.Info().Int("keys", len(protection)).Int("retained", len(retained)).Msg("Imported slashing protection")
+	data, err := json.Marshal(res)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode import result")
+		return nil, status.Error(codes.Internal, "Failed to encode import result")
+	}
+
+	return &wrappers.BytesValue{Value: data}, nil
+}
@@ -0,0 +1,132 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSlashingProtection(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	rulesSvc, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(base),
+		standardrules.WithLocker(locker),
+	)
+	require.NoError(t, err)
+
+	genesisRoot := make([]byte, 32)
+	genesisRoot[0] = 0x01
+	handler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+		admin.WithRules(rulesSvc),
+		admin.WithGenesisValidatorsRoot(genesisRoot),
+	)
+	require.NoError(t, err)
+	noRulesHandler, err := admin.New(ctx,
+		admin.WithLocker(locker),
+		admin.WithClients([]string{"admin1"}),
+	)
+	require.NoError(t, err)
+
+	adminCtx := context.WithValue(ctx, &interceptors.ClientName{}, "admin1")
+	clientCtx := context.WithValue(ctx, &interceptors.ClientName{}, "client1")
+
+	_, err = handler.ExportSlashingProtection(clientCtx, &empty.Empty{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = handler.ImportSlashingProtection(clientCtx, &wrappers.BytesValue{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = noRulesHandler.ExportSlashingProtection(adminCtx, &empty.Empty{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+	_, err = noRulesHandler.ImportSlashingProtection(adminCtx, &wrappers.BytesValue{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// Import interchange data from another signer, with multiple entries for a key.
+	pubKey := "0xb845089a1457f811bfc000588fbb4e713669be8ce060ea6be3c6ece09afc3794106c91ca73acda5e5457122d58723bed"
+	data := []byte(`{
+  "metadata": {
+    "interchange_format": "complete",
+    "interchange_format_version": "4",
+    "genesis_validators_root": "0x0100000000000000000000000000000000000000000000000000000000000000"
+  },
+  "data": [
+    {
+      "pubkey": "` + pubKey + `",
+      "signed_blocks": [{"slot": "81952"}, {"slot": "81951"}],
+      "signed_attestations": [{"source_epoch": "2290", "target_epoch": "3007"}, {"source_epoch": "2291", "target_epoch": "3008"}]
+    }
+  ]
+}`)
+	res, err := handler.ImportSlashingProtection(adminCtx, &wrappers.BytesValue{Value: data})
+	require.NoError(t, err)
+	var importResult admin.ImportResult
+	require.NoError(t, json.Unmarshal(res.GetValue(), &importResult))
+	require.Equal(t, 1, importResult.Imported)
+	require.Len(t, importResult.Retained, 0)
+
+	// Export the data and confirm the high-water marks.
+	res, err = handler.ExportSlashingProtection(adminCtx, &empty.Empty{})
+	require.NoError(t, err)
+	var interchange rules.Interchange
+	require.NoError(t, json.Unmarshal(res.GetValue(), &interchange))
+	require.Equal(t, "0x0100000000000000000000000000000000000000000000000000000000000000", interchange.Metadata.GenesisValidatorsRoot)
+	require.Len(t, interchange.Data, 1)
+	require.Equal(t, pubKey, interchange.Data[0].PublicKey)
+	require.Equal(t, []*rules.InterchangeProposal{{Slot: "81952"}}, interchange.Data[0].SignedBlocks)
+	require.Equal(t, []*rules.InterchangeAttestation{{SourceEpoch: "2291", TargetEpoch: "3008"}}, interchange.Data[0].SignedAttestations)
+
+	// Importing older data retains the existing data.
+	interchange.Data[0].SignedBlocks[0].Slot = "100"
+	data, err = json.Marshal(interchange)
+	require.NoError(t, err)
+	res, err = handler.ImportSlashingProtection(adminCtx, &wrappers.BytesValue{Value: data})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(res.GetValue(), &importResult))
+	require.Equal(t, 0, importResult.Imported)
+	require.Equal(t, []string{pubKey}, importResult.Retained)
+
+	// Data for a different chain is refused.
+	interchange.Metadata.GenesisValidatorsRoot = "0x0200000000000000000000000000000000000000000000000000000000000000"
+	data, err = json.Marshal(interchange)
+	require.NoError(t, err)
+	_, err = handler.ImportSlashingProtection(adminCtx, &wrappers.BytesValue{Value: data})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// Invalid data is refused.
+	_, err = handler.ImportSlashingProtection(adminCtx, &wrappers.BytesValue{Value: []byte("bad")})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,100 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consensus
+
+import (
+	context "context"
+	"encoding/json"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/consensus"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// consensusServer is the interface for the consensus GRPC service.
+// There is no protobuf definition for this service; requests carry JSON-encoded high-water
+// marks in well-known wrapper types, so no generated code is required.
+type consensusServer interface {
+	ProposeHighWaterMark(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BoolValue, error)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.consensus.v1.Consensus",
+	HandlerType: (*consensusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ProposeHighWaterMark",
+			Handler:    proposeHighWaterMarkHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "consensus",
+}
+
+func proposeHighWaterMarkHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(consensusServer).ProposeHighWaterMark(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: consensus.ProposeHighWaterMarkMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(consensusServer).ProposeHighWaterMark(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ProposeHighWaterMark handles the ProposeHighWaterMark() grpc call.
+func (h *Handler) ProposeHighWaterMark(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BoolValue, error) {
+	if !h.fromPeer(ctx) {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Proposed high-water mark not from a peer")
+		return nil, status.Error(codes.PermissionDenied, "Not a peer")
+	}
+
+	hwm := &consensus.HighWaterMark{}
+	if err := json.Unmarshal(req.Value, hwm); err != nil {
+		log.Warn().Err(err).Msg("Invalid high-water mark")
+		return nil, status.Error(codes.InvalidArgument, "Invalid high-water mark")
+	}
+
+	acknowledged, err := h.consensus.Acknowledge(ctx, hwm)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to acknowledge high-water mark")
+		return nil, status.Error(codes.Internal, "Failed to acknowledge high-water mark")
+	}
+
+	return &wrappers.BoolValue{Value: acknowledged}, nil
+}
+
+// fromPeer returns true if the request is from a known peer.
+func (h *Handler) fromPeer(ctx context.Context) bool {
+	client, ok := ctx.Value(&interceptors.ClientName{}).(string)
+	if !ok {
+		return false
+	}
+	for _, peer := range h.peers.All() {
+		if peer.Name == client {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the error details returned by Dirk.
+const ErrorDomain = "dirk.attestant.io"
+
+// reasonCodes maps the reasons that requests are not approved to gRPC status codes.
+var reasonCodes = map[rules.Reason]codes.Code{
+	rules.ReasonSlashing:         codes.FailedPrecondition,
+	rules.ReasonLimitExceeded:    codes.ResourceExhausted,
+	rules.ReasonPolicy:           codes.PermissionDenied,
+	rules.ReasonStoreUnavailable: codes.Unavailable,
+	rules.ReasonInvalidRequest:   codes.InvalidArgument,
+}
+
+// ResultError returns a gRPC status error for the result of a request that did not succeed, or nil if it
+// succeeded.  The status code is chosen by the reason if known, otherwise by the result, and the status
+// carries an ErrorInfo detail with the reason and the account so that clients can decide whether to retry.
+func ResultError(result core.Result, reason rules.Reason, account string) error {
+	if result == core.ResultSucceeded {
+		return nil
+	}
+
+	code, exists := reasonCodes[reason]
+	if !exists {
+		switch result {
+		case core.ResultDenied:
+			code = codes.PermissionDenied
+		case core.ResultFailed:
+			code = codes.Internal
+		default:
+			code = codes.Unknown
+		}
+	}
+
+	errorReason := strings.ToUpper(string(reason))
+	if errorReason == "" {
+		errorReason = strings.ToUpper(result.String())
+	}
+	st := status.New(code, fmt.Sprintf("Request %s", strings.ToLower(result.String())))
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: errorReason,
+		Domain: ErrorDomain,
+		Metadata: map[string]string{
+			"account": account,
+			"result":  strings.ToLower(result.String()),
+		},
+	})
+	if err != nil {
+		// Details are supplementary, so return the status without them.
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,114 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers_test
+
+import (
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResultError(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      core.Result
+		reason      rules.Reason
+		code        codes.Code
+		errorReason string
+	}{
+		{
+			name:   "Succeeded",
+			result: core.ResultSucceeded,
+			code:   codes.OK,
+		},
+		{
+			name:        "Slashing",
+			result:      core.ResultDenied,
+			reason:      rules.ReasonSlashing,
+			code:        codes.FailedPrecondition,
+			errorReason: "SLASHING",
+		},
+		{
+			name:        "LimitExceeded",
+			result:      core.ResultDenied,
+			reason:      rules.ReasonLimitExceeded,
+			code:        codes.ResourceExhausted,
+			errorReason: "LIMIT_EXCEEDED",
+		},
+		{
+			name:        "Policy",
+			result:      core.ResultDenied,
+			reason:      rules.ReasonPolicy,
+			code:        codes.PermissionDenied,
+			errorReason: "POLICY",
+		},
+		{
+			name:        "StoreUnavailable",
+			result:      core.ResultFailed,
+			reason:      rules.ReasonStoreUnavailable,
+			code:        codes.Unavailable,
+			errorReason: "STORE_UNAVAILABLE",
+		},
+		{
+			name:        "InvalidRequest",
+			result:      core.ResultFailed,
+			reason:      rules.ReasonInvalidRequest,
+			code:        codes.InvalidArgument,
+			errorReason: "INVALID_REQUEST",
+		},
+		{
+			name:        "DeniedNoReason",
+			result:      core.ResultDenied,
+			code:        codes.PermissionDenied,
+			errorReason: "DENIED",
+		},
+		{
+			name:        "FailedNoReason",
+			result:      core.ResultFailed,
+			code:        codes.Internal,
+			errorReason: "FAILED",
+		},
+		{
+			name:        "UnknownNoReason",
+			result:      core.ResultUnknown,
+			code:        codes.Unknown,
+			errorReason: "UNKNOWN",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := handlers.ResultError(test.result, test.reason, "Wallet 1/Account 1")
+			if test.code == codes.OK {
+				require.NoError(t, err)
+				return
+			}
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, test.code, st.Code())
+			require.Len(t, st.Details(), 1)
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, test.errorReason, info.Reason)
+			require.Equal(t, handlers.ErrorDomain, info.Domain)
+			require.Equal(t, "Wallet 1/Account 1", info.Metadata["account"])
+		})
+	}
+}
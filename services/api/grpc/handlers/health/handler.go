@@ -0,0 +1,222 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/fetcher"
+	"github.com/attestantio/dirk/services/peers"
+	"github.com/attestantio/dirk/services/sender"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Names of the services whose status is reported by the handler.  The overall status of the server is
+// reported for the empty service name, and is serving only if both the fetcher and rules are serving.
+// Peers are reported separately, as an unreachable peer does not stop the server signing.
+const (
+	FetcherService = "fetcher"
+	RulesService   = "rules"
+	PeersService   = "peers"
+)
+
+// Checker is implemented by services that can report whether they are able to serve requests.
+// Services that do not implement it are assumed to be able to serve requests.
+type Checker interface {
+	// Healthy returns an error if the service is unable to serve requests.
+	Healthy(ctx context.Context) error
+}
+
+// Handler is the health handler, implementing the GRPC health checking protocol.
+type Handler struct {
+	server   *health.Server
+	fetcher  fetcher.Service
+	rules    rules.Service
+	peers    peers.Service
+	sender   sender.Service
+	id       uint64
+	interval time.Duration
+	// healthy is the last known health of each service, used to log changes.
+	healthy map[string]bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new health handler, which checks the health of its services until the context is done or
+// the handler is shut down.
+func New(ctx context.Context, params ...Parameter) (*Handler, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	log = zerologger.With().Str("handler", "health").Str("impl", "grpc").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	h := &Handler{
+		server:   health.NewServer(),
+		fetcher:  parameters.fetcher,
+		rules:    parameters.rules,
+		peers:    parameters.peers,
+		sender:   parameters.sender,
+		id:       parameters.id,
+		interval: parameters.interval,
+		healthy:  make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+
+	// Services are not serving until they have been checked.
+	for _, service := range h.services() {
+		h.server.SetServingStatus(service, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	}
+
+	var checkCtx context.Context
+	checkCtx, h.cancel = context.WithCancel(ctx)
+	go h.run(checkCtx)
+
+	return h, nil
+}
+
+// Register registers the handler with the GRPC server.
+func Register(server *grpc.Server, h *Handler) {
+	grpc_health_v1.RegisterHealthServer(server, h.server)
+}
+
+// Shutdown stops checking the health of services and reports all services as not serving, so that
+// clients stop sending requests to the server.
+func (h *Handler) Shutdown() {
+	h.cancel()
+	<-h.done
+	h.server.Shutdown()
+}
+
+// services returns the names of the services reported by the handler.
+func (h *Handler) services() []string {
+	services := []string{"", FetcherService, RulesService}
+	if h.sender != nil {
+		services = append(services, PeersService)
+	}
+	return services
+}
+
+// run checks the health of the services at each interval until the context is done.
+func (h *Handler) run(ctx context.Context) {
+	defer close(h.done)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		h.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check checks the health of the services and updates their status.
+func (h *Handler) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, h.interval)
+	defer cancel()
+
+	fetcherErr := checkService(ctx, h.fetcher)
+	rulesErr := checkService(ctx, h.rules)
+	h.setStatus(FetcherService, fetcherErr)
+	h.setStatus(RulesService, rulesErr)
+	if fetcherErr != nil {
+		h.setStatus("", fetcherErr)
+	} else {
+		h.setStatus("", rulesErr)
+	}
+	if h.sender != nil {
+		h.setStatus(PeersService, h.checkPeers(ctx))
+	}
+}
+
+// checkService checks the health of a service, if it is able to report it.
+func checkService(ctx context.Context, service interface{}) error {
+	checker, isChecker := service.(Checker)
+	if !isChecker {
+		return nil
+	}
+	return checker.Healthy(ctx)
+}
+
+// checkPeers checks that all peers other than this instance are reachable.
+func (h *Handler) checkPeers(ctx context.Context) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	checked := 0
+	unreachable := 0
+	for id, peer := range h.peers.All() {
+		if id == h.id {
+			continue
+		}
+		checked++
+		wg.Add(1)
+		go func(peer *core.Endpoint) {
+			defer wg.Done()
+			if err := h.sender.Ping(ctx, peer); err != nil {
+				log.Debug().Uint64("peer", peer.ID).Err(err).Msg("Peer unreachable")
+				mu.Lock()
+				unreachable++
+				mu.Unlock()
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	if unreachable > 0 {
+		return fmt.Errorf("%d of %d peers unreachable", unreachable, checked)
+	}
+	return nil
+}
+
+// setStatus sets the status of a service given the result of its check.
+func (h *Handler) setStatus(service string, err error) {
+	healthy := err == nil
+	if wasHealthy, exists := h.healthy[service]; !exists || wasHealthy != healthy {
+		name := service
+		if name == "" {
+			name = "server"
+		}
+		if healthy {
+			log.Info().Str("service", name).Msg("Service is serving")
+		} else {
+			log.Warn().Str("service", name).Err(err).Msg("Service is not serving")
+		}
+		h.healthy[service] = healthy
+	}
+
+	if healthy {
+		h.server.SetServingStatus(service, grpc_health_v1.HealthCheckResponse_SERVING)
+	} else {
+		h.server.SetServingStatus(service, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/health"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	staticpeers "github.com/attestantio/dirk/services/peers/static"
+	mocksender "github.com/attestantio/dirk/services/sender/mock"
+	"github.com/attestantio/dirk/testing/mock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestNew(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	fetcher, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{scratch.New()}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []health.Parameter
+		err    string
+	}{
+		{
+			name: "FetcherMissing",
+			params: []health.Parameter{
+				health.WithRules(mockrules.New()),
+			},
+			err: "problem with parameters: no fetcher specified",
+		},
+		{
+			name: "RulesMissing",
+			params: []health.Parameter{
+				health.WithFetcher(fetcher),
+			},
+			err: "problem with parameters: no rules specified",
+		},
+		{
+			name: "PeersMissing",
+			params: []health.Parameter{
+				health.WithFetcher(fetcher),
+				health.WithRules(mockrules.New()),
+				health.WithSender(mocksender.New(1)),
+			},
+			err: "problem with parameters: no peers specified",
+		},
+		{
+			name: "IntervalZero",
+			params: []health.Parameter{
+				health.WithFetcher(fetcher),
+				health.WithRules(mockrules.New()),
+				health.WithInterval(0),
+			},
+			err: "problem with parameters: interval must be positive",
+		},
+		{
+			name: "Good",
+			params: []health.Parameter{
+				health.WithFetcher(fetcher),
+				health.WithRules(mockrules.New()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := health.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				h.Shutdown()
+			}
+		})
+	}
+}
+
+// serve serves the handler, returning a client for it.
+func serve(t *testing.T, h *health.Handler) grpc_health_v1.HealthClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	health.Register(server, h)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithInsecure(),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+// requireStatus requires that the given service reaches the given status.
+func requireStatus(t *testing.T, client grpc_health_v1.HealthClient, service string, status grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		res, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		return err == nil && res.Status == status
+	}, 5*time.Second, 10*time.Millisecond, fmt.Sprintf("service %q did not reach status %v", service, status))
+}
+
+func TestHealth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+
+	emptyFetcher, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{scratch.New()}))
+	require.NoError(t, err)
+
+	store := scratch.New()
+	walletID := uuid.New()
+	require.NoError(t, store.StoreWallet(walletID, "Test wallet", []byte(fmt.Sprintf(`{"uuid":"%s","version":1,"name":"Test wallet","type":"non-deterministic"}`, walletID.String()))))
+	fetcher, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+
+	peers, err := staticpeers.New(ctx, staticpeers.WithPeers(map[uint64]string{
+		1: "server-test01:8881",
+		2: "server-test02:8882",
+		3: "server-test03:8883",
+	}))
+	require.NoError(t, err)
+	reachablePeers, err := staticpeers.New(ctx, staticpeers.WithPeers(map[uint64]string{
+		1: "server-test01:8881",
+		2: "server-test02:8882",
+	}))
+	require.NoError(t, err)
+	// Peer 3 is not known to the mock sender, so is unreachable.
+	mock.Processes[2] = nil
+	defer delete(mock.Processes, 2)
+
+	serving := grpc_health_v1.HealthCheckResponse_SERVING
+	notServing := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+
+	tests := []struct {
+		name     string
+		params   []health.Parameter
+		statuses map[string]grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name: "NoWallets",
+			params: []health.Parameter{
+				health.WithFetcher(emptyFetcher),
+				health.WithRules(mockrules.New()),
+			},
+			statuses: map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+				"":                    notServing,
+				health.FetcherService: notServing,
+				health.RulesService:   serving,
+			},
+		},
+		{
+			name: "Good",
+			params: []health.Parameter{
+				health.WithFetcher(fetcher),
+				health.WithRules(mockrules.New()),
+			},
+			statuses: map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+				"":                    serving,
+				health.FetcherService: serving,
+				health.RulesService:   serving,
+			},
+		},
+		{
+			name: "PeersReachable",
+			params: []health.Parameter{
+				health.WithFetcher(fetcher),
+				health.WithRules(mockrules.New()),
+				health.WithPeers(reachablePeers),
+				health.WithSender(mocksender.New(1)),
+				health.WithID(1),
+			},
+			statuses: map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+				"":                    serving,
+				health.FetcherService: serving,
+				health.RulesService:   serving,
+				health.PeersService:   serving,
+			},
+		},
+		{
+			name: "PeerUnreachable",
+			params: []health.Parameter{
+				health.WithFetcher(fetcher),
+				health.WithRules(mockrules.New()),
+				health.WithPeers(peers),
+				health.WithSender(mocksender.New(1)),
+				health.WithID(1),
+			},
+			statuses: map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+				"":                    serving,
+				health.FetcherService: serving,
+				health.RulesService:   serving,
+				health.PeersService:   notServing,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			h, err := health.New(ctx, test.params...)
+			require.NoError(t, err)
+			client := serve(t, h)
+			for service, status := range test.statuses {
+				requireStatus(t, client, service, status)
+			}
+
+			// All services are not serving after shutdown.
+			h.Shutdown()
+			for service := range test.statuses {
+				requireStatus(t, client, service, notServing)
+			}
+		})
+	}
+}
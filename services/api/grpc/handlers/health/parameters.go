@@ -0,0 +1,124 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"errors"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/fetcher"
+	"github.com/attestantio/dirk/services/peers"
+	"github.com/attestantio/dirk/services/sender"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel zerolog.Level
+	fetcher  fetcher.Service
+	rules    rules.Service
+	peers    peers.Service
+	sender   sender.Service
+	id       uint64
+	interval time.Duration
+}
+
+// Parameter is the interface for handler parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the handler.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithFetcher sets the fetcher service for the handler.
+func WithFetcher(fetcher fetcher.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.fetcher = fetcher
+	})
+}
+
+// WithRules sets the rules service for the handler.
+func WithRules(rules rules.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rules = rules
+	})
+}
+
+// WithPeers sets the peers service for the handler.
+func WithPeers(peers peers.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.peers = peers
+	})
+}
+
+// WithSender sets the sender service for the handler.
+// If this is not supplied the reachability of peers is not checked.
+func WithSender(sender sender.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.sender = sender
+	})
+}
+
+// WithID sets the ID of this instance, so that it is not checked as a peer.
+func WithID(id uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.id = id
+	})
+}
+
+// WithInterval sets the interval between health checks.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		interval: 10 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.fetcher == nil {
+		return nil, errors.New("no fetcher specified")
+	}
+	if parameters.rules == nil {
+		return nil, errors.New("no rules specified")
+	}
+	if parameters.sender != nil && parameters.peers == nil {
+		return nil, errors.New("no peers specified")
+	}
+	if parameters.interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+
+	return &parameters, nil
+}
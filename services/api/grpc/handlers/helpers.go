@@ -21,7 +21,12 @@ import (
 )
 
 // GenerateCredentials generates checker credentials from the GRPC request information.
+// Credentials added by the credentials interceptor are used if present.
 func GenerateCredentials(ctx context.Context) *checker.Credentials {
+	if credentials, ok := interceptors.CredentialsFromContext(ctx); ok {
+		return credentials
+	}
+
 	res := &checker.Credentials{}
 
 	if requestID, ok := ctx.Value(&interceptors.RequestID{}).(string); ok {
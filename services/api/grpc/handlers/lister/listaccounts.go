@@ -24,8 +24,22 @@ import (
 	"github.com/attestantio/dirk/services/api/grpc/handlers"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+// pageTokenMetadataKey is the key in the request metadata in which clients can supply the token of the page of
+// accounts to list, as returned by a previous request.
+const pageTokenMetadataKey = "page-token"
+
+// pageSizeMetadataKey is the key in the request metadata in which clients can supply the maximum number of accounts
+// to return.
+const pageSizeMetadataKey = "page-size"
+
+// nextPageTokenMetadataKey is the key in the response header in which the token for the next page of accounts is
+// returned, if there are further accounts to list.
+const nextPageTokenMetadataKey = "next-page-token"
+
 // ListAccounts lists accounts.
 func (h *Handler) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
 	if req == nil {
@@ -38,7 +52,14 @@ func (h *Handler) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest)
 	res.Accounts = make([]*pb.Account, 0)
 	res.DistributedAccounts = make([]*pb.DistributedAccount, 0)
 
-	result, accounts := h.lister.ListAccounts(ctx, handlers.GenerateCredentials(ctx), req.Paths)
+	pageToken, pageSize, err := pageFromContext(ctx)
+	if err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Invalid page size")
+		res.State = pb.ResponseState_DENIED
+		return res, nil
+	}
+
+	result, accounts, nextPageToken := h.lister.ListAccountsPage(ctx, handlers.GenerateCredentials(ctx), req.Paths, pageToken, pageSize)
 	switch result {
 	case core.ResultDenied:
 		res.State = pb.ResponseState_DENIED
@@ -103,7 +124,34 @@ func (h *Handler) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest)
 		}
 	}
 
+	if nextPageToken != "" {
+		if err := grpc.SetHeader(ctx, metadata.Pairs(nextPageTokenMetadataKey, nextPageToken)); err != nil {
+			log.Warn().Err(err).Msg("Failed to set next page token")
+		}
+	}
+
 	res.State = pb.ResponseState_SUCCEEDED
 	log.Trace().Int("accounts", len(res.Accounts)).Int("distributedAccounts", len(res.DistributedAccounts)).Msg("Success")
 	return res, nil
 }
+
+// pageFromContext obtains the page token and page size for the listing from the request metadata, if present.
+func pageFromContext(ctx context.Context) (string, uint32, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", 0, nil
+	}
+	pageToken := ""
+	if values := md.Get(pageTokenMetadataKey); len(values) > 0 {
+		pageToken = values[0]
+	}
+	values := md.Get(pageSizeMetadataKey)
+	if len(values) == 0 {
+		return pageToken, 0, nil
+	}
+	pageSize, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil {
+		return "", 0, err
+	}
+	return pageToken, uint32(pageSize), nil
+}
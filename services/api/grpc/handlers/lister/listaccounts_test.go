@@ -21,7 +21,9 @@ import (
 	mockrules "github.com/attestantio/dirk/rules/mock"
 	"github.com/attestantio/dirk/services/api/grpc/handlers/lister"
 	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
 	mockchecker "github.com/attestantio/dirk/services/checker/mock"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
 	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
 	standardlister "github.com/attestantio/dirk/services/lister/standard"
 	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
@@ -33,6 +35,8 @@ import (
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestMain(m *testing.M) {
@@ -162,7 +166,114 @@ func TestListAccounts(t *testing.T) {
 	}
 }
 
+// headerStream is a server transport stream that captures the headers set by the handler.
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/v1.Lister/ListAccounts" }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
+
+// listPage lists a single page of accounts, returning the account names and the token for the next page.
+func listPage(t *testing.T, handler *lister.Handler, client string, paths []string, pageToken string, pageSize string) ([]string, string) {
+	ctx := context.WithValue(context.Background(), &interceptors.ClientName{}, client)
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("page-token", pageToken, "page-size", pageSize))
+	stream := &headerStream{}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+
+	resp, err := handler.ListAccounts(ctx, &pb.ListAccountsRequest{Paths: paths})
+	require.NoError(t, err)
+	require.Equal(t, pb.ResponseState_SUCCEEDED, resp.State)
+	names := make([]string, 0, len(resp.Accounts))
+	for _, account := range resp.Accounts {
+		names = append(names, account.Name)
+	}
+	nextPageToken := ""
+	if values := stream.header.Get("next-page-token"); len(values) > 0 {
+		nextPageToken = values[0]
+	}
+	return names, nextPageToken
+}
+
+func TestListAccountsPaginated(t *testing.T) {
+	handler, err := Setup()
+	require.Nil(t, err)
+
+	names, pageToken := listPage(t, handler, "client1", []string{"Wallet 1"}, "", "2")
+	require.Equal(t, []string{"Wallet 1/A different account", "Wallet 1/Account 1"}, names)
+	require.NotEmpty(t, pageToken)
+
+	names, pageToken = listPage(t, handler, "client1", []string{"Wallet 1"}, pageToken, "2")
+	require.Equal(t, []string{"Wallet 1/Account 2", "Wallet 1/Account 3"}, names)
+	require.NotEmpty(t, pageToken)
+
+	names, pageToken = listPage(t, handler, "client1", []string{"Wallet 1"}, pageToken, "2")
+	require.Equal(t, []string{"Wallet 1/Account 4"}, names)
+	require.Empty(t, pageToken)
+
+	// A page that exactly exhausts the accounts does not return a token.
+	names, pageToken = listPage(t, handler, "client1", []string{"Wallet 1"}, "", "5")
+	require.Len(t, names, 5)
+	require.Empty(t, pageToken)
+}
+
+func TestListAccountsPaginatedRestricted(t *testing.T) {
+	checker, err := staticchecker.New(context.Background(),
+		staticchecker.WithPermissions(map[string][]*checker.Permissions{
+			"client2": {
+				{
+					Path:       "Wallet 1/Account [24]",
+					Operations: []string{"All"},
+				},
+			},
+		}),
+	)
+	require.NoError(t, err)
+	handler, err := setupWithChecker(checker)
+	require.Nil(t, err)
+
+	names, pageToken := listPage(t, handler, "client2", []string{"Wallet 1"}, "", "1")
+	require.Equal(t, []string{"Wallet 1/Account 2"}, names)
+	require.NotEmpty(t, pageToken)
+
+	names, pageToken = listPage(t, handler, "client2", []string{"Wallet 1"}, pageToken, "1")
+	require.Equal(t, []string{"Wallet 1/Account 4"}, names)
+	require.Empty(t, pageToken)
+}
+
+func TestListAccountsBadPage(t *testing.T) {
+	handler, err := Setup()
+	require.Nil(t, err)
+
+	ctx := context.WithValue(context.Background(), &interceptors.ClientName{}, "client1")
+	for _, md := range []metadata.MD{
+		metadata.Pairs("page-size", "bad"),
+		metadata.Pairs("page-token", "!!!"),
+	} {
+		resp, err := handler.ListAccounts(metadata.NewIncomingContext(ctx, md), &pb.ListAccountsRequest{Paths: []string{"Wallet 1"}})
+		require.NoError(t, err)
+		require.Equal(t, pb.ResponseState_DENIED, resp.State)
+	}
+}
+
 func Setup() (*lister.Handler, error) {
+	checker, err := mockchecker.New()
+	if err != nil {
+		return nil, err
+	}
+
+	return setupWithChecker(checker)
+}
+
+func setupWithChecker(checker checker.Service) (*lister.Handler, error) {
 	ctx := context.Background()
 	store, err := accounts.Setup(ctx)
 	if err != nil {
@@ -187,11 +298,6 @@ func Setup() (*lister.Handler, error) {
 		return nil, err
 	}
 
-	checker, err := mockchecker.New()
-	if err != nil {
-		return nil, err
-	}
-
 	service, err := standardlister.New(ctx,
 		standardlister.WithChecker(checker),
 		standardlister.WithFetcher(fetcher),
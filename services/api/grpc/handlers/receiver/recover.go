@@ -0,0 +1,104 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package receiver
+
+import (
+	context "context"
+	"encoding/json"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/process"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoverServer is the interface for the recover GRPC service.
+// As with the reshare service there is no protobuf definition; requests carry JSON-encoded recover share requests
+// in well-known wrapper types.
+type recoverServer interface {
+	RecoverShare(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+}
+
+var recoverServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.recover.v1.Recover",
+	HandlerType: (*recoverServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RecoverShare",
+			Handler:    recoverShareHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "recover",
+}
+
+// RegisterRecover registers the handler's recover service with the GRPC server.
+func RegisterRecover(server *grpc.Server, h *Handler) {
+	server.RegisterService(&recoverServiceDesc, h)
+}
+
+func recoverShareHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(recoverServer).RecoverShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: process.RecoverShareMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(recoverServer).RecoverShare(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RecoverShare handles the RecoverShare() grpc call.
+// The request comes from a peer, which is trusted to pass on the client and request ID of the original request
+// so that the client's permissions and the rules can be checked here as well as by the peer.
+func (h *Handler) RecoverShare(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	senderID := h.senderID(ctx)
+	if senderID == 0 {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Failed to obtain participant ID of sender")
+		return nil, status.Error(codes.PermissionDenied, "Unknown sender")
+	}
+	log.Trace().Uint64("sender_id", senderID).Msg("Releasing share as per request from sender")
+
+	recoverReq := &process.RecoverShareRequest{}
+	if err := json.Unmarshal(req.GetValue(), recoverReq); err != nil {
+		log.Warn().Err(err).Msg("Invalid recover share request")
+		return nil, status.Error(codes.InvalidArgument, "Invalid recover share request")
+	}
+	if recoverReq.Client == "" {
+		log.Warn().Msg("Recover share request without client")
+		return nil, status.Error(codes.InvalidArgument, "No client")
+	}
+
+	credentials := &checker.Credentials{
+		Client:    recoverReq.Client,
+		RequestID: recoverReq.RequestID,
+	}
+	share, err := h.process.OnRecoverShare(ctx, senderID, credentials, recoverReq.Account)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to release share")
+		return nil, status.Error(codes.PermissionDenied, "Failed to release share")
+	}
+
+	return &wrappers.BytesValue{Value: share.Serialize()}, nil
+}
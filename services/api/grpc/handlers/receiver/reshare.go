@@ -0,0 +1,103 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package receiver
+
+import (
+	context "context"
+	"encoding/json"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/process"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/herumi/bls-eth-go-binary/bls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// reshareServer is the interface for the reshare GRPC service.
+// There is no protobuf definition for this service; requests carry JSON-encoded reshare requests
+// in well-known wrapper types, so no generated code is required.
+type reshareServer interface {
+	PrepareReshare(ctx context.Context, req *wrappers.BytesValue) (*empty.Empty, error)
+}
+
+var reshareServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.reshare.v1.Reshare",
+	HandlerType: (*reshareServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PrepareReshare",
+			Handler:    prepareReshareHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "reshare",
+}
+
+// RegisterReshare registers the handler's reshare service with the GRPC server.
+func RegisterReshare(server *grpc.Server, h *Handler) {
+	server.RegisterService(&reshareServiceDesc, h)
+}
+
+func prepareReshareHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(reshareServer).PrepareReshare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: process.PrepareReshareMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(reshareServer).PrepareReshare(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PrepareReshare handles the PrepareReshare() grpc call.
+func (h *Handler) PrepareReshare(ctx context.Context, req *wrappers.BytesValue) (*empty.Empty, error) {
+	senderID := h.senderID(ctx)
+	if senderID == 0 {
+		log.Warn().Interface("client", ctx.Value(&interceptors.ClientName{})).Msg("Failed to obtain participant ID of sender")
+		return nil, status.Error(codes.PermissionDenied, "Unknown sender")
+	}
+	log.Trace().Uint64("sender_id", senderID).Msg("Preparing to reshare as per request from sender")
+
+	reshareReq := &process.PrepareReshareRequest{}
+	if err := json.Unmarshal(req.GetValue(), reshareReq); err != nil {
+		log.Warn().Err(err).Msg("Invalid reshare request")
+		return nil, status.Error(codes.InvalidArgument, "Invalid reshare request")
+	}
+	verificationVector := make([]bls.PublicKey, len(reshareReq.VerificationVector))
+	for i, key := range reshareReq.VerificationVector {
+		if err := verificationVector[i].Deserialize(key); err != nil {
+			log.Warn().Err(err).Msg("Received verification vector is invalid")
+			return nil, status.Error(codes.InvalidArgument, "Invalid verification vector")
+		}
+	}
+
+	err := h.process.OnPrepareReshare(ctx, senderID, reshareReq.Account, reshareReq.Passphrase, reshareReq.Threshold, reshareReq.Participants, reshareReq.Dealers, verificationVector)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to prepare for distributed key resharing")
+		return nil, status.Error(codes.Internal, "Failed to prepare")
+	}
+
+	log.Trace().Msg("Completed reshare preparation successfully")
+	return &empty.Empty{}, nil
+}
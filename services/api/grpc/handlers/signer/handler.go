@@ -16,15 +16,21 @@ package signer
 import (
 	context "context"
 
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
 	"github.com/attestantio/dirk/services/signer"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
 )
 
 // Handler is the signer handler, allowing access to signer functions through grpc.
 type Handler struct {
-	signer signer.Service
+	signer   signer.Service
+	maxBatch int
+	errors   bool
 }
 
 // module-wide log.
@@ -43,8 +49,35 @@ func New(ctx context.Context, params ...Parameter) (*Handler, error) {
 	}
 
 	h := &Handler{
-		signer: parameters.signer,
+		signer:   parameters.signer,
+		maxBatch: parameters.maxBatch,
+		errors:   parameters.errors,
 	}
 
 	return h, nil
 }
+
+// withReasons returns a context that records the reason a request is not approved, if structured errors are returned.
+func (h *Handler) withReasons(ctx context.Context) context.Context {
+	if !h.errors {
+		return ctx
+	}
+	return rules.WithReasonRecorder(ctx)
+}
+
+// invalidRequest returns the response for a request that is refused before it is passed to the signer.
+func (h *Handler) invalidRequest(res *pb.SignResponse, account string) (*pb.SignResponse, error) {
+	if h.errors {
+		return nil, handlers.ResultError(core.ResultDenied, rules.ReasonInvalidRequest, account)
+	}
+	res.State = pb.ResponseState_DENIED
+	return res, nil
+}
+
+// resultError returns the structured error for a request that did not succeed, if structured errors are returned.
+func (h *Handler) resultError(ctx context.Context, result core.Result, account string) error {
+	if !h.errors {
+		return nil
+	}
+	return handlers.ResultError(result, rules.RecordedReason(ctx), account)
+}
@@ -0,0 +1,236 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/util"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultiSignMethod is the full name of the method to sign multiple generic requests.
+const MultiSignMethod = "/dirk.signer.v1.Multisigner/MultiSign"
+
+// multisignerServer is the interface for the multisigner GRPC service.
+// As with the signer stream there is no protobuf definition for this service.
+type multisignerServer interface {
+	MultiSign(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+}
+
+var multisignServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.signer.v1.Multisigner",
+	HandlerType: (*multisignerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MultiSign",
+			Handler:    multiSignHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "signer",
+}
+
+// RegisterMultisign registers the multisigner service with the GRPC server.
+func RegisterMultisign(server *grpc.Server, h *Handler) {
+	server.RegisterService(&multisignServiceDesc, h)
+}
+
+func multiSignHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrappers.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(multisignerServer).MultiSign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MultiSignMethod,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(multisignerServer).MultiSign(ctx, req.(*wrappers.BytesValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MultiSignRequest is the JSON representation of a request to sign multiple generic requests.
+type MultiSignRequest struct {
+	Requests []*MultiSignEntry `json:"requests"`
+}
+
+// MultiSignEntry is the JSON representation of a single generic signing request.
+// The account is identified by either its name or its public key.
+type MultiSignEntry struct {
+	ID      string `json:"id"`
+	Account string `json:"account,omitempty"`
+	PubKey  string `json:"pubkey,omitempty"`
+	Domain  string `json:"domain"`
+	Data    string `json:"data"`
+}
+
+// MultiSignResponse is the JSON representation of the responses to a multisign request.
+// Responses are in the same order as the requests.
+type MultiSignResponse struct {
+	Responses []*StreamSignResponse `json:"responses"`
+}
+
+// multiSignRequest is a decoded multisign entry.
+type multiSignRequest struct {
+	index   int
+	account string
+	pubKey  []byte
+	data    *rules.SignData
+}
+
+// key returns a key identifying the account of the request.
+func (r *multiSignRequest) key() string {
+	if len(r.pubKey) > 0 {
+		return fmt.Sprintf("%#x", r.pubKey)
+	}
+	return r.account
+}
+
+// MultiSign handles the MultiSign() grpc call.
+// Requests for different accounts are independent, so their rules and signatures are evaluated concurrently.  Requests
+// for the same account are evaluated in the order in which they were supplied.
+func (h *Handler) MultiSign(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	log.Trace().Msg("Handling request")
+
+	multiReq := &MultiSignRequest{}
+	if err := json.Unmarshal(req.GetValue(), multiReq); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid multisign request: %v", err)
+	}
+	if len(multiReq.Requests) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "No requests supplied")
+	}
+	if h.maxBatch > 0 && len(multiReq.Requests) > h.maxBatch {
+		log.Warn().Int("requests", len(multiReq.Requests)).Int("max_batch_size", h.maxBatch).Str("result", "failed").Msg("Request exceeds maximum batch size")
+		return nil, status.Errorf(codes.ResourceExhausted, "Request contains %d entries, exceeding the maximum of %d", len(multiReq.Requests), h.maxBatch)
+	}
+
+	res := &MultiSignResponse{
+		Responses: make([]*StreamSignResponse, len(multiReq.Requests)),
+	}
+	// Group the valid requests by account.
+	groups := make([][]*multiSignRequest, 0)
+	groupIndices := make(map[string]int)
+	for i, entry := range multiReq.Requests {
+		res.Responses[i] = &StreamSignResponse{State: pb.ResponseState_DENIED.String()}
+		if entry == nil {
+			continue
+		}
+		res.Responses[i].ID = entry.ID
+		decoded := decodeMultiSignEntry(i, entry)
+		if decoded == nil {
+			continue
+		}
+		groupIndex, exists := groupIndices[decoded.key()]
+		if !exists {
+			groupIndex = len(groups)
+			groupIndices[decoded.key()] = groupIndex
+			groups = append(groups, nil)
+		}
+		groups[groupIndex] = append(groups[groupIndex], decoded)
+	}
+	if len(groups) == 0 {
+		return encodeMultiSignResponse(res)
+	}
+
+	credentials := handlers.GenerateCredentials(ctx)
+	_, err := util.Scatter(len(groups), func(offset int, entries int, _ *sync.RWMutex) (interface{}, error) {
+		for i := offset; i < offset+entries; i++ {
+			for _, request := range groups[i] {
+				res.Responses[request.index].State, res.Responses[request.index].Signature = h.multiSignEntry(ctx, credentials, request)
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		log.Error().Err(err).Str("result", "failed").Msg("Failed to scatter sign")
+	}
+
+	return encodeMultiSignResponse(res)
+}
+
+// multiSignEntry signs a single entry of a multisign request, returning its state and signature.
+func (h *Handler) multiSignEntry(ctx context.Context, credentials *checker.Credentials, request *multiSignRequest) (string, string) {
+	result, signature := h.signer.SignGeneric(ctx, credentials, request.account, request.pubKey, request.data)
+	switch result {
+	case core.ResultSucceeded:
+		return pb.ResponseState_SUCCEEDED.String(), fmt.Sprintf("%#x", signature)
+	case core.ResultDenied:
+		return pb.ResponseState_DENIED.String(), ""
+	case core.ResultFailed:
+		return pb.ResponseState_FAILED.String(), ""
+	default:
+		return pb.ResponseState_UNKNOWN.String(), ""
+	}
+}
+
+// decodeMultiSignEntry decodes a single entry of a multisign request, returning nil if it is invalid.
+func decodeMultiSignEntry(index int, entry *MultiSignEntry) *multiSignRequest {
+	log := log.With().Str("id", entry.ID).Logger()
+	if entry.Account == "" && entry.PubKey == "" {
+		log.Warn().Str("result", "denied").Msg("Multisign request account not specified")
+		return nil
+	}
+
+	req := &multiSignRequest{
+		index:   index,
+		account: entry.Account,
+		data:    &rules.SignData{},
+	}
+	var err error
+	if entry.PubKey != "" {
+		if req.pubKey, err = decodeHex(entry.PubKey); err != nil {
+			log.Warn().Err(err).Str("result", "denied").Msg("Multisign request public key invalid")
+			return nil
+		}
+	}
+	if req.data.Domain, err = decodeHex(entry.Domain); err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Multisign request domain invalid")
+		return nil
+	}
+	if len(req.data.Domain) != 32 {
+		log.Warn().Int("length", len(req.data.Domain)).Str("result", "denied").Msg("Multisign request domain incorrect length")
+		return nil
+	}
+	if req.data.Data, err = decodeHex(entry.Data); err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Multisign request data invalid")
+		return nil
+	}
+
+	return req
+}
+
+// encodeMultiSignResponse encodes the response to a multisign request.
+func encodeMultiSignResponse(res *MultiSignResponse) (*wrappers.BytesValue, error) {
+	data, err := json.Marshal(res)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Failed to encode response")
+	}
+	return &wrappers.BytesValue{Value: data}, nil
+}
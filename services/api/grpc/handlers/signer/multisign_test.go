@@ -0,0 +1,157 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	context "context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/signer"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	mockchecker "github.com/attestantio/dirk/services/checker/mock"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	standardsigner "github.com/attestantio/dirk/services/signer/standard"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/require"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMultiSign(t *testing.T) {
+	ctx := context.Background()
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	unlocker, err := localunlocker.New(ctx,
+		localunlocker.WithAccountPassphrases([]string{"Account 1 passphrase", "Account 2 passphrase", "Account 3 passphrase"}))
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	ruler, err := golang.New(ctx, golang.WithLocker(locker), golang.WithRules(testRules))
+	require.NoError(t, err)
+	checker, err := mockchecker.New()
+	require.NoError(t, err)
+	signerSvc, err := standardsigner.New(ctx,
+		standardsigner.WithUnlocker(unlocker),
+		standardsigner.WithChecker(checker),
+		standardsigner.WithFetcher(fetcher),
+		standardsigner.WithRuler(ruler))
+	require.NoError(t, err)
+	handler, err := signer.New(ctx, signer.WithSigner(signerSvc), signer.WithMaxBatchSize(8))
+	require.NoError(t, err)
+
+	domain := fmt.Sprintf("0x20%062x", 0)
+	// Generic signing of attestations is always denied.
+	attestationDomain := fmt.Sprintf("0x01%062x", 0)
+	data := fmt.Sprintf("0x%064x", 1)
+
+	tests := []struct {
+		name     string
+		request  string
+		code     codes.Code
+		expected []string
+	}{
+		{
+			name:    "Invalid",
+			request: "bad",
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "Empty",
+			request: `{"requests":[]}`,
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "TooLarge",
+			request: `{"requests":[{},{},{},{},{},{},{},{},{}]}`,
+			code:    codes.ResourceExhausted,
+		},
+		{
+			name: "Good",
+			request: fmt.Sprintf(`{"requests":[
+{"id":"1","account":"Wallet 1/Account 1","domain":"%[1]s","data":"%[2]s"},
+{"id":"2","account":"Wallet 1/Account 2","domain":"%[1]s","data":"%[2]s"},
+{"id":"3","account":"Wallet 1/Account 1","domain":"%[1]s","data":"%[2]s"},
+{"id":"4","account":"Wallet 1/Account 3","domain":"%[3]s","data":"%[2]s"},
+{"id":"5","domain":"%[1]s","data":"%[2]s"},
+{"id":"6","account":"Wallet 1/Account 4","domain":"%[1]s","data":"%[2]s"},
+{"id":"7","account":"Wallet 1/Account 3","domain":"bad","data":"%[2]s"},
+{"id":"8","account":"Wallet 1/Account 2","domain":"%[1]s","data":"%[2]s"}
+]}`, domain, data, attestationDomain),
+			code: codes.OK,
+			expected: []string{
+				"SUCCEEDED",
+				"SUCCEEDED",
+				"SUCCEEDED",
+				// Attestation domain.
+				"DENIED",
+				// No account.
+				"DENIED",
+				// Account 4 is locked.
+				"DENIED",
+				// Bad domain.
+				"DENIED",
+				"SUCCEEDED",
+			},
+		},
+		{
+			name: "ShortDomain",
+			request: fmt.Sprintf(`{"requests":[
+{"id":"1","account":"Wallet 1/Account 1","domain":"0x01","data":"%[2]s"},
+{"id":"2","account":"Wallet 1/Account 2","domain":"%[1]s","data":"%[2]s"}
+]}`, domain, data),
+			code: codes.OK,
+			expected: []string{
+				// Domain shorter than 32 bytes.
+				"DENIED",
+				"SUCCEEDED",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(ctx, &interceptors.ClientName{}, "client1")
+			res, err := handler.MultiSign(ctx, &wrappers.BytesValue{Value: []byte(test.request)})
+			require.Equal(t, test.code, status.Code(err))
+			if err != nil {
+				return
+			}
+			multiRes := &signer.MultiSignResponse{}
+			require.NoError(t, json.Unmarshal(res.GetValue(), multiRes))
+			require.Len(t, multiRes.Responses, len(test.expected))
+			for i := range test.expected {
+				require.Equal(t, fmt.Sprintf("%d", i+1), multiRes.Responses[i].ID)
+				require.Equal(t, test.expected[i], multiRes.Responses[i].State, fmt.Sprintf("response %d", i+1))
+				if test.expected[i] == "SUCCEEDED" {
+					require.NotEmpty(t, multiRes.Responses[i].Signature)
+				} else {
+					require.Empty(t, multiRes.Responses[i].Signature)
+				}
+			}
+		})
+	}
+}
@@ -23,6 +23,8 @@ import (
 type parameters struct {
 	logLevel zerolog.Level
 	signer   signer.Service
+	maxBatch int
+	errors   bool
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +52,23 @@ func WithSigner(signer signer.Service) Parameter {
 	})
 }
 
+// WithMaxBatchSize sets the maximum number of requests in a single multisign request.  Larger requests
+// are refused with a resource exhausted error.  If this is 0 then the size of requests is not limited.
+func WithMaxBatchSize(maxBatchSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxBatch = maxBatchSize
+	})
+}
+
+// WithStructuredErrors returns a gRPC status error in place of the response for unary signing requests that
+// do not succeed.  The status code depends on the reason that the request was not approved, and the status
+// carries details of the reason and the account.  If not set, the state in the response gives the result.
+func WithStructuredErrors(structuredErrors bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.errors = structuredErrors
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
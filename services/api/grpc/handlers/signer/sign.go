@@ -15,40 +15,54 @@ package signer
 
 import (
 	context "context"
+	"strconv"
 	"strings"
 
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/api/grpc/handlers"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc/metadata"
 )
 
+// epochMetadataKey is the key in the request metadata in which clients can supply the epoch to which
+// generic data refers.
+const epochMetadataKey = "epoch"
+
 // Sign signs generic data.
 func (h *Handler) Sign(ctx context.Context, req *pb.SignRequest) (*pb.SignResponse, error) {
 	log.Trace().Msg("Handling request")
+	ctx = h.withReasons(ctx)
 
 	res := &pb.SignResponse{}
 	if req == nil {
 		log.Warn().Str("result", "denied").Msg("Request not specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if req.GetAccount() == "" && req.GetPublicKey() == nil {
 		log.Warn().Str("result", "denied").Msg("Neither account nor public key specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if !strings.Contains(req.GetAccount(), "/") {
 		log.Warn().Str("result", "denied").Msg("Invalid account specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
+	}
+
+	epoch, err := epochFromContext(ctx)
+	if err != nil {
+		log.Warn().Str("result", "denied").Err(err).Msg("Invalid epoch specified")
+		return h.invalidRequest(res, req.GetAccount())
 	}
 
 	data := &rules.SignData{
 		Domain: req.Domain,
 		Data:   req.Data,
+		Epoch:  epoch,
 	}
 	result, signature := h.signer.SignGeneric(ctx, handlers.GenerateCredentials(ctx), req.GetAccount(), req.GetPublicKey(), data)
+	if err := h.resultError(ctx, result, req.GetAccount()); err != nil {
+		return nil, err
+	}
 	switch result {
 	case core.ResultSucceeded:
 		res.State = pb.ResponseState_SUCCEEDED
@@ -64,3 +78,20 @@ func (h *Handler) Sign(ctx context.Context, req *pb.SignRequest) (*pb.SignRespon
 	log.Trace().Str("result", "succeeded").Msg("Success")
 	return res, nil
 }
+
+// epochFromContext obtains the epoch for generic data from the request metadata, if present.
+func epochFromContext(ctx context.Context) (*uint64, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	values := md.Get(epochMetadataKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	epoch, err := strconv.ParseUint(values[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &epoch, nil
+}
@@ -33,6 +33,7 @@ import (
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/metadata"
 )
 
 func TestMain(m *testing.M) {
@@ -46,6 +47,7 @@ func TestSign(t *testing.T) {
 	tests := []struct {
 		name   string
 		client string
+		epoch  string
 		req    *pb.SignRequest
 		state  pb.ResponseState
 		err    string
@@ -88,6 +90,25 @@ func TestSign(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "EpochInvalid",
+			client: "client1",
+			epoch:  "bad",
+			req: &pb.SignRequest{
+				Id: &pb.SignRequest_Account{
+					Account: "Wallet 1/Account 1",
+				},
+				Data: []byte{
+					0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+					0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+				},
+				Domain: []byte{
+					0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x1e, 0x3f,
+				},
+			},
+			state: pb.ResponseState_DENIED,
+		},
 		{
 			name:   "Good",
 			client: "client1",
@@ -106,6 +127,25 @@ func TestSign(t *testing.T) {
 			},
 			state: pb.ResponseState_SUCCEEDED,
 		},
+		{
+			name:   "EpochGood",
+			client: "client1",
+			epoch:  "10",
+			req: &pb.SignRequest{
+				Id: &pb.SignRequest_Account{
+					Account: "Wallet 1/Account 1",
+				},
+				Data: []byte{
+					0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+					0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+				},
+				Domain: []byte{
+					0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x1e, 0x3f,
+				},
+			},
+			state: pb.ResponseState_SUCCEEDED,
+		},
 	}
 
 	handler, err := Setup()
@@ -114,6 +154,9 @@ func TestSign(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			ctx := context.WithValue(context.Background(), &interceptors.ClientName{}, test.client)
+			if test.epoch != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("epoch", test.epoch))
+			}
 			resp, err := handler.Sign(ctx, test.req)
 			if test.err == "" {
 				require.NoError(t, err)
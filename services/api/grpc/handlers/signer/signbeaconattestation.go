@@ -26,37 +26,32 @@ import (
 // SignBeaconAttestation signs a attestation for a beacon block.
 func (h *Handler) SignBeaconAttestation(ctx context.Context, req *pb.SignBeaconAttestationRequest) (*pb.SignResponse, error) {
 	log.Trace().Msg("Handling request")
+	ctx = h.withReasons(ctx)
 
 	res := &pb.SignResponse{}
 	if req == nil {
 		log.Warn().Str("result", "denied").Msg("Request not specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if req.GetAccount() == "" && req.GetPublicKey() == nil {
 		log.Warn().Str("result", "denied").Msg("Neither accout nor public key specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if !strings.Contains(req.GetAccount(), "/") {
 		log.Warn().Str("result", "denied").Msg("Invalid account specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if req.Data == nil {
 		log.Warn().Str("result", "denied").Msg("Request data not specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if req.Data.Source == nil {
 		log.Warn().Str("result", "denied").Msg("Request source checkpoint not specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if req.Data.Target == nil {
 		log.Warn().Str("result", "denied").Msg("Request target checkpoint not specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 
 	data := &rules.SignBeaconAttestationData{
@@ -75,6 +70,9 @@ func (h *Handler) SignBeaconAttestation(ctx context.Context, req *pb.SignBeaconA
 	}
 
 	result, signature := h.signer.SignBeaconAttestation(ctx, handlers.GenerateCredentials(ctx), req.GetAccount(), req.GetPublicKey(), data)
+	if err := h.resultError(ctx, result, req.GetAccount()); err != nil {
+		return nil, err
+	}
 	switch result {
 	case core.ResultSucceeded:
 		res.State = pb.ResponseState_SUCCEEDED
@@ -20,6 +20,8 @@ import (
 	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/api/grpc/handlers"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // SignBeaconAttestations signs multiple beacon attestations.
@@ -40,6 +42,11 @@ func (h *Handler) SignBeaconAttestations(ctx context.Context, req *pb.SignBeacon
 		return res, nil
 	}
 
+	if h.maxBatch > 0 && len(req.Requests) > h.maxBatch {
+		log.Warn().Int("requests", len(req.Requests)).Int("max_batch_size", h.maxBatch).Str("result", "failed").Msg("Request exceeds maximum batch size")
+		return nil, status.Errorf(codes.ResourceExhausted, "Request contains %d entries, exceeding the maximum of %d", len(req.Requests), h.maxBatch)
+	}
+
 	res.Responses = make([]*pb.SignResponse, len(req.Requests))
 	for i := range req.Requests {
 		res.Responses[i] = &pb.SignResponse{State: pb.ResponseState_UNKNOWN}
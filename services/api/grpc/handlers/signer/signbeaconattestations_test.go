@@ -17,7 +17,9 @@ import (
 	context "context"
 	"testing"
 
+	"github.com/attestantio/dirk/services/api/grpc/handlers/signer"
 	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	mocksigner "github.com/attestantio/dirk/services/signer/mock"
 	"github.com/stretchr/testify/require"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
 )
@@ -276,3 +278,42 @@ func TestSignBeaconAttestations(t *testing.T) {
 		})
 	}
 }
+
+func TestSignBeaconAttestationsMaxBatchSize(t *testing.T) {
+	handler, err := signer.New(context.Background(),
+		signer.WithSigner(mocksigner.New()),
+		signer.WithMaxBatchSize(2),
+	)
+	require.NoError(t, err)
+
+	request := func(entries int) *pb.SignBeaconAttestationsRequest {
+		req := &pb.SignBeaconAttestationsRequest{
+			Requests: make([]*pb.SignBeaconAttestationRequest, entries),
+		}
+		for i := range req.Requests {
+			req.Requests[i] = &pb.SignBeaconAttestationRequest{
+				Id:     &pb.SignBeaconAttestationRequest_Account{Account: "Wallet 1/Account 1"},
+				Domain: make([]byte, 32),
+				Data: &pb.AttestationData{
+					BeaconBlockRoot: make([]byte, 32),
+					Source:          &pb.Checkpoint{Root: make([]byte, 32)},
+					Target:          &pb.Checkpoint{Epoch: 1, Root: make([]byte, 32)},
+				},
+			}
+		}
+		return req
+	}
+	ctx := context.WithValue(context.Background(), &interceptors.ClientName{}, "client1")
+
+	// A request at the limit is signed.
+	resp, err := handler.SignBeaconAttestations(ctx, request(2))
+	require.NoError(t, err)
+	require.Len(t, resp.Responses, 2)
+	for i := range resp.Responses {
+		require.Equal(t, pb.ResponseState_SUCCEEDED, resp.Responses[i].State)
+	}
+
+	// A request over the limit is refused.
+	_, err = handler.SignBeaconAttestations(ctx, request(3))
+	require.EqualError(t, err, "rpc error: code = ResourceExhausted desc = Request contains 3 entries, exceeding the maximum of 2")
+}
@@ -0,0 +1,343 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/api/grpc/handlers"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SignBeaconAttestationStreamMethod is the full name of the method to stream beacon attestation signing requests.
+const SignBeaconAttestationStreamMethod = "/dirk.signer.v1.SignerStream/SignBeaconAttestations"
+
+// maxStreamBatch is the maximum number of streamed requests passed to the signer in a single batch.
+// It is also the number of requests buffered for a stream, so a client that sends requests faster than
+// they can be signed is held back by flow control rather than by unbounded buffering in the server.
+const maxStreamBatch = 128
+
+// signerStreamServer is the interface for the signer stream GRPC service.
+// There is no protobuf definition for this service; messages carry JSON-encoded requests and responses
+// in well-known wrapper types, so no generated code is required.
+type signerStreamServer interface {
+	SignBeaconAttestationStream(stream grpc.ServerStream) error
+}
+
+var streamServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.signer.v1.SignerStream",
+	HandlerType: (*signerStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SignBeaconAttestations",
+			Handler:       signBeaconAttestationStreamHandler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "signer",
+}
+
+// RegisterStream registers the signer stream service with the GRPC server.
+func RegisterStream(server *grpc.Server, h *Handler) {
+	server.RegisterService(&streamServiceDesc, h)
+}
+
+func signBeaconAttestationStreamHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(signerStreamServer).SignBeaconAttestationStream(stream)
+}
+
+// StreamCheckpoint is the JSON representation of a checkpoint in a streamed request.
+type StreamCheckpoint struct {
+	Epoch uint64 `json:"epoch"`
+	Root  string `json:"root"`
+}
+
+// StreamSignBeaconAttestationRequest is the JSON representation of a streamed request to sign a beacon attestation.
+// The account is identified by either its name or its public key.
+type StreamSignBeaconAttestationRequest struct {
+	ID              string            `json:"id"`
+	Account         string            `json:"account,omitempty"`
+	PubKey          string            `json:"pubkey,omitempty"`
+	Domain          string            `json:"domain"`
+	Slot            uint64            `json:"slot"`
+	CommitteeIndex  uint64            `json:"committee_index"`
+	BeaconBlockRoot string            `json:"beacon_block_root"`
+	Source          *StreamCheckpoint `json:"source"`
+	Target          *StreamCheckpoint `json:"target"`
+}
+
+// StreamSignResponse is the JSON representation of a streamed signing response.
+// The ID is that of the request to which this is the response.
+type StreamSignResponse struct {
+	ID        string `json:"id"`
+	State     string `json:"state"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// streamRequest is a decoded streamed request.
+type streamRequest struct {
+	id      string
+	account string
+	pubKey  []byte
+	data    *rules.SignBeaconAttestationData
+	// invalid is set if the request could not be decoded, in which case it is denied without signing.
+	invalid bool
+}
+
+// key returns a key identifying the account of the request.
+func (r *streamRequest) key() string {
+	if len(r.pubKey) > 0 {
+		return fmt.Sprintf("%#x", r.pubKey)
+	}
+	return r.account
+}
+
+// SignBeaconAttestationStream handles the SignBeaconAttestations() grpc stream.
+// Requests are read from the stream as they arrive and passed to the signer in batches, so the same locking and
+// slashing protection applies as for unary requests.  Responses are returned in the order in which requests were
+// received, each carrying the ID of its request.  The client identity is obtained when the stream is opened and
+// applies to all requests on the stream.
+func (h *Handler) SignBeaconAttestationStream(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	credentials := handlers.GenerateCredentials(ctx)
+	log := log.With().Str("client", credentials.Client).Logger()
+	log.Trace().Msg("Stream opened")
+
+	reqs := make(chan *streamRequest, maxStreamBatch)
+	recvErr := make(chan error, 1)
+	go func() {
+		defer close(reqs)
+		for {
+			in := new(wrappers.BytesValue)
+			if err := stream.RecvMsg(in); err != nil {
+				if err != io.EOF {
+					recvErr <- err
+				}
+				return
+			}
+			select {
+			case reqs <- decodeStreamRequest(in.Value):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	draining := interceptors.DrainingFromContext(ctx)
+	var pending *streamRequest
+	for {
+		first := pending
+		pending = nil
+		if first == nil {
+			select {
+			case req, ok := <-reqs:
+				if !ok {
+					select {
+					case err := <-recvErr:
+						log.Debug().Err(err).Msg("Stream closed with error")
+						return err
+					default:
+						log.Trace().Msg("Stream closed")
+						return nil
+					}
+				}
+				first = req
+			case <-draining:
+				log.Debug().Msg("Closing stream on shutdown")
+				return status.Error(codes.Unavailable, "Shutting down")
+			}
+		}
+
+		// Gather further requests that have already arrived.  A batch cannot contain multiple requests for the
+		// same account, so a repeated account starts a new batch.
+		batch := []*streamRequest{first}
+		keys := map[string]bool{first.key(): true}
+	gather:
+		for len(batch) < maxStreamBatch {
+			select {
+			case req, ok := <-reqs:
+				if !ok {
+					break gather
+				}
+				if !req.invalid && keys[req.key()] {
+					pending = req
+					break gather
+				}
+				batch = append(batch, req)
+				keys[req.key()] = true
+			default:
+				break gather
+			}
+		}
+
+		if err := h.signStreamBatch(stream, credentials, batch); err != nil {
+			log.Debug().Err(err).Msg("Failed to send response")
+			return err
+		}
+	}
+}
+
+// signStreamBatch signs a batch of streamed requests and sends the responses.
+func (h *Handler) signStreamBatch(stream grpc.ServerStream, credentials *checker.Credentials, batch []*streamRequest) error {
+	states := make([]pb.ResponseState, len(batch))
+	signatures := make([][]byte, len(batch))
+
+	remaining := make([]int, 0, len(batch))
+	for i := range batch {
+		if batch[i].invalid {
+			states[i] = pb.ResponseState_DENIED
+			continue
+		}
+		states[i] = pb.ResponseState_UNKNOWN
+		remaining = append(remaining, i)
+	}
+
+	// The signer stops at the first request that fails its checks, leaving later requests unknown.  Streamed
+	// requests are independent of each other, so unknown requests are resubmitted without the failed request.
+	for len(remaining) > 0 {
+		accountNames := make([]string, len(remaining))
+		pubKeys := make([][]byte, len(remaining))
+		reqData := make([]*rules.SignBeaconAttestationData, len(remaining))
+		for i, index := range remaining {
+			accountNames[i] = batch[index].account
+			pubKeys[i] = batch[index].pubKey
+			reqData[i] = batch[index].data
+		}
+
+		results, sigs := h.signer.SignBeaconAttestations(stream.Context(), credentials, accountNames, pubKeys, reqData)
+		unknown := make([]int, 0)
+		for i := range results {
+			index := remaining[i]
+			switch results[i] {
+			case core.ResultSucceeded:
+				states[index] = pb.ResponseState_SUCCEEDED
+				signatures[index] = sigs[i]
+			case core.ResultDenied:
+				states[index] = pb.ResponseState_DENIED
+			case core.ResultFailed:
+				states[index] = pb.ResponseState_FAILED
+			default:
+				unknown = append(unknown, index)
+			}
+		}
+		if len(unknown) == len(remaining) {
+			// No progress was made, so resubmitting would not help.
+			break
+		}
+		remaining = unknown
+	}
+
+	for i := range batch {
+		res := &StreamSignResponse{
+			ID:    batch[i].id,
+			State: states[i].String(),
+		}
+		if signatures[i] != nil {
+			res.Signature = fmt.Sprintf("%#x", signatures[i])
+		}
+		data, err := json.Marshal(res)
+		if err != nil {
+			return status.Error(codes.Internal, "Failed to encode response")
+		}
+		if err := stream.SendMsg(&wrappers.BytesValue{Value: data}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeStreamRequest decodes a streamed request.
+// A request that cannot be decoded is marked as invalid, retaining its ID if available.
+func decodeStreamRequest(data []byte) *streamRequest {
+	in := &StreamSignBeaconAttestationRequest{}
+	if err := json.Unmarshal(data, in); err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Invalid streamed request")
+		return &streamRequest{invalid: true}
+	}
+	req := &streamRequest{
+		id:      in.ID,
+		account: in.Account,
+		invalid: true,
+	}
+	log := log.With().Str("id", in.ID).Logger()
+	if in.Account == "" && in.PubKey == "" {
+		log.Warn().Str("result", "denied").Msg("Streamed request account not specified")
+		return req
+	}
+	if in.Source == nil {
+		log.Warn().Str("result", "denied").Msg("Streamed request source checkpoint not specified")
+		return req
+	}
+	if in.Target == nil {
+		log.Warn().Str("result", "denied").Msg("Streamed request target checkpoint not specified")
+		return req
+	}
+
+	var err error
+	if in.PubKey != "" {
+		if req.pubKey, err = decodeHex(in.PubKey); err != nil {
+			log.Warn().Err(err).Str("result", "denied").Msg("Streamed request public key invalid")
+			return req
+		}
+	}
+	req.data = &rules.SignBeaconAttestationData{
+		Slot:           in.Slot,
+		CommitteeIndex: in.CommitteeIndex,
+		Source: &rules.Checkpoint{
+			Epoch: in.Source.Epoch,
+		},
+		Target: &rules.Checkpoint{
+			Epoch: in.Target.Epoch,
+		},
+	}
+	if req.data.Domain, err = decodeHex(in.Domain); err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Streamed request domain invalid")
+		return req
+	}
+	if req.data.BeaconBlockRoot, err = decodeHex(in.BeaconBlockRoot); err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Streamed request beacon block root invalid")
+		return req
+	}
+	if req.data.Source.Root, err = decodeHex(in.Source.Root); err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Streamed request source root invalid")
+		return req
+	}
+	if req.data.Target.Root, err = decodeHex(in.Target.Root); err != nil {
+		log.Warn().Err(err).Str("result", "denied").Msg("Streamed request target root invalid")
+		return req
+	}
+
+	req.invalid = false
+	return req
+}
+
+// decodeHex decodes a hex string, with or without a leading 0x.
+func decodeHex(input string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(input, "0x"))
+}
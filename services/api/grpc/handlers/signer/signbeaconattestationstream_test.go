@@ -0,0 +1,162 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	context "context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/signer"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	mockchecker "github.com/attestantio/dirk/services/checker/mock"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	standardsigner "github.com/attestantio/dirk/services/signer/standard"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/require"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc"
+)
+
+// testSignerStream is a signer stream that supplies a fixed set of requests and records responses.
+type testSignerStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	requests  [][]byte
+	responses []*signer.StreamSignResponse
+}
+
+func (s *testSignerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *testSignerStream) RecvMsg(m interface{}) error {
+	if len(s.requests) == 0 {
+		return io.EOF
+	}
+	m.(*wrappers.BytesValue).Value = s.requests[0]
+	s.requests = s.requests[1:]
+	return nil
+}
+
+func (s *testSignerStream) SendMsg(m interface{}) error {
+	res := &signer.StreamSignResponse{}
+	if err := json.Unmarshal(m.(*wrappers.BytesValue).Value, res); err != nil {
+		return err
+	}
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func streamAttestationRequest(t *testing.T, id string, account string, slot uint64, root byte) []byte {
+	t.Helper()
+	req := &signer.StreamSignBeaconAttestationRequest{
+		ID:              id,
+		Account:         account,
+		Domain:          fmt.Sprintf("0x01%062x", 0),
+		Slot:            slot,
+		BeaconBlockRoot: fmt.Sprintf("%#064x", root),
+		Source: &signer.StreamCheckpoint{
+			Epoch: 0,
+			Root:  fmt.Sprintf("%064x", 0),
+		},
+		Target: &signer.StreamCheckpoint{
+			Epoch: slot,
+			Root:  fmt.Sprintf("%#064x", root),
+		},
+	}
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	return data
+}
+
+func TestSignBeaconAttestationStream(t *testing.T) {
+	ctx := context.Background()
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	unlocker, err := localunlocker.New(ctx,
+		localunlocker.WithAccountPassphrases([]string{"Account 1 passphrase", "Account 2 passphrase", "Account 3 passphrase"}))
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	ruler, err := golang.New(ctx, golang.WithLocker(locker), golang.WithRules(testRules))
+	require.NoError(t, err)
+	checker, err := mockchecker.New()
+	require.NoError(t, err)
+	signerSvc, err := standardsigner.New(ctx,
+		standardsigner.WithUnlocker(unlocker),
+		standardsigner.WithChecker(checker),
+		standardsigner.WithFetcher(fetcher),
+		standardsigner.WithRuler(ruler))
+	require.NoError(t, err)
+	handler, err := signer.New(ctx, signer.WithSigner(signerSvc))
+	require.NoError(t, err)
+
+	stream := &testSignerStream{
+		ctx: context.WithValue(ctx, &interceptors.ClientName{}, "client1"),
+		requests: [][]byte{
+			streamAttestationRequest(t, "1", "Wallet 1/Account 1", 1, 0x01),
+			streamAttestationRequest(t, "2", "Wallet 1/Account 2", 1, 0x01),
+			streamAttestationRequest(t, "3", "Wallet 1/Account 1", 2, 0x02),
+			streamAttestationRequest(t, "4", "Wallet 1/Account 3", 1, 0x01),
+			// Same target epoch as request 2 with a different root, so slashable.
+			streamAttestationRequest(t, "5", "Wallet 1/Account 2", 1, 0x05),
+			[]byte(`{"id":"6","account":"Wallet 1/Account 3","domain":"0x00"}`),
+			streamAttestationRequest(t, "7", "Wallet 1/Account 2", 2, 0x02),
+			[]byte("bad"),
+			streamAttestationRequest(t, "9", "Wallet 1/Account 4", 1, 0x01),
+			streamAttestationRequest(t, "10", "Wallet 1/Account 3", 2, 0x02),
+		},
+	}
+	require.NoError(t, handler.SignBeaconAttestationStream(stream))
+
+	expected := []struct {
+		id    string
+		state string
+	}{
+		{id: "1", state: "SUCCEEDED"},
+		{id: "2", state: "SUCCEEDED"},
+		{id: "3", state: "SUCCEEDED"},
+		{id: "4", state: "SUCCEEDED"},
+		{id: "5", state: "DENIED"},
+		{id: "6", state: "DENIED"},
+		{id: "7", state: "SUCCEEDED"},
+		{id: "", state: "DENIED"},
+		// Account 4 is locked.
+		{id: "9", state: "DENIED"},
+		{id: "10", state: "SUCCEEDED"},
+	}
+	require.Len(t, stream.responses, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].id, stream.responses[i].ID)
+		require.Equal(t, expected[i].state, stream.responses[i].State, fmt.Sprintf("response %s", expected[i].id))
+		if expected[i].state == "SUCCEEDED" {
+			require.NotEmpty(t, stream.responses[i].Signature)
+		} else {
+			require.Empty(t, stream.responses[i].Signature)
+		}
+	}
+}
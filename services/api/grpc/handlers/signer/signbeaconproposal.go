@@ -21,32 +21,33 @@ import (
 	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/api/grpc/handlers"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc/metadata"
 )
 
+// graffitiMetadataKey is the key in the request metadata in which clients can supply the graffiti of the proposal.
+const graffitiMetadataKey = "graffiti"
+
 // SignBeaconProposal signs a proposal for a beacon block.
 func (h *Handler) SignBeaconProposal(ctx context.Context, req *pb.SignBeaconProposalRequest) (*pb.SignResponse, error) {
 	log.Trace().Msg("Handling request")
+	ctx = h.withReasons(ctx)
 
 	res := &pb.SignResponse{}
 	if req == nil {
 		log.Warn().Str("result", "denied").Msg("Request not specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if req.Data == nil {
 		log.Warn().Str("result", "denied").Msg("Request data not specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if req.GetAccount() == "" && req.GetPublicKey() == nil {
 		log.Warn().Str("result", "denied").Msg("Neither accout nor public key specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 	if !strings.Contains(req.GetAccount(), "/") {
 		log.Warn().Str("result", "denied").Msg("Invalid account specified")
-		res.State = pb.ResponseState_DENIED
-		return res, nil
+		return h.invalidRequest(res, req.GetAccount())
 	}
 
 	data := &rules.SignBeaconProposalData{
@@ -56,8 +57,12 @@ func (h *Handler) SignBeaconProposal(ctx context.Context, req *pb.SignBeaconProp
 		ParentRoot:    req.Data.ParentRoot,
 		StateRoot:     req.Data.StateRoot,
 		BodyRoot:      req.Data.BodyRoot,
+		Graffiti:      graffitiFromContext(ctx),
 	}
 	result, signature := h.signer.SignBeaconProposal(ctx, handlers.GenerateCredentials(ctx), req.GetAccount(), req.GetPublicKey(), data)
+	if err := h.resultError(ctx, result, req.GetAccount()); err != nil {
+		return nil, err
+	}
 	switch result {
 	case core.ResultSucceeded:
 		res.State = pb.ResponseState_SUCCEEDED
@@ -73,3 +78,16 @@ func (h *Handler) SignBeaconProposal(ctx context.Context, req *pb.SignBeaconProp
 	log.Trace().Str("result", "succeeded").Msg("Success")
 	return res, nil
 }
+
+// graffitiFromContext obtains the graffiti for a proposal from the request metadata, if present.
+func graffitiFromContext(ctx context.Context) []byte {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	values := md.Get(graffitiMetadataKey)
+	if len(values) == 0 {
+		return nil
+	}
+	return []byte(values[0])
+}
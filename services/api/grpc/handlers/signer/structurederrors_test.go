@@ -0,0 +1,156 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signer_test
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/api/grpc/handlers/signer"
+	"github.com/attestantio/dirk/services/checker"
+	mocksigner "github.com/attestantio/dirk/services/signer/mock"
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// reasonSigner is a signer that refuses beacon proposals with a given result and reason.
+type reasonSigner struct {
+	*mocksigner.Service
+	result core.Result
+	reason rules.Reason
+}
+
+func (s *reasonSigner) SignBeaconProposal(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignBeaconProposalData,
+) (core.Result, []byte) {
+	if s.result == core.ResultSucceeded {
+		return s.Service.SignBeaconProposal(ctx, credentials, accountName, pubKey, data)
+	}
+	rules.RecordReason(ctx, s.reason)
+	return s.result, nil
+}
+
+func TestStructuredErrors(t *testing.T) {
+	ctx := context.Background()
+	req := &pb.SignBeaconProposalRequest{
+		Id:     &pb.SignBeaconProposalRequest_Account{Account: "Wallet 1/Account 1"},
+		Domain: make([]byte, 32),
+		Data: &pb.BeaconBlockHeader{
+			Slot:       1,
+			ParentRoot: make([]byte, 32),
+			StateRoot:  make([]byte, 32),
+			BodyRoot:   make([]byte, 32),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		result core.Result
+		reason rules.Reason
+		req    *pb.SignBeaconProposalRequest
+		code   codes.Code
+	}{
+		{
+			name:   "Succeeded",
+			result: core.ResultSucceeded,
+			req:    req,
+			code:   codes.OK,
+		},
+		{
+			name:   "Slashing",
+			result: core.ResultDenied,
+			reason: rules.ReasonSlashing,
+			req:    req,
+			code:   codes.FailedPrecondition,
+		},
+		{
+			name:   "LimitExceeded",
+			result: core.ResultDenied,
+			reason: rules.ReasonLimitExceeded,
+			req:    req,
+			code:   codes.ResourceExhausted,
+		},
+		{
+			name:   "Policy",
+			result: core.ResultDenied,
+			reason: rules.ReasonPolicy,
+			req:    req,
+			code:   codes.PermissionDenied,
+		},
+		{
+			name:   "StoreUnavailable",
+			result: core.ResultFailed,
+			reason: rules.ReasonStoreUnavailable,
+			req:    req,
+			code:   codes.Unavailable,
+		},
+		{
+			name:   "InvalidRequest",
+			result: core.ResultFailed,
+			reason: rules.ReasonInvalidRequest,
+			req:    req,
+			code:   codes.InvalidArgument,
+		},
+		{
+			name: "MissingData",
+			req: &pb.SignBeaconProposalRequest{
+				Id:     &pb.SignBeaconProposalRequest_Account{Account: "Wallet 1/Account 1"},
+				Domain: make([]byte, 32),
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, err := signer.New(ctx,
+				signer.WithSigner(&reasonSigner{Service: mocksigner.New(), result: test.result, reason: test.reason}),
+				signer.WithStructuredErrors(true),
+			)
+			require.NoError(t, err)
+
+			resp, err := handler.SignBeaconProposal(ctx, test.req)
+			if test.code == codes.OK {
+				require.NoError(t, err)
+				require.Equal(t, pb.ResponseState_SUCCEEDED, resp.State)
+				return
+			}
+			require.Nil(t, resp)
+			st, ok := status.FromError(err)
+			require.True(t, ok)
+			require.Equal(t, test.code, st.Code())
+			require.Len(t, st.Details(), 1)
+			info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+			require.True(t, ok)
+			require.Equal(t, "Wallet 1/Account 1", info.Metadata["account"])
+		})
+	}
+
+	// Without structured errors the state in the response gives the result.
+	handler, err := signer.New(ctx,
+		signer.WithSigner(&reasonSigner{Service: mocksigner.New(), result: core.ResultDenied, reason: rules.ReasonSlashing}),
+	)
+	require.NoError(t, err)
+	resp, err := handler.SignBeaconProposal(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, pb.ResponseState_DENIED, resp.State)
+}
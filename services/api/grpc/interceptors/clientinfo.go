@@ -15,6 +15,7 @@ package interceptors
 
 import (
 	"context"
+	"crypto/x509"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -23,11 +24,21 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// ClientName is a context tag for the CN of the client's certificate.
+// ClientName is a context tag for the identity of the client, obtained from the client's certificate.
 type ClientName struct{}
 
-// ClientInfoInterceptor adds the client certificate common name to incoming requests.
-func ClientInfoInterceptor() grpc.UnaryServerInterceptor {
+// Subject alternative name fields from which the client identity can be obtained.
+const (
+	// ClientIdentitySANURI obtains the client identity from the first URI SAN, for example a SPIFFE ID.
+	ClientIdentitySANURI = "uri"
+	// ClientIdentitySANDNS obtains the client identity from the first DNS SAN.
+	ClientIdentitySANDNS = "dns"
+)
+
+// ClientInfoInterceptor adds the client identity to incoming requests.
+// If identitySAN is supplied the identity is obtained from that subject alternative name field when present in
+// the client's certificate, otherwise it is obtained from the certificate's common name.
+func ClientInfoInterceptor(identitySAN string) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		grpcPeer, ok := peer.FromContext(ctx)
 		if !ok {
@@ -40,9 +51,24 @@ func ClientInfoInterceptor() grpc.UnaryServerInterceptor {
 			peerCerts := authState.PeerCertificates
 			if len(peerCerts) > 0 {
 				peerCert := peerCerts[0]
-				newCtx = context.WithValue(ctx, &ClientName{}, peerCert.Subject.CommonName)
+				newCtx = context.WithValue(ctx, &ClientName{}, ClientIdentity(peerCert, identitySAN))
 			}
 		}
 		return handler(newCtx, req)
 	}
 }
+
+// ClientIdentity obtains the client identity from its certificate.
+func ClientIdentity(cert *x509.Certificate, identitySAN string) string {
+	switch identitySAN {
+	case ClientIdentitySANURI:
+		if len(cert.URIs) > 0 && cert.URIs[0] != nil {
+			return cert.URIs[0].String()
+		}
+	case ClientIdentitySANDNS:
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	}
+	return cert.Subject.CommonName
+}
@@ -0,0 +1,107 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientInfoInterceptor(t *testing.T) {
+	spiffeID, err := url.Parse("spiffe://example.org/ns/validators/sa/client1")
+	require.NoError(t, err)
+
+	plainCert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "client1"},
+	}
+	sanCert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "ignored"},
+		URIs:     []*url.URL{spiffeID},
+		DNSNames: []string{"client1.example.org"},
+	}
+
+	tests := []struct {
+		name        string
+		identitySAN string
+		cert        *x509.Certificate
+		client      string
+	}{
+		{
+			name:   "CommonName",
+			cert:   plainCert,
+			client: "client1",
+		},
+		{
+			name:   "CommonNameIgnoresSAN",
+			cert:   sanCert,
+			client: "ignored",
+		},
+		{
+			name:        "URISAN",
+			identitySAN: interceptors.ClientIdentitySANURI,
+			cert:        sanCert,
+			client:      "spiffe://example.org/ns/validators/sa/client1",
+		},
+		{
+			name:        "URISANMissing",
+			identitySAN: interceptors.ClientIdentitySANURI,
+			cert:        plainCert,
+			client:      "client1",
+		},
+		{
+			name:        "DNSSAN",
+			identitySAN: interceptors.ClientIdentitySANDNS,
+			cert:        sanCert,
+			client:      "client1.example.org",
+		},
+		{
+			name:        "DNSSANMissing",
+			identitySAN: interceptors.ClientIdentitySANDNS,
+			cert:        plainCert,
+			client:      "client1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{
+				AuthInfo: credentials.TLSInfo{
+					State: tls.ConnectionState{
+						HandshakeComplete: true,
+						PeerCertificates:  []*x509.Certificate{test.cert},
+					},
+				},
+			})
+			var client string
+			interceptor := interceptors.ClientInfoInterceptor(test.identitySAN)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				client = ctx.Value(&interceptors.ClientName{}).(string)
+				return nil, nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.client, client)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/tokenverifier"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Credentials is a context tag for the checker credentials of the request.
+type Credentials struct{}
+
+// TokenClientPrefix is the prefix of the names of clients identified by a token alone, keeping them separate from
+// the names of clients and peers identified by their certificates.
+const TokenClientPrefix = "token:"
+
+// CredentialsInterceptor adds checker credentials to incoming requests.
+// The client is obtained from the verified client certificate as per ClientInfoInterceptor, and the IP address
+// from the connection.  If a token verifier is supplied then a client may also present a bearer token in the
+// authorization metadata of the request.  If the client also presented a verified certificate the identity from the
+// token must match that of the certificate; otherwise the client is the identity from the token, prefixed with
+// TokenClientPrefix.  Tokens are refused for the services in certOnlyServices, which are identified by their
+// certificates alone.
+// If requireClientCert is true requests without either a verified client certificate or a verified token are rejected.
+// The client name and IP address are also added to the context individually, so this replaces
+// SourceIPInterceptor and ClientInfoInterceptor.
+func CredentialsInterceptor(identitySAN string,
+	requireClientCert bool,
+	tokenVerifier tokenverifier.Service,
+	certOnlyServices []string,
+) grpc.UnaryServerInterceptor {
+	certOnly := serviceSet(certOnlyServices)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		newCtx, err := credentialsContext(ctx, info.FullMethod, identitySAN, requireClientCert, tokenVerifier, certOnly)
+		if err != nil {
+			return nil, err
+		}
+		return handler(newCtx, req)
+	}
+}
+
+// CredentialsStreamInterceptor adds checker credentials to incoming streams.
+// See CredentialsInterceptor for details.
+func CredentialsStreamInterceptor(identitySAN string,
+	requireClientCert bool,
+	tokenVerifier tokenverifier.Service,
+	certOnlyServices []string,
+) grpc.StreamServerInterceptor {
+	certOnly := serviceSet(certOnlyServices)
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		newCtx, err := credentialsContext(stream.Context(), info.FullMethod, identitySAN, requireClientCert, tokenVerifier, certOnly)
+		if err != nil {
+			return err
+		}
+		wrapped := grpc_middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = newCtx
+		return handler(srv, wrapped)
+	}
+}
+
+// CredentialsFromContext returns the checker credentials added by the credentials interceptor.
+func CredentialsFromContext(ctx context.Context) (*checker.Credentials, bool) {
+	credentials, ok := ctx.Value(&Credentials{}).(*checker.Credentials)
+	return credentials, ok
+}
+
+// credentialsContext returns a context containing the credentials obtained from the peer.
+func credentialsContext(ctx context.Context,
+	fullMethod string,
+	identitySAN string,
+	requireClientCert bool,
+	tokenVerifier tokenverifier.Service,
+	certOnly map[string]bool,
+) (context.Context, error) {
+	grpcPeer, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Internal, "Failure")
+	}
+
+	res := &checker.Credentials{}
+	if requestID, ok := ctx.Value(&RequestID{}).(string); ok {
+		res.RequestID = requestID
+	}
+	if tcpAddr, ok := grpcPeer.Addr.(*net.TCPAddr); ok {
+		res.IP = tcpAddr.IP.String()
+	}
+	if tlsInfo, ok := grpcPeer.AuthInfo.(credentials.TLSInfo); ok {
+		state := tlsInfo.State
+		// Only a certificate that has been verified against the client CA is trusted.
+		if state.HandshakeComplete && len(state.VerifiedChains) > 0 && len(state.PeerCertificates) > 0 {
+			res.Client = ClientIdentity(state.PeerCertificates[0], identitySAN)
+		}
+	}
+	if tokenVerifier != nil {
+		if token, present := bearerToken(ctx); present {
+			if certOnly[serviceName(fullMethod)] {
+				return nil, status.Error(codes.Unauthenticated, "Token authentication not accepted for this service")
+			}
+			client, err := tokenVerifier.VerifyToken(ctx, token)
+			if err != nil || client == "" {
+				return nil, status.Error(codes.Unauthenticated, "Invalid token")
+			}
+			switch {
+			case res.Client == "":
+				res.Client = TokenClientPrefix + client
+			case res.Client != client:
+				// A client cannot present the certificate of one identity and the token of another.
+				return nil, status.Error(codes.Unauthenticated, "Token identity does not match client certificate")
+			}
+		}
+	}
+	if res.Client == "" && requireClientCert {
+		return nil, status.Error(codes.Unauthenticated, "No verified client certificate")
+	}
+
+	newCtx := context.WithValue(ctx, &Credentials{}, res)
+	if res.IP != "" {
+		newCtx = context.WithValue(newCtx, &ExternalIP{}, res.IP)
+	}
+	if res.Client != "" {
+		newCtx = context.WithValue(newCtx, &ClientName{}, res.Client)
+	}
+	return newCtx, nil
+}
+
+// serviceSet returns a set of service names.
+func serviceSet(services []string) map[string]bool {
+	res := make(map[string]bool, len(services))
+	for _, service := range services {
+		res[service] = true
+	}
+	return res
+}
+
+// serviceName returns the name of the service from the full name of a gRPC method, of the form /service/method.
+func serviceName(fullMethod string) string {
+	parts := strings.Split(strings.TrimPrefix(fullMethod, "/"), "/")
+	return parts[0]
+}
+
+// bearerToken returns the bearer token in the authorization metadata of the request, if present.
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+	// An authorization value that is not a bearer token is returned as an empty token, so is refused.
+	if len(values[0]) < 7 || !strings.EqualFold(values[0][:7], "bearer ") {
+		return "", true
+	}
+	return strings.TrimSpace(values[0][7:]), true
+}
@@ -0,0 +1,282 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type testServerStream struct {
+	ctx context.Context
+}
+
+func (s *testServerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *testServerStream) SendHeader(metadata.MD) error { return nil }
+func (s *testServerStream) SetTrailer(metadata.MD)       {}
+func (s *testServerStream) Context() context.Context     { return s.ctx }
+func (s *testServerStream) SendMsg(interface{}) error    { return nil }
+func (s *testServerStream) RecvMsg(interface{}) error    { return nil }
+
+func TestCredentialsInterceptor(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "client1"},
+	}
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 12345}
+
+	tests := []struct {
+		name              string
+		peer              *peer.Peer
+		requestID         string
+		requireClientCert bool
+		credentials       *checker.Credentials
+		code              codes.Code
+	}{
+		{
+			name: "NoPeer",
+			code: codes.Internal,
+		},
+		{
+			name: "Verified",
+			peer: &peer.Peer{
+				Addr: addr,
+				AuthInfo: credentials.TLSInfo{
+					State: tls.ConnectionState{
+						HandshakeComplete: true,
+						PeerCertificates:  []*x509.Certificate{cert},
+						VerifiedChains:    [][]*x509.Certificate{{cert}},
+					},
+				},
+			},
+			requestID:         "abc",
+			requireClientCert: true,
+			credentials: &checker.Credentials{
+				RequestID: "abc",
+				Client:    "client1",
+				IP:        "10.0.0.1",
+			},
+		},
+		{
+			name: "Unverified",
+			peer: &peer.Peer{
+				Addr: addr,
+				AuthInfo: credentials.TLSInfo{
+					State: tls.ConnectionState{
+						HandshakeComplete: true,
+						PeerCertificates:  []*x509.Certificate{cert},
+					},
+				},
+			},
+			requireClientCert: true,
+			code:              codes.Unauthenticated,
+		},
+		{
+			name: "NoTLS",
+			peer: &peer.Peer{
+				Addr: addr,
+			},
+			requireClientCert: true,
+			code:              codes.Unauthenticated,
+		},
+		{
+			name: "NoTLSNotRequired",
+			peer: &peer.Peer{
+				Addr: addr,
+			},
+			credentials: &checker.Credentials{
+				IP: "10.0.0.1",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.peer != nil {
+				ctx = peer.NewContext(ctx, test.peer)
+			}
+			if test.requestID != "" {
+				ctx = context.WithValue(ctx, &interceptors.RequestID{}, test.requestID)
+			}
+
+			// Unary.
+			var unaryCredentials *checker.Credentials
+			interceptor := interceptors.CredentialsInterceptor("", test.requireClientCert, nil, nil)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				unaryCredentials, _ = interceptors.CredentialsFromContext(ctx)
+				return nil, nil
+			})
+
+			// Stream.
+			var streamCredentials *checker.Credentials
+			streamInterceptor := interceptors.CredentialsStreamInterceptor("", test.requireClientCert, nil, nil)
+			streamErr := streamInterceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+				streamCredentials, _ = interceptors.CredentialsFromContext(stream.Context())
+				return nil
+			})
+
+			if test.code != codes.OK {
+				assert.Equal(t, test.code, status.Code(err))
+				assert.Equal(t, test.code, status.Code(streamErr))
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, streamErr)
+			assert.Equal(t, test.credentials, unaryCredentials)
+			// Stream requests do not pass through the request ID interceptor.
+			assert.Equal(t, test.credentials.Client, streamCredentials.Client)
+			assert.Equal(t, test.credentials.IP, streamCredentials.IP)
+		})
+	}
+}
+
+type testTokenVerifier struct{}
+
+func (v *testTokenVerifier) VerifyToken(ctx context.Context, token string) (string, error) {
+	switch token {
+	case "good":
+		return "tokenclient", nil
+	case "client1":
+		return "client1", nil
+	default:
+		return "", errors.New("bad token")
+	}
+}
+
+func TestCredentialsInterceptorToken(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "client1"},
+	}
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 12345}
+	verifiedPeer := &peer.Peer{
+		Addr: addr,
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				HandshakeComplete: true,
+				PeerCertificates:  []*x509.Certificate{cert},
+				VerifiedChains:    [][]*x509.Certificate{{cert}},
+			},
+		},
+	}
+	unverifiedPeer := &peer.Peer{
+		Addr: addr,
+	}
+
+	tests := []struct {
+		name          string
+		peer          *peer.Peer
+		authorization string
+		method        string
+		client        string
+		code          codes.Code
+	}{
+		{
+			name:   "CertificateOnly",
+			peer:   verifiedPeer,
+			client: "client1",
+		},
+		{
+			name:          "CertificateAndToken",
+			peer:          verifiedPeer,
+			authorization: "Bearer client1",
+			client:        "client1",
+		},
+		{
+			name:          "CertificateAndMismatchedToken",
+			peer:          verifiedPeer,
+			authorization: "Bearer good",
+			code:          codes.Unauthenticated,
+		},
+		{
+			name:          "CertificateAndBadToken",
+			peer:          verifiedPeer,
+			authorization: "Bearer bad",
+			code:          codes.Unauthenticated,
+		},
+		{
+			name:          "TokenOnly",
+			peer:          unverifiedPeer,
+			authorization: "bearer good",
+			client:        "token:tokenclient",
+		},
+		{
+			name:          "TokenImpersonatingCertificate",
+			peer:          unverifiedPeer,
+			authorization: "Bearer client1",
+			client:        "token:client1",
+		},
+		{
+			name:          "TokenPeerService",
+			peer:          unverifiedPeer,
+			authorization: "Bearer good",
+			method:        "/dirk.reshare.v1.Reshare/Reshare",
+			code:          codes.Unauthenticated,
+		},
+		{
+			name:   "CertificatePeerService",
+			peer:   verifiedPeer,
+			method: "/dirk.reshare.v1.Reshare/Reshare",
+			client: "client1",
+		},
+		{
+			name:          "NotBearer",
+			peer:          unverifiedPeer,
+			authorization: "Basic good",
+			code:          codes.Unauthenticated,
+		},
+		{
+			name: "Neither",
+			peer: unverifiedPeer,
+			code: codes.Unauthenticated,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), test.peer)
+			if test.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", test.authorization))
+			}
+
+			var unaryCredentials *checker.Credentials
+			interceptor := interceptors.CredentialsInterceptor("", true, &testTokenVerifier{}, []string{"dirk.reshare.v1.Reshare"})
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: test.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				unaryCredentials, _ = interceptors.CredentialsFromContext(ctx)
+				return nil, nil
+			})
+			if test.code != codes.OK {
+				assert.Equal(t, test.code, status.Code(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.client, unaryCredentials.Client)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Drainer tracks in-flight requests, allowing them to complete before shutdown.
+type Drainer struct {
+	mu       sync.Mutex
+	draining bool
+	drained  chan struct{}
+	active   sync.WaitGroup
+}
+
+// Draining is a context tag for a channel that is closed when the drainer starts draining.
+type Draining struct{}
+
+// NewDrainer creates a new drainer.
+func NewDrainer() *Drainer {
+	return &Drainer{
+		drained: make(chan struct{}),
+	}
+}
+
+// start registers the start of a request, returning false if the drainer is draining.
+func (d *Drainer) start() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active.Add(1)
+	return true
+}
+
+// Drain stops new requests from being accepted and waits for in-flight requests to complete.
+// If in-flight requests have not completed by the end of the grace period an error is returned.
+func (d *Drainer) Drain(ctx context.Context, gracePeriod time.Duration) error {
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		close(d.drained)
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.active.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return errors.New("in-flight requests did not complete within grace period")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DrainInterceptor tracks incoming requests with the drainer.
+// Once the drainer is draining, new requests are rejected as unavailable.
+func DrainInterceptor(drainer *Drainer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !drainer.start() {
+			return nil, status.Error(codes.Unavailable, "Shutting down")
+		}
+		defer drainer.active.Done()
+		return handler(ctx, req)
+	}
+}
+
+// DrainStreamInterceptor tracks incoming streams with the drainer.
+// Once the drainer is draining, new streams are rejected as unavailable.  Existing streams can obtain
+// a channel that is closed when draining starts with DrainingFromContext(), and should finish their
+// in-flight work and return when it is closed.
+func DrainStreamInterceptor(drainer *Drainer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !drainer.start() {
+			return status.Error(codes.Unavailable, "Shutting down")
+		}
+		defer drainer.active.Done()
+		wrapped := grpc_middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = context.WithValue(stream.Context(), &Draining{}, (<-chan struct{})(drainer.drained))
+		return handler(srv, wrapped)
+	}
+}
+
+// DrainingFromContext returns a channel that is closed when the server starts draining.
+// If the context was not set up by the drain stream interceptor the channel is never closed.
+func DrainingFromContext(ctx context.Context) <-chan struct{} {
+	draining, _ := ctx.Value(&Draining{}).(<-chan struct{})
+	return draining
+}
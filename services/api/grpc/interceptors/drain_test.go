@@ -0,0 +1,118 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDrainInterceptor(t *testing.T) {
+	ctx := context.Background()
+	drainer := interceptors.NewDrainer()
+	interceptor := interceptors.DrainInterceptor(drainer)
+	info := &grpc.UnaryServerInfo{}
+
+	// Start a request that blocks until released.
+	started := make(chan struct{})
+	release := make(chan struct{})
+	inFlightErr := make(chan error, 1)
+	go func() {
+		_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+		inFlightErr <- err
+	}()
+	<-started
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- drainer.Drain(ctx, time.Minute)
+	}()
+
+	// Wait for draining to begin; requests started now are rejected.
+	require.Eventually(t, func() bool {
+		_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return status.Code(err) == codes.Unavailable
+	}, time.Second, time.Millisecond)
+
+	// Draining does not complete until the in-flight request does.
+	select {
+	case <-drained:
+		require.Fail(t, "drain completed with request in flight")
+	default:
+	}
+	close(release)
+	require.NoError(t, <-inFlightErr)
+	require.NoError(t, <-drained)
+}
+
+func TestDrainGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	drainer := interceptors.NewDrainer()
+	interceptor := interceptors.DrainInterceptor(drainer)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	go func() {
+		_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+	}()
+	<-started
+
+	require.EqualError(t, drainer.Drain(ctx, 10*time.Millisecond), "in-flight requests did not complete within grace period")
+}
+
+func TestDrainStreamInterceptor(t *testing.T) {
+	ctx := context.Background()
+	drainer := interceptors.NewDrainer()
+	interceptor := interceptors.DrainStreamInterceptor(drainer)
+	info := &grpc.StreamServerInfo{}
+
+	// Start a stream that runs until draining starts.
+	started := make(chan struct{})
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- interceptor(nil, &testServerStream{ctx: ctx}, info, func(srv interface{}, stream grpc.ServerStream) error {
+			close(started)
+			<-interceptors.DrainingFromContext(stream.Context())
+			return status.Error(codes.Unavailable, "Shutting down")
+		})
+	}()
+	<-started
+
+	require.NoError(t, drainer.Drain(ctx, time.Minute))
+	require.Equal(t, codes.Unavailable, status.Code(<-streamErr))
+
+	// New streams are rejected.
+	err := interceptor(nil, &testServerStream{ctx: ctx}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,51 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"google.golang.org/grpc"
+)
+
+// Names of the interceptors provided by the API server.
+const (
+	// NameDrain is the name of the interceptor that tracks requests for draining.
+	NameDrain = "drain"
+	// NameTracing is the name of the interceptor that starts a server span.
+	NameTracing = "tracing"
+	// NameTags is the name of the interceptor that adds context tags.
+	NameTags = "tags"
+	// NameRequestID is the name of the interceptor that adds a request ID.
+	NameRequestID = "request-id"
+	// NameCredentials is the name of the interceptor that authenticates the client.
+	NameCredentials = "credentials"
+	// NameRateLimit is the name of the interceptor that rate limits clients.
+	NameRateLimit = "rate-limit"
+	// NameSequence is the name of the interceptor that checks request sequence numbers.
+	NameSequence = "sequence"
+	// NameRecovery is the name of the interceptor that recovers from panics in handlers.
+	NameRecovery = "recovery"
+	// NameLogging is the name of the interceptor that logs requests.
+	NameLogging = "logging"
+)
+
+// Interceptor is a named pair of unary and stream server interceptors, allowing interceptors to be
+// ordered by name.  Either interceptor can be nil if the interceptor does not apply to that type of call.
+type Interceptor struct {
+	// Name is the name of the interceptor.
+	Name string
+	// Unary is the interceptor for unary calls.
+	Unary grpc.UnaryServerInterceptor
+	// Stream is the interceptor for streams.
+	Stream grpc.StreamServerInterceptor
+}
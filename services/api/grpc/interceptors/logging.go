@@ -0,0 +1,48 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// LoggingInterceptor logs each incoming request with its method, result code and duration at debug level.
+// If placed after the request ID interceptor the request ID is included.
+func LoggingInterceptor(log zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		started := time.Now()
+		res, err := handler(ctx, req)
+		e := log.Debug().Str("method", info.FullMethod).Str("code", status.Code(err).String()).Dur("duration", time.Since(started))
+		if requestID, isString := ctx.Value(&RequestID{}).(string); isString {
+			e = e.Str("request_id", requestID)
+		}
+		e.Msg("Handled request")
+		return res, err
+	}
+}
+
+// LoggingStreamInterceptor logs each incoming stream with its method, result code and duration at debug level.
+func LoggingStreamInterceptor(log zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		started := time.Now()
+		err := handler(srv, stream)
+		log.Debug().Str("method", info.FullMethod).Str("code", status.Code(err).String()).Dur("duration", time.Since(started)).Msg("Handled stream")
+		return err
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/services/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimit is the rate at which a client may make requests.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate of requests.
+	RequestsPerSecond float64
+	// Burst is the number of requests that may be made at once; values below 1 are treated as 1.
+	Burst int
+}
+
+// RateLimiter limits the rate of requests from each client with a token bucket per client.
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     *RateLimit
+	overrides map[string]*RateLimit
+	buckets   map[string]*bucket
+	now       func() time.Time
+}
+
+// bucket is a token bucket for a single client.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates a rate limiter with a default limit for all clients, and overrides for specific clients.
+// A nil limit leaves clients without an override unlimited.
+func NewRateLimiter(limit *RateLimit, overrides map[string]*RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:     limit,
+		overrides: overrides,
+		buckets:   make(map[string]*bucket),
+		now:       time.Now,
+	}
+}
+
+// Allow returns true if a request from the client is within its limit, consuming a token if so.
+func (r *RateLimiter) Allow(client string) bool {
+	limit := r.limit
+	if override, exists := r.overrides[client]; exists {
+		limit = override
+	}
+	if limit == nil || limit.RequestsPerSecond <= 0 {
+		return true
+	}
+
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	b, exists := r.buckets[client]
+	if !exists {
+		b = &bucket{
+			tokens:  burst,
+			updated: now,
+		}
+		r.buckets[client] = b
+	}
+	b.tokens += now.Sub(b.updated).Seconds() * limit.RequestsPerSecond
+	if b.tokens > burst {
+		b.tokens = burst
+	}
+	b.updated = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RateLimitInterceptor rejects requests from clients that exceed their rate limit.
+func RateLimitInterceptor(limiter *RateLimiter, monitor metrics.APIMonitor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkRateLimit(ctx, limiter, monitor); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor rejects messages received on streams from clients that exceed their rate limit.
+// Each message received on a stream counts as a request, so a client cannot avoid its limit by sending its
+// requests over a single stream.
+func RateLimitStreamInterceptor(limiter *RateLimiter, monitor metrics.APIMonitor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &rateLimitedServerStream{
+			ServerStream: stream,
+			limiter:      limiter,
+			monitor:      monitor,
+		})
+	}
+}
+
+// rateLimitedServerStream checks the rate limit for each message received on a stream.
+type rateLimitedServerStream struct {
+	grpc.ServerStream
+	limiter *RateLimiter
+	monitor metrics.APIMonitor
+}
+
+// RecvMsg receives a message, returning an error in its place if the client has exceeded its rate limit.
+func (s *rateLimitedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkRateLimit(s.Context(), s.limiter, s.monitor)
+}
+
+// checkRateLimit checks the rate limit for the client of the request.
+func checkRateLimit(ctx context.Context, limiter *RateLimiter, monitor metrics.APIMonitor) error {
+	// Credentials are added by the credentials interceptor, which must run before this interceptor.
+	client := ""
+	if credentials, ok := CredentialsFromContext(ctx); ok {
+		client = credentials.Client
+	}
+	if limiter.Allow(client) {
+		return nil
+	}
+	monitor.RequestThrottled(client)
+	return status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+}
@@ -0,0 +1,164 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type throttleMonitor struct {
+	throttled map[string]int
+}
+
+func (m *throttleMonitor) RequestThrottled(client string) {
+	m.throttled[client]++
+}
+
+func TestRateLimiter(t *testing.T) {
+	// A low rate ensures that tokens are not refilled during the test.
+	limiter := interceptors.NewRateLimiter(&interceptors.RateLimit{RequestsPerSecond: 0.001, Burst: 2},
+		map[string]*interceptors.RateLimit{
+			"client3": {RequestsPerSecond: 0.001, Burst: 3},
+			"client4": {},
+		},
+	)
+
+	tests := []struct {
+		name    string
+		client  string
+		allowed bool
+	}{
+		{
+			name:    "Client1First",
+			client:  "client1",
+			allowed: true,
+		},
+		{
+			name:    "Client1Second",
+			client:  "client1",
+			allowed: true,
+		},
+		{
+			name:    "Client1Exhausted",
+			client:  "client1",
+			allowed: false,
+		},
+		{
+			name:    "Client2Independent",
+			client:  "client2",
+			allowed: true,
+		},
+		{
+			name:    "Client3First",
+			client:  "client3",
+			allowed: true,
+		},
+		{
+			name:    "Client3Second",
+			client:  "client3",
+			allowed: true,
+		},
+		{
+			name:    "Client3Third",
+			client:  "client3",
+			allowed: true,
+		},
+		{
+			name:    "Client3Exhausted",
+			client:  "client3",
+			allowed: false,
+		},
+		{
+			name:    "Client4Unlimited1",
+			client:  "client4",
+			allowed: true,
+		},
+		{
+			name:    "Client4Unlimited2",
+			client:  "client4",
+			allowed: true,
+		},
+		{
+			name:    "Client4Unlimited3",
+			client:  "client4",
+			allowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.allowed, limiter.Allow(test.client))
+		})
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	limiter := interceptors.NewRateLimiter(nil, nil)
+	for i := 0; i < 100; i++ {
+		require.True(t, limiter.Allow("client1"))
+	}
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	limiter := interceptors.NewRateLimiter(&interceptors.RateLimit{RequestsPerSecond: 0.001, Burst: 1}, nil)
+	monitor := &throttleMonitor{throttled: make(map[string]int)}
+	interceptor := interceptors.RateLimitInterceptor(limiter, monitor)
+
+	ctx := context.WithValue(context.Background(), &interceptors.Credentials{}, &checker.Credentials{Client: "client1"})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, 1, monitor.throttled["client1"])
+}
+
+func TestRateLimitStreamInterceptor(t *testing.T) {
+	limiter := interceptors.NewRateLimiter(&interceptors.RateLimit{RequestsPerSecond: 0.001, Burst: 2}, nil)
+	monitor := &throttleMonitor{throttled: make(map[string]int)}
+	interceptor := interceptors.RateLimitStreamInterceptor(limiter, monitor)
+
+	ctx := context.WithValue(context.Background(), &interceptors.Credentials{}, &checker.Credentials{Client: "client1"})
+	received := 0
+	err := interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		for {
+			if err := stream.RecvMsg(nil); err != nil {
+				return err
+			}
+			received++
+		}
+	})
+	// Each message on the stream is charged, so the third message exceeds the burst.
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Equal(t, 2, received)
+	require.Equal(t, 1, monitor.throttled["client1"])
+
+	// The limit also applies to new streams from the same client.
+	err = interceptor(nil, &testServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		return stream.RecvMsg(nil)
+	})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,56 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoveryInterceptor recovers from panics in the handlers of incoming requests, logging the panic and
+// returning an internal error to the client rather than terminating the process.
+func RecoveryInterceptor(log zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(log, info.FullMethod, r)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor recovers from panics in the handlers of incoming streams.
+// See RecoveryInterceptor for details.
+func RecoveryStreamInterceptor(log zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(log, info.FullMethod, r)
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
+
+// recovered logs a recovered panic and returns the error for the client.
+func recovered(log zerolog.Logger, method string, r interface{}) error {
+	log.Error().Str("method", method).Interface("panic", r).Bytes("stack", debug.Stack()).Msg("Recovered from panic in handler")
+	return status.Error(codes.Internal, "Internal error")
+}
@@ -0,0 +1,59 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	ctx := context.Background()
+	interceptor := interceptors.RecoveryInterceptor(zerolog.Nop())
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Method"}
+
+	res, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ok", res)
+
+	_, err = interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("bad handler")
+	})
+	require.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestRecoveryStreamInterceptor(t *testing.T) {
+	ctx := context.Background()
+	interceptor := interceptors.RecoveryStreamInterceptor(zerolog.Nop())
+	info := &grpc.StreamServerInfo{FullMethod: "/test/Stream"}
+
+	err := interceptor(nil, &testServerStream{ctx: ctx}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = interceptor(nil, &testServerStream{ctx: ctx}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		panic("bad handler")
+	})
+	require.Equal(t, codes.Internal, status.Code(err))
+}
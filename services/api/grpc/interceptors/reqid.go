@@ -15,22 +15,40 @@ package interceptors
 
 import (
 	"context"
-	"fmt"
-	"math/rand"
-	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+// RequestIDMetadataKey is the key in the request and response metadata for the request ID.
+const RequestIDMetadataKey = "x-request-id"
+
+// maxRequestIDLength is the maximum length of a client-supplied request ID.
+const maxRequestIDLength = 128
+
 // RequestID is a context tag for the request ID.
 type RequestID struct{}
 
 // RequestIDInterceptor adds a request ID to incoming requests.
+// If the client supplies a request ID in the request metadata it is used, otherwise one is generated.
+// The request ID is returned to the client in the response metadata.
 func RequestIDInterceptor() grpc.UnaryServerInterceptor {
-	rand.Seed(time.Now().UnixNano())
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		// #nosec G404
-		newCtx := context.WithValue(ctx, &RequestID{}, fmt.Sprintf("%02x", rand.Int31()))
+		requestID := ""
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(RequestIDMetadataKey); len(values) > 0 && len(values[0]) <= maxRequestIDLength {
+				requestID = values[0]
+			}
+		}
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+		// Failure to set the header only occurs if there is no server transport, in which case there is nobody
+		// to receive it.
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadataKey, requestID))
+
+		newCtx := context.WithValue(ctx, &RequestID{}, requestID)
 		return handler(newCtx, req)
 	}
 }
@@ -0,0 +1,74 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRequestIDInterceptor(t *testing.T) {
+	tests := []struct {
+		name      string
+		md        metadata.MD
+		requestID string
+	}{
+		{
+			name: "None",
+		},
+		{
+			name:      "Supplied",
+			md:        metadata.Pairs(interceptors.RequestIDMetadataKey, "my-request"),
+			requestID: "my-request",
+		},
+		{
+			name: "SuppliedEmpty",
+			md:   metadata.Pairs(interceptors.RequestIDMetadataKey, ""),
+		},
+		{
+			name: "SuppliedTooLong",
+			md:   metadata.Pairs(interceptors.RequestIDMetadataKey, strings.Repeat("a", 129)),
+		},
+	}
+
+	interceptor := interceptors.RequestIDInterceptor()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+			var requestID string
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				requestID = ctx.Value(&interceptors.RequestID{}).(string)
+				return nil, nil
+			})
+			require.NoError(t, err)
+			if test.requestID != "" {
+				assert.Equal(t, test.requestID, requestID)
+			} else {
+				_, err := uuid.Parse(requestID)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
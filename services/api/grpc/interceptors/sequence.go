@@ -0,0 +1,60 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/attestantio/dirk/services/checker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// SequenceMetadataKey is the key in the request metadata for the client's sequence number.
+const SequenceMetadataKey = "x-sequence-number"
+
+// SequenceInterceptor checks the sequence number supplied with incoming requests.
+// The session is the connection from which the request was received, so a client that reconnects starts a new
+// sequence.  Requests whose sequence number is refused by the checker are rejected.
+func SequenceInterceptor(sequenceChecker checker.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		sequence := uint64(0)
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(SequenceMetadataKey); len(values) > 0 {
+				var err error
+				sequence, err = strconv.ParseUint(values[0], 10, 64)
+				if err != nil {
+					return nil, status.Error(codes.InvalidArgument, "Invalid sequence number")
+				}
+			}
+		}
+
+		session := ""
+		if grpcPeer, ok := peer.FromContext(ctx); ok && grpcPeer.Addr != nil {
+			session = grpcPeer.Addr.String()
+		}
+
+		// Credentials are added by the credentials interceptor, which must run before this interceptor.
+		credentials, _ := CredentialsFromContext(ctx)
+		if !sequenceChecker.CheckSequence(ctx, credentials, session, sequence) {
+			return nil, status.Error(codes.PermissionDenied, "Sequence number refused")
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestSequenceInterceptor(t *testing.T) {
+	ctx := context.Background()
+	sequenceChecker, err := staticchecker.New(ctx, staticchecker.WithSequenceClients([]string{"client1"}))
+	require.NoError(t, err)
+	interceptor := interceptors.SequenceInterceptor(sequenceChecker)
+
+	session1 := &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 10000}}
+	session2 := &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 10001}}
+
+	tests := []struct {
+		name     string
+		client   string
+		session  *peer.Peer
+		sequence string
+		code     codes.Code
+	}{
+		{
+			name:     "First",
+			client:   "client1",
+			session:  session1,
+			sequence: "5",
+			code:     codes.OK,
+		},
+		{
+			name:     "InOrder",
+			client:   "client1",
+			session:  session1,
+			sequence: "6",
+			code:     codes.OK,
+		},
+		{
+			name:     "Gap",
+			client:   "client1",
+			session:  session1,
+			sequence: "10",
+			code:     codes.OK,
+		},
+		{
+			name:     "Repeated",
+			client:   "client1",
+			session:  session1,
+			sequence: "10",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "OutOfOrder",
+			client:   "client1",
+			session:  session1,
+			sequence: "8",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:    "Missing",
+			client:  "client1",
+			session: session1,
+			code:    codes.PermissionDenied,
+		},
+		{
+			name:     "Invalid",
+			client:   "client1",
+			session:  session1,
+			sequence: "bad",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "NewSession",
+			client:   "client1",
+			session:  session2,
+			sequence: "1",
+			code:     codes.OK,
+		},
+		{
+			name:     "NewSessionInOrder",
+			client:   "client1",
+			session:  session2,
+			sequence: "2",
+			code:     codes.OK,
+		},
+		{
+			name:    "NotRequired",
+			client:  "client2",
+			session: session1,
+			code:    codes.OK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), test.session)
+			ctx = context.WithValue(ctx, &interceptors.Credentials{}, &checker.Credentials{Client: test.client})
+			if test.sequence != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(interceptors.SequenceMetadataKey, test.sequence))
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			})
+			require.Equal(t, test.code, status.Code(err))
+		})
+	}
+}
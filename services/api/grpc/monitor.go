@@ -16,3 +16,6 @@ package grpc
 // noopMonitor is a monitor that does nothing, used in place of nil if an
 // external monitor is not supplied.
 type noopMonitor struct{}
+
+// RequestThrottled is called when a request from a client is rejected by the rate limiter.
+func (m *noopMonitor) RequestThrottled(client string) {}
@@ -14,12 +14,25 @@
 package grpc
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/backup"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/consensus"
+	"github.com/attestantio/dirk/services/fetcher"
 	"github.com/attestantio/dirk/services/lister"
+	"github.com/attestantio/dirk/services/locker"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/attestantio/dirk/services/peers"
 	"github.com/attestantio/dirk/services/process"
+	"github.com/attestantio/dirk/services/revocationchecker"
+	"github.com/attestantio/dirk/services/sender"
 	"github.com/attestantio/dirk/services/signer"
+	"github.com/attestantio/dirk/services/tokenverifier"
 	"github.com/attestantio/dirk/services/walletmanager"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -29,17 +42,38 @@ type parameters struct {
 	logLevel       zerolog.Level
 	monitor        metrics.APIMonitor
 	peers          peers.Service
+	consensus      consensus.Service
 	process        process.Service
 	accountManager accountmanager.Service
 	walletManager  walletmanager.Service
 	lister         lister.Service
 	signer         signer.Service
+	locker         locker.Service
+	checker        checker.Service
+	rules          rules.Service
+	genesisRoot    []byte
+	adminClients   []string
 	name           string
 	listenAddress  string
 	id             uint64
 	serverCert     []byte
 	serverKey      []byte
 	caCert         []byte
+	identitySAN    string
+	tokenVerifier  tokenverifier.Service
+	optionalCert   bool
+	revocation     revocationchecker.Service
+	maxBatchSize   int
+	rateLimiter    *interceptors.RateLimiter
+	structuredErrs bool
+	backup         backup.Service
+	fetcher        fetcher.Service
+	sender         sender.Service
+	healthInterval time.Duration
+	// interceptorOrder is the order in which interceptors run, by name.
+	interceptorOrder []string
+	// interceptors are additional interceptors supplied by the caller.
+	interceptors []*interceptors.Interceptor
 }
 
 // Parameter is the interface for service parameters.
@@ -74,6 +108,52 @@ func WithLister(lister lister.Service) Parameter {
 	})
 }
 
+// WithConsensus sets the slashing protection consensus service for this module.
+// If this is not supplied peers cannot propose slashing protection high-water marks.
+func WithConsensus(consensus consensus.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.consensus = consensus
+	})
+}
+
+// WithChecker sets the checker for this module.
+// If set, sequence numbers supplied with requests are checked.
+func WithChecker(checker checker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checker = checker
+	})
+}
+
+// WithLocker sets the locker for this module.
+func WithLocker(locker locker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.locker = locker
+	})
+}
+
+// WithAdminClients sets the clients that are allowed to make administrative requests.
+// If this is not supplied the admin API is not available.
+func WithAdminClients(clients []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.adminClients = clients
+	})
+}
+
+// WithRules sets the rules for this module, used by the admin API to export and import slashing protection.
+func WithRules(rules rules.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rules = rules
+	})
+}
+
+// WithGenesisValidatorsRoot sets the genesis validators root of the chain, used by the admin API to export
+// and import slashing protection.  If this is not supplied slashing protection is not available over the API.
+func WithGenesisValidatorsRoot(root []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisRoot = root
+	})
+}
+
 // WithProcess sets the process for this module.
 func WithProcess(process process.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -151,10 +231,115 @@ func WithCACert(caCert []byte) Parameter {
 	})
 }
 
+// WithClientIdentitySAN sets the subject alternative name field of client certificates from which
+// to obtain the client identity, if present.  If not set the client identity is the certificate's common name.
+func WithClientIdentitySAN(identitySAN string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.identitySAN = identitySAN
+	})
+}
+
+// WithTokenVerifier sets the verifier for bearer tokens presented by clients.  If set, clients may identify
+// themselves with a token in place of the identity in their client certificate.
+func WithTokenVerifier(tokenVerifier tokenverifier.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.tokenVerifier = tokenVerifier
+	})
+}
+
+// WithRevocationChecker sets the checker for revocation of client certificates.  If not set client
+// certificates are not checked for revocation.
+func WithRevocationChecker(revocation revocationchecker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.revocation = revocation
+	})
+}
+
+// WithOptionalClientCert allows clients to connect without a client certificate, in which case they must
+// present a token.  This requires a token verifier.
+func WithOptionalClientCert(optionalCert bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.optionalCert = optionalCert
+	})
+}
+
+// WithMaxBatchSize sets the maximum number of requests in a single multisign request.  Larger requests
+// are refused with a resource exhausted error.  If this is 0 then the size of requests is not limited
+// by the API, although the ruler applies its own limit.
+func WithMaxBatchSize(maxBatchSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxBatchSize = maxBatchSize
+	})
+}
+
+// WithBackup sets the backup service used by the admin API to back up slashing protection.
+func WithBackup(backup backup.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backup = backup
+	})
+}
+
+// WithFetcher sets the fetcher whose health is reported by the health service.
+// If this or the rules are not supplied the health service is not provided.
+func WithFetcher(fetcher fetcher.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.fetcher = fetcher
+	})
+}
+
+// WithSender sets the sender used by the health service to check that peers are reachable.
+// If this is not supplied the health of peers is not reported.
+func WithSender(sender sender.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.sender = sender
+	})
+}
+
+// WithHealthCheckInterval sets the interval between checks of the health service.
+func WithHealthCheckInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.healthInterval = interval
+	})
+}
+
+// WithRateLimiter sets the rate limiter for requests.  Requests from clients that exceed their rate limit are
+// refused with a resource exhausted error before they reach the handlers.  If not supplied requests are not limited.
+func WithRateLimiter(rateLimiter *interceptors.RateLimiter) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rateLimiter = rateLimiter
+	})
+}
+
+// WithInterceptorOrder sets the order in which the interceptors run, by name.  The names are those of the
+// interceptors provided by the server, as per the interceptors package, and of any additional interceptors.
+// The drain and credentials interceptors must be included.  If this is not supplied the interceptors
+// provided by the server run in their default order, followed by any additional interceptors.
+func WithInterceptorOrder(order []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interceptorOrder = order
+	})
+}
+
+// WithInterceptors sets additional interceptors, allowing embedders to add their own.
+func WithInterceptors(interceptors []*interceptors.Interceptor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interceptors = interceptors
+	})
+}
+
+// WithStructuredErrors returns a gRPC status error in place of the response for unary signing requests that
+// do not succeed, with a status code and details that depend on the reason the request was not approved.
+func WithStructuredErrors(structuredErrors bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.structuredErrs = structuredErrors
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:       zerolog.GlobalLevel(),
+		healthInterval: 10 * time.Second,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -199,6 +384,36 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if len(parameters.serverKey) == 0 {
 		return nil, errors.New("no server key specified")
 	}
+	if parameters.optionalCert && parameters.tokenVerifier == nil {
+		return nil, errors.New("client certificates can only be optional with a token verifier")
+	}
+	if parameters.healthInterval <= 0 {
+		return nil, errors.New("health check interval must be positive")
+	}
+	if parameters.maxBatchSize < 0 {
+		return nil, errors.New("max batch size cannot be negative")
+	}
+	switch parameters.identitySAN {
+	case "", interceptors.ClientIdentitySANURI, interceptors.ClientIdentitySANDNS:
+	default:
+		return nil, fmt.Errorf("unsupported client identity SAN %q", parameters.identitySAN)
+	}
+	interceptorNames := make(map[string]bool, len(parameters.interceptors))
+	for _, interceptor := range parameters.interceptors {
+		if interceptor == nil {
+			return nil, errors.New("nil interceptor specified")
+		}
+		if interceptor.Name == "" {
+			return nil, errors.New("interceptor has no name")
+		}
+		if builtinInterceptors[interceptor.Name] || interceptorNames[interceptor.Name] {
+			return nil, fmt.Errorf("duplicate interceptor %q", interceptor.Name)
+		}
+		if interceptor.Unary == nil && interceptor.Stream == nil {
+			return nil, fmt.Errorf("interceptor %q has no functions", interceptor.Name)
+		}
+		interceptorNames[interceptor.Name] = true
+	}
 
 	return &parameters, nil
 }
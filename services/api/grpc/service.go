@@ -15,17 +15,22 @@ package grpc
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
 	"net"
+	"time"
 
 	accountmanagerhandler "github.com/attestantio/dirk/services/api/grpc/handlers/accountmanager"
+	adminhandler "github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	consensushandler "github.com/attestantio/dirk/services/api/grpc/handlers/consensus"
+	healthhandler "github.com/attestantio/dirk/services/api/grpc/handlers/health"
 	listerhandler "github.com/attestantio/dirk/services/api/grpc/handlers/lister"
 	receiverhandler "github.com/attestantio/dirk/services/api/grpc/handlers/receiver"
 	signerhandler "github.com/attestantio/dirk/services/api/grpc/handlers/signer"
 	walletmanagerhandler "github.com/attestantio/dirk/services/api/grpc/handlers/walletmanager"
 	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/revocationchecker"
+	"github.com/attestantio/dirk/services/tokenverifier"
 	"github.com/attestantio/dirk/util/loggers"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
@@ -40,13 +45,24 @@ import (
 
 // Service provides the features and functions for the GRPC daemon.
 type Service struct {
-	monitor    metrics.APIMonitor
-	grpcServer *grpc.Server
+	monitor      metrics.APIMonitor
+	grpcServer   *grpc.Server
+	certificates *certificateManager
+	drainer      *interceptors.Drainer
+	health       *healthhandler.Handler
 }
 
 // module-wide log.
 var log zerolog.Logger
 
+// peerServices are the services used only between Dirk instances, which identify peers by their certificates alone.
+var peerServices = []string{
+	"v1.DKG",
+	"dirk.reshare.v1.Reshare",
+	"dirk.recover.v1.Recover",
+	"dirk.consensus.v1.Consensus",
+}
+
 // New creates a new API service over GRPC.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -64,7 +80,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		monitor: parameters.monitor,
 	}
 
-	if err := s.createServer(parameters.name, parameters.serverCert, parameters.serverKey, parameters.caCert); err != nil {
+	if err := s.createServer(parameters.name, parameters.serverCert, parameters.serverKey, parameters.caCert, parameters.identitySAN, parameters.tokenVerifier, parameters.optionalCert, parameters.revocation, parameters.checker, parameters.rateLimiter, parameters.interceptorOrder, parameters.interceptors); err != nil {
 		return nil, errors.Wrap(err, "failed to create API server")
 	}
 
@@ -99,12 +115,16 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	signerHandler, err := signerhandler.New(ctx,
 		signerhandler.WithSigner(parameters.signer),
+		signerhandler.WithMaxBatchSize(parameters.maxBatchSize),
+		signerhandler.WithStructuredErrors(parameters.structuredErrs),
 		signerhandler.WithLogLevel(parameters.logLevel),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create signer handler")
 	}
 	pb.RegisterSignerServer(s.grpcServer, signerHandler)
+	signerhandler.RegisterStream(s.grpcServer, signerHandler)
+	signerhandler.RegisterMultisign(s.grpcServer, signerHandler)
 
 	receiverHandler, err := receiverhandler.New(ctx,
 		receiverhandler.WithLogLevel(parameters.logLevel),
@@ -115,6 +135,54 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.Wrap(err, "failed to create receiver handler")
 	}
 	pb.RegisterDKGServer(s.grpcServer, receiverHandler)
+	receiverhandler.RegisterReshare(s.grpcServer, receiverHandler)
+	receiverhandler.RegisterRecover(s.grpcServer, receiverHandler)
+
+	if parameters.consensus != nil {
+		consensusHandler, err := consensushandler.New(ctx,
+			consensushandler.WithLogLevel(parameters.logLevel),
+			consensushandler.WithConsensus(parameters.consensus),
+			consensushandler.WithPeers(parameters.peers),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create consensus handler")
+		}
+		consensushandler.Register(s.grpcServer, consensusHandler)
+	}
+
+	if len(parameters.adminClients) > 0 {
+		adminHandler, err := adminhandler.New(ctx,
+			adminhandler.WithLogLevel(parameters.logLevel),
+			adminhandler.WithLocker(parameters.locker),
+			adminhandler.WithAccountManager(parameters.accountManager),
+			adminhandler.WithChecker(parameters.checker),
+			adminhandler.WithRules(parameters.rules),
+			adminhandler.WithProcess(parameters.process),
+			adminhandler.WithBackup(parameters.backup),
+			adminhandler.WithGenesisValidatorsRoot(parameters.genesisRoot),
+			adminhandler.WithClients(parameters.adminClients),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create admin handler")
+		}
+		adminhandler.Register(s.grpcServer, adminHandler)
+	}
+
+	if parameters.fetcher != nil && parameters.rules != nil {
+		s.health, err = healthhandler.New(ctx,
+			healthhandler.WithLogLevel(parameters.logLevel),
+			healthhandler.WithFetcher(parameters.fetcher),
+			healthhandler.WithRules(parameters.rules),
+			healthhandler.WithPeers(parameters.peers),
+			healthhandler.WithSender(parameters.sender),
+			healthhandler.WithID(parameters.id),
+			healthhandler.WithInterval(parameters.healthInterval),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create health handler")
+		}
+		healthhandler.Register(s.grpcServer, s.health)
+	}
 
 	err = s.serve(parameters.listenAddress)
 	if err != nil {
@@ -131,48 +199,124 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 }
 
 // createServer creates the GRPC server.
-func (s *Service) createServer(name string, certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) error {
+func (s *Service) createServer(name string,
+	certPEMBlock []byte,
+	keyPEMBlock []byte,
+	caPEMBlock []byte,
+	identitySAN string,
+	tokenVerifier tokenverifier.Service,
+	optionalCert bool,
+	revocation revocationchecker.Service,
+	sequenceChecker checker.Service,
+	rateLimiter *interceptors.RateLimiter,
+	interceptorOrder []string,
+	additionalInterceptors []*interceptors.Interceptor,
+) error {
 	grpclog.SetLoggerV2(loggers.NewGRPCLoggerV2(log.With().Str("service", "grpc").Logger()))
 
+	s.drainer = interceptors.NewDrainer()
+	availableInterceptors := []*interceptors.Interceptor{
+		{
+			Name:   interceptors.NameDrain,
+			Unary:  interceptors.DrainInterceptor(s.drainer),
+			Stream: interceptors.DrainStreamInterceptor(s.drainer),
+		},
+		{
+			Name:  interceptors.NameTracing,
+			Unary: interceptors.TracingInterceptor(),
+		},
+		{
+			Name:   interceptors.NameTags,
+			Unary:  grpc_ctxtags.UnaryServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
+			Stream: grpc_ctxtags.StreamServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
+		},
+		{
+			Name:  interceptors.NameRequestID,
+			Unary: interceptors.RequestIDInterceptor(),
+		},
+		{
+			Name:   interceptors.NameCredentials,
+			Unary:  interceptors.CredentialsInterceptor(identitySAN, true, tokenVerifier, peerServices),
+			Stream: interceptors.CredentialsStreamInterceptor(identitySAN, true, tokenVerifier, peerServices),
+		},
+		{
+			Name:   interceptors.NameRecovery,
+			Unary:  interceptors.RecoveryInterceptor(log),
+			Stream: interceptors.RecoveryStreamInterceptor(log),
+		},
+		{
+			Name:   interceptors.NameLogging,
+			Unary:  interceptors.LoggingInterceptor(log),
+			Stream: interceptors.LoggingStreamInterceptor(log),
+		},
+	}
+	if rateLimiter != nil {
+		availableInterceptors = append(availableInterceptors, &interceptors.Interceptor{
+			Name:   interceptors.NameRateLimit,
+			Unary:  interceptors.RateLimitInterceptor(rateLimiter, s.monitor),
+			Stream: interceptors.RateLimitStreamInterceptor(rateLimiter, s.monitor),
+		})
+	}
+	if sequenceChecker != nil {
+		availableInterceptors = append(availableInterceptors, &interceptors.Interceptor{
+			Name:  interceptors.NameSequence,
+			Unary: interceptors.SequenceInterceptor(sequenceChecker),
+		})
+	}
+	unaryInterceptors, streamInterceptors, err := chainInterceptors(interceptorOrder, availableInterceptors, additionalInterceptors)
+	if err != nil {
+		return err
+	}
 	grpcOpts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(
-			grpc_middleware.ChainUnaryServer(
-				grpc_ctxtags.UnaryServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
-				interceptors.RequestIDInterceptor(),
-				interceptors.SourceIPInterceptor(),
-				interceptors.ClientInfoInterceptor(),
-			)),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(unaryInterceptors...)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(streamInterceptors...)),
 	}
 
 	if name == "" {
 		return errors.New("no server name provided; cannot proceed")
 	}
 
-	serverCert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	certificates, err := newCertificateManager(certPEMBlock, keyPEMBlock, caPEMBlock, optionalCert, revocation)
 	if err != nil {
-		return errors.Wrap(err, "failed to load server keypair")
-	}
-
-	certPool := x509.NewCertPool()
-	if len(caPEMBlock) > 0 {
-		// Read in the certificate authority certificate; this is required to validate client certificates on incoming connections.
-		if ok := certPool.AppendCertsFromPEM(caPEMBlock); !ok {
-			return errors.New("could not add CA certificate to pool")
-		}
+		return err
 	}
+	s.certificates = certificates
 
-	serverCreds := credentials.NewTLS(&tls.Config{
-		ClientAuth:   tls.RequireAndVerifyClientCert,
-		Certificates: []tls.Certificate{serverCert},
-		ClientCAs:    certPool,
-		MinVersion:   tls.VersionTLS13,
-	})
+	serverCreds := credentials.NewTLS(certificates.tlsConfig())
 	grpcOpts = append(grpcOpts, grpc.Creds(serverCreds))
 	s.grpcServer = grpc.NewServer(grpcOpts...)
 
 	return nil
 }
 
+// ReloadCertificates replaces the server certificate, key and client CA certificate.
+// Existing connections are unaffected; new connections use the replacement material.
+// If the replacement material is invalid an error is returned and the existing material is retained.
+func (s *Service) ReloadCertificates(ctx context.Context, certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) error {
+	if err := s.certificates.reload(certPEMBlock, keyPEMBlock, caPEMBlock); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload certificates; retaining existing certificates")
+		return err
+	}
+	log.Info().Msg("Reloaded certificates")
+	return nil
+}
+
+// Drain stops the server accepting new requests and waits for in-flight requests to complete, up to the
+// grace period.  Requests received after draining starts are rejected as unavailable.
+func (s *Service) Drain(ctx context.Context, gracePeriod time.Duration) error {
+	log.Info().Dur("grace_period", gracePeriod).Msg("Draining in-flight requests")
+	if s.health != nil {
+		// Report as not serving first, so that load balancers stop sending requests.
+		s.health.Shutdown()
+	}
+	if err := s.drainer.Drain(ctx, gracePeriod); err != nil {
+		log.Warn().Err(err).Msg("Failed to drain in-flight requests")
+		return err
+	}
+	log.Info().Msg("Drained in-flight requests")
+	return nil
+}
+
 // Serve serves the GRPC server.
 func (s *Service) serve(listenAddress string) error {
 	conn, err := net.Listen("tcp", listenAddress)
@@ -0,0 +1,348 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/go-bytesutil"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// keystore is a keystore as listed by the keymanager API.
+type keystore struct {
+	ValidatingPubKey string `json:"validating_pubkey"`
+	DerivationPath   string `json:"derivation_path,omitempty"`
+	ReadOnly         bool   `json:"readonly"`
+}
+
+// keystoreStatus is the status of an individual keystore in an import or delete request.
+type keystoreStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type listKeystoresResponse struct {
+	Data []*keystore `json:"data"`
+}
+
+type importKeystoresRequest struct {
+	Keystores          []string `json:"keystores"`
+	Passwords          []string `json:"passwords"`
+	SlashingProtection string   `json:"slashing_protection,omitempty"`
+}
+
+type importKeystoresResponse struct {
+	Data []*keystoreStatus `json:"data"`
+}
+
+type deleteKeystoresRequest struct {
+	PubKeys []string `json:"pubkeys"`
+}
+
+type deleteKeystoresResponse struct {
+	Data               []*keystoreStatus `json:"data"`
+	SlashingProtection string            `json:"slashing_protection"`
+}
+
+// keymanagerError is an error response from the keymanager API.
+type keymanagerError struct {
+	Message string `json:"message"`
+}
+
+// keystores handles requests to the keymanager API keystores endpoint.
+func (s *Service) keystores(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodPost, http.MethodDelete:
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, &keymanagerError{Message: "Method not allowed"})
+		return
+	}
+	credentials, ok := s.credentials(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.listKeystores(w, r, credentials)
+	case http.MethodPost:
+		s.importKeystores(w, r, credentials)
+	case http.MethodDelete:
+		s.deleteKeystores(w, r, credentials)
+	}
+}
+
+// listKeystores lists the keystores that the client can access.
+// Distributed accounts are not listed, as they cannot generate a full signature on their own.
+func (s *Service) listKeystores(w http.ResponseWriter, r *http.Request, credentials *checker.Credentials) {
+	paths := s.wallets
+	if !contains(paths, s.importWallet) {
+		paths = append(append(make([]string, 0, len(paths)+1), paths...), s.importWallet)
+	}
+	result, accounts := s.lister.ListAccounts(r.Context(), credentials, paths)
+	switch result {
+	case core.ResultSucceeded:
+	case core.ResultDenied:
+		writeJSON(w, http.StatusForbidden, &keymanagerError{Message: "Denied"})
+		return
+	default:
+		writeJSON(w, http.StatusInternalServerError, &keymanagerError{Message: "Failed"})
+		return
+	}
+
+	res := &listKeystoresResponse{
+		Data: make([]*keystore, 0, len(accounts)),
+	}
+	for _, account := range accounts {
+		if _, isDistributed := account.(e2wtypes.DistributedAccount); isDistributed {
+			continue
+		}
+		pubKeyProvider, isProvider := account.(e2wtypes.AccountPublicKeyProvider)
+		if !isProvider {
+			continue
+		}
+		entry := &keystore{
+			ValidatingPubKey: fmt.Sprintf("%#x", pubKeyProvider.PublicKey().Marshal()),
+		}
+		if pathProvider, isProvider := account.(e2wtypes.AccountPathProvider); isProvider {
+			entry.DerivationPath = pathProvider.Path()
+		}
+		res.Data = append(res.Data, entry)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// importKeystores imports keystores, along with any slashing protection supplied for them.
+// Slashing protection can only be supplied by administrative clients.
+func (s *Service) importKeystores(w http.ResponseWriter, r *http.Request, credentials *checker.Credentials) {
+	req := &importKeystoresRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &keymanagerError{Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+	if len(req.Keystores) != len(req.Passwords) {
+		writeJSON(w, http.StatusBadRequest, &keymanagerError{Message: "Number of keystores and passwords differ"})
+		return
+	}
+
+	var protection map[[48]byte]*rules.SlashingProtection
+	if req.SlashingProtection != "" {
+		if !s.fromAdmin(credentials) {
+			log.Debug().Str("client", credentials.Client).Msg("Slashing protection supplied by non-administrative client")
+			writeJSON(w, http.StatusForbidden, &keymanagerError{Message: "Slashing protection can only be imported by administrative clients"})
+			return
+		}
+		var interchange rules.Interchange
+		if err := json.Unmarshal([]byte(req.SlashingProtection), &interchange); err != nil {
+			writeJSON(w, http.StatusBadRequest, &keymanagerError{Message: fmt.Sprintf("Invalid slashing protection: %v", err)})
+			return
+		}
+		var err error
+		protection, err = interchange.SlashingProtection(s.genesisRoot)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, &keymanagerError{Message: fmt.Sprintf("Invalid slashing protection: %v", err)})
+			return
+		}
+	}
+
+	res := &importKeystoresResponse{
+		Data: make([]*keystoreStatus, len(req.Keystores)),
+	}
+	for i := range req.Keystores {
+		res.Data[i] = s.importKeystore(r.Context(), credentials, req.Keystores[i], req.Passwords[i], protection)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// importKeystore imports a single keystore, along with any slashing protection for its key.
+func (s *Service) importKeystore(ctx context.Context,
+	credentials *checker.Credentials,
+	keystore string,
+	password string,
+	protection map[[48]byte]*rules.SlashingProtection,
+) *keystoreStatus {
+	result, pubKey, err := s.accountManager.Import(ctx, credentials, s.importWallet, []byte(keystore), []byte(password))
+	switch {
+	case result == core.ResultSucceeded:
+	case errors.Is(err, accountmanager.ErrAccountExists):
+		return &keystoreStatus{Status: "duplicate"}
+	case err != nil:
+		return &keystoreStatus{Status: "error", Message: err.Error()}
+	case result == core.ResultDenied:
+		return &keystoreStatus{Status: "error", Message: "Denied"}
+	default:
+		return &keystoreStatus{Status: "error", Message: "Failed"}
+	}
+
+	// Only the slashing protection for a key imported by this request is merged, so that a request cannot
+	// alter the protection of keys it does not supply.
+	key := bytesutil.ToBytes48(pubKey)
+	keyProtection, exists := protection[key]
+	if !exists {
+		return &keystoreStatus{Status: "imported"}
+	}
+	retained, err := s.rules.MergeSlashingProtection(ctx, map[[48]byte]*rules.SlashingProtection{key: keyProtection})
+	if err != nil {
+		log.Warn().Err(err).Str("pubkey", fmt.Sprintf("%#x", pubKey)).Msg("Failed to import slashing protection")
+		// The key must not sign without its protection, so pause signing until the protection is imported.
+		accountName := fmt.Sprintf("%s/%#x", s.importWallet, pubKey)
+		if result, err := s.accountManager.PauseSigning(ctx, credentials, accountName); err != nil || result != core.ResultSucceeded {
+			log.Error().Err(err).Str("account", accountName).Stringer("result", result).Msg("Failed to pause signing for account without slashing protection")
+		}
+		return &keystoreStatus{Status: "error", Message: "Failed to import slashing protection"}
+	}
+	if len(retained) > 0 {
+		log.Debug().Str("pubkey", fmt.Sprintf("%#x", pubKey)).Msg("Existing slashing protection contains newer data; not importing")
+	}
+
+	return &keystoreStatus{Status: "imported"}
+}
+
+// deleteKeystores deletes keystores, returning the slashing protection for the deleted keys.
+// Keys cannot be removed from their stores, so deletion pauses signing for the account and locks it.
+func (s *Service) deleteKeystores(w http.ResponseWriter, r *http.Request, credentials *checker.Credentials) {
+	req := &deleteKeystoresRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &keymanagerError{Message: fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+
+	// Every key is deactivated before slashing protection is exported, so that the export includes anything
+	// signed by the keys up to the point at which they stopped signing.
+	res := &deleteKeystoresResponse{
+		Data: make([]*keystoreStatus, len(req.PubKeys)),
+	}
+	keys := make([][48]byte, len(req.PubKeys))
+	for i := range req.PubKeys {
+		pubKey, err := hex.DecodeString(strings.TrimPrefix(req.PubKeys[i], "0x"))
+		if err != nil || len(pubKey) != 48 {
+			res.Data[i] = &keystoreStatus{Status: "error", Message: "Invalid public key"}
+			continue
+		}
+		keys[i] = bytesutil.ToBytes48(pubKey)
+		res.Data[i] = s.deleteKeystore(r.Context(), credentials, pubKey)
+	}
+
+	protection, err := s.rules.ExportSlashingProtection(r.Context())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain slashing protection")
+		writeJSON(w, http.StatusInternalServerError, &keymanagerError{Message: "Failed to obtain slashing protection"})
+		return
+	}
+
+	exported := make(map[[48]byte]*rules.SlashingProtection)
+	for i := range req.PubKeys {
+		keyProtection, exists := protection[keys[i]]
+		switch res.Data[i].Status {
+		case "deleted":
+		case "not_found":
+			// Slashing protection for keys that are not held is only released to administrative clients.
+			if !exists || !s.fromAdmin(credentials) {
+				continue
+			}
+			res.Data[i] = &keystoreStatus{Status: "not_active"}
+		default:
+			continue
+		}
+		if exists {
+			exported[keys[i]] = keyProtection
+		}
+	}
+
+	interchange, err := rules.NewInterchange(s.genesisRoot, exported)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to create interchange data")
+		writeJSON(w, http.StatusInternalServerError, &keymanagerError{Message: "Failed to create slashing protection"})
+		return
+	}
+	data, err := json.Marshal(interchange)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode interchange data")
+		writeJSON(w, http.StatusInternalServerError, &keymanagerError{Message: "Failed to create slashing protection"})
+		return
+	}
+	res.SlashingProtection = string(data)
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// deleteKeystore deletes a single keystore, pausing signing and locking the account.
+func (s *Service) deleteKeystore(ctx context.Context,
+	credentials *checker.Credentials,
+	pubKey []byte,
+) *keystoreStatus {
+	wallet, account, err := s.fetcher.FetchAccountByKey(ctx, pubKey)
+	if err != nil {
+		return &keystoreStatus{Status: "not_found"}
+	}
+	accountName := fmt.Sprintf("%s/%s", wallet.Name(), account.Name())
+
+	result, err := s.accountManager.PauseSigning(ctx, credentials, accountName)
+	switch {
+	case err != nil:
+		return &keystoreStatus{Status: "error", Message: err.Error()}
+	case result == core.ResultDenied:
+		return &keystoreStatus{Status: "error", Message: "Denied"}
+	case result != core.ResultSucceeded:
+		return &keystoreStatus{Status: "error", Message: "Failed"}
+	}
+	// Signing is already paused, so failing to lock the account does not stop it being deleted.
+	if result, err := s.accountManager.Lock(ctx, credentials, accountName); err != nil || result != core.ResultSucceeded {
+		log.Debug().Err(err).Str("account", accountName).Stringer("result", result).Msg("Failed to lock deleted account")
+	}
+	log.Info().Str("account", accountName).Msg("Deleted keystore")
+
+	return &keystoreStatus{Status: "deleted"}
+}
+
+// fromAdmin returns true if the request is from an administrative client.
+func (s *Service) fromAdmin(credentials *checker.Credentials) bool {
+	return s.adminClients[credentials.Client]
+}
+
+// writeJSON writes a JSON response with the given status.
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	res, err := json.Marshal(data)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode response")
+		http.Error(w, "Failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(res)
+}
+
+// contains returns true if the list of values contains the value.
+func contains(values []string, value string) bool {
+	for i := range values {
+		if values[i] == value {
+			return true
+		}
+	}
+	return false
+}
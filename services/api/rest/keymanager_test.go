@@ -0,0 +1,327 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/accountmanager"
+	standardaccountmanager "github.com/attestantio/dirk/services/accountmanager/standard"
+	"github.com/attestantio/dirk/services/api/rest"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	standardlister "github.com/attestantio/dirk/services/lister/standard"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	mockprocess "github.com/attestantio/dirk/services/process/mock"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	mocksigner "github.com/attestantio/dirk/services/signer/mock"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/attestantio/dirk/testing/resources"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/go-bytesutil"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	nd "github.com/wealdtech/go-eth2-wallet-nd/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+type keystoreStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// keymanagerRequest sends a request to the keymanager API, decoding the response in to res.
+func keymanagerRequest(t *testing.T, client *http.Client, method string, url string, body string, res interface{}) int {
+	req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	if res != nil && resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(res))
+	}
+	return resp.StatusCode
+}
+
+func newKeystore(t *testing.T, key *e2types.BLSPrivateKey, passphrase string) string {
+	crypto, err := keystorev4.New().Encrypt(key.Marshal(), passphrase)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]interface{}{
+		"crypto":  crypto,
+		"pubkey":  fmt.Sprintf("%x", key.PublicKey().Marshal()),
+		"version": 4,
+	})
+	require.NoError(t, err)
+	return string(data)
+}
+
+// orderRecorder records the order in which accounts are paused and slashing protection is exported.
+type orderRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *orderRecorder) record(event string) {
+	o.mu.Lock()
+	o.events = append(o.events, event)
+	o.mu.Unlock()
+}
+
+type recordingAccountManager struct {
+	accountmanager.Service
+	recorder *orderRecorder
+}
+
+func (a *recordingAccountManager) PauseSigning(ctx context.Context, credentials *checker.Credentials, account string) (core.Result, error) {
+	a.recorder.record("pause")
+	return a.Service.PauseSigning(ctx, credentials, account)
+}
+
+type recordingRules struct {
+	rules.Service
+	recorder *orderRecorder
+}
+
+func (r *recordingRules) ExportSlashingProtection(ctx context.Context) (map[[48]byte]*rules.SlashingProtection, error) {
+	r.recorder.record("export")
+	return r.Service.ExportSlashingProtection(ctx)
+}
+
+func TestKeymanager(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	_, err = nd.CreateWallet(ctx, "Imports", store, keystorev4.New())
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	checkerSvc, err := staticchecker.New(ctx, staticchecker.WithPermissions(map[string][]*checker.Permissions{
+		"client-test01": {
+			{
+				Path:       ".*",
+				Operations: []string{"All"},
+			},
+		},
+	}))
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	rulesSvc, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithLocker(locker),
+	)
+	require.NoError(t, err)
+	ruler, err := golang.New(ctx, golang.WithLocker(locker), golang.WithRules(rulesSvc))
+	require.NoError(t, err)
+	lister, err := standardlister.New(ctx,
+		standardlister.WithChecker(checkerSvc),
+		standardlister.WithFetcher(fetcher),
+		standardlister.WithRuler(ruler),
+	)
+	require.NoError(t, err)
+	process, err := mockprocess.New()
+	require.NoError(t, err)
+	unlocker, err := localunlocker.New(ctx)
+	require.NoError(t, err)
+	accountManager, err := standardaccountmanager.New(ctx,
+		standardaccountmanager.WithUnlocker(unlocker),
+		standardaccountmanager.WithChecker(checkerSvc),
+		standardaccountmanager.WithFetcher(fetcher),
+		standardaccountmanager.WithRuler(ruler),
+		standardaccountmanager.WithProcess(process),
+	)
+	require.NoError(t, err)
+
+	recorder := &orderRecorder{}
+
+	genesisRoot := make([]byte, 32)
+	genesisRoot[0] = 0x01
+	startService := func(adminClients []string) string {
+		address := freeAddress(t)
+		_, err := rest.New(ctx,
+			rest.WithSigner(mocksigner.New()),
+			rest.WithLister(lister),
+			rest.WithFetcher(fetcher),
+			rest.WithWallets([]string{"Wallet 1"}),
+			rest.WithAccountManager(&recordingAccountManager{Service: accountManager, recorder: recorder}),
+			rest.WithRules(&recordingRules{Service: rulesSvc, recorder: recorder}),
+			rest.WithGenesisValidatorsRoot(genesisRoot),
+			rest.WithImportWallet("Imports"),
+			rest.WithAdminClients(adminClients),
+			rest.WithServerCert(resources.SignerTest01Crt),
+			rest.WithServerKey(resources.SignerTest01Key),
+			rest.WithCACert(resources.CACrt),
+			rest.WithListenAddress(address),
+		)
+		require.NoError(t, err)
+		return fmt.Sprintf("https://%s/eth/v1/keystores", address)
+	}
+	url := startService([]string{"client-test01"})
+	nonAdminURL := startService(nil)
+	client := newClient(t)
+
+	// Initial listing contains the accounts of Wallet 1.
+	listed := struct {
+		Data []struct {
+			ValidatingPubKey string `json:"validating_pubkey"`
+			DerivationPath   string `json:"derivation_path"`
+		} `json:"data"`
+	}{}
+	require.Equal(t, http.StatusOK, keymanagerRequest(t, client, http.MethodGet, url, "", &listed))
+	require.Len(t, listed.Data, 6)
+	require.NotEmpty(t, listed.Data[0].DerivationPath)
+
+	key, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	pubKey := fmt.Sprintf("%#x", key.PublicKey().Marshal())
+	otherKey, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	_, existing, err := fetcher.FetchAccount(ctx, "Wallet 1/Account 1")
+	require.NoError(t, err)
+	require.NoError(t, existing.(e2wtypes.AccountLocker).Unlock(ctx, []byte("Account 1 passphrase")))
+	existingKey, err := existing.(e2wtypes.AccountPrivateKeyProvider).PrivateKey(ctx)
+	require.NoError(t, err)
+	// Slashing protection for a key that is not imported.
+	protectedPubKey := "0xb845089a1457f811bfc000588fbb4e713669be8ce060ea6be3c6ece09afc3794106c91ca73acda5e5457122d58723bed"
+	// Existing slashing protection for a key that is not held.
+	inactiveKey, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	inactivePubKey := fmt.Sprintf("%#x", inactiveKey.PublicKey().Marshal())
+	_, err = rulesSvc.MergeSlashingProtection(ctx, map[[48]byte]*rules.SlashingProtection{
+		bytesutil.ToBytes48(inactiveKey.PublicKey().Marshal()): {
+			HighestProposedSlot:        200,
+			HighestAttestedSourceEpoch: -1,
+			HighestAttestedTargetEpoch: -1,
+		},
+	})
+	require.NoError(t, err)
+	slashingProtection := `{"metadata":{"interchange_format":"complete","interchange_format_version":"4","genesis_validators_root":"0x0100000000000000000000000000000000000000000000000000000000000000"},"data":[{"pubkey":"` + pubKey + `","signed_blocks":[{"slot":"100"}],"signed_attestations":[{"source_epoch":"2","target_epoch":"3"}]},{"pubkey":"` + protectedPubKey + `","signed_blocks":[{"slot":"200"}],"signed_attestations":[]}]}`
+
+	importReq := func(keystores []string, passwords []string, protection string) string {
+		data, err := json.Marshal(map[string]interface{}{
+			"keystores":           keystores,
+			"passwords":           passwords,
+			"slashing_protection": protection,
+		})
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// Mismatched keystores and passwords.
+	require.Equal(t, http.StatusBadRequest, keymanagerRequest(t, client, http.MethodPost, url,
+		importReq([]string{newKeystore(t, key, "secret")}, []string{}, ""), nil))
+
+	// Slashing protection for the wrong chain.
+	require.Equal(t, http.StatusBadRequest, keymanagerRequest(t, client, http.MethodPost, url,
+		importReq([]string{newKeystore(t, key, "secret")}, []string{"secret"}, `{"metadata":{"interchange_format":"complete","interchange_format_version":"4","genesis_validators_root":"0x0200000000000000000000000000000000000000000000000000000000000000"},"data":[]}`), nil))
+
+	// Slashing protection from a non-administrative client.
+	require.Equal(t, http.StatusForbidden, keymanagerRequest(t, client, http.MethodPost, nonAdminURL,
+		importReq([]string{newKeystore(t, key, "secret")}, []string{"secret"}, slashingProtection), nil))
+
+	imported := struct {
+		Data []*keystoreStatus `json:"data"`
+	}{}
+	require.Equal(t, http.StatusOK, keymanagerRequest(t, client, http.MethodPost, url,
+		importReq(
+			[]string{
+				newKeystore(t, key, "secret"),
+				newKeystore(t, otherKey, "secret"),
+				newKeystore(t, existingKey.(*e2types.BLSPrivateKey), "secret"),
+			},
+			[]string{"secret", "wrong", "secret"},
+			slashingProtection,
+		), &imported))
+	require.Len(t, imported.Data, 3)
+	require.Equal(t, "imported", imported.Data[0].Status)
+	require.Equal(t, "error", imported.Data[1].Status)
+	require.Equal(t, "duplicate", imported.Data[2].Status)
+
+	// Listing now includes the imported key.
+	require.Equal(t, http.StatusOK, keymanagerRequest(t, client, http.MethodGet, url, "", &listed))
+	require.Len(t, listed.Data, 7)
+	found := false
+	for _, entry := range listed.Data {
+		if entry.ValidatingPubKey == pubKey {
+			found = true
+		}
+	}
+	require.True(t, found)
+
+	deleted := struct {
+		Data               []*keystoreStatus `json:"data"`
+		SlashingProtection string            `json:"slashing_protection"`
+	}{}
+	// Slashing protection for keys that are not held is not released to non-administrative clients.
+	require.Equal(t, http.StatusOK, keymanagerRequest(t, client, http.MethodDelete, nonAdminURL,
+		fmt.Sprintf(`{"pubkeys":["%s"]}`, inactivePubKey), &deleted))
+	require.Len(t, deleted.Data, 1)
+	require.Equal(t, "not_found", deleted.Data[0].Status)
+	require.NotContains(t, deleted.SlashingProtection, inactivePubKey)
+
+	recorder.events = nil
+	require.Equal(t, http.StatusOK, keymanagerRequest(t, client, http.MethodDelete, url,
+		fmt.Sprintf(`{"pubkeys":["%s","%#x","0x0102","%s","%s"]}`, pubKey, otherKey.PublicKey().Marshal(), protectedPubKey, inactivePubKey), &deleted))
+	// Signing is paused before slashing protection is exported, so the export includes everything signed.
+	require.Equal(t, []string{"pause", "export"}, recorder.events)
+	require.Len(t, deleted.Data, 5)
+	require.Equal(t, "deleted", deleted.Data[0].Status)
+	require.Equal(t, "not_found", deleted.Data[1].Status)
+	require.Equal(t, "error", deleted.Data[2].Status)
+	// Slashing protection was not imported for the key that was not imported.
+	require.Equal(t, "not_found", deleted.Data[3].Status)
+	require.Equal(t, "not_active", deleted.Data[4].Status)
+
+	// Slashing protection is returned for the deleted and inactive keys.
+	exported := struct {
+		Data []struct {
+			PubKey       string `json:"pubkey"`
+			SignedBlocks []struct {
+				Slot string `json:"slot"`
+			} `json:"signed_blocks"`
+		} `json:"data"`
+	}{}
+	require.NoError(t, json.Unmarshal([]byte(deleted.SlashingProtection), &exported))
+	require.Len(t, exported.Data, 2)
+	slots := make(map[string]string)
+	for _, entry := range exported.Data {
+		require.Len(t, entry.SignedBlocks, 1)
+		slots[entry.PubKey] = entry.SignedBlocks[0].Slot
+	}
+	require.Equal(t, "100", slots[pubKey])
+	require.Equal(t, "200", slots[inactivePubKey])
+
+	// The deleted account is locked.
+	_, account, err := fetcher.FetchAccount(ctx, "Imports/"+pubKey)
+	require.NoError(t, err)
+	unlocked, err := account.(e2wtypes.AccountLocker).IsUnlocked(ctx)
+	require.NoError(t, err)
+	require.False(t, unlocked)
+}
@@ -0,0 +1,258 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/fetcher"
+	"github.com/attestantio/dirk/services/lister"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/revocationchecker"
+	"github.com/attestantio/dirk/services/signer"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel       zerolog.Level
+	monitor        metrics.APIMonitor
+	signer         signer.Service
+	lister         lister.Service
+	fetcher        fetcher.Service
+	checker        checker.Service
+	listenAddress  string
+	serverCert     []byte
+	serverKey      []byte
+	caCert         []byte
+	identitySAN    string
+	revocation     revocationchecker.Service
+	wallets        []string
+	slotsPerEpoch  uint64
+	accountManager accountmanager.Service
+	rules          rules.Service
+	genesisRoot    []byte
+	importWallet   string
+	adminClients   []string
+	rateLimiter    *interceptors.RateLimiter
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for this module.
+func WithMonitor(monitor metrics.APIMonitor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithSigner sets the signer for this module.
+func WithSigner(signer signer.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.signer = signer
+	})
+}
+
+// WithLister sets the lister for this module.
+func WithLister(lister lister.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.lister = lister
+	})
+}
+
+// WithFetcher sets the account fetcher for this module.
+func WithFetcher(fetcher fetcher.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.fetcher = fetcher
+	})
+}
+
+// WithChecker sets the checker for this module, used to check the sequence numbers of requests.
+func WithChecker(checker checker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checker = checker
+	})
+}
+
+// WithListenAddress sets the listen address for this module.
+func WithListenAddress(listenAddress string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.listenAddress = listenAddress
+	})
+}
+
+// WithServerCert sets the server certificate for this module.
+func WithServerCert(serverCert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.serverCert = serverCert
+	})
+}
+
+// WithServerKey sets the server key for this module.
+func WithServerKey(serverKey []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.serverKey = serverKey
+	})
+}
+
+// WithCACert sets the CA certificate for this module.
+func WithCACert(caCert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = caCert
+	})
+}
+
+// WithClientIdentitySAN sets the subject alternative name field of client certificates from which the
+// client identity is obtained, as per the GRPC API.
+func WithClientIdentitySAN(identitySAN string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.identitySAN = identitySAN
+	})
+}
+
+// WithRevocationChecker sets the checker for revocation of client certificates.  If not set client
+// certificates are not checked for revocation.
+func WithRevocationChecker(revocation revocationchecker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.revocation = revocation
+	})
+}
+
+// WithWallets sets the wallets whose accounts are returned by the public keys endpoint.
+func WithWallets(wallets []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.wallets = wallets
+	})
+}
+
+// WithSlotsPerEpoch sets the number of slots in an epoch, used to select the fork version for a request.
+func WithSlotsPerEpoch(slotsPerEpoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotsPerEpoch = slotsPerEpoch
+	})
+}
+
+// WithAccountManager sets the account manager for this module.  If supplied, the keymanager API is served.
+func WithAccountManager(accountManager accountmanager.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.accountManager = accountManager
+	})
+}
+
+// WithRules sets the rules for this module, used to import and export slashing protection in the keymanager API.
+func WithRules(rules rules.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rules = rules
+	})
+}
+
+// WithGenesisValidatorsRoot sets the genesis validators root for slashing protection in the keymanager API.
+func WithGenesisValidatorsRoot(root []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisRoot = root
+	})
+}
+
+// WithImportWallet sets the wallet in to which the keymanager API imports keystores.
+func WithImportWallet(wallet string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.importWallet = wallet
+	})
+}
+
+// WithAdminClients sets the administrative clients for the keymanager API.  Only administrative clients may
+// import slashing protection, or obtain slashing protection for keys that are not active.
+func WithAdminClients(clients []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.adminClients = clients
+	})
+}
+
+// WithRateLimiter sets the rate limiter for requests, as per the GRPC API.  Requests from clients that exceed their
+// rate limit are rejected.  If not set requests are not limited.
+func WithRateLimiter(rateLimiter *interceptors.RateLimiter) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rateLimiter = rateLimiter
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		slotsPerEpoch: 32,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.monitor == nil {
+		// Use no-op monitor.
+		parameters.monitor = &noopMonitor{}
+	}
+	if parameters.signer == nil {
+		return nil, errors.New("no signer specified")
+	}
+	if parameters.lister == nil {
+		return nil, errors.New("no lister specified")
+	}
+	if parameters.fetcher == nil {
+		return nil, errors.New("no fetcher specified")
+	}
+	if parameters.listenAddress == "" {
+		return nil, errors.New("no listen address specified")
+	}
+	if parameters.serverCert == nil {
+		return nil, errors.New("no server certificate specified")
+	}
+	if parameters.serverKey == nil {
+		return nil, errors.New("no server key specified")
+	}
+	if parameters.slotsPerEpoch == 0 {
+		return nil, errors.New("slots per epoch cannot be 0")
+	}
+	if parameters.accountManager != nil {
+		if parameters.rules == nil {
+			return nil, errors.New("no rules specified")
+		}
+		if len(parameters.genesisRoot) != 32 {
+			return nil, errors.New("genesis validators root must be 32 bytes")
+		}
+		if parameters.importWallet == "" {
+			return nil, errors.New("no import wallet specified")
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,68 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/attestantio/dirk/core"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// publicKeys handles requests to list the public keys that the client can sign with.
+// Distributed accounts are not listed, as they cannot generate a full signature on their own.
+func (s *Service) publicKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	credentials, ok := s.credentials(w, r)
+	if !ok {
+		return
+	}
+
+	result, accounts := s.lister.ListAccounts(r.Context(), credentials, s.wallets)
+	switch result {
+	case core.ResultSucceeded:
+	case core.ResultDenied:
+		http.Error(w, "Denied", http.StatusForbidden)
+		return
+	default:
+		http.Error(w, "Failed", http.StatusInternalServerError)
+		return
+	}
+
+	res := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		if _, isDistributed := account.(e2wtypes.DistributedAccount); isDistributed {
+			continue
+		}
+		pubKeyProvider, isProvider := account.(e2wtypes.AccountPublicKeyProvider)
+		if !isProvider {
+			continue
+		}
+		res = append(res, fmt.Sprintf("%#x", pubKeyProvider.PublicKey().Marshal()))
+	}
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to encode public keys")
+		http.Error(w, "Failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
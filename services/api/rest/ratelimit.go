@@ -0,0 +1,35 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"net/http"
+)
+
+// rateLimit wraps a handler, rejecting requests from clients that exceed their rate limit.
+// Requests without a verified client certificate are passed to the handler, which refuses them.
+func (s *Service) rateLimit(handler http.HandlerFunc) http.HandlerFunc {
+	if s.rateLimiter == nil {
+		return handler
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := s.clientIdentity(r)
+		if client != "" && !s.rateLimiter.Allow(client) {
+			s.monitor.RequestThrottled(client)
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		handler(w, r)
+	}
+}
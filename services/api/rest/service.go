@@ -0,0 +1,231 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/accountmanager"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/fetcher"
+	"github.com/attestantio/dirk/services/lister"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/signer"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+const (
+	// upcheckPath is the path of the endpoint that confirms the server is available.
+	upcheckPath = "/upcheck"
+	// publicKeysPath is the path of the endpoint that lists the public keys available for signing.
+	publicKeysPath = "/api/v1/eth2/publicKeys"
+	// signPath is the prefix of the path of the endpoint that signs data; it is followed by the public key.
+	signPath = "/api/v1/eth2/sign/"
+	// keystoresPath is the path of the keymanager API endpoint that lists, imports and deletes keystores.
+	keystoresPath = "/eth/v1/keystores"
+)
+
+const (
+	// requestIDHeader is the request and response header for the request ID, as per the GRPC API.
+	requestIDHeader = "X-Request-ID"
+	// sequenceHeader is the request header for the client's sequence number, as per the GRPC API.
+	sequenceHeader = "X-Sequence-Number"
+	// maxRequestIDLength is the maximum length of a client-supplied request ID.
+	maxRequestIDLength = 128
+	// maxRequestSize is the maximum size of a request body, which is sufficient for a full beacon block.
+	maxRequestSize = 1024 * 1024
+)
+
+// Service provides a REST API compatible with the Web3Signer remote signing API and the keymanager API.
+type Service struct {
+	monitor        metrics.APIMonitor
+	signer         signer.Service
+	lister         lister.Service
+	fetcher        fetcher.Service
+	checker        checker.Service
+	identitySAN    string
+	wallets        []string
+	slotsPerEpoch  uint64
+	accountManager accountmanager.Service
+	rules          rules.Service
+	genesisRoot    []byte
+	importWallet   string
+	adminClients   map[string]bool
+	rateLimiter    *interceptors.RateLimiter
+	certificates   *certificateManager
+	server         *http.Server
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new REST API service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "api").Str("impl", "rest").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	certificates, err := newCertificateManager(parameters.serverCert, parameters.serverKey, parameters.caCert, parameters.revocation)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{
+		monitor:        parameters.monitor,
+		signer:         parameters.signer,
+		lister:         parameters.lister,
+		fetcher:        parameters.fetcher,
+		checker:        parameters.checker,
+		identitySAN:    parameters.identitySAN,
+		wallets:        parameters.wallets,
+		slotsPerEpoch:  parameters.slotsPerEpoch,
+		accountManager: parameters.accountManager,
+		rules:          parameters.rules,
+		genesisRoot:    parameters.genesisRoot,
+		importWallet:   parameters.importWallet,
+		adminClients:   make(map[string]bool, len(parameters.adminClients)),
+		rateLimiter:    parameters.rateLimiter,
+		certificates:   certificates,
+	}
+	for _, client := range parameters.adminClients {
+		s.adminClients[client] = true
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(upcheckPath, s.upcheck)
+	mux.HandleFunc(publicKeysPath, s.rateLimit(s.publicKeys))
+	mux.HandleFunc(signPath, s.rateLimit(s.sign))
+	if s.accountManager != nil {
+		mux.HandleFunc(keystoresPath, s.rateLimit(s.keystores))
+	}
+	s.server = &http.Server{
+		Handler:           mux,
+		TLSConfig:         certificates.tlsConfig(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	conn, err := net.Listen("tcp", parameters.listenAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start REST server")
+	}
+	log.Info().Str("address", parameters.listenAddress).Msg("Listening")
+
+	go func() {
+		if err := s.server.ServeTLS(conn, "", ""); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Msg("Could not start REST server")
+		}
+	}()
+
+	// Cancel service on context done.
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down REST server")
+		}
+	}()
+
+	return s, nil
+}
+
+// ReloadCertificates replaces the server certificate, key and client CA certificate.
+// Existing connections are unaffected; new connections use the replacement material.
+// If the replacement material is invalid an error is returned and the existing material is retained.
+func (s *Service) ReloadCertificates(ctx context.Context, certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) error {
+	if err := s.certificates.reload(certPEMBlock, keyPEMBlock, caPEMBlock); err != nil {
+		log.Warn().Err(err).Msg("Failed to reload certificates; retaining existing certificates")
+		return err
+	}
+	log.Info().Msg("Reloaded certificates")
+	return nil
+}
+
+// Drain stops the server accepting new requests and waits for in-flight requests to complete.
+// If in-flight requests have not completed by the end of the grace period an error is returned.
+func (s *Service) Drain(ctx context.Context, gracePeriod time.Duration) error {
+	log.Info().Dur("grace_period", gracePeriod).Msg("Draining in-flight requests")
+	drainCtx, cancel := context.WithTimeout(ctx, gracePeriod)
+	defer cancel()
+	if err := s.server.Shutdown(drainCtx); err != nil {
+		log.Warn().Err(err).Msg("Failed to drain in-flight requests")
+		return errors.Wrap(err, "in-flight requests did not complete within grace period")
+	}
+	log.Info().Msg("Drained in-flight requests")
+	return nil
+}
+
+// clientIdentity returns the identity of the client from its verified certificate, or an empty string if the
+// client did not present a verified certificate.
+func (s *Service) clientIdentity(r *http.Request) string {
+	// Only a certificate that has been verified against the client CA is trusted.
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.PeerCertificates) > 0 {
+		return interceptors.ClientIdentity(r.TLS.PeerCertificates[0], s.identitySAN)
+	}
+	return ""
+}
+
+// credentials obtains the checker credentials for a request, writing an error response and returning false
+// if the request cannot proceed.
+func (s *Service) credentials(w http.ResponseWriter, r *http.Request) (*checker.Credentials, bool) {
+	res := &checker.Credentials{}
+	if requestID := r.Header.Get(requestIDHeader); requestID != "" && len(requestID) <= maxRequestIDLength {
+		res.RequestID = requestID
+	} else {
+		res.RequestID = uuid.New().String()
+	}
+	w.Header().Set(requestIDHeader, res.RequestID)
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		res.IP = host
+	}
+	res.Client = s.clientIdentity(r)
+	if res.Client == "" {
+		http.Error(w, "No verified client certificate", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	if s.checker != nil {
+		sequence := uint64(0)
+		if value := r.Header.Get(sequenceHeader); value != "" {
+			var err error
+			sequence, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				http.Error(w, "Invalid sequence number", http.StatusBadRequest)
+				return nil, false
+			}
+		}
+		if !s.checker.CheckSequence(r.Context(), res, r.RemoteAddr, sequence) {
+			http.Error(w, "Sequence number refused", http.StatusForbidden)
+			return nil, false
+		}
+	}
+
+	return res, true
+}
@@ -0,0 +1,462 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	mockaccountmanager "github.com/attestantio/dirk/services/accountmanager/mock"
+	"github.com/attestantio/dirk/services/api/grpc/interceptors"
+	"github.com/attestantio/dirk/services/api/rest"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	standardlister "github.com/attestantio/dirk/services/lister/standard"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	mocksigner "github.com/attestantio/dirk/services/signer/mock"
+	"github.com/attestantio/dirk/testing/accounts"
+	"github.com/attestantio/dirk/testing/resources"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestMain(m *testing.M) {
+	if err := e2types.InitBLS(); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// recordingSigner records the data it is asked to sign, and returns the configured result.
+type recordingSigner struct {
+	*mocksigner.Service
+	result      core.Result
+	reason      rules.Reason
+	client      string
+	proposal    *rules.SignBeaconProposalData
+	attestation *rules.SignBeaconAttestationData
+	generic     *rules.SignData
+}
+
+func (s *recordingSigner) outcome(ctx context.Context, credentials *checker.Credentials, signature []byte) (core.Result, []byte) {
+	s.client = credentials.Client
+	if s.result != core.ResultSucceeded {
+		rules.RecordReason(ctx, s.reason)
+		return s.result, nil
+	}
+	return core.ResultSucceeded, signature
+}
+
+func (s *recordingSigner) SignBeaconProposal(ctx context.Context, credentials *checker.Credentials, accountName string, pubKey []byte, data *rules.SignBeaconProposalData) (core.Result, []byte) {
+	s.proposal = data
+	return s.outcome(ctx, credentials, bytes.Repeat([]byte{0x01}, 96))
+}
+
+func (s *recordingSigner) SignBeaconAttestation(ctx context.Context, credentials *checker.Credentials, accountName string, pubKey []byte, data *rules.SignBeaconAttestationData) (core.Result, []byte) {
+	s.attestation = data
+	return s.outcome(ctx, credentials, bytes.Repeat([]byte{0x02}, 96))
+}
+
+func (s *recordingSigner) SignGeneric(ctx context.Context, credentials *checker.Credentials, accountName string, pubKey []byte, data *rules.SignData) (core.Result, []byte) {
+	s.generic = data
+	return s.outcome(ctx, credentials, bytes.Repeat([]byte{0x03}, 96))
+}
+
+// freeAddress returns a local address on which nothing is listening.
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return address
+}
+
+func setup(t *testing.T) (string, *http.Client, *recordingSigner, []byte) {
+	_, base, client, signer, pubKey := setupService(t)
+	return base, client, signer, pubKey
+}
+
+func setupService(t *testing.T, params ...rest.Parameter) (*rest.Service, string, *http.Client, *recordingSigner, []byte) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	store, err := accounts.Setup(ctx)
+	require.NoError(t, err)
+	fetcher, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	checker, err := staticchecker.New(ctx, staticchecker.WithPermissions(map[string][]*checker.Permissions{
+		"client-test01": {
+			{
+				Path:       "Wallet 1/.*",
+				Operations: []string{"All"},
+			},
+		},
+	}))
+	require.NoError(t, err)
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	ruler, err := golang.New(ctx, golang.WithLocker(locker), golang.WithRules(mockrules.New()))
+	require.NoError(t, err)
+	lister, err := standardlister.New(ctx,
+		standardlister.WithChecker(checker),
+		standardlister.WithFetcher(fetcher),
+		standardlister.WithRuler(ruler),
+	)
+	require.NoError(t, err)
+	signer := &recordingSigner{Service: mocksigner.New(), result: core.ResultSucceeded}
+
+	address := freeAddress(t)
+	svc, err := rest.New(ctx, append([]rest.Parameter{
+		rest.WithSigner(signer),
+		rest.WithLister(lister),
+		rest.WithFetcher(fetcher),
+		rest.WithWallets([]string{"Wallet 1", "Wallet 2"}),
+		rest.WithServerCert(resources.SignerTest01Crt),
+		rest.WithServerKey(resources.SignerTest01Key),
+		rest.WithCACert(resources.CACrt),
+		rest.WithListenAddress(address),
+	}, params...)...)
+	require.NoError(t, err)
+
+	_, account, err := fetcher.FetchAccount(ctx, "Wallet 1/Account 1")
+	require.NoError(t, err)
+	pubKey := account.(e2wtypes.AccountPublicKeyProvider).PublicKey().Marshal()
+
+	return svc, fmt.Sprintf("https://%s", address), newClient(t), signer, pubKey
+}
+
+// newClient creates an HTTP client that authenticates as client-test01.
+func newClient(t *testing.T) *http.Client {
+	clientCert, err := tls.X509KeyPair(resources.ClientTest01Crt, resources.ClientTest01Key)
+	require.NoError(t, err)
+	rootCAs := x509.NewCertPool()
+	require.True(t, rootCAs.AppendCertsFromPEM(resources.CACrt))
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates: []tls.Certificate{clientCert},
+				RootCAs:      rootCAs,
+				ServerName:   "signer-test01",
+				MinVersion:   tls.VersionTLS13,
+			},
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	signer := mocksigner.New()
+	tests := []struct {
+		name   string
+		params []rest.Parameter
+		err    string
+	}{
+		{
+			name: "SignerMissing",
+			err:  "problem with parameters: no signer specified",
+		},
+		{
+			name:   "ListerMissing",
+			params: []rest.Parameter{rest.WithSigner(signer)},
+			err:    "problem with parameters: no lister specified",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := rest.New(ctx, test.params...)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestUpcheck(t *testing.T) {
+	base, client, _, _ := setup(t)
+
+	resp, err := client.Get(base + "/upcheck")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "OK", string(body))
+}
+
+func TestDrain(t *testing.T) {
+	svc, base, client, _, _ := setupService(t)
+
+	resp, err := client.Get(base + "/upcheck")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.NoError(t, svc.Drain(context.Background(), time.Second))
+
+	// New requests are refused once drained.
+	client.CloseIdleConnections()
+	_, err = client.Get(base + "/upcheck")
+	require.Error(t, err)
+}
+
+func TestReloadCertificates(t *testing.T) {
+	svc, base, client, _, _ := setupService(t)
+	ctx := context.Background()
+
+	resp, err := client.Get(base + "/upcheck")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "signer-test01", resp.TLS.PeerCertificates[0].Subject.CommonName)
+
+	// Bad material is rejected, and the existing material retained.
+	require.EqualError(t, svc.ReloadCertificates(ctx, resources.SignerTest03Crt, resources.SignerTest02Key, resources.CACrt), "failed to load server keypair: tls: private key does not match public key")
+
+	// New connections use the new certificate.
+	require.NoError(t, svc.ReloadCertificates(ctx, resources.SignerTest02Crt, resources.SignerTest02Key, resources.CACrt))
+	client.CloseIdleConnections()
+	client.Transport.(*http.Transport).TLSClientConfig.ServerName = "signer-test02"
+	resp, err = client.Get(base + "/upcheck")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, "signer-test02", resp.TLS.PeerCertificates[0].Subject.CommonName)
+}
+
+func TestPublicKeys(t *testing.T) {
+	base, client, _, pubKey := setup(t)
+
+	resp, err := client.Get(base + "/api/v1/eth2/publicKeys")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+	var pubKeys []string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&pubKeys))
+	// Only the accounts in Wallet 1 are permitted, and distributed accounts in Wallet 2 are not listed.
+	require.Len(t, pubKeys, 6)
+	require.Contains(t, pubKeys, fmt.Sprintf("%#x", pubKey))
+}
+
+type throttleMonitor struct {
+	throttled map[string]int
+}
+
+func (m *throttleMonitor) RequestThrottled(client string) {
+	m.throttled[client]++
+}
+
+func TestRateLimit(t *testing.T) {
+	// A low rate ensures that tokens are not refilled during the test.
+	limiter := interceptors.NewRateLimiter(&interceptors.RateLimit{RequestsPerSecond: 0.001, Burst: 2}, nil)
+	monitor := &throttleMonitor{throttled: make(map[string]int)}
+	_, base, client, _, pubKey := setupService(t,
+		rest.WithRateLimiter(limiter),
+		rest.WithMonitor(monitor),
+		rest.WithAccountManager(mockaccountmanager.New()),
+		rest.WithRules(mockrules.New()),
+		rest.WithGenesisValidatorsRoot(make([]byte, 32)),
+		rest.WithImportWallet("Wallet 1"),
+	)
+
+	// The limit applies across the Web3Signer and keymanager endpoints.
+	resp, err := client.Get(base + "/api/v1/eth2/publicKeys")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = client.Get(base + "/eth/v1/keystores")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := fmt.Sprintf(`{"type": "RANDAO_REVEAL", %s, "randao_reveal": {"epoch": "10"}}`, forkInfo)
+	resp, err = client.Post(fmt.Sprintf("%s/api/v1/eth2/sign/%#x", base, pubKey), "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	resp, err = client.Get(base + "/eth/v1/keystores")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.Equal(t, 2, monitor.throttled["client-test01"])
+
+	// The upcheck is not limited.
+	resp, err = client.Get(base + "/upcheck")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+const forkInfo = `"fork_info": {
+  "fork": {"previous_version": "0x00000001", "current_version": "0x00000002", "epoch": "10"},
+  "genesis_validators_root": "0x0101010101010101010101010101010101010101010101010101010101010101"
+}`
+
+const attestation = `"attestation": {
+  "slot": "320", "index": "1",
+  "beacon_block_root": "0x0202020202020202020202020202020202020202020202020202020202020202",
+  "source": {"epoch": "9", "root": "0x0303030303030303030303030303030303030303030303030303030303030303"},
+  "target": {"epoch": "10", "root": "0x0404040404040404040404040404040404040404040404040404040404040404"}
+}`
+
+func TestSign(t *testing.T) {
+	base, client, signer, pubKey := setup(t)
+	genesisValidatorsRoot := bytes.Repeat([]byte{0x01}, 32)
+	previousDomain, err := e2types.ComputeDomain(e2types.DomainBeaconProposer, []byte{0x00, 0x00, 0x00, 0x01}, genesisValidatorsRoot)
+	require.NoError(t, err)
+	currentDomain, err := e2types.ComputeDomain(e2types.DomainBeaconAttester, []byte{0x00, 0x00, 0x00, 0x02}, genesisValidatorsRoot)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		pubKey    string
+		body      string
+		result    core.Result
+		reason    rules.Reason
+		status    int
+		signature string
+	}{
+		{
+			name:   "BadPubKey",
+			pubKey: "0x0102",
+			body:   `{"type": "ATTESTATION", ` + forkInfo + `, ` + attestation + `}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "UnknownPubKey",
+			pubKey: fmt.Sprintf("%#x", bytes.Repeat([]byte{0x01}, 48)),
+			body:   `{"type": "ATTESTATION", ` + forkInfo + `, ` + attestation + `}`,
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "UnsupportedType",
+			body:   `{"type": "SYNC_COMMITTEE_MESSAGE", ` + forkInfo + `}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ForkInfoMissing",
+			body:   `{"type": "ATTESTATION", ` + attestation + `}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "SigningRootMismatch",
+			body:   `{"type": "ATTESTATION", "signingRoot": "0x0505050505050505050505050505050505050505050505050505050505050505", ` + forkInfo + `, ` + attestation + `}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:      "Attestation",
+			body:      `{"type": "ATTESTATION", ` + forkInfo + `, ` + attestation + `}`,
+			result:    core.ResultSucceeded,
+			status:    http.StatusOK,
+			signature: fmt.Sprintf("%#x", bytes.Repeat([]byte{0x02}, 96)),
+		},
+		{
+			name: "Block",
+			body: `{"type": "BLOCK_V2", ` + forkInfo + `, "beacon_block": {"version": "PHASE0", "block_header": {
+  "slot": "64", "proposer_index": "5",
+  "parent_root": "0x0202020202020202020202020202020202020202020202020202020202020202",
+  "state_root": "0x0303030303030303030303030303030303030303030303030303030303030303",
+  "body_root": "0x0404040404040404040404040404040404040404040404040404040404040404"}}}`,
+			result:    core.ResultSucceeded,
+			status:    http.StatusOK,
+			signature: fmt.Sprintf("%#x", bytes.Repeat([]byte{0x01}, 96)),
+		},
+		{
+			name:      "RANDAOReveal",
+			body:      `{"type": "RANDAO_REVEAL", ` + forkInfo + `, "randao_reveal": {"epoch": "12"}}`,
+			result:    core.ResultSucceeded,
+			status:    http.StatusOK,
+			signature: fmt.Sprintf("%#x", bytes.Repeat([]byte{0x03}, 96)),
+		},
+		{
+			name:   "Slashable",
+			body:   `{"type": "ATTESTATION", ` + forkInfo + `, ` + attestation + `}`,
+			result: core.ResultDenied,
+			reason: rules.ReasonSlashing,
+			status: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "Denied",
+			body:   `{"type": "ATTESTATION", ` + forkInfo + `, ` + attestation + `}`,
+			result: core.ResultDenied,
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "Failed",
+			body:   `{"type": "ATTESTATION", ` + forkInfo + `, ` + attestation + `}`,
+			result: core.ResultFailed,
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signer.result = test.result
+			signer.reason = test.reason
+			key := test.pubKey
+			if key == "" {
+				key = fmt.Sprintf("%#x", pubKey)
+			}
+			resp, err := client.Post(base+"/api/v1/eth2/sign/"+key, "application/json", strings.NewReader(test.body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			require.Equal(t, test.status, resp.StatusCode)
+			if test.signature != "" {
+				body, err := ioutil.ReadAll(resp.Body)
+				require.NoError(t, err)
+				require.Equal(t, test.signature, string(body))
+				require.Equal(t, "client-test01", signer.client)
+			}
+		})
+	}
+
+	// Confirm the fork version is selected by the epoch of the request.
+	require.Equal(t, currentDomain, signer.attestation.Domain)
+	require.Equal(t, previousDomain, signer.proposal.Domain)
+	require.Equal(t, uint64(64), signer.proposal.Slot)
+	require.Equal(t, []byte{0x0c, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, signer.generic.Data)
+}
+
+func TestSignJSON(t *testing.T) {
+	base, client, _, pubKey := setup(t)
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/api/v1/eth2/sign/%#x", base, pubKey),
+		strings.NewReader(`{"type": "ATTESTATION", `+forkInfo+`, `+attestation+`}`))
+	require.NoError(t, err)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Request-ID", "test-request")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "test-request", resp.Header.Get("X-Request-ID"))
+	var res map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	require.Equal(t, fmt.Sprintf("%#x", bytes.Repeat([]byte{0x02}, 96)), res["signature"])
+}
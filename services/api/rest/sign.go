@@ -0,0 +1,254 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/pkg/errors"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// signResponse is the JSON response to a request to sign.
+type signResponse struct {
+	Signature string `json:"signature"`
+}
+
+// sign handles requests to sign.
+func (s *Service) sign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	credentials, ok := s.credentials(w, r)
+	if !ok {
+		return
+	}
+	log := log.With().Str("request_id", credentials.RequestID).Str("client", credentials.Client).Logger()
+
+	pubKey, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, signPath), "0x"))
+	if err != nil || len(pubKey) != 48 {
+		http.Error(w, "Invalid public key", http.StatusBadRequest)
+		return
+	}
+	if _, _, err := s.fetcher.FetchAccountByKey(r.Context(), pubKey); err != nil {
+		http.Error(w, "Public key not found", http.StatusNotFound)
+		return
+	}
+
+	req := &signRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(req); err != nil {
+		log.Debug().Err(err).Msg("Invalid request")
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx := rules.WithReasonRecorder(r.Context())
+	result, signature, err := s.signRequest(ctx, credentials, pubKey, req)
+	if err != nil {
+		log.Debug().Err(err).Str("type", req.Type).Msg("Invalid request")
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if result != core.ResultSucceeded {
+		code, msg := resultStatus(result, rules.RecordedReason(ctx))
+		log.Debug().Str("type", req.Type).Str("result", result.String()).Int("status", code).Msg("Not signed")
+		http.Error(w, msg, code)
+		return
+	}
+
+	sig := fmt.Sprintf("%#x", signature)
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		data, err := json.Marshal(&signResponse{Signature: sig})
+		if err != nil {
+			http.Error(w, "Failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(sig))
+}
+
+// signRequest signs the request through the signer, returning an error if the request is invalid.
+func (s *Service) signRequest(ctx context.Context,
+	credentials *checker.Credentials,
+	pubKey []byte,
+	req *signRequest,
+) (
+	core.Result,
+	[]byte,
+	error,
+) {
+	switch req.Type {
+	case "BLOCK", "BLOCK_V2":
+		data, err := req.proposal()
+		if err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		data.Domain, err = req.ForkInfo.domain(e2types.DomainBeaconProposer, data.Slot/s.slotsPerEpoch)
+		if err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		root, err := proposalRoot(data)
+		if err != nil {
+			return core.ResultUnknown, nil, errors.Wrap(err, "failed to obtain block header root")
+		}
+		if err := req.checkSigningRoot(root, data.Domain); err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		result, signature := s.signer.SignBeaconProposal(ctx, credentials, "", pubKey, data)
+		return result, signature, nil
+	case "ATTESTATION":
+		if req.Attestation == nil || req.Attestation.Source == nil || req.Attestation.Target == nil {
+			return core.ResultUnknown, nil, errors.New("attestation missing")
+		}
+		domain, err := req.ForkInfo.domain(e2types.DomainBeaconAttester, uint64(req.Attestation.Target.Epoch))
+		if err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		root, err := req.Attestation.HashTreeRoot()
+		if err != nil {
+			return core.ResultUnknown, nil, errors.Wrap(err, "failed to obtain attestation root")
+		}
+		if err := req.checkSigningRoot(root[:], domain); err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		result, signature := s.signer.SignBeaconAttestation(ctx, credentials, "", pubKey, &rules.SignBeaconAttestationData{
+			Domain:          domain,
+			Slot:            uint64(req.Attestation.Slot),
+			CommitteeIndex:  uint64(req.Attestation.Index),
+			BeaconBlockRoot: req.Attestation.BeaconBlockRoot[:],
+			Source: &rules.Checkpoint{
+				Epoch: uint64(req.Attestation.Source.Epoch),
+				Root:  req.Attestation.Source.Root[:],
+			},
+			Target: &rules.Checkpoint{
+				Epoch: uint64(req.Attestation.Target.Epoch),
+				Root:  req.Attestation.Target.Root[:],
+			},
+		})
+		return result, signature, nil
+	case "RANDAO_REVEAL":
+		if req.RANDAOReveal == nil {
+			return core.ResultUnknown, nil, errors.New("RANDAO reveal missing")
+		}
+		epoch, err := parseUint64("epoch", req.RANDAOReveal.Epoch)
+		if err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		return s.signGeneric(ctx, credentials, pubKey, req, e2types.DomainRANDAO, epoch, uint64Root(epoch))
+	case "AGGREGATION_SLOT":
+		if req.AggregationSlot == nil {
+			return core.ResultUnknown, nil, errors.New("aggregation slot missing")
+		}
+		slot, err := parseUint64("slot", req.AggregationSlot.Slot)
+		if err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		return s.signGeneric(ctx, credentials, pubKey, req, domainSelectionProof, slot/s.slotsPerEpoch, uint64Root(slot))
+	case "AGGREGATE_AND_PROOF":
+		if req.AggregateAndProof == nil || req.AggregateAndProof.Aggregate == nil || req.AggregateAndProof.Aggregate.Data == nil {
+			return core.ResultUnknown, nil, errors.New("aggregate and proof missing")
+		}
+		root, err := req.AggregateAndProof.HashTreeRoot()
+		if err != nil {
+			return core.ResultUnknown, nil, errors.Wrap(err, "failed to obtain aggregate and proof root")
+		}
+		epoch := uint64(req.AggregateAndProof.Aggregate.Data.Slot) / s.slotsPerEpoch
+		return s.signGeneric(ctx, credentials, pubKey, req, domainAggregateAndProof, epoch, root[:])
+	case "VOLUNTARY_EXIT":
+		if req.VoluntaryExit == nil {
+			return core.ResultUnknown, nil, errors.New("voluntary exit missing")
+		}
+		domain, err := req.ForkInfo.domain(e2types.DomainVoluntaryExit, uint64(req.VoluntaryExit.Epoch))
+		if err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		root, err := req.VoluntaryExit.HashTreeRoot()
+		if err != nil {
+			return core.ResultUnknown, nil, errors.Wrap(err, "failed to obtain voluntary exit root")
+		}
+		if err := req.checkSigningRoot(root[:], domain); err != nil {
+			return core.ResultUnknown, nil, err
+		}
+		result, signature := s.signer.SignVoluntaryExit(ctx, credentials, "", pubKey, &rules.SignVoluntaryExitData{
+			Domain:         domain,
+			Epoch:          uint64(req.VoluntaryExit.Epoch),
+			ValidatorIndex: uint64(req.VoluntaryExit.ValidatorIndex),
+		})
+		return result, signature, nil
+	default:
+		return core.ResultUnknown, nil, fmt.Errorf("unsupported type %q", req.Type)
+	}
+}
+
+// signGeneric signs the root of an object for which the signer has no specific method.
+func (s *Service) signGeneric(ctx context.Context,
+	credentials *checker.Credentials,
+	pubKey []byte,
+	req *signRequest,
+	domainType e2types.DomainType,
+	epoch uint64,
+	root []byte,
+) (
+	core.Result,
+	[]byte,
+	error,
+) {
+	domain, err := req.ForkInfo.domain(domainType, epoch)
+	if err != nil {
+		return core.ResultUnknown, nil, err
+	}
+	if err := req.checkSigningRoot(root, domain); err != nil {
+		return core.ResultUnknown, nil, err
+	}
+	result, signature := s.signer.SignGeneric(ctx, credentials, "", pubKey, &rules.SignData{
+		Domain: domain,
+		Data:   root,
+		Epoch:  &epoch,
+	})
+	return result, signature, nil
+}
+
+// resultStatus returns the HTTP status code and message for a request that was not signed.
+// Requests refused by slashing protection return 412, as per the Web3Signer API.
+func resultStatus(result core.Result, reason rules.Reason) (int, string) {
+	switch reason {
+	case rules.ReasonSlashing:
+		return http.StatusPreconditionFailed, "Signing operation failed due to slashing protection rules"
+	case rules.ReasonLimitExceeded:
+		return http.StatusTooManyRequests, "Request exceeds limit"
+	case rules.ReasonPolicy:
+		return http.StatusForbidden, "Denied by policy"
+	case rules.ReasonStoreUnavailable:
+		return http.StatusServiceUnavailable, "Slashing protection unavailable"
+	case rules.ReasonInvalidRequest:
+		return http.StatusBadRequest, "Invalid request"
+	}
+	if result == core.ResultDenied {
+		return http.StatusForbidden, "Denied"
+	}
+	return http.StatusInternalServerError, "Failed"
+}
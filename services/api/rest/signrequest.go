@@ -0,0 +1,189 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/dirk/rules"
+	spec "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+// Domain types that the types library does not supply as a DomainType.
+var (
+	domainSelectionProof    = e2types.DomainType{0x05, 0x00, 0x00, 0x00}
+	domainAggregateAndProof = e2types.DomainType{0x06, 0x00, 0x00, 0x00}
+)
+
+// signRequest is a request to sign, as per the Web3Signer remote signing API.
+type signRequest struct {
+	Type              string                  `json:"type"`
+	ForkInfo          *forkInfo               `json:"fork_info"`
+	SigningRoot       string                  `json:"signingRoot"`
+	Block             *spec.BeaconBlock       `json:"block"`
+	BeaconBlock       *beaconBlockV2          `json:"beacon_block"`
+	Attestation       *spec.AttestationData   `json:"attestation"`
+	AggregationSlot   *aggregationSlot        `json:"aggregation_slot"`
+	AggregateAndProof *spec.AggregateAndProof `json:"aggregate_and_proof"`
+	RANDAOReveal      *randaoReveal           `json:"randao_reveal"`
+	VoluntaryExit     *spec.VoluntaryExit     `json:"voluntary_exit"`
+}
+
+// forkInfo is the fork information of a request to sign.
+type forkInfo struct {
+	Fork                  *spec.Fork `json:"fork"`
+	GenesisValidatorsRoot string     `json:"genesis_validators_root"`
+}
+
+// beaconBlockV2 is the block of a BLOCK_V2 request to sign, which contains either a block or its header.
+type beaconBlockV2 struct {
+	Version     string                  `json:"version"`
+	Block       *spec.BeaconBlock       `json:"block"`
+	BlockHeader *spec.BeaconBlockHeader `json:"block_header"`
+}
+
+// aggregationSlot is the slot of an AGGREGATION_SLOT request to sign.
+type aggregationSlot struct {
+	Slot string `json:"slot"`
+}
+
+// randaoReveal is the epoch of a RANDAO_REVEAL request to sign.
+type randaoReveal struct {
+	Epoch string `json:"epoch"`
+}
+
+// domain returns the domain for the given domain type at the given epoch.
+func (f *forkInfo) domain(domainType e2types.DomainType, epoch uint64) ([]byte, error) {
+	if f == nil || f.Fork == nil {
+		return nil, errors.New("fork info missing")
+	}
+	genesisValidatorsRoot, err := hex.DecodeString(strings.TrimPrefix(f.GenesisValidatorsRoot, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid genesis validators root")
+	}
+	if len(genesisValidatorsRoot) != 32 {
+		return nil, errors.New("genesis validators root must be 32 bytes")
+	}
+	forkVersion := f.Fork.CurrentVersion
+	if spec.Epoch(epoch) < f.Fork.Epoch {
+		forkVersion = f.Fork.PreviousVersion
+	}
+	return e2types.ComputeDomain(domainType, forkVersion[:], genesisValidatorsRoot)
+}
+
+// proposal returns the data for a BLOCK or BLOCK_V2 request to sign.
+func (r *signRequest) proposal() (*rules.SignBeaconProposalData, error) {
+	block := r.Block
+	var header *spec.BeaconBlockHeader
+	if r.Type == "BLOCK_V2" {
+		if r.BeaconBlock == nil {
+			return nil, errors.New("beacon block missing")
+		}
+		if r.BeaconBlock.Version != "" && !strings.EqualFold(r.BeaconBlock.Version, "PHASE0") {
+			return nil, fmt.Errorf("unsupported block version %s", r.BeaconBlock.Version)
+		}
+		block = r.BeaconBlock.Block
+		header = r.BeaconBlock.BlockHeader
+	}
+
+	if block != nil {
+		if block.Body == nil {
+			return nil, errors.New("block body missing")
+		}
+		bodyRoot, err := block.Body.HashTreeRoot()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain block body root")
+		}
+		return &rules.SignBeaconProposalData{
+			Slot:          uint64(block.Slot),
+			ProposerIndex: uint64(block.ProposerIndex),
+			ParentRoot:    block.ParentRoot[:],
+			StateRoot:     block.StateRoot[:],
+			BodyRoot:      bodyRoot[:],
+			Graffiti:      block.Body.Graffiti,
+		}, nil
+	}
+	if header != nil {
+		return &rules.SignBeaconProposalData{
+			Slot:          uint64(header.Slot),
+			ProposerIndex: uint64(header.ProposerIndex),
+			ParentRoot:    header.ParentRoot[:],
+			StateRoot:     header.StateRoot[:],
+			BodyRoot:      header.BodyRoot[:],
+		}, nil
+	}
+	return nil, errors.New("block missing")
+}
+
+// proposalRoot returns the root of the block header for proposal data.
+func proposalRoot(data *rules.SignBeaconProposalData) ([]byte, error) {
+	header := &spec.BeaconBlockHeader{
+		Slot:          spec.Slot(data.Slot),
+		ProposerIndex: spec.ValidatorIndex(data.ProposerIndex),
+	}
+	copy(header.ParentRoot[:], data.ParentRoot)
+	copy(header.StateRoot[:], data.StateRoot)
+	copy(header.BodyRoot[:], data.BodyRoot)
+	root, err := header.HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	return root[:], nil
+}
+
+// uint64Root returns the hash tree root of a uint64.
+func uint64Root(value uint64) []byte {
+	root := make([]byte, 32)
+	binary.LittleEndian.PutUint64(root, value)
+	return root
+}
+
+// checkSigningRoot checks the signing root supplied by the client, if any, against that of the object root
+// and domain of the request.
+func (r *signRequest) checkSigningRoot(objectRoot []byte, domain []byte) error {
+	if r.SigningRoot == "" {
+		return nil
+	}
+	suppliedRoot, err := hex.DecodeString(strings.TrimPrefix(r.SigningRoot, "0x"))
+	if err != nil {
+		return errors.Wrap(err, "invalid signing root")
+	}
+	signingData := &spec.SigningData{}
+	copy(signingData.ObjectRoot[:], objectRoot)
+	copy(signingData.Domain[:], domain)
+	signingRoot, err := signingData.HashTreeRoot()
+	if err != nil {
+		return errors.Wrap(err, "failed to generate signing root")
+	}
+	if !bytes.Equal(signingRoot[:], suppliedRoot) {
+		return fmt.Errorf("signing root %#x does not match request", suppliedRoot)
+	}
+	return nil
+}
+
+// parseUint64 parses a decimal string as used by the Web3Signer API.
+func parseUint64(name string, value string) (uint64, error) {
+	res, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, fmt.Sprintf("invalid %s", name))
+	}
+	return res, nil
+}
@@ -0,0 +1,102 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/attestantio/dirk/services/auditor"
+	"github.com/pkg/errors"
+)
+
+// maxLineSize is the maximum size of a single line in the audit log.
+const maxLineSize = 1024 * 1024
+
+// lineHash returns the hash of a line of the audit log, excluding its terminating newline.
+func lineHash(line []byte) string {
+	hash := sha256.Sum256(line)
+	return fmt.Sprintf("%#x", hash)
+}
+
+// lastLine returns the last non-empty line of the given file, or nil if the file is empty.
+func lastLine(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, nil
+	}
+	return last, nil
+}
+
+// VerifyChain verifies the hash chain of the supplied audit log files, which should be provided oldest first
+// (for example audit.log.2, audit.log.1, audit.log).  It returns the number of records verified.
+// The first record is accepted whatever its previous hash, as earlier records may have been rotated away.
+func VerifyChain(paths ...string) (int, error) {
+	records := 0
+	prevHash := ""
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return records, errors.Wrap(err, "failed to open audit log")
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+		line := 0
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+			record := &auditor.Record{}
+			if err := json.Unmarshal(data, record); err != nil {
+				file.Close()
+				return records, errors.Wrap(err, fmt.Sprintf("invalid record at %s:%d", path, line))
+			}
+			if records > 0 && record.PrevHash != prevHash {
+				file.Close()
+				return records, fmt.Errorf("hash chain broken at %s:%d", path, line)
+			}
+			prevHash = lineHash(data)
+			records++
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return records, errors.Wrap(err, "failed to read audit log")
+		}
+	}
+	return records, nil
+}
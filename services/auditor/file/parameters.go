@@ -0,0 +1,127 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"time"
+
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel       zerolog.Level
+	monitor        metrics.AuditorMonitor
+	path           string
+	maxSize        int64
+	maxBackups     int
+	bufferSize     int
+	enqueueTimeout time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for this module.
+func WithMonitor(monitor metrics.AuditorMonitor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithPath sets the path of the audit log file.
+func WithPath(path string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.path = path
+	})
+}
+
+// WithMaxSize sets the size in bytes at which the audit log file is rotated.
+// If this is 0 then the file is not rotated.
+func WithMaxSize(maxSize int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxSize = maxSize
+	})
+}
+
+// WithMaxBackups sets the number of rotated audit log files to keep.
+func WithMaxBackups(maxBackups int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxBackups = maxBackups
+	})
+}
+
+// WithBufferSize sets the number of records that can be queued for writing.
+func WithBufferSize(bufferSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bufferSize = bufferSize
+	})
+}
+
+// WithEnqueueTimeout sets the maximum time to wait for space in the queue before a record is dropped.
+func WithEnqueueTimeout(enqueueTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.enqueueTimeout = enqueueTimeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:       zerolog.GlobalLevel(),
+		maxBackups:     10,
+		bufferSize:     1024,
+		enqueueTimeout: 100 * time.Millisecond,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.monitor == nil {
+		// Use no-op monitor.
+		parameters.monitor = &noopMonitor{}
+	}
+	if parameters.path == "" {
+		return nil, errors.New("no path specified")
+	}
+	if parameters.maxSize < 0 {
+		return nil, errors.New("max size cannot be negative")
+	}
+	if parameters.maxBackups < 0 {
+		return nil, errors.New("max backups cannot be negative")
+	}
+	if parameters.bufferSize <= 0 {
+		return nil, errors.New("buffer size must be positive")
+	}
+
+	return &parameters, nil
+}
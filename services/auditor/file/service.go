@@ -0,0 +1,228 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/attestantio/dirk/services/auditor"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service writes audit records as JSON lines to a file.
+type Service struct {
+	monitor        metrics.AuditorMonitor
+	path           string
+	maxSize        int64
+	maxBackups     int
+	enqueueTimeout time.Duration
+	records        chan *auditor.Record
+	done           chan struct{}
+	file           *os.File
+	size           int64
+	lastHash       string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new file audit service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "auditor").Str("impl", "file").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	s := &Service{
+		monitor:        parameters.monitor,
+		path:           parameters.path,
+		maxSize:        parameters.maxSize,
+		maxBackups:     parameters.maxBackups,
+		enqueueTimeout: parameters.enqueueTimeout,
+		records:        make(chan *auditor.Record, parameters.bufferSize),
+		done:           make(chan struct{}),
+	}
+	if err := s.open(); err != nil {
+		return nil, errors.Wrap(err, "failed to open audit log")
+	}
+	lastHash, err := s.loadLastHash()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain last hash of audit log")
+	}
+	s.lastHash = lastHash
+
+	go s.write(ctx)
+
+	return s, nil
+}
+
+// Audit records the decision for a request.
+// The record is queued for writing; if the queue is full for longer than the enqueue timeout the record is dropped.
+func (s *Service) Audit(ctx context.Context, record *auditor.Record) {
+	if record == nil {
+		return
+	}
+	select {
+	case s.records <- record:
+		return
+	default:
+	}
+
+	// Queue is full; apply backpressure for a limited time.
+	timer := time.NewTimer(s.enqueueTimeout)
+	defer timer.Stop()
+	select {
+	case s.records <- record:
+	case <-timer.C:
+		log.Error().Str("request_id", record.RequestID).Msg("Audit queue full; record dropped")
+	case <-ctx.Done():
+		log.Error().Str("request_id", record.RequestID).Msg("Context done; record dropped")
+	}
+}
+
+// Done returns a channel that is closed when the service has written all queued records and closed the file.
+func (s *Service) Done() <-chan struct{} {
+	return s.done
+}
+
+// write writes records to the file until the context is done.
+func (s *Service) write(ctx context.Context) {
+	defer close(s.done)
+	for {
+		select {
+		case record := <-s.records:
+			s.writeRecord(record)
+		case <-ctx.Done():
+			// Drain any remaining records before closing.
+			for {
+				select {
+				case record := <-s.records:
+					s.writeRecord(record)
+				default:
+					if err := s.file.Close(); err != nil {
+						log.Error().Err(err).Msg("Failed to close audit log")
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeRecord writes a single record to the file, rotating it if required.
+// Each record is chained to its predecessor by the hash of the predecessor's line.
+func (s *Service) writeRecord(record *auditor.Record) {
+	record.PrevHash = s.lastHash
+	data, err := json.Marshal(record)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal audit record")
+		return
+	}
+	s.lastHash = lineHash(data)
+	data = append(data, '\n')
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			log.Error().Err(err).Msg("Failed to rotate audit log")
+		}
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to write audit record")
+	}
+}
+
+// loadLastHash obtains the hash of the last record written to the audit log, looking in the most recent
+// backup if the audit log is empty.
+func (s *Service) loadLastHash() (string, error) {
+	line, err := lastLine(s.path)
+	if err != nil {
+		return "", err
+	}
+	if line == nil {
+		line, err = lastLine(fmt.Sprintf("%s.1", s.path))
+		if err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return "", err
+		}
+	}
+	if line == nil {
+		return "", nil
+	}
+	return lineHash(line), nil
+}
+
+// open opens the audit log file for appending.
+func (s *Service) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate rotates the audit log file, keeping up to the maximum number of backups.
+// The audit log file is always reopened, even if rotation fails, so that records continue to be written.
+func (s *Service) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return errors.Wrap(err, "failed to close audit log")
+	}
+	rotateErr := s.shift()
+	if err := s.open(); err != nil {
+		return errors.Wrap(err, "failed to reopen audit log")
+	}
+	return rotateErr
+}
+
+// shift moves the audit log file and its backups along by one.
+func (s *Service) shift() error {
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil {
+			return errors.Wrap(err, "failed to remove audit log")
+		}
+		return nil
+	}
+	for i := s.maxBackups - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", s.path, i)
+		if _, err := os.Stat(from); err == nil {
+			if err := os.Rename(from, fmt.Sprintf("%s.%d", s.path, i+1)); err != nil {
+				return errors.Wrap(err, "failed to rename audit log backup")
+			}
+		}
+	}
+	if err := os.Rename(s.path, fmt.Sprintf("%s.1", s.path)); err != nil {
+		return errors.Wrap(err, "failed to rename audit log")
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/services/auditor"
+	"github.com/attestantio/dirk/services/auditor/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+
+	tests := []struct {
+		name       string
+		path       string
+		bufferSize int
+		err        string
+	}{
+		{
+			name: "PathMissing",
+			err:  "problem with parameters: no path specified",
+		},
+		{
+			name: "PathBad",
+			path: "/no/such/path/audit.log",
+			err:  "failed to open audit log: open /no/such/path/audit.log: no such file or directory",
+		},
+		{
+			name:       "BufferSizeBad",
+			path:       filepath.Join(base, "audit.log"),
+			bufferSize: -1,
+			err:        "problem with parameters: buffer size must be positive",
+		},
+		{
+			name: "Good",
+			path: filepath.Join(base, "audit.log"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := []file.Parameter{
+				file.WithPath(test.path),
+			}
+			if test.bufferSize != 0 {
+				params = append(params, file.WithBufferSize(test.bufferSize))
+			}
+			_, err := file.New(ctx, params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func readRecords(t *testing.T, path string) []*auditor.Record {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	records := make([]*auditor.Record, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		record := &auditor.Record{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), record))
+		records = append(records, record)
+	}
+	require.NoError(t, scanner.Err())
+	return records
+}
+
+func TestAudit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	path := filepath.Join(base, "audit.log")
+
+	s, err := file.New(ctx, file.WithPath(path))
+	require.NoError(t, err)
+
+	slot := uint64(12345)
+	for i := 0; i < 10; i++ {
+		s.Audit(ctx, &auditor.Record{
+			Timestamp: time.Unix(1600000000, 0).UTC(),
+			RequestID: fmt.Sprintf("%d", i),
+			Client:    "client1",
+			Account:   "wallet/account",
+			Action:    "Sign beacon proposal",
+			Slot:      &slot,
+			Result:    "Approved",
+		})
+	}
+	cancel()
+	<-s.Done()
+
+	records := readRecords(t, path)
+	require.Len(t, records, 10)
+	for i, record := range records {
+		assert.Equal(t, fmt.Sprintf("%d", i), record.RequestID)
+		assert.Equal(t, slot, *record.Slot)
+	}
+
+	// Confirm the format is stable.
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `{"timestamp":"2020-09-13T12:26:40Z","request_id":"0","client":"client1","account":"wallet/account","action":"Sign beacon proposal","slot":12345,"result":"Approved"}`)
+}
+
+func TestRotate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	path := filepath.Join(base, "audit.log")
+
+	// Each record is 126 bytes plus 82 bytes for the hash of its predecessor, so a max size of 500 results
+	// in 2 records per file.
+	s, err := file.New(ctx,
+		file.WithPath(path),
+		file.WithMaxSize(500),
+		file.WithMaxBackups(2),
+	)
+	require.NoError(t, err)
+
+	for i := 0; i < 7; i++ {
+		s.Audit(ctx, &auditor.Record{
+			Timestamp: time.Unix(1600000000, 0).UTC(),
+			RequestID: fmt.Sprintf("%d", i),
+			Client:    "client1",
+			Account:   "wallet/account",
+			Action:    "Sign",
+			Result:    "Approved",
+		})
+	}
+	cancel()
+	<-s.Done()
+
+	records := readRecords(t, path)
+	require.Len(t, records, 1)
+	assert.Equal(t, "6", records[0].RequestID)
+	records = readRecords(t, fmt.Sprintf("%s.1", path))
+	require.Len(t, records, 2)
+	assert.Equal(t, "4", records[0].RequestID)
+	records = readRecords(t, fmt.Sprintf("%s.2", path))
+	require.Len(t, records, 2)
+	assert.Equal(t, "2", records[0].RequestID)
+	_, err = os.Stat(fmt.Sprintf("%s.3", path))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestChain(t *testing.T) {
+	base, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(base)
+	path := filepath.Join(base, "audit.log")
+
+	// Write records across two instances of the service, to confirm the chain is continued on restart.
+	for run := 0; run < 2; run++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		s, err := file.New(ctx,
+			file.WithPath(path),
+			file.WithMaxSize(500),
+			file.WithMaxBackups(5),
+		)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			s.Audit(ctx, &auditor.Record{
+				Timestamp:       time.Unix(1600000000, 0).UTC(),
+				RequestID:       fmt.Sprintf("%d-%d", run, i),
+				Client:          "client1",
+				Account:         "wallet/account",
+				Action:          "Sign",
+				Result:          "Signed",
+				SignatureDigest: "0x01",
+			})
+		}
+		cancel()
+		<-s.Done()
+	}
+
+	paths := []string{
+		fmt.Sprintf("%s.2", path),
+		fmt.Sprintf("%s.1", path),
+		path,
+	}
+	records, err := file.VerifyChain(paths...)
+	require.NoError(t, err)
+	assert.Equal(t, 6, records)
+	assert.Empty(t, readRecords(t, paths[0])[0].PrevHash)
+	assert.NotEmpty(t, readRecords(t, path)[0].PrevHash)
+
+	// Tamper with a record in the middle of the chain.
+	data, err := ioutil.ReadFile(paths[1])
+	require.NoError(t, err)
+	data = bytes.Replace(data, []byte(`"result":"Signed"`), []byte(`"result":"Denied"`), 1)
+	require.NoError(t, ioutil.WriteFile(paths[1], data, 0600))
+	_, err = file.VerifyChain(paths...)
+	assert.EqualError(t, err, fmt.Sprintf("hash chain broken at %s:2", paths[1]))
+
+	// Missing file.
+	_, err = file.VerifyChain(filepath.Join(base, "missing"))
+	assert.Contains(t, err.Error(), "failed to open audit log")
+}
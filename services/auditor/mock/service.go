@@ -0,0 +1,50 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"context"
+	"sync"
+
+	"github.com/attestantio/dirk/services/auditor"
+)
+
+// Service is a mock audit service that keeps records in memory.
+type Service struct {
+	mutex   sync.Mutex
+	records []*auditor.Record
+}
+
+// New creates a new mock audit service.
+func New() *Service {
+	return &Service{
+		records: make([]*auditor.Record, 0),
+	}
+}
+
+// Audit records the decision for a request.
+func (s *Service) Audit(ctx context.Context, record *auditor.Record) {
+	s.mutex.Lock()
+	s.records = append(s.records, record)
+	s.mutex.Unlock()
+}
+
+// Records returns the records audited to date.
+func (s *Service) Records() []*auditor.Record {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := make([]*auditor.Record, len(s.records))
+	copy(res, s.records)
+	return res
+}
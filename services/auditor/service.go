@@ -0,0 +1,48 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auditor
+
+import (
+	"context"
+	"time"
+)
+
+// Record is an audit record for a single request.
+// The JSON representation of a record is stable, and should only be extended.
+type Record struct {
+	Timestamp   time.Time `json:"timestamp"`
+	RequestID   string    `json:"request_id,omitempty"`
+	Client      string    `json:"client"`
+	IP          string    `json:"ip,omitempty"`
+	Account     string    `json:"account"`
+	PubKey      string    `json:"pubkey,omitempty"`
+	Action      string    `json:"action"`
+	Domain      string    `json:"domain,omitempty"`
+	Slot        *uint64   `json:"slot,omitempty"`
+	Epoch       *uint64   `json:"epoch,omitempty"`
+	SourceEpoch *uint64   `json:"source_epoch,omitempty"`
+	TargetEpoch *uint64   `json:"target_epoch,omitempty"`
+	Result      string    `json:"result"`
+	// SignatureDigest is the hash of the signature returned for a signing request.
+	SignatureDigest string `json:"signature_digest,omitempty"`
+	// PrevHash is the hash of the previous record in the log, if the audit service chains its records.
+	PrevHash string `json:"prev_hash,omitempty"`
+}
+
+// Service is the interface for an audit service.
+type Service interface {
+	// Audit records the decision for a request.
+	// It should not block for a significant period of time.
+	Audit(ctx context.Context, record *Record)
+}
@@ -0,0 +1,34 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package backup
+
+import (
+	"context"
+)
+
+// Service is the interface for a service that backs up slashing protection data.
+type Service interface {
+	// Backup takes a snapshot of the slashing protection data, returning the name of the backup.
+	Backup(ctx context.Context) (string, error)
+}
+
+// Destination is the interface for a location in which backups are held.
+type Destination interface {
+	// Write writes a backup with the given name.  A backup must only be visible once it is complete.
+	Write(ctx context.Context, name string, data []byte) error
+	// List lists the names of the backups.
+	List(ctx context.Context) ([]string, error)
+	// Delete deletes the backup with the given name.
+	Delete(ctx context.Context, name string) error
+}
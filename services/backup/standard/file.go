@@ -0,0 +1,75 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// FileDestination holds backups in a directory of the filesystem.
+type FileDestination struct {
+	dir string
+}
+
+// NewFileDestination creates a destination that holds backups in the given directory, creating it if required.
+func NewFileDestination(dir string) (*FileDestination, error) {
+	if dir == "" {
+		return nil, errors.New("no directory specified")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "failed to create directory")
+	}
+	return &FileDestination{
+		dir: dir,
+	}, nil
+}
+
+// Write writes a backup with the given name.
+// The backup is written to a temporary file and moved in to place when complete.
+func (d *FileDestination) Write(ctx context.Context, name string, data []byte) error {
+	path := filepath.Join(d.dir, name)
+	tmpPath := fmt.Sprintf("%s.tmp", path)
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// List lists the names of the backups.
+func (d *FileDestination) List(ctx context.Context) ([]string, error) {
+	files, err := ioutil.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || strings.HasSuffix(file.Name(), ".tmp") {
+			continue
+		}
+		names = append(names, file.Name())
+	}
+	return names, nil
+}
+
+// Delete deletes the backup with the given name.
+func (d *FileDestination) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(d.dir, name))
+}
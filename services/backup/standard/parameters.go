@@ -0,0 +1,106 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/backup"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel    zerolog.Level
+	rules       rules.Service
+	destination backup.Destination
+	interval    time.Duration
+	retain      int
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithRules sets the rules whose slashing protection data is backed up.
+func WithRules(rules rules.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rules = rules
+	})
+}
+
+// WithDestination sets the destination in which backups are held.
+func WithDestination(destination backup.Destination) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.destination = destination
+	})
+}
+
+// WithInterval sets the interval at which backups are taken.
+// A value of 0 disables scheduled backups.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithRetain sets the number of backups to retain.
+// A value of 0 retains all backups.
+func WithRetain(retain int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.retain = retain
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.rules == nil {
+		return nil, errors.New("no rules specified")
+	}
+	if parameters.destination == nil {
+		return nil, errors.New("no destination specified")
+	}
+	if parameters.interval < 0 {
+		return nil, errors.New("interval cannot be negative")
+	}
+	if parameters.retain < 0 {
+		return nil, errors.New("retain cannot be negative")
+	}
+
+	return &parameters, nil
+}
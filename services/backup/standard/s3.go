@@ -0,0 +1,94 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+)
+
+// S3Destination holds backups in an S3-compatible object store.
+type S3Destination struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+}
+
+// NewS3Destination creates a destination that holds backups in the given bucket, under the given prefix.
+func NewS3Destination(client s3iface.S3API, bucket string, prefix string) (*S3Destination, error) {
+	if client == nil {
+		return nil, errors.New("no client specified")
+	}
+	if bucket == "" {
+		return nil, errors.New("no bucket specified")
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &S3Destination{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// Write writes a backup with the given name.
+// Objects in the store only become visible once they have been written in full.
+func (d *S3Destination) Write(ctx context.Context, name string, data []byte) error {
+	_, err := d.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(fmt.Sprintf("%s%s", d.prefix, name)),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+// List lists the names of the backups.
+func (d *S3Destination) List(ctx context.Context) ([]string, error) {
+	names := make([]string, 0)
+	err := d.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(d.bucket),
+		Prefix: aws.String(d.prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, item := range page.Contents {
+			name := strings.TrimPrefix(aws.StringValue(item.Key), d.prefix)
+			// Objects in sub-directories are not backups.
+			if !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// Delete deletes the backup with the given name.
+func (d *S3Destination) Delete(ctx context.Context, name string) error {
+	_, err := d.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(d.bucket),
+		Key:    aws.String(fmt.Sprintf("%s%s", d.prefix, name)),
+	})
+	return err
+}
@@ -0,0 +1,167 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/backup"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+const (
+	// namePrefix is the prefix of the names of backups.
+	namePrefix = "slashing-protection-"
+	// nameSuffix is the suffix of the names of backups.
+	nameSuffix = ".bak"
+	// nameTimeFormat is the format of the time in the names of backups.  It is fixed width, so that
+	// backups sort by name in the order in which they were taken.
+	nameTimeFormat = "20060102T150405.000000000Z"
+)
+
+// Service backs up slashing protection data to a destination, on request and at a regular interval.
+type Service struct {
+	// mu serialises backups, so that retention is applied to a consistent list of backups.
+	mu          sync.Mutex
+	rules       rules.Service
+	destination backup.Destination
+	retain      int
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new backup service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "backup").Str("impl", "standard").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	s := &Service{
+		rules:       parameters.rules,
+		destination: parameters.destination,
+		retain:      parameters.retain,
+		done:        make(chan struct{}),
+	}
+
+	var scheduleCtx context.Context
+	scheduleCtx, s.cancel = context.WithCancel(ctx)
+	if parameters.interval > 0 {
+		go s.schedule(scheduleCtx, parameters.interval)
+	} else {
+		close(s.done)
+	}
+
+	return s, nil
+}
+
+// Close stops scheduled backups, waiting for any backup in progress to complete.
+// It must be called before the rules are closed.
+func (s *Service) Close(ctx context.Context) error {
+	s.cancel()
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return nil
+}
+
+// Backup takes a snapshot of the slashing protection data, returning the name of the backup.
+// Once the backup is written, backups beyond the number to retain are deleted, oldest first.
+func (s *Service) Backup(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf := new(bytes.Buffer)
+	if err := s.rules.BackupSlashingProtection(ctx, buf); err != nil {
+		return "", errors.Wrap(err, "failed to take snapshot")
+	}
+	name := fmt.Sprintf("%s%s%s", namePrefix, time.Now().UTC().Format(nameTimeFormat), nameSuffix)
+	if err := s.destination.Write(ctx, name, buf.Bytes()); err != nil {
+		return "", errors.Wrap(err, "failed to write backup")
+	}
+	log.Info().Str("name", name).Int("size", buf.Len()).Msg("Backed up slashing protection")
+
+	if err := s.applyRetention(ctx); err != nil {
+		// The backup itself succeeded, so this is not returned as an error.
+		log.Warn().Err(err).Msg("Failed to delete old backups")
+	}
+
+	return name, nil
+}
+
+// applyRetention deletes the oldest backups beyond the number to retain.
+func (s *Service) applyRetention(ctx context.Context) error {
+	if s.retain == 0 {
+		return nil
+	}
+
+	names, err := s.destination.List(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to list backups")
+	}
+	backups := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, namePrefix) && strings.HasSuffix(name, nameSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= s.retain {
+		return nil
+	}
+	sort.Strings(backups)
+	for _, name := range backups[:len(backups)-s.retain] {
+		if err := s.destination.Delete(ctx, name); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to delete backup %s", name))
+		}
+		log.Debug().Str("name", name).Msg("Deleted old backup")
+	}
+
+	return nil
+}
+
+// schedule takes backups at the given interval until the context is cancelled.
+func (s *Service) schedule(ctx context.Context, interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Backup(ctx); err != nil {
+				log.Error().Err(err).Msg("Failed to back up slashing protection")
+			}
+		}
+	}
+}
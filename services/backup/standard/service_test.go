@@ -0,0 +1,251 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/backup"
+	"github.com/attestantio/dirk/services/backup/standard"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// mockS3 is an in-memory S3 client.
+type mockS3 struct {
+	s3iface.S3API
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *mockS3) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) DeleteObjectWithContext(ctx aws.Context, input *s3.DeleteObjectInput, _ ...request.Option) (*s3.DeleteObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key))
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (m *mockS3) ListObjectsV2PagesWithContext(ctx aws.Context, input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	output := &s3.ListObjectsV2Output{}
+	for key := range m.objects {
+		if strings.HasPrefix(key, aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Prefix)) {
+			output.Contents = append(output.Contents, &s3.Object{Key: aws.String(strings.TrimPrefix(key, aws.StringValue(input.Bucket)+"/"))})
+		}
+	}
+	fn(output, true)
+	return nil
+}
+
+// setupRules creates rules with a single signed attestation.
+func setupRules(t *testing.T) *standardrules.Service {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+	rulesSvc, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { rulesSvc.Close(ctx) })
+
+	domain := make([]byte, 32)
+	domain[0] = 0x01
+	require.Equal(t, rules.APPROVED, rulesSvc.OnSignBeaconAttestation(ctx, &rules.ReqMetadata{PubKey: make([]byte, 48)}, &rules.SignBeaconAttestationData{
+		Domain: domain,
+		Source: &rules.Checkpoint{Epoch: 1},
+		Target: &rules.Checkpoint{Epoch: 2},
+	}))
+	return rulesSvc
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	rulesSvc := setupRules(t)
+	destination, err := standard.NewFileDestination(t.TempDir())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "RulesMissing",
+			params: []standard.Parameter{
+				standard.WithDestination(destination),
+			},
+			err: "problem with parameters: no rules specified",
+		},
+		{
+			name: "DestinationMissing",
+			params: []standard.Parameter{
+				standard.WithRules(rulesSvc),
+			},
+			err: "problem with parameters: no destination specified",
+		},
+		{
+			name: "IntervalNegative",
+			params: []standard.Parameter{
+				standard.WithRules(rulesSvc),
+				standard.WithDestination(destination),
+				standard.WithInterval(-1 * time.Second),
+			},
+			err: "problem with parameters: interval cannot be negative",
+		},
+		{
+			name: "RetainNegative",
+			params: []standard.Parameter{
+				standard.WithRules(rulesSvc),
+				standard.WithDestination(destination),
+				standard.WithRetain(-1),
+			},
+			err: "problem with parameters: retain cannot be negative",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithRules(rulesSvc),
+				standard.WithDestination(destination),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+	rulesSvc := setupRules(t)
+
+	fileDestination, err := standard.NewFileDestination(t.TempDir())
+	require.NoError(t, err)
+	s3Destination, err := standard.NewS3Destination(&mockS3{objects: make(map[string][]byte)}, "bucket", "/backups/")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		destination backup.Destination
+	}{
+		{
+			name:        "File",
+			destination: fileDestination,
+		},
+		{
+			name:        "S3",
+			destination: s3Destination,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Objects that are not backups are ignored by retention.
+			require.NoError(t, test.destination.Write(ctx, "other", []byte("other")))
+
+			service, err := standard.New(ctx,
+				standard.WithRules(rulesSvc),
+				standard.WithDestination(test.destination),
+				standard.WithRetain(2),
+			)
+			require.NoError(t, err)
+
+			names := make([]string, 3)
+			for i := range names {
+				names[i], err = service.Backup(ctx)
+				require.NoError(t, err)
+			}
+
+			// Only the most recent backups are retained.
+			listed, err := test.destination.List(ctx)
+			require.NoError(t, err)
+			sort.Strings(listed)
+			require.Equal(t, []string{"other", names[1], names[2]}, listed)
+		})
+	}
+}
+
+func TestBackupContents(t *testing.T) {
+	ctx := context.Background()
+	rulesSvc := setupRules(t)
+	dir := t.TempDir()
+	destination, err := standard.NewFileDestination(dir)
+	require.NoError(t, err)
+	service, err := standard.New(ctx,
+		standard.WithRules(rulesSvc),
+		standard.WithDestination(destination),
+	)
+	require.NoError(t, err)
+
+	name, err := service.Backup(ctx)
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(dir + "/" + name)
+	require.NoError(t, err)
+	protection, err := rulesSvc.ParseSlashingProtectionBackup(ctx, bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Len(t, protection, 1)
+	require.Equal(t, int64(2), protection[[48]byte{}].HighestAttestedTargetEpoch)
+}
+
+func TestScheduledBackup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rulesSvc := setupRules(t)
+	destination, err := standard.NewFileDestination(t.TempDir())
+	require.NoError(t, err)
+	service, err := standard.New(ctx,
+		standard.WithRules(rulesSvc),
+		standard.WithDestination(destination),
+		standard.WithInterval(10*time.Millisecond),
+		standard.WithRetain(1),
+	)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		names, err := destination.List(ctx)
+		return err == nil && len(names) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// Close stops scheduled backups.
+	require.NoError(t, service.Close(ctx))
+}
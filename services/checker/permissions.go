@@ -35,6 +35,8 @@ func DumpPermissions(perms map[string][]*Permissions) {
 				var pathDescriptor string
 				if perm.Path == "" {
 					pathDescriptor = "all accounts"
+				} else if len(strings.TrimPrefix(perm.Path, "0x")) == 96 {
+					pathDescriptor = fmt.Sprintf("the account with public key %s", perm.Path)
 				} else {
 					pathDescriptor = fmt.Sprintf("accounts matching the path %q", perm.Path)
 				}
@@ -13,7 +13,10 @@
 
 package checker
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Credentials are the credentials used to check.
 type Credentials struct {
@@ -28,4 +31,14 @@ type Credentials struct {
 // Service is the interface for checking client access to accounts.
 type Service interface {
 	Check(ctx context.Context, credentials *Credentials, account string, operation string) bool
+	// ReadOnly returns true if the client may only carry out operations that do not sign or change state.
+	ReadOnly(ctx context.Context, credentials *Credentials) bool
+	// ActionPermitted returns true if the client is permitted to carry out the given action.
+	ActionPermitted(ctx context.Context, credentials *Credentials, action string) bool
+	// CheckSequence returns true if the sequence number supplied by the client for the given session is acceptable.
+	// A sequence number of 0 means that the client did not supply one.
+	CheckSequence(ctx context.Context, credentials *Credentials, session string, sequence uint64) bool
+	// LastActivity returns the time of the last request from each known client.
+	// Clients that have not made a request since startup have a zero time.
+	LastActivity(ctx context.Context) map[string]time.Time
 }
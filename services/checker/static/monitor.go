@@ -13,6 +13,14 @@
 
 package static
 
+import (
+	"time"
+)
+
 // noopMonitor is a monitor that does nothing, used in plae of nil if an
 // external monitor is not supplied.
 type noopMonitor struct{}
+
+// ClientActive is called when a known client makes a request.
+func (n *noopMonitor) ClientActive(client string, at time.Time) {
+}
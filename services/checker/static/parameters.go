@@ -14,11 +14,13 @@
 package static
 
 import (
+	"encoding/hex"
 	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/fetcher"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -26,10 +28,14 @@ import (
 )
 
 type parameters struct {
-	logLevel    zerolog.Level
-	monitor     metrics.CheckerMonitor
-	permissions map[string][]*checker.Permissions
-	access      map[string][]*path
+	logLevel        zerolog.Level
+	monitor         metrics.CheckerMonitor
+	permissions     map[string][]*checker.Permissions
+	readOnlyClients []string
+	clientActions   map[string][]string
+	sequenceClients []string
+	fetcher         fetcher.Service
+	access          map[string][]*path
 }
 
 // Parameter is the interface for service parameters.
@@ -64,6 +70,36 @@ func WithPermissions(permissions map[string][]*checker.Permissions) Parameter {
 	})
 }
 
+// WithReadOnlyClients sets the clients that may only carry out operations that do not sign or change state.
+func WithReadOnlyClients(clients []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.readOnlyClients = clients
+	})
+}
+
+// WithClientActions sets the actions that each listed client is permitted to carry out.
+// Clients that are not listed are permitted to carry out all actions.
+func WithClientActions(clientActions map[string][]string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientActions = clientActions
+	})
+}
+
+// WithSequenceClients sets the clients that must supply increasing sequence numbers with their requests.
+func WithSequenceClients(clients []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.sequenceClients = clients
+	})
+}
+
+// WithFetcher sets the fetcher used to obtain the public keys of accounts, for permissions given by public key.
+// It is only used when the service is created; permission updates continue to use the original fetcher.
+func WithFetcher(fetcher fetcher.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.fetcher = fetcher
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -92,6 +128,14 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 
 		paths := make([]*path, len(permissions))
 		for i, permission := range permissions {
+			if pubKey := permissionPubKey(permission.Path); pubKey != nil {
+				log.Trace().Str("pubkey", fmt.Sprintf("%#x", pubKey)).Strs("operations", permission.Operations).Msg("Adding permission")
+				paths[i] = &path{
+					pubKey:     pubKey,
+					operations: permission.Operations,
+				}
+				continue
+			}
 			walletName, accountName, err := e2wallet.WalletAndAccountNames(permission.Path)
 			if err != nil {
 				return nil, fmt.Errorf("invalid account path %s", permission.Path)
@@ -117,9 +161,47 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		parameters.access[client] = paths
 	}
 
+	for _, client := range parameters.readOnlyClients {
+		if client == "" {
+			return nil, errors.New("invalid client name for read-only client")
+		}
+	}
+
+	for _, client := range parameters.sequenceClients {
+		if client == "" {
+			return nil, errors.New("invalid client name for sequence client")
+		}
+	}
+
+	for client, actions := range parameters.clientActions {
+		if client == "" {
+			return nil, errors.New("invalid client name for client actions")
+		}
+		if len(actions) == 0 {
+			return nil, fmt.Errorf("no actions for client %s", client)
+		}
+		for _, action := range actions {
+			if action == "" {
+				return nil, fmt.Errorf("invalid action for client %s", client)
+			}
+		}
+	}
+
 	return &parameters, nil
 }
 
+// permissionPubKey returns the public key given by a permission path, or nil if the path is not a public key.
+func permissionPubKey(path string) []byte {
+	if len(strings.TrimPrefix(path, "0x")) != 96 {
+		return nil
+	}
+	pubKey, err := hex.DecodeString(strings.TrimPrefix(path, "0x"))
+	if err != nil {
+		return nil
+	}
+	return pubKey
+}
+
 // regexify turns a name in to a regex.  It attaches anchors if required, and also makes the regex case-insensitive.
 func regexify(name string) (*regexp.Regexp, error) {
 	// Empty equates to all.
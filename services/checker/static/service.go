@@ -14,31 +14,78 @@
 package static
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/fetcher"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
 // Service checks access against a static list.
 type Service struct {
-	monitor metrics.CheckerMonitor
-	access  map[string][]*path
+	monitor     metrics.CheckerMonitor
+	fetcher     fetcher.Service
+	policyMu    sync.RWMutex
+	policy      *policy
+	sequencesMu sync.Mutex
+	sequences   map[string]*sequenceState
 }
 
+// policy is the set of permissions applied by the checker.
+// It can be replaced whilst the service is running.
+type policy struct {
+	access          map[string][]*path
+	readOnlyClients map[string]bool
+	clientActions   map[string]map[string]bool
+	sequenceClients map[string]bool
+	// activity is the time of the last request from each known client, in Unix nanoseconds.
+	// The map is fixed for the policy so that it is bounded, and can be read without locking.
+	activity map[string]*int64
+}
+
+// sequenceState is the sequence state for a client.
+type sequenceState struct {
+	session  string
+	sequence uint64
+}
+
+// path is a permission for a set of accounts, given by either wallet and account regular expressions or a public key.
 type path struct {
 	wallet     *regexp.Regexp
 	account    *regexp.Regexp
+	pubKey     []byte
 	operations []string
 }
 
+// permission is the result of checking an operation against the operations of a single path.
+type permission int
+
+const (
+	// permissionUnspecified means that the path neither allows nor denies the operation.
+	permissionUnspecified permission = iota
+	// permissionAllowed means that the path allows the operation.
+	permissionAllowed
+	// permissionDenied means that the path explicitly denies the operation.
+	permissionDenied
+)
+
+// explicitOperations are operations that are only allowed if named explicitly; "All" does not include them.
+var explicitOperations = map[string]bool{
+	"recover account": true,
+}
+
 // module-wide log.
 var log zerolog.Logger
 
@@ -55,14 +102,88 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		log = log.Level(parameters.logLevel)
 	}
 
+	if parameters.fetcher == nil && hasPubKeyPaths(parameters.access) {
+		return nil, errors.New("problem with parameters: permissions by public key require a fetcher")
+	}
+
 	s := &Service{
-		monitor: parameters.monitor,
-		access:  parameters.access,
+		monitor:   parameters.monitor,
+		fetcher:   parameters.fetcher,
+		policy:    newPolicy(parameters, nil),
+		sequences: make(map[string]*sequenceState),
 	}
 
 	return s, nil
 }
 
+// newPolicy creates a policy from checked parameters.
+// The activity of clients in the previous policy, if any, is carried over.
+func newPolicy(parameters *parameters, previous *policy) *policy {
+	readOnlyClients := make(map[string]bool, len(parameters.readOnlyClients))
+	for _, client := range parameters.readOnlyClients {
+		readOnlyClients[client] = true
+	}
+
+	clientActions := make(map[string]map[string]bool, len(parameters.clientActions))
+	for client, actions := range parameters.clientActions {
+		clientActions[client] = make(map[string]bool, len(actions))
+		for _, action := range actions {
+			clientActions[client][strings.ToLower(action)] = true
+		}
+	}
+
+	sequenceClients := make(map[string]bool, len(parameters.sequenceClients))
+	for _, client := range parameters.sequenceClients {
+		sequenceClients[client] = true
+	}
+
+	activity := make(map[string]*int64, len(parameters.access))
+	for client := range parameters.access {
+		if previous != nil && previous.activity[client] != nil {
+			activity[client] = previous.activity[client]
+		} else {
+			activity[client] = new(int64)
+		}
+	}
+
+	return &policy{
+		access:          parameters.access,
+		readOnlyClients: readOnlyClients,
+		clientActions:   clientActions,
+		sequenceClients: sequenceClients,
+		activity:        activity,
+	}
+}
+
+// currentPolicy returns the policy currently in force.
+func (s *Service) currentPolicy() *policy {
+	s.policyMu.RLock()
+	defer s.policyMu.RUnlock()
+	return s.policy
+}
+
+// UpdatePermissions replaces the permissions applied by the checker.
+// Only the permission parameters (permissions, read-only clients, client actions and sequence clients)
+// are used; all other parameters are ignored.  The parameters are checked before the permissions are
+// applied, so on error the existing permissions remain in force.  Requests already being checked
+// complete with the permissions in force when they started.
+func (s *Service) UpdatePermissions(ctx context.Context, params ...Parameter) error {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return errors.Wrap(err, "problem with parameters")
+	}
+	if s.fetcher == nil && hasPubKeyPaths(parameters.access) {
+		return errors.New("problem with parameters: permissions by public key require a fetcher")
+	}
+
+	s.policyMu.Lock()
+	s.policy = newPolicy(parameters, s.policy)
+	s.policyMu.Unlock()
+	log.Info().Int("clients", len(parameters.access)).Msg("Updated permissions")
+
+	return nil
+}
+
 // Check checks the client to see if the account is allowed.
 func (s *Service) Check(ctx context.Context, credentials *checker.Credentials, account string, operation string) bool {
 	log.Trace().Str("account", account).Str("operation", operation).Msg("Checking permissions for operation")
@@ -87,28 +208,181 @@ func (s *Service) Check(ctx context.Context, credentials *checker.Credentials, a
 		return false
 	}
 
-	paths, exists := s.access[credentials.Client]
+	policy := s.currentPolicy()
+	paths, exists := policy.access[credentials.Client]
 	if !exists {
 		log.Warn().Str("result", "denied").Msg("No rules for client")
 		return false
 	}
+	s.recordActivity(policy, credentials.Client)
 
-	antiOperation := fmt.Sprintf("~%s", operation)
+	// All matching paths are checked, as an explicit denial in any path overrides an allowance in another.
+	var pubKeys [][]byte
+	pubKeysObtained := false
+	allowed := false
 	for _, path := range paths {
-		if path.wallet.Match([]byte(walletName)) && path.account.Match([]byte(accountName)) {
-			for i := range path.operations {
-				if strings.EqualFold(path.operations[i], "none") || strings.EqualFold(path.operations[i], antiOperation) {
-					log.Trace().Str("result", "denied").Msg("Negative permission matched")
-					return false
-				}
-				if strings.EqualFold(path.operations[i], "all") || strings.EqualFold(path.operations[i], operation) {
-					log.Trace().Str("result", "succeeded").Msg("Positive permission matched")
-					return true
-				}
+		if path.pubKey != nil {
+			if !pubKeysObtained {
+				pubKeys = s.accountPubKeys(ctx, account)
+				pubKeysObtained = true
 			}
+			if !containsPubKey(pubKeys, path.pubKey) {
+				continue
+			}
+		} else if !path.wallet.Match([]byte(walletName)) || !path.account.Match([]byte(accountName)) {
+			continue
+		}
+		switch path.check(operation) {
+		case permissionDenied:
+			log.Trace().Str("result", "denied").Msg("Negative permission matched")
+			return false
+		case permissionAllowed:
+			allowed = true
 		}
 	}
 
+	if allowed {
+		log.Trace().Str("result", "succeeded").Msg("Positive permission matched")
+		return true
+	}
 	log.Trace().Str("result", "denied").Msg("No matching rules")
 	return false
 }
+
+// check checks an operation against the operations of the path, which are applied in order.
+func (p *path) check(operation string) permission {
+	antiOperation := fmt.Sprintf("~%s", operation)
+	for i := range p.operations {
+		if strings.EqualFold(p.operations[i], "none") || strings.EqualFold(p.operations[i], antiOperation) {
+			return permissionDenied
+		}
+		if strings.EqualFold(p.operations[i], "all") && !explicitOperations[strings.ToLower(operation)] {
+			return permissionAllowed
+		}
+		if strings.EqualFold(p.operations[i], operation) {
+			return permissionAllowed
+		}
+	}
+	return permissionUnspecified
+}
+
+// accountPubKeys returns the public keys of the account, including the composite public key of a
+// distributed account.  If the account cannot be obtained, for example because it does not yet exist,
+// no keys are returned.
+func (s *Service) accountPubKeys(ctx context.Context, account string) [][]byte {
+	_, acc, err := s.fetcher.FetchAccount(ctx, account)
+	if err != nil {
+		return nil
+	}
+	pubKeys := make([][]byte, 0, 2)
+	if provider, isProvider := acc.(e2wtypes.AccountPublicKeyProvider); isProvider {
+		pubKeys = append(pubKeys, provider.PublicKey().Marshal())
+	}
+	if provider, isProvider := acc.(e2wtypes.AccountCompositePublicKeyProvider); isProvider {
+		pubKeys = append(pubKeys, provider.CompositePublicKey().Marshal())
+	}
+	return pubKeys
+}
+
+// containsPubKey returns true if the public key is in the list of public keys.
+func containsPubKey(pubKeys [][]byte, pubKey []byte) bool {
+	for i := range pubKeys {
+		if bytes.Equal(pubKeys[i], pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPubKeyPaths returns true if any client has a permission given by public key.
+func hasPubKeyPaths(access map[string][]*path) bool {
+	for _, paths := range access {
+		for _, path := range paths {
+			if path.pubKey != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadOnly returns true if the client may only carry out operations that do not sign or change state.
+func (s *Service) ReadOnly(ctx context.Context, credentials *checker.Credentials) bool {
+	if credentials == nil {
+		return false
+	}
+	return s.currentPolicy().readOnlyClients[credentials.Client]
+}
+
+// ActionPermitted returns true if the client is permitted to carry out the given action.
+func (s *Service) ActionPermitted(ctx context.Context, credentials *checker.Credentials, action string) bool {
+	if credentials == nil {
+		// Credentials are checked by the access check; there is no allow-list to apply here.
+		return true
+	}
+	actions, exists := s.currentPolicy().clientActions[credentials.Client]
+	if !exists {
+		// No allow-list for this client, so all actions are permitted.
+		return true
+	}
+	return actions[strings.ToLower(action)]
+}
+
+// CheckSequence returns true if the sequence number supplied by the client for the given session is acceptable.
+// Clients that are not required to supply sequence numbers are always accepted.  For other clients the sequence
+// number must be greater than the last sequence number seen from the client in the same session; a new session
+// resets the sequence.
+func (s *Service) CheckSequence(ctx context.Context, credentials *checker.Credentials, session string, sequence uint64) bool {
+	if credentials == nil || !s.currentPolicy().sequenceClients[credentials.Client] {
+		return true
+	}
+	log := log.With().Str("client", credentials.Client).Str("session", session).Uint64("sequence", sequence).Logger()
+	if sequence == 0 {
+		log.Warn().Str("result", "denied").Msg("No sequence number")
+		return false
+	}
+
+	s.sequencesMu.Lock()
+	defer s.sequencesMu.Unlock()
+	state, exists := s.sequences[credentials.Client]
+	if !exists || state.session != session {
+		log.Trace().Msg("New session")
+		s.sequences[credentials.Client] = &sequenceState{
+			session:  session,
+			sequence: sequence,
+		}
+		return true
+	}
+	if sequence <= state.sequence {
+		log.Warn().Uint64("previous_sequence", state.sequence).Str("result", "denied").Msg("Sequence number not greater than previous; probable replay")
+		return false
+	}
+	state.sequence = sequence
+	return true
+}
+
+// recordActivity records a request from a known client.
+func (s *Service) recordActivity(policy *policy, client string) {
+	lastActivity, exists := policy.activity[client]
+	if !exists {
+		return
+	}
+	now := time.Now()
+	atomic.StoreInt64(lastActivity, now.UnixNano())
+	s.monitor.ClientActive(client, now)
+}
+
+// LastActivity returns the time of the last request from each known client.
+// Clients that have not made a request since startup have a zero time.
+func (s *Service) LastActivity(ctx context.Context) map[string]time.Time {
+	activity := s.currentPolicy().activity
+	res := make(map[string]time.Time, len(activity))
+	for client, lastActivity := range activity {
+		if nanos := atomic.LoadInt64(lastActivity); nanos != 0 {
+			res[client] = time.Unix(0, nanos)
+		} else {
+			res[client] = time.Time{}
+		}
+	}
+	return res
+}
@@ -16,16 +16,24 @@ package static_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/checker/static"
+	"github.com/attestantio/dirk/services/fetcher/mem"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/attestantio/dirk/services/metrics/prometheus"
 	"github.com/attestantio/dirk/services/ruler"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	nd "github.com/wealdtech/go-eth2-wallet-nd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
 func TestNew(t *testing.T) {
@@ -250,6 +258,24 @@ func TestLists(t *testing.T) {
 					Operations: []string{"None", "All"},
 				},
 			},
+			// client5 allows everything for wallet 1, but denies signing for an account in another entry.
+			"client5": {
+				{
+					Path:       "Wallet1",
+					Operations: []string{"All"},
+				},
+				{
+					Path:       "Wallet1/Account1",
+					Operations: []string{"~Sign"},
+				},
+			},
+			// client6 allows recovery for wallet 1, which must be named explicitly.
+			"client6": {
+				{
+					Path:       "Wallet1",
+					Operations: []string{"Recover account"},
+				},
+			},
 		}),
 	)
 	require.Nil(t, err)
@@ -262,29 +288,41 @@ func TestLists(t *testing.T) {
 	}{
 		{
 			name:    "Nil",
-			results: []bool{false, false, false, false},
+			results: []bool{false, false, false, false, false, false},
 		},
 		{
 			name:      "SignWallet1",
 			account:   "Wallet1/Account1",
 			operation: ruler.ActionSign,
-			results:   []bool{true, true, false, false},
+			results:   []bool{true, true, false, false, false, false},
 		},
 		{
 			name:      "SignWallet2",
 			account:   "Wallet2/Account1",
 			operation: ruler.ActionSign,
-			results:   []bool{false, true, false, false},
+			results:   []bool{false, true, false, false, false, false},
 		},
 		{
 			name:      "AccessAccountsWallet1",
 			account:   "Wallet1/Account1",
 			operation: ruler.ActionAccessAccount,
-			results:   []bool{false, false, true, false},
+			results:   []bool{false, false, true, false, true, false},
+		},
+		{
+			name:      "SignWallet1Account2",
+			account:   "Wallet1/Account2",
+			operation: ruler.ActionSign,
+			results:   []bool{true, true, false, false, true, false},
+		},
+		{
+			name:      "RecoverWallet1",
+			account:   "Wallet1/Account1",
+			operation: ruler.ActionRecoverAccount,
+			results:   []bool{false, false, false, false, false, true},
 		},
 	}
 
-	clients := []string{"client1", "client2", "client3", "client4"}
+	clients := []string{"client1", "client2", "client3", "client4", "client5", "client6"}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -300,3 +338,266 @@ func TestLists(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckPubKey(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, e2types.InitBLS())
+
+	store := scratch.New()
+	wallet, err := nd.CreateWallet(ctx, "Wallet1", store, keystorev4.New())
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
+	account1, err := wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Account1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Account2", []byte("pass"))
+	require.NoError(t, err)
+	pubKey := fmt.Sprintf("%#x", account1.(e2wtypes.AccountPublicKeyProvider).PublicKey().Marshal())
+
+	fetcher, err := mem.New(ctx, mem.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+
+	permissions := map[string][]*checker.Permissions{
+		// client1 allows signing for a single key.
+		"client1": {
+			{
+				Path:       pubKey,
+				Operations: []string{"Sign"},
+			},
+		},
+		// client2 allows everything for wallet 1, except signing for a single key.
+		"client2": {
+			{
+				Path:       "Wallet1",
+				Operations: []string{"All"},
+			},
+			{
+				Path:       strings.TrimPrefix(pubKey, "0x"),
+				Operations: []string{"~Sign"},
+			},
+		},
+	}
+
+	_, err = static.New(ctx,
+		static.WithLogLevel(zerolog.Disabled),
+		static.WithPermissions(permissions),
+	)
+	require.EqualError(t, err, "problem with parameters: permissions by public key require a fetcher")
+
+	service, err := static.New(ctx,
+		static.WithLogLevel(zerolog.Disabled),
+		static.WithPermissions(permissions),
+		static.WithFetcher(fetcher),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		client    string
+		account   string
+		operation string
+		result    bool
+	}{
+		{
+			name:      "Client1Account1Sign",
+			client:    "client1",
+			account:   "Wallet1/Account1",
+			operation: ruler.ActionSign,
+			result:    true,
+		},
+		{
+			name:      "Client1Account1AccessAccount",
+			client:    "client1",
+			account:   "Wallet1/Account1",
+			operation: ruler.ActionAccessAccount,
+			result:    false,
+		},
+		{
+			name:      "Client1Account2Sign",
+			client:    "client1",
+			account:   "Wallet1/Account2",
+			operation: ruler.ActionSign,
+			result:    false,
+		},
+		{
+			name:      "Client1UnknownAccountSign",
+			client:    "client1",
+			account:   "Wallet1/Account3",
+			operation: ruler.ActionSign,
+			result:    false,
+		},
+		{
+			name:      "Client2Account1Sign",
+			client:    "client2",
+			account:   "Wallet1/Account1",
+			operation: ruler.ActionSign,
+			result:    false,
+		},
+		{
+			name:      "Client2Account1AccessAccount",
+			client:    "client2",
+			account:   "Wallet1/Account1",
+			operation: ruler.ActionAccessAccount,
+			result:    true,
+		},
+		{
+			name:      "Client2Account2Sign",
+			client:    "client2",
+			account:   "Wallet1/Account2",
+			operation: ruler.ActionSign,
+			result:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			credentials := &checker.Credentials{Client: test.client}
+			require.Equal(t, test.result, service.Check(ctx, credentials, test.account, test.operation))
+		})
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := static.New(ctx, static.WithReadOnlyClients([]string{""}))
+	require.EqualError(t, err, "problem with parameters: invalid client name for read-only client")
+
+	service, err := static.New(ctx, static.WithReadOnlyClients([]string{"monitor"}))
+	require.NoError(t, err)
+
+	assert.False(t, service.ReadOnly(ctx, nil))
+	assert.False(t, service.ReadOnly(ctx, &checker.Credentials{Client: "client1"}))
+	assert.True(t, service.ReadOnly(ctx, &checker.Credentials{Client: "monitor"}))
+}
+
+func TestActionPermitted(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := static.New(ctx, static.WithClientActions(map[string][]string{"": {"Sign"}}))
+	require.EqualError(t, err, "problem with parameters: invalid client name for client actions")
+
+	_, err = static.New(ctx, static.WithClientActions(map[string][]string{"signer": {}}))
+	require.EqualError(t, err, "problem with parameters: no actions for client signer")
+
+	_, err = static.New(ctx, static.WithClientActions(map[string][]string{"signer": {""}}))
+	require.EqualError(t, err, "problem with parameters: invalid action for client signer")
+
+	service, err := static.New(ctx, static.WithClientActions(map[string][]string{"signer": {"Sign", "sign beacon attestation"}}))
+	require.NoError(t, err)
+
+	assert.True(t, service.ActionPermitted(ctx, nil, "Sign"))
+	assert.True(t, service.ActionPermitted(ctx, &checker.Credentials{Client: "client1"}, "Create account"))
+	assert.True(t, service.ActionPermitted(ctx, &checker.Credentials{Client: "signer"}, "Sign"))
+	assert.True(t, service.ActionPermitted(ctx, &checker.Credentials{Client: "signer"}, "Sign beacon attestation"))
+	assert.False(t, service.ActionPermitted(ctx, &checker.Credentials{Client: "signer"}, "Create account"))
+}
+
+func TestCheckSequence(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := static.New(ctx, static.WithSequenceClients([]string{""}))
+	require.EqualError(t, err, "problem with parameters: invalid client name for sequence client")
+
+	service, err := static.New(ctx, static.WithSequenceClients([]string{"client1"}))
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{Client: "client1"}
+	assert.True(t, service.CheckSequence(ctx, nil, "session1", 0))
+	assert.True(t, service.CheckSequence(ctx, &checker.Credentials{Client: "client2"}, "session1", 0))
+	assert.False(t, service.CheckSequence(ctx, credentials, "session1", 0))
+	assert.True(t, service.CheckSequence(ctx, credentials, "session1", 1))
+	assert.True(t, service.CheckSequence(ctx, credentials, "session1", 2))
+	assert.False(t, service.CheckSequence(ctx, credentials, "session1", 2))
+	assert.False(t, service.CheckSequence(ctx, credentials, "session1", 1))
+	// A new session resets the sequence.
+	assert.True(t, service.CheckSequence(ctx, credentials, "session2", 1))
+	assert.False(t, service.CheckSequence(ctx, credentials, "session2", 1))
+}
+
+func TestLastActivity(t *testing.T) {
+	ctx := context.Background()
+	service, err := static.New(ctx, static.WithPermissions(map[string][]*checker.Permissions{
+		"client1": {{Path: "Wallet1", Operations: []string{"All"}}},
+		"client2": {{Path: "Wallet1", Operations: []string{"All"}}},
+	}))
+	require.NoError(t, err)
+
+	activity := service.LastActivity(ctx)
+	require.Len(t, activity, 2)
+	require.True(t, activity["client1"].IsZero())
+	require.True(t, activity["client2"].IsZero())
+
+	// Unknown clients are not tracked.
+	service.Check(ctx, &checker.Credentials{Client: "client3"}, "Wallet1/Account1", "Sign")
+	require.Len(t, service.LastActivity(ctx), 2)
+
+	service.Check(ctx, &checker.Credentials{Client: "client1"}, "Wallet1/Account1", "Sign")
+	first := service.LastActivity(ctx)["client1"]
+	require.False(t, first.IsZero())
+	require.True(t, service.LastActivity(ctx)["client2"].IsZero())
+
+	// Activity is recorded for requests that are denied as well as approved.
+	time.Sleep(time.Millisecond)
+	service.Check(ctx, &checker.Credentials{Client: "client1"}, "Wallet2/Account1", "Sign")
+	second := service.LastActivity(ctx)["client1"]
+	require.True(t, second.After(first))
+}
+
+func TestUpdatePermissions(t *testing.T) {
+	ctx := context.Background()
+	service, err := static.New(ctx,
+		static.WithPermissions(map[string][]*checker.Permissions{
+			"client1": {{Path: "Wallet1", Operations: []string{"All"}}},
+		}),
+		static.WithReadOnlyClients([]string{"client1"}),
+	)
+	require.NoError(t, err)
+
+	client1 := &checker.Credentials{Client: "client1"}
+	client2 := &checker.Credentials{Client: "client2"}
+	require.True(t, service.Check(ctx, client1, "Wallet1/Account1", "Sign"))
+	require.False(t, service.Check(ctx, client2, "Wallet1/Account1", "Sign"))
+	require.True(t, service.ReadOnly(ctx, client1))
+	first := service.LastActivity(ctx)["client1"]
+	require.False(t, first.IsZero())
+
+	// Invalid permissions leave the existing permissions in place.
+	err = service.UpdatePermissions(ctx, static.WithPermissions(map[string][]*checker.Permissions{
+		"client2": nil,
+	}))
+	require.EqualError(t, err, "problem with parameters: client client2 requires at least one permission")
+	require.True(t, service.Check(ctx, client1, "Wallet1/Account1", "Sign"))
+	require.False(t, service.Check(ctx, client2, "Wallet1/Account1", "Sign"))
+
+	// Grant access to client2 and restrict client1.
+	require.NoError(t, service.UpdatePermissions(ctx,
+		static.WithPermissions(map[string][]*checker.Permissions{
+			"client1": {{Path: "Wallet1/Account2", Operations: []string{"All"}}},
+			"client2": {{Path: "Wallet1", Operations: []string{"All"}}},
+		}),
+		static.WithClientActions(map[string][]string{
+			"client2": {ruler.ActionSign},
+		}),
+		static.WithSequenceClients([]string{"client2"}),
+	))
+	require.False(t, service.Check(ctx, client1, "Wallet1/Account1", "Sign"))
+	require.True(t, service.Check(ctx, client1, "Wallet1/Account2", "Sign"))
+	require.True(t, service.Check(ctx, client2, "Wallet1/Account1", "Sign"))
+	require.False(t, service.ReadOnly(ctx, client1))
+	require.True(t, service.ActionPermitted(ctx, client2, ruler.ActionSign))
+	require.False(t, service.ActionPermitted(ctx, client2, ruler.ActionLockWallet))
+	require.False(t, service.CheckSequence(ctx, client2, "session", 0))
+
+	// Activity is carried over for existing clients and tracked for new clients.
+	activity := service.LastActivity(ctx)
+	require.Len(t, activity, 2)
+	require.False(t, activity["client1"].Before(first))
+	require.False(t, activity["client2"].IsZero())
+
+	// Revoke access for client1.
+	require.NoError(t, service.UpdatePermissions(ctx, static.WithPermissions(map[string][]*checker.Permissions{
+		"client2": {{Path: "Wallet1", Operations: []string{"All"}}},
+	})))
+	require.False(t, service.Check(ctx, client1, "Wallet1/Account2", "Sign"))
+	require.Len(t, service.LastActivity(ctx), 1)
+}
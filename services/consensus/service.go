@@ -0,0 +1,67 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consensus
+
+import (
+	"context"
+
+	"github.com/attestantio/dirk/core"
+)
+
+// ProposeHighWaterMarkMethod is the full name of the peer RPC method for proposing a high-water mark advance.
+const ProposeHighWaterMarkMethod = "/dirk.consensus.v1.Consensus/ProposeHighWaterMark"
+
+// Kinds of high-water mark.
+const (
+	// KindProposal is the high-water mark for beacon block proposals.
+	KindProposal = "proposal"
+	// KindAttestation is the high-water mark for beacon attestations.
+	KindAttestation = "attestation"
+)
+
+// HighWaterMark is a proposed advance of the slashing protection high-water mark for an account.
+type HighWaterMark struct {
+	// Account is the name of the account, in the form wallet/account.  Peers that share a
+	// distributed account know it by the same name, but each holds a different key share.
+	Account string `json:"account"`
+	// Kind is the kind of high-water mark.
+	Kind string `json:"kind"`
+	// Slot is the slot of a proposal.
+	Slot uint64 `json:"slot,omitempty"`
+	// SourceEpoch is the source epoch of an attestation.
+	SourceEpoch uint64 `json:"source_epoch,omitempty"`
+	// TargetEpoch is the target epoch of an attestation.
+	TargetEpoch uint64 `json:"target_epoch,omitempty"`
+	// Root is the root of the data to be signed.  It allows each peer that is asked to sign the
+	// same data to confirm the same high-water mark.
+	Root []byte `json:"root"`
+}
+
+// Service is the interface for confirming slashing protection with peers.
+type Service interface {
+	// Confirm confirms with a quorum of peers that the high-water mark does not conflict with
+	// theirs, advancing them.  An error is returned if the quorum is not reached.
+	Confirm(ctx context.Context, hwm *HighWaterMark) error
+
+	// Acknowledge is called when a peer proposes a high-water mark advance.  It returns true if the
+	// high-water mark does not conflict with the local high-water mark, in which case the local
+	// high-water mark has been advanced.
+	Acknowledge(ctx context.Context, hwm *HighWaterMark) (bool, error)
+}
+
+// Transport is the interface for sending proposed high-water mark advances to peers.
+type Transport interface {
+	// ProposeHighWaterMark proposes a high-water mark advance to a peer, returning true if acknowledged.
+	ProposeHighWaterMark(ctx context.Context, peer *core.Endpoint, hwm *HighWaterMark) (bool, error)
+}
@@ -0,0 +1,143 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"time"
+
+	"github.com/attestantio/dirk/services/consensus"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/peers"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel    zerolog.Level
+	monitor     metrics.ConsensusMonitor
+	peers       peers.Service
+	transport   consensus.Transport
+	id          uint64
+	storagePath string
+	quorum      uint32
+	timeout     time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for this module.
+func WithMonitor(monitor metrics.ConsensusMonitor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithPeers sets the peers service.
+func WithPeers(peers peers.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.peers = peers
+	})
+}
+
+// WithTransport sets the transport used to send proposed high-water marks to peers.
+func WithTransport(transport consensus.Transport) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.transport = transport
+	})
+}
+
+// WithID sets the ID of this instance amongst the peers.
+func WithID(id uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.id = id
+	})
+}
+
+// WithStoragePath sets the path for the high-water mark store.
+func WithStoragePath(storagePath string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.storagePath = storagePath
+	})
+}
+
+// WithQuorum sets the number of peers, including this instance, that must acknowledge a high-water mark.
+func WithQuorum(quorum uint32) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.quorum = quorum
+	})
+}
+
+// WithTimeout sets the time to wait for peers to acknowledge a high-water mark.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		timeout:  2 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.monitor == nil {
+		// Use no-op monitor.
+		parameters.monitor = &noopMonitor{}
+	}
+	if parameters.peers == nil {
+		return nil, errors.New("no peers specified")
+	}
+	if parameters.transport == nil {
+		return nil, errors.New("no transport specified")
+	}
+	if parameters.id == 0 {
+		return nil, errors.New("no ID specified")
+	}
+	if parameters.storagePath == "" {
+		return nil, errors.New("no storage path specified")
+	}
+	if parameters.quorum == 0 {
+		return nil, errors.New("no quorum specified")
+	}
+	if int(parameters.quorum) > len(parameters.peers.All()) {
+		return nil, errors.New("quorum larger than number of peers")
+	}
+	if parameters.timeout == 0 {
+		return nil, errors.New("no timeout specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,238 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/consensus"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/peers"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service confirms slashing protection high-water marks with peers.
+type Service struct {
+	monitor   metrics.ConsensusMonitor
+	peers     peers.Service
+	transport consensus.Transport
+	id        uint64
+	quorum    uint32
+	timeout   time.Duration
+	store     *standardrules.Store
+	// storeMu serialises checks and updates of the high-water marks.
+	storeMu sync.Mutex
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new slashing protection consensus service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "consensus").Str("impl", "standard").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	store, err := standardrules.NewStore(parameters.storagePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open high-water mark store")
+	}
+
+	return &Service{
+		monitor:   parameters.monitor,
+		peers:     parameters.peers,
+		transport: parameters.transport,
+		id:        parameters.id,
+		quorum:    parameters.quorum,
+		timeout:   parameters.timeout,
+		store:     store,
+	}, nil
+}
+
+// Close closes the high-water mark store.
+func (s *Service) Close(ctx context.Context) error {
+	return s.store.Close(ctx)
+}
+
+// Confirm confirms with a quorum of peers that the high-water mark does not conflict with
+// theirs, advancing them.  An error is returned if the quorum is not reached.
+// The quorum includes this instance, whose high-water mark is checked first.
+// All peers are asked to acknowledge, even once the quorum has been reached, so that as many
+// as possible are advanced.  High-water marks only move forward, so peers that acknowledge an
+// advance that does not reach quorum will refuse some requests that they would otherwise have
+// signed, but will never sign a slashable request as a result.
+func (s *Service) Confirm(ctx context.Context, hwm *consensus.HighWaterMark) error {
+	log := log.With().Str("account", hwm.Account).Str("kind", hwm.Kind).Logger()
+
+	acknowledged, err := s.Acknowledge(ctx, hwm)
+	if err != nil {
+		s.monitor.ConfirmCompleted(hwm.Kind, "failed")
+		return errors.Wrap(err, "failed to check local high-water mark")
+	}
+	if !acknowledged {
+		s.monitor.ConfirmCompleted(hwm.Kind, "conflict")
+		return errors.New("conflicts with local high-water mark")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	acknowledgements := uint32(1)
+	var acknowledgementsMu sync.Mutex
+	var wg sync.WaitGroup
+	for id, peer := range s.peers.All() {
+		if id == s.id {
+			continue
+		}
+		wg.Add(1)
+		go func(id uint64, peer *core.Endpoint) {
+			defer wg.Done()
+			acknowledged, err := s.transport.ProposeHighWaterMark(ctx, peer, hwm)
+			if err != nil {
+				log.Warn().Uint64("peer", id).Err(err).Msg("Failed to propose high-water mark to peer")
+				return
+			}
+			if !acknowledged {
+				log.Warn().Uint64("peer", id).Msg("Peer refused high-water mark")
+				return
+			}
+			acknowledgementsMu.Lock()
+			acknowledgements++
+			acknowledgementsMu.Unlock()
+		}(id, peer)
+	}
+	wg.Wait()
+
+	if acknowledgements < s.quorum {
+		s.monitor.ConfirmCompleted(hwm.Kind, "no_quorum")
+		return fmt.Errorf("%d acknowledgements less than quorum of %d", acknowledgements, s.quorum)
+	}
+
+	s.monitor.ConfirmCompleted(hwm.Kind, "confirmed")
+	return nil
+}
+
+// Acknowledge is called when a peer proposes a high-water mark advance.  It returns true if the
+// high-water mark does not conflict with the local high-water mark, in which case the local
+// high-water mark has been advanced.
+func (s *Service) Acknowledge(ctx context.Context, hwm *consensus.HighWaterMark) (bool, error) {
+	if err := checkHighWaterMark(hwm); err != nil {
+		return false, err
+	}
+	key := highWaterMarkKey(hwm)
+
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	var existing *consensus.HighWaterMark
+	data, err := s.store.Fetch(ctx, key)
+	if err != nil {
+		if !errors.Is(err, standardrules.ErrNotFound) {
+			return false, errors.Wrap(err, "failed to fetch high-water mark")
+		}
+	} else {
+		existing = &consensus.HighWaterMark{}
+		if err := json.Unmarshal(data, existing); err != nil {
+			return false, errors.Wrap(err, "failed to decode high-water mark")
+		}
+	}
+
+	if existing != nil {
+		if conflicts(existing, hwm) {
+			log.Debug().Str("account", hwm.Account).Str("kind", hwm.Kind).Msg("High-water mark conflicts")
+			return false, nil
+		}
+		if sameHighWaterMark(existing, hwm) {
+			// Already acknowledged, for example as proposed by another peer.
+			return true, nil
+		}
+	}
+
+	data, err = json.Marshal(hwm)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to encode high-water mark")
+	}
+	if err := s.store.Store(ctx, key, data); err != nil {
+		return false, errors.Wrap(err, "failed to store high-water mark")
+	}
+
+	return true, nil
+}
+
+// checkHighWaterMark checks that a high-water mark is well-formed.
+func checkHighWaterMark(hwm *consensus.HighWaterMark) error {
+	if hwm == nil {
+		return errors.New("no high-water mark supplied")
+	}
+	if hwm.Account == "" {
+		return errors.New("no account supplied")
+	}
+	if hwm.Kind != consensus.KindProposal && hwm.Kind != consensus.KindAttestation {
+		return fmt.Errorf("unknown kind %q", hwm.Kind)
+	}
+	if len(hwm.Root) == 0 {
+		return errors.New("no root supplied")
+	}
+	return nil
+}
+
+// highWaterMarkKey returns the storage key for a high-water mark.
+func highWaterMarkKey(hwm *consensus.HighWaterMark) []byte {
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s/%s", hwm.Kind, hwm.Account)))
+	return key[:]
+}
+
+// conflicts returns true if the proposed high-water mark conflicts with the existing high-water mark.
+// A proposed high-water mark that is the same as the existing high-water mark does not conflict.
+func conflicts(existing *consensus.HighWaterMark, proposed *consensus.HighWaterMark) bool {
+	switch proposed.Kind {
+	case consensus.KindProposal:
+		if proposed.Slot < existing.Slot {
+			return true
+		}
+		return proposed.Slot == existing.Slot && !bytes.Equal(proposed.Root, existing.Root)
+	case consensus.KindAttestation:
+		if proposed.SourceEpoch < existing.SourceEpoch || proposed.TargetEpoch < existing.TargetEpoch {
+			return true
+		}
+		return proposed.TargetEpoch == existing.TargetEpoch &&
+			(proposed.SourceEpoch != existing.SourceEpoch || !bytes.Equal(proposed.Root, existing.Root))
+	default:
+		return true
+	}
+}
+
+// sameHighWaterMark returns true if the two high-water marks are the same.
+func sameHighWaterMark(existing *consensus.HighWaterMark, proposed *consensus.HighWaterMark) bool {
+	return existing.Slot == proposed.Slot &&
+		existing.SourceEpoch == proposed.SourceEpoch &&
+		existing.TargetEpoch == proposed.TargetEpoch &&
+		bytes.Equal(existing.Root, proposed.Root)
+}
@@ -0,0 +1,284 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/services/consensus"
+	standardconsensus "github.com/attestantio/dirk/services/consensus/standard"
+	"github.com/attestantio/dirk/services/peers"
+	staticpeers "github.com/attestantio/dirk/services/peers/static"
+	sendermock "github.com/attestantio/dirk/services/sender/mock"
+	"github.com/attestantio/dirk/testing/mock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+func createPeers(ctx context.Context, t *testing.T) peers.Service {
+	peersSvc, err := staticpeers.New(ctx,
+		staticpeers.WithPeers(map[uint64]string{
+			1: "signer-test01:8881",
+			2: "signer-test02:8882",
+			3: "signer-test03:8883",
+		}),
+	)
+	require.NoError(t, err)
+	return peersSvc
+}
+
+// createConsensusServices creates a simulated set of peers, each with its own high-water mark store.
+// Only the peers listed in online are reachable.
+func createConsensusServices(ctx context.Context, t *testing.T, online []uint64) map[uint64]*standardconsensus.Service {
+	peersSvc := createPeers(ctx, t)
+	base := t.TempDir()
+	services := make(map[uint64]*standardconsensus.Service)
+	mock.Consensuses = make(map[uint64]consensus.Service)
+	for id := uint64(1); id <= 3; id++ {
+		service, err := standardconsensus.New(ctx,
+			standardconsensus.WithPeers(peersSvc),
+			standardconsensus.WithTransport(sendermock.New(id)),
+			standardconsensus.WithID(id),
+			standardconsensus.WithStoragePath(filepath.Join(base, string(rune('0'+id)))),
+			standardconsensus.WithQuorum(2),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = service.Close(ctx) })
+		services[id] = service
+	}
+	for _, id := range online {
+		mock.Consensuses[id] = services[id]
+	}
+	return services
+}
+
+func proposal(slot uint64, root byte) *consensus.HighWaterMark {
+	return &consensus.HighWaterMark{
+		Account: "Wallet/Account",
+		Kind:    consensus.KindProposal,
+		Slot:    slot,
+		Root:    []byte{root},
+	}
+}
+
+func attestation(sourceEpoch uint64, targetEpoch uint64, root byte) *consensus.HighWaterMark {
+	return &consensus.HighWaterMark{
+		Account:     "Wallet/Account",
+		Kind:        consensus.KindAttestation,
+		SourceEpoch: sourceEpoch,
+		TargetEpoch: targetEpoch,
+		Root:        []byte{root},
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	peersSvc := createPeers(ctx, t)
+	transport := sendermock.New(1)
+
+	tests := []struct {
+		name   string
+		params []standardconsensus.Parameter
+		err    string
+	}{
+		{
+			name: "PeersMissing",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithTransport(transport),
+				standardconsensus.WithID(1),
+				standardconsensus.WithStoragePath(t.TempDir()),
+				standardconsensus.WithQuorum(2),
+			},
+			err: "problem with parameters: no peers specified",
+		},
+		{
+			name: "TransportMissing",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithPeers(peersSvc),
+				standardconsensus.WithID(1),
+				standardconsensus.WithStoragePath(t.TempDir()),
+				standardconsensus.WithQuorum(2),
+			},
+			err: "problem with parameters: no transport specified",
+		},
+		{
+			name: "IDMissing",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithPeers(peersSvc),
+				standardconsensus.WithTransport(transport),
+				standardconsensus.WithStoragePath(t.TempDir()),
+				standardconsensus.WithQuorum(2),
+			},
+			err: "problem with parameters: no ID specified",
+		},
+		{
+			name: "StoragePathMissing",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithPeers(peersSvc),
+				standardconsensus.WithTransport(transport),
+				standardconsensus.WithID(1),
+				standardconsensus.WithQuorum(2),
+			},
+			err: "problem with parameters: no storage path specified",
+		},
+		{
+			name: "QuorumMissing",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithPeers(peersSvc),
+				standardconsensus.WithTransport(transport),
+				standardconsensus.WithID(1),
+				standardconsensus.WithStoragePath(t.TempDir()),
+			},
+			err: "problem with parameters: no quorum specified",
+		},
+		{
+			name: "QuorumTooLarge",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithPeers(peersSvc),
+				standardconsensus.WithTransport(transport),
+				standardconsensus.WithID(1),
+				standardconsensus.WithStoragePath(t.TempDir()),
+				standardconsensus.WithQuorum(4),
+			},
+			err: "problem with parameters: quorum larger than number of peers",
+		},
+		{
+			name: "TimeoutZero",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithPeers(peersSvc),
+				standardconsensus.WithTransport(transport),
+				standardconsensus.WithID(1),
+				standardconsensus.WithStoragePath(t.TempDir()),
+				standardconsensus.WithQuorum(2),
+				standardconsensus.WithTimeout(0),
+			},
+			err: "problem with parameters: no timeout specified",
+		},
+		{
+			name: "Good",
+			params: []standardconsensus.Parameter{
+				standardconsensus.WithPeers(peersSvc),
+				standardconsensus.WithTransport(transport),
+				standardconsensus.WithID(1),
+				standardconsensus.WithStoragePath(t.TempDir()),
+				standardconsensus.WithQuorum(2),
+				standardconsensus.WithTimeout(time.Second),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, err := standardconsensus.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, service.Close(ctx))
+			}
+		})
+	}
+}
+
+func TestConfirmProposal(t *testing.T) {
+	ctx := context.Background()
+	services := createConsensusServices(ctx, t, []uint64{1, 2, 3})
+
+	// Initial proposal.
+	require.NoError(t, services[1].Confirm(ctx, proposal(10, 1)))
+	// The same proposal from another peer is acknowledged.
+	require.NoError(t, services[2].Confirm(ctx, proposal(10, 1)))
+	// A different proposal for the same slot is refused.
+	require.EqualError(t, services[3].Confirm(ctx, proposal(10, 2)), "conflicts with local high-water mark")
+	// An earlier proposal is refused.
+	require.EqualError(t, services[1].Confirm(ctx, proposal(9, 1)), "conflicts with local high-water mark")
+	// A later proposal is accepted.
+	require.NoError(t, services[3].Confirm(ctx, proposal(11, 3)))
+}
+
+func TestConfirmAttestation(t *testing.T) {
+	ctx := context.Background()
+	services := createConsensusServices(ctx, t, []uint64{1, 2, 3})
+
+	require.NoError(t, services[1].Confirm(ctx, attestation(2, 3, 1)))
+	// Same target with a different root is refused.
+	require.Error(t, services[2].Confirm(ctx, attestation(2, 3, 2)))
+	// Same target with a different source is refused.
+	require.Error(t, services[2].Confirm(ctx, attestation(1, 3, 1)))
+	// Earlier source is refused.
+	require.Error(t, services[2].Confirm(ctx, attestation(1, 4, 1)))
+	// Later target is accepted.
+	require.NoError(t, services[3].Confirm(ctx, attestation(3, 4, 1)))
+}
+
+func TestConfirmConflictingPeer(t *testing.T) {
+	ctx := context.Background()
+	services := createConsensusServices(ctx, t, []uint64{1, 2, 3})
+
+	// Peers 2 and 3 have seen a later proposal that peer 1 has not.
+	_, err := services[2].Acknowledge(ctx, proposal(20, 1))
+	require.NoError(t, err)
+	_, err = services[3].Acknowledge(ctx, proposal(20, 1))
+	require.NoError(t, err)
+
+	require.EqualError(t, services[1].Confirm(ctx, proposal(15, 1)), "1 acknowledgements less than quorum of 2")
+}
+
+func TestConfirmPartition(t *testing.T) {
+	ctx := context.Background()
+	// Only peer 1 is reachable.
+	services := createConsensusServices(ctx, t, []uint64{1})
+
+	require.EqualError(t, services[1].Confirm(ctx, proposal(10, 1)), "1 acknowledgements less than quorum of 2")
+
+	// Once the partition heals the advance, already held locally, is confirmed by the peers.
+	mock.Consensuses[2] = services[2]
+	mock.Consensuses[3] = services[3]
+	require.NoError(t, services[1].Confirm(ctx, proposal(10, 1)))
+}
+
+func TestConfirmMinority(t *testing.T) {
+	ctx := context.Background()
+	// Peer 3 is unreachable, but peers 1 and 2 form a quorum.
+	services := createConsensusServices(ctx, t, []uint64{1, 2})
+
+	require.NoError(t, services[1].Confirm(ctx, proposal(10, 1)))
+	require.Error(t, services[2].Confirm(ctx, proposal(10, 2)))
+}
+
+func TestAcknowledgeInvalid(t *testing.T) {
+	ctx := context.Background()
+	services := createConsensusServices(ctx, t, []uint64{1, 2, 3})
+
+	_, err := services[1].Acknowledge(ctx, nil)
+	require.EqualError(t, err, "no high-water mark supplied")
+
+	_, err = services[1].Acknowledge(ctx, &consensus.HighWaterMark{Kind: consensus.KindProposal, Root: []byte{1}})
+	require.EqualError(t, err, "no account supplied")
+
+	_, err = services[1].Acknowledge(ctx, &consensus.HighWaterMark{Account: "Wallet/Account", Kind: "unknown", Root: []byte{1}})
+	require.EqualError(t, err, `unknown kind "unknown"`)
+
+	_, err = services[1].Acknowledge(ctx, &consensus.HighWaterMark{Account: "Wallet/Account", Kind: consensus.KindProposal})
+	require.EqualError(t, err, "no root supplied")
+}
@@ -0,0 +1,157 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// deriveRetryInterval is the minimum time between attempts to derive the accounts of a wallet when searching by
+// public key, as each attempt may require the wallet to be unlocked.
+const deriveRetryInterval = time.Minute
+
+// isDerivedAccountName returns true if the name of an account is a derivation path, in which case the
+// account is derived from the seed of its wallet rather than held in its store.
+func isDerivedAccountName(name string) bool {
+	return strings.HasPrefix(name, "m/")
+}
+
+// derivedAccountPath returns the EIP-2334 validator signing key path for the given index.
+func derivedAccountPath(index uint32) string {
+	return fmt.Sprintf("m/12381/3600/%d/0/0", index)
+}
+
+// DerivedAccounts provides the accounts of the wallet that are derived from its seed on demand
+// rather than held in its store.  The accounts are derived when first requested.
+func (s *Service) DerivedAccounts(ctx context.Context, wallet e2wtypes.Wallet) ([]e2wtypes.Account, error) {
+	count, exists := s.derivedCounts[wallet.Name()]
+	if !exists {
+		return nil, nil
+	}
+
+	s.derivedMx.Lock()
+	accounts, exists := s.derived[wallet.Name()]
+	s.derivedMx.Unlock()
+	if exists {
+		return accounts, nil
+	}
+
+	names := make([]string, count)
+	for i := range names {
+		names[i] = derivedAccountPath(uint32(i))
+	}
+	accounts, err := s.deriveAccounts(ctx, wallet, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range accounts {
+		s.cacheAccount(wallet, account)
+	}
+
+	s.derivedMx.Lock()
+	s.derived[wallet.Name()] = accounts
+	s.derivedMx.Unlock()
+	log.Trace().Str("wallet", wallet.Name()).Int("accounts", len(accounts)).Msg("Derived accounts")
+
+	return accounts, nil
+}
+
+// deriveAll derives the accounts of all wallets with derived accounts, returning true if any
+// accounts were derived that had not been before.
+func (s *Service) deriveAll(ctx context.Context) bool {
+	derived := false
+	for walletName := range s.derivedCounts {
+		s.derivedMx.Lock()
+		_, exists := s.derived[walletName]
+		failed := time.Since(s.deriveFailures[walletName]) < deriveRetryInterval
+		s.derivedMx.Unlock()
+		if exists || failed {
+			continue
+		}
+		wallet, err := s.FetchWallet(ctx, walletName)
+		if err == nil {
+			_, err = s.DerivedAccounts(ctx, wallet)
+		}
+		if err != nil {
+			log.Warn().Str("wallet", walletName).Err(err).Msg("Failed to derive accounts")
+			s.derivedMx.Lock()
+			s.deriveFailures[walletName] = time.Now()
+			s.derivedMx.Unlock()
+			continue
+		}
+		derived = true
+	}
+	return derived
+}
+
+// deriveAccounts derives accounts with the given paths from the seed of the wallet, unlocking the wallet for
+// the duration if required.  The derived accounts are locked, and are unlocked by the unlocker in the same way
+// as accounts held in stores.
+func (s *Service) deriveAccounts(ctx context.Context, wallet e2wtypes.Wallet, names []string) ([]e2wtypes.Account, error) {
+	if wallet.Type() != "hierarchical deterministic" {
+		return nil, errors.New("wallet does not support derived accounts")
+	}
+	provider, isProvider := wallet.(e2wtypes.WalletAccountByNameProvider)
+	if !isProvider {
+		return nil, errors.New("wallet does not allow fetching account by name")
+	}
+	locker, isLocker := wallet.(e2wtypes.WalletLocker)
+	if !isLocker {
+		return nil, errors.New("wallet does not support unlocking")
+	}
+
+	s.deriveMx.Lock()
+	defer s.deriveMx.Unlock()
+
+	unlocked, err := locker.IsUnlocked(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to establish if wallet is unlocked")
+	}
+	if !unlocked {
+		unlocked, err = s.unlocker.UnlockWallet(ctx, wallet)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unlock wallet")
+		}
+		if !unlocked {
+			return nil, errors.New("failed to unlock wallet with known passphrases")
+		}
+		defer func() {
+			if err := locker.Lock(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to lock wallet")
+			}
+		}()
+	}
+
+	accounts := make([]e2wtypes.Account, 0, len(names))
+	for _, name := range names {
+		account, err := provider.AccountByName(ctx, name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to derive account %s", name)
+		}
+		if accountLocker, isLocker := account.(e2wtypes.AccountLocker); isLocker {
+			if err := accountLocker.Lock(ctx); err != nil {
+				return nil, errors.Wrapf(err, "failed to lock account %s", name)
+			}
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
@@ -0,0 +1,160 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/services/fetcher/mem"
+	"github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestDerivedAccounts(t *testing.T) {
+	ctx := context.Background()
+
+	stores, err := createTestStores()
+	require.NoError(t, err)
+	unlocker, err := local.New(ctx,
+		local.WithLogLevel(zerolog.Disabled),
+		local.WithWalletPassphrases([]string{"secret"}),
+	)
+	require.NoError(t, err)
+	badUnlocker, err := local.New(ctx,
+		local.WithLogLevel(zerolog.Disabled),
+		local.WithWalletPassphrases([]string{"bad"}),
+	)
+	require.NoError(t, err)
+
+	_, err = mem.New(ctx,
+		mem.WithStores(stores),
+		mem.WithDerivedAccounts(map[string]uint32{"Test HD wallet": 2}),
+	)
+	require.EqualError(t, err, "problem with parameters: no unlocker specified for derived accounts")
+
+	tests := []struct {
+		name     string
+		wallet   string
+		derived  map[string]uint32
+		unlocker *local.Service
+		accounts []string
+		err      string
+	}{
+		{
+			name:     "NotConfigured",
+			wallet:   "Test wallet",
+			derived:  map[string]uint32{"Test HD wallet": 2},
+			unlocker: unlocker,
+		},
+		{
+			name:     "NotHD",
+			wallet:   "Test wallet",
+			derived:  map[string]uint32{"Test wallet": 2},
+			unlocker: unlocker,
+			err:      "wallet does not support derived accounts",
+		},
+		{
+			name:     "BadPassphrase",
+			wallet:   "Test HD wallet",
+			derived:  map[string]uint32{"Test HD wallet": 2},
+			unlocker: badUnlocker,
+			err:      "failed to unlock wallet with known passphrases",
+		},
+		{
+			name:     "Good",
+			wallet:   "Test HD wallet",
+			derived:  map[string]uint32{"Test HD wallet": 2},
+			unlocker: unlocker,
+			accounts: []string{"m/12381/3600/0/0/0", "m/12381/3600/1/0/0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fetcher, err := mem.New(ctx,
+				mem.WithLogLevel(zerolog.Disabled),
+				mem.WithStores(stores),
+				mem.WithUnlocker(test.unlocker),
+				mem.WithDerivedAccounts(test.derived),
+			)
+			require.NoError(t, err)
+			wallet, err := fetcher.FetchWallet(ctx, test.wallet)
+			require.NoError(t, err)
+
+			accounts, err := fetcher.DerivedAccounts(ctx, wallet)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, accounts, len(test.accounts))
+			for i := range accounts {
+				require.Equal(t, test.accounts[i], accounts[i].Name())
+				// Derived accounts are locked, and can be unlocked by the unlocker.
+				unlocked, err := accounts[i].(e2wtypes.AccountLocker).IsUnlocked(ctx)
+				require.NoError(t, err)
+				require.False(t, unlocked)
+				unlocked, err = test.unlocker.UnlockAccount(ctx, wallet, accounts[i])
+				require.NoError(t, err)
+				require.True(t, unlocked)
+			}
+			// The wallet is locked again after derivation.
+			unlocked, err := wallet.(e2wtypes.WalletLocker).IsUnlocked(ctx)
+			require.NoError(t, err)
+			require.False(t, unlocked)
+		})
+	}
+}
+
+func TestFetchDerivedAccount(t *testing.T) {
+	ctx := context.Background()
+
+	stores, err := createTestStores()
+	require.NoError(t, err)
+	unlocker, err := local.New(ctx,
+		local.WithLogLevel(zerolog.Disabled),
+		local.WithWalletPassphrases([]string{"secret"}),
+	)
+	require.NoError(t, err)
+	fetcher, err := mem.New(ctx,
+		mem.WithLogLevel(zerolog.Disabled),
+		mem.WithStores(stores),
+		mem.WithUnlocker(unlocker),
+		mem.WithDerivedAccounts(map[string]uint32{"Test HD wallet": 2}),
+	)
+	require.NoError(t, err)
+
+	// Accounts outside of the configured count can be fetched by path.
+	_, account, err := fetcher.FetchAccount(ctx, "Test HD wallet/m/12381/3600/5/0/0")
+	require.NoError(t, err)
+	require.Equal(t, "m/12381/3600/5/0/0", account.Name())
+
+	// Accounts in the configured count can be fetched by public key without first being listed.
+	other, err := mem.New(ctx,
+		mem.WithLogLevel(zerolog.Disabled),
+		mem.WithStores(stores),
+		mem.WithUnlocker(unlocker),
+		mem.WithDerivedAccounts(map[string]uint32{"Test HD wallet": 2}),
+	)
+	require.NoError(t, err)
+	_, derived, err := other.FetchAccount(ctx, "Test HD wallet/m/12381/3600/1/0/0")
+	require.NoError(t, err)
+	pubKey := derived.(e2wtypes.AccountPublicKeyProvider).PublicKey().Marshal()
+	_, account, err = fetcher.FetchAccountByKey(ctx, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, "m/12381/3600/1/0/0", account.Name())
+}
@@ -15,6 +15,7 @@ package mem
 
 import (
 	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/unlocker"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
@@ -26,6 +27,9 @@ type parameters struct {
 	monitor   metrics.FetcherMonitor
 	encryptor e2wtypes.Encryptor
 	stores    []e2wtypes.Store
+	watch     bool
+	unlocker  unlocker.Service
+	derived   map[string]uint32
 }
 
 // Parameter is the interface for service parameters.
@@ -67,6 +71,29 @@ func WithStores(stores []e2wtypes.Store) Parameter {
 	})
 }
 
+// WithWatch watches filesystem stores for changes, picking up wallets and accounts created outside of Dirk.
+func WithWatch(watch bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.watch = watch
+	})
+}
+
+// WithUnlocker sets the unlocker, used to unlock wallets from which accounts are derived.
+func WithUnlocker(unlocker unlocker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.unlocker = unlocker
+	})
+}
+
+// WithDerivedAccounts sets the number of accounts derived on demand from the seed of each hierarchical
+// deterministic wallet, keyed by wallet name.  The accounts are at the EIP-2334 validator signing key paths
+// m/12381/3600/i/0/0 for i from 0, and are not held in the wallet's store.
+func WithDerivedAccounts(derived map[string]uint32) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.derived = derived
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -89,6 +116,9 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if len(parameters.stores) == 0 {
 		return nil, errors.New("no stores specified")
 	}
+	if len(parameters.derived) > 0 && parameters.unlocker == nil {
+		return nil, errors.New("no unlocker specified for derived accounts")
+	}
 
 	return &parameters, nil
 }
@@ -19,8 +19,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/unlocker"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -43,6 +45,14 @@ type Service struct {
 	accounts      map[string]e2wtypes.Account
 	accountsMx    sync.RWMutex
 	encryptor     e2wtypes.Encryptor
+	unlocker      unlocker.Service
+	// derivedCounts is the number of accounts derived from the seed of each wallet.
+	derivedCounts  map[string]uint32
+	derived        map[string][]e2wtypes.Account
+	deriveFailures map[string]time.Time
+	derivedMx      sync.Mutex
+	// deriveMx serialises derivation, as the wallet is unlocked for its duration.
+	deriveMx sync.Mutex
 }
 
 // module-wide log.
@@ -62,17 +72,42 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
-		monitor:     parameters.monitor,
-		encryptor:   parameters.encryptor,
-		stores:      parameters.stores,
-		pubKeyPaths: make(map[[48]byte]string),
-		wallets:     make(map[string]e2wtypes.Wallet),
-		accounts:    make(map[string]e2wtypes.Account),
+		monitor:        parameters.monitor,
+		encryptor:      parameters.encryptor,
+		stores:         parameters.stores,
+		pubKeyPaths:    make(map[[48]byte]string),
+		wallets:        make(map[string]e2wtypes.Wallet),
+		accounts:       make(map[string]e2wtypes.Account),
+		unlocker:       parameters.unlocker,
+		derivedCounts:  parameters.derived,
+		derived:        make(map[string][]e2wtypes.Account),
+		deriveFailures: make(map[string]time.Time),
+	}
+
+	if parameters.watch {
+		if err := s.watch(ctx); err != nil {
+			return nil, err
+		}
 	}
 
 	return s, nil
 }
 
+// Healthy returns an error if no wallets can be obtained from the stores.
+func (s *Service) Healthy(ctx context.Context) error {
+	wallets := 0
+	for _, store := range s.stores {
+		// The channel must be drained to allow the store to finish.
+		for range store.RetrieveWallets() {
+			wallets++
+		}
+	}
+	if wallets == 0 {
+		return errors.New("no wallets available")
+	}
+	return nil
+}
+
 // FetchWallet fetches the wallet.
 func (s *Service) FetchWallet(ctx context.Context, path string) (e2wtypes.Wallet, error) {
 	log := log.With().Str("path", path).Logger()
@@ -138,6 +173,18 @@ func (s *Service) FetchAccount(ctx context.Context, path string) (e2wtypes.Walle
 		log.Warn().Msg("Invalid path")
 		return nil, nil, errors.Wrap(err, "invalid path")
 	}
+	if isDerivedAccountName(accountName) {
+		if _, exists := s.derivedCounts[wallet.Name()]; exists {
+			accounts, err := s.deriveAccounts(ctx, wallet, []string{accountName})
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to derive account")
+				return nil, nil, err
+			}
+			s.cacheAccount(wallet, accounts[0])
+			log.Trace().Msg("Account derived")
+			return wallet, accounts[0], nil
+		}
+	}
 	accountByNameProvider, isProvider := wallet.(e2wtypes.WalletAccountByNameProvider)
 	if !isProvider {
 		log.Warn().Msg("Account cannot be fetched by name")
@@ -148,15 +195,21 @@ func (s *Service) FetchAccount(ctx context.Context, path string) (e2wtypes.Walle
 		log.Warn().Err(err).Msg("Account not found")
 		return nil, nil, errors.Wrap(err, "failed to obtain account by name")
 	}
+	s.cacheAccount(wallet, account)
+
+	log.Trace().Msg("Account found in store")
+	return wallet, account, nil
+}
+
+// cacheAccount adds an account to the cache.
+func (s *Service) cacheAccount(wallet e2wtypes.Wallet, account e2wtypes.Account) {
+	path := fmt.Sprintf("%s/%s", wallet.Name(), account.Name())
 	s.accountsMx.Lock()
 	s.accounts[path] = account
 	s.accountsMx.Unlock()
 	s.pubKeyPathsMx.Lock()
-	s.pubKeyPaths[bytesutil.ToBytes48(account.(e2wtypes.AccountPublicKeyProvider).PublicKey().Marshal())] = fmt.Sprintf("%s/%s", wallet.Name(), account.Name())
+	s.pubKeyPaths[bytesutil.ToBytes48(account.(e2wtypes.AccountPublicKeyProvider).PublicKey().Marshal())] = path
 	s.pubKeyPathsMx.Unlock()
-
-	log.Trace().Msg("Account found in store")
-	return wallet, account, nil
 }
 
 // FetchAccountByKey fetches the account given its public key.
@@ -173,6 +226,17 @@ func (s *Service) FetchAccountByKey(ctx context.Context, pubKey []byte) (e2wtype
 		return s.FetchAccount(ctx, account)
 	}
 
+	// Derived accounts are not held in stores, so derive them in case the key is one of them.
+	if s.deriveAll(ctx) {
+		s.pubKeyPathsMx.RLock()
+		account, exists = s.pubKeyPaths[bytesutil.ToBytes48(pubKey)]
+		s.pubKeyPathsMx.RUnlock()
+		if exists {
+			log.Trace().Msg("Account found in derived accounts")
+			return s.FetchAccount(ctx, account)
+		}
+	}
+
 	// We don't.  Trawl wallets to find the result.
 	for _, store := range s.stores {
 		for walletBytes := range store.RetrieveWallets() {
@@ -203,6 +267,23 @@ func (s *Service) FetchAccountByKey(ctx context.Context, pubKey []byte) (e2wtype
 	return nil, nil, errors.New("account not found")
 }
 
+// Invalidate removes any cached information about the account with the given path.
+func (s *Service) Invalidate(ctx context.Context, path string) {
+	log.Trace().Str("path", path).Msg("Invalidating account")
+
+	s.accountsMx.Lock()
+	delete(s.accounts, path)
+	s.accountsMx.Unlock()
+
+	s.pubKeyPathsMx.Lock()
+	for pubKey, pubKeyPath := range s.pubKeyPaths {
+		if pubKeyPath == path {
+			delete(s.pubKeyPaths, pubKey)
+		}
+	}
+	s.pubKeyPathsMx.Unlock()
+}
+
 func walletFromBytes(ctx context.Context, data []byte, store e2wtypes.Store, encryptor e2wtypes.Encryptor) (e2wtypes.Wallet, error) {
 	if store == nil {
 		return nil, errors.New("no store provided")
@@ -273,6 +273,70 @@ func TestFetchAccountByKey(t *testing.T) {
 	}
 }
 
+func TestInvalidate(t *testing.T) {
+	ctx := context.Background()
+
+	stores, err := createTestStores()
+	require.Nil(t, err)
+	fetcher, err := mem.New(context.Background(),
+		mem.WithStores(stores))
+	require.Nil(t, err)
+
+	_, account, err := fetcher.FetchAccount(ctx, "Test wallet/Test account")
+	require.Nil(t, err)
+
+	// Fetching again should return the cached account.
+	_, cachedAccount, err := fetcher.FetchAccount(ctx, "Test wallet/Test account")
+	require.Nil(t, err)
+	require.True(t, account == cachedAccount)
+
+	// Fetching after invalidation should return the account afresh from the store.
+	fetcher.Invalidate(ctx, "Test wallet/Test account")
+	_, refetchedAccount, err := fetcher.FetchAccount(ctx, "Test wallet/Test account")
+	require.Nil(t, err)
+	require.False(t, account == refetchedAccount)
+	require.Equal(t, account.ID(), refetchedAccount.ID())
+
+	// Invalidating an unknown account should not cause a problem.
+	fetcher.Invalidate(ctx, "Test wallet/unknown account")
+}
+
+func TestHealthy(t *testing.T) {
+	ctx := context.Background()
+
+	stores, err := createTestStores()
+	require.Nil(t, err)
+
+	tests := []struct {
+		name   string
+		stores []e2wtypes.Store
+		err    string
+	}{
+		{
+			name:   "Empty",
+			stores: []e2wtypes.Store{scratch.New()},
+			err:    "no wallets available",
+		},
+		{
+			name:   "Good",
+			stores: stores,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fetcher, err := mem.New(ctx, mem.WithStores(test.stores))
+			require.Nil(t, err)
+			err = fetcher.Healthy(ctx)
+			if test.err == "" {
+				require.Nil(t, err)
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
 func TestWalletLocking(t *testing.T) {
 	ctx := context.Background()
 
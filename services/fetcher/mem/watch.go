@@ -0,0 +1,134 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// locationProvider is implemented by stores that are held in a local directory.
+type locationProvider interface {
+	Location() string
+}
+
+// watch watches the directories of filesystem stores, and evicts cached wallets when their contents change so that
+// accounts created outside of Dirk are picked up.
+func (s *Service) watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "failed to create watcher")
+	}
+
+	bases := make(map[string]bool)
+	for _, store := range s.stores {
+		provider, isProvider := store.(locationProvider)
+		if !isProvider || store.Name() != "filesystem" {
+			continue
+		}
+		base := filepath.Clean(provider.Location())
+		if err := s.watchStore(watcher, base); err != nil {
+			watcher.Close()
+			return errors.Wrap(err, "failed to watch store")
+		}
+		bases[base] = true
+	}
+	if len(bases) == 0 {
+		log.Debug().Msg("No filesystem stores to watch")
+		return watcher.Close()
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				s.handleEvent(watcher, bases, event)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warn().Err(err).Msg("Error watching stores")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchStore watches the directory of a store and the directories of its wallets.
+func (s *Service) watchStore(watcher *fsnotify.Watcher, base string) error {
+	if err := watcher.Add(base); err != nil {
+		return err
+	}
+	entries, err := ioutil.ReadDir(base)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := watcher.Add(filepath.Join(base, entry.Name())); err != nil {
+			return err
+		}
+	}
+	log.Trace().Str("location", base).Msg("Watching store")
+	return nil
+}
+
+// handleEvent handles a change in the directory of a store.
+func (s *Service) handleEvent(watcher *fsnotify.Watcher, bases map[string]bool, event fsnotify.Event) {
+	dir := filepath.Dir(event.Name)
+	if bases[dir] {
+		// A change to the store itself; watch new wallet directories.
+		if event.Op&fsnotify.Create == fsnotify.Create {
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+				if err := watcher.Add(event.Name); err != nil {
+					log.Warn().Str("path", event.Name).Err(err).Msg("Failed to watch new wallet")
+				}
+				log.Trace().Str("path", event.Name).Msg("Watching new wallet")
+			}
+		}
+		return
+	}
+	if !bases[filepath.Dir(dir)] {
+		return
+	}
+	// A change to a wallet.
+	s.evictWallet(filepath.Base(dir))
+}
+
+// evictWallet removes the wallet with the given ID from the cache, so that it is read afresh from its store.
+func (s *Service) evictWallet(id string) {
+	s.walletsMx.Lock()
+	defer s.walletsMx.Unlock()
+	for name, wallet := range s.wallets {
+		if strings.EqualFold(wallet.ID().String(), id) {
+			log.Trace().Str("wallet", name).Msg("Wallet changed; evicting from cache")
+			delete(s.wallets, name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mem_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/services/fetcher/mem"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	nd "github.com/wealdtech/go-eth2-wallet-nd/v2"
+	filesystem "github.com/wealdtech/go-eth2-wallet-store-filesystem"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	base := t.TempDir()
+	store := filesystem.New(filesystem.WithLocation(base))
+	_, err := nd.CreateWallet(ctx, "Existing wallet", store, keystorev4.New())
+	require.NoError(t, err)
+
+	service, err := mem.New(ctx,
+		mem.WithLogLevel(zerolog.Disabled),
+		mem.WithStores([]e2wtypes.Store{store}),
+		mem.WithWatch(true),
+	)
+	require.NoError(t, err)
+
+	// Cache the existing wallet.
+	_, err = service.FetchWallet(ctx, "Existing wallet")
+	require.NoError(t, err)
+
+	// Create an account in the existing wallet, and a new wallet, outside of the fetcher.
+	externalStore := filesystem.New(filesystem.WithLocation(base))
+	wallet, err := nd.OpenWallet(ctx, "Existing wallet", externalStore, keystorev4.New())
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "New account", []byte("secret"))
+	require.NoError(t, err)
+	newWallet, err := nd.CreateWallet(ctx, "New wallet", externalStore, keystorev4.New())
+	require.NoError(t, err)
+	require.NoError(t, newWallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
+	_, err = newWallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Account", []byte("secret"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		_, _, err := service.FetchAccount(ctx, "Existing wallet/New account")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, _, err := service.FetchAccount(ctx, "New wallet/Account")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatchNoFilesystemStores(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := mem.New(ctx,
+		mem.WithLogLevel(zerolog.Disabled),
+		mem.WithStores([]e2wtypes.Store{scratch.New()}),
+		mem.WithWatch(true),
+	)
+	require.NoError(t, err)
+}
@@ -24,4 +24,10 @@ type Service interface {
 	FetchWallet(ctx context.Context, path string) (types.Wallet, error)
 	FetchAccount(ctx context.Context, path string) (types.Wallet, types.Account, error)
 	FetchAccountByKey(ctx context.Context, pubKey []byte) (types.Wallet, types.Account, error)
+	// DerivedAccounts provides the accounts of the wallet that are derived from its seed on demand
+	// rather than held in its store.
+	DerivedAccounts(ctx context.Context, wallet types.Wallet) ([]types.Account, error)
+	// Invalidate removes any cached information about the account with the given path, so that it is
+	// obtained afresh from its store when next fetched.
+	Invalidate(ctx context.Context, path string)
 }
@@ -35,3 +35,12 @@ func (s *Service) ListAccounts(ctx context.Context,
 	paths []string) (core.Result, []e2wtypes.Account) {
 	return core.ResultSucceeded, make([]e2wtypes.Account, 0)
 }
+
+// ListAccountsPage lists a page of accessible accounts given by the paths.
+func (s *Service) ListAccountsPage(ctx context.Context,
+	credentials *checker.Credentials,
+	paths []string,
+	pageToken string,
+	pageSize uint32) (core.Result, []e2wtypes.Account, string) {
+	return core.ResultSucceeded, make([]e2wtypes.Account, 0), ""
+}
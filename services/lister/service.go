@@ -27,4 +27,14 @@ type Service interface {
 	ListAccounts(ctx context.Context,
 		credentials *checker.Credentials,
 		paths []string) (core.Result, []e2wtypes.Account)
+
+	// ListAccountsPage lists a page of accessible accounts given by the paths, ordered by name.
+	// The page starts after the account given by the page token, or with the first account if the token
+	// is empty, and contains at most pageSize accounts, or all remaining accounts if pageSize is 0.
+	// It also returns the token for the next page, which is empty if there are no further accounts.
+	ListAccountsPage(ctx context.Context,
+		credentials *checker.Credentials,
+		paths []string,
+		pageToken string,
+		pageSize uint32) (core.Result, []e2wtypes.Account, string)
 }
@@ -18,13 +18,13 @@ import (
 
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/services/checker"
-	"github.com/opentracing/opentracing-go"
+	"go.opentelemetry.io/otel"
 )
 
 // checkAccess returns true if the client can access the account.
 func (s *Service) checkAccess(ctx context.Context, credentials *checker.Credentials, accountName string, action string) core.Result {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.lister.checkAccess")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.lister.standard").Start(ctx, "services.lister.checkAccess")
+	defer span.End()
 
 	if s.checker.Check(ctx, credentials, accountName, action) {
 		return core.ResultSucceeded
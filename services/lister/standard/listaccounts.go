@@ -15,8 +15,10 @@ package standard
 
 import (
 	context "context"
+	"encoding/base64"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -24,17 +26,35 @@ import (
 	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/ruler"
+	"github.com/rs/zerolog"
 	wallet "github.com/wealdtech/go-eth2-wallet"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
+// candidate is an account that matches the paths of a listing.
+type candidate struct {
+	walletName string
+	account    e2wtypes.Account
+}
+
 // ListAccounts lists accounts.
 func (s *Service) ListAccounts(ctx context.Context, credentials *checker.Credentials, paths []string) (core.Result, []e2wtypes.Account) {
+	result, accounts, _ := s.ListAccountsPage(ctx, credentials, paths, "", 0)
+	return result, accounts
+}
+
+// ListAccountsPage lists a page of accounts, ordered by name.
+func (s *Service) ListAccountsPage(ctx context.Context,
+	credentials *checker.Credentials,
+	paths []string,
+	pageToken string,
+	pageSize uint32,
+) (core.Result, []e2wtypes.Account, string) {
 	started := time.Now()
 
 	if credentials == nil {
 		log.Error().Msg("No credentials supplied")
-		return core.ResultFailed, nil
+		return core.ResultFailed, nil, ""
 	}
 
 	log := log.With().
@@ -44,7 +64,45 @@ func (s *Service) ListAccounts(ctx context.Context, credentials *checker.Credent
 		Logger()
 	log.Trace().Msg("Request received")
 
+	after, err := decodePageToken(pageToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid page token")
+		return core.ResultDenied, nil, ""
+	}
+
+	candidates := s.matchAccounts(ctx, log, paths)
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		if name > after {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
 	accounts := make([]e2wtypes.Account, 0)
+	nextPageToken := ""
+	lastName := ""
+	for _, name := range names {
+		if !s.accessible(ctx, log, credentials, paths, pageToken, pageSize, name, candidates[name]) {
+			continue
+		}
+		if pageSize > 0 && uint32(len(accounts)) == pageSize {
+			// The page is full and there is at least one further accessible account.
+			nextPageToken = encodePageToken(lastName)
+			break
+		}
+		accounts = append(accounts, candidates[name].account)
+		lastName = name
+	}
+
+	log.Trace().Str("result", "succeeded").Int("accounts", len(accounts)).Bool("more", nextPageToken != "").Msg("Success")
+	s.monitor.ListAccountsCompleted(started)
+	return core.ResultSucceeded, accounts, nextPageToken
+}
+
+// matchAccounts returns the accounts that match the paths, keyed by account name.
+func (s *Service) matchAccounts(ctx context.Context, log zerolog.Logger, paths []string) map[string]*candidate {
+	candidates := make(map[string]*candidate)
 	for _, path := range paths {
 		log := log.With().Str("path", path).Logger()
 		walletName, accountPath, err := wallet.WalletAndAccountNames(path)
@@ -78,50 +136,80 @@ func (s *Service) ListAccounts(ctx context.Context, credentials *checker.Credent
 			continue
 		}
 
+		accounts, err := s.fetcher.DerivedAccounts(ctx, wallet)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to obtain derived accounts")
+		}
 		for account := range wallet.Accounts(ctx) {
+			accounts = append(accounts, account)
+		}
+		for _, account := range accounts {
 			if accountRegex.Match([]byte(account.Name())) {
-				accountName := fmt.Sprintf("%s/%s", wallet.Name(), account.Name())
-				log := log.With().Str("account", accountName).Logger()
-				checkRes := s.checkAccess(ctx, credentials, accountName, ruler.ActionAccessAccount)
-				if checkRes != core.ResultSucceeded {
-					log.Debug().Msg("Access refused")
-					continue
-				}
-				log.Trace().Msg("Access allowed")
-
-				// Confirm listing of the key.
-				var pubKey []byte
-				pubKeyProvider, isProvider := account.(e2wtypes.AccountPublicKeyProvider)
-				if !isProvider {
-					log.Warn().Msg("No public key available")
-					continue
-				}
-				pubKey = pubKeyProvider.PublicKey().Marshal()
-
-				if compositePubKeyProvider, isProvider := account.(e2wtypes.AccountCompositePublicKeyProvider); isProvider {
-					pubKey = compositePubKeyProvider.CompositePublicKey().Marshal()
-				}
-				data := &rules.AccessAccountData{
-					Paths: paths,
-				}
-
-				rulesData := []*ruler.RulesData{
-					{
-						WalletName:  wallet.Name(),
-						AccountName: account.Name(),
-						PubKey:      pubKey,
-						Data:        data,
-					},
-				}
-				results := s.ruler.RunRules(ctx, credentials, ruler.ActionAccessAccount, rulesData)
-				if results[0] == rules.APPROVED {
-					accounts = append(accounts, account)
+				candidates[fmt.Sprintf("%s/%s", wallet.Name(), account.Name())] = &candidate{
+					walletName: wallet.Name(),
+					account:    account,
 				}
 			}
 		}
 	}
+	return candidates
+}
 
-	log.Trace().Str("result", "succeeded").Int("accounts", len(accounts)).Msg("Success")
-	s.monitor.ListAccountsCompleted(started)
-	return core.ResultSucceeded, accounts
+// accessible returns true if the client is permitted to access the account, and the rules approve its listing.
+func (s *Service) accessible(ctx context.Context,
+	log zerolog.Logger,
+	credentials *checker.Credentials,
+	paths []string,
+	pageToken string,
+	pageSize uint32,
+	accountName string,
+	candidate *candidate,
+) bool {
+	log = log.With().Str("account", accountName).Logger()
+	checkRes := s.checkAccess(ctx, credentials, accountName, ruler.ActionAccessAccount)
+	if checkRes != core.ResultSucceeded {
+		log.Debug().Msg("Access refused")
+		return false
+	}
+	log.Trace().Msg("Access allowed")
+
+	// Confirm listing of the key.
+	pubKeyProvider, isProvider := candidate.account.(e2wtypes.AccountPublicKeyProvider)
+	if !isProvider {
+		log.Warn().Msg("No public key available")
+		return false
+	}
+	pubKey := pubKeyProvider.PublicKey().Marshal()
+	if compositePubKeyProvider, isProvider := candidate.account.(e2wtypes.AccountCompositePublicKeyProvider); isProvider {
+		pubKey = compositePubKeyProvider.CompositePublicKey().Marshal()
+	}
+
+	rulesData := []*ruler.RulesData{
+		{
+			WalletName:  candidate.walletName,
+			AccountName: candidate.account.Name(),
+			PubKey:      pubKey,
+			Data: &rules.AccessAccountData{
+				Paths:     paths,
+				PageToken: pageToken,
+				PageSize:  pageSize,
+			},
+		},
+	}
+	results := s.ruler.RunRules(ctx, credentials, ruler.ActionAccessAccount, rulesData)
+	return results[0] == rules.APPROVED
+}
+
+// encodePageToken encodes the name of the last account in a page as the token for the next page.
+func encodePageToken(accountName string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(accountName))
+}
+
+// decodePageToken decodes a page token to the name of the account after which the page starts.
+func decodePageToken(pageToken string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(pageToken)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
 }
@@ -0,0 +1,240 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxIdleConns is the maximum number of idle connections kept to the Redis server.
+const maxIdleConns = 16
+
+// redisError is an error returned by the Redis server.
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+// client is a minimal client for the Redis serialization protocol, supporting the commands
+// required for locking.
+type client struct {
+	address   string
+	password  string
+	db        int
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	idle      chan *conn
+}
+
+// conn is a connection to the Redis server.
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// newClient creates a new client.  Connections are made when required.
+func newClient(address string, password string, db int, tlsConfig *tls.Config, timeout time.Duration) *client {
+	return &client{
+		address:   address,
+		password:  password,
+		db:        db,
+		tlsConfig: tlsConfig,
+		timeout:   timeout,
+		idle:      make(chan *conn, maxIdleConns),
+	}
+}
+
+// do sends a command to the Redis server and returns its reply, which is one of nil, string,
+// int64 or []interface{}.  Errors returned by the server are returned as a redisError.
+func (c *client) do(args ...string) (interface{}, error) {
+	cn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(c.timeout, args...)
+	if err != nil {
+		cn.Close()
+		return nil, err
+	}
+	c.release(cn)
+	if e, isError := reply.(redisError); isError {
+		return nil, e
+	}
+	return reply, nil
+}
+
+// close closes idle connections.
+func (c *client) close() {
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// conn returns an idle connection, or a new connection if there are none.
+func (c *client) conn() (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var netConn net.Conn
+	var err error
+	if c.tlsConfig != nil {
+		netConn, err = tls.DialWithDialer(dialer, "tcp", c.address, c.tlsConfig)
+	} else {
+		netConn, err = dialer.Dial("tcp", c.address)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to Redis")
+	}
+	cn := &conn{
+		Conn:   netConn,
+		reader: bufio.NewReader(netConn),
+	}
+
+	if c.password != "" {
+		if err := cn.expectOK(c.timeout, "AUTH", c.password); err != nil {
+			cn.Close()
+			return nil, errors.Wrap(err, "failed to authenticate with Redis")
+		}
+	}
+	if c.db != 0 {
+		if err := cn.expectOK(c.timeout, "SELECT", strconv.Itoa(c.db)); err != nil {
+			cn.Close()
+			return nil, errors.Wrap(err, "failed to select Redis database")
+		}
+	}
+
+	return cn, nil
+}
+
+// release returns a connection to the idle pool, closing it if the pool is full.
+func (c *client) release(cn *conn) {
+	select {
+	case c.idle <- cn:
+	default:
+		cn.Close()
+	}
+}
+
+// expectOK sends a command that must return OK.
+func (cn *conn) expectOK(timeout time.Duration, args ...string) error {
+	reply, err := cn.do(timeout, args...)
+	if err != nil {
+		return err
+	}
+	if e, isError := reply.(redisError); isError {
+		return e
+	}
+	if reply != "OK" {
+		return fmt.Errorf("unexpected reply %v", reply)
+	}
+	return nil
+}
+
+// do sends a command and reads its reply.
+func (cn *conn) do(timeout time.Duration, args ...string) (interface{}, error) {
+	if err := cn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, errors.Wrap(err, "failed to set deadline")
+	}
+
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := cn.Write(buf); err != nil {
+		return nil, errors.Wrap(err, "failed to send command")
+	}
+
+	return readReply(cn.reader)
+}
+
+// readReply reads a single reply.
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read reply")
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("invalid reply")
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return redisError(line[1:]), nil
+	case ':':
+		val, err := strconv.ParseInt(line[1:], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid integer reply")
+		}
+		return val, nil
+	case '$':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid bulk reply")
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, errors.Wrap(err, "failed to read bulk reply")
+		}
+		return string(data[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid array reply")
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		vals := make([]interface{}, length)
+		for i := range vals {
+			vals[i], err = readReply(reader)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return vals, nil
+	default:
+		return nil, fmt.Errorf("unknown reply type %q", line[0])
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"time"
+
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	monitor       metrics.LockerMonitor
+	trackHolders  bool
+	address       string
+	password      string
+	db            int
+	caCert        []byte
+	keyPrefix     string
+	ttl           time.Duration
+	timeout       time.Duration
+	retryInterval time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for this module.
+func WithMonitor(monitor metrics.LockerMonitor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithTrackHolders tracks the holders of locks taken by this instance, allowing them to be introspected.
+func WithTrackHolders(trackHolders bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.trackHolders = trackHolders
+	})
+}
+
+// WithAddress sets the address of the Redis server, as host:port.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithPassword sets the password used to authenticate with the Redis server.
+func WithPassword(password string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.password = password
+	})
+}
+
+// WithDB sets the Redis database in which locks are held.
+func WithDB(db int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.db = db
+	})
+}
+
+// WithCACert sets the CA certificate used to verify the Redis server.  If this is supplied the
+// connection to the Redis server uses TLS.
+func WithCACert(caCert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = caCert
+	})
+}
+
+// WithKeyPrefix sets the prefix of the Redis keys used for locks.
+func WithKeyPrefix(keyPrefix string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.keyPrefix = keyPrefix
+	})
+}
+
+// WithTTL sets the time for which a lock is held in Redis without being renewed.  Held locks are
+// renewed periodically, so this bounds the time for which a lock remains after an instance fails.
+func WithTTL(ttl time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.ttl = ttl
+	})
+}
+
+// WithTimeout sets the maximum time to wait for the Redis server to respond to each command.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithRetryInterval sets the time between attempts to acquire a lock held by another instance.
+func WithRetryInterval(retryInterval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.retryInterval = retryInterval
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		keyPrefix:     "dirk:lock:",
+		ttl:           30 * time.Second,
+		timeout:       time.Second,
+		retryInterval: 10 * time.Millisecond,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.monitor == nil {
+		// Use no-op monitor.
+		parameters.monitor = &noopMonitor{}
+	}
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.db < 0 {
+		return nil, errors.New("db cannot be negative")
+	}
+	if parameters.ttl < 100*time.Millisecond {
+		return nil, errors.New("TTL must be at least 100ms")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	if parameters.timeout >= parameters.ttl/3 {
+		return nil, errors.New("timeout must be less than a third of the TTL")
+	}
+	if parameters.retryInterval <= 0 {
+		return nil, errors.New("retry interval must be greater than 0")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,266 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/services/locker"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// acquireScript sets a lock if it is not held, returning the next fencing token for the lock, or 0 if it is held.
+// The fencing token is a counter that persists across acquisitions, so is higher for each holder of the lock.
+const acquireScript = `if redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then return redis.call("incr", KEYS[2]) else return 0 end`
+
+// releaseScript deletes a lock only if it is still held with the given token.
+const releaseScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// renewScript extends a lock only if it is still held with the given token.
+const renewScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("pexpire", KEYS[1], ARGV[2]) else return 0 end`
+
+// errorBackoff is the time to wait before retrying after a failure to communicate with Redis.
+const errorBackoff = time.Second
+
+// Service provides a global account locker backed by Redis, allowing multiple instances that share
+// slashing protection to lock accounts against each other.  Locks are first taken within the
+// instance, so only one request per account at a time contends for the lock in Redis.
+// Locks are held in Redis with a TTL, and renewed while held, so locks held by an instance that
+// fails are released once the TTL expires.  If Redis is unavailable locks cannot be acquired, so
+// requests wait until their context is done rather than proceed without the lock.
+// A lock that cannot be renewed before its TTL expires is lost, and its holder informed.  Each
+// acquisition of a lock has a fencing token, allowing stores to refuse updates from a holder that
+// continues after losing its lock.
+type Service struct {
+	monitor       metrics.LockerMonitor
+	local         *syncmaplocker.Service
+	client        *client
+	keyPrefix     string
+	ttl           time.Duration
+	retryInterval time.Duration
+	// held contains the leases of locks held by this instance.
+	held sync.Map
+}
+
+// lease is a lock held in Redis.
+type lease struct {
+	token string
+	lost  func()
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new Redis locker.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "locker").Str("impl", "redis").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	var tlsConfig *tls.Config
+	if len(parameters.caCert) > 0 {
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM(parameters.caCert) {
+			return nil, errors.New("failed to add CA certificate")
+		}
+		tlsConfig = &tls.Config{
+			RootCAs:    cp,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	// The local locker does not report to the monitor, as acquisition is reported once the lock in
+	// Redis is also held.
+	local, err := syncmaplocker.New(ctx,
+		syncmaplocker.WithLogLevel(parameters.logLevel),
+		syncmaplocker.WithTrackHolders(parameters.trackHolders),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create local locker")
+	}
+
+	s := &Service{
+		monitor:       parameters.monitor,
+		local:         local,
+		client:        newClient(parameters.address, parameters.password, parameters.db, tlsConfig, parameters.timeout),
+		keyPrefix:     parameters.keyPrefix,
+		ttl:           parameters.ttl,
+		retryInterval: parameters.retryInterval,
+	}
+
+	// An unavailable Redis server does not prevent startup, but is reported.
+	if _, err := s.client.do("PING"); err != nil {
+		log.Warn().Err(err).Str("address", parameters.address).Msg("Failed to contact Redis server")
+	}
+
+	return s, nil
+}
+
+// Lock acquires a lock for a given public key.
+// It returns an error if the context is done before the lock is acquired.
+func (s *Service) Lock(ctx context.Context, key [48]byte) error {
+	_, err := s.LockWithHolder(ctx, key, "", "", nil)
+	return err
+}
+
+// LockWithHolder acquires a lock for a given public key, recording the action and client
+// for which it is held if holder tracking is enabled.
+// It returns an error if the context is done before the lock is acquired.
+// lost is called, if supplied, if the lock cannot be renewed before its TTL expires.
+// It returns the fencing token of the lock.
+func (s *Service) LockWithHolder(ctx context.Context, key [48]byte, action string, client string, lost func()) (uint64, error) {
+	started := time.Now()
+	if _, err := s.local.LockWithHolder(ctx, key, action, client, nil); err != nil {
+		return 0, err
+	}
+
+	redisKey := s.redisKey(key)
+	token := newToken()
+	ttl := strconv.FormatInt(s.ttl.Milliseconds(), 10)
+	var fencingToken uint64
+	for {
+		reply, err := s.client.do("EVAL", acquireScript, "2", redisKey, s.fencingKey(key), token, ttl)
+		if err == nil {
+			if counter, isCounter := reply.(int64); isCounter && counter > 0 {
+				fencingToken = uint64(counter)
+				break
+			}
+		}
+		backoff := s.retryInterval
+		if err != nil {
+			log.Error().Err(err).Str("key", redisKey).Msg("Failed to acquire lock; retrying")
+			backoff = errorBackoff
+		}
+		select {
+		case <-ctx.Done():
+			s.local.Unlock(key)
+			return 0, errors.Wrap(ctx.Err(), "lock not acquired")
+		case <-time.After(backoff):
+		}
+	}
+	acquired := time.Now()
+	s.monitor.LockAcquired(acquired.Sub(started))
+
+	lease := &lease{
+		token: token,
+		lost:  lost,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	s.held.Store(key, lease)
+	go s.renew(redisKey, lease, acquired)
+
+	return fencingToken, nil
+}
+
+// Unlock frees a lock for a given public key.
+func (s *Service) Unlock(key [48]byte) {
+	val, exists := s.held.Load(key)
+	if !exists {
+		panic("Attempt to unlock an unknown lock")
+	}
+	s.held.Delete(key)
+	lease := val.(*lease)
+	close(lease.stop)
+	<-lease.done
+
+	redisKey := s.redisKey(key)
+	if _, err := s.client.do("EVAL", releaseScript, "1", redisKey, lease.token); err != nil {
+		log.Error().Err(err).Str("key", redisKey).Msg("Failed to release lock; it will be released when it expires")
+	}
+	s.local.Unlock(key)
+}
+
+// HeldLocks returns the locks that are currently held by this instance, oldest first.
+// It returns an error if holder tracking is not enabled.
+func (s *Service) HeldLocks() ([]*locker.HeldLock, error) {
+	return s.local.HeldLocks()
+}
+
+// Close closes the connections to the Redis server.
+func (s *Service) Close() {
+	s.client.close()
+}
+
+// renew renews a held lock until it is released or lost.
+func (s *Service) renew(redisKey string, lease *lease, renewed time.Time) {
+	defer close(lease.done)
+	ttl := strconv.FormatInt(s.ttl.Milliseconds(), 10)
+	ticker := time.NewTicker(s.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-lease.stop:
+			return
+		case <-ticker.C:
+			started := time.Now()
+			reply, err := s.client.do("EVAL", renewScript, "1", redisKey, lease.token, ttl)
+			switch {
+			case err == nil && reply == int64(1):
+				renewed = started
+				continue
+			case err == nil:
+				log.Error().Str("key", redisKey).Msg("Lock lost before it was released")
+			case time.Since(renewed) < s.ttl:
+				log.Warn().Err(err).Str("key", redisKey).Msg("Failed to renew lock")
+				continue
+			default:
+				log.Error().Err(err).Str("key", redisKey).Msg("Failed to renew lock before it expired; lock lost")
+			}
+			if lease.lost != nil {
+				lease.lost()
+			}
+			return
+		}
+	}
+}
+
+// redisKey returns the Redis key for the lock of a public key.
+func (s *Service) redisKey(key [48]byte) string {
+	return s.keyPrefix + hex.EncodeToString(key[:])
+}
+
+// fencingKey returns the Redis key for the fencing token counter of the lock of a public key.
+func (s *Service) fencingKey(key [48]byte) string {
+	return s.keyPrefix + "fence:" + hex.EncodeToString(key[:])
+}
+
+// newToken returns a random token identifying a single acquisition of a lock.
+func newToken() string {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(token)
+}
@@ -0,0 +1,348 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redis_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	redislocker "github.com/attestantio/dirk/services/locker/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis is an in-process Redis server supporting the commands used by the locker.
+type fakeRedis struct {
+	mu       sync.Mutex
+	password string
+	values   map[string]string
+	expiries map[string]time.Time
+}
+
+// startRedis starts a fake Redis server, returning its address.
+func startRedis(t *testing.T, password string) (*fakeRedis, string) {
+	server := &fakeRedis{
+		password: password,
+		values:   make(map[string]string),
+		expiries: make(map[string]time.Time),
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server, listener.Addr().String()
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	authenticated := r.password == ""
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		var reply string
+		switch {
+		case strings.EqualFold(args[0], "AUTH"):
+			if args[1] == r.password {
+				authenticated = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid password\r\n"
+			}
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+		default:
+			reply = r.command(args)
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (r *fakeRedis) command(args []string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, expiry := range r.expiries {
+		if time.Now().After(expiry) {
+			delete(r.values, key)
+			delete(r.expiries, key)
+		}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "EVAL":
+		if strings.Contains(args[1], "incr") {
+			// EVAL script 2 key fencingkey token ttl
+			if _, exists := r.values[args[3]]; exists {
+				return ":0\r\n"
+			}
+			ttl, _ := strconv.Atoi(args[6])
+			r.values[args[3]] = args[5]
+			r.expiries[args[3]] = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+			counter, _ := strconv.Atoi(r.values[args[4]])
+			r.values[args[4]] = strconv.Itoa(counter + 1)
+			return fmt.Sprintf(":%d\r\n", counter+1)
+		}
+		// EVAL script 1 key token [ttl]
+		if r.values[args[3]] != args[4] {
+			return ":0\r\n"
+		}
+		if strings.Contains(args[1], "pexpire") {
+			ttl, _ := strconv.Atoi(args[5])
+			r.expiries[args[3]] = time.Now().Add(time.Duration(ttl) * time.Millisecond)
+		} else {
+			delete(r.values, args[3])
+			delete(r.expiries, args[3])
+		}
+		return ":1\r\n"
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// expire removes a key, as if it had expired.
+func (r *fakeRedis) expire(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.values, key)
+	delete(r.expiries, key)
+}
+
+func (r *fakeRedis) held(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expiry, exists := r.expiries[key]
+	return exists && time.Now().Before(expiry)
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:length])
+	}
+	return args, nil
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []redislocker.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			err:  "problem with parameters: no address specified",
+		},
+		{
+			name: "DBNegative",
+			params: []redislocker.Parameter{
+				redislocker.WithAddress("localhost:6379"),
+				redislocker.WithDB(-1),
+			},
+			err: "problem with parameters: db cannot be negative",
+		},
+		{
+			name: "TTLShort",
+			params: []redislocker.Parameter{
+				redislocker.WithAddress("localhost:6379"),
+				redislocker.WithTTL(10 * time.Millisecond),
+			},
+			err: "problem with parameters: TTL must be at least 100ms",
+		},
+		{
+			name: "TimeoutLong",
+			params: []redislocker.Parameter{
+				redislocker.WithAddress("localhost:6379"),
+				redislocker.WithTTL(time.Second),
+				redislocker.WithTimeout(500 * time.Millisecond),
+			},
+			err: "problem with parameters: timeout must be less than a third of the TTL",
+		},
+		{
+			name: "CACertInvalid",
+			params: []redislocker.Parameter{
+				redislocker.WithAddress("localhost:6379"),
+				redislocker.WithCACert([]byte("invalid")),
+			},
+			err: "failed to add CA certificate",
+		},
+		{
+			name: "Good",
+			params: []redislocker.Parameter{
+				redislocker.WithAddress("localhost:6379"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := redislocker.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInstances(t *testing.T) {
+	ctx := context.Background()
+	server, address := startRedis(t, "secret")
+
+	// Two lockers sharing a Redis server represent two instances.
+	newInstance := func() *redislocker.Service {
+		s, err := redislocker.New(ctx,
+			redislocker.WithAddress(address),
+			redislocker.WithPassword("secret"),
+			redislocker.WithTTL(300*time.Millisecond),
+			redislocker.WithTimeout(50*time.Millisecond),
+			redislocker.WithRetryInterval(5*time.Millisecond),
+			redislocker.WithTrackHolders(true),
+		)
+		require.NoError(t, err)
+		t.Cleanup(s.Close)
+		return s
+	}
+	instance1 := newInstance()
+	instance2 := newInstance()
+
+	key := [48]byte{0x01}
+	redisKey := "dirk:lock:01" + strings.Repeat("00", 47)
+
+	token1, err := instance1.LockWithHolder(ctx, key, "Sign", "client1", nil)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), token1)
+	require.True(t, server.held(redisKey))
+	heldLocks, err := instance1.HeldLocks()
+	require.NoError(t, err)
+	require.Len(t, heldLocks, 1)
+	require.Equal(t, "client1", heldLocks[0].Client)
+
+	// Waiting for a lock held by another instance is abandoned when the context is done.
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	require.EqualError(t, instance2.Lock(waitCtx, key), "lock not acquired: context deadline exceeded")
+
+	acquired := make(chan uint64)
+	go func() {
+		token, err := instance2.LockWithHolder(ctx, key, "", "", nil)
+		assert.NoError(t, err)
+		acquired <- token
+	}()
+
+	// The lock is renewed, so remains held by the first instance beyond its TTL.
+	select {
+	case <-acquired:
+		require.Fail(t, "lock acquired whilst held by another instance")
+	case <-time.After(600 * time.Millisecond):
+	}
+	require.True(t, server.held(redisKey))
+
+	instance1.Unlock(key)
+	select {
+	case token2 := <-acquired:
+		// Fencing tokens increase with each acquisition of the lock.
+		require.Equal(t, uint64(2), token2)
+	case <-time.After(time.Second):
+		require.Fail(t, "lock not acquired after release by another instance")
+	}
+	instance2.Unlock(key)
+	require.False(t, server.held(redisKey))
+
+	// Locks for different keys do not contend.
+	require.NoError(t, instance1.Lock(ctx, key))
+	require.NoError(t, instance2.Lock(ctx, [48]byte{0x02}))
+	instance2.Unlock([48]byte{0x02})
+	instance1.Unlock(key)
+}
+
+func TestLost(t *testing.T) {
+	ctx := context.Background()
+	server, address := startRedis(t, "")
+
+	s, err := redislocker.New(ctx,
+		redislocker.WithAddress(address),
+		redislocker.WithTTL(150*time.Millisecond),
+		redislocker.WithTimeout(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+	t.Cleanup(s.Close)
+
+	key := [48]byte{0x01}
+	lost := make(chan struct{})
+	_, err = s.LockWithHolder(ctx, key, "", "", func() { close(lost) })
+	require.NoError(t, err)
+
+	// The lock is taken by another holder when it expires before it is renewed.
+	server.expire("dirk:lock:01" + strings.Repeat("00", 47))
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		require.Fail(t, "loss of lock not reported")
+	}
+	s.Unlock(key)
+}
+
+func TestUnlockUnknown(t *testing.T) {
+	ctx := context.Background()
+	_, address := startRedis(t, "")
+
+	s, err := redislocker.New(ctx, redislocker.WithAddress(address))
+	require.NoError(t, err)
+	require.Panics(t, func() { s.Unlock([48]byte{0x01}) })
+}
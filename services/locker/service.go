@@ -13,10 +13,58 @@
 
 package locker
 
+import (
+	"context"
+	"time"
+)
+
+// HeldLock contains information about a lock that is currently held.
+type HeldLock struct {
+	// PubKey is the public key that is locked.
+	PubKey [48]byte
+	// Action is the action for which the lock was taken.
+	Action string
+	// Client is the client for which the lock was taken.
+	Client string
+	// Acquired is the time at which the lock was acquired.
+	Acquired time.Time
+}
+
 // Service provides the features and functions for a global account locker.
 type Service interface {
 	// Lock acquires a lock for a given public key.
-	Lock(key [48]byte)
+	// It returns an error if the context is done before the lock is acquired.
+	Lock(ctx context.Context, key [48]byte) error
+	// LockWithHolder acquires a lock for a given public key, recording the action and client
+	// for which it is held if holder tracking is enabled.
+	// It returns an error if the context is done before the lock is acquired.
+	// If the lock is lost before it is unlocked, for example because it is held in an external service
+	// that cannot be reached to renew it, lost is called if supplied; the holder must then stop acting
+	// under the lock.
+	// It returns the fencing token of the lock, which is higher each time the lock for a key is acquired,
+	// or 0 if the locker does not issue fencing tokens.
+	LockWithHolder(ctx context.Context, key [48]byte, action string, client string, lost func()) (uint64, error)
 	// Unlock frees a lock for a given public key.
 	Unlock(key [48]byte)
+	// HeldLocks returns the locks that are currently held.
+	// It returns an error if holder tracking is not enabled.
+	HeldLocks() ([]*HeldLock, error)
+}
+
+type fencingTokensKey struct{}
+
+// WithFencingTokens returns a context carrying the fencing tokens of the locks held for public keys,
+// allowing stores to refuse updates made under a lock that has since been acquired by another holder.
+func WithFencingTokens(ctx context.Context, tokens map[[48]byte]uint64) context.Context {
+	return context.WithValue(ctx, fencingTokensKey{}, tokens)
+}
+
+// FencingToken returns the fencing token carried by the context for the lock of a public key,
+// or 0 if there is none.
+func FencingToken(ctx context.Context, key [48]byte) uint64 {
+	tokens, ok := ctx.Value(fencingTokensKey{}).(map[[48]byte]uint64)
+	if !ok {
+		return 0
+	}
+	return tokens[key]
 }
@@ -13,6 +13,11 @@
 
 package syncmap
 
+import "time"
+
 // noopMonitor is a monitor that does nothing, used in place of nil if an
 // external monitor is not supplied.
 type noopMonitor struct{}
+
+// LockAcquired is called when a lock is acquired.
+func (m *noopMonitor) LockAcquired(duration time.Duration) {}
@@ -19,8 +19,9 @@ import (
 )
 
 type parameters struct {
-	logLevel zerolog.Level
-	monitor  metrics.LockerMonitor
+	logLevel     zerolog.Level
+	monitor      metrics.LockerMonitor
+	trackHolders bool
 }
 
 // Parameter is the interface for service parameters.
@@ -48,6 +49,13 @@ func WithMonitor(monitor metrics.LockerMonitor) Parameter {
 	})
 }
 
+// WithTrackHolders tracks the holders of locks, allowing them to be introspected.
+func WithTrackHolders(trackHolders bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.trackHolders = trackHolders
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
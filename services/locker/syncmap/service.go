@@ -15,8 +15,11 @@ package syncmap
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/attestantio/dirk/services/locker"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -24,10 +27,16 @@ import (
 )
 
 // Service provides a global account locker using sync.Map
+// Each lock is a channel with a buffer of one, which is held while it contains a value, so that waiting
+// for a lock can be abandoned.
 type Service struct {
 	monitor      metrics.LockerMonitor
 	locks        *sync.Map
 	newLockMutex *sync.Mutex
+	trackHolders bool
+	// holders contains the holders of locks, if tracked.  It is separate from locks so
+	// that introspection never waits on a held lock.
+	holders *sync.Map
 }
 
 // module-wide log.
@@ -50,24 +59,54 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		monitor:      parameters.monitor,
 		locks:        &sync.Map{},
 		newLockMutex: &sync.Mutex{},
+		trackHolders: parameters.trackHolders,
+		holders:      &sync.Map{},
 	}
 
 	return s, nil
 }
 
 // Lock acquires a lock for a given public key.
-func (s *Service) Lock(key [48]byte) {
+// It returns an error if the context is done before the lock is acquired.
+func (s *Service) Lock(ctx context.Context, key [48]byte) error {
+	_, err := s.LockWithHolder(ctx, key, "", "", nil)
+	return err
+}
+
+// LockWithHolder acquires a lock for a given public key, recording the action and client
+// for which it is held if holder tracking is enabled.
+// It returns an error if the context is done before the lock is acquired.
+// Locks held in memory are never lost, and have no fencing token.
+func (s *Service) LockWithHolder(ctx context.Context, key [48]byte, action string, client string, _ func()) (uint64, error) {
+	started := time.Now()
 	lock, exists := s.locks.Load(key)
 	if !exists {
 		s.newLockMutex.Lock()
 		lock, exists = s.locks.Load(key)
 		if !exists {
-			lock = &sync.Mutex{}
+			lock = make(chan struct{}, 1)
 			s.locks.Store(key, lock)
 		}
 		s.newLockMutex.Unlock()
 	}
-	lock.(*sync.Mutex).Lock()
+	select {
+	case lock.(chan struct{}) <- struct{}{}:
+	case <-ctx.Done():
+		return 0, errors.Wrap(ctx.Err(), "lock not acquired")
+	}
+	acquired := time.Now()
+	s.monitor.LockAcquired(acquired.Sub(started))
+
+	if s.trackHolders {
+		s.holders.Store(key, &locker.HeldLock{
+			PubKey:   key,
+			Action:   action,
+			Client:   client,
+			Acquired: acquired,
+		})
+	}
+
+	return 0, nil
 }
 
 // Unlock frees a lock for a given public key.
@@ -76,5 +115,28 @@ func (s *Service) Unlock(key [48]byte) {
 	if !exists {
 		panic("Attempt to unlock an unknown lock")
 	}
-	lock.(*sync.Mutex).Unlock()
+	if s.trackHolders {
+		s.holders.Delete(key)
+	}
+	<-lock.(chan struct{})
+}
+
+// HeldLocks returns the locks that are currently held, oldest first.
+// It returns an error if holder tracking is not enabled.
+func (s *Service) HeldLocks() ([]*locker.HeldLock, error) {
+	if !s.trackHolders {
+		return nil, errors.New("lock holder tracking not enabled")
+	}
+
+	heldLocks := make([]*locker.HeldLock, 0)
+	s.holders.Range(func(_ interface{}, value interface{}) bool {
+		heldLock := *(value.(*locker.HeldLock))
+		heldLocks = append(heldLocks, &heldLock)
+		return true
+	})
+	sort.Slice(heldLocks, func(i int, j int) bool {
+		return heldLocks[i].Acquired.Before(heldLocks[j].Acquired)
+	})
+
+	return heldLocks, nil
 }
@@ -17,6 +17,7 @@ import (
 	"context"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/attestantio/dirk/services/locker/syncmap"
 	"github.com/attestantio/dirk/services/metrics"
@@ -77,7 +78,7 @@ func TestLocking(t *testing.T) {
 		wg.Add(1)
 		go func() {
 			for i := 0; i < 1024; i++ {
-				locker.Lock(testKey)
+				assert.NoError(t, locker.Lock(ctx, testKey))
 				counter++
 				locker.Unlock(testKey)
 			}
@@ -89,6 +90,25 @@ func TestLocking(t *testing.T) {
 	assert.Equal(t, 16*1024, counter)
 }
 
+func TestLockContext(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmap.New(ctx, syncmap.WithLogLevel(zerolog.Disabled))
+	require.Nil(t, err)
+
+	testKey := [48]byte{}
+	require.NoError(t, locker.Lock(ctx, testKey))
+
+	// Waiting for a held lock is abandoned when the context is done.
+	waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.EqualError(t, locker.Lock(waitCtx, testKey), "lock not acquired: context deadline exceeded")
+
+	locker.Unlock(testKey)
+	require.NoError(t, locker.Lock(ctx, testKey))
+	locker.Unlock(testKey)
+}
+
 func TestBadUnlock(t *testing.T) {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 	ctx := context.Background()
@@ -99,3 +119,47 @@ func TestBadUnlock(t *testing.T) {
 
 	assert.Panics(t, func() { locker.Unlock(testKey) })
 }
+
+func TestHeldLocks(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmap.New(ctx, syncmap.WithLogLevel(zerolog.Disabled))
+	require.Nil(t, err)
+	_, err = locker.HeldLocks()
+	require.EqualError(t, err, "lock holder tracking not enabled")
+
+	locker, err = syncmap.New(ctx, syncmap.WithLogLevel(zerolog.Disabled), syncmap.WithTrackHolders(true))
+	require.Nil(t, err)
+
+	heldLocks, err := locker.HeldLocks()
+	require.Nil(t, err)
+	require.Len(t, heldLocks, 0)
+
+	key1 := [48]byte{0x01}
+	key2 := [48]byte{0x02}
+	_, err = locker.LockWithHolder(ctx, key1, "Sign", "client1", nil)
+	require.NoError(t, err)
+	require.NoError(t, locker.Lock(ctx, key2))
+
+	// Introspection must not block while locks are held.
+	heldLocks, err = locker.HeldLocks()
+	require.Nil(t, err)
+	require.Len(t, heldLocks, 2)
+	assert.Equal(t, key1, heldLocks[0].PubKey)
+	assert.Equal(t, "Sign", heldLocks[0].Action)
+	assert.Equal(t, "client1", heldLocks[0].Client)
+	assert.False(t, heldLocks[0].Acquired.IsZero())
+	assert.Equal(t, key2, heldLocks[1].PubKey)
+	assert.Equal(t, "", heldLocks[1].Client)
+
+	locker.Unlock(key1)
+	heldLocks, err = locker.HeldLocks()
+	require.Nil(t, err)
+	require.Len(t, heldLocks, 1)
+	assert.Equal(t, key2, heldLocks[0].PubKey)
+
+	locker.Unlock(key2)
+	heldLocks, err = locker.HeldLocks()
+	require.Nil(t, err)
+	require.Len(t, heldLocks, 0)
+}
@@ -0,0 +1,33 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (s *Service) setupAPIMetrics() error {
+	s.apiRequestsThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dirk",
+		Subsystem: "api",
+		Name:      "requests_throttled_total",
+		Help:      "The number of requests rejected by the rate limiter.",
+	}, []string{"client"})
+	return prometheus.Register(s.apiRequestsThrottled)
+}
+
+// RequestThrottled is called when a request from a client is rejected by the rate limiter.
+func (s *Service) RequestThrottled(client string) {
+	s.apiRequestsThrottled.WithLabelValues(s.clientLabel(client)).Inc()
+}
@@ -0,0 +1,35 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (s *Service) setupCheckerMetrics() error {
+	s.checkerClientLastActivity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "dirk",
+		Subsystem: "checker",
+		Name:      "client_last_activity_timestamp_seconds",
+		Help:      "The Unix timestamp of the last request from the client.",
+	}, []string{"client"})
+	return prometheus.Register(s.checkerClientLastActivity)
+}
+
+// ClientActive is called when a known client makes a request.
+func (s *Service) ClientActive(client string, at time.Time) {
+	s.checkerClientLastActivity.WithLabelValues(s.clientLabel(client)).Set(float64(at.Unix()))
+}
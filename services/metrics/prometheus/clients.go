@@ -0,0 +1,69 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"sync"
+)
+
+// clientLabels limits the number of distinct values of the client label, to avoid an
+// unbounded number of time series being created by clients with many identities.
+type clientLabels struct {
+	mu       sync.RWMutex
+	max      int
+	overflow string
+	clients  map[string]bool
+	warned   bool
+}
+
+// newClientLabels creates a new client label limiter.
+func newClientLabels(max int, overflow string) *clientLabels {
+	return &clientLabels{
+		max:      max,
+		overflow: overflow,
+		clients:  make(map[string]bool),
+	}
+}
+
+// label returns the label value to use for the given client.  Clients are labelled individually
+// until the maximum is reached, after which new clients are labelled with the overflow value.
+func (c *clientLabels) label(client string) string {
+	c.mu.RLock()
+	tracked := c.clients[client]
+	c.mu.RUnlock()
+	if tracked {
+		return client
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clients[client] {
+		return client
+	}
+	if len(c.clients) >= c.max {
+		if !c.warned {
+			log.Warn().Int("max_client_labels", c.max).Str("client", client).Msg("Maximum client labels reached; further clients will be labelled as overflow")
+			c.warned = true
+		}
+		return c.overflow
+	}
+	c.clients[client] = true
+	return client
+}
+
+// clientLabel returns the label value to use for the given client.
+// All metrics with a client label should obtain the label value from this function.
+func (s *Service) clientLabel(client string) string {
+	return s.clientLabels.label(client)
+}
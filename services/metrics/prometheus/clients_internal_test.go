@@ -0,0 +1,60 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientLabels(t *testing.T) {
+	labels := newClientLabels(3, "other")
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, fmt.Sprintf("client%d", i), labels.label(fmt.Sprintf("client%d", i)))
+	}
+
+	// Beyond the cap new clients use the overflow label.
+	require.Equal(t, "other", labels.label("client3"))
+	require.Equal(t, "other", labels.label("client4"))
+
+	// Clients seen before reaching the cap retain their own label.
+	require.Equal(t, "client0", labels.label("client0"))
+	require.Equal(t, "client2", labels.label("client2"))
+	require.Len(t, labels.clients, 3)
+}
+
+func TestClientLabelsDisabled(t *testing.T) {
+	labels := newClientLabels(0, "unknown")
+	require.Equal(t, "unknown", labels.label("client1"))
+}
+
+func TestClientLabelsConcurrent(t *testing.T) {
+	labels := newClientLabels(10, "other")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			labels.label(fmt.Sprintf("client%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, labels.clients, 10)
+}
@@ -0,0 +1,37 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (s *Service) setupConsensusMetrics() error {
+	s.consensusConfirmations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dirk",
+		Subsystem: "consensus",
+		Name:      "confirmations_total",
+		Help:      "The number of high-water mark confirmations with peers.",
+	}, []string{"kind", "result"})
+	if err := prometheus.Register(s.consensusConfirmations); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmCompleted is called when confirmation of a high-water mark with peers has completed.
+func (s *Service) ConfirmCompleted(kind string, result string) {
+	s.consensusConfirmations.WithLabelValues(kind, result).Inc()
+}
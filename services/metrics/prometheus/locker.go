@@ -0,0 +1,43 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (s *Service) setupLockerMetrics() error {
+	s.lockerWaitTimer =
+		prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "dirk",
+			Subsystem: "locker",
+			Name:      "wait_duration_seconds",
+			Help:      "The time dirk spends waiting to acquire account locks.",
+			Buckets: []float64{
+				0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1.0, 2.0, 5.0,
+			},
+		})
+	if err := prometheus.Register(s.lockerWaitTimer); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// LockAcquired is called when a lock is acquired, with the time spent waiting for it.
+func (s *Service) LockAcquired(duration time.Duration) {
+	s.lockerWaitTimer.Observe(duration.Seconds())
+}
@@ -20,8 +20,10 @@ import (
 )
 
 type parameters struct {
-	logLevel zerolog.Level
-	address  string
+	logLevel            zerolog.Level
+	address             string
+	maxClientLabels     int
+	clientLabelOverflow string
 }
 
 // Parameter is the interface for service parameters.
@@ -49,10 +51,27 @@ func WithAddress(address string) Parameter {
 	})
 }
 
+// WithMaxClientLabels sets the maximum number of distinct client label values that are tracked.
+// Clients beyond this are recorded with the overflow label.
+func WithMaxClientLabels(maxClientLabels int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxClientLabels = maxClientLabels
+	})
+}
+
+// WithClientLabelOverflow sets the client label value used for clients beyond the maximum.
+func WithClientLabelOverflow(clientLabelOverflow string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientLabelOverflow = clientLabelOverflow
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:            zerolog.GlobalLevel(),
+		maxClientLabels:     100,
+		clientLabelOverflow: "other",
 	}
 	for _, p := range params {
 		if params != nil {
@@ -63,6 +82,12 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.address == "" {
 		return nil, errors.New("no address specified")
 	}
+	if parameters.maxClientLabels < 0 {
+		return nil, errors.New("max client labels cannot be negative")
+	}
+	if parameters.clientLabelOverflow == "" {
+		return nil, errors.New("no client label overflow specified")
+	}
 
 	return &parameters, nil
 }
@@ -0,0 +1,59 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (s *Service) setupRulerMetrics() error {
+	s.rulerProcessTimer =
+		prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "dirk",
+			Subsystem: "ruler",
+			Name:      "duration_seconds",
+			Help:      "The time dirk spends evaluating rules for a request.",
+			Buckets: []float64{
+				0.001, 0.002, 0.005, 0.01, 0.02, 0.05, 0.1, 0.2, 0.5, 1.0, 2.0, 5.0,
+			},
+		}, []string{"action"})
+	if err := prometheus.Register(s.rulerProcessTimer); err != nil {
+		return err
+	}
+
+	s.rulerEvaluations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dirk",
+		Subsystem: "ruler",
+		Name:      "evaluations_total",
+		Help:      "The number of rule evaluations.",
+	}, []string{"action", "result"})
+	if err := prometheus.Register(s.rulerEvaluations); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RulesCompleted is called when rules have been run for a request, with the result of each entry.
+func (s *Service) RulesCompleted(started time.Time, action string, results []rules.Result) {
+	action = strings.ToLower(action)
+	s.rulerProcessTimer.WithLabelValues(action).Observe(time.Since(started).Seconds())
+	for _, result := range results {
+		s.rulerEvaluations.WithLabelValues(action, strings.ToLower(result.String())).Inc()
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func (s *Service) setupRulesMetrics() error {
+	s.rulesStoreRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dirk",
+		Subsystem: "rules_store",
+		Name:      "retries_total",
+		Help:      "The number of retried operations against the rules store.",
+	}, []string{"operation"})
+	if err := prometheus.Register(s.rulesStoreRetries); err != nil {
+		return err
+	}
+
+	s.rulesSlashingsPrevented = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "dirk",
+		Subsystem: "rules",
+		Name:      "slashings_prevented_total",
+		Help:      "The number of requests refused because signing them would be slashable.",
+	}, []string{"action"})
+	if err := prometheus.Register(s.rulesSlashingsPrevented); err != nil {
+		return err
+	}
+
+	s.rulesStoreBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "dirk",
+		Subsystem: "rules_store",
+		Name:      "breaker_state",
+		Help:      "The state of the circuit breaker around the rules store; 1 for the current state, else 0.",
+	}, []string{"state"})
+	if err := prometheus.Register(s.rulesStoreBreakerState); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// StoreRetried is called when an operation against the rules store is retried.
+func (s *Service) StoreRetried(operation string) {
+	s.rulesStoreRetries.WithLabelValues(operation).Inc()
+}
+
+// SlashingPrevented is called when a request is refused because signing it would be slashable.
+func (s *Service) SlashingPrevented(action string) {
+	s.rulesSlashingsPrevented.WithLabelValues(action).Inc()
+}
+
+// StoreBreakerState is called when the circuit breaker around the rules store changes state.
+func (s *Service) StoreBreakerState(state string) {
+	for _, known := range []string{"closed", "open", "half-open"} {
+		if known == state {
+			s.rulesStoreBreakerState.WithLabelValues(known).Set(1)
+		} else {
+			s.rulesStoreBreakerState.WithLabelValues(known).Set(0)
+		}
+	}
+}
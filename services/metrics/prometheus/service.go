@@ -40,6 +40,23 @@ type Service struct {
 
 	signerProcessTimer *prometheus.HistogramVec
 	signerRequests     *prometheus.CounterVec
+
+	rulerProcessTimer *prometheus.HistogramVec
+	rulerEvaluations  *prometheus.CounterVec
+
+	rulesStoreRetries       *prometheus.CounterVec
+	rulesSlashingsPrevented *prometheus.CounterVec
+	rulesStoreBreakerState  *prometheus.GaugeVec
+
+	consensusConfirmations *prometheus.CounterVec
+
+	checkerClientLastActivity *prometheus.GaugeVec
+
+	apiRequestsThrottled *prometheus.CounterVec
+
+	lockerWaitTimer prometheus.Histogram
+
+	clientLabels *clientLabels
 }
 
 // module-wide log.
@@ -58,7 +75,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		log = log.Level(parameters.logLevel)
 	}
 
-	s := &Service{}
+	s := &Service{
+		clientLabels: newClientLabels(parameters.maxClientLabels, parameters.clientLabelOverflow),
+	}
 
 	if err := s.setupBaseMetrics(); err != nil {
 		return nil, errors.Wrap(err, "failed to set up base metrics")
@@ -78,6 +97,24 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if err := s.setupSignerMetrics(); err != nil {
 		return nil, errors.Wrap(err, "failed to set up signer metrics")
 	}
+	if err := s.setupRulerMetrics(); err != nil {
+		return nil, errors.Wrap(err, "failed to set up ruler metrics")
+	}
+	if err := s.setupRulesMetrics(); err != nil {
+		return nil, errors.Wrap(err, "failed to set up rules metrics")
+	}
+	if err := s.setupConsensusMetrics(); err != nil {
+		return nil, errors.Wrap(err, "failed to set up consensus metrics")
+	}
+	if err := s.setupLockerMetrics(); err != nil {
+		return nil, errors.Wrap(err, "failed to set up locker metrics")
+	}
+	if err := s.setupCheckerMetrics(); err != nil {
+		return nil, errors.Wrap(err, "failed to set up checker metrics")
+	}
+	if err := s.setupAPIMetrics(); err != nil {
+		return nil, errors.Wrap(err, "failed to set up API metrics")
+	}
 
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
 )
 
 // Service is the generic metrics service.
@@ -41,6 +42,8 @@ type UnlockerMonitor interface {
 
 // CheckerMonitor monitors the checker service.
 type CheckerMonitor interface {
+	// ClientActive is called when a known client makes a request.
+	ClientActive(client string, at time.Time)
 }
 
 // SignerMonitor monitors the signer service.
@@ -55,14 +58,34 @@ type FetcherMonitor interface {
 
 // LockerMonitor monitors the locker service.
 type LockerMonitor interface {
+	// LockAcquired is called when a lock is acquired, with the time spent waiting for it.
+	LockAcquired(duration time.Duration)
+}
+
+// RulesMonitor monitors the rules service.
+type RulesMonitor interface {
+	// StoreRetried is called when an operation against the rules store is retried.
+	StoreRetried(operation string)
+	// SlashingPrevented is called when a request is refused because signing it would be slashable.
+	SlashingPrevented(action string)
+	// StoreBreakerState is called when the circuit breaker around the rules store changes state.
+	StoreBreakerState(state string)
 }
 
 // RulerMonitor monitors the ruler service.
 type RulerMonitor interface {
+	// RulesCompleted is called when rules have been run for a request, with the result of each entry.
+	RulesCompleted(started time.Time, action string, results []rules.Result)
+}
+
+// AuditorMonitor monitors the auditor service.
+type AuditorMonitor interface {
 }
 
 // APIMonitor monitors the API service.
 type APIMonitor interface {
+	// RequestThrottled is called when a request from a client is rejected by the rate limiter.
+	RequestThrottled(client string)
 }
 
 // PeersMonitor monitors the dirk peers service.
@@ -73,6 +96,12 @@ type PeersMonitor interface {
 type ProcessMonitor interface {
 }
 
+// ConsensusMonitor monitors the slashing protection consensus service.
+type ConsensusMonitor interface {
+	// ConfirmCompleted is called when confirmation of a high-water mark with peers has completed.
+	ConfirmCompleted(kind string, result string)
+}
+
 // SenderMonitor monitors the sender service.
 type SenderMonitor interface {
 }
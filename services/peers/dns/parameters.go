@@ -0,0 +1,122 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// Resolver is the interface for resolving SRV records.
+// It is satisfied by net.Resolver.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service string, proto string, name string) (string, []*net.SRV, error)
+}
+
+type parameters struct {
+	logLevel        zerolog.Level
+	monitor         metrics.PeersMonitor
+	name            string
+	refreshInterval time.Duration
+	idOffset        uint64
+	resolver        Resolver
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for this module.
+func WithMonitor(monitor metrics.PeersMonitor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithName sets the DNS name of the SRV records for the peers, for example _dirk._tcp.example.com.
+func WithName(name string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.name = name
+	})
+}
+
+// WithRefreshInterval sets the interval at which the SRV records are resolved again.
+func WithRefreshInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.refreshInterval = interval
+	})
+}
+
+// WithIDOffset sets the offset added to the number at the end of each peer's host name to obtain its ID.
+func WithIDOffset(offset uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.idOffset = offset
+	})
+}
+
+// WithResolver sets the resolver for SRV records.
+func WithResolver(resolver Resolver) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.resolver = resolver
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:        zerolog.GlobalLevel(),
+		refreshInterval: time.Minute,
+		resolver:        net.DefaultResolver,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.monitor == nil {
+		// Use no-op monitor.
+		parameters.monitor = &noopMonitor{}
+	}
+	if parameters.name == "" {
+		return nil, errors.New("no name specified")
+	}
+	if parameters.refreshInterval <= 0 {
+		return nil, errors.New("refresh interval must be positive")
+	}
+	if parameters.resolver == nil {
+		return nil, errors.New("no resolver specified")
+	}
+
+	return &parameters, nil
+}
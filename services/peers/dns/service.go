@@ -0,0 +1,209 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// ErrNotFound is returned when a peer is not found.
+var ErrNotFound = errors.New("not found")
+
+// Service provides a list of peers discovered from DNS SRV records.
+// The ID of each peer is the number at the end of the first label of its host name, plus the ID offset;
+// for example with an offset of 1 the peer dirk-0.dirk.example.com has ID 1.
+type Service struct {
+	name     string
+	idOffset uint64
+	resolver Resolver
+	mutex    sync.RWMutex
+	peers    map[uint64]*core.Endpoint
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new peers provider.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "peers").Str("impl", "dns").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	s := &Service{
+		name:     parameters.name,
+		idOffset: parameters.idOffset,
+		resolver: parameters.resolver,
+	}
+
+	peers, err := s.resolve(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve peers")
+	}
+	s.peers = peers
+	log.Trace().Int("peers", len(peers)).Msg("Resolved peers")
+
+	go s.refresh(ctx, parameters.refreshInterval)
+
+	return s, nil
+}
+
+// Peer returns the peer with the given ID.
+func (s *Service) Peer(id uint64) (*core.Endpoint, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	peer, exists := s.peers[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return &core.Endpoint{
+		ID:   peer.ID,
+		Name: peer.Name,
+		Port: peer.Port,
+	}, nil
+}
+
+// All returns all peers.
+func (s *Service) All() map[uint64]*core.Endpoint {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	res := make(map[uint64]*core.Endpoint, len(s.peers))
+	for id, peer := range s.peers {
+		res[id] = &core.Endpoint{
+			ID:   peer.ID,
+			Name: peer.Name,
+			Port: peer.Port,
+		}
+	}
+	return res
+}
+
+// Suitable returns peers that are suitable given the supplied requirements.
+// At current any peer that is present is considered suitable.
+func (s *Service) Suitable(threshold uint32) ([]*core.Endpoint, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	suitable := uint32(0)
+	res := make([]*core.Endpoint, threshold)
+	for _, peer := range s.peers {
+		res[suitable] = &core.Endpoint{
+			ID:   peer.ID,
+			Name: peer.Name,
+			Port: peer.Port,
+		}
+		suitable++
+		if suitable == threshold {
+			break
+		}
+	}
+	if suitable < threshold {
+		return nil, errors.New("not enough suitable peers")
+	}
+	return res, nil
+}
+
+// refresh resolves the peers at the given interval until the context is done.
+// If resolution fails the existing peers are retained.
+func (s *Service) refresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			peers, err := s.resolve(ctx)
+			if err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh peers; retaining existing peers")
+				continue
+			}
+			s.mutex.Lock()
+			changed := !reflect.DeepEqual(s.peers, peers)
+			s.peers = peers
+			s.mutex.Unlock()
+			if changed {
+				log.Info().Int("peers", len(peers)).Msg("Peers updated")
+			}
+		}
+	}
+}
+
+// resolve resolves the peers from the SRV records.
+func (s *Service) resolve(ctx context.Context) (map[uint64]*core.Endpoint, error) {
+	_, records, err := s.resolver.LookupSRV(ctx, "", "", s.name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up SRV records")
+	}
+
+	peers := make(map[uint64]*core.Endpoint, len(records))
+	for _, record := range records {
+		name := strings.TrimSuffix(record.Target, ".")
+		if record.Port == 0 {
+			log.Warn().Str("target", name).Msg("SRV record has no port; ignoring")
+			continue
+		}
+		id, err := s.peerID(name)
+		if err != nil {
+			log.Warn().Str("target", name).Err(err).Msg("Failed to obtain peer ID; ignoring")
+			continue
+		}
+		if existing, exists := peers[id]; exists {
+			return nil, fmt.Errorf("duplicate peer ID %d for %s and %s", id, existing.Name, name)
+		}
+		peers[id] = &core.Endpoint{
+			ID:   id,
+			Name: name,
+			Port: uint32(record.Port),
+		}
+	}
+	if len(peers) == 0 {
+		return nil, errors.New("no peers found")
+	}
+
+	return peers, nil
+}
+
+// peerID obtains the ID of a peer from the number at the end of the first label of its host name.
+func (s *Service) peerID(name string) (uint64, error) {
+	label := strings.SplitN(name, ".", 2)[0]
+	start := len(label)
+	for start > 0 && label[start-1] >= '0' && label[start-1] <= '9' {
+		start--
+	}
+	if start == len(label) {
+		return 0, errors.New("host name does not end with a number")
+	}
+	num, err := strconv.ParseUint(label[start:], 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid number in host name")
+	}
+	return num + s.idOffset, nil
+}
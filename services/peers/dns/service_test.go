@@ -0,0 +1,214 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dns_test
+
+import (
+	context "context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	dnspeers "github.com/attestantio/dirk/services/peers/dns"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	os.Exit(m.Run())
+}
+
+// resolver is a resolver that returns fixed SRV records.
+type resolver struct {
+	mutex   sync.Mutex
+	records []*net.SRV
+	err     error
+}
+
+func (r *resolver) LookupSRV(ctx context.Context, service string, proto string, name string) (string, []*net.SRV, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	return name, r.records, nil
+}
+
+func (r *resolver) set(records []*net.SRV, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.records = records
+	r.err = err
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		params   []dnspeers.Parameter
+		records  []*net.SRV
+		resolved error
+		peers    map[uint64]*core.Endpoint
+		err      string
+	}{
+		{
+			name: "NameMissing",
+			err:  "problem with parameters: no name specified",
+		},
+		{
+			name: "RefreshIntervalZero",
+			params: []dnspeers.Parameter{
+				dnspeers.WithName("_dirk._tcp.example.com"),
+				dnspeers.WithRefreshInterval(0),
+			},
+			err: "problem with parameters: refresh interval must be positive",
+		},
+		{
+			name: "ResolverNil",
+			params: []dnspeers.Parameter{
+				dnspeers.WithName("_dirk._tcp.example.com"),
+				dnspeers.WithResolver(nil),
+			},
+			err: "problem with parameters: no resolver specified",
+		},
+		{
+			name: "LookupFailed",
+			params: []dnspeers.Parameter{
+				dnspeers.WithName("_dirk._tcp.example.com"),
+			},
+			resolved: errors.New("no such host"),
+			err:      "failed to resolve peers: failed to look up SRV records: no such host",
+		},
+		{
+			name: "NoPeers",
+			params: []dnspeers.Parameter{
+				dnspeers.WithName("_dirk._tcp.example.com"),
+			},
+			records: []*net.SRV{
+				{Target: "dirk.example.com.", Port: 13141},
+				{Target: "dirk2.example.com.", Port: 0},
+			},
+			err: "failed to resolve peers: no peers found",
+		},
+		{
+			name: "DuplicateID",
+			params: []dnspeers.Parameter{
+				dnspeers.WithName("_dirk._tcp.example.com"),
+			},
+			records: []*net.SRV{
+				{Target: "dirk1.example.com.", Port: 13141},
+				{Target: "signer1.example.com.", Port: 13141},
+			},
+			err: "failed to resolve peers: duplicate peer ID 1 for dirk1.example.com and signer1.example.com",
+		},
+		{
+			name: "Good",
+			params: []dnspeers.Parameter{
+				dnspeers.WithName("_dirk._tcp.example.com"),
+			},
+			records: []*net.SRV{
+				{Target: "dirk1.example.com.", Port: 13141},
+				{Target: "dirk2.example.com.", Port: 13142},
+				{Target: "dirk.example.com.", Port: 13143},
+			},
+			peers: map[uint64]*core.Endpoint{
+				1: {ID: 1, Name: "dirk1.example.com", Port: 13141},
+				2: {ID: 2, Name: "dirk2.example.com", Port: 13142},
+			},
+		},
+		{
+			name: "Offset",
+			params: []dnspeers.Parameter{
+				dnspeers.WithName("_dirk._tcp.dirk.default.svc.cluster.local"),
+				dnspeers.WithIDOffset(1),
+			},
+			records: []*net.SRV{
+				{Target: "dirk-0.dirk.default.svc.cluster.local.", Port: 13141},
+				{Target: "dirk-1.dirk.default.svc.cluster.local.", Port: 13141},
+			},
+			peers: map[uint64]*core.Endpoint{
+				1: {ID: 1, Name: "dirk-0.dirk.default.svc.cluster.local", Port: 13141},
+				2: {ID: 2, Name: "dirk-1.dirk.default.svc.cluster.local", Port: 13141},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &resolver{records: test.records, err: test.resolved}
+			params := append([]dnspeers.Parameter{dnspeers.WithResolver(r)}, test.params...)
+			s, err := dnspeers.New(ctx, params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.peers, s.All())
+		})
+	}
+}
+
+func TestPeer(t *testing.T) {
+	ctx := context.Background()
+
+	r := &resolver{records: []*net.SRV{{Target: "dirk1.example.com.", Port: 13141}}}
+	s, err := dnspeers.New(ctx, dnspeers.WithName("_dirk._tcp.example.com"), dnspeers.WithResolver(r))
+	require.NoError(t, err)
+
+	peer, err := s.Peer(1)
+	require.NoError(t, err)
+	assert.Equal(t, &core.Endpoint{ID: 1, Name: "dirk1.example.com", Port: 13141}, peer)
+	_, err = s.Peer(2)
+	assert.Equal(t, dnspeers.ErrNotFound, err)
+
+	suitable, err := s.Suitable(1)
+	require.NoError(t, err)
+	assert.Len(t, suitable, 1)
+	_, err = s.Suitable(2)
+	assert.EqualError(t, err, "not enough suitable peers")
+}
+
+func TestRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := &resolver{records: []*net.SRV{{Target: "dirk1.example.com.", Port: 13141}}}
+	s, err := dnspeers.New(ctx,
+		dnspeers.WithName("_dirk._tcp.example.com"),
+		dnspeers.WithResolver(r),
+		dnspeers.WithRefreshInterval(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	// A peer moves and another is added.
+	r.set([]*net.SRV{
+		{Target: "dirk1.example.org.", Port: 13141},
+		{Target: "dirk2.example.org.", Port: 13141},
+	}, nil)
+	require.Eventually(t, func() bool { return len(s.All()) == 2 }, time.Second, 10*time.Millisecond)
+	peer, err := s.Peer(1)
+	require.NoError(t, err)
+	assert.Equal(t, "dirk1.example.org", peer.Name)
+
+	// Failure to resolve retains the existing peers.
+	r.set(nil, errors.New("timeout"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, s.All(), 2)
+}
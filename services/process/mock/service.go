@@ -65,7 +65,7 @@ func (s *Service) OnAbort(ctx context.Context, sender uint64, account string) er
 }
 
 // OnGenerate is called when an request to generate a new key is received.
-func (s *Service) OnGenerate(ctx context.Context, credentials *checker.Credentials, account string, passphrase []byte, threshold uint32, numParticipants uint32) ([]byte, []*core.Endpoint, error) {
+func (s *Service) OnGenerate(ctx context.Context, credentials *checker.Credentials, account string, passphrase []byte, threshold uint32, numParticipants uint32, path string) ([]byte, []*core.Endpoint, error) {
 	return nil, []*core.Endpoint{
 		{
 			ID:   1,
@@ -84,3 +84,28 @@ func (s *Service) OnGenerate(ctx context.Context, credentials *checker.Credentia
 func (s *Service) OnContribute(ctx context.Context, sender uint64, account string, secret bls.SecretKey, vVec []bls.PublicKey) (bls.SecretKey, []bls.PublicKey, error) {
 	return bls.SecretKey{}, nil, nil
 }
+
+// OnPrepareReshare is called when we receive a request from the given participant to prepare to reshare an account.
+func (s *Service) OnPrepareReshare(ctx context.Context, sender uint64, account string, passphrase []byte, threshold uint32, participants []*core.Endpoint, dealers []uint64, verificationVector []bls.PublicKey) error {
+	return nil
+}
+
+// OnReshare is called when a request to reshare an existing distributed account is received.
+func (s *Service) OnReshare(ctx context.Context, account string, passphrase []byte, threshold uint32, participants []uint64) ([]byte, []*core.Endpoint, error) {
+	return nil, nil, nil
+}
+
+// OnRefresh is called when a request to refresh the shares of an existing distributed account is received.
+func (s *Service) OnRefresh(ctx context.Context, account string, passphrase []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// OnRecover is called when a request to recover the private key of an existing distributed account is received.
+func (s *Service) OnRecover(ctx context.Context, credentials *checker.Credentials, account string, passphrase []byte) ([]byte, error) {
+	return nil, nil
+}
+
+// OnRecoverShare is called when we receive a request for our share of a distributed account for recovery.
+func (s *Service) OnRecoverShare(ctx context.Context, sender uint64, credentials *checker.Credentials, account string) (bls.SecretKey, error) {
+	return bls.SecretKey{}, nil
+}
@@ -36,8 +36,65 @@ type Service interface {
 	OnAbort(ctx context.Context, sender uint64, account string) error
 
 	// OnGenerate is called when an request to generate a new key is received.
-	OnGenerate(ctx context.Context, credentials *checker.Credentials, account string, passphrase []byte, threshold uint32, numParticipants uint32) ([]byte, []*core.Endpoint, error)
+	// If path is supplied the key is created at that derivation path.
+	OnGenerate(ctx context.Context, credentials *checker.Credentials, account string, passphrase []byte, threshold uint32, numParticipants uint32, path string) ([]byte, []*core.Endpoint, error)
 
 	// OnContribute is is called when we need to swap contributions with another participant.
 	OnContribute(ctx context.Context, sender uint64, account string, secret bls.SecretKey, vVec []bls.PublicKey) (bls.SecretKey, []bls.PublicKey, error)
+
+	// OnPrepareReshare is called when we receive a request from the given participant to prepare to reshare an existing
+	// distributed account.  The dealers are the existing participants that will contribute their shares, and the
+	// verification vector is that of the existing account.
+	OnPrepareReshare(ctx context.Context,
+		sender uint64,
+		account string,
+		passphrase []byte,
+		threshold uint32,
+		participants []*core.Endpoint,
+		dealers []uint64,
+		verificationVector []bls.PublicKey,
+	) error
+
+	// OnReshare is called when a request to reshare an existing distributed account to a new set of participants
+	// and threshold is received.  The group public key of the account is unchanged.
+	OnReshare(ctx context.Context, account string, passphrase []byte, threshold uint32, participants []uint64) ([]byte, []*core.Endpoint, error)
+
+	// OnRefresh is called when a request to refresh the shares of an existing distributed account is received.
+	// All shares are replaced with new shares for the same participants and threshold, so old shares can no longer be
+	// combined with new ones.  The group public key of the account is unchanged.
+	OnRefresh(ctx context.Context, account string, passphrase []byte) ([]byte, error)
+
+	// OnRecover is called when a request to recover the private key of an existing distributed account from a
+	// threshold of its participants is received.  The key is returned as an EIP-2335 keystore encrypted with the
+	// passphrase.
+	OnRecover(ctx context.Context, credentials *checker.Credentials, account string, passphrase []byte) ([]byte, error)
+
+	// OnRecoverShare is called when we receive a request from the given participant for our share of a distributed
+	// account, to recover its private key on behalf of the client with the given credentials.
+	OnRecoverShare(ctx context.Context, sender uint64, credentials *checker.Credentials, account string) (bls.SecretKey, error)
+}
+
+// PrepareReshareMethod is the full name of the peer RPC method for preparing to reshare a distributed account.
+const PrepareReshareMethod = "/dirk.reshare.v1.Reshare/PrepareReshare"
+
+// RecoverShareMethod is the full name of the peer RPC method for obtaining a share of a distributed account for recovery.
+const RecoverShareMethod = "/dirk.recover.v1.Recover/RecoverShare"
+
+// RecoverShareRequest is the JSON-encoded body of a request for a share of a distributed account for recovery.
+// The client and request ID are those of the request to recover the account, so that each participant can check
+// the client's permissions itself.
+type RecoverShareRequest struct {
+	Account   string `json:"account"`
+	Client    string `json:"client"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// PrepareReshareRequest is the JSON-encoded body of a request to prepare to reshare a distributed account.
+type PrepareReshareRequest struct {
+	Account            string           `json:"account"`
+	Passphrase         []byte           `json:"passphrase,omitempty"`
+	Threshold          uint32           `json:"threshold"`
+	Participants       []*core.Endpoint `json:"participants"`
+	Dealers            []uint64         `json:"dealers"`
+	VerificationVector [][]byte         `json:"verification_vector"`
 }
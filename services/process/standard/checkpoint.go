@@ -0,0 +1,227 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/herumi/bls-eth-go-binary/bls"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/go-ecodec"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// checkpoint is the persisted state of a generation.  It holds our contribution to the generation, which is
+// all that is required to resume: contributions from other participants are exchanged again when resuming,
+// so a participant that cannot resume makes a new contribution that replaces its old one everywhere.
+type checkpoint struct {
+	Account             string            `json:"account"`
+	Passphrase          []byte            `json:"passphrase"`
+	Threshold           uint32            `json:"threshold"`
+	Participants        []*core.Endpoint  `json:"participants"`
+	DistributionSecrets map[uint64][]byte `json:"distribution_secrets"`
+	DistributionVVec    [][]byte          `json:"distribution_vvec"`
+}
+
+// checkpointFile returns the path of the checkpoint file for the given account.
+func (s *Service) checkpointFile(account string) string {
+	return filepath.Join(s.checkpointPath, fmt.Sprintf("%x", sha256.Sum256([]byte(account))))
+}
+
+// saveCheckpoint persists our contribution to a generation, encrypted with the generation passphrase.
+// Reshares are not checkpointed.
+func (s *Service) saveCheckpoint(ctx context.Context, generation *generation) error {
+	if s.checkpointPath == "" || generation.dealers != nil {
+		return nil
+	}
+
+	cp := &checkpoint{
+		Account:             generation.account,
+		Passphrase:          generation.passphrase,
+		Threshold:           generation.threshold,
+		Participants:        generation.participants,
+		DistributionSecrets: make(map[uint64][]byte, len(generation.distributionSecrets)),
+		DistributionVVec:    make([][]byte, len(generation.distributionVVec)),
+	}
+	for id, secret := range generation.distributionSecrets {
+		cp.DistributionSecrets[id] = secret.Serialize()
+	}
+	for i := range generation.distributionVVec {
+		cp.DistributionVVec[i] = generation.distributionVVec[i].Serialize()
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode checkpoint")
+	}
+	data, err = ecodec.Encrypt(data, s.generationPassphrase)
+	if err != nil {
+		return errors.Wrap(err, "failed to encrypt checkpoint")
+	}
+
+	// The checkpoint is written to a temporary file and moved in to place when complete.
+	path := s.checkpointFile(generation.account)
+	tmpPath := fmt.Sprintf("%s.tmp", path)
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return errors.Wrap(err, "failed to write checkpoint")
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// loadCheckpoint loads the generation for the given account from its checkpoint.
+// Expired checkpoints are removed rather than loaded.
+func (s *Service) loadCheckpoint(ctx context.Context, account string) (*generation, error) {
+	if s.checkpointPath == "" {
+		return nil, ErrNotFound
+	}
+
+	path := s.checkpointFile(account)
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "failed to access checkpoint")
+	}
+	if time.Since(info.ModTime()) > s.checkpointLifetime {
+		log.Debug().Str("account", account).Msg("Checkpoint expired; removing")
+		s.deleteCheckpoint(ctx, account)
+		return nil, ErrNotFound
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read checkpoint")
+	}
+	data, err = ecodec.Decrypt(data, s.generationPassphrase)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt checkpoint")
+	}
+	cp := &checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, errors.Wrap(err, "failed to decode checkpoint")
+	}
+	if cp.Account != account {
+		return nil, errors.New("checkpoint is for a different account")
+	}
+
+	generation := &generation{
+		processStarted:      time.Now(),
+		id:                  s.id,
+		account:             cp.Account,
+		passphrase:          cp.Passphrase,
+		threshold:           cp.Threshold,
+		participants:        cp.Participants,
+		distributionSecrets: make(map[uint64]bls.SecretKey, len(cp.DistributionSecrets)),
+		distributionVVec:    make([]bls.PublicKey, len(cp.DistributionVVec)),
+		sharedSecrets:       make(map[uint64]bls.SecretKey),
+		sharedVVecs:         make(map[uint64][]bls.PublicKey),
+		resumed:             true,
+	}
+	for id, data := range cp.DistributionSecrets {
+		secret := bls.SecretKey{}
+		if err := secret.Deserialize(data); err != nil {
+			return nil, errors.Wrap(err, "invalid distribution secret in checkpoint")
+		}
+		generation.distributionSecrets[id] = secret
+	}
+	for i := range cp.DistributionVVec {
+		if err := generation.distributionVVec[i].Deserialize(cp.DistributionVVec[i]); err != nil {
+			return nil, errors.Wrap(err, "invalid verification vector in checkpoint")
+		}
+	}
+	secret, exists := generation.distributionSecrets[s.id]
+	if !exists {
+		return nil, errors.New("checkpoint does not contain our contribution")
+	}
+	if !verifyContribution(s.id, secret, generation.distributionVVec) {
+		return nil, errors.New("checkpoint contains invalid contribution")
+	}
+	generation.sharedSecrets[s.id] = secret
+	generation.sharedVVecs[s.id] = generation.distributionVVec
+
+	return generation, nil
+}
+
+// deleteCheckpoint removes the checkpoint for the given account, if present.
+func (s *Service) deleteCheckpoint(ctx context.Context, account string) {
+	if s.checkpointPath == "" {
+		return
+	}
+	if err := os.Remove(s.checkpointFile(account)); err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("account", account).Msg("Failed to remove checkpoint")
+	}
+}
+
+// pruneCheckpoints removes checkpoints that have not been updated within the checkpoint lifetime.
+func (s *Service) pruneCheckpoints(ctx context.Context) error {
+	files, err := ioutil.ReadDir(s.checkpointPath)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() || time.Since(file.ModTime()) <= s.checkpointLifetime {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.checkpointPath, file.Name())); err != nil {
+			log.Warn().Err(err).Str("file", file.Name()).Msg("Failed to remove expired checkpoint")
+			continue
+		}
+		log.Trace().Str("file", file.Name()).Msg("Removed expired checkpoint")
+	}
+	return nil
+}
+
+// resumable returns true if the generation can be resumed by a request to prepare with the given
+// threshold and participants.
+func (s *Service) resumable(generation *generation, threshold uint32, participants []*core.Endpoint) bool {
+	if s.checkpointPath == "" || generation.dealers != nil || generation.threshold != threshold {
+		return false
+	}
+	if len(generation.participants) != len(participants) {
+		return false
+	}
+	ids := make(map[uint64]bool, len(participants))
+	for _, participant := range generation.participants {
+		ids[participant.ID] = true
+	}
+	for _, participant := range participants {
+		if !ids[participant.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// alreadyCommitted returns true if a resumed generation was committed before it was interrupted, in which case
+// we hold the account with the share and composite public key that the generation produces.
+func (s *Service) alreadyCommitted(ctx context.Context, generation *generation, privateKey bls.SecretKey, aggregateVVec []bls.PublicKey) bool {
+	if !generation.resumed || generation.dealers != nil {
+		return false
+	}
+	_, account, err := s.distributedAccount(ctx, generation.account)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(account.PublicKey().Marshal(), privateKey.GetPublicKey().Serialize()) &&
+		bytes.Equal(account.(e2wtypes.DistributedAccount).CompositePublicKey().Marshal(), aggregateVVec[0].Serialize())
+}
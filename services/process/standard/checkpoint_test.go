@@ -0,0 +1,106 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/process"
+	standardprocess "github.com/attestantio/dirk/services/process/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointParameters(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "checkpoints")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = createProcessService(ctx, 1,
+		standardprocess.WithCheckpointPath(dir),
+		standardprocess.WithGenerationPassphrase(nil),
+	)
+	require.EqualError(t, err, "problem with parameters: checkpoints require a generation passphrase")
+
+	_, err = createProcessService(ctx, 1,
+		standardprocess.WithCheckpointPath(dir),
+		standardprocess.WithCheckpointLifetime(0),
+	)
+	require.EqualError(t, err, "problem with parameters: checkpoint lifetime must be positive")
+}
+
+func TestResumeFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "checkpoints")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	createService := func(id uint64) process.Service {
+		service, err := createProcessService(ctx, id, standardprocess.WithCheckpointPath(filepath.Join(dir, fmt.Sprintf("%d", id))))
+		require.NoError(t, err)
+		return service
+	}
+	services := map[uint64]process.Service{
+		1: createService(1),
+		2: createService(2),
+		3: createService(3),
+	}
+
+	endpoints := []*core.Endpoint{
+		{ID: 1, Name: "signer-test01", Port: 8881},
+		{ID: 2, Name: "signer-test02", Port: 8882},
+		{ID: 3, Name: "signer-test03", Port: 8883},
+	}
+	for _, endpoint := range endpoints {
+		require.NoError(t, services[endpoint.ID].OnPrepare(ctx, 1, "Test/Test", nil, 2, endpoints))
+	}
+	for _, endpoint := range endpoints {
+		require.NoError(t, services[endpoint.ID].OnExecute(ctx, 1, "Test/Test"))
+	}
+	// Only the first participant commits before the generation is interrupted.
+	pubKey, _, err := services[1].OnCommit(ctx, 1, "Test/Test", []byte("Confirmation data"))
+	require.NoError(t, err)
+
+	// Restart the second participant, losing its in-memory state.
+	services[2] = createService(2)
+
+	// Resume the generation.
+	for _, endpoint := range endpoints {
+		require.NoError(t, services[endpoint.ID].OnPrepare(ctx, 1, "Test/Test", nil, 2, endpoints))
+	}
+	for _, endpoint := range endpoints {
+		require.NoError(t, services[endpoint.ID].OnExecute(ctx, 1, "Test/Test"))
+	}
+	for _, endpoint := range endpoints {
+		participantPubKey, _, err := services[endpoint.ID].OnCommit(ctx, 1, "Test/Test", []byte("Confirmation data"))
+		require.NoError(t, err)
+		require.Equal(t, pubKey, participantPubKey)
+	}
+
+	// Aborting removes the checkpoints, so a subsequent generation starts afresh.
+	for _, endpoint := range endpoints {
+		require.EqualError(t, services[endpoint.ID].OnAbort(ctx, 1, "Test/Test"), standardprocess.ErrNotInProgress.Error())
+		files, err := ioutil.ReadDir(filepath.Join(dir, fmt.Sprintf("%d", endpoint.ID)))
+		require.NoError(t, err)
+		require.Len(t, files, 0)
+	}
+}
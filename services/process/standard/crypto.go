@@ -15,6 +15,7 @@ package standard
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/attestantio/dirk/util"
 	"github.com/herumi/bls-eth-go-binary/bls"
@@ -31,7 +32,12 @@ func (s *Service) contribution(ctx context.Context, generation *generation) erro
 	verificationKeys := make([]bls.PublicKey, threshold)
 	for i := uint32(0); i < threshold; i++ {
 		sks[i] = bls.SecretKey{}
-		sks[i].SetByCSPRNG()
+		if i == 0 && generation.secret != nil {
+			// We are resharing, so our existing share is the secret to distribute.
+			sks[i] = *generation.secret
+		} else {
+			sks[i].SetByCSPRNG()
+		}
 		verificationKeys[i] = *sks[i].GetPublicKey()
 	}
 
@@ -56,9 +62,13 @@ func (s *Service) contribution(ctx context.Context, generation *generation) erro
 		}
 	}
 
-	generation.sharedSecrets[generation.id] = secrets[generation.id]
-	generation.sharedVVecs[generation.id] = verificationKeys
+	if secret, exists := secrets[generation.id]; exists {
+		// Keep our own contribution (not present if we are a dealer that is leaving the account).
+		generation.sharedSecrets[generation.id] = secret
+		generation.sharedVVecs[generation.id] = verificationKeys
+	}
 	generation.distributionSecrets = secrets
+	generation.distributionVVec = verificationKeys
 
 	return nil
 }
@@ -107,6 +117,84 @@ func (s *Service) contribution(ctx context.Context, generation *generation) erro
 // 	return nil
 // }
 
+// generateKey combines the contributions of all participants to obtain our share of a newly generated key,
+// along with its verification vector.
+func generateKey(generation *generation) (bls.SecretKey, []bls.PublicKey, error) {
+	if len(generation.sharedSecrets) != len(generation.participants) {
+		contributedParticipants := make([]uint64, 0)
+		for k := range generation.sharedSecrets {
+			contributedParticipants = append(contributedParticipants, k)
+		}
+		allParticipants := make([]uint64, len(generation.participants))
+		for k := range generation.participants {
+			allParticipants[k] = generation.participants[k].ID
+		}
+
+		return bls.SecretKey{}, nil, fmt.Errorf("have %d contributions (%v) , need %d (%v), aborting", len(generation.sharedSecrets), contributedParticipants, len(allParticipants), allParticipants)
+	}
+	if len(generation.sharedVVecs) != len(generation.participants) {
+		return bls.SecretKey{}, nil, fmt.Errorf("have %d contributions, need %d, aborting", len(generation.sharedVVecs), len(generation.participants))
+	}
+
+	privateKey := bls.SecretKey{}
+	for k := range generation.sharedSecrets {
+		sharedSecret := generation.sharedSecrets[k]
+		privateKey.Add(&sharedSecret)
+	}
+	aggregateVVec := make([]bls.PublicKey, generation.threshold)
+	for _, sharedVVec := range generation.sharedVVecs {
+		for i := range sharedVVec {
+			aggregateVVec[i].Add(&sharedVVec[i])
+		}
+	}
+
+	return privateKey, aggregateVVec, nil
+}
+
+// reshareKey combines the contributions of the dealers to obtain our share of a reshared key, along with its
+// verification vector.  Each dealer's contribution is built on its share of the existing key, so interpolating
+// the contributions recovers a new sharing of the existing key rather than a new key.
+func reshareKey(generation *generation) (bls.SecretKey, []bls.PublicKey, error) {
+	if len(generation.sharedSecrets) != len(generation.dealers) {
+		return bls.SecretKey{}, nil, fmt.Errorf("have %d contributions, need %d, aborting", len(generation.sharedSecrets), len(generation.dealers))
+	}
+
+	ids := make([]bls.ID, len(generation.dealers))
+	secrets := make([]bls.SecretKey, len(generation.dealers))
+	for i, dealer := range generation.dealers {
+		secret, exists := generation.sharedSecrets[dealer]
+		if !exists {
+			return bls.SecretKey{}, nil, fmt.Errorf("no contribution from %d, aborting", dealer)
+		}
+		ids[i] = *util.BLSID(dealer)
+		secrets[i] = secret
+	}
+
+	privateKey := bls.SecretKey{}
+	if err := privateKey.Recover(secrets, ids); err != nil {
+		return bls.SecretKey{}, nil, errors.Wrap(err, "failed to recover reshared key")
+	}
+	aggregateVVec := make([]bls.PublicKey, generation.threshold)
+	keys := make([]bls.PublicKey, len(generation.dealers))
+	for i := range aggregateVVec {
+		for j, dealer := range generation.dealers {
+			keys[j] = generation.sharedVVecs[dealer][i]
+		}
+		if err := aggregateVVec[i].Recover(keys, ids); err != nil {
+			return bls.SecretKey{}, nil, errors.Wrap(err, "failed to recover reshared verification vector")
+		}
+	}
+
+	if !aggregateVVec[0].IsEqual(&generation.verificationVector[0]) {
+		return bls.SecretKey{}, nil, errors.New("reshared key does not match existing key")
+	}
+	if !verifyContribution(generation.id, privateKey, aggregateVVec) {
+		return bls.SecretKey{}, nil, errors.New("reshared key does not match verification vector")
+	}
+
+	return privateKey, aggregateVVec, nil
+}
+
 // verifyContribution verifies another participant's contribution.
 func verifyContribution(id uint64, secretShare bls.SecretKey, vVec []bls.PublicKey) bool {
 	var vVecKey bls.PublicKey
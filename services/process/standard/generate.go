@@ -18,16 +18,17 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"time"
 
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/ruler"
 	"github.com/attestantio/dirk/util"
 	"github.com/herumi/bls-eth-go-binary/bls"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	wallet "github.com/wealdtech/go-eth2-wallet"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"go.opentelemetry.io/otel"
 )
 
 // OnGenerate is called when an request to generate a new key is received.
@@ -37,6 +38,7 @@ func (s *Service) OnGenerate(ctx context.Context,
 	passphrase []byte,
 	signingThreshold uint32,
 	numParticipants uint32,
+	path string,
 ) ([]byte, []*core.Endpoint, error) {
 	// Check parameters.
 	if numParticipants == 0 {
@@ -51,6 +53,10 @@ func (s *Service) OnGenerate(ctx context.Context,
 		log.Warn().Uint32("participants", numParticipants).Uint32("signing_threshold", signingThreshold).Msg("Signing threshold too low")
 		return nil, nil, errors.New("signing threshold too low")
 	}
+	if path != "" && numParticipants != 1 {
+		log.Warn().Str("path", path).Msg("Path supplied for distributed account")
+		return nil, nil, errors.New("path not supported for distributed accounts")
+	}
 
 	log := log.With().Str("account", account).Logger()
 	// Ensure we don't already have this account.
@@ -84,7 +90,7 @@ func (s *Service) OnGenerate(ctx context.Context,
 
 	if numParticipants == 1 {
 		// Only 1 participant means we are generating a standard account.
-		pubKey, err := s.generate(ctx, credentials, wallet, accountName, passphrase)
+		pubKey, err := s.generate(ctx, credentials, wallet, accountName, passphrase, path)
 		if err != nil {
 			log.Error().Err(err).Msg("Failed to generate account")
 			return nil, nil, errors.New("failed account generation")
@@ -94,9 +100,9 @@ func (s *Service) OnGenerate(ctx context.Context,
 	return s.generateDistributed(ctx, credentials, wallet, account, passphrase, signingThreshold, numParticipants)
 }
 
-func (s *Service) generate(ctx context.Context, credentials *checker.Credentials, wallet e2wtypes.Wallet, accountName string, passphrase []byte) ([]byte, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.process.generate")
-	defer span.Finish()
+func (s *Service) generate(ctx context.Context, credentials *checker.Credentials, wallet e2wtypes.Wallet, accountName string, passphrase []byte, path string) ([]byte, error) {
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.generate")
+	defer span.End()
 
 	if wallet.Type() == "distributed" {
 		log.Error().Msg("Incorrect wallet type to create key")
@@ -119,6 +125,18 @@ func (s *Service) generate(ctx context.Context, credentials *checker.Credentials
 		}()
 	}
 
+	if path != "" {
+		creator, isCreator := wallet.(e2wtypes.WalletPathedAccountCreator)
+		if !isCreator {
+			return nil, errors.New("wallet does not support account creation with path")
+		}
+		createdAccount, err := creator.CreatePathedAccount(ctx, path, accountName, passphrase)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create account with path")
+		}
+		return createdAccount.PublicKey().Marshal(), nil
+	}
+
 	createdAccount, err := wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, accountName, passphrase)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create account")
@@ -126,9 +144,12 @@ func (s *Service) generate(ctx context.Context, credentials *checker.Credentials
 	return createdAccount.PublicKey().Marshal(), nil
 }
 
+// generateDistributed generates a distributed account, retrying with exponential backoff if an attempt fails.
+// If generations are checkpointed the participants resume the failed attempt, otherwise they abort it and
+// the generation restarts, which is only possible if the attempt failed before any participant committed.
 func (s *Service) generateDistributed(ctx context.Context, credentials *checker.Credentials, wallet e2wtypes.Wallet, account string, passphrase []byte, signingThreshold uint32, numParticipants uint32) ([]byte, []*core.Endpoint, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.process.generateDistributed")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.generateDistributed")
+	defer span.End()
 
 	if wallet.Type() != "distributed" {
 		log.Error().Msg("Incorrect wallet type to generate distributed key")
@@ -141,67 +162,138 @@ func (s *Service) generateDistributed(ctx context.Context, credentials *checker.
 		return nil, nil, errors.New("no suitable participants")
 	}
 
+	backoff := s.retryBackoff
+	for attempt := 1; ; attempt++ {
+		pubKey, retryable, err := s.attemptGenerateDistributed(ctx, account, passphrase, signingThreshold, participants)
+		if err == nil && s.checkpointPath != "" {
+			// The participants no longer need their checkpoints.
+			s.abortGeneration(ctx, account, participants)
+		}
+		if err == nil {
+			return pubKey, participants, nil
+		}
+		if !retryable || attempt >= s.maxAttempts {
+			if s.checkpointPath != "" {
+				s.abortGeneration(ctx, account, participants)
+			}
+			return nil, nil, err
+		}
+		log.Debug().Err(err).Str("account", account).Int("attempt", attempt).Dur("backoff", backoff).Msg("Distributed key generation failed; retrying")
+		select {
+		case <-ctx.Done():
+			return nil, nil, err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// attemptGenerateDistributed makes a single attempt to generate a distributed account.  If generations are not
+// checkpointed and the attempt fails all participants are asked to abort.  The returned flag is true if the
+// attempt can be retried.
+func (s *Service) attemptGenerateDistributed(ctx context.Context, account string, passphrase []byte, signingThreshold uint32, participants []*core.Endpoint) ([]byte, bool, error) {
+	// Participants that have checkpointed the generation resume it on the next attempt, including
+	// those that have already committed.
+	resumable := s.checkpointPath != ""
+
+	// Confirmation data is 32 random bytes.
+	confirmationData := make([]byte, 32)
+	n, err := rand.Read(confirmationData)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to generate commit data")
+	}
+	if n != 32 {
+		return nil, false, errors.New("failed to generate enough commit data")
+	}
+
 	// Send prepare request to all participants.
+	prepareCtx, cancel := context.WithTimeout(ctx, s.prepareTimeout)
 	for _, participant := range participants {
 		log.Trace().Str("endpoint", participant.String()).Msg("Sending prepare request to endpoint")
-		if err := s.senderSvc.Prepare(ctx, participant, account, passphrase, signingThreshold, participants); err != nil {
+		if err := s.senderSvc.Prepare(prepareCtx, participant, account, passphrase, signingThreshold, participants); err != nil {
+			cancel()
 			log.Error().Err(err).Str("endpoint", participant.String()).Msg("Failed to prepare on endpoint")
-			return nil, nil, errors.Wrap(err, "failed to prepare endpoints")
+			if !resumable {
+				s.abortGeneration(ctx, account, participants)
+			}
+			return nil, true, errors.Wrap(err, "failed to prepare endpoints")
 		}
 	}
+	cancel()
 
 	// Send execute request to all participants.
+	executeCtx, cancel := context.WithTimeout(ctx, s.executeTimeout)
 	for _, participant := range participants {
 		log.Trace().Str("endpoint", participant.String()).Msg("Sending execute request to endpoint")
-		if err := s.senderSvc.Execute(ctx, participant, account); err != nil {
+		if err := s.senderSvc.Execute(executeCtx, participant, account); err != nil {
+			cancel()
 			log.Error().Err(err).Str("endpoint", participant.String()).Msg("Failed to execute on endpoint")
-			return nil, nil, errors.Wrap(err, "failed to execute generation")
+			if !resumable {
+				s.abortGeneration(ctx, account, participants)
+			}
+			return nil, true, errors.Wrap(err, "failed to execute generation")
 		}
 	}
+	cancel()
 
 	// Send commit request to all endpoints.
+	// Participants that have committed hold the account from here on, so failures can only be retried if resumable.
 	pubKeys := make([][]byte, len(participants))
-	// Confirmation data is 32 random bytes.
-	confirmationData := make([]byte, 32)
-	n, err := rand.Read(confirmationData)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "failed to generate commit data")
-	}
-	if n != 32 {
-		return nil, nil, errors.New("failed to generate enough commit data")
-	}
 	confirmationSigs := make([][]byte, len(participants))
+	commitCtx, cancel := context.WithTimeout(ctx, s.commitTimeout)
+	defer cancel()
 	for i, participant := range participants {
 		log.Trace().Str("endpoint", participant.String()).Msg("Sending commit request to endpoint")
-		pubKeys[i], confirmationSigs[i], err = s.senderSvc.Commit(ctx, participant, account, confirmationData)
+		pubKeys[i], confirmationSigs[i], err = s.senderSvc.Commit(commitCtx, participant, account, confirmationData)
 		if err != nil {
 			log.Error().Err(err).Str("endpoint", participant.String()).Msg("Failed to commit on endpoint")
-			return nil, nil, errors.Wrap(err, "failed to complete generation")
+			if !resumable {
+				s.abortGeneration(ctx, account, participants[i:])
+			}
+			return nil, resumable, errors.Wrap(err, "failed to complete generation")
 		}
 		if len(pubKeys[i]) == 0 {
 			log.Error().Uint64("participant", participant.ID).Msg("Received empty public key from participant on commit")
-			return nil, nil, errors.New("failed to complete generation")
+			s.abortGeneration(ctx, account, participants[i+1:])
+			return nil, false, errors.New("failed to complete generation")
 		}
 		if len(confirmationSigs[i]) == 0 {
 			log.Error().Uint64("participant", participant.ID).Msg("Received empty confirmation signature from participant on commit")
-			return nil, nil, errors.New("failed to complete generation")
+			s.abortGeneration(ctx, account, participants[i+1:])
+			return nil, false, errors.New("failed to complete generation")
 		}
 	}
 
 	for i := range pubKeys {
 		if !bytes.Equal(pubKeys[i], pubKeys[(i+1)%len(pubKeys)]) {
 			log.Error().Msg("pubkey mismatch")
-			return nil, nil, errors.New("Invalid generation")
+			return nil, false, errors.New("Invalid generation")
 		}
 	}
 
 	// Check composite signatures.
+	if !verifyConfirmationSignatures(participants, signingThreshold, pubKeys[0], confirmationData, confirmationSigs) {
+		return nil, false, errors.New("Invalid generation")
+	}
+
+	log.Trace().Str("account", account).Str("pubKey", fmt.Sprintf("%x", pubKeys[0])).Msg("Generated account")
+	return pubKeys[0], false, nil
+}
+
+// verifyConfirmationSignatures verifies that the confirmation signatures from each threshold-sized window of
+// participants combine to a valid signature for the public key.
+func verifyConfirmationSignatures(participants []*core.Endpoint,
+	signingThreshold uint32,
+	pubKeyBytes []byte,
+	confirmationData []byte,
+	confirmationSigs [][]byte,
+) bool {
 	ids := make([]bls.ID, signingThreshold)
 	sigs := make([]bls.Sign, signingThreshold)
 	pubKey := bls.PublicKey{}
-	if err := pubKey.Deserialize(pubKeys[0]); err != nil {
+	if err := pubKey.Deserialize(pubKeyBytes); err != nil {
 		log.Error().Err(err).Msg("Failed to deserialize public key")
-		return nil, nil, errors.New("Invalid generation")
+		return false
 	}
 	compositeSig := bls.Sign{}
 	for i := 0; i < len(participants)+1-int(signingThreshold); i++ {
@@ -210,19 +302,17 @@ func (s *Service) generateDistributed(ctx context.Context, credentials *checker.
 			sigs[j] = bls.Sign{}
 			if err := sigs[j].Deserialize(confirmationSigs[i+j]); err != nil {
 				log.Error().Err(err).Msg("Failed to deserialize confirmation signature")
-				return nil, nil, errors.New("Invalid generation")
+				return false
 			}
 		}
 		if err := compositeSig.Recover(sigs, ids); err != nil {
 			log.Error().Err(err).Msg("Failed to recover composite signature")
-			return nil, nil, errors.New("Invalid generation")
+			return false
 		}
 		if !compositeSig.VerifyByte(&pubKey, confirmationData) {
-			log.Error().Err(err).Msg("Failed to confirm composite signature")
-			return nil, nil, errors.New("Invalid generation")
+			log.Error().Msg("Failed to confirm composite signature")
+			return false
 		}
 	}
-
-	log.Trace().Str("account", account).Str("pubKey", fmt.Sprintf("%x", pubKeys[0])).Msg("Generated account")
-	return pubKeys[0], participants, nil
+	return true
 }
@@ -0,0 +1,88 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/process"
+	standardprocess "github.com/attestantio/dirk/services/process/standard"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDistributedRetry(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		err         string
+	}{
+		{
+			name:        "MaxAttemptsZero",
+			maxAttempts: 0,
+			err:         "problem with parameters: max attempts must be at least 1",
+		},
+		{
+			name:        "NoRetry",
+			maxAttempts: 1,
+			err:         "failed to prepare endpoints: in progress",
+		},
+		{
+			name:        "Retry",
+			maxAttempts: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			services := make(map[uint64]process.Service)
+			for id := uint64(1); id <= 5; id++ {
+				service, err := createProcessService(ctx, id,
+					standardprocess.WithMaxAttempts(test.maxAttempts),
+					standardprocess.WithRetryBackoff(10*time.Millisecond),
+				)
+				if err != nil {
+					require.EqualError(t, err, test.err)
+					return
+				}
+				services[id] = service
+			}
+
+			// Leave a generation in progress on one participant, so that the first attempt fails.
+			endpoints := []*core.Endpoint{
+				{ID: 3, Name: "signer-test03", Port: 8883},
+				{ID: 4, Name: "signer-test04", Port: 8884},
+			}
+			require.NoError(t, services[3].OnPrepare(ctx, 4, "Test/Test", nil, 2, endpoints))
+
+			pubKey, participants, err := services[1].OnGenerate(ctx, &checker.Credentials{Client: "client1"}, "Test/Test", nil, 3, 5, "")
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				// All participants should have been cleaned up.
+				for id, service := range services {
+					require.EqualError(t, service.OnAbort(ctx, 1, "Test/Test"), standardprocess.ErrNotInProgress.Error(), "participant %d", id)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, pubKey, 48)
+			require.Len(t, participants, 5)
+		})
+	}
+}
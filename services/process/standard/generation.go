@@ -19,8 +19,8 @@ import (
 
 	"github.com/attestantio/dirk/core"
 	"github.com/herumi/bls-eth-go-binary/bls"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
 )
 
 // ErrNotFound is returned when a generation is not found.
@@ -40,6 +40,8 @@ type generation struct {
 	// Metadata.
 	processStarted time.Time
 	id             uint64
+	// resumed is true if the generation has been resumed, in which case contributions are exchanged again.
+	resumed bool
 
 	// Information about the key to generate.
 	account      string
@@ -47,8 +49,15 @@ type generation struct {
 	threshold    uint32
 	participants []*core.Endpoint
 
-	// Secrets to distribute to each participant.
+	// Information about the existing key, when resharing.
+	// Dealers are the existing participants that contribute their shares; the secret is our share if we are a dealer.
+	dealers            []uint64
+	verificationVector []bls.PublicKey
+	secret             *bls.SecretKey
+
+	// Secrets to distribute to each participant, and their verification vector.
 	distributionSecrets map[uint64]bls.SecretKey
+	distributionVVec    []bls.PublicKey
 
 	// Information from each participant (including us).
 	sharedSecrets map[uint64]bls.SecretKey
@@ -58,16 +67,16 @@ type generation struct {
 // getGeneration fetches an active generation.
 // This assumes that a write lock is already held on generationsMu.
 func (s *Service) getGeneration(ctx context.Context, account string) (*generation, error) {
-	span, _ := opentracing.StartSpanFromContext(ctx, "services.process.getGeneration")
-	defer span.Finish()
+	_, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.getGeneration")
+	defer span.End()
 
 	generator, exists := s.generations[account]
 	if !exists {
 		return nil, ErrNotFound
 	}
 
-	// Generations more than 10 seconds old need to be removed.
-	if time.Since(generator.processStarted) > 10*time.Second {
+	// Generations that have outlived all of their phases need to be removed.
+	if time.Since(generator.processStarted) > s.prepareTimeout+s.executeTimeout+s.commitTimeout {
 		// Been too long; remove it.
 		log.Debug().Str("account", account).Msg("Generation been active too long; invalidating")
 		delete(s.generations, account)
@@ -76,3 +85,13 @@ func (s *Service) getGeneration(ctx context.Context, account string) (*generatio
 
 	return generator, nil
 }
+
+// abortGeneration makes a best-effort attempt to abort the generation of the given account on the given endpoints,
+// so that they do not hold partial state that would block a subsequent attempt.
+func (s *Service) abortGeneration(ctx context.Context, account string, endpoints []*core.Endpoint) {
+	for _, endpoint := range endpoints {
+		if err := s.senderSvc.Abort(ctx, endpoint, account); err != nil {
+			log.Debug().Err(err).Str("endpoint", endpoint.String()).Msg("Failed to abort generation on endpoint")
+		}
+	}
+}
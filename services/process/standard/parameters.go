@@ -14,9 +14,13 @@
 package standard
 
 import (
+	"time"
+
 	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/fetcher"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/attestantio/dirk/services/peers"
+	"github.com/attestantio/dirk/services/ruler"
 	"github.com/attestantio/dirk/services/sender"
 	"github.com/attestantio/dirk/services/unlocker"
 	"github.com/pkg/errors"
@@ -29,13 +33,23 @@ type parameters struct {
 	logLevel             zerolog.Level
 	monitor              metrics.ProcessMonitor
 	checker              checker.Service
+	ruler                ruler.Service
 	sender               sender.Service
 	unlocker             unlocker.Service
 	encryptor            e2wtypes.Encryptor
+	fetcher              fetcher.Service
 	id                   uint64
 	peers                peers.Service
 	stores               []e2wtypes.Store
 	generationPassphrase []byte
+	refreshInterval      time.Duration
+	prepareTimeout       time.Duration
+	executeTimeout       time.Duration
+	commitTimeout        time.Duration
+	maxAttempts          int
+	retryBackoff         time.Duration
+	checkpointPath       string
+	checkpointLifetime   time.Duration
 }
 
 // Parameter is the interface for service parameters.
@@ -70,6 +84,14 @@ func WithChecker(checker checker.Service) Parameter {
 	})
 }
 
+// WithRuler sets the ruler for this module.
+// If not supplied distributed accounts cannot be recovered, as the rules cannot approve the release of our shares.
+func WithRuler(ruler ruler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.ruler = ruler
+	})
+}
+
 // WithSender sets the sender for this module.
 func WithSender(sender sender.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -91,6 +113,14 @@ func WithEncryptor(encryptor e2wtypes.Encryptor) Parameter {
 	})
 }
 
+// WithFetcher sets the fetcher for this module.
+// If supplied, the fetcher's cached copy of an account is invalidated when the account is reshared.
+func WithFetcher(fetcher fetcher.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.fetcher = fetcher
+	})
+}
+
 // WithPeers sets the peers for this module.
 func WithPeers(peers peers.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -119,11 +149,78 @@ func WithGenerationPassphrase(generationPassphrase []byte) Parameter {
 	})
 }
 
+// WithRefreshInterval sets the interval at which the shares of distributed accounts are refreshed.
+// If this is 0 shares are only refreshed on request.
+func WithRefreshInterval(refreshInterval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.refreshInterval = refreshInterval
+	})
+}
+
+// WithPrepareTimeout sets the time allowed for all participants to prepare for a distributed key generation.
+func WithPrepareTimeout(prepareTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.prepareTimeout = prepareTimeout
+	})
+}
+
+// WithExecuteTimeout sets the time allowed for all participants to exchange their contributions to a
+// distributed key generation.
+func WithExecuteTimeout(executeTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.executeTimeout = executeTimeout
+	})
+}
+
+// WithCommitTimeout sets the time allowed for all participants to commit a distributed key generation.
+func WithCommitTimeout(commitTimeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.commitTimeout = commitTimeout
+	})
+}
+
+// WithMaxAttempts sets the maximum number of attempts for a distributed key generation that fails
+// before any participant has committed the key.
+func WithMaxAttempts(maxAttempts int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxAttempts = maxAttempts
+	})
+}
+
+// WithRetryBackoff sets the initial delay before retrying a failed distributed key generation.
+// The delay doubles with each subsequent attempt.
+func WithRetryBackoff(retryBackoff time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.retryBackoff = retryBackoff
+	})
+}
+
+// WithCheckpointPath sets the directory in which the state of distributed key generations is checkpointed,
+// allowing interrupted generations to be resumed.  If not supplied generations are not checkpointed.
+func WithCheckpointPath(checkpointPath string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checkpointPath = checkpointPath
+	})
+}
+
+// WithCheckpointLifetime sets the time for which an unused checkpoint is retained.
+func WithCheckpointLifetime(checkpointLifetime time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checkpointLifetime = checkpointLifetime
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:  zerolog.GlobalLevel(),
-		encryptor: keystorev4.New(),
+		logLevel:           zerolog.GlobalLevel(),
+		encryptor:          keystorev4.New(),
+		prepareTimeout:     2 * time.Second,
+		executeTimeout:     5 * time.Second,
+		commitTimeout:      3 * time.Second,
+		maxAttempts:        1,
+		retryBackoff:       time.Second,
+		checkpointLifetime: time.Hour,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -156,6 +253,32 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.stores == nil {
 		return nil, errors.New("no stores specified")
 	}
+	if parameters.refreshInterval < 0 {
+		return nil, errors.New("refresh interval cannot be negative")
+	}
+	if parameters.prepareTimeout <= 0 {
+		return nil, errors.New("prepare timeout must be positive")
+	}
+	if parameters.executeTimeout <= 0 {
+		return nil, errors.New("execute timeout must be positive")
+	}
+	if parameters.commitTimeout <= 0 {
+		return nil, errors.New("commit timeout must be positive")
+	}
+	if parameters.maxAttempts < 1 {
+		return nil, errors.New("max attempts must be at least 1")
+	}
+	if parameters.retryBackoff < 0 {
+		return nil, errors.New("retry backoff cannot be negative")
+	}
+	if parameters.checkpointPath != "" {
+		if len(parameters.generationPassphrase) == 0 {
+			return nil, errors.New("checkpoints require a generation passphrase")
+		}
+		if parameters.checkpointLifetime <= 0 {
+			return nil, errors.New("checkpoint lifetime must be positive")
+		}
+	}
 
 	return &parameters, nil
 }
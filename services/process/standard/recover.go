@@ -0,0 +1,218 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/util"
+	"github.com/google/uuid"
+	"github.com/herumi/bls-eth-go-binary/bls"
+	"github.com/pkg/errors"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// recoveredKeystore is the EIP-2335 keystore of a recovered private key.
+type recoveredKeystore struct {
+	Crypto  map[string]interface{} `json:"crypto"`
+	PubKey  string                 `json:"pubkey"`
+	UUID    string                 `json:"uuid"`
+	Version uint                   `json:"version"`
+}
+
+// OnRecover is called when a request to recover the private key of an existing distributed account from a
+// threshold of its participants is received.  Each participant, including us, releases its share only if the
+// client has the 'Recover account' permission for the account and the rules approve; the shares are combined
+// here and the key is returned as an EIP-2335 keystore encrypted with the passphrase.
+func (s *Service) OnRecover(ctx context.Context,
+	credentials *checker.Credentials,
+	account string,
+	passphrase []byte,
+) ([]byte, error) {
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnRecover")
+	defer span.End()
+
+	log := log.With().Str("account", account).Logger()
+	if credentials == nil {
+		log.Error().Msg("No credentials supplied")
+		return nil, errors.New("no credentials supplied")
+	}
+	log = log.With().Str("request_id", credentials.RequestID).Str("client", credentials.Client).Logger()
+	if len(passphrase) == 0 {
+		log.Warn().Msg("No passphrase supplied")
+		return nil, errors.New("no passphrase supplied")
+	}
+
+	_, existingAccount, err := s.distributedAccount(ctx, account)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain account")
+		return nil, errors.Wrap(err, "unknown account")
+	}
+	verificationVector, err := accountVerificationVector(existingAccount)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain verification vector")
+		return nil, err
+	}
+	distributedAccount := existingAccount.(e2wtypes.DistributedAccount)
+	threshold := distributedAccount.SigningThreshold()
+
+	// Our own share is required, so that recovery cannot be carried out by a coordinator that does not
+	// itself approve it.
+	share, err := s.releaseShare(ctx, credentials, account, s.id)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain our share")
+		return nil, errors.Wrap(err, "failed to obtain own share")
+	}
+	ids := []bls.ID{*util.BLSID(s.id)}
+	shares := []bls.SecretKey{*share}
+
+	// Obtain the remaining shares from the other participants, in order, until we have enough.
+	for _, id := range participantIDs(distributedAccount) {
+		if uint32(len(shares)) == threshold {
+			break
+		}
+		if id == s.id {
+			continue
+		}
+		peer, err := s.peersSvc.Peer(id)
+		if err != nil {
+			log.Warn().Uint64("participant", id).Err(err).Msg("Failed to obtain participant")
+			continue
+		}
+		log.Trace().Str("endpoint", peer.String()).Msg("Requesting share from endpoint")
+		share, err := s.senderSvc.RecoverShare(ctx, peer, credentials, account)
+		if err != nil {
+			log.Warn().Uint64("participant", id).Err(err).Msg("Failed to obtain share from participant")
+			continue
+		}
+		if !verifyContribution(id, share, verificationVector) {
+			log.Warn().Uint64("participant", id).Msg("Received invalid share from participant")
+			continue
+		}
+		ids = append(ids, *util.BLSID(id))
+		shares = append(shares, share)
+	}
+	if uint32(len(shares)) < threshold {
+		log.Warn().Int("shares", len(shares)).Uint32("threshold", threshold).Msg("Insufficient shares to recover account")
+		return nil, fmt.Errorf("obtained %d shares, need %d", len(shares), threshold)
+	}
+
+	var privateKey bls.SecretKey
+	if err := privateKey.Recover(shares, ids); err != nil {
+		return nil, errors.Wrap(err, "failed to recover private key")
+	}
+	pubKey := privateKey.GetPublicKey().Serialize()
+	if !bytes.Equal(pubKey, distributedAccount.CompositePublicKey().Marshal()) {
+		log.Error().Msg("Recovered private key does not match composite public key")
+		return nil, errors.New("recovered key does not match account")
+	}
+
+	crypto, err := keystorev4.New().Encrypt(privateKey.Serialize(), string(passphrase))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encrypt private key")
+	}
+	data, err := json.Marshal(&recoveredKeystore{
+		Crypto:  crypto,
+		PubKey:  fmt.Sprintf("%x", pubKey),
+		UUID:    uuid.New().String(),
+		Version: 4,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal keystore")
+	}
+
+	log.Info().Msg("Recovered distributed account")
+	return data, nil
+}
+
+// OnRecoverShare is called when we receive a request from the given participant for our share of a distributed
+// account, to recover its private key on behalf of the client with the given credentials.
+func (s *Service) OnRecoverShare(ctx context.Context,
+	sender uint64,
+	credentials *checker.Credentials,
+	account string,
+) (bls.SecretKey, error) {
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnRecoverShare")
+	defer span.End()
+
+	if credentials == nil {
+		return bls.SecretKey{}, errors.New("no credentials supplied")
+	}
+	log := log.With().Uint64("sender_id", sender).Str("account", account).Str("request_id", credentials.RequestID).Str("client", credentials.Client).Logger()
+
+	share, err := s.releaseShare(ctx, credentials, account, sender)
+	if err != nil {
+		log.Warn().Err(err).Msg("Refused to release share")
+		return bls.SecretKey{}, err
+	}
+
+	log.Info().Msg("Released share for recovery")
+	return *share, nil
+}
+
+// releaseShare obtains our share of a distributed account for recovery by the given coordinator, if the client is
+// permitted to recover the account and the rules approve.
+func (s *Service) releaseShare(ctx context.Context,
+	credentials *checker.Credentials,
+	account string,
+	coordinator uint64,
+) (*bls.SecretKey, error) {
+	if s.rulerSvc == nil {
+		return nil, errors.New("recovery not available")
+	}
+	if s.checkAccess(ctx, credentials, account, ruler.ActionRecoverAccount) != core.ResultSucceeded {
+		return nil, errors.New("not permitted to recover account")
+	}
+
+	wallet, existingAccount, err := s.distributedAccount(ctx, account)
+	if err != nil {
+		return nil, errors.Wrap(err, "unknown account")
+	}
+	if _, exists := existingAccount.(e2wtypes.DistributedAccount).Participants()[coordinator]; !exists {
+		return nil, fmt.Errorf("coordinator %d is not a participant", coordinator)
+	}
+
+	rulesData := []*ruler.RulesData{
+		{
+			WalletName:  wallet.Name(),
+			AccountName: existingAccount.Name(),
+			PubKey:      existingAccount.PublicKey().Marshal(),
+			Data: &rules.RecoverAccountData{
+				Coordinator: coordinator,
+			},
+		},
+	}
+	results := s.rulerSvc.RunRules(ctx, credentials, ruler.ActionRecoverAccount, rulesData)
+	switch results[0] {
+	case rules.DENIED:
+		return nil, errors.New("denied by rules")
+	case rules.FAILED:
+		return nil, errors.New("rules check failed")
+	}
+
+	verificationVector, err := accountVerificationVector(existingAccount)
+	if err != nil {
+		return nil, err
+	}
+	return s.existingShare(ctx, account, verificationVector)
+}
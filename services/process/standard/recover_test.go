@@ -0,0 +1,173 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"encoding/json"
+	"testing"
+
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/process"
+	standardprocess "github.com/attestantio/dirk/services/process/standard"
+	goruler "github.com/attestantio/dirk/services/ruler/golang"
+	"github.com/herumi/bls-eth-go-binary/bls"
+	"github.com/stretchr/testify/require"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+// createRecoveryServices is a helper to create process services that can recover distributed accounts.
+// Participants in refusers do not permit the client to recover accounts.
+func createRecoveryServices(ctx context.Context, t *testing.T, num uint64, refusers []uint64) map[uint64]process.Service {
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	ruler, err := goruler.New(ctx,
+		goruler.WithLocker(locker),
+		goruler.WithRules(mockrules.New()),
+	)
+	require.NoError(t, err)
+
+	services := make(map[uint64]process.Service)
+	for id := uint64(1); id <= num; id++ {
+		operations := []string{"Recover account"}
+		for _, refuser := range refusers {
+			if refuser == id {
+				operations = []string{"All"}
+			}
+		}
+		checkerSvc, err := staticchecker.New(ctx,
+			staticchecker.WithPermissions(map[string][]*checker.Permissions{
+				"recoverer": {{Path: ".*", Operations: operations}},
+			}),
+		)
+		require.NoError(t, err)
+		service, err := createProcessService(ctx, id,
+			standardprocess.WithChecker(checkerSvc),
+			standardprocess.WithRuler(ruler),
+		)
+		require.NoError(t, err)
+		services[id] = service
+	}
+	return services
+}
+
+func TestOnRecover(t *testing.T) {
+	ctx := context.Background()
+	credentials := &checker.Credentials{Client: "recoverer"}
+
+	tests := []struct {
+		name        string
+		noRuler     bool
+		refusers    []uint64
+		credentials *checker.Credentials
+		account     string
+		passphrase  []byte
+		err         string
+	}{
+		{
+			name:       "NoCredentials",
+			account:    "Test/Test",
+			passphrase: []byte("recovery"),
+			err:        "no credentials supplied",
+		},
+		{
+			name:        "NoPassphrase",
+			credentials: credentials,
+			account:     "Test/Test",
+			err:         "no passphrase supplied",
+		},
+		{
+			name:        "UnknownAccount",
+			credentials: credentials,
+			account:     "Test/Unknown",
+			passphrase:  []byte("recovery"),
+			err:         "unknown account: failed to obtain account: no account with name \"Unknown\"",
+		},
+		{
+			name:        "NoRuler",
+			noRuler:     true,
+			credentials: credentials,
+			account:     "Test/Test",
+			passphrase:  []byte("recovery"),
+			err:         "failed to obtain own share: recovery not available",
+		},
+		{
+			name:        "NotPermitted",
+			credentials: &checker.Credentials{Client: "other"},
+			account:     "Test/Test",
+			passphrase:  []byte("recovery"),
+			err:         "failed to obtain own share: not permitted to recover account",
+		},
+		{
+			name:        "CoordinatorRefuses",
+			refusers:    []uint64{1},
+			credentials: credentials,
+			account:     "Test/Test",
+			passphrase:  []byte("recovery"),
+			err:         "failed to obtain own share: not permitted to recover account",
+		},
+		{
+			name:        "InsufficientShares",
+			refusers:    []uint64{2, 3},
+			credentials: credentials,
+			account:     "Test/Test",
+			passphrase:  []byte("recovery"),
+			err:         "obtained 1 shares, need 2",
+		},
+		{
+			name:        "ParticipantRefuses",
+			refusers:    []uint64{2},
+			credentials: credentials,
+			account:     "Test/Test",
+			passphrase:  []byte("recovery"),
+		},
+		{
+			name:        "Good",
+			credentials: credentials,
+			account:     "Test/Test",
+			passphrase:  []byte("recovery"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var services map[uint64]process.Service
+			if test.noRuler {
+				services = createProcessServices(ctx, t, 3)
+			} else {
+				services = createRecoveryServices(ctx, t, 3, test.refusers)
+			}
+			pubKey := generateDistributedAccount(ctx, t, services, "Test/Test")
+
+			data, err := services[1].OnRecover(ctx, test.credentials, test.account, test.passphrase)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+
+			// The keystore holds the full private key of the account.
+			keystore := make(map[string]interface{})
+			require.NoError(t, json.Unmarshal(data, &keystore))
+			secret, err := keystorev4.New().Decrypt(keystore["crypto"].(map[string]interface{}), string(test.passphrase))
+			require.NoError(t, err)
+			var privateKey bls.SecretKey
+			require.NoError(t, privateKey.Deserialize(secret))
+			require.Equal(t, pubKey, privateKey.GetPublicKey().Serialize())
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// OnRefresh is called when a request to refresh the shares of an existing distributed account is received.
+// Refreshing reshares the account to its existing participants and threshold; every participant obtains a new share
+// from a newly generated polynomial, so a threshold of shares from before and after the refresh cannot be combined.
+func (s *Service) OnRefresh(ctx context.Context, account string, passphrase []byte) ([]byte, error) {
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnRefresh")
+	defer span.End()
+
+	_, existingAccount, err := s.distributedAccount(ctx, account)
+	if err != nil {
+		log.Warn().Str("account", account).Err(err).Msg("Failed to obtain account")
+		return nil, errors.Wrap(err, "unknown account")
+	}
+	distributedAccount := existingAccount.(e2wtypes.DistributedAccount)
+
+	pubKey, _, err := s.OnReshare(ctx, account, passphrase, distributedAccount.SigningThreshold(), participantIDs(distributedAccount))
+	if err != nil {
+		return nil, err
+	}
+
+	return pubKey, nil
+}
+
+// scheduleRefresh refreshes the shares of distributed accounts at the configured interval until the context is cancelled.
+func (s *Service) scheduleRefresh(ctx context.Context) {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshAll(ctx)
+		}
+	}
+}
+
+// refreshAll refreshes the shares of all distributed accounts that we coordinate.
+// To avoid participants attempting to refresh the same account at the same time, each account is refreshed only by
+// its participant with the lowest ID.
+func (s *Service) refreshAll(ctx context.Context) {
+	refreshed := 0
+	for wallet := range e2wallet.Wallets(e2wallet.WithStore(s.stores[0]), e2wallet.WithEncryptor(s.encryptor)) {
+		if wallet.Type() != "distributed" {
+			continue
+		}
+		for account := range wallet.Accounts(ctx) {
+			distributedAccount, isDistributed := account.(e2wtypes.DistributedAccount)
+			if !isDistributed {
+				continue
+			}
+			if participantIDs(distributedAccount)[0] != s.id {
+				continue
+			}
+			path := fmt.Sprintf("%s/%s", wallet.Name(), account.Name())
+			if _, err := s.OnRefresh(ctx, path, nil); err != nil {
+				log.Error().Str("account", path).Err(err).Msg("Failed to refresh shares")
+				continue
+			}
+			refreshed++
+		}
+	}
+	log.Info().Int("accounts", refreshed).Msg("Refreshed shares of distributed accounts")
+}
+
+// participantIDs returns the IDs of the participants of a distributed account, in increasing order.
+func participantIDs(account e2wtypes.DistributedAccount) []uint64 {
+	ids := make([]uint64, 0, len(account.Participants()))
+	for id := range account.Participants() {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i int, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
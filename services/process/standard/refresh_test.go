@@ -0,0 +1,40 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnRefresh(t *testing.T) {
+	ctx := context.Background()
+
+	services := createProcessServices(ctx, t, 5)
+	pubKey := generateDistributedAccount(ctx, t, services, "Test/Test")
+
+	_, err := services[1].OnRefresh(ctx, "Test/Unknown", nil)
+	require.EqualError(t, err, "unknown account: failed to obtain account: no account with name \"Unknown\"")
+
+	refreshed, err := services[1].OnRefresh(ctx, "Test/Test", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.Equal(t, pubKey, refreshed)
+
+	// Refresh again from another participant, using the refreshed shares.
+	refreshed, err = services[3].OnRefresh(ctx, "Test/Test", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.Equal(t, pubKey, refreshed)
+}
@@ -0,0 +1,502 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/util"
+	"github.com/herumi/bls-eth-go-binary/bls"
+	"github.com/pkg/errors"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	distributed "github.com/wealdtech/go-eth2-wallet-distributed"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"go.opentelemetry.io/otel"
+)
+
+// OnReshare is called when a request to reshare an existing distributed account to a new set of participants
+// and threshold is received.  The group public key of the account is unchanged.
+func (s *Service) OnReshare(ctx context.Context,
+	account string,
+	passphrase []byte,
+	signingThreshold uint32,
+	participantIDs []uint64,
+) ([]byte, []*core.Endpoint, error) {
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnReshare")
+	defer span.End()
+
+	log := log.With().Str("account", account).Logger()
+
+	// Check parameters.
+	numParticipants := uint32(len(participantIDs))
+	if numParticipants < 2 {
+		log.Warn().Uint32("participants", numParticipants).Msg("Too few participants")
+		return nil, nil, errors.New("too few participants")
+	}
+	if signingThreshold > numParticipants {
+		log.Warn().Uint32("participants", numParticipants).Uint32("signing_threshold", signingThreshold).Msg("Signing threshold too high")
+		return nil, nil, errors.New("signing threshold too high")
+	}
+	if signingThreshold <= numParticipants/2 {
+		log.Warn().Uint32("participants", numParticipants).Uint32("signing_threshold", signingThreshold).Msg("Signing threshold too low")
+		return nil, nil, errors.New("signing threshold too low")
+	}
+
+	_, existingAccount, err := s.distributedAccount(ctx, account)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain account")
+		return nil, nil, errors.Wrap(err, "unknown account")
+	}
+	verificationVector, err := accountVerificationVector(existingAccount)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain verification vector")
+		return nil, nil, err
+	}
+	existingDistributedAccount := existingAccount.(e2wtypes.DistributedAccount)
+	pubKey := existingDistributedAccount.CompositePublicKey().Marshal()
+
+	participants, err := s.endpoints(participantIDs)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain participants")
+		return nil, nil, err
+	}
+	dealers, err := selectDealers(existingDistributedAccount.Participants(), existingDistributedAccount.SigningThreshold(), participantIDs)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to select dealers")
+		return nil, nil, err
+	}
+
+	// Recipients of the request are the new participants, plus any dealers that are leaving the account.
+	recipients := make([]*core.Endpoint, len(participants))
+	copy(recipients, participants)
+	departingDealers := make([]*core.Endpoint, 0)
+	for _, dealer := range dealers {
+		if isParticipant(participants, dealer) {
+			continue
+		}
+		peer, err := s.peersSvc.Peer(dealer)
+		if err != nil {
+			log.Warn().Uint64("dealer", dealer).Err(err).Msg("Failed to obtain dealer")
+			return nil, nil, errors.Wrap(err, "failed to obtain dealer")
+		}
+		recipients = append(recipients, peer)
+		departingDealers = append(departingDealers, peer)
+	}
+
+	// Send prepare request to all recipients.
+	for _, recipient := range recipients {
+		log.Trace().Str("endpoint", recipient.String()).Msg("Sending prepare reshare request to endpoint")
+		if err := s.senderSvc.PrepareReshare(ctx, recipient, account, passphrase, signingThreshold, participants, dealers, verificationVector); err != nil {
+			log.Error().Err(err).Str("endpoint", recipient.String()).Msg("Failed to prepare reshare on endpoint")
+			s.abortGeneration(ctx, account, recipients)
+			return nil, nil, errors.Wrap(err, "failed to prepare endpoints")
+		}
+	}
+
+	// Send execute request to all dealers.
+	for _, recipient := range recipients {
+		if !isDealer(dealers, recipient.ID) {
+			continue
+		}
+		log.Trace().Str("endpoint", recipient.String()).Msg("Sending execute request to endpoint")
+		if err := s.senderSvc.Execute(ctx, recipient, account); err != nil {
+			log.Error().Err(err).Str("endpoint", recipient.String()).Msg("Failed to execute on endpoint")
+			s.abortGeneration(ctx, account, recipients)
+			return nil, nil, errors.Wrap(err, "failed to execute reshare")
+		}
+	}
+
+	// Confirmation data is 32 random bytes.
+	confirmationData := make([]byte, 32)
+	n, err := rand.Read(confirmationData)
+	if err != nil {
+		s.abortGeneration(ctx, account, recipients)
+		return nil, nil, errors.Wrap(err, "failed to generate commit data")
+	}
+	if n != 32 {
+		s.abortGeneration(ctx, account, recipients)
+		return nil, nil, errors.New("failed to generate enough commit data")
+	}
+
+	// Send commit request to all participants.
+	confirmationSigs := make([][]byte, len(participants))
+	for i, participant := range participants {
+		log.Trace().Str("endpoint", participant.String()).Msg("Sending commit request to endpoint")
+		var participantPubKey []byte
+		participantPubKey, confirmationSigs[i], err = s.senderSvc.Commit(ctx, participant, account, confirmationData)
+		if err != nil {
+			log.Error().Err(err).Str("endpoint", participant.String()).Msg("Failed to commit on endpoint")
+			return nil, nil, errors.Wrap(err, "failed to complete reshare")
+		}
+		if !bytes.Equal(participantPubKey, pubKey) {
+			log.Error().Uint64("participant", participant.ID).Msg("Received incorrect public key from participant on commit")
+			return nil, nil, errors.New("failed to complete reshare")
+		}
+		if len(confirmationSigs[i]) == 0 {
+			log.Error().Uint64("participant", participant.ID).Msg("Received empty confirmation signature from participant on commit")
+			return nil, nil, errors.New("failed to complete reshare")
+		}
+	}
+
+	// Dealers that are leaving the account have nothing to commit, so tidy up.
+	s.abortGeneration(ctx, account, departingDealers)
+
+	if !verifyConfirmationSignatures(participants, signingThreshold, pubKey, confirmationData, confirmationSigs) {
+		return nil, nil, errors.New("Invalid reshare")
+	}
+
+	log.Trace().Str("pubKey", fmt.Sprintf("%x", pubKey)).Msg("Reshared account")
+	return pubKey, participants, nil
+}
+
+// OnPrepareReshare is called when we receive a request from the given participant to prepare to reshare an existing
+// distributed account.
+func (s *Service) OnPrepareReshare(ctx context.Context,
+	sender uint64,
+	account string,
+	passphrase []byte,
+	threshold uint32,
+	participants []*core.Endpoint,
+	dealers []uint64,
+	verificationVector []bls.PublicKey,
+) error {
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnPrepareReshare")
+	defer span.End()
+	log.Trace().Uint64("sender_id", sender).Str("account", account).Msg("Preparing for distributed key resharing")
+
+	if len(dealers) == 0 {
+		return errors.New("no dealers")
+	}
+	if len(verificationVector) == 0 {
+		return errors.New("no verification vector")
+	}
+	if uint32(len(participants)) < threshold {
+		return errors.New("signing threshold too high")
+	}
+
+	s.generationsMu.Lock()
+	defer s.generationsMu.Unlock()
+
+	if _, err := s.getGeneration(ctx, account); err == nil {
+		log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Already in progress")
+		return ErrInProgress
+	}
+
+	generation := &generation{
+		processStarted:     time.Now(),
+		id:                 s.id,
+		account:            account,
+		passphrase:         passphrase,
+		threshold:          threshold,
+		participants:       participants,
+		dealers:            dealers,
+		verificationVector: verificationVector,
+		sharedSecrets:      make(map[uint64]bls.SecretKey),
+		sharedVVecs:        make(map[uint64][]bls.PublicKey),
+	}
+
+	if isDealer(dealers, s.id) {
+		secret, err := s.existingShare(ctx, account, verificationVector)
+		if err != nil {
+			log.Debug().Uint64("sender_id", sender).Str("account", account).Err(err).Msg("Failed to obtain our existing share")
+			return errors.Wrap(err, "failed to obtain existing share")
+		}
+		generation.secret = secret
+		if err := s.contribution(ctx, generation); err != nil {
+			log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Failed to generate our own contribution")
+			return errors.Wrap(err, "failed to generate own contribution")
+		}
+	}
+
+	s.generations[account] = generation
+
+	return nil
+}
+
+// executeReshare sends our contribution to each of the other new participants when resharing.
+// Contributions only pass from dealers to participants, so nothing is obtained in return.
+func (s *Service) executeReshare(ctx context.Context, generation *generation) error {
+	if generation.secret == nil {
+		// We are not a dealer, so have nothing to send.
+		return nil
+	}
+
+	for _, participant := range generation.participants {
+		if participant.ID == s.id {
+			continue
+		}
+		log.Trace().Uint64("id", s.id).Uint64("peer", participant.ID).Msg("Sending reshare contribution")
+		if _, _, err := s.senderSvc.SendContribution(ctx, participant, generation.account, generation.distributionSecrets[participant.ID], generation.distributionVVec); err != nil {
+			return errors.Wrap(err, "failed to send contribution")
+		}
+	}
+
+	return nil
+}
+
+// receiveReshareContribution receives a dealer's contribution when resharing.
+// This assumes that a write lock is already held on generationsMu.
+func receiveReshareContribution(generation *generation, sender uint64, secret bls.SecretKey, vVec []bls.PublicKey) (bls.SecretKey, []bls.PublicKey, error) {
+	if !isDealer(generation.dealers, sender) {
+		return bls.SecretKey{}, nil, fmt.Errorf("contribution from non-dealer %d", sender)
+	}
+	if !isParticipant(generation.participants, generation.id) {
+		return bls.SecretKey{}, nil, errors.New("not a participant")
+	}
+	if _, exists := generation.sharedSecrets[sender]; exists {
+		return bls.SecretKey{}, nil, fmt.Errorf("duplicate contribution from %d", sender)
+	}
+	if uint32(len(vVec)) != generation.threshold || !verifyContribution(generation.id, secret, vVec) {
+		log.Warn().Uint64("sender", sender).Str("account", generation.account).Msg("Received invalid contribution")
+		return bls.SecretKey{}, nil, fmt.Errorf("invalid contribution from %d", sender)
+	}
+
+	// The contribution must be built on the dealer's share of the existing key.
+	var dealerPubKey bls.PublicKey
+	if err := dealerPubKey.Set(generation.verificationVector, util.BLSID(sender)); err != nil {
+		return bls.SecretKey{}, nil, errors.Wrap(err, "failed to obtain dealer public key")
+	}
+	if !vVec[0].IsEqual(&dealerPubKey) {
+		log.Warn().Uint64("sender", sender).Str("account", generation.account).Msg("Received contribution not built on existing share")
+		return bls.SecretKey{}, nil, fmt.Errorf("invalid contribution from %d", sender)
+	}
+
+	generation.sharedSecrets[sender] = secret
+	generation.sharedVVecs[sender] = vVec
+
+	return bls.SecretKey{}, nil, nil
+}
+
+// distributedAccount obtains the existing distributed account with the given name, along with its wallet.
+func (s *Service) distributedAccount(ctx context.Context, account string) (e2wtypes.Wallet, e2wtypes.Account, error) {
+	walletName, accountName, err := e2wallet.WalletAndAccountNames(account)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid account")
+	}
+	wallet, err := distributed.OpenWallet(ctx, walletName, s.stores[0], s.encryptor)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to open wallet")
+	}
+	existingAccount, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, accountName)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to obtain account")
+	}
+	if _, isDistributed := existingAccount.(e2wtypes.DistributedAccount); !isDistributed {
+		return nil, nil, errors.New("account is not distributed")
+	}
+
+	return wallet, existingAccount, nil
+}
+
+// existingShare obtains our share of the existing key, confirming that it matches the verification vector.
+func (s *Service) existingShare(ctx context.Context, account string, verificationVector []bls.PublicKey) (*bls.SecretKey, error) {
+	wallet, existingAccount, err := s.distributedAccount(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	existingVerificationVector, err := accountVerificationVector(existingAccount)
+	if err != nil {
+		return nil, err
+	}
+	if len(existingVerificationVector) != len(verificationVector) {
+		return nil, errors.New("verification vector mismatch")
+	}
+	for i := range verificationVector {
+		if !existingVerificationVector[i].IsEqual(&verificationVector[i]) {
+			return nil, errors.New("verification vector mismatch")
+		}
+	}
+
+	locker, isLocker := existingAccount.(e2wtypes.AccountLocker)
+	if !isLocker {
+		return nil, errors.New("account does not support unlocking")
+	}
+	unlocked, err := s.unlockerSvc.UnlockAccount(ctx, wallet, existingAccount)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unlock account")
+	}
+	if !unlocked {
+		return nil, errors.New("failed to unlock account with known passphrases")
+	}
+	defer func() {
+		if err := locker.Lock(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to lock account")
+		}
+	}()
+
+	privateKeyProvider, isProvider := existingAccount.(e2wtypes.AccountPrivateKeyProvider)
+	if !isProvider {
+		return nil, errors.New("account does not provide its private key")
+	}
+	privateKey, err := privateKeyProvider.PrivateKey(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain private key")
+	}
+	secret := &bls.SecretKey{}
+	if err := secret.Deserialize(privateKey.Marshal()); err != nil {
+		return nil, errors.Wrap(err, "invalid private key")
+	}
+
+	// Confirm that the share is ours.
+	var pubKey bls.PublicKey
+	if err := pubKey.Set(verificationVector, util.BLSID(s.id)); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain public key")
+	}
+	if !secret.GetPublicKey().IsEqual(&pubKey) {
+		return nil, errors.New("share does not match verification vector")
+	}
+
+	return secret, nil
+}
+
+// replaceDistributedAccount replaces an existing distributed account with a reshared version.
+// The replacement is created in a scratch wallet so that it is encoded exactly as the wallet would encode it, and
+// is then written over the existing account with the existing account's ID, so that the wallet's index still holds.
+func (s *Service) replaceDistributedAccount(ctx context.Context,
+	wallet e2wtypes.Wallet,
+	existingAccount e2wtypes.Account,
+	privateKey []byte,
+	threshold uint32,
+	verificationVector [][]byte,
+	participants map[uint64]string,
+	passphrase []byte,
+) error {
+	scratchStore := scratch.New()
+	scratchWallet, err := distributed.CreateWallet(ctx, wallet.Name(), scratchStore, s.encryptor)
+	if err != nil {
+		return errors.Wrap(err, "failed to create scratch wallet")
+	}
+	if err := scratchWallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte{}); err != nil {
+		return errors.Wrap(err, "failed to unlock scratch wallet")
+	}
+	account, err := scratchWallet.(e2wtypes.WalletDistributedAccountImporter).ImportDistributedAccount(ctx, existingAccount.Name(), privateKey, threshold, verificationVector, participants, passphrase)
+	if err != nil {
+		return errors.Wrap(err, "failed to create reshared account")
+	}
+	data, err := scratchStore.RetrieveAccount(scratchWallet.ID(), account.ID())
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain reshared account")
+	}
+
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return errors.Wrap(err, "failed to decode reshared account")
+	}
+	fields["uuid"] = existingAccount.ID().String()
+	data, err = json.Marshal(fields)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode reshared account")
+	}
+
+	if err := s.stores[0].StoreAccount(wallet.ID(), existingAccount.ID(), data); err != nil {
+		return errors.Wrap(err, "failed to store reshared account")
+	}
+
+	return nil
+}
+
+// endpoints returns the endpoints for the given participant IDs, ordered by ID.
+func (s *Service) endpoints(ids []uint64) ([]*core.Endpoint, error) {
+	endpoints := make([]*core.Endpoint, 0, len(ids))
+	for _, id := range ids {
+		if isParticipant(endpoints, id) {
+			return nil, fmt.Errorf("duplicate participant %d", id)
+		}
+		peer, err := s.peersSvc.Peer(id)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("unknown participant %d", id))
+		}
+		endpoints = append(endpoints, peer)
+	}
+	sort.Slice(endpoints, func(i int, j int) bool {
+		return endpoints[i].ID < endpoints[j].ID
+	})
+
+	return endpoints, nil
+}
+
+// selectDealers selects the existing participants that will deal their shares when resharing.
+// Existing participants that remain in the new set of participants are preferred, so that a participant
+// that is being replaced (for example because it has failed) is not required unless necessary.
+func selectDealers(existingParticipants map[uint64]string, threshold uint32, participants []uint64) ([]uint64, error) {
+	if uint32(len(existingParticipants)) < threshold {
+		return nil, errors.New("not enough existing participants")
+	}
+
+	retained := make(map[uint64]bool, len(participants))
+	for _, participant := range participants {
+		retained[participant] = true
+	}
+	candidates := make([]uint64, 0, len(existingParticipants))
+	for id := range existingParticipants {
+		candidates = append(candidates, id)
+	}
+	sort.Slice(candidates, func(i int, j int) bool {
+		if retained[candidates[i]] != retained[candidates[j]] {
+			return retained[candidates[i]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	dealers := candidates[:threshold]
+	sort.Slice(dealers, func(i int, j int) bool {
+		return dealers[i] < dealers[j]
+	})
+
+	return dealers, nil
+}
+
+// accountVerificationVector returns the verification vector for an account.
+func accountVerificationVector(account e2wtypes.Account) ([]bls.PublicKey, error) {
+	provider, isProvider := account.(e2wtypes.AccountVerificationVectorProvider)
+	if !isProvider {
+		return nil, errors.New("account does not provide its verification vector")
+	}
+	verificationVector := make([]bls.PublicKey, len(provider.VerificationVector()))
+	for i, key := range provider.VerificationVector() {
+		if err := verificationVector[i].Deserialize(key.Marshal()); err != nil {
+			return nil, errors.Wrap(err, "invalid verification vector")
+		}
+	}
+
+	return verificationVector, nil
+}
+
+// isDealer returns true if the given ID is one of the dealers.
+func isDealer(dealers []uint64, id uint64) bool {
+	for _, dealer := range dealers {
+		if dealer == id {
+			return true
+		}
+	}
+	return false
+}
+
+// isParticipant returns true if the given ID is one of the participants.
+func isParticipant(participants []*core.Endpoint, id uint64) bool {
+	for _, participant := range participants {
+		if participant.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/process"
+	"github.com/attestantio/dirk/testing/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// generateDistributedAccount is a helper to generate a 2-of-3 distributed account across the given services.
+func generateDistributedAccount(ctx context.Context, t *testing.T, services map[uint64]process.Service, account string) []byte {
+	endpoints := []*core.Endpoint{
+		{ID: 1, Name: "signer-test01", Port: 8881},
+		{ID: 2, Name: "signer-test02", Port: 8882},
+		{ID: 3, Name: "signer-test03", Port: 8883},
+	}
+	for _, endpoint := range endpoints {
+		require.NoError(t, services[endpoint.ID].OnPrepare(ctx, endpoint.ID, account, []byte("Test account 1 passphrase"), 2, endpoints))
+	}
+	for _, endpoint := range endpoints {
+		require.NoError(t, services[endpoint.ID].OnExecute(ctx, endpoint.ID, account))
+	}
+	var pubKey []byte
+	for _, endpoint := range endpoints {
+		participantPubKey, _, err := services[endpoint.ID].OnCommit(ctx, endpoint.ID, account, []byte("Confirmation data"))
+		require.NoError(t, err)
+		pubKey = participantPubKey
+	}
+	return pubKey
+}
+
+// createProcessServices is a helper to create a number of process services.
+func createProcessServices(ctx context.Context, t *testing.T, num uint64) map[uint64]process.Service {
+	services := make(map[uint64]process.Service)
+	for id := uint64(1); id <= num; id++ {
+		service, err := createProcessService(ctx, id)
+		require.NoError(t, err)
+		services[id] = service
+	}
+	return services
+}
+
+func TestOnReshare(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		account      string
+		threshold    uint32
+		participants []uint64
+		failed       []uint64
+		err          string
+	}{
+		{
+			name:         "TooFewParticipants",
+			account:      "Test/Test",
+			threshold:    1,
+			participants: []uint64{1},
+			err:          "too few participants",
+		},
+		{
+			name:         "ThresholdTooHigh",
+			account:      "Test/Test",
+			threshold:    4,
+			participants: []uint64{1, 2, 3},
+			err:          "signing threshold too high",
+		},
+		{
+			name:         "ThresholdTooLow",
+			account:      "Test/Test",
+			threshold:    2,
+			participants: []uint64{1, 2, 3, 4},
+			err:          "signing threshold too low",
+		},
+		{
+			name:         "UnknownAccount",
+			account:      "Test/Unknown",
+			threshold:    2,
+			participants: []uint64{1, 2, 3},
+			err:          "unknown account: failed to obtain account: no account with name \"Unknown\"",
+		},
+		{
+			name:         "UnknownParticipant",
+			account:      "Test/Test",
+			threshold:    2,
+			participants: []uint64{1, 2, 9},
+			err:          "unknown participant 9: not found",
+		},
+		{
+			name:         "DuplicateParticipant",
+			account:      "Test/Test",
+			threshold:    2,
+			participants: []uint64{1, 2, 2},
+			err:          "duplicate participant 2",
+		},
+		{
+			name:         "FailedDealer",
+			account:      "Test/Test",
+			threshold:    2,
+			participants: []uint64{1, 4, 5},
+			failed:       []uint64{2},
+			err:          "failed to prepare endpoints: unknown mock process 2",
+		},
+		{
+			name:         "IncreaseThreshold",
+			account:      "Test/Test",
+			threshold:    3,
+			participants: []uint64{1, 2, 3, 4},
+		},
+		{
+			name:         "ReplaceFailedParticipant",
+			account:      "Test/Test",
+			threshold:    2,
+			participants: []uint64{1, 2, 4},
+			failed:       []uint64{3},
+		},
+		{
+			name:         "NewParticipants",
+			account:      "Test/Test",
+			threshold:    3,
+			participants: []uint64{2, 3, 4, 5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			services := createProcessServices(ctx, t, 5)
+			pubKey := generateDistributedAccount(ctx, t, services, "Test/Test")
+			for _, id := range test.failed {
+				delete(mock.Processes, id)
+			}
+
+			reshared, participants, err := services[1].OnReshare(ctx, test.account, nil, test.threshold, test.participants)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, pubKey, reshared)
+			require.Len(t, participants, len(test.participants))
+		})
+	}
+}
+
+func TestOnReshareRepeated(t *testing.T) {
+	ctx := context.Background()
+
+	services := createProcessServices(ctx, t, 5)
+	pubKey := generateDistributedAccount(ctx, t, services, "Test/Test")
+
+	// Reshare from 2-of-3 to 3-of-4.  The passphrase must be known to the unlocker for the shares to be reshared again.
+	reshared, _, err := services[1].OnReshare(ctx, "Test/Test", []byte("Test account 1 passphrase"), 3, []uint64{1, 2, 3, 4})
+	require.NoError(t, err)
+	require.Equal(t, pubKey, reshared)
+
+	// Reshare again without the original coordinator, which requires the replaced and newly created shares.
+	reshared, _, err = services[4].OnReshare(ctx, "Test/Test", nil, 2, []uint64{2, 3, 4})
+	require.NoError(t, err)
+	require.Equal(t, pubKey, reshared)
+}
+
+func TestOnPrepareReshareMissingInformation(t *testing.T) {
+	ctx := context.Background()
+
+	services := createProcessServices(ctx, t, 5)
+	generateDistributedAccount(ctx, t, services, "Test/Test")
+
+	endpoints := []*core.Endpoint{
+		{ID: 1, Name: "signer-test01", Port: 8881},
+		{ID: 4, Name: "signer-test04", Port: 8884},
+		{ID: 5, Name: "signer-test05", Port: 8885},
+	}
+
+	require.EqualError(t, services[4].OnPrepareReshare(ctx, 1, "Test/Test", nil, 2, endpoints, nil, nil), "no dealers")
+	require.EqualError(t, services[4].OnPrepareReshare(ctx, 1, "Test/Test", nil, 2, endpoints, []uint64{1, 2}, nil), "no verification vector")
+}
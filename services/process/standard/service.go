@@ -16,34 +16,47 @@ package standard
 import (
 	"context"
 	"fmt"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/fetcher"
 	"github.com/attestantio/dirk/services/peers"
+	"github.com/attestantio/dirk/services/ruler"
 	"github.com/attestantio/dirk/services/sender"
 	"github.com/attestantio/dirk/services/unlocker"
 	"github.com/herumi/bls-eth-go-binary/bls"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	e2wallet "github.com/wealdtech/go-eth2-wallet"
 	distributed "github.com/wealdtech/go-eth2-wallet-distributed"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"go.opentelemetry.io/otel"
 )
 
 // Service is used to manage the process of distributed key generation operations.
 type Service struct {
 	checkerSvc           checker.Service
+	rulerSvc             ruler.Service
 	senderSvc            sender.Service
 	peersSvc             peers.Service
 	unlockerSvc          unlocker.Service
+	fetcherSvc           fetcher.Service
 	encryptor            e2wtypes.Encryptor
 	id                   uint64
 	stores               []e2wtypes.Store
 	generationPassphrase []byte
+	refreshInterval      time.Duration
+	prepareTimeout       time.Duration
+	executeTimeout       time.Duration
+	commitTimeout        time.Duration
+	maxAttempts          int
+	retryBackoff         time.Duration
+	checkpointPath       string
+	checkpointLifetime   time.Duration
 
 	generations   map[string]*generation
 	generationsMu sync.RWMutex
@@ -67,16 +80,39 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	s := &Service{
 		checkerSvc:           parameters.checker,
+		rulerSvc:             parameters.ruler,
 		unlockerSvc:          parameters.unlocker,
 		senderSvc:            parameters.sender,
 		peersSvc:             parameters.peers,
+		fetcherSvc:           parameters.fetcher,
 		id:                   parameters.id,
 		stores:               parameters.stores,
 		encryptor:            parameters.encryptor,
 		generationPassphrase: parameters.generationPassphrase,
+		refreshInterval:      parameters.refreshInterval,
+		prepareTimeout:       parameters.prepareTimeout,
+		executeTimeout:       parameters.executeTimeout,
+		commitTimeout:        parameters.commitTimeout,
+		maxAttempts:          parameters.maxAttempts,
+		retryBackoff:         parameters.retryBackoff,
+		checkpointPath:       parameters.checkpointPath,
+		checkpointLifetime:   parameters.checkpointLifetime,
 		generations:          make(map[string]*generation),
 	}
 
+	if s.checkpointPath != "" {
+		if err := os.MkdirAll(s.checkpointPath, 0700); err != nil {
+			return nil, errors.Wrap(err, "failed to create checkpoint directory")
+		}
+		if err := s.pruneCheckpoints(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to prune checkpoints")
+		}
+	}
+
+	if s.refreshInterval > 0 {
+		go s.scheduleRefresh(ctx)
+	}
+
 	return s, nil
 }
 
@@ -87,16 +123,36 @@ func (s *Service) OnPrepare(ctx context.Context,
 	passphrase []byte,
 	threshold uint32,
 	participants []*core.Endpoint) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.process.OnPrepare")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnPrepare")
+	defer span.End()
 	log.Trace().Uint64("sender_id", sender).Str("account", account).Msg("Preparing for distributed key generation")
 
 	s.generationsMu.Lock()
 	defer s.generationsMu.Unlock()
 
-	if _, err := s.getGeneration(ctx, account); err == nil {
-		log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Already in progress")
-		return ErrInProgress
+	if existing, err := s.getGeneration(ctx, account); err == nil {
+		if !s.resumable(existing, threshold, participants) {
+			log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Already in progress")
+			return ErrInProgress
+		}
+		log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Resuming generation in progress")
+		existing.processStarted = time.Now()
+		existing.passphrase = passphrase
+		existing.resumed = true
+		return nil
+	}
+
+	existing, err := s.loadCheckpoint(ctx, account)
+	switch {
+	case err == nil && s.resumable(existing, threshold, participants):
+		log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Resuming generation from checkpoint")
+		existing.passphrase = passphrase
+		s.generations[account] = existing
+		return nil
+	case err == nil:
+		log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Checkpoint does not match generation; ignoring")
+	case err != ErrNotFound:
+		log.Warn().Err(err).Uint64("sender_id", sender).Str("account", account).Msg("Failed to load checkpoint; ignoring")
 	}
 
 	s.generations[account] = &generation{
@@ -114,14 +170,18 @@ func (s *Service) OnPrepare(ctx context.Context,
 		log.Debug().Uint64("sender_id", sender).Str("account", account).Msg("Failed to generate our own contribution")
 		return errors.Wrap(err, "failed to generate own contribution")
 	}
+	if err := s.saveCheckpoint(ctx, s.generations[account]); err != nil {
+		// The generation can continue, but cannot be resumed if interrupted.
+		log.Warn().Err(err).Uint64("sender_id", sender).Str("account", account).Msg("Failed to checkpoint generation")
+	}
 
 	return nil
 }
 
 // OnExecute is called when we receive a request from the given participant to execute the given DKG.
 func (s *Service) OnExecute(ctx context.Context, sender uint64, account string) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.process.OnExecute")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnExecute")
+	defer span.End()
 	log.Trace().Uint64("sender", sender).Str("account", account).Msg("Executing")
 
 	s.generationsMu.Lock()
@@ -135,6 +195,10 @@ func (s *Service) OnExecute(ctx context.Context, sender uint64, account string)
 		return err
 	}
 
+	if generation.dealers != nil {
+		return s.executeReshare(ctx, generation)
+	}
+
 	// We need to swap secrets with all the other participants.
 	// Initiate calls to any participants with a higher ID than us.
 	verificationVector := generation.sharedVVecs[generation.id]
@@ -153,7 +217,7 @@ func (s *Service) OnExecute(ctx context.Context, sender uint64, account string)
 				log.Warn().Msg("Contribution invalid")
 				return fmt.Errorf("invalid contribution from %d", id)
 			}
-			if _, exists := generation.sharedSecrets[id]; exists {
+			if _, exists := generation.sharedSecrets[id]; exists && !generation.resumed {
 				return fmt.Errorf("duplicate contribution from %d", id)
 			}
 			generation.sharedSecrets[id] = recipientSecret
@@ -165,8 +229,8 @@ func (s *Service) OnExecute(ctx context.Context, sender uint64, account string)
 
 // OnCommit is called when we receive a request from the given participant to commit the given DKG.
 func (s *Service) OnCommit(ctx context.Context, sender uint64, account string, confirmationData []byte) ([]byte, []byte, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.process.OnCommit")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnCommit")
+	defer span.End()
 
 	s.generationsMu.Lock()
 	defer s.generationsMu.Unlock()
@@ -176,31 +240,17 @@ func (s *Service) OnCommit(ctx context.Context, sender uint64, account string, c
 		return nil, nil, ErrNotInProgress
 	}
 
-	if len(generation.sharedSecrets) != len(generation.participants) {
-		contributedParticipants := make([]uint64, 0)
-		for k := range generation.sharedSecrets {
-			contributedParticipants = append(contributedParticipants, k)
-		}
-		allParticipants := make([]uint64, len(generation.participants))
-		for k := range generation.participants {
-			allParticipants[k] = generation.participants[k].ID
+	var privateKey bls.SecretKey
+	var aggregateVVec []bls.PublicKey
+	if generation.dealers != nil {
+		privateKey, aggregateVVec, err = reshareKey(generation)
+		if err != nil {
+			return nil, nil, err
 		}
-
-		return nil, nil, fmt.Errorf("have %d contributions (%v) , need %d (%v), aborting", len(generation.sharedSecrets), contributedParticipants, len(allParticipants), allParticipants)
-	}
-	if len(generation.sharedVVecs) != len(generation.participants) {
-		return nil, nil, fmt.Errorf("have %d contributions, need %d, aborting", len(generation.sharedVVecs), len(generation.participants))
-	}
-
-	privateKey := bls.SecretKey{}
-	for k := range generation.sharedSecrets {
-		sharedSecret := generation.sharedSecrets[k]
-		privateKey.Add(&sharedSecret)
-	}
-	aggregateVVec := make([]bls.PublicKey, generation.threshold)
-	for _, sharedVVec := range generation.sharedVVecs {
-		for i := range sharedVVec {
-			aggregateVVec[i].Add(&sharedVVec[i])
+	} else {
+		privateKey, aggregateVVec, err = generateKey(generation)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
 
@@ -208,11 +258,17 @@ func (s *Service) OnCommit(ctx context.Context, sender uint64, account string, c
 	if passphrase == nil {
 		passphrase = s.generationPassphrase
 	}
-	err = s.storeDistributedKey(ctx, generation.account, passphrase, privateKey, generation.threshold, aggregateVVec, generation.participants)
-	if err != nil {
+	if s.alreadyCommitted(ctx, generation, privateKey, aggregateVVec) {
+		// The generation was committed before it was interrupted, so there is nothing to store.
+		log.Debug().Str("account", account).Msg("Resumed generation already committed")
+	} else if err := s.storeDistributedKey(ctx, generation.account, passphrase, privateKey, generation.threshold, aggregateVVec, generation.participants, generation.dealers != nil); err != nil {
 		log.Warn().Err(err).Msg("Failed to create key")
 		return nil, nil, ErrNotCreated
 	}
+	if generation.dealers != nil && s.fetcherSvc != nil {
+		// Ensure that the reshared account is used from here on.
+		s.fetcherSvc.Invalidate(ctx, account)
+	}
 
 	// Attempt to retrieve the key to ensure it has been stored properly.
 	walletName, accountName, err := e2wallet.WalletAndAccountNames(account)
@@ -244,12 +300,15 @@ func (s *Service) OnCommit(ctx context.Context, sender uint64, account string, c
 
 // OnAbort is called when we receive a request from the given participant to abort the given DKG.
 func (s *Service) OnAbort(ctx context.Context, sender uint64, account string) error {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.process.OnAbort")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnAbort")
+	defer span.End()
 
 	s.generationsMu.Lock()
 	defer s.generationsMu.Unlock()
 
+	// Any checkpoint is removed even if the generation is no longer in progress, as it will not be resumed.
+	s.deleteCheckpoint(ctx, account)
+
 	_, err := s.getGeneration(ctx, account)
 	if err == ErrNotFound {
 		return ErrNotInProgress
@@ -262,8 +321,8 @@ func (s *Service) OnAbort(ctx context.Context, sender uint64, account string) er
 
 // OnContribute is is called when we need to swap contributions with another participant.
 func (s *Service) OnContribute(ctx context.Context, sender uint64, account string, secret bls.SecretKey, vVec []bls.PublicKey) (bls.SecretKey, []bls.PublicKey, error) {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.process.OnContribute")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.process.standard").Start(ctx, "services.process.OnContribute")
+	defer span.End()
 
 	s.generationsMu.Lock()
 	defer s.generationsMu.Unlock()
@@ -273,6 +332,10 @@ func (s *Service) OnContribute(ctx context.Context, sender uint64, account strin
 		return bls.SecretKey{}, nil, err
 	}
 
+	if generation.dealers != nil {
+		return receiveReshareContribution(generation, sender, secret, vVec)
+	}
+
 	if !verifyContribution(generation.id, secret, vVec) {
 		log.Warn().Uint64("sender", sender).Str("account", account).Msg("Received invalid contribution")
 		return bls.SecretKey{}, nil, fmt.Errorf("invalid contribution from %d", sender)
@@ -293,7 +356,8 @@ func (s *Service) storeDistributedKey(ctx context.Context,
 	privateKey bls.SecretKey,
 	threshold uint32,
 	verificationVector []bls.PublicKey,
-	participants []*core.Endpoint) error {
+	participants []*core.Endpoint,
+	replace bool) error {
 	store := s.stores[0]
 
 	walletName, accountName, err := e2wallet.WalletAndAccountNames(account)
@@ -327,6 +391,13 @@ func (s *Service) storeDistributedKey(ctx context.Context,
 	for i := range participants {
 		walletParticipants[participants[i].ID] = participants[i].ConnectAddress()
 	}
+	if replace {
+		existingAccount, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, accountName)
+		if err == nil {
+			// We hold a share of the account being reshared, so replace it.
+			return s.replaceDistributedAccount(ctx, wallet, existingAccount, privateKey.Serialize(), threshold, vVec, walletParticipants, passphrase)
+		}
+	}
 	_, err = wallet.(e2wtypes.WalletDistributedAccountImporter).ImportDistributedAccount(ctx, accountName, privateKey.Serialize(), threshold, vVec, walletParticipants, passphrase)
 	if err != nil {
 		return errors.Wrap(err, "failed to import account")
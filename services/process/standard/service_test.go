@@ -45,8 +45,8 @@ import (
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
-// Helper to create a process service.
-func createProcessService(ctx context.Context, id uint64) (process.Service, error) {
+// Helper to create a process service.  Any parameters supplied override the defaults.
+func createProcessService(ctx context.Context, id uint64, params ...standardprocess.Parameter) (process.Service, error) {
 	stores := []e2wtypes.Store{scratch.New()}
 	if _, err := distributed.CreateWallet(ctx, "Test", stores[0], keystorev4.New()); err != nil {
 		return nil, err
@@ -56,6 +56,8 @@ func createProcessService(ctx context.Context, id uint64) (process.Service, erro
 			1: "signer-test01:8881",
 			2: "signer-test02:8882",
 			3: "signer-test03:8883",
+			4: "signer-test04:8884",
+			5: "signer-test05:8885",
 		}),
 	)
 	if err != nil {
@@ -72,7 +74,7 @@ func createProcessService(ctx context.Context, id uint64) (process.Service, erro
 		return nil, err
 	}
 
-	process, err := standardprocess.New(ctx,
+	process, err := standardprocess.New(ctx, append([]standardprocess.Parameter{
 		standardprocess.WithChecker(checkerSvc),
 		standardprocess.WithGenerationPassphrase([]byte("secret")),
 		standardprocess.WithID(id),
@@ -80,7 +82,7 @@ func createProcessService(ctx context.Context, id uint64) (process.Service, erro
 		standardprocess.WithSender(sendermock.New(id)),
 		standardprocess.WithStores(stores),
 		standardprocess.WithUnlocker(unlockerSvc),
-	)
+	}, params...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +139,7 @@ func TestNew(t *testing.T) {
 		sender               sender.Service
 		unlocker             unlocker.Service
 		id                   uint64
+		refreshInterval      time.Duration
 		err                  string
 	}{
 		{
@@ -211,6 +214,19 @@ func TestNew(t *testing.T) {
 			unlocker:             unlockerSvc,
 			err:                  "problem with parameters: no ID specified",
 		},
+		{
+			name:                 "RefreshIntervalNegative",
+			peers:                peersSvc,
+			checker:              checkerSvc,
+			stores:               stores,
+			endpoints:            endpoints,
+			generationPassphrase: []byte("secret"),
+			sender:               senderSvc,
+			unlocker:             unlockerSvc,
+			id:                   1,
+			refreshInterval:      -1 * time.Second,
+			err:                  "problem with parameters: refresh interval cannot be negative",
+		},
 		{
 			name:                 "Good",
 			peers:                peersSvc,
@@ -235,6 +251,7 @@ func TestNew(t *testing.T) {
 				standardprocess.WithSender(test.sender),
 				standardprocess.WithUnlocker(test.unlocker),
 				standardprocess.WithID(test.id),
+				standardprocess.WithRefreshInterval(test.refreshInterval),
 			)
 
 			if test.err != "" {
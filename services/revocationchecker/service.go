@@ -0,0 +1,26 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocationchecker
+
+import (
+	"context"
+	"crypto/x509"
+)
+
+// Service is the interface for checking whether client certificates have been revoked.
+type Service interface {
+	// CheckRevocation checks the chains verified for a client certificate, returning an error
+	// if none of them is free of revoked certificates.
+	CheckRevocation(ctx context.Context, verifiedChains [][]*x509.Certificate) error
+}
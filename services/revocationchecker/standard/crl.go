@@ -0,0 +1,154 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// revocationList is a parsed certificate revocation list.
+type revocationList struct {
+	list      *pkix.CertificateList
+	rawIssuer []byte
+	revoked   map[string]bool
+}
+
+// refreshCRLsPeriodically fetches the revocation lists again at the refresh interval.
+func (s *Service) refreshCRLsPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(s.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.refreshCRLs(ctx); err != nil {
+				log.Warn().Err(err).Msg("Failed to refresh certificate revocation lists; retaining existing lists")
+			}
+		}
+	}
+}
+
+// refreshCRLs fetches the revocation lists.  Lists that cannot be fetched retain their previous contents,
+// and the first error encountered is returned.
+func (s *Service) refreshCRLs(ctx context.Context) error {
+	var firstErr error
+	for _, location := range s.crlLocations {
+		crl, err := s.fetchCRL(ctx, location)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "failed to obtain CRL from %s", location)
+			}
+			continue
+		}
+		if crl.list.HasExpired(time.Now()) {
+			log.Warn().Str("location", location).Time("next_update", crl.list.TBSCertList.NextUpdate).Msg("Certificate revocation list has passed its next update time")
+		}
+		s.crlsMu.Lock()
+		s.crls[location] = crl
+		s.crlsMu.Unlock()
+		log.Trace().Str("location", location).Int("revoked", len(crl.revoked)).Msg("Obtained certificate revocation list")
+	}
+	return firstErr
+}
+
+// fetchCRL fetches and parses a revocation list.
+func (s *Service) fetchCRL(ctx context.Context, location string) (*revocationList, error) {
+	var data []byte
+	var err error
+	if isURL(location) {
+		data, err = s.fetch(ctx, http.MethodGet, location, "", nil)
+	} else {
+		data, err = ioutil.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// ParseCRL accepts both DER and PEM encodings.
+	list, err := x509.ParseCRL(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CRL")
+	}
+	rawIssuer, err := asn1.Marshal(list.TBSCertList.Issuer)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid CRL issuer")
+	}
+	revoked := make(map[string]bool, len(list.TBSCertList.RevokedCertificates))
+	for _, entry := range list.TBSCertList.RevokedCertificates {
+		revoked[entry.SerialNumber.String()] = true
+	}
+
+	return &revocationList{
+		list:      list,
+		rawIssuer: rawIssuer,
+		revoked:   revoked,
+	}, nil
+}
+
+// checkCRLs checks a certificate against the revocation lists of its issuer.
+func (s *Service) checkCRLs(cert *x509.Certificate, issuer *x509.Certificate) error {
+	s.crlsMu.RLock()
+	defer s.crlsMu.RUnlock()
+
+	for location, crl := range s.crls {
+		if !bytes.Equal(crl.rawIssuer, cert.RawIssuer) {
+			continue
+		}
+		if err := issuer.CheckCRLSignature(crl.list); err != nil {
+			// A different issuer with the same name.
+			continue
+		}
+		if crl.revoked[cert.SerialNumber.String()] {
+			log.Warn().Str("subject", cert.Subject.String()).Str("serial", cert.SerialNumber.String()).Str("location", location).Msg("Certificate has been revoked")
+			return fmt.Errorf("certificate %s has been revoked", cert.SerialNumber.String())
+		}
+	}
+
+	return nil
+}
+
+// fetch fetches data from a URL.
+func (s *Service) fetch(ctx context.Context, method string, url string, contentType string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("request returned status %d", resp.StatusCode)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	return data, nil
+}
@@ -0,0 +1,100 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ocsp"
+)
+
+// ocspResult is the cached result of an OCSP check.
+type ocspResult struct {
+	err     error
+	expires time.Time
+}
+
+// checkOCSP checks a certificate with the OCSP responders that it names.
+func (s *Service) checkOCSP(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate) error {
+	if len(cert.OCSPServer) == 0 {
+		return nil
+	}
+
+	key := sha256.Sum256(cert.Raw)
+	s.ocspMu.Lock()
+	cached, exists := s.ocspResponses[key]
+	s.ocspMu.Unlock()
+	if exists && time.Now().Before(cached.expires) {
+		return cached.err
+	}
+
+	resp, err := s.queryOCSP(ctx, cert, issuer)
+	if err == nil && resp.Status == ocsp.Unknown {
+		err = errors.New("responder does not know certificate")
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("subject", cert.Subject.String()).Str("serial", cert.SerialNumber.String()).Msg("Failed to obtain OCSP status of certificate")
+		if s.ocspSoftFail {
+			return nil
+		}
+		return errors.Wrap(err, "failed to obtain OCSP status")
+	}
+
+	res := &ocspResult{
+		expires: resp.NextUpdate,
+	}
+	if res.expires.IsZero() {
+		// The responder has newer information available at all times; cache the result for the refresh interval.
+		res.expires = time.Now().Add(s.refreshInterval)
+	}
+	if resp.Status == ocsp.Revoked {
+		log.Warn().Str("subject", cert.Subject.String()).Str("serial", cert.SerialNumber.String()).Msg("Certificate has been revoked")
+		res.err = fmt.Errorf("certificate %s has been revoked", cert.SerialNumber.String())
+	}
+	s.ocspMu.Lock()
+	s.ocspResponses[key] = res
+	s.ocspMu.Unlock()
+
+	return res.err
+}
+
+// queryOCSP obtains the status of a certificate from the first of its OCSP responders to respond.
+func (s *Service) queryOCSP(ctx context.Context, cert *x509.Certificate, issuer *x509.Certificate) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create OCSP request")
+	}
+
+	for _, server := range cert.OCSPServer {
+		data, err := s.fetch(ctx, http.MethodPost, server, "application/ocsp-request", req)
+		if err != nil {
+			log.Debug().Err(err).Str("responder", server).Msg("OCSP request failed")
+			continue
+		}
+		resp, err := ocsp.ParseResponseForCert(data, cert, issuer)
+		if err != nil {
+			log.Debug().Err(err).Str("responder", server).Msg("Invalid OCSP response")
+			continue
+		}
+		return resp, nil
+	}
+
+	return nil, errors.New("no OCSP responder provided a valid response")
+}
@@ -0,0 +1,128 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	crls            []string
+	refreshInterval time.Duration
+	ocsp            bool
+	ocspSoftFail    bool
+	timeout         time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithCRLs sets the certificate revocation lists.  Each is either the path to a file or an HTTP(S) URL, and
+// may be DER or PEM encoded.
+func WithCRLs(crls []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.crls = crls
+	})
+}
+
+// WithRefreshInterval sets the interval at which certificate revocation lists are fetched again.
+func WithRefreshInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.refreshInterval = interval
+	})
+}
+
+// WithOCSP enables checking certificates with the OCSP responders that they name.
+func WithOCSP(enabled bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.ocsp = enabled
+	})
+}
+
+// WithOCSPSoftFail accepts certificates whose status cannot be obtained from their OCSP responder.  If not set
+// such certificates are refused.
+func WithOCSPSoftFail(softFail bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.ocspSoftFail = softFail
+	})
+}
+
+// WithTimeout sets the maximum time to wait when fetching revocation lists or OCSP responses.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:        zerolog.GlobalLevel(),
+		refreshInterval: time.Hour,
+		timeout:         5 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if len(parameters.crls) == 0 && !parameters.ocsp {
+		return nil, errors.New("no CRLs specified and OCSP not enabled")
+	}
+	for _, crl := range parameters.crls {
+		if crl == "" {
+			return nil, errors.New("empty CRL location specified")
+		}
+		if isURL(crl) {
+			if _, err := url.ParseRequestURI(crl); err != nil {
+				return nil, errors.Wrapf(err, "invalid CRL URL %q", crl)
+			}
+		}
+	}
+	if parameters.refreshInterval <= 0 {
+		return nil, errors.New("refresh interval must be greater than 0")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+
+	return &parameters, nil
+}
+
+// isURL returns true if the location of a CRL is a URL rather than a file.
+func isURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
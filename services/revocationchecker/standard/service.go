@@ -0,0 +1,108 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service checks client certificates against certificate revocation lists and OCSP responders.
+type Service struct {
+	crlLocations    []string
+	refreshInterval time.Duration
+	ocsp            bool
+	ocspSoftFail    bool
+	client          *http.Client
+
+	crlsMu sync.RWMutex
+	crls   map[string]*revocationList
+
+	ocspMu        sync.Mutex
+	ocspResponses map[[32]byte]*ocspResult
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new revocation checker service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "revocationchecker").Str("impl", "standard").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	s := &Service{
+		crlLocations:    parameters.crls,
+		refreshInterval: parameters.refreshInterval,
+		ocsp:            parameters.ocsp,
+		ocspSoftFail:    parameters.ocspSoftFail,
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+		crls:          make(map[string]*revocationList),
+		ocspResponses: make(map[[32]byte]*ocspResult),
+	}
+
+	if len(s.crlLocations) > 0 {
+		// Revocation lists must be available at startup, otherwise revoked certificates would be accepted.
+		if err := s.refreshCRLs(ctx); err != nil {
+			return nil, err
+		}
+		go s.refreshCRLsPeriodically(ctx)
+	}
+
+	return s, nil
+}
+
+// CheckRevocation checks the chains verified for a client certificate, returning an error
+// if none of them is free of revoked certificates.
+func (s *Service) CheckRevocation(ctx context.Context, verifiedChains [][]*x509.Certificate) error {
+	var err error
+	for _, chain := range verifiedChains {
+		if err = s.checkChain(ctx, chain); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// checkChain checks each certificate in a chain against its issuer.
+func (s *Service) checkChain(ctx context.Context, chain []*x509.Certificate) error {
+	// The final certificate in the chain is the trusted root, which is not checked.
+	for i := 0; i < len(chain)-1; i++ {
+		if err := s.checkCRLs(chain[i], chain[i+1]); err != nil {
+			return err
+		}
+		if s.ocsp {
+			if err := s.checkOCSP(ctx, chain[i], chain[i+1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
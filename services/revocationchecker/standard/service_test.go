@@ -0,0 +1,314 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/services/revocationchecker/standard"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newAuthority(t *testing.T) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &authority{cert: cert, key: key}
+}
+
+// issue issues a client certificate, returning its verified chain.
+func (a *authority) issue(t *testing.T, serial int64, ocspServers []string) []*x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		OCSPServer:   ocspServers,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, key.Public(), a.key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return []*x509.Certificate{cert, a.cert}
+}
+
+// crl creates a revocation list revoking the supplied serial numbers.
+func (a *authority) crl(t *testing.T, serials ...int64) []byte {
+	revoked := make([]pkix.RevokedCertificate, 0, len(serials))
+	for _, serial := range serials {
+		revoked = append(revoked, pkix.RevokedCertificate{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now(),
+		})
+	}
+	crl, err := a.cert.CreateCRL(rand.Reader, a.key, revoked, time.Now(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	return crl
+}
+
+// ocspResponder responds to OCSP requests, revoking the supplied serial numbers.
+type ocspResponder struct {
+	authority *authority
+	mutex     sync.Mutex
+	revoked   map[int64]bool
+	requests  int
+}
+
+func (r *ocspResponder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	ocspReq, err := ocsp.ParseRequest(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.mutex.Lock()
+	r.requests++
+	status := ocsp.Good
+	if r.revoked[ocspReq.SerialNumber.Int64()] {
+		status = ocsp.Revoked
+	}
+	r.mutex.Unlock()
+	resp, err := ocsp.CreateResponse(r.authority.cert, r.authority.cert, ocsp.Response{
+		Status:       status,
+		SerialNumber: ocspReq.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+		RevokedAt:    time.Now().Add(-time.Minute),
+	}, crypto.Signer(r.authority.key))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(resp)
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	ca := newAuthority(t)
+	crlFile := filepath.Join(t.TempDir(), "ca.crl")
+	require.NoError(t, ioutil.WriteFile(crlFile, ca.crl(t, 3), 0o600))
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "Nothing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no CRLs specified and OCSP not enabled",
+		},
+		{
+			name: "CRLEmpty",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCRLs([]string{""}),
+			},
+			err: "problem with parameters: empty CRL location specified",
+		},
+		{
+			name: "RefreshIntervalZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCRLs([]string{crlFile}),
+				standard.WithRefreshInterval(0),
+			},
+			err: "problem with parameters: refresh interval must be greater than 0",
+		},
+		{
+			name: "TimeoutZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithOCSP(true),
+				standard.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "CRLMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCRLs([]string{filepath.Join(t.TempDir(), "missing.crl")}),
+			},
+			err: "failed to obtain CRL from",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithCRLs([]string{crlFile}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCRL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ca := newAuthority(t)
+	otherCA := newAuthority(t)
+
+	crlFile := filepath.Join(t.TempDir(), "ca.crl")
+	require.NoError(t, ioutil.WriteFile(crlFile, ca.crl(t, 3), 0o600))
+
+	var crlMu sync.Mutex
+	crl := ca.crl(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		crlMu.Lock()
+		defer crlMu.Unlock()
+		_, _ = w.Write(crl)
+	}))
+	defer server.Close()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithCRLs([]string{crlFile, server.URL}),
+		standard.WithRefreshInterval(10*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	// Certificate 2 is good, certificate 3 is revoked.
+	require.NoError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{ca.issue(t, 2, nil)}))
+	require.EqualError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{ca.issue(t, 3, nil)}), "certificate 3 has been revoked")
+
+	// Revocation lists from a different issuer do not apply.
+	require.NoError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{otherCA.issue(t, 3, nil)}))
+
+	// No verified chains, as when a client does not supply a certificate.
+	require.NoError(t, s.CheckRevocation(ctx, nil))
+
+	// Revoke certificate 2 in the remote list and wait for it to be refreshed.
+	crlMu.Lock()
+	crl = ca.crl(t, 2)
+	crlMu.Unlock()
+	require.Eventually(t, func() bool {
+		return s.CheckRevocation(ctx, [][]*x509.Certificate{ca.issue(t, 2, nil)}) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// An unavailable list retains its previous contents.
+	require.NoError(t, os.Remove(crlFile))
+	time.Sleep(50 * time.Millisecond)
+	require.EqualError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{ca.issue(t, 3, nil)}), "certificate 3 has been revoked")
+}
+
+func TestOCSP(t *testing.T) {
+	ctx := context.Background()
+	ca := newAuthority(t)
+	responder := &ocspResponder{
+		authority: ca,
+		revoked:   map[int64]bool{3: true},
+	}
+	server := httptest.NewServer(responder)
+	defer server.Close()
+
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithOCSP(true),
+	)
+	require.NoError(t, err)
+
+	good := ca.issue(t, 2, []string{server.URL})
+	require.NoError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{good}))
+	require.EqualError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{ca.issue(t, 3, []string{server.URL})}), "certificate 3 has been revoked")
+
+	// Responses are cached.
+	require.NoError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{good}))
+	responder.mutex.Lock()
+	require.Equal(t, 2, responder.requests)
+	responder.mutex.Unlock()
+
+	// Certificates without a responder are not checked.
+	require.NoError(t, s.CheckRevocation(ctx, [][]*x509.Certificate{ca.issue(t, 3, nil)}))
+}
+
+func TestOCSPUnavailable(t *testing.T) {
+	ctx := context.Background()
+	ca := newAuthority(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	chain := ca.issue(t, 2, []string{server.URL})
+
+	hardFail, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithOCSP(true),
+	)
+	require.NoError(t, err)
+	require.EqualError(t, hardFail.CheckRevocation(ctx, [][]*x509.Certificate{chain}), "failed to obtain OCSP status: no OCSP responder provided a valid response")
+
+	softFail, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithOCSP(true),
+		standard.WithOCSPSoftFail(true),
+	)
+	require.NoError(t, err)
+	require.NoError(t, softFail.CheckRevocation(ctx, [][]*x509.Certificate{chain}))
+}
@@ -0,0 +1,116 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/auditor"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+)
+
+// audit sends the results of running rules to the auditor, if present.
+func (s *Service) audit(ctx context.Context,
+	credentials *checker.Credentials,
+	action string,
+	rulesData []*ruler.RulesData,
+	results []rules.Result,
+) {
+	if s.auditor == nil || rules.IsDryRun(ctx) {
+		return
+	}
+
+	timestamp := time.Now().UTC()
+	for i := range rulesData {
+		if rulesData[i] == nil || i >= len(results) {
+			continue
+		}
+		record := &auditor.Record{
+			Timestamp: timestamp,
+			Action:    action,
+			Result:    results[i].String(),
+		}
+		if credentials != nil {
+			record.RequestID = credentials.RequestID
+			record.Client = credentials.Client
+			record.IP = credentials.IP
+		}
+		if rulesData[i].AccountName == "" {
+			record.Account = rulesData[i].WalletName
+		} else {
+			record.Account = fmt.Sprintf("%s/%s", rulesData[i].WalletName, rulesData[i].AccountName)
+		}
+		if len(rulesData[i].PubKey) > 0 {
+			record.PubKey = fmt.Sprintf("%#x", rulesData[i].PubKey)
+		}
+		switch data := rulesData[i].Data.(type) {
+		case *rules.SignData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			record.Epoch = data.Epoch
+		case *rules.SignBeaconProposalData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			slot := data.Slot
+			record.Slot = &slot
+		case *rules.SignRANDAORevealData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			epoch := data.Epoch
+			record.Epoch = &epoch
+		case *rules.SignAggregateAndProofData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			slot := data.Slot
+			record.Slot = &slot
+		case *rules.SignAggregationSlotData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			slot := data.Slot
+			record.Slot = &slot
+		case *rules.SignSyncCommitteeMessageData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			slot := data.Slot
+			record.Slot = &slot
+		case *rules.SignSyncCommitteeSelectionProofData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			slot := data.Slot
+			record.Slot = &slot
+		case *rules.SignSyncCommitteeContributionAndProofData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			slot := data.Slot
+			record.Slot = &slot
+		case *rules.SignVoluntaryExitData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			epoch := data.Epoch
+			record.Epoch = &epoch
+		case *rules.SignBLSToExecutionChangeData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+		case *rules.SignValidatorRegistrationData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+		case *rules.SignBeaconAttestationData:
+			record.Domain = fmt.Sprintf("%#x", data.Domain)
+			slot := data.Slot
+			record.Slot = &slot
+			if data.Source != nil {
+				sourceEpoch := data.Source.Epoch
+				record.SourceEpoch = &sourceEpoch
+			}
+			if data.Target != nil {
+				targetEpoch := data.Target.Epoch
+				record.TargetEpoch = &targetEpoch
+			}
+		}
+		s.auditor.Audit(ctx, record)
+	}
+}
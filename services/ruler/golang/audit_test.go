@@ -0,0 +1,97 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang_test
+
+import (
+	"context"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	mockauditor "github.com/attestantio/dirk/services/auditor/mock"
+	"github.com/attestantio/dirk/services/checker"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func _byteStr(t *testing.T, input string) []byte {
+	bytes, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	require.Nil(t, err)
+	return bytes
+}
+
+func TestAudit(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	storagePath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(storagePath)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+	)
+	require.NoError(t, err)
+	auditor := mockauditor.New()
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(testRules),
+		golang.WithAuditor(auditor),
+	)
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{
+		RequestID: "abc",
+		Client:    "client1",
+		IP:        "1.2.3.4",
+	}
+	pubKey := _byteStr(t, "a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c")
+	data := []*ruler.RulesData{
+		{
+			WalletName:  "wallet",
+			AccountName: "account",
+			PubKey:      pubKey,
+			Data: &rules.SignBeaconProposalData{
+				Domain: _byteStr(t, "0000000000000000000000000000000000000000000000000000000000000000"),
+				Slot:   5,
+			},
+		},
+	}
+
+	// First request is approved, second is denied as it is for the same slot.
+	require.Equal(t, []rules.Result{rules.APPROVED}, service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, data))
+	require.Equal(t, []rules.Result{rules.DENIED}, service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, data))
+
+	records := auditor.Records()
+	require.Len(t, records, 2)
+	for _, record := range records {
+		assert.Equal(t, "abc", record.RequestID)
+		assert.Equal(t, "client1", record.Client)
+		assert.Equal(t, "1.2.3.4", record.IP)
+		assert.Equal(t, "wallet/account", record.Account)
+		assert.Equal(t, "0xa99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c", record.PubKey)
+		assert.Equal(t, ruler.ActionSignBeaconProposal, record.Action)
+		require.NotNil(t, record.Slot)
+		assert.Equal(t, uint64(5), *record.Slot)
+	}
+	assert.Equal(t, "Approved", records[0].Result)
+	assert.Equal(t, "Denied", records[1].Result)
+}
@@ -0,0 +1,115 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/consensus"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/rs/zerolog"
+)
+
+// confirmWithPeers confirms approved proposals and attestations with peers, if configured.
+// Any approved entry that cannot be confirmed is marked as failed.
+func (s *Service) confirmWithPeers(ctx context.Context,
+	log zerolog.Logger,
+	action string,
+	rulesData []*ruler.RulesData,
+	results []rules.Result,
+) {
+	if s.consensus == nil || rules.IsDryRun(ctx) {
+		return
+	}
+	if action != ruler.ActionSignBeaconProposal && action != ruler.ActionSignBeaconAttestation {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i] != rules.APPROVED {
+			continue
+		}
+		hwm := highWaterMark(rulesData[i])
+		if hwm == nil {
+			log.Warn().Msg("Data not of expected type for consensus")
+			results[i] = rules.FAILED
+			continue
+		}
+		wg.Add(1)
+		go func(i int, hwm *consensus.HighWaterMark) {
+			defer wg.Done()
+			if err := s.consensus.Confirm(ctx, hwm); err != nil {
+				log.Warn().Str("account", hwm.Account).Err(err).Msg("Failed to confirm high-water mark with peers")
+				results[i] = rules.FAILED
+			}
+		}(i, hwm)
+	}
+	wg.Wait()
+}
+
+// highWaterMark returns the high-water mark for the rules data, or nil if it is not a proposal or attestation.
+func highWaterMark(rulesData *ruler.RulesData) *consensus.HighWaterMark {
+	switch data := rulesData.Data.(type) {
+	case *rules.SignBeaconProposalData:
+		root := sha256.New()
+		root.Write(data.Domain)
+		writeUint64(root, data.Slot)
+		writeUint64(root, data.ProposerIndex)
+		root.Write(data.ParentRoot)
+		root.Write(data.StateRoot)
+		root.Write(data.BodyRoot)
+		return &consensus.HighWaterMark{
+			Account: rulesDataName(rulesData),
+			Kind:    consensus.KindProposal,
+			Slot:    data.Slot,
+			Root:    root.Sum(nil),
+		}
+	case *rules.SignBeaconAttestationData:
+		if data.Source == nil || data.Target == nil {
+			return nil
+		}
+		root := sha256.New()
+		root.Write(data.Domain)
+		writeUint64(root, data.Slot)
+		writeUint64(root, data.CommitteeIndex)
+		root.Write(data.BeaconBlockRoot)
+		writeUint64(root, data.Source.Epoch)
+		root.Write(data.Source.Root)
+		writeUint64(root, data.Target.Epoch)
+		root.Write(data.Target.Root)
+		return &consensus.HighWaterMark{
+			Account:     rulesDataName(rulesData),
+			Kind:        consensus.KindAttestation,
+			SourceEpoch: data.Source.Epoch,
+			TargetEpoch: data.Target.Epoch,
+			Root:        root.Sum(nil),
+		}
+	default:
+		return nil
+	}
+}
+
+// writeUint64 writes a fixed-length encoding of the value.
+func writeUint64(w io.Writer, value uint64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], value)
+	// Writes to a hash never fail.
+	_, _ = w.Write(buf[:])
+}
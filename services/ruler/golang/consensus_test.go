@@ -0,0 +1,144 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/consensus"
+	standardconsensus "github.com/attestantio/dirk/services/consensus/standard"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	staticpeers "github.com/attestantio/dirk/services/peers/static"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	sendermock "github.com/attestantio/dirk/services/sender/mock"
+	"github.com/attestantio/dirk/testing/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRulesConsensus(t *testing.T) {
+	ctx := context.Background()
+
+	peersSvc, err := staticpeers.New(ctx,
+		staticpeers.WithPeers(map[uint64]string{
+			1: "signer-test01:8881",
+			2: "signer-test02:8882",
+			3: "signer-test03:8883",
+		}),
+	)
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{
+		Client: "client-test01",
+	}
+	proposal := func(slot uint64) []*ruler.RulesData {
+		return []*ruler.RulesData{
+			{
+				WalletName:  "Test wallet",
+				AccountName: "Test account",
+				PubKey:      make([]byte, 48),
+				Data: &rules.SignBeaconProposalData{
+					Domain:        make([]byte, 32),
+					Slot:          slot,
+					ProposerIndex: 1,
+					ParentRoot:    make([]byte, 32),
+					StateRoot:     make([]byte, 32),
+					BodyRoot:      make([]byte, 32),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		online []uint64
+		// peerSlot is a slot for which peers 2 and 3 have already acknowledged a proposal.
+		peerSlot uint64
+		slot     uint64
+		result   rules.Result
+	}{
+		{
+			name:   "Confirmed",
+			online: []uint64{1, 2, 3},
+			slot:   10,
+			result: rules.APPROVED,
+		},
+		{
+			name:     "PeersConflict",
+			online:   []uint64{1, 2, 3},
+			peerSlot: 20,
+			slot:     10,
+			result:   rules.FAILED,
+		},
+		{
+			name:   "Partition",
+			online: []uint64{1},
+			slot:   10,
+			result: rules.FAILED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock.Consensuses = make(map[uint64]consensus.Service)
+			services := make(map[uint64]*standardconsensus.Service)
+			for id := uint64(1); id <= 3; id++ {
+				service, err := standardconsensus.New(ctx,
+					standardconsensus.WithPeers(peersSvc),
+					standardconsensus.WithTransport(sendermock.New(id)),
+					standardconsensus.WithID(id),
+					standardconsensus.WithStoragePath(t.TempDir()),
+					standardconsensus.WithQuorum(2),
+				)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = service.Close(ctx) })
+				services[id] = service
+			}
+			for _, id := range test.online {
+				mock.Consensuses[id] = services[id]
+			}
+			if test.peerSlot != 0 {
+				for _, id := range []uint64{2, 3} {
+					_, err := services[id].Acknowledge(ctx, &consensus.HighWaterMark{
+						Account: "Test wallet/Test account",
+						Kind:    consensus.KindProposal,
+						Slot:    test.peerSlot,
+						Root:    []byte{0x01},
+					})
+					require.NoError(t, err)
+				}
+			}
+
+			locker, err := syncmaplocker.New(ctx)
+			require.NoError(t, err)
+			testRules, err := standardrules.New(ctx,
+				standardrules.WithStoragePath(t.TempDir()),
+			)
+			require.NoError(t, err)
+			service, err := golang.New(ctx,
+				golang.WithLocker(locker),
+				golang.WithRules(testRules),
+				golang.WithConsensus(services[1]),
+			)
+			require.NoError(t, err)
+
+			results := service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, proposal(test.slot))
+			require.Equal(t, []rules.Result{test.result}, results)
+		})
+	}
+}
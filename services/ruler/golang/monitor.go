@@ -13,6 +13,15 @@
 
 package golang
 
+import (
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+)
+
 // noopMonitor is a monitor that does nothing, used in place of nil if an
 // external monitor is not supplied.
 type noopMonitor struct{}
+
+// RulesCompleted is called when rules have been run for a request.
+func (n *noopMonitor) RulesCompleted(started time.Time, action string, results []rules.Result) {}
@@ -0,0 +1,92 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/checker"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMonitor records the results of rules runs.
+type recordingMonitor struct {
+	mutex   sync.Mutex
+	actions []string
+	results [][]rules.Result
+}
+
+func (m *recordingMonitor) RulesCompleted(started time.Time, action string, results []rules.Result) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.actions = append(m.actions, action)
+	m.results = append(m.results, results)
+}
+
+func TestRunRulesMonitor(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	monitor := &recordingMonitor{}
+	service, err := golang.New(ctx,
+		golang.WithMonitor(monitor),
+		golang.WithLocker(locker),
+		golang.WithRules(testRules),
+	)
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{Client: "client-test01"}
+	proposal := []*ruler.RulesData{
+		{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			PubKey:      make([]byte, 48),
+			Data: &rules.SignBeaconProposalData{
+				Domain:     make([]byte, 32),
+				Slot:       10,
+				ParentRoot: make([]byte, 32),
+				StateRoot:  make([]byte, 32),
+				BodyRoot:   make([]byte, 32),
+			},
+		},
+	}
+
+	// Dry runs are not monitored.
+	service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, proposal, ruler.WithDryRun())
+	require.Empty(t, monitor.actions)
+
+	// The first proposal is approved, the repeat denied.
+	service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, proposal)
+	service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, proposal)
+	// Missing data fails.
+	service.RunRules(ctx, credentials, ruler.ActionSign, nil)
+
+	assert.Equal(t, []string{ruler.ActionSignBeaconProposal, ruler.ActionSignBeaconProposal, ruler.ActionSign}, monitor.actions)
+	assert.Equal(t, [][]rules.Result{{rules.APPROVED}, {rules.DENIED}, {rules.FAILED}}, monitor.results)
+}
@@ -14,18 +14,32 @@
 package golang
 
 import (
+	"time"
+
 	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/auditor"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/consensus"
 	"github.com/attestantio/dirk/services/locker"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
+// defaultMaxBatchSize is the default maximum number of entries in a single call to run rules.
+const defaultMaxBatchSize = 4096
+
 type parameters struct {
-	logLevel zerolog.Level
-	monitor  metrics.RulerMonitor
-	rules    rules.Service
-	locker   locker.Service
+	logLevel  zerolog.Level
+	monitor   metrics.RulerMonitor
+	rules     rules.Service
+	locker    locker.Service
+	auditor   auditor.Service
+	consensus consensus.Service
+	checker   checker.Service
+	timeout   time.Duration
+	lenient   bool
+	maxBatch  int
 }
 
 // Parameter is the interface for service parameters.
@@ -67,6 +81,55 @@ func WithRules(rules rules.Service) Parameter {
 	})
 }
 
+// WithAuditor sets the auditor for this module.
+func WithAuditor(auditor auditor.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.auditor = auditor
+	})
+}
+
+// WithConsensus sets the slashing protection consensus service for this module.
+// If supplied, approved proposals and attestations must also be confirmed by a quorum of peers.
+func WithConsensus(consensus consensus.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.consensus = consensus
+	})
+}
+
+// WithChecker sets the checker for this module.
+// If supplied, clients with read-only credentials are denied all actions other than accessing accounts.
+func WithChecker(checker checker.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checker = checker
+	})
+}
+
+// WithTimeout sets the overall timeout for running rules.  Once exceeded, entries that have not yet been
+// evaluated fail.  If this is 0 then running rules is bounded only by the caller's context.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithLenientData relaxes the handling of empty and nil rules data.  By default an empty batch returns
+// a single FAILED result, and a nil entry, or an entry with nil data, fails the batch without evaluating
+// any entries.  If lenient, an empty batch returns an empty set of results, and a nil entry, or an entry
+// with nil data, fails only that entry; the remaining entries are evaluated as normal.
+func WithLenientData(lenient bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.lenient = lenient
+	})
+}
+
+// WithMaxBatchSize sets the maximum number of entries in a single call to run rules.  Larger batches are
+// refused in their entirety, before any locks are acquired.  If this is 0 then the default of 4096 is used.
+func WithMaxBatchSize(maxBatchSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxBatch = maxBatchSize
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -88,6 +151,15 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.rules == nil {
 		return nil, errors.New("no rules specified")
 	}
+	if parameters.timeout < 0 {
+		return nil, errors.New("timeout cannot be negative")
+	}
+	if parameters.maxBatch < 0 {
+		return nil, errors.New("max batch size cannot be negative")
+	}
+	if parameters.maxBatch == 0 {
+		parameters.maxBatch = defaultMaxBatchSize
+	}
 
 	return &parameters, nil
 }
@@ -14,14 +14,19 @@
 package golang
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/locker"
 	"github.com/attestantio/dirk/services/ruler"
-	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
 )
 
 // RunRules runs a number of rules and returns a result.
@@ -29,15 +34,184 @@ func (s *Service) RunRules(ctx context.Context,
 	credentials *checker.Credentials,
 	action string,
 	rulesData []*ruler.RulesData,
+	opts ...ruler.RunOption,
 ) []rules.Result {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "ruler.golang.RunRules")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.ruler.golang").Start(ctx, "ruler.golang.RunRules")
+	defer span.End()
+
+	var options ruler.RunOptions
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+
+	started := time.Now()
+	results := s.runRulesWithOptions(ctx, credentials, action, rulesData, &options)
+	if !options.DryRun {
+		s.monitor.RulesCompleted(started, action, results)
+	}
+
+	return results
+}
+
+// runRulesWithOptions runs a number of rules with the given options and returns a result.
+func (s *Service) runRulesWithOptions(ctx context.Context,
+	credentials *checker.Credentials,
+	action string,
+	rulesData []*ruler.RulesData,
+	options *ruler.RunOptions,
+) []rules.Result {
+	if len(rulesData) > s.maxBatch {
+		// Refuse the batch before doing any work on it, so an oversized batch cannot tie up locks.
+		log.Warn().
+			Str("client", clientName(credentials)).
+			Str("action", action).
+			Int("entries", len(rulesData)).
+			Int("max_batch_size", s.maxBatch).
+			Str("result", "failed").
+			Msg("Batch exceeds maximum size")
+		rules.RecordReason(ctx, rules.ReasonLimitExceeded)
+		results := make([]rules.Result, len(rulesData))
+		for i := range results {
+			results[i] = rules.FAILED
+		}
+		return results
+	}
+
+	if options.DryRun {
+		ctx = rules.WithDryRun(ctx)
+	}
+	if options.AtomicBatch {
+		ctx = rules.WithAtomicBatch(ctx)
+	}
+
+	if s.timeout > 0 {
+		// If the caller's context has an earlier deadline it takes precedence.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	if s.readOnlyDenied(ctx, credentials, action) || s.actionDenied(ctx, credentials, action) {
+		rules.RecordReason(ctx, rules.ReasonPolicy)
+		results := make([]rules.Result, len(rulesData))
+		if len(results) == 0 && !s.lenient {
+			results = make([]rules.Result, 1)
+		}
+		for i := range results {
+			results[i] = rules.DENIED
+		}
+		return results
+	}
+
+	results := s.checkAndRunRules(ctx, credentials, action, rulesData)
+	if options.AtomicBatch {
+		results = atomicResults(credentials, results)
+	}
+
+	return results
+}
+
+// readOnlyDenied returns true if the action is denied because the client has read-only credentials.
+// Accessing accounts is the only action that neither signs nor changes state.
+func (s *Service) readOnlyDenied(ctx context.Context, credentials *checker.Credentials, action string) bool {
+	if s.checker == nil || action == ruler.ActionAccessAccount {
+		return false
+	}
+	if !s.checker.ReadOnly(ctx, credentials) {
+		return false
+	}
+	log.Debug().
+		Str("request_id", credentials.RequestID).
+		Str("client", credentials.Client).
+		Str("action", action).
+		Str("result", "denied").
+		Msg("Action not permitted for client with read-only credentials")
+	return true
+}
+
+// actionDenied returns true if the action is denied because it is not in the client's permitted actions.
+func (s *Service) actionDenied(ctx context.Context, credentials *checker.Credentials, action string) bool {
+	if s.checker == nil || credentials == nil {
+		return false
+	}
+	if s.checker.ActionPermitted(ctx, credentials, action) {
+		return false
+	}
+	log.Debug().
+		Str("request_id", credentials.RequestID).
+		Str("client", credentials.Client).
+		Str("action", action).
+		Str("result", "denied").
+		Msg("Action not in permitted actions for client")
+	return true
+}
+
+// atomicResults marks all results as denied if any result is denied, or otherwise as failed if
+// any result is not approved.
+func atomicResults(credentials *checker.Credentials, results []rules.Result) []rules.Result {
+	batchResult := rules.APPROVED
+	for i := range results {
+		if results[i] == rules.APPROVED {
+			continue
+		}
+		log := log
+		if credentials != nil {
+			log = log.With().Str("request_id", credentials.RequestID).Logger()
+		}
+		log.Debug().Int("entry", i).Str("result", results[i].String()).Msg("Entry not approved; batch not approved")
+		if results[i] == rules.DENIED {
+			// A denial takes precedence, as retrying the batch will not help.
+			batchResult = rules.DENIED
+			break
+		}
+		batchResult = rules.FAILED
+	}
+	if batchResult != rules.APPROVED {
+		for i := range results {
+			results[i] = batchResult
+		}
+	}
+	return results
+}
+
+// checkAndRunRules checks the data, obtains suitable locks, and runs the rules.
+func (s *Service) checkAndRunRules(ctx context.Context,
+	credentials *checker.Credentials,
+	action string,
+	rulesData []*ruler.RulesData,
+) []rules.Result {
+	log := log
+	if credentials != nil {
+		log = log.With().Str("request_id", credentials.RequestID).Logger()
+	}
 
 	// There must be some data.
 	if len(rulesData) == 0 {
 		log.Debug().Msg("Received no rules data entries")
+		if s.lenient {
+			return []rules.Result{}
+		}
 		return []rules.Result{rules.FAILED}
 	}
+	if s.lenient && len(rulesData) > 1 {
+		return s.checkAndRunPresentRules(ctx, log, credentials, action, rulesData)
+	}
+	if len(rulesData) == 1 {
+		return s.checkAndRunRule(ctx, log, credentials, action, rulesData)
+	}
+
+	return s.checkAndRunMultipleRules(ctx, log, credentials, action, rulesData)
+}
+
+// checkAndRunMultipleRules is the general path for checkAndRunRules.
+func (s *Service) checkAndRunMultipleRules(ctx context.Context,
+	log zerolog.Logger,
+	credentials *checker.Credentials,
+	action string,
+	rulesData []*ruler.RulesData,
+) []rules.Result {
 	results := make([]rules.Result, len(rulesData))
 	for i := range rulesData {
 		results[i] = rules.UNKNOWN
@@ -55,10 +229,8 @@ func (s *Service) RunRules(ctx context.Context,
 		}
 	}
 
-	// Only some actions require locking.
-	if action == ruler.ActionSign ||
-		action == ruler.ActionSignBeaconProposal ||
-		action == ruler.ActionSignBeaconAttestation {
+	rulesCtx := ctx
+	if requiresLocking(action) {
 		// We cannot allow multiple requests for the same public key.
 		pubKeyMap := make(map[[48]byte]bool)
 		for i := range rulesData {
@@ -77,17 +249,178 @@ func (s *Service) RunRules(ctx context.Context,
 			pubKeyMap[key] = true
 		}
 
-		// Lock each public key as we come to it, to ensure that there can only be a single active rule
-		// (and hence data update) for a given public key at any time.
-		for i := range rulesData {
-			var lockKey [48]byte
-			copy(lockKey[:], rulesData[i].PubKey)
-			s.locker.Lock(lockKey)
-			defer s.locker.Unlock(lockKey)
+		// Lock each public key, to ensure that there can only be a single active rule (and hence data update)
+		// for a given public key at any time.  Keys are locked in sorted order to avoid deadlock with
+		// concurrent requests for overlapping sets of keys.
+		lockKeys := make([][48]byte, 0, len(pubKeyMap))
+		for key := range pubKeyMap {
+			lockKeys = append(lockKeys, key)
+		}
+		sort.Slice(lockKeys, func(i int, j int) bool {
+			return bytes.Compare(lockKeys[i][:], lockKeys[j][:]) < 0
+		})
+		// The rules are run with a context that is cancelled if any of the locks is lost.
+		var cancel context.CancelFunc
+		rulesCtx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		tokens := make(map[[48]byte]uint64, len(lockKeys))
+		client := clientName(credentials)
+		for i := range lockKeys {
+			token, err := s.locker.LockWithHolder(ctx, lockKeys[i], action, client, cancel)
+			if err != nil {
+				log.Warn().Err(err).Str("pubkey", fmt.Sprintf("%#x", lockKeys[i])).Msg("Failed to obtain lock")
+				for j := range results {
+					results[j] = rules.FAILED
+				}
+				return results
+			}
+			defer s.locker.Unlock(lockKeys[i])
+			tokens[lockKeys[i]] = token
+		}
+		rulesCtx = locker.WithFencingTokens(rulesCtx, tokens)
+	}
+
+	results = s.runRules(rulesCtx, credentials, action, rulesData)
+	failOnLostLock(ctx, rulesCtx, log, results)
+	s.confirmWithPeers(ctx, log, action, rulesData, results)
+	s.audit(ctx, credentials, action, rulesData, results)
+
+	return results
+}
+
+// checkAndRunPresentRules fails nil entries, and entries with nil data, individually, and checks
+// and runs the remaining entries.
+func (s *Service) checkAndRunPresentRules(ctx context.Context,
+	log zerolog.Logger,
+	credentials *checker.Credentials,
+	action string,
+	rulesData []*ruler.RulesData,
+) []rules.Result {
+	results := make([]rules.Result, len(rulesData))
+	present := make([]*ruler.RulesData, 0, len(rulesData))
+	indices := make([]int, 0, len(rulesData))
+	for i := range rulesData {
+		switch {
+		case rulesData[i] == nil:
+			log.Debug().Int("entry", i).Msg("Received nil rules data")
+			results[i] = rules.FAILED
+		case rulesData[i].Data == nil:
+			log.Debug().Int("entry", i).Msg("Received nil data in rules data")
+			results[i] = rules.FAILED
+		default:
+			present = append(present, rulesData[i])
+			indices = append(indices, i)
 		}
 	}
 
-	return s.runRules(ctx, credentials, action, rulesData)
+	var presentResults []rules.Result
+	switch len(present) {
+	case 0:
+		return results
+	case 1:
+		presentResults = s.checkAndRunRule(ctx, log, credentials, action, present)
+	default:
+		presentResults = s.checkAndRunMultipleRules(ctx, log, credentials, action, present)
+	}
+	for i := range presentResults {
+		results[indices[i]] = presentResults[i]
+	}
+
+	return results
+}
+
+// checkAndRunRule is the fast path for checkAndRunRules with a single entry.
+// It avoids the allocations required to check and lock multiple public keys, and must
+// produce the same results as checkAndRunMultipleRules.
+func (s *Service) checkAndRunRule(ctx context.Context,
+	log zerolog.Logger,
+	credentials *checker.Credentials,
+	action string,
+	rulesData []*ruler.RulesData,
+) []rules.Result {
+	if rulesData[0] == nil {
+		log.Debug().Msg("Received nil rules data")
+		return []rules.Result{rules.FAILED}
+	}
+	if rulesData[0].Data == nil {
+		log.Debug().Msg("Received nil data in rules data")
+		return []rules.Result{rules.FAILED}
+	}
+
+	rulesCtx := ctx
+	if requiresLocking(action) {
+		if len(rulesData[0].PubKey) == 0 {
+			log.Debug().Msg("Received no pubkey in rules data")
+			return []rules.Result{rules.FAILED}
+		}
+		var key [48]byte
+		copy(key[:], rulesData[0].PubKey)
+		var cancel context.CancelFunc
+		rulesCtx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		token, err := s.locker.LockWithHolder(ctx, key, action, clientName(credentials), cancel)
+		if err != nil {
+			log.Warn().Err(err).Str("pubkey", fmt.Sprintf("%#x", key)).Msg("Failed to obtain lock")
+			return []rules.Result{rules.FAILED}
+		}
+		defer s.locker.Unlock(key)
+		rulesCtx = locker.WithFencingTokens(rulesCtx, map[[48]byte]uint64{key: token})
+	}
+
+	results := make([]rules.Result, 1)
+	entryLog := log.With().Str("account", rulesDataName(rulesData[0])).Logger()
+	if err := rulesCtx.Err(); err != nil {
+		entryLog.Warn().Err(err).Msg("Rules not run before deadline")
+		results[0] = rules.FAILED
+	} else {
+		metadata, err := s.assembleMetadata(rulesCtx, credentials, rulesData[0].WalletName, rulesData[0].AccountName, rulesData[0].PubKey)
+		if err != nil {
+			entryLog.Warn().Err(err).Msg("Failed to assemble metadata")
+			results[0] = rules.FAILED
+		} else {
+			results[0] = s.runRule(rulesCtx, entryLog, action, metadata, rulesData[0])
+		}
+	}
+	failOnLostLock(ctx, rulesCtx, log, results)
+	s.confirmWithPeers(ctx, log, action, rulesData, results)
+	s.audit(ctx, credentials, action, rulesData, results)
+
+	return results
+}
+
+// failOnLostLock fails all results if the context in which the rules were run was cancelled by the loss of
+// a lock, as another holder of the lock may since have updated the data on which the rules relied.
+func failOnLostLock(ctx context.Context, rulesCtx context.Context, log zerolog.Logger, results []rules.Result) {
+	if ctx.Err() != nil || rulesCtx.Err() == nil {
+		return
+	}
+	log.Error().Msg("Lock lost whilst running rules; failing request")
+	for i := range results {
+		results[i] = rules.FAILED
+	}
+}
+
+// requiresLocking returns true if the action requires the public keys of its entries to be locked.
+func requiresLocking(action string) bool {
+	return action == ruler.ActionSign ||
+		action == ruler.ActionSignBeaconProposal ||
+		action == ruler.ActionSignBeaconAttestation ||
+		action == ruler.ActionSignRANDAOReveal ||
+		action == ruler.ActionSignAggregateAndProof ||
+		action == ruler.ActionSignAggregationSlot ||
+		action == ruler.ActionSignSyncCommitteeMessage ||
+		action == ruler.ActionSignSyncCommitteeSelectionProof ||
+		action == ruler.ActionSignSyncCommitteeContributionAndProof ||
+		action == ruler.ActionSignVoluntaryExit ||
+		action == ruler.ActionSignBLSToExecutionChange ||
+		action == ruler.ActionSignValidatorRegistration ||
+		action == ruler.ActionSignDeposit ||
+		action == ruler.ActionPauseSigning ||
+		action == ruler.ActionResumeSigning ||
+		action == ruler.ActionExportAccount ||
+		action == ruler.ActionRecoverAccount ||
+		action == ruler.ActionLockAccounts ||
+		action == ruler.ActionUnlockAccounts
 }
 
 // runRules runs a number of rules and returns a result.
@@ -103,112 +436,261 @@ func (s *Service) runRules(ctx context.Context,
 		return s.runRulesForMultipleBeaconAttestations(ctx, credentials, action, rulesData)
 	}
 
+	log := log
+	if credentials != nil {
+		log = log.With().Str("request_id", credentials.RequestID).Logger()
+	}
+
 	results := make([]rules.Result, len(rulesData))
 	for i := range rulesData {
 		results[i] = rules.UNKNOWN
 	}
+	cache := newMetadataCache(s, credentials)
 	for i := range rulesData {
+		if err := ctx.Err(); err != nil {
+			// Abandon the batch, failing the entries that have not been evaluated.
+			log.Warn().Err(err).Int("evaluated", i).Int("entries", len(rulesData)).Msg("Rules not run before deadline")
+			for j := i; j < len(rulesData); j++ {
+				results[j] = rules.FAILED
+			}
+			break
+		}
 		if rulesData[i] == nil {
 			continue
 		}
-		var name string
-		if rulesData[i].AccountName == "" {
-			name = rulesData[i].WalletName
-		} else {
-			name = fmt.Sprintf("%s/%s", rulesData[i].WalletName, rulesData[i].AccountName)
-		}
-		log := log.With().Str("account", name).Logger()
+		log := log.With().Str("account", rulesDataName(rulesData[i])).Logger()
 
-		metadata, err := s.assembleMetadata(ctx, credentials, rulesData[i].AccountName, rulesData[i].PubKey)
+		metadata, err := cache.assembleMetadata(ctx, rulesData[i].WalletName, rulesData[i].AccountName, rulesData[i].PubKey)
 		if err != nil {
 			log.Warn().Err(err).Msg("Failed to assemble metadata")
 			results[i] = rules.FAILED
 			continue
 		}
-		switch action {
-		case ruler.ActionSign:
-			rulesData, isExpectedType := rulesData[i].Data.(*rules.SignData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnSign(ctx, metadata, rulesData)
-		case ruler.ActionSignBeaconProposal:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.SignBeaconProposalData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnSignBeaconProposal(ctx, metadata, reqData)
-		case ruler.ActionSignBeaconAttestation:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.SignBeaconAttestationData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnSignBeaconAttestation(ctx, metadata, reqData)
-		case ruler.ActionAccessAccount:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.AccessAccountData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnListAccounts(ctx, metadata, reqData)
-		case ruler.ActionLockWallet:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.LockWalletData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnLockWallet(ctx, metadata, reqData)
-		case ruler.ActionUnlockWallet:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.UnlockWalletData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnUnlockWallet(ctx, metadata, reqData)
-		case ruler.ActionLockAccount:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.LockAccountData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnLockAccount(ctx, metadata, reqData)
-		case ruler.ActionUnlockAccount:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.UnlockAccountData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnUnlockAccount(ctx, metadata, reqData)
-		case ruler.ActionCreateAccount:
-			reqData, isExpectedType := rulesData[i].Data.(*rules.CreateAccountData)
-			if !isExpectedType {
-				log.Warn().Msg("Data not of expected type")
-				results[i] = rules.FAILED
-				continue
-			}
-			results[i] = s.rules.OnCreateAccount(ctx, metadata, reqData)
-		default:
-			log.Warn().Str("action", action).Msg("Unknown action")
-			results[i] = rules.FAILED
+		results[i] = s.runRule(ctx, log, action, metadata, rulesData[i])
+	}
+
+	return results
+}
+
+// runRule runs the rule for a single entry and returns its result.
+func (s *Service) runRule(ctx context.Context,
+	log zerolog.Logger,
+	action string,
+	metadata *rules.ReqMetadata,
+	rulesData *ruler.RulesData,
+) rules.Result {
+	result := rules.UNKNOWN
+	switch action {
+	case ruler.ActionSign:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
 		}
-		if results[i] == rules.UNKNOWN {
-			log.Error().Msg("Unknown result from rule")
-			results[i] = rules.FAILED
+		result = s.rules.OnSign(ctx, metadata, reqData)
+	case ruler.ActionSignBeaconProposal:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignBeaconProposalData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
 		}
+		result = s.rules.OnSignBeaconProposal(ctx, metadata, reqData)
+	case ruler.ActionSignBeaconAttestation:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignBeaconAttestationData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignBeaconAttestation(ctx, metadata, reqData)
+	case ruler.ActionSignRANDAOReveal:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignRANDAORevealData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignRANDAOReveal(ctx, metadata, reqData)
+	case ruler.ActionSignAggregateAndProof:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignAggregateAndProofData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignAggregateAndProof(ctx, metadata, reqData)
+	case ruler.ActionSignAggregationSlot:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignAggregationSlotData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignAggregationSlot(ctx, metadata, reqData)
+	case ruler.ActionSignSyncCommitteeMessage:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignSyncCommitteeMessageData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignSyncCommitteeMessage(ctx, metadata, reqData)
+	case ruler.ActionSignSyncCommitteeSelectionProof:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignSyncCommitteeSelectionProofData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignSyncCommitteeSelectionProof(ctx, metadata, reqData)
+	case ruler.ActionSignSyncCommitteeContributionAndProof:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignSyncCommitteeContributionAndProofData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignSyncCommitteeContributionAndProof(ctx, metadata, reqData)
+	case ruler.ActionSignVoluntaryExit:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignVoluntaryExitData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignVoluntaryExit(ctx, metadata, reqData)
+	case ruler.ActionSignBLSToExecutionChange:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignBLSToExecutionChangeData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignBLSToExecutionChange(ctx, metadata, reqData)
+	case ruler.ActionSignValidatorRegistration:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignValidatorRegistrationData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignValidatorRegistration(ctx, metadata, reqData)
+	case ruler.ActionSignDeposit:
+		reqData, isExpectedType := rulesData.Data.(*rules.SignDepositData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnSignDeposit(ctx, metadata, reqData)
+	case ruler.ActionAccessAccount:
+		reqData, isExpectedType := rulesData.Data.(*rules.AccessAccountData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnListAccounts(ctx, metadata, reqData)
+	case ruler.ActionLockWallet:
+		reqData, isExpectedType := rulesData.Data.(*rules.LockWalletData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnLockWallet(ctx, metadata, reqData)
+	case ruler.ActionUnlockWallet:
+		reqData, isExpectedType := rulesData.Data.(*rules.UnlockWalletData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnUnlockWallet(ctx, metadata, reqData)
+	case ruler.ActionLockAccount, ruler.ActionLockAccounts:
+		reqData, isExpectedType := rulesData.Data.(*rules.LockAccountData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnLockAccount(ctx, metadata, reqData)
+	case ruler.ActionUnlockAccount, ruler.ActionUnlockAccounts:
+		reqData, isExpectedType := rulesData.Data.(*rules.UnlockAccountData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnUnlockAccount(ctx, metadata, reqData)
+	case ruler.ActionPauseSigning:
+		reqData, isExpectedType := rulesData.Data.(*rules.PauseSigningData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnPauseSigning(ctx, metadata, reqData)
+	case ruler.ActionResumeSigning:
+		reqData, isExpectedType := rulesData.Data.(*rules.ResumeSigningData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnResumeSigning(ctx, metadata, reqData)
+	case ruler.ActionExportAccount:
+		reqData, isExpectedType := rulesData.Data.(*rules.ExportAccountData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnExportAccount(ctx, metadata, reqData)
+	case ruler.ActionRecoverAccount:
+		reqData, isExpectedType := rulesData.Data.(*rules.RecoverAccountData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnRecoverAccount(ctx, metadata, reqData)
+	case ruler.ActionCreateAccount:
+		reqData, isExpectedType := rulesData.Data.(*rules.CreateAccountData)
+		if !isExpectedType {
+			log.Warn().Msg("Data not of expected type")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
+			return rules.FAILED
+		}
+		result = s.rules.OnCreateAccount(ctx, metadata, reqData)
+	default:
+		log.Warn().Str("action", action).Msg("Unknown action")
+		result = rules.FAILED
+	}
+	if result == rules.UNKNOWN {
+		log.Error().Msg("Unknown result from rule")
+		result = rules.FAILED
 	}
 
-	return results
+	return result
+}
+
+// rulesDataName returns the name of the wallet or account to which the rules data refers.
+func rulesDataName(rulesData *ruler.RulesData) string {
+	if rulesData.AccountName == "" {
+		return rulesData.WalletName
+	}
+	return fmt.Sprintf("%s/%s", rulesData.WalletName, rulesData.AccountName)
+}
+
+// clientName returns the name of the client in the credentials, if present.
+func clientName(credentials *checker.Credentials) string {
+	if credentials == nil {
+		return ""
+	}
+	return credentials.Client
 }
 
 // runRulesForMultipleBeaconAttestations is the fast path for multisigning beacon attestations.
@@ -222,21 +704,21 @@ func (s *Service) runRulesForMultipleBeaconAttestations(ctx context.Context,
 		results[i] = rules.UNKNOWN
 	}
 
+	log := log
+	if credentials != nil {
+		log = log.With().Str("request_id", credentials.RequestID).Logger()
+	}
+
 	metadatas := make([]*rules.ReqMetadata, len(rulesData))
 	reqData := make([]*rules.SignBeaconAttestationData, len(rulesData))
+	cache := newMetadataCache(s, credentials)
 	var err error
 	for i := range rulesData {
-		var name string
-		if rulesData[i].AccountName == "" {
-			name = rulesData[i].WalletName
-		} else {
-			name = fmt.Sprintf("%s/%s", rulesData[i].WalletName, rulesData[i].AccountName)
-		}
-		log := log.With().Str("account", name).Logger()
+		log := log.With().Str("account", rulesDataName(rulesData[i])).Logger()
 
 		// We are strict here; any failure in metadata or data will result in an immediate return.
 		// This ensures that the later code is simplified, and user errors are picked up quickly.
-		metadatas[i], err = s.assembleMetadata(ctx, credentials, rulesData[i].AccountName, rulesData[i].PubKey)
+		metadatas[i], err = cache.assembleMetadata(ctx, rulesData[i].WalletName, rulesData[i].AccountName, rulesData[i].PubKey)
 		if err != nil {
 			log.Warn().Err(err).Msg("Failed to assemble metadata")
 			results[i] = rules.FAILED
@@ -245,16 +727,25 @@ func (s *Service) runRulesForMultipleBeaconAttestations(ctx context.Context,
 		data, isBeaconAttestationData := rulesData[i].Data.(*rules.SignBeaconAttestationData)
 		if !isBeaconAttestationData {
 			log.Warn().Msg("Data is not for signing beacon attestation")
+			rules.RecordReason(ctx, rules.ReasonInvalidRequest)
 			results[i] = rules.FAILED
 			return results
 		}
 		reqData[i] = data
 	}
 
+	if err := ctx.Err(); err != nil {
+		log.Warn().Err(err).Msg("Rules not run before deadline")
+		for i := range results {
+			results[i] = rules.FAILED
+		}
+		return results
+	}
+
 	return s.rules.OnSignBeaconAttestations(ctx, metadatas, reqData)
 }
 
-func (s *Service) assembleMetadata(ctx context.Context, credentials *checker.Credentials, accountName string, pubKey []byte) (*rules.ReqMetadata, error) {
+func (s *Service) assembleMetadata(ctx context.Context, credentials *checker.Credentials, walletName string, accountName string, pubKey []byte) (*rules.ReqMetadata, error) {
 	if credentials == nil {
 		return nil, errors.New("no credentials")
 	}
@@ -265,9 +756,51 @@ func (s *Service) assembleMetadata(ctx context.Context, credentials *checker.Cre
 	}
 
 	return &rules.ReqMetadata{
-		Account: accountName,
-		PubKey:  pubKey,
-		IP:      credentials.IP,
-		Client:  credentials.Client,
+		Wallet:    walletName,
+		Account:   accountName,
+		PubKey:    pubKey,
+		IP:        credentials.IP,
+		Client:    credentials.Client,
+		RequestID: credentials.RequestID,
 	}, nil
 }
+
+// metadataCache caches assembled metadata for the duration of a single run of the rules.
+// All entries in a run share the same credentials, so metadata is keyed by wallet and account, and public key.
+type metadataCache struct {
+	s           *Service
+	credentials *checker.Credentials
+	entries     map[string]map[string]*rules.ReqMetadata
+}
+
+// newMetadataCache creates a new metadata cache for the given credentials.
+func newMetadataCache(s *Service, credentials *checker.Credentials) *metadataCache {
+	return &metadataCache{
+		s:           s,
+		credentials: credentials,
+		entries:     make(map[string]map[string]*rules.ReqMetadata),
+	}
+}
+
+// assembleMetadata returns the metadata for the given wallet, account and public key, assembling it if not already cached.
+// Errors are not cached.
+func (c *metadataCache) assembleMetadata(ctx context.Context, walletName string, accountName string, pubKey []byte) (*rules.ReqMetadata, error) {
+	key := walletName + "/" + accountName
+	accountEntries, exists := c.entries[key]
+	if exists {
+		if metadata, exists := accountEntries[string(pubKey)]; exists {
+			return metadata, nil
+		}
+	}
+
+	metadata, err := c.s.assembleMetadata(ctx, c.credentials, walletName, accountName, pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		accountEntries = make(map[string]*rules.ReqMetadata)
+		c.entries[key] = accountEntries
+	}
+	accountEntries[string(pubKey)] = metadata
+	return metadata, nil
+}
@@ -0,0 +1,141 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	"github.com/attestantio/dirk/services/checker"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+const benchmarkBatchSize = 1024
+
+func benchmarkService(b *testing.B) *Service {
+	ctx := context.Background()
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(b, err)
+	service, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithLocker(locker),
+		WithRules(mockrules.New()),
+	)
+	require.NoError(b, err)
+	return service
+}
+
+// BenchmarkAssembleMetadata assembles metadata for a homogeneous batch without the cache.
+func BenchmarkAssembleMetadata(b *testing.B) {
+	ctx := context.Background()
+	service := benchmarkService(b)
+	credentials := &checker.Credentials{Client: "client1"}
+	pubKey := make([]byte, 48)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < benchmarkBatchSize; j++ {
+			if _, err := service.assembleMetadata(ctx, credentials, "wallet", "account", pubKey); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkAssembleMetadataCached assembles metadata for a homogeneous batch with the cache.
+func BenchmarkAssembleMetadataCached(b *testing.B) {
+	ctx := context.Background()
+	service := benchmarkService(b)
+	credentials := &checker.Credentials{Client: "client1"}
+	pubKey := make([]byte, 48)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache := newMetadataCache(service, credentials)
+		for j := 0; j < benchmarkBatchSize; j++ {
+			if _, err := cache.assembleMetadata(ctx, "wallet", "account", pubKey); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkRunRules runs the rules for a homogeneous batch of generic signing requests.
+func BenchmarkRunRules(b *testing.B) {
+	ctx := context.Background()
+	service := benchmarkService(b)
+	credentials := &checker.Credentials{Client: "client1"}
+	rulesData := make([]*ruler.RulesData, benchmarkBatchSize)
+	for i := range rulesData {
+		rulesData[i] = &ruler.RulesData{
+			WalletName:  "wallet",
+			AccountName: "account",
+			PubKey:      make([]byte, 48),
+			Data:        &rules.SignData{},
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.runRules(ctx, credentials, ruler.ActionSign, rulesData)
+	}
+}
+
+// singleRulesData returns a single generic signing request.
+func singleRulesData() []*ruler.RulesData {
+	return []*ruler.RulesData{
+		{
+			WalletName:  "wallet",
+			AccountName: "account",
+			PubKey:      make([]byte, 48),
+			Data:        &rules.SignData{},
+		},
+	}
+}
+
+// BenchmarkCheckAndRunRulesSingle checks and runs the rules for a single entry via the fast path.
+func BenchmarkCheckAndRunRulesSingle(b *testing.B) {
+	ctx := context.Background()
+	service := benchmarkService(b)
+	credentials := &checker.Credentials{Client: "client1"}
+	rulesData := singleRulesData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.checkAndRunRule(ctx, log, credentials, ruler.ActionSign, rulesData)
+	}
+}
+
+// BenchmarkCheckAndRunRulesSingleGeneral checks and runs the rules for a single entry via the general path.
+func BenchmarkCheckAndRunRulesSingleGeneral(b *testing.B) {
+	ctx := context.Background()
+	service := benchmarkService(b)
+	credentials := &checker.Credentials{Client: "client1"}
+	rulesData := singleRulesData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.checkAndRunMultipleRules(ctx, log, credentials, ruler.ActionSign, rulesData)
+	}
+}
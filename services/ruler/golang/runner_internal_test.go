@@ -0,0 +1,134 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package golang
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/checker"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleRuleParity(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	storagePath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(storagePath)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+	)
+	require.NoError(t, err)
+	service, err := New(ctx,
+		WithLocker(locker),
+		WithRules(testRules),
+	)
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{Client: "client1"}
+	pubKey := make([]byte, 48)
+	data := make([]byte, 32)
+	goodDomain := append([]byte{0x02}, make([]byte, 31)...)
+	attestationDomain := append([]byte{0x01}, make([]byte, 31)...)
+
+	tests := []struct {
+		name        string
+		credentials *checker.Credentials
+		action      string
+		data        *ruler.RulesData
+		result      rules.Result
+	}{
+		{
+			name:        "Nil",
+			credentials: credentials,
+			action:      ruler.ActionSign,
+			result:      rules.FAILED,
+		},
+		{
+			name:        "DataNil",
+			credentials: credentials,
+			action:      ruler.ActionSign,
+			data:        &ruler.RulesData{WalletName: "wallet", AccountName: "account", PubKey: pubKey},
+			result:      rules.FAILED,
+		},
+		{
+			name:        "PubKeyMissing",
+			credentials: credentials,
+			action:      ruler.ActionSign,
+			data:        &ruler.RulesData{WalletName: "wallet", AccountName: "account", Data: &rules.SignData{Domain: goodDomain, Data: data}},
+			result:      rules.FAILED,
+		},
+		{
+			name:   "CredentialsMissing",
+			action: ruler.ActionSign,
+			data:   &ruler.RulesData{WalletName: "wallet", AccountName: "account", PubKey: pubKey, Data: &rules.SignData{Domain: goodDomain, Data: data}},
+			result: rules.FAILED,
+		},
+		{
+			name:        "DataWrongType",
+			credentials: credentials,
+			action:      ruler.ActionSign,
+			data:        &ruler.RulesData{WalletName: "wallet", AccountName: "account", PubKey: pubKey, Data: &rules.AccessAccountData{}},
+			result:      rules.FAILED,
+		},
+		{
+			name:        "UnknownAction",
+			credentials: credentials,
+			action:      "unknown",
+			data:        &ruler.RulesData{WalletName: "wallet", AccountName: "account", PubKey: pubKey, Data: &rules.SignData{Domain: goodDomain, Data: data}},
+			result:      rules.FAILED,
+		},
+		{
+			name:        "Denied",
+			credentials: credentials,
+			action:      ruler.ActionSign,
+			data:        &ruler.RulesData{WalletName: "wallet", AccountName: "account", PubKey: pubKey, Data: &rules.SignData{Domain: attestationDomain, Data: data}},
+			result:      rules.DENIED,
+		},
+		{
+			name:        "Approved",
+			credentials: credentials,
+			action:      ruler.ActionSign,
+			data:        &ruler.RulesData{WalletName: "wallet", AccountName: "account", PubKey: pubKey, Data: &rules.SignData{Domain: goodDomain, Data: data}},
+			result:      rules.APPROVED,
+		},
+		{
+			name:        "ApprovedNoLocking",
+			credentials: credentials,
+			action:      ruler.ActionAccessAccount,
+			data:        &ruler.RulesData{WalletName: "wallet", AccountName: "account", Data: &rules.AccessAccountData{}},
+			result:      rules.APPROVED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rulesData := []*ruler.RulesData{test.data}
+			single := service.checkAndRunRule(ctx, log, test.credentials, test.action, rulesData)
+			general := service.checkAndRunMultipleRules(ctx, log, test.credentials, test.action, rulesData)
+			assert.Equal(t, general, single)
+			assert.Equal(t, []rules.Result{test.result}, single)
+		})
+	}
+}
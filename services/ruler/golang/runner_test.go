@@ -9,10 +9,14 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/attestantio/dirk/rules"
+	mockrules "github.com/attestantio/dirk/rules/mock"
 	standardrules "github.com/attestantio/dirk/rules/standard"
 	"github.com/attestantio/dirk/services/checker"
+	staticchecker "github.com/attestantio/dirk/services/checker/static"
+	"github.com/attestantio/dirk/services/locker"
 	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
 	"github.com/attestantio/dirk/services/ruler"
 	"github.com/attestantio/dirk/services/ruler/golang"
@@ -431,6 +435,200 @@ func TestRunRules(t *testing.T) {
 			},
 			results: []rules.Result{rules.APPROVED, rules.APPROVED},
 		},
+		{
+			name:   "SignRANDAORevealDataBad",
+			action: ruler.ActionSignRANDAOReveal,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "SignRANDAORevealGood",
+			action: ruler.ActionSignRANDAOReveal,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.SignRANDAORevealData{
+						Domain: []byte{
+							0x02, 0x00, 0x00, 0x00, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+							0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						},
+						Epoch: 5,
+					},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results: []rules.Result{rules.APPROVED},
+		},
+		{
+			name:   "SignAggregateAndProofDataBad",
+			action: ruler.ActionSignAggregateAndProof,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "SignAggregateAndProofGood",
+			action: ruler.ActionSignAggregateAndProof,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.SignAggregateAndProofData{
+						Domain: []byte{
+							0x06, 0x00, 0x00, 0x00, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+							0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						},
+						AggregatorIndex: 6,
+						Slot:            5,
+						AggregateRoot: []byte{
+							0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+							0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39, 0x3a, 0x3b, 0x3c, 0x3d, 0x3e, 0x3f,
+						},
+					},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results: []rules.Result{rules.APPROVED},
+		},
+		{
+			name:   "SignAggregationSlotDataBad",
+			action: ruler.ActionSignAggregationSlot,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "SignAggregationSlotGood",
+			action: ruler.ActionSignAggregationSlot,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.SignAggregationSlotData{
+						Domain: []byte{
+							0x05, 0x00, 0x00, 0x00, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+							0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						},
+						Slot: 5,
+					},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results: []rules.Result{rules.APPROVED},
+		},
+		{
+			name:   "SignDepositDataBad",
+			action: ruler.ActionSignDeposit,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "SignDepositGood",
+			action: ruler.ActionSignDeposit,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.SignDepositData{
+						Domain: []byte{
+							0x03, 0x00, 0x00, 0x00, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+							0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						},
+						WithdrawalCredentials: make([]byte, 32),
+						Amount:                32000000000,
+					},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "sign",
+			},
+			results: []rules.Result{rules.APPROVED},
+		},
 		{
 			name:   "SignBeaconProposalData2Bad",
 			action: ruler.ActionSignBeaconProposal,
@@ -913,92 +1111,259 @@ func TestRunRules(t *testing.T) {
 			},
 			results: []rules.Result{rules.APPROVED, rules.APPROVED},
 		},
-	}
-
-	ctx := context.Background()
-
-	locker, err := syncmaplocker.New(ctx)
-	require.NoError(t, err)
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			capture := logger.NewLogCapture()
-			storagePath, err := ioutil.TempDir("", "")
-			require.NoError(t, err)
-			defer os.RemoveAll(storagePath)
-			testRules, err := standardrules.New(ctx,
-				standardrules.WithStoragePath(storagePath),
-			)
-			require.NoError(t, err)
-			service, err := golang.New(ctx,
-				golang.WithLocker(locker),
-				golang.WithRules(testRules))
-			require.NoError(t, err)
-			results := service.RunRules(ctx, test.credentials, test.action, test.data)
-			require.Equal(t, test.results, results)
-			if test.logEntry != "" {
-				capture.AssertHasEntry(t, test.logEntry)
-			}
-		})
-	}
-}
-
-func TestRunRulesSignBeaconAttestationSoak(t *testing.T) {
-	zerolog.SetGlobalLevel(zerolog.Disabled)
-	ctx := context.Background()
-
-	locker, err := syncmaplocker.New(ctx)
-	require.NoError(t, err)
-
-	storagePath, err := ioutil.TempDir("", "")
-	require.NoError(t, err)
-	defer os.RemoveAll(storagePath)
-	testRules, err := standardrules.New(ctx,
-		standardrules.WithStoragePath(storagePath),
-	)
-	require.NoError(t, err)
-	service, err := golang.New(ctx,
-		golang.WithLocker(locker),
-		golang.WithRules(testRules))
-	require.NoError(t, err)
-
-	// The soak test will create a number of goroutines that will all attempt to sign an attestation at the same time,
-	// albeit with different data.  This should result in 1 success and the result denied.
-
-	require.NoError(t, e2types.InitBLS())
-	privKey, err := e2types.GenerateBLSPrivateKey()
-	require.NoError(t, err)
-	pubKey := privKey.PublicKey().Marshal()
-
-	// p is parallelism.
-	p := 64
-	runtime.GOMAXPROCS(2 * p)
-	requests := make([]*rules.SignBeaconAttestationData, p)
-	for i := 0; i < p; i++ {
-		requests[i] = &rules.SignBeaconAttestationData{
-			Domain:          []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
-			CommitteeIndex:  uint64(i),
-			BeaconBlockRoot: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
-			Source: &rules.Checkpoint{
-				Epoch: 5,
-				Root:  []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{
+			name:   "PauseSigningDataBad",
+			action: ruler.ActionPauseSigning,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
 			},
-			Target: &rules.Checkpoint{
-				Epoch: 6,
-				Root:  []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			credentials: &checker.Credentials{
+				Client: "admin",
 			},
-		}
-	}
-
-	// Run for 256 slots.
-	for curSlot := uint64(10000); curSlot < uint64(10256); curSlot++ {
-		// Set up the slot-specific data.
-		for i := 0; i < p; i++ {
-			requests[i].Slot = curSlot
-			requests[i].Source.Epoch++
-			requests[i].Target.Epoch++
-		}
-
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "PauseSigningGood",
+			action: ruler.ActionPauseSigning,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.PauseSigningData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "admin",
+			},
+			results: []rules.Result{rules.APPROVED},
+		},
+		{
+			name:   "ResumeSigningDataBad",
+			action: ruler.ActionResumeSigning,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "admin",
+			},
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "ResumeSigningGood",
+			action: ruler.ActionResumeSigning,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.ResumeSigningData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "admin",
+			},
+			results: []rules.Result{rules.APPROVED},
+		},
+		{
+			name:   "ExportAccountDataBad",
+			action: ruler.ActionExportAccount,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "admin",
+			},
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "ExportAccountNotPaused",
+			action: ruler.ActionExportAccount,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.ExportAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "admin",
+			},
+			results: []rules.Result{rules.DENIED},
+		},
+		{
+			name:   "RecoverAccountDataBad",
+			action: ruler.ActionRecoverAccount,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.AccessAccountData{},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "admin",
+			},
+			results:  []rules.Result{rules.FAILED},
+			logEntry: "Data not of expected type",
+		},
+		{
+			name:   "RecoverAccountNotPaused",
+			action: ruler.ActionRecoverAccount,
+			data: []*ruler.RulesData{
+				{
+					WalletName:  "wallet",
+					AccountName: "account",
+					PubKey: []byte{
+						0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+						0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17, 0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+						0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29, 0x2a, 0x2b, 0x2c, 0x2d, 0x2e, 0x2f,
+					},
+					Data: &rules.RecoverAccountData{Coordinator: 2},
+				},
+			},
+			credentials: &checker.Credentials{
+				Client: "admin",
+			},
+			results: []rules.Result{rules.DENIED},
+		},
+	}
+
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			capture := logger.NewLogCapture()
+			storagePath, err := ioutil.TempDir("", "")
+			require.NoError(t, err)
+			defer os.RemoveAll(storagePath)
+			testRules, err := standardrules.New(ctx,
+				standardrules.WithStoragePath(storagePath),
+				standardrules.WithDepositPolicies(map[string]*standardrules.DepositPolicy{
+					"account": {WithdrawalCredentials: [][]byte{make([]byte, 32)}},
+				}),
+			)
+			require.NoError(t, err)
+			service, err := golang.New(ctx,
+				golang.WithLocker(locker),
+				golang.WithRules(testRules))
+			require.NoError(t, err)
+			results := service.RunRules(ctx, test.credentials, test.action, test.data)
+			require.Equal(t, test.results, results)
+			if test.logEntry != "" {
+				capture.AssertHasEntry(t, test.logEntry)
+			}
+		})
+	}
+}
+
+func TestRunRulesSignBeaconAttestationSoak(t *testing.T) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	storagePath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(storagePath)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+	)
+	require.NoError(t, err)
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(testRules))
+	require.NoError(t, err)
+
+	// The soak test will create a number of goroutines that will all attempt to sign an attestation at the same time,
+	// albeit with different data.  This should result in 1 success and the result denied.
+
+	require.NoError(t, e2types.InitBLS())
+	privKey, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	pubKey := privKey.PublicKey().Marshal()
+
+	// p is parallelism.
+	p := 64
+	runtime.GOMAXPROCS(2 * p)
+	requests := make([]*rules.SignBeaconAttestationData, p)
+	for i := 0; i < p; i++ {
+		requests[i] = &rules.SignBeaconAttestationData{
+			Domain:          []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			CommitteeIndex:  uint64(i),
+			BeaconBlockRoot: []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			Source: &rules.Checkpoint{
+				Epoch: 5,
+				Root:  []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			},
+			Target: &rules.Checkpoint{
+				Epoch: 6,
+				Root:  []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			},
+		}
+	}
+
+	// Run for 256 slots.
+	for curSlot := uint64(10000); curSlot < uint64(10256); curSlot++ {
+		// Set up the slot-specific data.
+		for i := 0; i < p; i++ {
+			requests[i].Slot = curSlot
+			requests[i].Source.Epoch++
+			requests[i].Target.Epoch++
+		}
+
 		// Set up the counts.
 		approved := uint32(0)
 		denied := uint32(0)
@@ -1124,3 +1489,726 @@ func TestRunRulesSignBeaconProposalSoak(t *testing.T) {
 		assert.Equal(t, uint32(p-1), denied, fmt.Sprintf("Incorrect denials for slot %d", curSlot))
 	}
 }
+
+func TestRunRulesAtomicBatch(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	storagePath, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(storagePath)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(storagePath),
+	)
+	require.NoError(t, err)
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(testRules))
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{
+		Client: "client-test01",
+	}
+	data := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	goodDomain := []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	// Generic signing of attestations is always denied.
+	badDomain := []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	rulesData := func(domains ...[]byte) []*ruler.RulesData {
+		res := make([]*ruler.RulesData, len(domains))
+		for i := range domains {
+			pubKey := make([]byte, 48)
+			pubKey[0] = byte(i)
+			res[i] = &ruler.RulesData{
+				WalletName:  "Test wallet",
+				AccountName: fmt.Sprintf("Test account %d", i),
+				PubKey:      pubKey,
+				Data: &rules.SignData{
+					Domain: domains[i],
+					Data:   data,
+				},
+			}
+		}
+		return res
+	}
+
+	tests := []struct {
+		name    string
+		data    []*ruler.RulesData
+		opts    []ruler.RunOption
+		results []rules.Result
+	}{
+		{
+			name:    "AllApproved",
+			data:    rulesData(goodDomain, goodDomain),
+			results: []rules.Result{rules.APPROVED, rules.APPROVED},
+		},
+		{
+			name:    "AllApprovedAtomic",
+			data:    rulesData(goodDomain, goodDomain),
+			opts:    []ruler.RunOption{ruler.WithAtomicBatch()},
+			results: []rules.Result{rules.APPROVED, rules.APPROVED},
+		},
+		{
+			name:    "OneDenied",
+			data:    rulesData(goodDomain, badDomain, goodDomain),
+			results: []rules.Result{rules.APPROVED, rules.DENIED, rules.APPROVED},
+		},
+		{
+			name:    "OneDeniedAtomic",
+			data:    rulesData(goodDomain, badDomain, goodDomain),
+			opts:    []ruler.RunOption{ruler.WithAtomicBatch()},
+			results: []rules.Result{rules.DENIED, rules.DENIED, rules.DENIED},
+		},
+
+		{
+			name: "InvalidEntryAtomic",
+			data: append(rulesData(goodDomain), &ruler.RulesData{
+				WalletName:  "Test wallet",
+				AccountName: "Test account",
+			}),
+			opts:    []ruler.RunOption{ruler.WithAtomicBatch()},
+			results: []rules.Result{rules.FAILED, rules.FAILED},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := service.RunRules(ctx, credentials, ruler.ActionSign, test.data, test.opts...)
+			assert.Equal(t, test.results, results)
+		})
+	}
+}
+
+// resultRules returns a fixed result when signing generic data.
+type resultRules struct {
+	*mockrules.Service
+	result rules.Result
+}
+
+func (r *resultRules) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
+	return r.result
+}
+
+func TestRunRulesResultMapping(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		result rules.Result
+		mapped rules.Result
+	}{
+		{
+			name:   "Unknown",
+			result: rules.UNKNOWN,
+			mapped: rules.FAILED,
+		},
+		{
+			name:   "Approved",
+			result: rules.APPROVED,
+			mapped: rules.APPROVED,
+		},
+		{
+			name:   "Denied",
+			result: rules.DENIED,
+			mapped: rules.DENIED,
+		},
+		{
+			name:   "Failed",
+			result: rules.FAILED,
+			mapped: rules.FAILED,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, err := golang.New(ctx,
+				golang.WithLocker(locker),
+				golang.WithRules(&resultRules{Service: mockrules.New(), result: test.result}),
+			)
+			require.NoError(t, err)
+
+			results := service.RunRules(ctx, &checker.Credentials{Client: "client-test01"}, ruler.ActionSign, []*ruler.RulesData{
+				{
+					WalletName:  "Test wallet",
+					AccountName: "Test account",
+					PubKey:      make([]byte, 48),
+					Data:        &rules.SignData{},
+				},
+			})
+			assert.Equal(t, []rules.Result{test.mapped}, results)
+		})
+	}
+}
+
+func TestRunRulesReadOnly(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	readOnlyChecker, err := staticchecker.New(ctx, staticchecker.WithReadOnlyClients([]string{"monitor"}))
+	require.NoError(t, err)
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(mockrules.New()),
+		golang.WithChecker(readOnlyChecker),
+	)
+	require.NoError(t, err)
+
+	accessData := []*ruler.RulesData{
+		{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			Data:        &rules.AccessAccountData{},
+		},
+	}
+	signData := []*ruler.RulesData{
+		{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			PubKey:      make([]byte, 48),
+			Data:        &rules.SignData{},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		client  string
+		action  string
+		data    []*ruler.RulesData
+		results []rules.Result
+	}{
+		{
+			name:    "ReadOnlyAccess",
+			client:  "monitor",
+			action:  ruler.ActionAccessAccount,
+			data:    accessData,
+			results: []rules.Result{rules.APPROVED},
+		},
+		{
+			name:    "ReadOnlySign",
+			client:  "monitor",
+			action:  ruler.ActionSign,
+			data:    signData,
+			results: []rules.Result{rules.DENIED},
+		},
+		{
+			name:   "ReadOnlyLockWallet",
+			client: "monitor",
+			action: ruler.ActionLockWallet,
+			data: []*ruler.RulesData{
+				{
+					WalletName: "Test wallet",
+					Data:       &rules.LockWalletData{},
+				},
+			},
+			results: []rules.Result{rules.DENIED},
+		},
+		{
+			name:    "Sign",
+			client:  "client1",
+			action:  ruler.ActionSign,
+			data:    signData,
+			results: []rules.Result{rules.APPROVED},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := service.RunRules(ctx, &checker.Credentials{Client: test.client}, test.action, test.data)
+			assert.Equal(t, test.results, results)
+		})
+	}
+}
+
+// countingRules are rules that count the number of sign and create account requests they see.
+type countingRules struct {
+	*mockrules.Service
+	calls int
+}
+
+func (r *countingRules) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
+	r.calls++
+	return rules.APPROVED
+}
+
+func (r *countingRules) OnCreateAccount(ctx context.Context, metadata *rules.ReqMetadata, req *rules.CreateAccountData) rules.Result {
+	r.calls++
+	return rules.APPROVED
+}
+
+func TestRunRulesClientActions(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	actionsChecker, err := staticchecker.New(ctx, staticchecker.WithClientActions(map[string][]string{
+		"signer": {ruler.ActionSign},
+	}))
+	require.NoError(t, err)
+	testRules := &countingRules{Service: mockrules.New()}
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(testRules),
+		golang.WithChecker(actionsChecker),
+	)
+	require.NoError(t, err)
+
+	signData := []*ruler.RulesData{
+		{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			PubKey:      make([]byte, 48),
+			Data:        &rules.SignData{},
+		},
+	}
+	createAccountData := []*ruler.RulesData{
+		{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			Data:        &rules.CreateAccountData{},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		client  string
+		action  string
+		data    []*ruler.RulesData
+		results []rules.Result
+		calls   int
+	}{
+		{
+			name:    "SignerSign",
+			client:  "signer",
+			action:  ruler.ActionSign,
+			data:    signData,
+			results: []rules.Result{rules.APPROVED},
+			calls:   1,
+		},
+		{
+			name:    "SignerCreateAccount",
+			client:  "signer",
+			action:  ruler.ActionCreateAccount,
+			data:    createAccountData,
+			results: []rules.Result{rules.DENIED},
+			calls:   0,
+		},
+		{
+			name:    "UnlistedCreateAccount",
+			client:  "client1",
+			action:  ruler.ActionCreateAccount,
+			data:    createAccountData,
+			results: []rules.Result{rules.APPROVED},
+			calls:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testRules.calls = 0
+			results := service.RunRules(ctx, &checker.Credentials{Client: test.client}, test.action, test.data)
+			assert.Equal(t, test.results, results)
+			assert.Equal(t, test.calls, testRules.calls)
+		})
+	}
+}
+
+func TestRunRulesDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(testRules),
+	)
+	require.NoError(t, err)
+
+	credentials := &checker.Credentials{Client: "client-test01"}
+	pubKey := make([]byte, 48)
+	proposal := []*ruler.RulesData{
+		{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			PubKey:      pubKey,
+			Data: &rules.SignBeaconProposalData{
+				Domain:     make([]byte, 32),
+				Slot:       10,
+				ParentRoot: make([]byte, 32),
+				StateRoot:  make([]byte, 32),
+				BodyRoot:   make([]byte, 32),
+			},
+		},
+	}
+	protection := func() map[[48]byte]*rules.SlashingProtection {
+		protection, err := testRules.ExportSlashingProtection(ctx)
+		require.NoError(t, err)
+		return protection
+	}
+
+	// Dry runs are approved but leave the slashing store unchanged, so can be repeated.
+	for i := 0; i < 2; i++ {
+		results := service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, proposal, ruler.WithDryRun())
+		require.Equal(t, []rules.Result{rules.APPROVED}, results)
+		require.Empty(t, protection())
+	}
+
+	// A normal run advances the slashing store.
+	results := service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, proposal)
+	require.Equal(t, []rules.Result{rules.APPROVED}, results)
+	var key [48]byte
+	copy(key[:], pubKey)
+	require.Equal(t, int64(10), protection()[key].HighestProposedSlot)
+
+	// A dry run now reports the request as slashable.
+	results = service.RunRules(ctx, credentials, ruler.ActionSignBeaconProposal, proposal, ruler.WithDryRun())
+	require.Equal(t, []rules.Result{rules.DENIED}, results)
+}
+
+// slowRules are rules that take a fixed time to approve each request.
+type slowRules struct {
+	*mockrules.Service
+	delay time.Duration
+}
+
+func (r *slowRules) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
+	time.Sleep(r.delay)
+	return rules.APPROVED
+}
+
+func TestRunRulesTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	_, err = golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(mockrules.New()),
+		golang.WithTimeout(-1*time.Second),
+	)
+	require.EqualError(t, err, "problem with parameters: timeout cannot be negative")
+
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(&slowRules{Service: mockrules.New(), delay: 50 * time.Millisecond}),
+		golang.WithTimeout(75*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	rulesData := make([]*ruler.RulesData, 4)
+	for i := range rulesData {
+		pubKey := make([]byte, 48)
+		pubKey[0] = byte(i)
+		rulesData[i] = &ruler.RulesData{
+			WalletName:  "Test wallet",
+			AccountName: fmt.Sprintf("Test account %d", i),
+			PubKey:      pubKey,
+			Data:        &rules.SignData{},
+		}
+	}
+
+	// The first two entries are evaluated before the deadline; the remainder are not.
+	results := service.RunRules(ctx, &checker.Credentials{Client: "client-test01"}, ruler.ActionSign, rulesData)
+	require.Equal(t, []rules.Result{rules.APPROVED, rules.APPROVED, rules.FAILED, rules.FAILED}, results)
+
+	// A caller's earlier deadline takes precedence.
+	deadlineCtx, cancel := context.WithTimeout(ctx, 25*time.Millisecond)
+	defer cancel()
+	results = service.RunRules(deadlineCtx, &checker.Credentials{Client: "client-test01"}, ruler.ActionSign, rulesData)
+	require.Equal(t, []rules.Result{rules.APPROVED, rules.FAILED, rules.FAILED, rules.FAILED}, results)
+}
+
+// leaseLocker is a locker that issues fencing tokens, and can lose its locks as soon as they are acquired.
+type leaseLocker struct {
+	*syncmaplocker.Service
+	lose bool
+}
+
+func (l *leaseLocker) LockWithHolder(ctx context.Context, key [48]byte, action string, client string, lost func()) (uint64, error) {
+	if _, err := l.Service.LockWithHolder(ctx, key, action, client, nil); err != nil {
+		return 0, err
+	}
+	if l.lose {
+		lost()
+	}
+	return uint64(key[0]) + 10, nil
+}
+
+// fencingRules records the fencing tokens with which rules are run.
+type fencingRules struct {
+	*mockrules.Service
+	mu     sync.Mutex
+	tokens []uint64
+}
+
+func (r *fencingRules) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
+	var key [48]byte
+	copy(key[:], metadata.PubKey)
+	r.mu.Lock()
+	r.tokens = append(r.tokens, locker.FencingToken(ctx, key))
+	r.mu.Unlock()
+	return rules.APPROVED
+}
+
+func TestRunRulesLease(t *testing.T) {
+	ctx := context.Background()
+
+	rulesData := make([]*ruler.RulesData, 2)
+	for i := range rulesData {
+		pubKey := make([]byte, 48)
+		pubKey[0] = byte(i)
+		rulesData[i] = &ruler.RulesData{
+			WalletName:  "Test wallet",
+			AccountName: fmt.Sprintf("Test account %d", i),
+			PubKey:      pubKey,
+			Data:        &rules.SignData{},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		lose    bool
+		entries int
+		results []rules.Result
+		tokens  []uint64
+	}{
+		{
+			name:    "Single",
+			entries: 1,
+			results: []rules.Result{rules.APPROVED},
+			tokens:  []uint64{10},
+		},
+		{
+			name:    "Multiple",
+			entries: 2,
+			results: []rules.Result{rules.APPROVED, rules.APPROVED},
+			tokens:  []uint64{10, 11},
+		},
+		{
+			name:    "SingleLost",
+			lose:    true,
+			entries: 1,
+			results: []rules.Result{rules.FAILED},
+		},
+		{
+			name:    "MultipleLost",
+			lose:    true,
+			entries: 2,
+			results: []rules.Result{rules.FAILED, rules.FAILED},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			local, err := syncmaplocker.New(ctx)
+			require.NoError(t, err)
+			rulesSvc := &fencingRules{Service: mockrules.New()}
+			service, err := golang.New(ctx,
+				golang.WithLocker(&leaseLocker{Service: local, lose: test.lose}),
+				golang.WithRules(rulesSvc),
+			)
+			require.NoError(t, err)
+
+			results := service.RunRules(ctx, &checker.Credentials{Client: "client-test01"}, ruler.ActionSign, rulesData[:test.entries])
+			require.Equal(t, test.results, results)
+			if test.tokens != nil {
+				require.ElementsMatch(t, test.tokens, rulesSvc.tokens)
+			}
+		})
+	}
+}
+
+func TestRunRulesLenientData(t *testing.T) {
+	ctx := context.Background()
+
+	signData := func(pubKeyByte byte) *ruler.RulesData {
+		pubKey := make([]byte, 48)
+		pubKey[0] = pubKeyByte
+		return &ruler.RulesData{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			PubKey:      pubKey,
+			Data:        &rules.SignData{},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		lenient bool
+		data    []*ruler.RulesData
+		results []rules.Result
+		calls   int
+	}{
+		{
+			name:    "StrictEmpty",
+			data:    []*ruler.RulesData{},
+			results: []rules.Result{rules.FAILED},
+		},
+		{
+			name:    "LenientEmpty",
+			lenient: true,
+			data:    []*ruler.RulesData{},
+			results: []rules.Result{},
+		},
+		{
+			name:    "StrictNilEntry",
+			data:    []*ruler.RulesData{signData(1), nil, signData(2)},
+			results: []rules.Result{rules.UNKNOWN, rules.FAILED, rules.UNKNOWN},
+		},
+		{
+			name:    "LenientNilEntry",
+			lenient: true,
+			data:    []*ruler.RulesData{signData(1), nil, signData(2)},
+			results: []rules.Result{rules.APPROVED, rules.FAILED, rules.APPROVED},
+			calls:   2,
+		},
+		{
+			name:    "StrictNilData",
+			data:    []*ruler.RulesData{signData(1), {WalletName: "Test wallet", AccountName: "Test account"}},
+			results: []rules.Result{rules.UNKNOWN, rules.FAILED},
+		},
+		{
+			name:    "LenientNilData",
+			lenient: true,
+			data:    []*ruler.RulesData{signData(1), {WalletName: "Test wallet", AccountName: "Test account"}},
+			results: []rules.Result{rules.APPROVED, rules.FAILED},
+			calls:   1,
+		},
+		{
+			name:    "LenientAllNil",
+			lenient: true,
+			data:    []*ruler.RulesData{nil, nil},
+			results: []rules.Result{rules.FAILED, rules.FAILED},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			locker, err := syncmaplocker.New(ctx)
+			require.NoError(t, err)
+			testRules := &countingRules{Service: mockrules.New()}
+			service, err := golang.New(ctx,
+				golang.WithLocker(locker),
+				golang.WithRules(testRules),
+				golang.WithLenientData(test.lenient),
+			)
+			require.NoError(t, err)
+
+			results := service.RunRules(ctx, &checker.Credentials{Client: "client1"}, ruler.ActionSign, test.data)
+			require.Equal(t, test.results, results)
+			require.Equal(t, test.calls, testRules.calls)
+		})
+	}
+}
+
+// metadataRules captures the metadata of the last request.
+type metadataRules struct {
+	*mockrules.Service
+	metadata *rules.ReqMetadata
+}
+
+func (r *metadataRules) OnLockWallet(ctx context.Context, metadata *rules.ReqMetadata, req *rules.LockWalletData) rules.Result {
+	r.metadata = metadata
+	return rules.APPROVED
+}
+
+func (r *metadataRules) OnSign(ctx context.Context, metadata *rules.ReqMetadata, req *rules.SignData) rules.Result {
+	r.metadata = metadata
+	return rules.APPROVED
+}
+
+func TestRunRulesMetadataWallet(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	testRules := &metadataRules{Service: mockrules.New()}
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(testRules),
+	)
+	require.NoError(t, err)
+	credentials := &checker.Credentials{Client: "client1"}
+
+	// Wallet requests have no account.
+	results := service.RunRules(ctx, credentials, ruler.ActionLockWallet, []*ruler.RulesData{
+		{
+			WalletName: "Test wallet",
+			Data:       &rules.LockWalletData{},
+		},
+	})
+	require.Equal(t, []rules.Result{rules.APPROVED}, results)
+	require.Equal(t, "Test wallet", testRules.metadata.Wallet)
+	require.Equal(t, "", testRules.metadata.Account)
+	require.Equal(t, []string{"Test wallet/", rules.GlobalPolicyKey}, testRules.metadata.PolicyKeys())
+
+	results = service.RunRules(ctx, credentials, ruler.ActionSign, []*ruler.RulesData{
+		{
+			WalletName:  "Test wallet",
+			AccountName: "Test account",
+			PubKey:      make([]byte, 48),
+			Data:        &rules.SignData{},
+		},
+	})
+	require.Equal(t, []rules.Result{rules.APPROVED}, results)
+	require.Equal(t, "Test wallet", testRules.metadata.Wallet)
+	require.Equal(t, "Test account", testRules.metadata.Account)
+	require.Equal(t, []string{"Test account", "Test wallet/", rules.GlobalPolicyKey}, testRules.metadata.PolicyKeys())
+}
+
+func TestRunRulesMaxBatchSize(t *testing.T) {
+	ctx := context.Background()
+
+	locker, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+
+	_, err = golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(mockrules.New()),
+		golang.WithMaxBatchSize(-1),
+	)
+	require.EqualError(t, err, "problem with parameters: max batch size cannot be negative")
+
+	service, err := golang.New(ctx,
+		golang.WithLocker(locker),
+		golang.WithRules(mockrules.New()),
+		golang.WithMaxBatchSize(2),
+	)
+	require.NoError(t, err)
+
+	rulesData := func(entries int) []*ruler.RulesData {
+		data := make([]*ruler.RulesData, entries)
+		for i := range data {
+			pubKey := make([]byte, 48)
+			pubKey[0] = byte(i)
+			data[i] = &ruler.RulesData{
+				WalletName:  "Test wallet",
+				AccountName: fmt.Sprintf("Test account %d", i),
+				PubKey:      pubKey,
+				Data:        &rules.SignData{},
+			}
+		}
+		return data
+	}
+	credentials := &checker.Credentials{Client: "client-test01"}
+
+	// A batch at the limit is evaluated.
+	results := service.RunRules(ctx, credentials, ruler.ActionSign, rulesData(2))
+	require.Equal(t, []rules.Result{rules.APPROVED, rules.APPROVED}, results)
+
+	// A batch over the limit is refused in its entirety.
+	results = service.RunRules(ctx, credentials, ruler.ActionSign, rulesData(3))
+	require.Equal(t, []rules.Result{rules.FAILED, rules.FAILED, rules.FAILED}, results)
+}
@@ -15,8 +15,12 @@ package golang
 
 import (
 	"context"
+	"time"
 
 	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/auditor"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/consensus"
 	"github.com/attestantio/dirk/services/locker"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/pkg/errors"
@@ -26,9 +30,15 @@ import (
 
 // Service is the ruler service.
 type Service struct {
-	monitor metrics.RulerMonitor
-	locker  locker.Service
-	rules   rules.Service
+	monitor   metrics.RulerMonitor
+	locker    locker.Service
+	rules     rules.Service
+	auditor   auditor.Service
+	consensus consensus.Service
+	checker   checker.Service
+	timeout   time.Duration
+	lenient   bool
+	maxBatch  int
 }
 
 // module-wide log.
@@ -48,9 +58,15 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}
 
 	s := &Service{
-		monitor: parameters.monitor,
-		locker:  parameters.locker,
-		rules:   parameters.rules,
+		monitor:   parameters.monitor,
+		locker:    parameters.locker,
+		rules:     parameters.rules,
+		auditor:   parameters.auditor,
+		consensus: parameters.consensus,
+		checker:   parameters.checker,
+		timeout:   parameters.timeout,
+		lenient:   parameters.lenient,
+		maxBatch:  parameters.maxBatch,
 	}
 
 	return s, nil
@@ -0,0 +1,129 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"time"
+
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel   zerolog.Level
+	address    string
+	timeout    time.Duration
+	failOpen   bool
+	ruler      ruler.Service
+	clientCert []byte
+	clientKey  []byte
+	caCert     []byte
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithAddress sets the address of the policy service.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithTimeout sets the maximum time to wait for the policy service to respond.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithFailOpen sets the ruler to rely on the local ruler alone if the policy service cannot be consulted,
+// rather than failing the request.
+func WithFailOpen(failOpen bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.failOpen = failOpen
+	})
+}
+
+// WithRuler sets the local ruler that is run for requests approved by the policy service.
+func WithRuler(ruler ruler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.ruler = ruler
+	})
+}
+
+// WithClientCert sets the client certificate presented to the policy service.
+func WithClientCert(clientCert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientCert = clientCert
+	})
+}
+
+// WithClientKey sets the client key for the client certificate.
+func WithClientKey(clientKey []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clientKey = clientKey
+	})
+}
+
+// WithCACert sets the CA certificate used to verify the policy service.
+func WithCACert(caCert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = caCert
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		timeout:  time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	if parameters.ruler == nil {
+		return nil, errors.New("no ruler specified")
+	}
+	if (len(parameters.clientCert) == 0) != (len(parameters.clientKey) == 0) {
+		return nil, errors.New("client certificate and key must be specified together")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,212 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/pkg/errors"
+)
+
+// EvaluateMethod is the full name of the method called on the policy service.
+// There is no protobuf definition for this service; requests and responses carry JSON
+// documents in well-known wrapper types, so policy services require no generated code.
+const EvaluateMethod = "/dirk.ruler.v1.PolicyService/Evaluate"
+
+// Results returned by the policy service.
+const (
+	ResultApproved = "APPROVED"
+	ResultDenied   = "DENIED"
+	ResultFailed   = "FAILED"
+)
+
+// EvaluateRequest is the request sent to the policy service.
+type EvaluateRequest struct {
+	// Action is the action to evaluate, as per the ruler actions.
+	Action string `json:"action"`
+	// Client is the client making the request.
+	Client string `json:"client"`
+	// IP is the originating IP address of the request.
+	IP string `json:"ip,omitempty"`
+	// RequestID is the ID of the request.
+	RequestID string `json:"request_id,omitempty"`
+	// Entries are the entries to evaluate.
+	Entries []*EvaluateEntry `json:"entries"`
+	// AtomicBatch is true if the entries will only be acted upon if all of them are approved.
+	AtomicBatch bool `json:"atomic_batch,omitempty"`
+	// DryRun is true if the request is a dry run, and will not be acted upon.
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// EvaluateEntry is an entry in a request sent to the policy service.
+type EvaluateEntry struct {
+	// Wallet is the name of the wallet.
+	Wallet string `json:"wallet"`
+	// Account is the name of the account.
+	Account string `json:"account"`
+	// PubKey is the public key of the account, if known.
+	PubKey []byte `json:"pubkey,omitempty"`
+	// Data is the action-specific data of the entry.
+	Data interface{} `json:"data"`
+}
+
+// EvaluateResponse is the response returned by the policy service.
+type EvaluateResponse struct {
+	// Results are the results for each entry in the request, in order.
+	Results []string `json:"results"`
+}
+
+// RunRules runs a set of rules for the given information.
+func (s *Service) RunRules(ctx context.Context,
+	credentials *checker.Credentials,
+	action string,
+	rulesData []*ruler.RulesData,
+	opts ...ruler.RunOption,
+) []rules.Result {
+	if credentials == nil || len(rulesData) == 0 {
+		// Nothing to consult the policy service about; the local ruler handles these cases.
+		return s.ruler.RunRules(ctx, credentials, action, rulesData, opts...)
+	}
+	options := &ruler.RunOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	req := &EvaluateRequest{
+		Action:      action,
+		Client:      credentials.Client,
+		IP:          credentials.IP,
+		RequestID:   credentials.RequestID,
+		Entries:     make([]*EvaluateEntry, len(rulesData)),
+		AtomicBatch: options.AtomicBatch,
+		DryRun:      options.DryRun,
+	}
+	for i := range rulesData {
+		if rulesData[i] == nil {
+			req.Entries[i] = &EvaluateEntry{}
+			continue
+		}
+		req.Entries[i] = &EvaluateEntry{
+			Wallet:  rulesData[i].WalletName,
+			Account: rulesData[i].AccountName,
+			PubKey:  rulesData[i].PubKey,
+			Data:    rulesData[i].Data,
+		}
+	}
+
+	res, err := s.call(ctx, req)
+	if err != nil {
+		if s.failOpen {
+			log.Warn().Err(err).Str("action", action).Msg("Failed to consult policy service; evaluating with local rules alone")
+			return s.ruler.RunRules(ctx, credentials, action, rulesData, opts...)
+		}
+		log.Error().Err(err).Str("action", action).Msg("Failed to consult policy service")
+		return allResults(len(rulesData), rules.FAILED)
+	}
+
+	results := make([]rules.Result, len(rulesData))
+	approved := make([]*ruler.RulesData, 0, len(rulesData))
+	approvedIndices := make([]int, 0, len(rulesData))
+	denied := false
+	for i := range res.Results {
+		switch res.Results[i] {
+		case ResultApproved:
+			approved = append(approved, rulesData[i])
+			approvedIndices = append(approvedIndices, i)
+			continue
+		case ResultDenied:
+			results[i] = rules.DENIED
+			denied = true
+		default:
+			results[i] = rules.FAILED
+		}
+		log.Debug().Str("action", action).Int("entry", i).Str("result", res.Results[i]).Msg("Policy service did not approve entry")
+	}
+
+	if options.AtomicBatch && len(approved) != len(rulesData) {
+		// At least one entry was not approved, so none are.
+		if denied {
+			return allResults(len(rulesData), rules.DENIED)
+		}
+		return allResults(len(rulesData), rules.FAILED)
+	}
+
+	if len(approved) > 0 {
+		localResults := s.ruler.RunRules(ctx, credentials, action, approved, opts...)
+		for i := range approvedIndices {
+			results[approvedIndices[i]] = localResults[i]
+		}
+	}
+
+	return results
+}
+
+// call calls the policy service, returning its validated response.
+func (s *Service) call(ctx context.Context, req *EvaluateRequest) (*EvaluateResponse, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode request")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	out := &wrappers.BytesValue{}
+	if err := s.conn.Invoke(ctx, EvaluateMethod, &wrappers.BytesValue{Value: data}, out); err != nil {
+		return nil, errors.Wrap(err, "failed to call policy service")
+	}
+
+	return parseEvaluateResponse(out.Value, len(req.Entries))
+}
+
+// parseEvaluateResponse parses a response from the policy service, rejecting any response
+// that does not contain a valid result for each entry.
+func parseEvaluateResponse(data []byte, entries int) (*EvaluateResponse, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	res := &EvaluateResponse{}
+	if err := decoder.Decode(res); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+	if decoder.More() {
+		return nil, errors.New("invalid response: trailing data")
+	}
+	if len(res.Results) != entries {
+		return nil, fmt.Errorf("invalid response: expected %d results, found %d", entries, len(res.Results))
+	}
+	for i := range res.Results {
+		switch res.Results[i] {
+		case ResultApproved, ResultDenied, ResultFailed:
+		default:
+			return nil, fmt.Errorf("invalid response: unknown result %q", res.Results[i])
+		}
+	}
+
+	return res, nil
+}
+
+// allResults returns a set of results with the same value.
+func allResults(count int, result rules.Result) []rules.Result {
+	results := make([]rules.Result, count)
+	for i := range results {
+		results[i] = result
+	}
+	return results
+}
@@ -0,0 +1,111 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/util/tracing"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Service is a ruler that consults a remote policy service before running the local ruler.
+// Requests are approved only if both the policy service and the local ruler approve them;
+// the policy service is consulted first, so local rules with state such as slashing
+// protection are only updated for requests that the policy service has approved.
+type Service struct {
+	conn     *grpc.ClientConn
+	timeout  time.Duration
+	failOpen bool
+	ruler    ruler.Service
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new remote ruler service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "ruler").Str("impl", "remote").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	var transportOpt grpc.DialOption
+	if len(parameters.clientCert) == 0 {
+		log.Warn().Str("address", parameters.address).Msg("Connecting to policy service without TLS")
+		transportOpt = grpc.WithInsecure()
+	} else {
+		credentials, err := composeCredentials(parameters.clientCert, parameters.clientKey, parameters.caCert)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to compose client credentials")
+		}
+		transportOpt = grpc.WithTransportCredentials(credentials)
+	}
+
+	// The connection is established lazily, so an unavailable policy service does not prevent startup.
+	conn, err := grpc.DialContext(ctx, parameters.address, transportOpt, grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create connection to policy service")
+	}
+	if parameters.failOpen {
+		log.Warn().Msg("Requests will be evaluated by local rules alone if the policy service cannot be consulted")
+	}
+
+	return &Service{
+		conn:     conn,
+		timeout:  parameters.timeout,
+		failOpen: parameters.failOpen,
+		ruler:    parameters.ruler,
+	}, nil
+}
+
+// Close closes the connection to the policy service.
+func (s *Service) Close(ctx context.Context) error {
+	return s.conn.Close()
+}
+
+func composeCredentials(certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) (credentials.TransportCredentials, error) {
+	clientCert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to access client certificate/key")
+	}
+
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		MinVersion:   tls.VersionTLS13,
+	}
+	if len(caPEMBlock) > 0 {
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM(caPEMBlock) {
+			return nil, errors.New("failed to add CA certificate")
+		}
+		tlsCfg.RootCAs = cp
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
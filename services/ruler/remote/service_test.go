@@ -0,0 +1,334 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/services/ruler/remote"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// policyServer is the interface for the fake policy service.
+type policyServer interface {
+	Evaluate(ctx context.Context, req *wrappers.BytesValue) (*wrappers.BytesValue, error)
+}
+
+// fakePolicyService is an in-process policy service.
+type fakePolicyService struct {
+	mu       sync.Mutex
+	requests []*remote.EvaluateRequest
+	respond  func(req *remote.EvaluateRequest) string
+	delay    time.Duration
+}
+
+func (p *fakePolicyService) Evaluate(ctx context.Context, in *wrappers.BytesValue) (*wrappers.BytesValue, error) {
+	req := &remote.EvaluateRequest{}
+	if err := json.Unmarshal(in.Value, req); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.requests = append(p.requests, req)
+	p.mu.Unlock()
+	time.Sleep(p.delay)
+	return &wrappers.BytesValue{Value: []byte(p.respond(req))}, nil
+}
+
+var policyDesc = grpc.ServiceDesc{
+	ServiceName: "dirk.ruler.v1.PolicyService",
+	HandlerType: (*policyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Evaluate",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(wrappers.BytesValue)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return srv.(policyServer).Evaluate(ctx, in)
+			},
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "ruler",
+}
+
+// startPolicyService starts a fake policy service, returning its address.
+func startPolicyService(t *testing.T, policyService *fakePolicyService) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	server.RegisterService(&policyDesc, policyService)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// localRuler is a local ruler that approves everything, recording the entries it is asked to run.
+type localRuler struct {
+	calls [][]*ruler.RulesData
+}
+
+func (r *localRuler) RunRules(ctx context.Context, credentials *checker.Credentials, action string, data []*ruler.RulesData, opts ...ruler.RunOption) []rules.Result {
+	r.calls = append(r.calls, data)
+	results := make([]rules.Result, len(data))
+	for i := range results {
+		results[i] = rules.APPROVED
+	}
+	return results
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []remote.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			params: []remote.Parameter{
+				remote.WithRuler(&localRuler{}),
+			},
+			err: "problem with parameters: no address specified",
+		},
+		{
+			name: "RulerMissing",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+			},
+			err: "problem with parameters: no ruler specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+				remote.WithRuler(&localRuler{}),
+				remote.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "ClientKeyMissing",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+				remote.WithRuler(&localRuler{}),
+				remote.WithClientCert([]byte("cert")),
+			},
+			err: "problem with parameters: client certificate and key must be specified together",
+		},
+		{
+			name: "Good",
+			params: []remote.Parameter{
+				remote.WithAddress("localhost:1"),
+				remote.WithRuler(&localRuler{}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := remote.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunRules(t *testing.T) {
+	ctx := context.Background()
+	credentials := &checker.Credentials{Client: "client1", IP: "10.0.0.1", RequestID: "req1"}
+	rulesData := []*ruler.RulesData{
+		{WalletName: "Wallet", AccountName: "1", Data: &rules.SignBeaconAttestationData{Slot: 1}},
+		{WalletName: "Wallet", AccountName: "2", Data: &rules.SignBeaconAttestationData{Slot: 2}},
+		{WalletName: "Wallet", AccountName: "3", Data: &rules.SignBeaconAttestationData{Slot: 3}},
+	}
+
+	tests := []struct {
+		name     string
+		response string
+		delay    time.Duration
+		failOpen bool
+		opts     []ruler.RunOption
+		results  []rules.Result
+		local    int
+	}{
+		{
+			name:     "Approved",
+			response: `{"results":["APPROVED","APPROVED","APPROVED"]}`,
+			results:  []rules.Result{rules.APPROVED, rules.APPROVED, rules.APPROVED},
+			local:    3,
+		},
+		{
+			name:     "Mixed",
+			response: `{"results":["DENIED","APPROVED","FAILED"]}`,
+			results:  []rules.Result{rules.DENIED, rules.APPROVED, rules.FAILED},
+			local:    1,
+		},
+		{
+			name:     "AtomicDenied",
+			response: `{"results":["APPROVED","DENIED","FAILED"]}`,
+			opts:     []ruler.RunOption{ruler.WithAtomicBatch()},
+			results:  []rules.Result{rules.DENIED, rules.DENIED, rules.DENIED},
+		},
+		{
+			name:     "AtomicFailed",
+			response: `{"results":["APPROVED","FAILED","APPROVED"]}`,
+			opts:     []ruler.RunOption{ruler.WithAtomicBatch()},
+			results:  []rules.Result{rules.FAILED, rules.FAILED, rules.FAILED},
+		},
+		{
+			name:     "WrongResultCount",
+			response: `{"results":["APPROVED"]}`,
+			results:  []rules.Result{rules.FAILED, rules.FAILED, rules.FAILED},
+		},
+		{
+			name:     "UnknownResult",
+			response: `{"results":["APPROVED","MAYBE","APPROVED"]}`,
+			results:  []rules.Result{rules.FAILED, rules.FAILED, rules.FAILED},
+		},
+		{
+			name:     "ExtraField",
+			response: `{"results":["APPROVED","APPROVED","APPROVED"],"extra":true}`,
+			results:  []rules.Result{rules.FAILED, rules.FAILED, rules.FAILED},
+		},
+		{
+			name:     "Timeout",
+			response: `{"results":["APPROVED","APPROVED","APPROVED"]}`,
+			delay:    200 * time.Millisecond,
+			results:  []rules.Result{rules.FAILED, rules.FAILED, rules.FAILED},
+		},
+		{
+			name:     "TimeoutFailOpen",
+			response: `{"results":["DENIED","DENIED","DENIED"]}`,
+			delay:    200 * time.Millisecond,
+			failOpen: true,
+			results:  []rules.Result{rules.APPROVED, rules.APPROVED, rules.APPROVED},
+			local:    3,
+		},
+		{
+			name:     "DeniedFailOpen",
+			response: `{"results":["DENIED","APPROVED","APPROVED"]}`,
+			failOpen: true,
+			results:  []rules.Result{rules.DENIED, rules.APPROVED, rules.APPROVED},
+			local:    2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policyService := &fakePolicyService{
+				respond: func(req *remote.EvaluateRequest) string { return test.response },
+				delay:   test.delay,
+			}
+			address := startPolicyService(t, policyService)
+			local := &localRuler{}
+			service, err := remote.New(ctx,
+				remote.WithAddress(address),
+				remote.WithRuler(local),
+				remote.WithTimeout(100*time.Millisecond),
+				remote.WithFailOpen(test.failOpen),
+			)
+			require.NoError(t, err)
+			defer service.Close(ctx)
+
+			results := service.RunRules(ctx, credentials, ruler.ActionSignBeaconAttestation, rulesData, test.opts...)
+			require.Equal(t, test.results, results)
+			if test.local == 0 {
+				require.Len(t, local.calls, 0)
+			} else {
+				require.Len(t, local.calls, 1)
+				require.Len(t, local.calls[0], test.local)
+			}
+		})
+	}
+}
+
+func TestRequest(t *testing.T) {
+	ctx := context.Background()
+	policyService := &fakePolicyService{
+		respond: func(req *remote.EvaluateRequest) string { return `{"results":["APPROVED"]}` },
+	}
+	address := startPolicyService(t, policyService)
+	service, err := remote.New(ctx,
+		remote.WithAddress(address),
+		remote.WithRuler(&localRuler{}),
+	)
+	require.NoError(t, err)
+	defer service.Close(ctx)
+
+	results := service.RunRules(ctx,
+		&checker.Credentials{Client: "client1", IP: "10.0.0.1", RequestID: "req1"},
+		ruler.ActionSignBeaconProposal,
+		[]*ruler.RulesData{
+			{
+				WalletName:  "Wallet",
+				AccountName: "1",
+				PubKey:      []byte{0x01, 0x02},
+				Data:        &rules.SignBeaconProposalData{Slot: 5},
+			},
+		},
+		ruler.WithDryRun(),
+	)
+	require.Equal(t, []rules.Result{rules.APPROVED}, results)
+
+	require.Len(t, policyService.requests, 1)
+	req := policyService.requests[0]
+	require.Equal(t, ruler.ActionSignBeaconProposal, req.Action)
+	require.Equal(t, "client1", req.Client)
+	require.Equal(t, "10.0.0.1", req.IP)
+	require.Equal(t, "req1", req.RequestID)
+	require.True(t, req.DryRun)
+	require.False(t, req.AtomicBatch)
+	require.Len(t, req.Entries, 1)
+	require.Equal(t, "Wallet", req.Entries[0].Wallet)
+	require.Equal(t, "1", req.Entries[0].Account)
+	require.Equal(t, []byte{0x01, 0x02}, req.Entries[0].PubKey)
+	data, err := json.Marshal(req.Entries[0].Data)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Slot":5`)
+}
+
+func TestUnavailable(t *testing.T) {
+	ctx := context.Background()
+	local := &localRuler{}
+	service, err := remote.New(ctx,
+		remote.WithAddress("127.0.0.1:1"),
+		remote.WithRuler(local),
+		remote.WithTimeout(100*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer service.Close(ctx)
+
+	credentials := &checker.Credentials{Client: "client1"}
+	rulesData := []*ruler.RulesData{{WalletName: "Wallet", AccountName: "1", Data: &rules.SignData{}}}
+	require.Equal(t, []rules.Result{rules.FAILED}, service.RunRules(ctx, credentials, ruler.ActionSign, rulesData))
+	require.Len(t, local.calls, 0)
+}
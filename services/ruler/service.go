@@ -27,6 +27,26 @@ var (
 	ActionSignBeaconAttestation = "Sign beacon attestation"
 	// ActionSignBeaconProposal is the action of signing a beacon proposal.
 	ActionSignBeaconProposal = "Sign beacon proposal"
+	// ActionSignRANDAOReveal is the action of signing a RANDAO reveal.
+	ActionSignRANDAOReveal = "Sign RANDAO reveal"
+	// ActionSignAggregateAndProof is the action of signing an aggregate and proof.
+	ActionSignAggregateAndProof = "Sign aggregate and proof"
+	// ActionSignAggregationSlot is the action of signing an aggregation slot selection proof.
+	ActionSignAggregationSlot = "Sign aggregation slot"
+	// ActionSignSyncCommitteeMessage is the action of signing a sync committee message.
+	ActionSignSyncCommitteeMessage = "Sign sync committee message"
+	// ActionSignSyncCommitteeSelectionProof is the action of signing a sync committee selection proof.
+	ActionSignSyncCommitteeSelectionProof = "Sign sync committee selection proof"
+	// ActionSignSyncCommitteeContributionAndProof is the action of signing a sync committee contribution and proof.
+	ActionSignSyncCommitteeContributionAndProof = "Sign sync committee contribution and proof"
+	// ActionSignVoluntaryExit is the action of signing a voluntary exit.
+	ActionSignVoluntaryExit = "Sign voluntary exit"
+	// ActionSignBLSToExecutionChange is the action of signing a BLS to execution change.
+	ActionSignBLSToExecutionChange = "Sign BLS to execution change"
+	// ActionSignValidatorRegistration is the action of signing a validator registration.
+	ActionSignValidatorRegistration = "Sign validator registration"
+	// ActionSignDeposit is the action of signing deposit data.
+	ActionSignDeposit = "Sign deposit"
 	// ActionAccessAccount is the action of accessing an account.
 	ActionAccessAccount = "Access account"
 	// ActionCreateAccount is the action of creating an account.
@@ -39,6 +59,18 @@ var (
 	ActionLockAccount = "Lock account"
 	// ActionUnlockAccount is the action of unlocking an account.
 	ActionUnlockAccount = "Unlock account"
+	// ActionPauseSigning is the action of pausing signing for an account.
+	ActionPauseSigning = "Pause signing"
+	// ActionResumeSigning is the action of resuming signing for an account.
+	ActionResumeSigning = "Resume signing"
+	// ActionExportAccount is the action of exporting an account.
+	ActionExportAccount = "Export account"
+	// ActionRecoverAccount is the action of releasing a share of a distributed account to recover its private key.
+	ActionRecoverAccount = "Recover account"
+	// ActionLockAccounts is the action of locking multiple accounts.
+	ActionLockAccounts = "Lock accounts"
+	// ActionUnlockAccounts is the action of unlocking multiple accounts.
+	ActionUnlockAccounts = "Unlock accounts"
 )
 
 // RulesData contains data for the rules.
@@ -49,8 +81,42 @@ type RulesData struct {
 	Data        interface{}
 }
 
+// RunOptions are options for running a set of rules.
+type RunOptions struct {
+	// AtomicBatch marks all entries as not approved if any single entry is not approved.
+	AtomicBatch bool
+	// DryRun evaluates the entries without changing any persistent state.
+	DryRun bool
+}
+
+// RunOption is an option for running a set of rules.
+type RunOption func(*RunOptions)
+
+// WithAtomicBatch requires that either all entries in the batch are approved or none are.
+// If any entry is denied all entries are marked as denied, otherwise if any entry is not approved
+// all entries are marked as failed, so none of them will be signed.
+// Rules that evaluate the batch together, such as slashing protection for multiple beacon
+// attestations, record no state unless every entry is approved.  Other rules that record state
+// on approval will have done so for the entries they approved; this can only result in future
+// requests being refused, never in a slashable signature.
+func WithAtomicBatch() RunOption {
+	return func(o *RunOptions) {
+		o.AtomicBatch = true
+	}
+}
+
+// WithDryRun evaluates the entries and returns the results that they would have, but does not
+// change any persistent state: slashing protection is not advanced, peers are not asked to confirm
+// high-water marks, and the results are not audited.  The caller must not sign the entries.
+func WithDryRun() RunOption {
+	return func(o *RunOptions) {
+		o.DryRun = true
+	}
+}
+
 // Service provides an interface to check requests against a rules engine.
 type Service interface {
 	// RunRules runs a set of rules for the given information.
-	RunRules(context.Context, *checker.Credentials, string, []*RulesData) []rules.Result
+	// By default each entry is evaluated and returns its own result.
+	RunRules(context.Context, *checker.Credentials, string, []*RulesData, ...RunOption) []rules.Result
 }
@@ -17,9 +17,17 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
+	"fmt"
 	"sync"
 
 	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/consensus"
+	"github.com/attestantio/dirk/services/process"
+	"github.com/attestantio/dirk/util/tracing"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/herumi/bls-eth-go-binary/bls"
 	"github.com/jackc/puddle"
 	"github.com/pkg/errors"
@@ -28,6 +36,7 @@ import (
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 // Service is used to manage the sender piece of distributed key generation operations.
@@ -195,6 +204,110 @@ func (s *Service) SendContribution(ctx context.Context, peer *core.Endpoint, acc
 	return resSecret, resVVec, nil
 }
 
+// PrepareReshare sends a request to the given participant to prepare to reshare a distributed account.
+func (s *Service) PrepareReshare(ctx context.Context,
+	peer *core.Endpoint,
+	account string,
+	passphrase []byte,
+	threshold uint32,
+	participants []*core.Endpoint,
+	dealers []uint64,
+	verificationVector []bls.PublicKey) error {
+	connResource, err := s.obtainConnection(ctx, peer.ConnectAddress())
+	if err != nil {
+		return errors.Wrap(err, "Failed to obtain connection for PrepareReshare()")
+	}
+	defer connResource.Release()
+
+	vVec := make([][]byte, len(verificationVector))
+	for i, key := range verificationVector {
+		vVec[i] = key.Serialize()
+	}
+	data, err := json.Marshal(&process.PrepareReshareRequest{
+		Account:            account,
+		Passphrase:         passphrase,
+		Threshold:          threshold,
+		Participants:       participants,
+		Dealers:            dealers,
+		VerificationVector: vVec,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode reshare request")
+	}
+	req := &wrappers.BytesValue{Value: data}
+	res := &empty.Empty{}
+	if err := connResource.Value().(*grpc.ClientConn).Invoke(ctx, process.PrepareReshareMethod, req, res); err != nil {
+		return errors.Wrap(err, "Failed to call PrepareReshare()")
+	}
+	return nil
+}
+
+// RecoverShare requests the given participant's share of a distributed account for recovery.
+func (s *Service) RecoverShare(ctx context.Context, peer *core.Endpoint, credentials *checker.Credentials, account string) (bls.SecretKey, error) {
+	connResource, err := s.obtainConnection(ctx, peer.ConnectAddress())
+	if err != nil {
+		return bls.SecretKey{}, errors.Wrap(err, "Failed to obtain connection for RecoverShare()")
+	}
+	defer connResource.Release()
+
+	data, err := json.Marshal(&process.RecoverShareRequest{
+		Account:   account,
+		Client:    credentials.Client,
+		RequestID: credentials.RequestID,
+	})
+	if err != nil {
+		return bls.SecretKey{}, errors.Wrap(err, "Failed to encode recover share request")
+	}
+	req := &wrappers.BytesValue{Value: data}
+	res := &wrappers.BytesValue{}
+	if err := connResource.Value().(*grpc.ClientConn).Invoke(ctx, process.RecoverShareMethod, req, res); err != nil {
+		return bls.SecretKey{}, errors.Wrap(err, "Failed to call RecoverShare()")
+	}
+	var share bls.SecretKey
+	if err := share.Deserialize(res.Value); err != nil {
+		return bls.SecretKey{}, errors.Wrap(err, "Invalid share")
+	}
+	return share, nil
+}
+
+// ProposeHighWaterMark proposes a high-water mark advance to a peer, returning true if acknowledged.
+func (s *Service) ProposeHighWaterMark(ctx context.Context, peer *core.Endpoint, hwm *consensus.HighWaterMark) (bool, error) {
+	connResource, err := s.obtainConnection(ctx, peer.ConnectAddress())
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to obtain connection for ProposeHighWaterMark()")
+	}
+	defer connResource.Release()
+
+	data, err := json.Marshal(hwm)
+	if err != nil {
+		return false, errors.Wrap(err, "Failed to encode high-water mark")
+	}
+	req := &wrappers.BytesValue{Value: data}
+	res := &wrappers.BoolValue{}
+	if err := connResource.Value().(*grpc.ClientConn).Invoke(ctx, consensus.ProposeHighWaterMarkMethod, req, res); err != nil {
+		return false, errors.Wrap(err, "Failed to call ProposeHighWaterMark()")
+	}
+	return res.Value, nil
+}
+
+// Ping checks that the given peer is reachable and serving requests, using the peer's health service.
+func (s *Service) Ping(ctx context.Context, peer *core.Endpoint) error {
+	connResource, err := s.obtainConnection(ctx, peer.ConnectAddress())
+	if err != nil {
+		return errors.Wrap(err, "Failed to obtain connection for Ping()")
+	}
+	defer connResource.Release()
+
+	res, err := grpc_health_v1.NewHealthClient(connResource.Value().(*grpc.ClientConn)).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to call Check()")
+	}
+	if res.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("peer is %s", res.Status)
+	}
+	return nil
+}
+
 func composeCredentials(ctx context.Context, certPEMBlock []byte, keyPEMBlock []byte, caPEMBlock []byte) (credentials.TransportCredentials, error) {
 	clientCert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
 	if err != nil {
@@ -224,6 +337,7 @@ func (s *Service) obtainConnection(ctx context.Context, address string) (*puddle
 		constructor := func(ctx context.Context) (interface{}, error) {
 			return grpc.Dial(address, []grpc.DialOption{
 				grpc.WithTransportCredentials(s.credentials),
+				grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor()),
 			}...)
 		}
 		destructor := func(val interface{}) {
@@ -18,6 +18,8 @@ import (
 	"fmt"
 
 	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/consensus"
 	"github.com/attestantio/dirk/testing/mock"
 	"github.com/herumi/bls-eth-go-binary/bls"
 )
@@ -78,3 +80,38 @@ func (s *Service) SendContribution(ctx context.Context, recipient *core.Endpoint
 	}
 	return process.OnContribute(ctx, s.id, account, distributionSecret, verificationVector)
 }
+
+// PrepareReshare sends a request to the given participant to prepare to reshare a distributed account.
+func (s *Service) PrepareReshare(ctx context.Context, recipient *core.Endpoint, account string, passphrase []byte, threshold uint32, participants []*core.Endpoint, dealers []uint64, verificationVector []bls.PublicKey) error {
+	process, exists := mock.Processes[recipient.ID]
+	if !exists {
+		return fmt.Errorf("unknown mock process %d", recipient.ID)
+	}
+	return process.OnPrepareReshare(ctx, s.id, account, passphrase, threshold, participants, dealers, verificationVector)
+}
+
+// RecoverShare requests the given participant's share of a distributed account for recovery.
+func (s *Service) RecoverShare(ctx context.Context, recipient *core.Endpoint, credentials *checker.Credentials, account string) (bls.SecretKey, error) {
+	process, exists := mock.Processes[recipient.ID]
+	if !exists {
+		return bls.SecretKey{}, fmt.Errorf("unknown mock process %d", recipient.ID)
+	}
+	return process.OnRecoverShare(ctx, s.id, credentials, account)
+}
+
+// ProposeHighWaterMark proposes a high-water mark advance to a peer, returning true if acknowledged.
+func (s *Service) ProposeHighWaterMark(ctx context.Context, recipient *core.Endpoint, hwm *consensus.HighWaterMark) (bool, error) {
+	consensus, exists := mock.Consensuses[recipient.ID]
+	if !exists {
+		return false, fmt.Errorf("unknown mock consensus %d", recipient.ID)
+	}
+	return consensus.Acknowledge(ctx, hwm)
+}
+
+// Ping checks that the given peer is reachable and serving requests.
+func (s *Service) Ping(ctx context.Context, recipient *core.Endpoint) error {
+	if _, exists := mock.Processes[recipient.ID]; !exists {
+		return fmt.Errorf("unknown mock process %d", recipient.ID)
+	}
+	return nil
+}
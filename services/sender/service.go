@@ -17,10 +17,13 @@ import (
 	"context"
 
 	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/consensus"
 	"github.com/herumi/bls-eth-go-binary/bls"
 )
 
-// Service is the interface for a DKG sender.
+// Service is the interface for sending requests to peers, for distributed key generation
+// and slashing protection consensus.
 type Service interface {
 	// Prepare sends a request to the given participant to prepare for DKG.
 	Prepare(ctx context.Context,
@@ -37,4 +40,20 @@ type Service interface {
 	Abort(ctx context.Context, recipient *core.Endpoint, account string) error
 	// SendContribution sends a contribution to a recipient.
 	SendContribution(ctx context.Context, recipient *core.Endpoint, account string, distributionSecret bls.SecretKey, verificationVector []bls.PublicKey) (bls.SecretKey, []bls.PublicKey, error)
+	// PrepareReshare sends a request to the given participant to prepare to reshare a distributed account.
+	PrepareReshare(ctx context.Context,
+		recipient *core.Endpoint,
+		account string,
+		passphrase []byte,
+		threshold uint32,
+		participants []*core.Endpoint,
+		dealers []uint64,
+		verificationVector []bls.PublicKey) error
+	// RecoverShare requests the given participant's share of a distributed account, to recover its private key on
+	// behalf of the client with the given credentials.
+	RecoverShare(ctx context.Context, recipient *core.Endpoint, credentials *checker.Credentials, account string) (bls.SecretKey, error)
+	// ProposeHighWaterMark proposes a high-water mark advance to a peer, returning true if acknowledged.
+	ProposeHighWaterMark(ctx context.Context, recipient *core.Endpoint, hwm *consensus.HighWaterMark) (bool, error)
+	// Ping checks that the given peer is reachable and serving requests.
+	Ping(ctx context.Context, recipient *core.Endpoint) error
 }
@@ -98,3 +98,115 @@ func (s *Service) SignBeaconProposal(ctx context.Context,
 		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
 	}
 }
+
+// SignDeposit signs deposit data.
+func (s *Service) SignDeposit(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignDepositData) (core.Result, []byte) {
+	return core.ResultSucceeded, []byte{
+		0x90, 0x42, 0xa3, 0x1d, 0xb8, 0x1e, 0x14, 0x65, 0x98, 0xce, 0xd6, 0xe5, 0x6d, 0xff, 0x63, 0x11,
+		0xdf, 0xfb, 0x39, 0x52, 0xbc, 0xd0, 0x8f, 0xf9, 0x22, 0x78, 0xad, 0x72, 0x19, 0xb0, 0x69, 0xc9,
+		0x86, 0xdb, 0x5d, 0x07, 0x22, 0x01, 0x76, 0xae, 0xd6, 0x1e, 0x6b, 0xe0, 0xc0, 0x52, 0x7f, 0x6d,
+		0x0a, 0x16, 0x12, 0x25, 0x62, 0x6e, 0x69, 0xc7, 0xfc, 0x6f, 0xd2, 0xc5, 0x7d, 0x38, 0x99, 0x64,
+		0x03, 0xc2, 0x95, 0x70, 0x4b, 0x94, 0xab, 0x7a, 0x36, 0x4c, 0x18, 0x5b, 0x98, 0x34, 0x56, 0xe5,
+		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
+	}
+}
+
+// SignValidatorRegistration signs a validator registration with an external block builder.
+func (s *Service) SignValidatorRegistration(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignValidatorRegistrationData) (core.Result, []byte) {
+	return core.ResultSucceeded, []byte{
+		0x90, 0x42, 0xa3, 0x1d, 0xb8, 0x1e, 0x14, 0x65, 0x98, 0xce, 0xd6, 0xe5, 0x6d, 0xff, 0x63, 0x11,
+		0xdf, 0xfb, 0x39, 0x52, 0xbc, 0xd0, 0x8f, 0xf9, 0x22, 0x78, 0xad, 0x72, 0x19, 0xb0, 0x69, 0xc9,
+		0x86, 0xdb, 0x5d, 0x07, 0x22, 0x01, 0x76, 0xae, 0xd6, 0x1e, 0x6b, 0xe0, 0xc0, 0x52, 0x7f, 0x6d,
+		0x0a, 0x16, 0x12, 0x25, 0x62, 0x6e, 0x69, 0xc7, 0xfc, 0x6f, 0xd2, 0xc5, 0x7d, 0x38, 0x99, 0x64,
+		0x03, 0xc2, 0x95, 0x70, 0x4b, 0x94, 0xab, 0x7a, 0x36, 0x4c, 0x18, 0x5b, 0x98, 0x34, 0x56, 0xe5,
+		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
+	}
+}
+
+// SignBLSToExecutionChange signs a change of withdrawal credentials to an execution address.
+func (s *Service) SignBLSToExecutionChange(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignBLSToExecutionChangeData) (core.Result, []byte) {
+	return core.ResultSucceeded, []byte{
+		0x90, 0x42, 0xa3, 0x1d, 0xb8, 0x1e, 0x14, 0x65, 0x98, 0xce, 0xd6, 0xe5, 0x6d, 0xff, 0x63, 0x11,
+		0xdf, 0xfb, 0x39, 0x52, 0xbc, 0xd0, 0x8f, 0xf9, 0x22, 0x78, 0xad, 0x72, 0x19, 0xb0, 0x69, 0xc9,
+		0x86, 0xdb, 0x5d, 0x07, 0x22, 0x01, 0x76, 0xae, 0xd6, 0x1e, 0x6b, 0xe0, 0xc0, 0x52, 0x7f, 0x6d,
+		0x0a, 0x16, 0x12, 0x25, 0x62, 0x6e, 0x69, 0xc7, 0xfc, 0x6f, 0xd2, 0xc5, 0x7d, 0x38, 0x99, 0x64,
+		0x03, 0xc2, 0x95, 0x70, 0x4b, 0x94, 0xab, 0x7a, 0x36, 0x4c, 0x18, 0x5b, 0x98, 0x34, 0x56, 0xe5,
+		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
+	}
+}
+
+// SignVoluntaryExit signs a voluntary exit.
+func (s *Service) SignVoluntaryExit(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignVoluntaryExitData) (core.Result, []byte) {
+	return core.ResultSucceeded, []byte{
+		0x90, 0x42, 0xa3, 0x1d, 0xb8, 0x1e, 0x14, 0x65, 0x98, 0xce, 0xd6, 0xe5, 0x6d, 0xff, 0x63, 0x11,
+		0xdf, 0xfb, 0x39, 0x52, 0xbc, 0xd0, 0x8f, 0xf9, 0x22, 0x78, 0xad, 0x72, 0x19, 0xb0, 0x69, 0xc9,
+		0x86, 0xdb, 0x5d, 0x07, 0x22, 0x01, 0x76, 0xae, 0xd6, 0x1e, 0x6b, 0xe0, 0xc0, 0x52, 0x7f, 0x6d,
+		0x0a, 0x16, 0x12, 0x25, 0x62, 0x6e, 0x69, 0xc7, 0xfc, 0x6f, 0xd2, 0xc5, 0x7d, 0x38, 0x99, 0x64,
+		0x03, 0xc2, 0x95, 0x70, 0x4b, 0x94, 0xab, 0x7a, 0x36, 0x4c, 0x18, 0x5b, 0x98, 0x34, 0x56, 0xe5,
+		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
+	}
+}
+
+// SignSyncCommitteeMessage signs a sync committee message.
+func (s *Service) SignSyncCommitteeMessage(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignSyncCommitteeMessageData) (core.Result, []byte) {
+	return core.ResultSucceeded, []byte{
+		0x90, 0x42, 0xa3, 0x1d, 0xb8, 0x1e, 0x14, 0x65, 0x98, 0xce, 0xd6, 0xe5, 0x6d, 0xff, 0x63, 0x11,
+		0xdf, 0xfb, 0x39, 0x52, 0xbc, 0xd0, 0x8f, 0xf9, 0x22, 0x78, 0xad, 0x72, 0x19, 0xb0, 0x69, 0xc9,
+		0x86, 0xdb, 0x5d, 0x07, 0x22, 0x01, 0x76, 0xae, 0xd6, 0x1e, 0x6b, 0xe0, 0xc0, 0x52, 0x7f, 0x6d,
+		0x0a, 0x16, 0x12, 0x25, 0x62, 0x6e, 0x69, 0xc7, 0xfc, 0x6f, 0xd2, 0xc5, 0x7d, 0x38, 0x99, 0x64,
+		0x03, 0xc2, 0x95, 0x70, 0x4b, 0x94, 0xab, 0x7a, 0x36, 0x4c, 0x18, 0x5b, 0x98, 0x34, 0x56, 0xe5,
+		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
+	}
+}
+
+// SignSyncCommitteeSelectionProof signs a sync committee selection proof.
+func (s *Service) SignSyncCommitteeSelectionProof(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignSyncCommitteeSelectionProofData) (core.Result, []byte) {
+	return core.ResultSucceeded, []byte{
+		0x90, 0x42, 0xa3, 0x1d, 0xb8, 0x1e, 0x14, 0x65, 0x98, 0xce, 0xd6, 0xe5, 0x6d, 0xff, 0x63, 0x11,
+		0xdf, 0xfb, 0x39, 0x52, 0xbc, 0xd0, 0x8f, 0xf9, 0x22, 0x78, 0xad, 0x72, 0x19, 0xb0, 0x69, 0xc9,
+		0x86, 0xdb, 0x5d, 0x07, 0x22, 0x01, 0x76, 0xae, 0xd6, 0x1e, 0x6b, 0xe0, 0xc0, 0x52, 0x7f, 0x6d,
+		0x0a, 0x16, 0x12, 0x25, 0x62, 0x6e, 0x69, 0xc7, 0xfc, 0x6f, 0xd2, 0xc5, 0x7d, 0x38, 0x99, 0x64,
+		0x03, 0xc2, 0x95, 0x70, 0x4b, 0x94, 0xab, 0x7a, 0x36, 0x4c, 0x18, 0x5b, 0x98, 0x34, 0x56, 0xe5,
+		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
+	}
+}
+
+// SignSyncCommitteeContributionAndProof signs a sync committee contribution and proof.
+func (s *Service) SignSyncCommitteeContributionAndProof(ctx context.Context,
+	credentials *checker.Credentials,
+	accountName string,
+	pubKey []byte,
+	data *rules.SignSyncCommitteeContributionAndProofData) (core.Result, []byte) {
+	return core.ResultSucceeded, []byte{
+		0x90, 0x42, 0xa3, 0x1d, 0xb8, 0x1e, 0x14, 0x65, 0x98, 0xce, 0xd6, 0xe5, 0x6d, 0xff, 0x63, 0x11,
+		0xdf, 0xfb, 0x39, 0x52, 0xbc, 0xd0, 0x8f, 0xf9, 0x22, 0x78, 0xad, 0x72, 0x19, 0xb0, 0x69, 0xc9,
+		0x86, 0xdb, 0x5d, 0x07, 0x22, 0x01, 0x76, 0xae, 0xd6, 0x1e, 0x6b, 0xe0, 0xc0, 0x52, 0x7f, 0x6d,
+		0x0a, 0x16, 0x12, 0x25, 0x62, 0x6e, 0x69, 0xc7, 0xfc, 0x6f, 0xd2, 0xc5, 0x7d, 0x38, 0x99, 0x64,
+		0x03, 0xc2, 0x95, 0x70, 0x4b, 0x94, 0xab, 0x7a, 0x36, 0x4c, 0x18, 0x5b, 0x98, 0x34, 0x56, 0xe5,
+		0xf9, 0x57, 0x50, 0xd9, 0x0e, 0x92, 0xb1, 0xef, 0x8a, 0x53, 0xd6, 0x3b, 0x3d, 0xf1, 0x91, 0x5a,
+	}
+}
@@ -50,4 +50,53 @@ type Service interface {
 		accountName string,
 		pubKey []byte,
 		data *rules.SignBeaconProposalData) (core.Result, []byte)
+
+	// SignDeposit signs deposit data.
+	SignDeposit(ctx context.Context,
+		credentials *checker.Credentials,
+		accountName string,
+		pubKey []byte,
+		data *rules.SignDepositData) (core.Result, []byte)
+
+	// SignVoluntaryExit signs a voluntary exit.
+	SignVoluntaryExit(ctx context.Context,
+		credentials *checker.Credentials,
+		accountName string,
+		pubKey []byte,
+		data *rules.SignVoluntaryExitData) (core.Result, []byte)
+
+	// SignBLSToExecutionChange signs a change of withdrawal credentials to an execution address.
+	SignBLSToExecutionChange(ctx context.Context,
+		credentials *checker.Credentials,
+		accountName string,
+		pubKey []byte,
+		data *rules.SignBLSToExecutionChangeData) (core.Result, []byte)
+
+	// SignValidatorRegistration signs a validator registration with an external block builder.
+	SignValidatorRegistration(ctx context.Context,
+		credentials *checker.Credentials,
+		accountName string,
+		pubKey []byte,
+		data *rules.SignValidatorRegistrationData) (core.Result, []byte)
+
+	// SignSyncCommitteeMessage signs a sync committee message.
+	SignSyncCommitteeMessage(ctx context.Context,
+		credentials *checker.Credentials,
+		accountName string,
+		pubKey []byte,
+		data *rules.SignSyncCommitteeMessageData) (core.Result, []byte)
+
+	// SignSyncCommitteeSelectionProof signs a sync committee selection proof.
+	SignSyncCommitteeSelectionProof(ctx context.Context,
+		credentials *checker.Credentials,
+		accountName string,
+		pubKey []byte,
+		data *rules.SignSyncCommitteeSelectionProofData) (core.Result, []byte)
+
+	// SignSyncCommitteeContributionAndProof signs a sync committee contribution and proof.
+	SignSyncCommitteeContributionAndProof(ctx context.Context,
+		credentials *checker.Credentials,
+		accountName string,
+		pubKey []byte,
+		data *rules.SignSyncCommitteeContributionAndProofData) (core.Result, []byte)
 }
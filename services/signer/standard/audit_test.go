@@ -0,0 +1,106 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	mockauditor "github.com/attestantio/dirk/services/auditor/mock"
+	"github.com/attestantio/dirk/services/checker"
+	mockchecker "github.com/attestantio/dirk/services/checker/mock"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	standardsigner "github.com/attestantio/dirk/services/signer/standard"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestAuditSignatures(t *testing.T) {
+	ctx := context.Background()
+
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, make([]byte, 64))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	account, err := wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	testRules, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(testRules))
+	require.NoError(t, err)
+	unlockerSvc, err := localunlocker.New(ctx, localunlocker.WithAccountPassphrases([]string{"Test account 1 passphrase"}))
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+	auditorSvc := mockauditor.New()
+	signerSvc, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc),
+		standardsigner.WithAuditor(auditorSvc))
+	require.NoError(t, err)
+
+	domain := make([]byte, 32)
+	copy(domain, []byte{0x01, 0x00, 0x00, 0x00})
+	data := &rules.SignBeaconAttestationData{
+		Domain:          domain,
+		Slot:            64,
+		BeaconBlockRoot: make([]byte, 32),
+		Source:          &rules.Checkpoint{Epoch: 1, Root: make([]byte, 32)},
+		Target:          &rules.Checkpoint{Epoch: 2, Root: make([]byte, 32)},
+	}
+	credentials := &checker.Credentials{RequestID: "1", Client: "client1", IP: "127.0.0.1"}
+
+	res, signature := signerSvc.SignBeaconAttestation(ctx, credentials, "Test wallet/Test account 1", nil, data)
+	require.Equal(t, core.ResultSucceeded, res)
+
+	// A request denied by the rules is not signed, so does not generate a signature record.
+	res, _ = signerSvc.SignBeaconAttestation(ctx, credentials, "Test wallet/Test account 1", nil, data)
+	require.Equal(t, core.ResultDenied, res)
+
+	records := auditorSvc.Records()
+	require.Len(t, records, 1)
+	digest := sha256.Sum256(signature)
+	assert.Equal(t, "1", records[0].RequestID)
+	assert.Equal(t, "client1", records[0].Client)
+	assert.Equal(t, "127.0.0.1", records[0].IP)
+	assert.Equal(t, "Test wallet/Test account 1", records[0].Account)
+	assert.Equal(t, fmt.Sprintf("%#x", account.PublicKey().Marshal()), records[0].PubKey)
+	assert.Equal(t, ruler.ActionSignBeaconAttestation, records[0].Action)
+	assert.Equal(t, "Signed", records[0].Result)
+	assert.Equal(t, fmt.Sprintf("%#x", digest), records[0].SignatureDigest)
+}
@@ -0,0 +1,46 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// blsToExecutionChange is the data signed for a BLS to execution change.
+type blsToExecutionChange struct {
+	ValidatorIndex     uint64
+	FromBLSPubKey      []byte `ssz-size:"48"`
+	ToExecutionAddress []byte `ssz-size:"20"`
+}
+
+// HashTreeRoot ssz hashes the blsToExecutionChange object.
+func (b *blsToExecutionChange) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(b)
+}
+
+// HashTreeRootWith ssz hashes the blsToExecutionChange object with a hasher.
+func (b *blsToExecutionChange) HashTreeRootWith(hh *ssz.Hasher) error {
+	indx := hh.Index()
+	hh.PutUint64(b.ValidatorIndex)
+	if len(b.FromBLSPubKey) != 48 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(b.FromBLSPubKey)
+	if len(b.ToExecutionAddress) != 20 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(b.ToExecutionAddress)
+	hh.Merkleize(indx)
+	return nil
+}
@@ -18,9 +18,10 @@ import (
 	"fmt"
 
 	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/checker"
-	"github.com/opentracing/opentracing-go"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"go.opentelemetry.io/otel"
 )
 
 // preCheck carries out pre-checks for all signing requests.
@@ -38,10 +39,12 @@ func (s *Service) preCheck(ctx context.Context, credentials *checker.Credentials
 		return nil, nil, result
 	}
 
-	// Unlock the account if necessary.
-	result = s.unlockAccount(ctx, wallet, account)
-	if result != core.ResultSucceeded {
-		return nil, nil, result
+	// Unlock the account if necessary.  Backends that hold their own keys do not use the account's key.
+	if s.unlock {
+		result = s.unlockAccount(ctx, wallet, account)
+		if result != core.ResultSucceeded {
+			return nil, nil, result
+		}
 	}
 
 	return wallet, account, core.ResultSucceeded
@@ -76,13 +79,14 @@ func (s *Service) checkAccess(ctx context.Context, credentials *checker.Credenti
 	if s.checker.Check(ctx, credentials, accountName, action) {
 		return core.ResultSucceeded
 	}
+	rules.RecordReason(ctx, rules.ReasonPolicy)
 	return core.ResultDenied
 }
 
 // unlockAccount returns true if the client can access the account.
 func (s *Service) unlockAccount(ctx context.Context, wallet e2wtypes.Wallet, account e2wtypes.Account) core.Result {
-	span, ctx := opentracing.StartSpanFromContext(ctx, "services.signer.accountUnlock")
-	defer span.Finish()
+	ctx, span := otel.Tracer("attestantio.dirk.services.signer.standard").Start(ctx, "services.signer.accountUnlock")
+	defer span.End()
 
 	if wallet == nil {
 		log.Warn().Str("result", "denied").Msg("No wallet provided")
@@ -14,10 +14,12 @@
 package standard
 
 import (
+	"github.com/attestantio/dirk/services/auditor"
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/fetcher"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/services/signingbackend"
 	"github.com/attestantio/dirk/services/unlocker"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -30,6 +32,9 @@ type parameters struct {
 	fetcher  fetcher.Service
 	ruler    ruler.Service
 	unlocker unlocker.Service
+	backend  signingbackend.Service
+	delegate bool
+	auditor  auditor.Service
 }
 
 // Parameter is the interface for service parameters.
@@ -85,6 +90,34 @@ func WithFetcher(fetcher fetcher.Service) Parameter {
 	})
 }
 
+// WithSigningBackend sets the backend that generates signatures once the rules have approved a request.
+// If not supplied, signatures are generated with the key material held by the account in the local keystore.
+func WithSigningBackend(backend signingbackend.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backend = backend
+	})
+}
+
+// WithGenericDelegation delegates generic signing requests with beacon proposer or beacon attester domains
+// to the rules for beacon proposals and attestations, so that slashing protection applies.  This is only
+// possible if the request data is the SSZ encoding of the beacon block header or attestation data rather
+// than its root; requests with roots continue to be refused.  Generic requests with the voluntary exit
+// domain and the SSZ encoding of the voluntary exit are similarly delegated to the voluntary exit rules,
+// and generic requests with the RANDAO domain are delegated to the RANDAO reveal rules.
+func WithGenericDelegation(delegate bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.delegate = delegate
+	})
+}
+
+// WithAuditor sets the auditor that records the signatures generated by this module.
+// If not supplied, signatures are not audited.
+func WithAuditor(auditor auditor.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.auditor = auditor
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
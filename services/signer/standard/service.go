@@ -16,10 +16,13 @@ package standard
 import (
 	context "context"
 
+	"github.com/attestantio/dirk/services/auditor"
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/fetcher"
 	"github.com/attestantio/dirk/services/metrics"
 	"github.com/attestantio/dirk/services/ruler"
+	"github.com/attestantio/dirk/services/signingbackend"
+	localsigningbackend "github.com/attestantio/dirk/services/signingbackend/local"
 	"github.com/attestantio/dirk/services/unlocker"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -33,6 +36,11 @@ type Service struct {
 	fetcher  fetcher.Service
 	ruler    ruler.Service
 	unlocker unlocker.Service
+	backend  signingbackend.Service
+	// unlock is true if accounts must be unlocked before the backend can sign with them.
+	unlock   bool
+	delegate bool
+	auditor  auditor.Service
 }
 
 // module-wide log.
@@ -51,11 +59,28 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		log = log.Level(parameters.logLevel)
 	}
 
+	backend := parameters.backend
+	if backend == nil {
+		backend, err = localsigningbackend.New(ctx, localsigningbackend.WithLogLevel(parameters.logLevel))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create local signing backend")
+		}
+	}
+
+	unlock := true
+	if keyHolder, isKeyHolder := backend.(signingbackend.KeyHolder); isKeyHolder && keyHolder.HoldsKeys() {
+		unlock = false
+	}
+
 	return &Service{
 		monitor:  parameters.monitor,
 		unlocker: parameters.unlocker,
 		checker:  parameters.checker,
 		fetcher:  parameters.fetcher,
 		ruler:    parameters.ruler,
+		backend:  backend,
+		unlock:   unlock,
+		delegate: parameters.delegate,
+		auditor:  parameters.auditor,
 	}, nil
 }
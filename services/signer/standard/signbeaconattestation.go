@@ -144,7 +144,7 @@ func (s *Service) SignBeaconAttestation(
 	}
 
 	// Sign it.
-	signature, err := signRoot(ctx, account, signingRoot[:])
+	signature, err := s.signRoot(ctx, credentials, ruler.ActionSignBeaconAttestation, rulesData[0], account, signingRoot[:])
 	if err != nil {
 		log.Error().Err(err).Str("result", "failed").Msg("Failed to sign")
 		s.monitor.SignCompleted(started, "attestation", core.ResultFailed)
@@ -202,7 +202,7 @@ func (s *Service) SignBeaconAttestations(
 			}
 
 			// Sign it.
-			signature, err := signRoot(ctx, accounts[i], signingRoot[:])
+			signature, err := s.signRoot(ctx, credentials, ruler.ActionSignBeaconAttestation, rulesData[i], accounts[i], signingRoot[:])
 			if err != nil {
 				log.Error().Err(err).Str("result", "failed").Msg("Failed to sign")
 				s.monitor.SignCompleted(started, "attestation", core.ResultFailed)
@@ -128,7 +128,7 @@ func (s *Service) SignBeaconProposal(
 	}
 
 	// Sign it.
-	signature, err := signRoot(ctx, account, signingRoot[:])
+	signature, err := s.signRoot(ctx, credentials, ruler.ActionSignBeaconProposal, rulesData[0], account, signingRoot[:])
 	if err != nil {
 		log.Error().Err(err).Str("result", "failed").Msg("Failed to sign")
 		s.monitor.SignCompleted(started, "proposal", core.ResultFailed)
@@ -14,7 +14,9 @@
 package standard
 
 import (
+	"bytes"
 	context "context"
+	"encoding/binary"
 	"fmt"
 	"time"
 
@@ -22,6 +24,8 @@ import (
 	"github.com/attestantio/dirk/rules"
 	"github.com/attestantio/dirk/services/checker"
 	"github.com/attestantio/dirk/services/ruler"
+	spec "github.com/attestantio/go-eth2-client/spec/phase0"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
 )
 
 // SignGeneric signs generic data.
@@ -65,6 +69,11 @@ func (s *Service) SignGeneric(
 		s.monitor.SignCompleted(started, "generic", core.ResultDenied)
 		return core.ResultDenied, nil
 	}
+	if len(data.Domain) != 32 {
+		log.Warn().Int("length", len(data.Domain)).Str("result", "denied").Msg("Request domain incorrect length")
+		s.monitor.SignCompleted(started, "generic", core.ResultDenied)
+		return core.ResultDenied, nil
+	}
 
 	wallet, account, checkRes := s.preCheck(ctx, credentials, accountName, pubKey, ruler.ActionSign)
 	if checkRes != core.ResultSucceeded {
@@ -74,16 +83,63 @@ func (s *Service) SignGeneric(
 	accountName = fmt.Sprintf("%s/%s", wallet.Name(), account.Name())
 	log = log.With().Str("account", accountName).Logger()
 
+	// Requests for which there are specific rules are checked by those rules.
+	action := ruler.ActionSign
+	var actionData interface{} = data
+	dataRoot := data.Data
+	switch {
+	case s.delegate && len(data.Domain) >= 4 && bytes.Equal(data.Domain[0:4], e2types.DomainBeaconProposer[:]) && len(data.Data) != 32:
+		// Beacon proposals are checked by their own rules if the full block header is supplied.
+		var err error
+		action, actionData, dataRoot, err = delegatedBeaconProposal(data)
+		if err != nil {
+			log.Warn().Err(err).Str("result", "denied").Msg("Invalid beacon block header data")
+			s.monitor.SignCompleted(started, "generic", core.ResultDenied)
+			return core.ResultDenied, nil
+		}
+	case s.delegate && len(data.Domain) >= 4 && bytes.Equal(data.Domain[0:4], e2types.DomainBeaconAttester[:]) && len(data.Data) != 32:
+		// Beacon attestations are checked by their own rules if the full attestation data is supplied.
+		var err error
+		action, actionData, dataRoot, err = delegatedBeaconAttestation(data)
+		if err != nil {
+			log.Warn().Err(err).Str("result", "denied").Msg("Invalid beacon attestation data")
+			s.monitor.SignCompleted(started, "generic", core.ResultDenied)
+			return core.ResultDenied, nil
+		}
+	case s.delegate && len(data.Domain) >= 4 && bytes.Equal(data.Domain[0:4], e2types.DomainVoluntaryExit[:]) && len(data.Data) != 32:
+		// Voluntary exits are checked by their own rules if the full voluntary exit is supplied.
+		var err error
+		action, actionData, dataRoot, err = delegatedVoluntaryExit(data)
+		if err != nil {
+			log.Warn().Err(err).Str("result", "denied").Msg("Invalid voluntary exit data")
+			s.monitor.SignCompleted(started, "generic", core.ResultDenied)
+			return core.ResultDenied, nil
+		}
+	case s.delegate && len(data.Domain) >= 4 && bytes.Equal(data.Domain[0:4], e2types.DomainRANDAO[:]):
+		// RANDAO reveals are checked by their own rules.
+		epoch, valid := randaoRevealEpoch(data.Data)
+		if !valid {
+			log.Warn().Str("result", "denied").Msg("Invalid RANDAO reveal data")
+			s.monitor.SignCompleted(started, "generic", core.ResultDenied)
+			return core.ResultDenied, nil
+		}
+		action = ruler.ActionSignRANDAOReveal
+		actionData = &rules.SignRANDAORevealData{
+			Domain: data.Domain,
+			Epoch:  epoch,
+		}
+	}
+
 	// Confirm approval via rules.
 	rulesData := []*ruler.RulesData{
 		{
 			WalletName:  wallet.Name(),
 			AccountName: account.Name(),
 			PubKey:      account.PublicKey().Marshal(),
-			Data:        data,
+			Data:        actionData,
 		},
 	}
-	results := s.ruler.RunRules(ctx, credentials, ruler.ActionSign, rulesData)
+	results := s.ruler.RunRules(ctx, credentials, action, rulesData)
 	switch results[0] {
 	case rules.DENIED:
 		s.monitor.SignCompleted(started, "generic", core.ResultDenied)
@@ -95,7 +151,7 @@ func (s *Service) SignGeneric(
 		return core.ResultFailed, nil
 	}
 
-	signingRoot, err := generateSigningRoot(ctx, data.Data, data.Domain)
+	signingRoot, err := generateSigningRoot(ctx, dataRoot, data.Domain)
 	if err != nil {
 		log.Error().Err(err).Str("result", "failed").Msg("Failed to generate signing root")
 		s.monitor.SignCompleted(started, "generic", core.ResultFailed)
@@ -103,7 +159,7 @@ func (s *Service) SignGeneric(
 	}
 
 	// Sign it.
-	signature, err := signRoot(ctx, account, signingRoot[:])
+	signature, err := s.signRoot(ctx, credentials, action, rulesData[0], account, signingRoot[:])
 	if err != nil {
 		log.Error().Err(err).Str("result", "failed").Msg("Failed to sign")
 		s.monitor.SignCompleted(started, "generic", core.ResultFailed)
@@ -114,3 +170,83 @@ func (s *Service) SignGeneric(
 	s.monitor.SignCompleted(started, "generic", core.ResultSucceeded)
 	return core.ResultSucceeded, signature
 }
+
+// randaoRevealEpoch returns the epoch of the data for a RANDAO reveal, which is the hash tree root
+// of the epoch.  It returns false if the data is not a valid epoch root.
+func randaoRevealEpoch(data []byte) (uint64, bool) {
+	if len(data) != 32 {
+		return 0, false
+	}
+	for _, b := range data[8:] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return binary.LittleEndian.Uint64(data[0:8]), true
+}
+
+// delegatedBeaconProposal decodes the SSZ-encoded beacon block header in generic data, returning
+// the action and data for the beacon proposal rules along with the root of the header.
+func delegatedBeaconProposal(data *rules.SignData) (string, interface{}, []byte, error) {
+	blockHeader := &spec.BeaconBlockHeader{}
+	if err := blockHeader.UnmarshalSSZ(data.Data); err != nil {
+		return "", nil, nil, err
+	}
+	root, err := blockHeader.HashTreeRoot()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return ruler.ActionSignBeaconProposal, &rules.SignBeaconProposalData{
+		Domain:        data.Domain,
+		Slot:          uint64(blockHeader.Slot),
+		ProposerIndex: uint64(blockHeader.ProposerIndex),
+		ParentRoot:    blockHeader.ParentRoot[:],
+		StateRoot:     blockHeader.StateRoot[:],
+		BodyRoot:      blockHeader.BodyRoot[:],
+	}, root[:], nil
+}
+
+// delegatedBeaconAttestation decodes the SSZ-encoded attestation data in generic data, returning
+// the action and data for the beacon attestation rules along with the root of the attestation data.
+func delegatedBeaconAttestation(data *rules.SignData) (string, interface{}, []byte, error) {
+	attestation := &spec.AttestationData{}
+	if err := attestation.UnmarshalSSZ(data.Data); err != nil {
+		return "", nil, nil, err
+	}
+	root, err := attestation.HashTreeRoot()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return ruler.ActionSignBeaconAttestation, &rules.SignBeaconAttestationData{
+		Domain:          data.Domain,
+		Slot:            uint64(attestation.Slot),
+		CommitteeIndex:  uint64(attestation.Index),
+		BeaconBlockRoot: attestation.BeaconBlockRoot[:],
+		Source: &rules.Checkpoint{
+			Epoch: uint64(attestation.Source.Epoch),
+			Root:  attestation.Source.Root[:],
+		},
+		Target: &rules.Checkpoint{
+			Epoch: uint64(attestation.Target.Epoch),
+			Root:  attestation.Target.Root[:],
+		},
+	}, root[:], nil
+}
+
+// delegatedVoluntaryExit decodes the SSZ-encoded voluntary exit in generic data, returning the action
+// and data for the voluntary exit rules along with the root of the voluntary exit.
+func delegatedVoluntaryExit(data *rules.SignData) (string, interface{}, []byte, error) {
+	voluntaryExit := &spec.VoluntaryExit{}
+	if err := voluntaryExit.UnmarshalSSZ(data.Data); err != nil {
+		return "", nil, nil, err
+	}
+	root, err := voluntaryExit.HashTreeRoot()
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return ruler.ActionSignVoluntaryExit, &rules.SignVoluntaryExitData{
+		Domain:         data.Domain,
+		Epoch:          uint64(voluntaryExit.Epoch),
+		ValidatorIndex: uint64(voluntaryExit.ValidatorIndex),
+	}, root[:], nil
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/attestantio/dirk/core"
 	"github.com/attestantio/dirk/rules"
 	mockrules "github.com/attestantio/dirk/rules/mock"
+	standardrules "github.com/attestantio/dirk/rules/standard"
 	"github.com/attestantio/dirk/services/checker"
 	mockchecker "github.com/attestantio/dirk/services/checker/mock"
 	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
@@ -28,6 +29,7 @@ import (
 	"github.com/attestantio/dirk/services/ruler/golang"
 	standardsigner "github.com/attestantio/dirk/services/signer/standard"
 	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	spec "github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
@@ -127,6 +129,21 @@ func TestSignGeneric(t *testing.T) {
 			accountName: "Test wallet/Test account 1",
 			res:         core.ResultDenied,
 		},
+		{
+			name:        "DomainShort",
+			credentials: &checker.Credentials{Client: "client1"},
+			data: &rules.SignData{
+				Data: []byte{
+					0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+					0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f,
+					0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+					0x18, 0x19, 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f,
+				},
+				Domain: []byte{0x01},
+			},
+			accountName: "Test wallet/Test account 1",
+			res:         core.ResultDenied,
+		},
 		{
 			name:        "DomainMissing",
 			credentials: &checker.Credentials{Client: "client1"},
@@ -170,3 +187,328 @@ func TestSignGeneric(t *testing.T) {
 		})
 	}
 }
+
+func TestSignGenericDelegation(t *testing.T) {
+	ctx := context.Background()
+
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	seed := make([]byte, 64)
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, seed)
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	testRules, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(testRules))
+	require.NoError(t, err)
+	unlockerSvc, err := localunlocker.New(ctx, localunlocker.WithAccountPassphrases([]string{"Test account 1 passphrase"}))
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+	delegatingSigner, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc),
+		standardsigner.WithGenericDelegation(true))
+	require.NoError(t, err)
+	nonDelegatingSigner, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc))
+	require.NoError(t, err)
+
+	attestationData := func(sourceEpoch uint64, targetEpoch uint64, root byte) []byte {
+		data := &spec.AttestationData{
+			Slot:   spec.Slot(targetEpoch * 32),
+			Source: &spec.Checkpoint{Epoch: spec.Epoch(sourceEpoch)},
+			Target: &spec.Checkpoint{Epoch: spec.Epoch(targetEpoch)},
+		}
+		data.BeaconBlockRoot[0] = root
+		encoded, err := data.MarshalSSZ()
+		require.NoError(t, err)
+		return encoded
+	}
+	domain := make([]byte, 32)
+	copy(domain, []byte{0x01, 0x00, 0x00, 0x00})
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name   string
+		signer *standardsigner.Service
+		data   []byte
+		res    core.Result
+	}{
+		{
+			name:   "NotDelegated",
+			signer: nonDelegatingSigner,
+			data:   attestationData(1, 2, 0x01),
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Root",
+			signer: delegatingSigner,
+			data:   make([]byte, 32),
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Invalid",
+			signer: delegatingSigner,
+			data:   make([]byte, 16),
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Good",
+			signer: delegatingSigner,
+			data:   attestationData(1, 2, 0x01),
+			res:    core.ResultSucceeded,
+		},
+		{
+			name:   "DoubleVote",
+			signer: delegatingSigner,
+			data:   attestationData(1, 2, 0x02),
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Surround",
+			signer: delegatingSigner,
+			data:   attestationData(0, 3, 0x01),
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Next",
+			signer: delegatingSigner,
+			data:   attestationData(2, 3, 0x01),
+			res:    core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, _ := test.signer.SignGeneric(ctx, credentials, "Test wallet/Test account 1", nil, &rules.SignData{
+				Domain: domain,
+				Data:   test.data,
+			})
+			require.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestSignGenericVoluntaryExit(t *testing.T) {
+	ctx := context.Background()
+
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	seed := make([]byte, 64)
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, seed)
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithVoluntaryExitPolicies(map[string]*standardrules.VoluntaryExitPolicy{
+			"Test account 1": {Allowed: true},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(testRules))
+	require.NoError(t, err)
+	unlockerSvc, err := localunlocker.New(ctx, localunlocker.WithAccountPassphrases([]string{"Test account 1 passphrase"}))
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+	delegatingSigner, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc),
+		standardsigner.WithGenericDelegation(true))
+	require.NoError(t, err)
+	nonDelegatingSigner, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc))
+	require.NoError(t, err)
+
+	voluntaryExit := &spec.VoluntaryExit{Epoch: 1, ValidatorIndex: 2}
+	encoded, err := voluntaryExit.MarshalSSZ()
+	require.NoError(t, err)
+	root, err := voluntaryExit.HashTreeRoot()
+	require.NoError(t, err)
+	domain := make([]byte, 32)
+	copy(domain, []byte{0x04, 0x00, 0x00, 0x00})
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name   string
+		signer *standardsigner.Service
+		data   []byte
+		res    core.Result
+	}{
+		{
+			name:   "NotDelegated",
+			signer: nonDelegatingSigner,
+			data:   encoded,
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "NotDelegatedRoot",
+			signer: nonDelegatingSigner,
+			data:   root[:],
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Root",
+			signer: delegatingSigner,
+			data:   root[:],
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Good",
+			signer: delegatingSigner,
+			data:   encoded,
+			res:    core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, _ := test.signer.SignGeneric(ctx, credentials, "Test wallet/Test account 1", nil, &rules.SignData{
+				Domain: domain,
+				Data:   test.data,
+			})
+			require.Equal(t, test.res, res)
+		})
+	}
+}
+
+func TestSignGenericRANDAOReveal(t *testing.T) {
+	ctx := context.Background()
+
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	seed := make([]byte, 64)
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, seed)
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	testRules, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+	// Restricted rules only permit client1 to request generic signatures with the application domain type.
+	restrictedRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithSignDomainTypes(map[string][][]byte{
+			"client1": {{0x00, 0x00, 0x00, 0x01}},
+		}),
+	)
+	require.NoError(t, err)
+	defer restrictedRules.Close(ctx)
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(testRules))
+	require.NoError(t, err)
+	restrictedRulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(restrictedRules))
+	require.NoError(t, err)
+	unlockerSvc, err := localunlocker.New(ctx, localunlocker.WithAccountPassphrases([]string{"Test account 1 passphrase"}))
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+	delegatingSigner, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc),
+		standardsigner.WithGenericDelegation(true))
+	require.NoError(t, err)
+	nonDelegatingSigner, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc))
+	require.NoError(t, err)
+	restrictedSigner, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(restrictedRulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc))
+	require.NoError(t, err)
+
+	domain := make([]byte, 32)
+	copy(domain, []byte{0x02, 0x00, 0x00, 0x00})
+	epochRoot := make([]byte, 32)
+	epochRoot[0] = 0x0a
+	invalidRoot := make([]byte, 32)
+	invalidRoot[0] = 0x0a
+	invalidRoot[31] = 0x01
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name   string
+		signer *standardsigner.Service
+		data   []byte
+		res    core.Result
+	}{
+		{
+			name:   "NotDelegatedNotPermitted",
+			signer: restrictedSigner,
+			data:   epochRoot,
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "NotDelegated",
+			signer: nonDelegatingSigner,
+			data:   invalidRoot,
+			res:    core.ResultSucceeded,
+		},
+		{
+			name:   "Invalid",
+			signer: delegatingSigner,
+			data:   invalidRoot,
+			res:    core.ResultDenied,
+		},
+		{
+			name:   "Good",
+			signer: delegatingSigner,
+			data:   epochRoot,
+			res:    core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, _ := test.signer.SignGeneric(ctx, credentials, "Test wallet/Test account 1", nil, &rules.SignData{
+				Domain: domain,
+				Data:   test.data,
+			})
+			require.Equal(t, test.res, res)
+		})
+	}
+}
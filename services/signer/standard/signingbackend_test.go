@@ -0,0 +1,193 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	mockrules "github.com/attestantio/dirk/rules/mock"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/checker"
+	mockchecker "github.com/attestantio/dirk/services/checker/mock"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	standardsigner "github.com/attestantio/dirk/services/signer/standard"
+	mocksigningbackend "github.com/attestantio/dirk/services/signingbackend/mock"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	"github.com/stretchr/testify/require"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestSigningBackend(t *testing.T) {
+	ctx := context.Background()
+
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, make([]byte, 64))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	testRules, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(testRules))
+	require.NoError(t, err)
+	unlockerSvc, err := localunlocker.New(ctx, localunlocker.WithAccountPassphrases([]string{"Test account 1 passphrase"}))
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+	backend := mocksigningbackend.New()
+	signerSvc, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc),
+		standardsigner.WithSigningBackend(backend))
+	require.NoError(t, err)
+
+	domain := make([]byte, 32)
+	copy(domain, []byte{0x01, 0x00, 0x00, 0x00})
+	attestation := func(sourceEpoch uint64, targetEpoch uint64) *rules.SignBeaconAttestationData {
+		return &rules.SignBeaconAttestationData{
+			Domain:          domain,
+			Slot:            targetEpoch * 32,
+			BeaconBlockRoot: make([]byte, 32),
+			Source:          &rules.Checkpoint{Epoch: sourceEpoch, Root: make([]byte, 32)},
+			Target:          &rules.Checkpoint{Epoch: targetEpoch, Root: make([]byte, 32)},
+		}
+	}
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name   string
+		data   *rules.SignBeaconAttestationData
+		res    core.Result
+		signed uint64
+	}{
+		{
+			name:   "Good",
+			data:   attestation(1, 2),
+			res:    core.ResultSucceeded,
+			signed: 1,
+		},
+		{
+			name:   "DoubleVote",
+			data:   attestation(1, 2),
+			res:    core.ResultDenied,
+			signed: 1,
+		},
+		{
+			name:   "Surround",
+			data:   attestation(0, 3),
+			res:    core.ResultDenied,
+			signed: 1,
+		},
+		{
+			name:   "Next",
+			data:   attestation(2, 3),
+			res:    core.ResultSucceeded,
+			signed: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, signature := signerSvc.SignBeaconAttestation(ctx, credentials, "Test wallet/Test account 1", nil, test.data)
+			require.Equal(t, test.res, res)
+			if test.res == core.ResultSucceeded {
+				require.Len(t, signature, 96)
+			}
+			require.Equal(t, test.signed, backend.Signed())
+		})
+	}
+}
+
+func TestKeyHolderBackend(t *testing.T) {
+	ctx := context.Background()
+
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, make([]byte, 64))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(mockrules.New()))
+	require.NoError(t, err)
+	// The unlocker does not have the passphrase, so the account cannot be unlocked.
+	unlockerSvc, err := localunlocker.New(ctx)
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+
+	domain := make([]byte, 32)
+	copy(domain, []byte{0x20, 0x00, 0x00, 0x00})
+	data := &rules.SignData{
+		Domain: domain,
+		Data:   make([]byte, 32),
+	}
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name    string
+		backend *mocksigningbackend.Service
+		res     core.Result
+	}{
+		{
+			name:    "AccountLocked",
+			backend: mocksigningbackend.New(),
+			res:     core.ResultDenied,
+		},
+		{
+			name:    "KeyHolder",
+			backend: mocksigningbackend.NewKeyHolder(),
+			res:     core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signerSvc, err := standardsigner.New(ctx,
+				standardsigner.WithChecker(checkerSvc),
+				standardsigner.WithFetcher(fetcherSvc),
+				standardsigner.WithRuler(rulerSvc),
+				standardsigner.WithUnlocker(unlockerSvc),
+				standardsigner.WithSigningBackend(test.backend))
+			require.NoError(t, err)
+			res, _ := signerSvc.SignGeneric(ctx, credentials, "Test wallet/Test account 1", nil, data)
+			require.Equal(t, test.res, res)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/checker"
+	mockchecker "github.com/attestantio/dirk/services/checker/mock"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	"github.com/attestantio/dirk/services/signer"
+	standardsigner "github.com/attestantio/dirk/services/signer/standard"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	spec "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func singleAccountSigner(ctx context.Context, t *testing.T) (signer.Service, e2wtypes.Account) {
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	seed := make([]byte, 64)
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, seed)
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	account, err := wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	testRules, err := standardrules.New(ctx, standardrules.WithStoragePath(t.TempDir()))
+	require.NoError(t, err)
+	t.Cleanup(func() { testRules.Close(ctx) })
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(testRules))
+	require.NoError(t, err)
+	unlockerSvc, err := localunlocker.New(ctx, localunlocker.WithAccountPassphrases([]string{"Test account 1 passphrase"}))
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+	signerSvc, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc))
+	require.NoError(t, err)
+
+	return signerSvc, account
+}
+
+func verifyRootSignature(t *testing.T, account e2wtypes.Account, signature []byte, root [32]byte, domain []byte) {
+	signingData := &spec.SigningData{ObjectRoot: root}
+	copy(signingData.Domain[:], domain)
+	signingRoot, err := signingData.HashTreeRoot()
+	require.NoError(t, err)
+	sig, err := e2types.BLSSignatureFromBytes(signature)
+	require.NoError(t, err)
+	require.True(t, sig.Verify(signingRoot[:], account.PublicKey()))
+}
+
+func TestSignSyncCommitteeMessage(t *testing.T) {
+	ctx := context.Background()
+	signerSvc, account := singleAccountSigner(ctx, t)
+
+	domain, err := e2types.ComputeDomain(e2types.DomainType{0x07, 0x00, 0x00, 0x00}, []byte{0x00, 0x00, 0x00, 0x00}, make([]byte, 32))
+	require.NoError(t, err)
+	credentials := &checker.Credentials{Client: "client1"}
+	var root [32]byte
+	root[0] = 0x01
+
+	tests := []struct {
+		name string
+		data *rules.SignSyncCommitteeMessageData
+		res  core.Result
+	}{
+		{
+			name: "Nil",
+			res:  core.ResultDenied,
+		},
+		{
+			name: "DomainMissing",
+			data: &rules.SignSyncCommitteeMessageData{
+				Slot:            1,
+				BeaconBlockRoot: root[:],
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name: "BeaconBlockRootShort",
+			data: &rules.SignSyncCommitteeMessageData{
+				Domain:          domain,
+				Slot:            1,
+				BeaconBlockRoot: root[:31],
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name: "Good",
+			data: &rules.SignSyncCommitteeMessageData{
+				Domain:          domain,
+				Slot:            1,
+				BeaconBlockRoot: root[:],
+			},
+			res: core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, signature := signerSvc.SignSyncCommitteeMessage(ctx, credentials, "Test wallet/Test account 1", nil, test.data)
+			require.Equal(t, test.res, res)
+			if res != core.ResultSucceeded {
+				require.Nil(t, signature)
+				return
+			}
+
+			// The signature is over the beacon block root itself.
+			verifyRootSignature(t, account, signature, root, test.data.Domain)
+		})
+	}
+}
+
+func TestSignSyncCommitteeSelectionProof(t *testing.T) {
+	ctx := context.Background()
+	signerSvc, account := singleAccountSigner(ctx, t)
+
+	domain, err := e2types.ComputeDomain(e2types.DomainType{0x08, 0x00, 0x00, 0x00}, []byte{0x00, 0x00, 0x00, 0x00}, make([]byte, 32))
+	require.NoError(t, err)
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name string
+		data *rules.SignSyncCommitteeSelectionProofData
+		res  core.Result
+	}{
+		{
+			name: "Nil",
+			res:  core.ResultDenied,
+		},
+		{
+			name: "SubcommitteeIndexInvalid",
+			data: &rules.SignSyncCommitteeSelectionProofData{
+				Domain:            domain,
+				Slot:              1,
+				SubcommitteeIndex: 4,
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name: "Good",
+			data: &rules.SignSyncCommitteeSelectionProofData{
+				Domain:            domain,
+				Slot:              1,
+				SubcommitteeIndex: 2,
+			},
+			res: core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, signature := signerSvc.SignSyncCommitteeSelectionProof(ctx, credentials, "Test wallet/Test account 1", nil, test.data)
+			require.Equal(t, test.res, res)
+			if res != core.ResultSucceeded {
+				require.Nil(t, signature)
+				return
+			}
+
+			// The signature is over the hash tree root of the slot and subcommittee index.
+			chunks := make([]byte, 64)
+			binary.LittleEndian.PutUint64(chunks[0:8], test.data.Slot)
+			binary.LittleEndian.PutUint64(chunks[32:40], test.data.SubcommitteeIndex)
+			verifyRootSignature(t, account, signature, sha256.Sum256(chunks), test.data.Domain)
+		})
+	}
+}
+
+func TestSignSyncCommitteeContributionAndProof(t *testing.T) {
+	ctx := context.Background()
+	signerSvc, _ := singleAccountSigner(ctx, t)
+
+	domain, err := e2types.ComputeDomain(e2types.DomainType{0x09, 0x00, 0x00, 0x00}, []byte{0x00, 0x00, 0x00, 0x00}, make([]byte, 32))
+	require.NoError(t, err)
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name string
+		data *rules.SignSyncCommitteeContributionAndProofData
+		res  core.Result
+	}{
+		{
+			name: "Nil",
+			res:  core.ResultDenied,
+		},
+		{
+			name: "SelectionProofMissing",
+			data: &rules.SignSyncCommitteeContributionAndProofData{
+				Domain:          domain,
+				Slot:            1,
+				BeaconBlockRoot: make([]byte, 32),
+				AggregationBits: make([]byte, 16),
+				Signature:       make([]byte, 96),
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name: "Good",
+			data: &rules.SignSyncCommitteeContributionAndProofData{
+				Domain:          domain,
+				Slot:            1,
+				BeaconBlockRoot: make([]byte, 32),
+				AggregationBits: make([]byte, 16),
+				Signature:       make([]byte, 96),
+				SelectionProof:  make([]byte, 96),
+			},
+			res: core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, signature := signerSvc.SignSyncCommitteeContributionAndProof(ctx, credentials, "Test wallet/Test account 1", nil, test.data)
+			require.Equal(t, test.res, res)
+			if res == core.ResultSucceeded {
+				require.Len(t, signature, 96)
+			} else {
+				require.Nil(t, signature)
+			}
+		})
+	}
+}
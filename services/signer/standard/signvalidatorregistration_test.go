@@ -0,0 +1,104 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+)
+
+func TestSignValidatorRegistration(t *testing.T) {
+	ctx := context.Background()
+	signerSvc, account := singleAccountSigner(ctx, t)
+
+	domain, err := e2types.ComputeDomain(e2types.DomainType{0x00, 0x00, 0x00, 0x01}, []byte{0x00, 0x00, 0x00, 0x00}, make([]byte, 32))
+	require.NoError(t, err)
+	credentials := &checker.Credentials{Client: "client1"}
+	feeRecipient := make([]byte, 20)
+	feeRecipient[19] = 0x01
+
+	tests := []struct {
+		name string
+		data *rules.SignValidatorRegistrationData
+		res  core.Result
+	}{
+		{
+			name: "Nil",
+			res:  core.ResultDenied,
+		},
+		{
+			name: "DomainMissing",
+			data: &rules.SignValidatorRegistrationData{
+				FeeRecipient: feeRecipient,
+				GasLimit:     30000000,
+				Timestamp:    1606824023,
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name: "FeeRecipientShort",
+			data: &rules.SignValidatorRegistrationData{
+				Domain:       domain,
+				FeeRecipient: feeRecipient[1:],
+				GasLimit:     30000000,
+				Timestamp:    1606824023,
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name: "Good",
+			data: &rules.SignValidatorRegistrationData{
+				Domain:       domain,
+				FeeRecipient: feeRecipient,
+				GasLimit:     30000000,
+				Timestamp:    1606824023,
+			},
+			res: core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, signature := signerSvc.SignValidatorRegistration(ctx, credentials, "Test wallet/Test account 1", nil, test.data)
+			require.Equal(t, test.res, res)
+			if res != core.ResultSucceeded {
+				require.Nil(t, signature)
+				return
+			}
+
+			// The signature is over the hash tree root of the fee recipient, gas limit, timestamp and the
+			// account's public key.
+			chunks := make([]byte, 128)
+			copy(chunks[0:20], test.data.FeeRecipient)
+			binary.LittleEndian.PutUint64(chunks[32:40], test.data.GasLimit)
+			binary.LittleEndian.PutUint64(chunks[64:72], test.data.Timestamp)
+			pubKey := make([]byte, 64)
+			copy(pubKey, account.PublicKey().Marshal())
+			pubKeyRoot := sha256.Sum256(pubKey)
+			copy(chunks[96:128], pubKeyRoot[:])
+			left := sha256.Sum256(chunks[0:64])
+			right := sha256.Sum256(chunks[64:128])
+			root := sha256.Sum256(append(left[:], right[:]...))
+			verifyRootSignature(t, account, signature, root, test.data.Domain)
+		})
+	}
+}
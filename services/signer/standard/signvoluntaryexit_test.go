@@ -0,0 +1,150 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	context "context"
+	"testing"
+
+	"github.com/attestantio/dirk/core"
+	"github.com/attestantio/dirk/rules"
+	standardrules "github.com/attestantio/dirk/rules/standard"
+	"github.com/attestantio/dirk/services/checker"
+	mockchecker "github.com/attestantio/dirk/services/checker/mock"
+	memfetcher "github.com/attestantio/dirk/services/fetcher/mem"
+	syncmaplocker "github.com/attestantio/dirk/services/locker/syncmap"
+	"github.com/attestantio/dirk/services/ruler/golang"
+	standardsigner "github.com/attestantio/dirk/services/signer/standard"
+	localunlocker "github.com/attestantio/dirk/services/unlocker/local"
+	spec "github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestSignVoluntaryExit(t *testing.T) {
+	ctx := context.Background()
+
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	seed := make([]byte, 64)
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, encryptor, seed)
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	account, err := wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 1", []byte("Test account 1 passphrase"))
+	require.NoError(t, err)
+	_, err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account 2", []byte("Test account 2 passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	testRules, err := standardrules.New(ctx,
+		standardrules.WithStoragePath(t.TempDir()),
+		standardrules.WithVoluntaryExitPolicies(map[string]*standardrules.VoluntaryExitPolicy{
+			"Test account 1": {Allowed: true},
+		}),
+	)
+	require.NoError(t, err)
+	defer testRules.Close(ctx)
+
+	lockerSvc, err := syncmaplocker.New(ctx)
+	require.NoError(t, err)
+	fetcherSvc, err := memfetcher.New(ctx, memfetcher.WithStores([]e2wtypes.Store{store}))
+	require.NoError(t, err)
+	rulerSvc, err := golang.New(ctx, golang.WithLocker(lockerSvc), golang.WithRules(testRules))
+	require.NoError(t, err)
+	unlockerSvc, err := localunlocker.New(ctx, localunlocker.WithAccountPassphrases([]string{"Test account 1 passphrase", "Test account 2 passphrase"}))
+	require.NoError(t, err)
+	checkerSvc, err := mockchecker.New()
+	require.NoError(t, err)
+	signerSvc, err := standardsigner.New(ctx,
+		standardsigner.WithChecker(checkerSvc),
+		standardsigner.WithFetcher(fetcherSvc),
+		standardsigner.WithRuler(rulerSvc),
+		standardsigner.WithUnlocker(unlockerSvc))
+	require.NoError(t, err)
+
+	domain, err := e2types.ComputeDomain(e2types.DomainVoluntaryExit, []byte{0x00, 0x00, 0x00, 0x00}, make([]byte, 32))
+	require.NoError(t, err)
+	credentials := &checker.Credentials{Client: "client1"}
+
+	tests := []struct {
+		name    string
+		account string
+		data    *rules.SignVoluntaryExitData
+		res     core.Result
+	}{
+		{
+			name:    "Nil",
+			account: "Test wallet/Test account 1",
+			res:     core.ResultDenied,
+		},
+		{
+			name:    "DomainMissing",
+			account: "Test wallet/Test account 1",
+			data: &rules.SignVoluntaryExitData{
+				Epoch:          100,
+				ValidatorIndex: 1,
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name:    "NotAllowed",
+			account: "Test wallet/Test account 2",
+			data: &rules.SignVoluntaryExitData{
+				Domain:         domain,
+				Epoch:          100,
+				ValidatorIndex: 2,
+			},
+			res: core.ResultDenied,
+		},
+		{
+			name:    "Good",
+			account: "Test wallet/Test account 1",
+			data: &rules.SignVoluntaryExitData{
+				Domain:         domain,
+				Epoch:          100,
+				ValidatorIndex: 1,
+			},
+			res: core.ResultSucceeded,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, signature := signerSvc.SignVoluntaryExit(ctx, credentials, test.account, nil, test.data)
+			require.Equal(t, test.res, res)
+			if res != core.ResultSucceeded {
+				require.Nil(t, signature)
+				return
+			}
+
+			voluntaryExit := &spec.VoluntaryExit{
+				Epoch:          spec.Epoch(test.data.Epoch),
+				ValidatorIndex: spec.ValidatorIndex(test.data.ValidatorIndex),
+			}
+			root, err := voluntaryExit.HashTreeRoot()
+			require.NoError(t, err)
+			signingData := &spec.SigningData{ObjectRoot: root}
+			copy(signingData.Domain[:], test.data.Domain)
+			signingRoot, err := signingData.HashTreeRoot()
+			require.NoError(t, err)
+			sig, err := e2types.BLSSignatureFromBytes(signature)
+			require.NoError(t, err)
+			require.True(t, sig.Verify(signingRoot[:], account.PublicKey()))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// syncAggregatorSelectionData is the data signed for a sync committee selection proof.
+type syncAggregatorSelectionData struct {
+	Slot              uint64
+	SubcommitteeIndex uint64
+}
+
+// HashTreeRoot ssz hashes the syncAggregatorSelectionData object.
+func (s *syncAggregatorSelectionData) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(s)
+}
+
+// HashTreeRootWith ssz hashes the syncAggregatorSelectionData object with a hasher.
+func (s *syncAggregatorSelectionData) HashTreeRootWith(hh *ssz.Hasher) error {
+	indx := hh.Index()
+	hh.PutUint64(s.Slot)
+	hh.PutUint64(s.SubcommitteeIndex)
+	hh.Merkleize(indx)
+	return nil
+}
+
+// syncCommitteeContribution is a sync committee contribution.
+type syncCommitteeContribution struct {
+	Slot              uint64
+	BeaconBlockRoot   []byte `ssz-size:"32"`
+	SubcommitteeIndex uint64
+	AggregationBits   []byte `ssz-size:"16"`
+	Signature         []byte `ssz-size:"96"`
+}
+
+// HashTreeRoot ssz hashes the syncCommitteeContribution object.
+func (s *syncCommitteeContribution) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(s)
+}
+
+// HashTreeRootWith ssz hashes the syncCommitteeContribution object with a hasher.
+func (s *syncCommitteeContribution) HashTreeRootWith(hh *ssz.Hasher) error {
+	indx := hh.Index()
+	hh.PutUint64(s.Slot)
+	if len(s.BeaconBlockRoot) != 32 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(s.BeaconBlockRoot)
+	hh.PutUint64(s.SubcommitteeIndex)
+	if len(s.AggregationBits) != 16 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(s.AggregationBits)
+	if len(s.Signature) != 96 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(s.Signature)
+	hh.Merkleize(indx)
+	return nil
+}
+
+// contributionAndProof is the data signed for a sync committee contribution and proof.
+type contributionAndProof struct {
+	AggregatorIndex uint64
+	Contribution    *syncCommitteeContribution
+	SelectionProof  []byte `ssz-size:"96"`
+}
+
+// HashTreeRoot ssz hashes the contributionAndProof object.
+func (c *contributionAndProof) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(c)
+}
+
+// HashTreeRootWith ssz hashes the contributionAndProof object with a hasher.
+func (c *contributionAndProof) HashTreeRootWith(hh *ssz.Hasher) error {
+	indx := hh.Index()
+	hh.PutUint64(c.AggregatorIndex)
+	if err := c.Contribution.HashTreeRootWith(hh); err != nil {
+		return err
+	}
+	if len(c.SelectionProof) != 96 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(c.SelectionProof)
+	hh.Merkleize(indx)
+	return nil
+}
@@ -15,8 +15,13 @@ package standard
 
 import (
 	context "context"
-	"errors"
+	"crypto/sha256"
+	"fmt"
+	"time"
 
+	"github.com/attestantio/dirk/services/auditor"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
 )
 
@@ -29,14 +34,40 @@ func generateSigningRoot(ctx context.Context, root []byte, domain []byte) ([32]b
 	return signingData.HashTreeRoot()
 }
 
-func signRoot(ctx context.Context, account e2wtypes.Account, root []byte) ([]byte, error) {
-	signer, isSigner := account.(e2wtypes.AccountSigner)
-	if !isSigner {
-		return nil, errors.New("not a signer")
-	}
-	signature, err := signer.Sign(ctx, root)
+// signRoot signs a root that the rules have approved with the signing backend,
+// and records the signature with the auditor if present.
+func (s *Service) signRoot(ctx context.Context,
+	credentials *checker.Credentials,
+	action string,
+	rulesData *ruler.RulesData,
+	account e2wtypes.Account,
+	root []byte,
+) (
+	[]byte,
+	error,
+) {
+	signature, err := s.backend.Sign(ctx, account, root)
 	if err != nil {
 		return nil, err
 	}
-	return signature.Marshal(), nil
+
+	if s.auditor != nil {
+		digest := sha256.Sum256(signature)
+		record := &auditor.Record{
+			Timestamp:       time.Now().UTC(),
+			Account:         fmt.Sprintf("%s/%s", rulesData.WalletName, rulesData.AccountName),
+			PubKey:          fmt.Sprintf("%#x", rulesData.PubKey),
+			Action:          action,
+			Result:          "Signed",
+			SignatureDigest: fmt.Sprintf("%#x", digest),
+		}
+		if credentials != nil {
+			record.RequestID = credentials.RequestID
+			record.Client = credentials.Client
+			record.IP = credentials.IP
+		}
+		s.auditor.Audit(ctx, record)
+	}
+
+	return signature, nil
 }
@@ -0,0 +1,48 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	ssz "github.com/ferranbt/fastssz"
+)
+
+// validatorRegistration is the data signed for a validator registration with an external block builder.
+type validatorRegistration struct {
+	FeeRecipient []byte `ssz-size:"20"`
+	GasLimit     uint64
+	Timestamp    uint64
+	PubKey       []byte `ssz-size:"48"`
+}
+
+// HashTreeRoot ssz hashes the validatorRegistration object.
+func (v *validatorRegistration) HashTreeRoot() ([32]byte, error) {
+	return ssz.HashWithDefaultHasher(v)
+}
+
+// HashTreeRootWith ssz hashes the validatorRegistration object with a hasher.
+func (v *validatorRegistration) HashTreeRootWith(hh *ssz.Hasher) error {
+	indx := hh.Index()
+	if len(v.FeeRecipient) != 20 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(v.FeeRecipient)
+	hh.PutUint64(v.GasLimit)
+	hh.PutUint64(v.Timestamp)
+	if len(v.PubKey) != 48 {
+		return ssz.ErrBytesLength
+	}
+	hh.PutBytes(v.PubKey)
+	hh.Merkleize(indx)
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// Service is a signing backend that signs with the key material held by the account in the local keystore.
+type Service struct{}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new local signing backend.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "signingbackend").Str("impl", "local").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	return &Service{}, nil
+}
+
+// Sign signs the root with the key of the account, returning the marshalled signature.
+func (s *Service) Sign(ctx context.Context, account e2wtypes.Account, root []byte) ([]byte, error) {
+	signer, isSigner := account.(e2wtypes.AccountSigner)
+	if !isSigner {
+		return nil, errors.New("not a signer")
+	}
+	signature, err := signer.Sign(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	return signature.Marshal(), nil
+}
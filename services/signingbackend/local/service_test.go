@@ -0,0 +1,56 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package local_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/services/signingbackend/local"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestSign(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, e2types.InitBLS())
+
+	store := scratch.New()
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, keystorev4.New(), make([]byte, 64))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	account, err := wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Test account", []byte("passphrase"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	service, err := local.New(ctx)
+	require.NoError(t, err)
+
+	root := make([]byte, 32)
+
+	// Locked account.
+	_, err = service.Sign(ctx, account, root)
+	require.Error(t, err)
+
+	require.NoError(t, account.(e2wtypes.AccountLocker).Unlock(ctx, []byte("passphrase")))
+	signature, err := service.Sign(ctx, account, root)
+	require.NoError(t, err)
+	sig, err := e2types.BLSSignatureFromBytes(signature)
+	require.NoError(t, err)
+	require.True(t, sig.Verify(root, account.(e2wtypes.AccountPublicKeyProvider).PublicKey()))
+}
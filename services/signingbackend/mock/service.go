@@ -0,0 +1,57 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"context"
+	"sync/atomic"
+
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// Service is a mock signing backend that returns a fixed signature.
+type Service struct {
+	signed    uint64
+	holdsKeys bool
+}
+
+// New creates a new mock signing backend.
+func New() *Service {
+	return &Service{}
+}
+
+// NewKeyHolder creates a new mock signing backend that holds its own keys.
+func NewKeyHolder() *Service {
+	return &Service{
+		holdsKeys: true,
+	}
+}
+
+// HoldsKeys returns true if the backend was created to hold its own keys.
+func (s *Service) HoldsKeys() bool {
+	return s.holdsKeys
+}
+
+// Sign returns a fixed signature.
+func (s *Service) Sign(ctx context.Context, account e2wtypes.Account, root []byte) ([]byte, error) {
+	atomic.AddUint64(&s.signed, 1)
+	signature := make([]byte, 96)
+	signature[0] = 0xc0
+	return signature, nil
+}
+
+// Signed returns the number of signatures the backend has provided.
+func (s *Service) Signed() uint64 {
+	return atomic.LoadUint64(&s.signed)
+}
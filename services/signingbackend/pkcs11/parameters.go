@@ -0,0 +1,101 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel   zerolog.Level
+	library    string
+	tokenLabel string
+	pin        string
+	mechanism  uint
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLibrary sets the path to the PKCS#11 library supplied by the HSM vendor.
+func WithLibrary(library string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.library = library
+	})
+}
+
+// WithTokenLabel sets the label of the token that holds the keys.
+func WithTokenLabel(tokenLabel string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.tokenLabel = tokenLabel
+	})
+}
+
+// WithPIN sets the user PIN for the token.
+func WithPIN(pin string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.pin = pin
+	})
+}
+
+// WithMechanism sets the PKCS#11 mechanism used to generate BLS signatures.
+// PKCS#11 does not define a BLS mechanism, so this is the vendor-defined value for the token.
+func WithMechanism(mechanism uint) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.mechanism = mechanism
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.library == "" {
+		return nil, errors.New("no library specified")
+	}
+	if parameters.tokenLabel == "" {
+		return nil, errors.New("no token label specified")
+	}
+	if parameters.pin == "" {
+		return nil, errors.New("no PIN specified")
+	}
+	if parameters.mechanism == 0 {
+		return nil, errors.New("no mechanism specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,224 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	p11 "github.com/miekg/pkcs11"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// token is the part of the PKCS#11 API used to sign.
+type token interface {
+	FindObjectsInit(sh p11.SessionHandle, temp []*p11.Attribute) error
+	FindObjects(sh p11.SessionHandle, max int) ([]p11.ObjectHandle, bool, error)
+	FindObjectsFinal(sh p11.SessionHandle) error
+	SignInit(sh p11.SessionHandle, m []*p11.Mechanism, o p11.ObjectHandle) error
+	Sign(sh p11.SessionHandle, message []byte) ([]byte, error)
+}
+
+// Service is a signing backend that signs with keys held in an HSM accessed through PKCS#11.
+//
+// The private key for an account is the object in the token with a class of private key and an ID of the
+// account's public key.  The signature returned by the token is verified against the public key before it
+// is returned.
+type Service struct {
+	// mu serialises use of the session, which PKCS#11 does not allow to carry out concurrent operations.
+	mu        sync.Mutex
+	token     token
+	session   p11.SessionHandle
+	mechanism uint
+	keys      map[string]p11.ObjectHandle
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new PKCS#11 signing backend.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "signingbackend").Str("impl", "pkcs11").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	p11Ctx := p11.New(parameters.library)
+	if p11Ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 library %s", parameters.library)
+	}
+	if err := p11Ctx.Initialize(); err != nil && !isError(err, p11.CKR_CRYPTOKI_ALREADY_INITIALIZED) {
+		p11Ctx.Destroy()
+		return nil, errors.Wrap(err, "failed to initialise PKCS#11 library")
+	}
+	session, err := openSession(p11Ctx, parameters.tokenLabel, parameters.pin)
+	if err != nil {
+		_ = p11Ctx.Finalize()
+		p11Ctx.Destroy()
+		return nil, err
+	}
+	log.Trace().Str("token", parameters.tokenLabel).Msg("Opened session with token")
+
+	s := &Service{
+		token:     p11Ctx,
+		session:   session,
+		mechanism: parameters.mechanism,
+		keys:      make(map[string]p11.ObjectHandle),
+	}
+
+	// Close the session on context done.
+	go func(ctx context.Context, p11Ctx *p11.Ctx) {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err := p11Ctx.Logout(s.session); err != nil {
+			log.Warn().Err(err).Msg("Failed to log out of token")
+		}
+		if err := p11Ctx.CloseSession(s.session); err != nil {
+			log.Warn().Err(err).Msg("Failed to close session with token")
+		}
+		if err := p11Ctx.Finalize(); err != nil {
+			log.Warn().Err(err).Msg("Failed to finalise PKCS#11 library")
+		}
+		p11Ctx.Destroy()
+	}(ctx, p11Ctx)
+
+	return s, nil
+}
+
+// openSession opens a logged-in session with the token with the given label.
+func openSession(p11Ctx *p11.Ctx, tokenLabel string, pin string) (p11.SessionHandle, error) {
+	slots, err := p11Ctx.GetSlotList(true)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain slots")
+	}
+	for _, slot := range slots {
+		info, err := p11Ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to obtain token information")
+		}
+		if strings.TrimSpace(info.Label) != tokenLabel {
+			continue
+		}
+		session, err := p11Ctx.OpenSession(slot, p11.CKF_SERIAL_SESSION)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to open session with token")
+		}
+		if err := p11Ctx.Login(session, p11.CKU_USER, pin); err != nil && !isError(err, p11.CKR_USER_ALREADY_LOGGED_IN) {
+			_ = p11Ctx.CloseSession(session)
+			return 0, errors.Wrap(err, "failed to log in to token")
+		}
+		return session, nil
+	}
+	return 0, fmt.Errorf("token %s not found", tokenLabel)
+}
+
+// isError returns true if the error is the given PKCS#11 return value.
+func isError(err error, rv uint) bool {
+	p11Err, isP11Err := err.(p11.Error)
+	return isP11Err && uint(p11Err) == rv
+}
+
+// HoldsKeys returns true, as keys are held in the token rather than the account.
+func (s *Service) HoldsKeys() bool {
+	return true
+}
+
+// Sign signs the root with the key of the account, returning the marshalled signature.
+func (s *Service) Sign(ctx context.Context, account e2wtypes.Account, root []byte) ([]byte, error) {
+	pubKeyProvider, isProvider := account.(e2wtypes.AccountPublicKeyProvider)
+	if !isProvider {
+		return nil, errors.New("account does not provide a public key")
+	}
+	pubKey := pubKeyProvider.PublicKey()
+
+	signature, err := s.sign(pubKey.Marshal(), root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure that the token has signed with the key of the account.
+	sig, err := e2types.BLSSignatureFromBytes(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "token returned an invalid signature")
+	}
+	if !sig.Verify(root, pubKey) {
+		return nil, errors.New("token returned a signature that does not verify")
+	}
+
+	return signature, nil
+}
+
+// sign signs the root with the private key whose ID is the public key.
+func (s *Service) sign(pubKey []byte, root []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := s.findKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.token.SignInit(s.session, []*p11.Mechanism{p11.NewMechanism(s.mechanism, nil)}, key); err != nil {
+		return nil, errors.Wrap(err, "failed to initialise signing")
+	}
+	signature, err := s.token.Sign(s.session, root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign")
+	}
+	return signature, nil
+}
+
+// findKey finds the handle of the private key whose ID is the public key.
+// It must be called with the mutex held.
+func (s *Service) findKey(pubKey []byte) (p11.ObjectHandle, error) {
+	if key, exists := s.keys[string(pubKey)]; exists {
+		return key, nil
+	}
+
+	template := []*p11.Attribute{
+		p11.NewAttribute(p11.CKA_CLASS, p11.CKO_PRIVATE_KEY),
+		p11.NewAttribute(p11.CKA_ID, pubKey),
+	}
+	if err := s.token.FindObjectsInit(s.session, template); err != nil {
+		return 0, errors.Wrap(err, "failed to initialise key search")
+	}
+	keys, _, err := s.token.FindObjects(s.session, 2)
+	if finalErr := s.token.FindObjectsFinal(s.session); finalErr != nil {
+		log.Warn().Err(finalErr).Msg("Failed to finalise key search")
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to search for key")
+	}
+	switch len(keys) {
+	case 0:
+		return 0, fmt.Errorf("no key for %#x in token", pubKey)
+	case 1:
+		s.keys[string(pubKey)] = keys[0]
+		return keys[0], nil
+	default:
+		return 0, fmt.Errorf("multiple keys for %#x in token", pubKey)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	p11 "github.com/miekg/pkcs11"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// mockToken is a token that holds keys in memory.
+type mockToken struct {
+	ids      [][]byte
+	keys     []e2types.PrivateKey
+	searchID []byte
+	signKey  p11.ObjectHandle
+	searches int
+}
+
+func (m *mockToken) FindObjectsInit(sh p11.SessionHandle, temp []*p11.Attribute) error {
+	m.searches++
+	for _, attr := range temp {
+		if attr.Type == p11.CKA_ID {
+			m.searchID = attr.Value
+		}
+	}
+	return nil
+}
+
+func (m *mockToken) FindObjects(sh p11.SessionHandle, max int) ([]p11.ObjectHandle, bool, error) {
+	res := make([]p11.ObjectHandle, 0)
+	for i := range m.ids {
+		if bytes.Equal(m.ids[i], m.searchID) && len(res) < max {
+			res = append(res, p11.ObjectHandle(i+1))
+		}
+	}
+	return res, false, nil
+}
+
+func (m *mockToken) FindObjectsFinal(sh p11.SessionHandle) error {
+	return nil
+}
+
+func (m *mockToken) SignInit(sh p11.SessionHandle, mechs []*p11.Mechanism, o p11.ObjectHandle) error {
+	if len(mechs) != 1 || mechs[0].Mechanism != 0x80000001 {
+		return errors.New("unsupported mechanism")
+	}
+	m.signKey = o
+	return nil
+}
+
+func (m *mockToken) Sign(sh p11.SessionHandle, message []byte) ([]byte, error) {
+	return m.keys[m.signKey-1].Sign(message).Marshal(), nil
+}
+
+func TestSign(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, e2types.InitBLS())
+
+	store := scratch.New()
+	wallet, err := hd.CreateWallet(ctx, "Test wallet", []byte("secret"), store, keystorev4.New(), make([]byte, 64))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("secret")))
+	accounts := make([]e2wtypes.Account, 4)
+	for i := range accounts {
+		accounts[i], err = wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, fmt.Sprintf("Test account %d", i), []byte("passphrase"))
+		require.NoError(t, err)
+	}
+
+	key1, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	key2, err := e2types.GenerateBLSPrivateKey()
+	require.NoError(t, err)
+	pubKey := func(account e2wtypes.Account) []byte {
+		return account.(e2wtypes.AccountPublicKeyProvider).PublicKey().Marshal()
+	}
+	token := &mockToken{
+		ids: [][]byte{
+			// Account 0 is held in the token.
+			pubKey(accounts[0]),
+			// Account 1 is held in the token with the wrong key.
+			pubKey(accounts[1]),
+			// Account 2 is held in the token twice.
+			pubKey(accounts[2]),
+			pubKey(accounts[2]),
+		},
+		keys: []e2types.PrivateKey{
+			nil,
+			key1,
+			key2,
+			key2,
+		},
+	}
+	// Account 0's key is the hd key, obtained from the account itself.
+	require.NoError(t, accounts[0].(e2wtypes.AccountLocker).Unlock(ctx, []byte("passphrase")))
+	token.keys[0], err = accounts[0].(e2wtypes.AccountPrivateKeyProvider).PrivateKey(ctx)
+	require.NoError(t, err)
+	require.NoError(t, accounts[0].(e2wtypes.AccountLocker).Lock(ctx))
+
+	s := &Service{
+		token:     token,
+		mechanism: 0x80000001,
+		keys:      make(map[string]p11.ObjectHandle),
+	}
+	root := bytes.Repeat([]byte{0x01}, 32)
+
+	tests := []struct {
+		name    string
+		account e2wtypes.Account
+		err     string
+	}{
+		{
+			name:    "Good",
+			account: accounts[0],
+		},
+		{
+			name:    "WrongKey",
+			account: accounts[1],
+			err:     "token returned a signature that does not verify",
+		},
+		{
+			name:    "MultipleKeys",
+			account: accounts[2],
+			err:     "multiple keys for",
+		},
+		{
+			name:    "Missing",
+			account: accounts[3],
+			err:     "no key for",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signature, err := s.Sign(ctx, test.account, root)
+			if test.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.err)
+			} else {
+				require.NoError(t, err)
+				sig, err := e2types.BLSSignatureFromBytes(signature)
+				require.NoError(t, err)
+				require.True(t, sig.Verify(root, test.account.(e2wtypes.AccountPublicKeyProvider).PublicKey()))
+			}
+		})
+	}
+
+	// The key handle is cached after the first search.
+	searches := token.searches
+	_, err = s.Sign(ctx, accounts[0], root)
+	require.NoError(t, err)
+	require.Equal(t, searches, token.searches)
+}
+
+func TestHoldsKeys(t *testing.T) {
+	require.True(t, (&Service{}).HoldsKeys())
+}
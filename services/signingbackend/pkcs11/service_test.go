@@ -0,0 +1,86 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pkcs11_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/services/signingbackend/pkcs11"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []pkcs11.Parameter
+		err    string
+	}{
+		{
+			name: "LibraryMissing",
+			params: []pkcs11.Parameter{
+				pkcs11.WithTokenLabel("dirk"),
+				pkcs11.WithPIN("1234"),
+				pkcs11.WithMechanism(0x80000001),
+			},
+			err: "problem with parameters: no library specified",
+		},
+		{
+			name: "TokenLabelMissing",
+			params: []pkcs11.Parameter{
+				pkcs11.WithLibrary("/usr/lib/libhsm.so"),
+				pkcs11.WithPIN("1234"),
+				pkcs11.WithMechanism(0x80000001),
+			},
+			err: "problem with parameters: no token label specified",
+		},
+		{
+			name: "PINMissing",
+			params: []pkcs11.Parameter{
+				pkcs11.WithLibrary("/usr/lib/libhsm.so"),
+				pkcs11.WithTokenLabel("dirk"),
+				pkcs11.WithMechanism(0x80000001),
+			},
+			err: "problem with parameters: no PIN specified",
+		},
+		{
+			name: "MechanismMissing",
+			params: []pkcs11.Parameter{
+				pkcs11.WithLibrary("/usr/lib/libhsm.so"),
+				pkcs11.WithTokenLabel("dirk"),
+				pkcs11.WithPIN("1234"),
+			},
+			err: "problem with parameters: no mechanism specified",
+		},
+		{
+			name: "LibraryBad",
+			params: []pkcs11.Parameter{
+				pkcs11.WithLibrary("/nonexistent/libhsm.so"),
+				pkcs11.WithTokenLabel("dirk"),
+				pkcs11.WithPIN("1234"),
+				pkcs11.WithMechanism(0x80000001),
+			},
+			err: "failed to load PKCS#11 library /nonexistent/libhsm.so",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := pkcs11.New(ctx, test.params...)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
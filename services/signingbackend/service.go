@@ -0,0 +1,37 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package signingbackend
+
+import (
+	"context"
+
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// Service provides signatures for roots that the rules have already approved.
+//
+// A backend is only invoked after the rules, including slashing protection, have approved the request,
+// so protection applies regardless of where the key material is held.  Backends must not carry out
+// any checks of their own that could approve a request the rules have denied.
+type Service interface {
+	// Sign signs the root with the key of the account, returning the marshalled signature.
+	Sign(ctx context.Context, account e2wtypes.Account, root []byte) ([]byte, error)
+}
+
+// KeyHolder is implemented by backends that hold key material outside of the account, for example in an HSM.
+// Accounts do not need to be unlocked for such backends to sign with them.
+type KeyHolder interface {
+	// HoldsKeys returns true if the backend holds the key material for accounts.
+	HoldsKeys() bool
+}
@@ -0,0 +1,238 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// jsonWebKey is a public key used by the issuer to sign tokens.
+type jsonWebKey struct {
+	id        string
+	algorithm string
+	key       crypto.PublicKey
+}
+
+type jwkJSON struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+type jwksJSON struct {
+	Keys []*jwkJSON `json:"keys"`
+}
+
+type discoveryJSON struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// keysFor returns the keys that could have signed a token with the given key ID, refreshing
+// the keys from the issuer if none are known.
+func (s *Service) keysFor(ctx context.Context, keyID string) []*jsonWebKey {
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	keys := matchingKeys(s.keys, keyID)
+	if len(keys) == 0 && time.Since(s.lastRefresh) >= minRefreshInterval {
+		// The issuer may have rotated its keys.
+		if err := s.refreshKeys(ctx); err != nil {
+			log.Warn().Err(err).Str("issuer", s.issuer).Msg("Failed to refresh signing keys from issuer")
+		}
+		keys = matchingKeys(s.keys, keyID)
+	}
+
+	return keys
+}
+
+// matchingKeys returns the keys with the given ID, or all keys if no ID is supplied.
+func matchingKeys(keys []*jsonWebKey, keyID string) []*jsonWebKey {
+	if keyID == "" {
+		return keys
+	}
+	res := make([]*jsonWebKey, 0, 1)
+	for _, key := range keys {
+		if key.id == keyID {
+			res = append(res, key)
+		}
+	}
+	return res
+}
+
+// refreshKeys fetches the signing keys from the issuer.  It must be called with the keys mutex held.
+func (s *Service) refreshKeys(ctx context.Context) error {
+	s.lastRefresh = time.Now()
+
+	if s.jwksURL == "" {
+		if s.configuredURL != "" {
+			s.jwksURL = s.configuredURL
+		} else {
+			jwksURL, err := s.discoverJWKSURL(ctx)
+			if err != nil {
+				return err
+			}
+			s.jwksURL = jwksURL
+		}
+	}
+
+	jwks := &jwksJSON{}
+	if err := s.fetchJSON(ctx, s.jwksURL, jwks); err != nil {
+		return errors.Wrap(err, "failed to fetch signing keys")
+	}
+	keys := make([]*jsonWebKey, 0, len(jwks.Keys))
+	for _, data := range jwks.Keys {
+		if data.Use != "" && data.Use != "sig" {
+			continue
+		}
+		key, err := parseKey(data)
+		if err != nil {
+			log.Debug().Err(err).Str("kid", data.KeyID).Msg("Ignoring unusable signing key")
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return errors.New("issuer provided no usable signing keys")
+	}
+	s.keys = keys
+	log.Trace().Int("keys", len(keys)).Msg("Obtained signing keys")
+
+	return nil
+}
+
+// discoverJWKSURL obtains the URL of the signing keys from the issuer's discovery document.
+func (s *Service) discoverJWKSURL(ctx context.Context) (string, error) {
+	discovery := &discoveryJSON{}
+	url := fmt.Sprintf("%s/.well-known/openid-configuration", strings.TrimSuffix(s.issuer, "/"))
+	if err := s.fetchJSON(ctx, url, discovery); err != nil {
+		return "", errors.Wrap(err, "failed to fetch discovery document")
+	}
+	if discovery.Issuer != s.issuer {
+		return "", fmt.Errorf("discovery document is for issuer %q", discovery.Issuer)
+	}
+	if discovery.JWKSURI == "" {
+		return "", errors.New("discovery document does not provide a JWKS URI")
+	}
+	return discovery.JWKSURI, nil
+}
+
+// fetchJSON fetches and decodes a JSON document.
+func (s *Service) fetchJSON(ctx context.Context, url string, res interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request returned status %d", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read response")
+	}
+	if err := json.Unmarshal(body, res); err != nil {
+		return errors.Wrap(err, "invalid response")
+	}
+	return nil
+}
+
+// parseKey parses a JSON web key.
+func parseKey(data *jwkJSON) (*jsonWebKey, error) {
+	res := &jsonWebKey{
+		id:        data.KeyID,
+		algorithm: data.Algorithm,
+	}
+	switch data.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(data.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid modulus")
+		}
+		e, err := decodeBigInt(data.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid exponent")
+		}
+		if !e.IsInt64() || e.Int64() > int64(^uint32(0)>>1) {
+			return nil, errors.New("exponent too large")
+		}
+		res.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		var curve elliptic.Curve
+		switch data.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", data.Curve)
+		}
+		x, err := decodeBigInt(data.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid x coordinate")
+		}
+		y, err := decodeBigInt(data.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid y coordinate")
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		res.key = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", data.KeyType)
+	}
+	if res.algorithm != "" && !keyMatchesAlgorithm(res.algorithm, res.key) {
+		return nil, fmt.Errorf("key not usable with its algorithm %q", res.algorithm)
+	}
+	return res, nil
+}
+
+// decodeBigInt decodes an unpadded base64url-encoded big-endian integer.
+func decodeBigInt(input string) (*big.Int, error) {
+	if input == "" {
+		return nil, errors.New("missing")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(input)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,122 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel      zerolog.Level
+	issuer        string
+	audience      string
+	identityClaim string
+	jwksURL       string
+	timeout       time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithIssuer sets the issuer of the tokens.  Tokens must have an 'iss' claim matching this value.
+func WithIssuer(issuer string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.issuer = issuer
+	})
+}
+
+// WithAudience sets the audience of the tokens.  Tokens must have an 'aud' claim containing this value.
+func WithAudience(audience string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.audience = audience
+	})
+}
+
+// WithIdentityClaim sets the claim that provides the identity of the client.  If not set the 'sub' claim is used.
+func WithIdentityClaim(identityClaim string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.identityClaim = identityClaim
+	})
+}
+
+// WithJWKSURL sets the URL from which to fetch the issuer's signing keys.  If not set the URL is obtained from
+// the issuer's OpenID Connect discovery document.
+func WithJWKSURL(jwksURL string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.jwksURL = jwksURL
+	})
+}
+
+// WithTimeout sets the maximum time to wait for the issuer to respond.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:      zerolog.GlobalLevel(),
+		identityClaim: "sub",
+		timeout:       5 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.issuer == "" {
+		return nil, errors.New("no issuer specified")
+	}
+	if parameters.jwksURL == "" {
+		// The issuer is used to find the discovery document, so must be a URL.
+		if _, err := url.ParseRequestURI(parameters.issuer); err != nil {
+			return nil, errors.Wrap(err, "invalid issuer")
+		}
+	} else if _, err := url.ParseRequestURI(parameters.jwksURL); err != nil {
+		return nil, errors.Wrap(err, "invalid JWKS URL")
+	}
+	if parameters.audience == "" {
+		return nil, errors.New("no audience specified")
+	}
+	if parameters.identityClaim == "" {
+		return nil, errors.New("no identity claim specified")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+
+	return &parameters, nil
+}
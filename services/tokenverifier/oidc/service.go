@@ -0,0 +1,80 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// minRefreshInterval is the minimum time between fetches of the issuer's signing keys, to avoid
+// clients presenting tokens with unknown key IDs causing a fetch for every request.
+const minRefreshInterval = 30 * time.Second
+
+// Service verifies JSON web tokens issued by an OpenID Connect provider.
+type Service struct {
+	issuer        string
+	audience      string
+	identityClaim string
+	configuredURL string
+	client        *http.Client
+
+	keysMu      sync.Mutex
+	keys        []*jsonWebKey
+	jwksURL     string
+	lastRefresh time.Time
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new OpenID Connect token verifier service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "tokenverifier").Str("impl", "oidc").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	s := &Service{
+		issuer:        parameters.issuer,
+		audience:      parameters.audience,
+		identityClaim: parameters.identityClaim,
+		configuredURL: parameters.jwksURL,
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+	}
+
+	// An unavailable issuer does not prevent startup; the keys are fetched again when a token is presented.
+	s.keysMu.Lock()
+	err = s.refreshKeys(ctx)
+	s.keysMu.Unlock()
+	if err != nil {
+		log.Warn().Err(err).Str("issuer", s.issuer).Msg("Failed to obtain signing keys from issuer")
+	}
+
+	return s, nil
+}
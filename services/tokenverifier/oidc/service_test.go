@@ -0,0 +1,424 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/services/tokenverifier/oidc"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type issuer struct {
+	server *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+	// ec384Key is a P-384 key, used to check that keys are only used with the algorithm for their curve.
+	ec384Key *ecdsa.PrivateKey
+}
+
+func newIssuer(t *testing.T) *issuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ec384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	res := &issuer{
+		rsaKey:   rsaKey,
+		ecKey:    ecKey,
+		ec384Key: ec384Key,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   res.server.URL,
+			"jwks_uri": res.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "rsa",
+					"use": "sig",
+					"n":   encode(rsaKey.N.Bytes()),
+					"e":   encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+				},
+				{
+					"kty": "EC",
+					"kid": "ec",
+					"crv": "P-256",
+					"x":   encode(ecKey.X.Bytes()),
+					"y":   encode(ecKey.Y.Bytes()),
+				},
+				{
+					"kty": "EC",
+					"kid": "ec384",
+					"crv": "P-384",
+					"x":   encode(ec384Key.X.Bytes()),
+					"y":   encode(ec384Key.Y.Bytes()),
+				},
+				{
+					// A P-256 key that claims to be for use with ES384.
+					"kty": "EC",
+					"kid": "mislabelled",
+					"alg": "ES384",
+					"crv": "P-256",
+					"x":   encode(ecKey.X.Bytes()),
+					"y":   encode(ecKey.Y.Bytes()),
+				},
+			},
+		})
+	})
+	res.server = httptest.NewServer(mux)
+	t.Cleanup(res.server.Close)
+
+	return res
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// token creates a token with the given header and claims, signed by the issuer's key for the algorithm.
+// ECDSA tokens are signed with the P-384 key if the key ID is "ec384", regardless of the algorithm.
+func (i *issuer) token(t *testing.T, header map[string]string, claims map[string]interface{}) string {
+	headerData, err := json.Marshal(header)
+	require.NoError(t, err)
+	claimsData, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signingInput := fmt.Sprintf("%s.%s", encode(headerData), encode(claimsData))
+	var digest []byte
+	if header["alg"] == "ES384" {
+		hash := sha512.Sum384([]byte(signingInput))
+		digest = hash[:]
+	} else {
+		hash := sha256.Sum256([]byte(signingInput))
+		digest = hash[:]
+	}
+
+	var signature []byte
+	switch header["alg"] {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest)
+		require.NoError(t, err)
+	case "ES256", "ES384":
+		key := i.ecKey
+		if header["kid"] == "ec384" {
+			key = i.ec384Key
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		require.NoError(t, err)
+		// The signature is the concatenation of r and s, each padded to the size of the curve.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		rBytes := r.Bytes()
+		sBytes := s.Bytes()
+		copy(signature[size-len(rBytes):size], rBytes)
+		copy(signature[2*size-len(sBytes):], sBytes)
+	}
+
+	return fmt.Sprintf("%s.%s", signingInput, encode(signature))
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	issuer := newIssuer(t)
+
+	tests := []struct {
+		name   string
+		params []oidc.Parameter
+		err    string
+	}{
+		{
+			name: "IssuerMissing",
+			params: []oidc.Parameter{
+				oidc.WithLogLevel(zerolog.Disabled),
+				oidc.WithAudience("dirk"),
+			},
+			err: "problem with parameters: no issuer specified",
+		},
+		{
+			name: "IssuerInvalid",
+			params: []oidc.Parameter{
+				oidc.WithLogLevel(zerolog.Disabled),
+				oidc.WithIssuer("issuer"),
+				oidc.WithAudience("dirk"),
+			},
+			err: "problem with parameters: invalid issuer: parse \"issuer\": invalid URI for request",
+		},
+		{
+			name: "AudienceMissing",
+			params: []oidc.Parameter{
+				oidc.WithLogLevel(zerolog.Disabled),
+				oidc.WithIssuer(issuer.server.URL),
+			},
+			err: "problem with parameters: no audience specified",
+		},
+		{
+			name: "IdentityClaimMissing",
+			params: []oidc.Parameter{
+				oidc.WithLogLevel(zerolog.Disabled),
+				oidc.WithIssuer(issuer.server.URL),
+				oidc.WithAudience("dirk"),
+				oidc.WithIdentityClaim(""),
+			},
+			err: "problem with parameters: no identity claim specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []oidc.Parameter{
+				oidc.WithLogLevel(zerolog.Disabled),
+				oidc.WithIssuer(issuer.server.URL),
+				oidc.WithAudience("dirk"),
+				oidc.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "IssuerUnavailable",
+			params: []oidc.Parameter{
+				oidc.WithLogLevel(zerolog.Disabled),
+				oidc.WithIssuer("http://localhost:1"),
+				oidc.WithAudience("dirk"),
+			},
+		},
+		{
+			name: "Good",
+			params: []oidc.Parameter{
+				oidc.WithLogLevel(zerolog.Disabled),
+				oidc.WithIssuer(issuer.server.URL),
+				oidc.WithAudience("dirk"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := oidc.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifyToken(t *testing.T) {
+	ctx := context.Background()
+	issuer := newIssuer(t)
+
+	verifier, err := oidc.New(ctx,
+		oidc.WithLogLevel(zerolog.Disabled),
+		oidc.WithIssuer(issuer.server.URL),
+		oidc.WithAudience("dirk"),
+	)
+	require.NoError(t, err)
+
+	now := time.Now().Unix()
+	goodClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss": issuer.server.URL,
+			"aud": "dirk",
+			"sub": "client1",
+			"exp": now + 60,
+		}
+	}
+	withClaim := func(name string, value interface{}) map[string]interface{} {
+		claims := goodClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+	rsaHeader := map[string]string{"alg": "RS256", "kid": "rsa"}
+
+	tests := []struct {
+		name     string
+		token    string
+		identity string
+		err      string
+	}{
+		{
+			name:  "Malformed",
+			token: "abc",
+			err:   "token is not a signed JWT",
+		},
+		{
+			name:  "AlgorithmNone",
+			token: issuer.token(t, map[string]string{"alg": "none"}, goodClaims()),
+			err:   "unsupported token algorithm \"none\"",
+		},
+		{
+			name:  "AlgorithmHMAC",
+			token: issuer.token(t, map[string]string{"alg": "HS256", "kid": "rsa"}, goodClaims()),
+			err:   "unsupported token algorithm \"HS256\"",
+		},
+		{
+			name:  "UnknownKey",
+			token: issuer.token(t, map[string]string{"alg": "RS256", "kid": "unknown"}, goodClaims()),
+			err:   "token signature not verified",
+		},
+		{
+			name:  "WrongKeyType",
+			token: issuer.token(t, map[string]string{"alg": "RS256", "kid": "ec"}, goodClaims()),
+			err:   "token signature not verified",
+		},
+		{
+			name:  "WrongCurve",
+			token: issuer.token(t, map[string]string{"alg": "ES256", "kid": "ec384"}, goodClaims()),
+			err:   "token signature not verified",
+		},
+		{
+			name:  "KeyAlgorithmMismatch",
+			token: issuer.token(t, map[string]string{"alg": "ES384", "kid": "mislabelled"}, goodClaims()),
+			err:   "token signature not verified",
+		},
+		{
+			name:  "WrongIssuer",
+			token: issuer.token(t, rsaHeader, withClaim("iss", "https://other.example.com/")),
+			err:   "token not from expected issuer",
+		},
+		{
+			name:  "WrongAudience",
+			token: issuer.token(t, rsaHeader, withClaim("aud", "other")),
+			err:   "token not for expected audience",
+		},
+		{
+			name:  "WrongAudienceList",
+			token: issuer.token(t, rsaHeader, withClaim("aud", []string{"other", "another"})),
+			err:   "token not for expected audience",
+		},
+		{
+			name:  "NoAudience",
+			token: issuer.token(t, rsaHeader, withClaim("aud", nil)),
+			err:   "token not for expected audience",
+		},
+		{
+			name:  "NoExpiry",
+			token: issuer.token(t, rsaHeader, withClaim("exp", nil)),
+			err:   "token has no expiry",
+		},
+		{
+			name:  "Expired",
+			token: issuer.token(t, rsaHeader, withClaim("exp", now-60)),
+			err:   "token has expired",
+		},
+		{
+			name:     "ExpiredWithinSkew",
+			token:    issuer.token(t, rsaHeader, withClaim("exp", now-10)),
+			identity: "client1",
+		},
+		{
+			name:  "InvalidExpiry",
+			token: issuer.token(t, rsaHeader, withClaim("exp", "tomorrow")),
+			err:   "invalid exp claim",
+		},
+		{
+			name:  "NotYetValid",
+			token: issuer.token(t, rsaHeader, withClaim("nbf", now+60)),
+			err:   "token not yet valid",
+		},
+		{
+			name:     "NotYetValidWithinSkew",
+			token:    issuer.token(t, rsaHeader, withClaim("nbf", now+10)),
+			identity: "client1",
+		},
+		{
+			name:  "NoSubject",
+			token: issuer.token(t, rsaHeader, withClaim("sub", nil)),
+			err:   "token has no sub claim",
+		},
+		{
+			name:     "RSA",
+			token:    issuer.token(t, rsaHeader, goodClaims()),
+			identity: "client1",
+		},
+		{
+			name:     "EC",
+			token:    issuer.token(t, map[string]string{"alg": "ES256", "kid": "ec"}, goodClaims()),
+			identity: "client1",
+		},
+		{
+			name:     "NoKeyID",
+			token:    issuer.token(t, map[string]string{"alg": "ES256"}, goodClaims()),
+			identity: "client1",
+		},
+		{
+			name:     "AudienceList",
+			token:    issuer.token(t, rsaHeader, withClaim("aud", []string{"other", "dirk"})),
+			identity: "client1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, err := verifier.VerifyToken(ctx, test.token)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.identity, identity)
+			}
+		})
+	}
+}
+
+func TestIdentityClaim(t *testing.T) {
+	ctx := context.Background()
+	issuer := newIssuer(t)
+
+	verifier, err := oidc.New(ctx,
+		oidc.WithLogLevel(zerolog.Disabled),
+		oidc.WithIssuer(issuer.server.URL),
+		oidc.WithAudience("dirk"),
+		oidc.WithIdentityClaim("client_id"),
+		oidc.WithJWKSURL(issuer.server.URL+"/keys"),
+	)
+	require.NoError(t, err)
+
+	token := issuer.token(t, map[string]string{"alg": "RS256", "kid": "rsa"}, map[string]interface{}{
+		"iss":       issuer.server.URL,
+		"aud":       "dirk",
+		"sub":       "subject",
+		"client_id": "client1",
+		"exp":       time.Now().Unix() + 60,
+	})
+	identity, err := verifier.VerifyToken(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "client1", identity)
+}
@@ -0,0 +1,230 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// clockSkew is the allowance for differences between the clocks of the issuer and this server.
+const clockSkew = 30 * time.Second
+
+type headerJSON struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// VerifyToken verifies the token, returning the identity of the client to which it was issued.
+func (s *Service) VerifyToken(ctx context.Context, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("token is not a signed JWT")
+	}
+
+	headerData, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", errors.Wrap(err, "invalid token header encoding")
+	}
+	header := &headerJSON{}
+	if err := json.Unmarshal(headerData, header); err != nil {
+		return "", errors.Wrap(err, "invalid token header")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.Wrap(err, "invalid token signature encoding")
+	}
+	if err := s.verifySignature(ctx, header, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return "", err
+	}
+
+	claimsData, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.Wrap(err, "invalid token claims encoding")
+	}
+	claims := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(claimsData))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return "", errors.Wrap(err, "invalid token claims")
+	}
+
+	return s.checkClaims(claims, time.Now())
+}
+
+// verifySignature verifies the signature of the token against the issuer's signing keys.
+func (s *Service) verifySignature(ctx context.Context, header *headerJSON, signingInput []byte, signature []byte) error {
+	hash, err := hashFor(header.Algorithm)
+	if err != nil {
+		return err
+	}
+	hasher := hash.New()
+	if _, err := hasher.Write(signingInput); err != nil {
+		return errors.Wrap(err, "failed to hash token")
+	}
+	digest := hasher.Sum(nil)
+
+	for _, key := range s.keysFor(ctx, header.KeyID) {
+		if key.algorithm != "" && key.algorithm != header.Algorithm {
+			continue
+		}
+		if verify(header.Algorithm, key.key, hash, digest, signature) {
+			return nil
+		}
+	}
+	return errors.New("token signature not verified")
+}
+
+// hashFor returns the hash used by the given signing algorithm.
+// Only asymmetric algorithms are supported; in particular 'none' and the HMAC algorithms are refused.
+func hashFor(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case "RS256", "PS256", "ES256":
+		return crypto.SHA256, nil
+	case "RS384", "PS384", "ES384":
+		return crypto.SHA384, nil
+	case "RS512", "PS512", "ES512":
+		return crypto.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported token algorithm %q", algorithm)
+	}
+}
+
+// minRSAKeyBits is the minimum size of RSA key accepted, as required by RFC 7518.
+const minRSAKeyBits = 2048
+
+// keyMatchesAlgorithm returns true if the key is of the type required by the algorithm and, for the
+// ECDSA algorithms, on the curve that the algorithm specifies.
+func keyMatchesAlgorithm(algorithm string, key crypto.PublicKey) bool {
+	switch algorithm {
+	case "RS256", "RS384", "RS512", "PS256", "PS384", "PS512":
+		pubKey, ok := key.(*rsa.PublicKey)
+		return ok && pubKey.N.BitLen() >= minRSAKeyBits
+	case "ES256":
+		pubKey, ok := key.(*ecdsa.PublicKey)
+		return ok && pubKey.Curve == elliptic.P256()
+	case "ES384":
+		pubKey, ok := key.(*ecdsa.PublicKey)
+		return ok && pubKey.Curve == elliptic.P384()
+	case "ES512":
+		pubKey, ok := key.(*ecdsa.PublicKey)
+		return ok && pubKey.Curve == elliptic.P521()
+	default:
+		return false
+	}
+}
+
+// verify verifies a signature with a key, returning true if the key matches the algorithm and the signature is valid.
+func verify(algorithm string, key crypto.PublicKey, hash crypto.Hash, digest []byte, signature []byte) bool {
+	if !keyMatchesAlgorithm(algorithm, key) {
+		return false
+	}
+	switch algorithm[:2] {
+	case "RS":
+		return rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), hash, digest, signature) == nil
+	case "PS":
+		return rsa.VerifyPSS(key.(*rsa.PublicKey), hash, digest, signature, nil) == nil
+	case "ES":
+		pubKey := key.(*ecdsa.PublicKey)
+		// The signature is the concatenation of fixed-length r and s values.
+		size := (pubKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(pubKey, digest, r, s)
+	default:
+		return false
+	}
+}
+
+// checkClaims checks the claims of the token, returning the identity of the client.
+func (s *Service) checkClaims(claims map[string]interface{}, now time.Time) (string, error) {
+	if issuer, ok := claims["iss"].(string); !ok || issuer != s.issuer {
+		return "", errors.New("token not from expected issuer")
+	}
+	if !hasAudience(claims["aud"], s.audience) {
+		return "", errors.New("token not for expected audience")
+	}
+
+	expiry, err := timeClaim(claims, "exp")
+	if err != nil {
+		return "", err
+	}
+	if expiry == nil {
+		return "", errors.New("token has no expiry")
+	}
+	if now.After(expiry.Add(clockSkew)) {
+		return "", errors.New("token has expired")
+	}
+	notBefore, err := timeClaim(claims, "nbf")
+	if err != nil {
+		return "", err
+	}
+	if notBefore != nil && now.Add(clockSkew).Before(*notBefore) {
+		return "", errors.New("token not yet valid")
+	}
+
+	identity, ok := claims[s.identityClaim].(string)
+	if !ok || identity == "" {
+		return "", fmt.Errorf("token has no %s claim", s.identityClaim)
+	}
+	return identity, nil
+}
+
+// hasAudience returns true if the audience claim, which may be a string or an array of strings, contains the audience.
+func hasAudience(claim interface{}, audience string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, entry := range aud {
+			if entry == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timeClaim returns the time held in a numeric date claim, or nil if the claim is not present.
+func timeClaim(claims map[string]interface{}, name string) (*time.Time, error) {
+	claim, exists := claims[name]
+	if !exists {
+		return nil, nil
+	}
+	number, ok := claim.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s claim", name)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s claim", name)
+	}
+	res := time.Unix(int64(seconds), 0)
+	return &res, nil
+}
@@ -0,0 +1,24 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenverifier
+
+import (
+	"context"
+)
+
+// Service is the interface for verifying bearer tokens presented by clients.
+type Service interface {
+	// VerifyToken verifies the token, returning the identity of the client to which it was issued.
+	VerifyToken(ctx context.Context, token string) (string, error)
+}
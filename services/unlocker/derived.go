@@ -0,0 +1,34 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package unlocker
+
+import (
+	"strings"
+
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// IsDerivedAccount returns true if the account was derived on demand from the seed of its wallet rather
+// than held in its store.  Such accounts are named for their path, and are encrypted with an empty
+// passphrase as access to them is controlled by the passphrase of the wallet from which they are derived.
+func IsDerivedAccount(account e2wtypes.Account) bool {
+	if !strings.HasPrefix(account.Name(), "m/") {
+		return false
+	}
+	pathProvider, isProvider := account.(e2wtypes.AccountPathProvider)
+	if !isProvider {
+		return false
+	}
+	return pathProvider.Path() == account.Name()
+}
@@ -17,6 +17,7 @@ import (
 	"context"
 
 	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/unlocker"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
@@ -98,6 +99,12 @@ func (s *Service) UnlockAccount(ctx context.Context, wallet e2wtypes.Wallet, acc
 		return true, nil
 	}
 
+	if unlocker.IsDerivedAccount(account) {
+		if err := locker.Unlock(ctx, nil); err == nil {
+			return true, nil
+		}
+	}
+
 	for _, passphrase := range s.accountPassphrases {
 		if err := locker.Unlock(ctx, []byte(passphrase)); err == nil {
 			return true, nil
@@ -161,6 +161,14 @@ func TestUnlockAccount(t *testing.T) {
 
 	wallet := mock.NewWallet("Test wallet")
 
+	// Derive an account from an HD wallet.
+	hdWallet, err := hd.CreateWallet(ctx, "HD wallet", []byte("wallet secret"), scratch.New(), keystorev4.New(), make([]byte, 64))
+	require.NoError(t, err)
+	require.NoError(t, hdWallet.(e2wtypes.WalletLocker).Unlock(ctx, []byte("wallet secret")))
+	derivedAccount, err := hdWallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "m/12381/3600/0/0/0")
+	require.NoError(t, err)
+	require.NoError(t, derivedAccount.(e2wtypes.AccountLocker).Lock(ctx))
+
 	tests := []struct {
 		name    string
 		wallet  e2wtypes.Wallet
@@ -196,6 +204,12 @@ func TestUnlockAccount(t *testing.T) {
 			account: mock.NewAccount("Account 1", []byte("secret2")),
 			result:  true,
 		},
+		{
+			name:    "Derived",
+			wallet:  hdWallet,
+			account: derivedAccount,
+			result:  true,
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,293 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// minRefreshWait is the minimum time between attempts to refresh the token.
+const minRefreshWait = 5 * time.Second
+
+// errPermissionDenied is returned when Vault refuses a request with the current token.
+var errPermissionDenied = errors.New("permission denied")
+
+// client is a minimal client for the Vault HTTP API.
+type client struct {
+	address         string
+	http            *http.Client
+	token           string
+	tokenFile       string
+	appRoleMount    string
+	appRoleID       string
+	appRoleSecretID string
+
+	tokenMu      sync.RWMutex
+	currentToken string
+	renewable    bool
+	expiry       time.Time
+}
+
+type authJSON struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int64  `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+type responseJSON struct {
+	Auth   *authJSON              `json:"auth"`
+	Data   map[string]interface{} `json:"data"`
+	Errors []string               `json:"errors"`
+}
+
+// newClient creates a new Vault client.
+func newClient(parameters *parameters) (*client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(parameters.caCert) > 0 {
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM(parameters.caCert) {
+			return nil, errors.New("failed to add CA certificate")
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    cp,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return &client{
+		address: strings.TrimSuffix(parameters.address, "/"),
+		http: &http.Client{
+			Timeout:   parameters.timeout,
+			Transport: transport,
+		},
+		token:           parameters.token,
+		tokenFile:       parameters.tokenFile,
+		appRoleMount:    parameters.appRoleMount,
+		appRoleID:       parameters.appRoleID,
+		appRoleSecretID: parameters.appRoleSecretID,
+	}, nil
+}
+
+// authenticate obtains a token, either by logging in with AppRole or from the configured token.
+func (c *client) authenticate(ctx context.Context) error {
+	if c.appRoleID != "" {
+		resp, err := c.do(ctx, http.MethodPost, fmt.Sprintf("auth/%s/login", c.appRoleMount), "", map[string]string{
+			"role_id":   c.appRoleID,
+			"secret_id": c.appRoleSecretID,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to log in with AppRole")
+		}
+		if resp.Auth == nil || resp.Auth.ClientToken == "" {
+			return errors.New("AppRole login did not return a token")
+		}
+		c.setToken(resp.Auth.ClientToken, resp.Auth.Renewable, resp.Auth.LeaseDuration)
+		return nil
+	}
+
+	token := c.token
+	if c.tokenFile != "" {
+		data, err := ioutil.ReadFile(c.tokenFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read token file")
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	resp, err := c.do(ctx, http.MethodGet, "auth/token/lookup-self", token, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to look up token")
+	}
+	ttl, _ := resp.Data["ttl"].(float64)
+	renewable, _ := resp.Data["renewable"].(bool)
+	c.setToken(token, renewable, int64(ttl))
+	return nil
+}
+
+// renew renews the current token.
+func (c *client) renew(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodPost, "auth/token/renew-self", c.currentTokenValue(), map[string]string{})
+	if err != nil {
+		return err
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return errors.New("renewal did not return a token")
+	}
+	c.setToken(resp.Auth.ClientToken, resp.Auth.Renewable, resp.Auth.LeaseDuration)
+	return nil
+}
+
+// refresh renews the current token if possible, and otherwise authenticates again.
+func (c *client) refresh(ctx context.Context) error {
+	c.tokenMu.RLock()
+	renewable := c.renewable
+	c.tokenMu.RUnlock()
+
+	if renewable {
+		err := c.renew(ctx)
+		if err == nil {
+			log.Trace().Msg("Renewed token")
+			return nil
+		}
+		log.Debug().Err(err).Msg("Failed to renew token; authenticating again")
+	}
+	if err := c.authenticate(ctx); err != nil {
+		return err
+	}
+	log.Trace().Msg("Obtained new token")
+	return nil
+}
+
+// maintainToken refreshes the token before it expires, until the context is cancelled.
+func (c *client) maintainToken(ctx context.Context) {
+	for {
+		wait := c.refreshWait()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if err := c.refresh(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to refresh Vault token")
+		}
+	}
+}
+
+// refreshWait returns the time to wait before refreshing the token, which is two thirds of its remaining lifetime.
+func (c *client) refreshWait() time.Duration {
+	c.tokenMu.RLock()
+	expiry := c.expiry
+	c.tokenMu.RUnlock()
+
+	if expiry.IsZero() {
+		// The token does not expire, but may be revoked; check it occasionally.
+		return time.Hour
+	}
+	wait := time.Until(expiry) * 2 / 3
+	if wait < minRefreshWait {
+		wait = minRefreshWait
+	}
+	return wait
+}
+
+func (c *client) setToken(token string, renewable bool, ttl int64) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	c.currentToken = token
+	c.renewable = renewable
+	if ttl > 0 {
+		c.expiry = time.Now().Add(time.Duration(ttl) * time.Second)
+	} else {
+		c.expiry = time.Time{}
+	}
+}
+
+func (c *client) currentTokenValue() string {
+	c.tokenMu.RLock()
+	defer c.tokenMu.RUnlock()
+	return c.currentToken
+}
+
+// readSecret reads the values of a KV secret, in order of their keys.  Both version 1 and version 2
+// secrets engines are supported; for version 2 the path includes the 'data' element, for example
+// 'secret/data/dirk/accounts'.
+func (c *client) readSecret(ctx context.Context, path string) ([]string, error) {
+	resp, err := c.do(ctx, http.MethodGet, path, c.currentTokenValue(), nil)
+	if errors.Is(err, errPermissionDenied) {
+		// The token may have been revoked or rotated; authenticate again and retry.
+		if err := c.authenticate(ctx); err != nil {
+			return nil, err
+		}
+		resp, err = c.do(ctx, http.MethodGet, path, c.currentTokenValue(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := resp.Data
+	if inner, isMap := data["data"].(map[string]interface{}); isMap {
+		if _, isV2 := data["metadata"]; isV2 {
+			data = inner
+		}
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value, isString := data[key].(string); isString {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// do carries out a request against the Vault API.
+func (c *client) do(ctx context.Context, method string, path string, token string, body interface{}) (*responseJSON, error) {
+	var reqBody *bytes.Reader
+	if body == nil {
+		reqBody = bytes.NewReader(nil)
+	} else {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal request")
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s/v1/%s", c.address, strings.TrimPrefix(path, "/")), reqBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request failed")
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	res := &responseJSON{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, res); err != nil {
+			return nil, errors.Wrap(err, "invalid response")
+		}
+	}
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, errPermissionDenied
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("request returned status %d: %s", resp.StatusCode, strings.Join(res.Errors, "; "))
+	}
+	return res, nil
+}
@@ -0,0 +1,223 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel           zerolog.Level
+	monitor            metrics.UnlockerMonitor
+	address            string
+	token              string
+	tokenFile          string
+	appRoleMount       string
+	appRoleID          string
+	appRoleSecretID    string
+	caCert             []byte
+	timeout            time.Duration
+	cacheDuration      time.Duration
+	walletPaths        []string
+	accountPaths       []string
+	walletPassphrases  []string
+	accountPassphrases []string
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithMonitor sets the monitor for this module.
+func WithMonitor(monitor metrics.UnlockerMonitor) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithAddress sets the address of the Vault server.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithToken sets the token used to authenticate with Vault.
+func WithToken(token string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.token = token
+	})
+}
+
+// WithTokenFile sets a file containing the token used to authenticate with Vault, for example as written by
+// Vault agent.  The file is read again whenever the token needs to be replaced.
+func WithTokenFile(tokenFile string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.tokenFile = tokenFile
+	})
+}
+
+// WithAppRoleMount sets the path at which the AppRole auth method is mounted.  Defaults to 'approle'.
+func WithAppRoleMount(mount string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.appRoleMount = mount
+	})
+}
+
+// WithAppRoleID sets the role ID used to authenticate with Vault's AppRole auth method.
+func WithAppRoleID(roleID string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.appRoleID = roleID
+	})
+}
+
+// WithAppRoleSecretID sets the secret ID used to authenticate with Vault's AppRole auth method.
+func WithAppRoleSecretID(secretID string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.appRoleSecretID = secretID
+	})
+}
+
+// WithCACert sets the CA certificate used to verify the Vault server.
+func WithCACert(caCert []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.caCert = caCert
+	})
+}
+
+// WithTimeout sets the maximum time to wait for Vault to respond.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithCacheDuration sets the time for which passphrases fetched from Vault are held before being fetched again.
+// If this is 0 passphrases are fetched for every unlock.
+func WithCacheDuration(cacheDuration time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.cacheDuration = cacheDuration
+	})
+}
+
+// WithWalletPaths sets the paths of the KV secrets that hold wallet passphrases.
+func WithWalletPaths(paths []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.walletPaths = paths
+	})
+}
+
+// WithAccountPaths sets the paths of the KV secrets that hold account passphrases.
+func WithAccountPaths(paths []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.accountPaths = paths
+	})
+}
+
+// WithWalletPassphrases sets additional wallet unlock passphrases, tried before those held in Vault.
+func WithWalletPassphrases(passphrases []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.walletPassphrases = passphrases
+	})
+}
+
+// WithAccountPassphrases sets additional account unlock passphrases, tried before those held in Vault.
+func WithAccountPassphrases(passphrases []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.accountPassphrases = passphrases
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:           zerolog.GlobalLevel(),
+		appRoleMount:       "approle",
+		timeout:            5 * time.Second,
+		cacheDuration:      time.Minute,
+		walletPassphrases:  []string{},
+		accountPassphrases: []string{},
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.monitor == nil {
+		// Use no-op monitor.
+		parameters.monitor = &noopMonitor{}
+	}
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if _, err := url.ParseRequestURI(parameters.address); err != nil {
+		return nil, errors.Wrap(err, "invalid address")
+	}
+	methods := 0
+	if parameters.token != "" {
+		methods++
+	}
+	if parameters.tokenFile != "" {
+		methods++
+	}
+	if parameters.appRoleID != "" || parameters.appRoleSecretID != "" {
+		if parameters.appRoleID == "" || parameters.appRoleSecretID == "" {
+			return nil, errors.New("AppRole role ID and secret ID must be specified together")
+		}
+		methods++
+	}
+	if methods != 1 {
+		return nil, errors.New("exactly one of token, token file or AppRole must be specified")
+	}
+	if parameters.appRoleMount == "" {
+		return nil, errors.New("no AppRole mount specified")
+	}
+	if parameters.timeout <= 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+	if parameters.cacheDuration < 0 {
+		return nil, errors.New("cache duration cannot be negative")
+	}
+	if len(parameters.walletPaths) == 0 && len(parameters.accountPaths) == 0 {
+		return nil, errors.New("no passphrase paths specified")
+	}
+	if parameters.walletPassphrases == nil {
+		return nil, errors.New("no wallet passphrases supplied")
+	}
+	if parameters.accountPassphrases == nil {
+		return nil, errors.New("no account passphrases supplied")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,184 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/dirk/services/metrics"
+	"github.com/attestantio/dirk/services/unlocker"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// Service is an unlocker service that fetches unlock passphrases for wallets and accounts from Vault.
+type Service struct {
+	monitor            metrics.UnlockerMonitor
+	client             *client
+	cacheDuration      time.Duration
+	walletPaths        []string
+	accountPaths       []string
+	walletPassphrases  []string
+	accountPassphrases []string
+
+	cacheMu sync.Mutex
+	cache   map[string]*cachedSecret
+}
+
+// cachedSecret holds the passphrases fetched from a path.
+type cachedSecret struct {
+	passphrases []string
+	fetched     time.Time
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new unlocker service that fetches unlock passphrases for wallets and accounts from Vault.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "unlocker").Str("impl", "vault").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	client, err := newClient(parameters)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Vault client")
+	}
+	if err := client.authenticate(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to authenticate with Vault")
+	}
+	// Keep the token valid for as long as Dirk is running.
+	go client.maintainToken(ctx)
+
+	s := &Service{
+		monitor:            parameters.monitor,
+		client:             client,
+		cacheDuration:      parameters.cacheDuration,
+		walletPaths:        parameters.walletPaths,
+		accountPaths:       parameters.accountPaths,
+		walletPassphrases:  parameters.walletPassphrases,
+		accountPassphrases: parameters.accountPassphrases,
+		cache:              make(map[string]*cachedSecret),
+	}
+
+	return s, nil
+}
+
+// UnlockWallet attempts to unlock a wallet.
+func (s *Service) UnlockWallet(ctx context.Context, wallet e2wtypes.Wallet) (bool, error) {
+	if wallet == nil {
+		return false, errors.New("no wallet supplied")
+	}
+
+	locker, isUnlocker := wallet.(e2wtypes.WalletLocker)
+	if !isUnlocker {
+		// Wallet does not support unlocking.
+		return true, nil
+	}
+
+	if wallet.Type() == "non-deterministic" {
+		// Non-deterministic wallets don't have passphrases.
+		err := locker.Unlock(ctx, nil)
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	for _, passphrase := range s.walletPassphrases {
+		if err := locker.Unlock(ctx, []byte(passphrase)); err == nil {
+			return true, nil
+		}
+	}
+	for _, passphrase := range s.passphrases(ctx, s.walletPaths) {
+		if err := locker.Unlock(ctx, []byte(passphrase)); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// UnlockAccount attempts to unlock an account.
+func (s *Service) UnlockAccount(ctx context.Context, wallet e2wtypes.Wallet, account e2wtypes.Account) (bool, error) {
+	if wallet == nil {
+		return false, errors.New("no wallet supplied")
+	}
+	if account == nil {
+		return false, errors.New("no account supplied")
+	}
+
+	locker, isUnlocker := account.(e2wtypes.AccountLocker)
+	if !isUnlocker {
+		// Account does not support unlocking.
+		return true, nil
+	}
+
+	if unlocker.IsDerivedAccount(account) {
+		if err := locker.Unlock(ctx, nil); err == nil {
+			return true, nil
+		}
+	}
+
+	for _, passphrase := range s.accountPassphrases {
+		if err := locker.Unlock(ctx, []byte(passphrase)); err == nil {
+			return true, nil
+		}
+	}
+	for _, passphrase := range s.passphrases(ctx, s.accountPaths) {
+		if err := locker.Unlock(ctx, []byte(passphrase)); err == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// passphrases returns the passphrases held at the given paths.  If a path cannot be read any
+// passphrases previously fetched from it are returned.
+func (s *Service) passphrases(ctx context.Context, paths []string) []string {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	res := make([]string, 0)
+	for _, path := range paths {
+		cached, exists := s.cache[path]
+		if !exists || time.Since(cached.fetched) >= s.cacheDuration {
+			passphrases, err := s.client.readSecret(ctx, path)
+			if err != nil {
+				log.Warn().Err(err).Str("path", path).Msg("Failed to fetch passphrases from Vault")
+			} else {
+				cached = &cachedSecret{
+					passphrases: passphrases,
+					fetched:     time.Now(),
+				}
+				s.cache[path] = cached
+			}
+		}
+		if cached != nil {
+			res = append(res, cached.passphrases...)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,388 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vault_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/attestantio/dirk/services/unlocker/vault"
+	"github.com/attestantio/dirk/testing/mock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	hd "github.com/wealdtech/go-eth2-wallet-hd/v2"
+	scratch "github.com/wealdtech/go-eth2-wallet-store-scratch"
+)
+
+// fakeVault is a minimal Vault server.
+type fakeVault struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	tokens map[string]bool
+	logins int
+}
+
+func newFakeVault(t *testing.T) *fakeVault {
+	v := &fakeVault{
+		tokens: map[string]bool{"root": true},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		req := make(map[string]string)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req["role_id"] != "role" || req["secret_id"] != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		v.mu.Lock()
+		v.logins++
+		token := fmt.Sprintf("approle-%d", v.logins)
+		v.tokens[token] = true
+		v.mu.Unlock()
+		v.write(w, map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600, "renewable": true},
+		})
+	})
+	mux.HandleFunc("/v1/auth/token/lookup-self", func(w http.ResponseWriter, r *http.Request) {
+		if !v.authorized(w, r) {
+			return
+		}
+		v.write(w, map[string]interface{}{
+			"data": map[string]interface{}{"ttl": 0, "renewable": false},
+		})
+	})
+	mux.HandleFunc("/v1/secret/data/dirk/accounts", func(w http.ResponseWriter, r *http.Request) {
+		if !v.authorized(w, r) {
+			return
+		}
+		v.write(w, map[string]interface{}{
+			"data": map[string]interface{}{
+				"data":     map[string]interface{}{"a": "secret", "b": "secret2"},
+				"metadata": map[string]interface{}{"version": 1},
+			},
+		})
+	})
+	mux.HandleFunc("/v1/kv/dirk/wallets", func(w http.ResponseWriter, r *http.Request) {
+		if !v.authorized(w, r) {
+			return
+		}
+		v.write(w, map[string]interface{}{
+			"data": map[string]interface{}{"passphrase": "secret"},
+		})
+	})
+	v.server = httptest.NewServer(mux)
+	t.Cleanup(v.server.Close)
+
+	return v
+}
+
+func (v *fakeVault) authorized(w http.ResponseWriter, r *http.Request) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.tokens[r.Header.Get("X-Vault-Token")] {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return false
+	}
+	return true
+}
+
+func (v *fakeVault) write(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+func (v *fakeVault) revoke(token string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.tokens, token)
+}
+
+func (v *fakeVault) grant(token string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tokens[token] = true
+}
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeVault(t)
+
+	tests := []struct {
+		name   string
+		params []vault.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithToken("root"),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+			},
+			err: "problem with parameters: no address specified",
+		},
+		{
+			name: "AuthMissing",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+			},
+			err: "problem with parameters: exactly one of token, token file or AppRole must be specified",
+		},
+		{
+			name: "AuthMultiple",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithToken("root"),
+				vault.WithAppRoleID("role"),
+				vault.WithAppRoleSecretID("secret"),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+			},
+			err: "problem with parameters: exactly one of token, token file or AppRole must be specified",
+		},
+		{
+			name: "AppRoleSecretIDMissing",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithAppRoleID("role"),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+			},
+			err: "problem with parameters: AppRole role ID and secret ID must be specified together",
+		},
+		{
+			name: "PathsMissing",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithToken("root"),
+			},
+			err: "problem with parameters: no passphrase paths specified",
+		},
+		{
+			name: "CacheDurationNegative",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithToken("root"),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+				vault.WithCacheDuration(-1),
+			},
+			err: "problem with parameters: cache duration cannot be negative",
+		},
+		{
+			name: "TokenInvalid",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithToken("invalid"),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+			},
+			err: "failed to authenticate with Vault: failed to look up token: permission denied",
+		},
+		{
+			name: "GoodToken",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithToken("root"),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+			},
+		},
+		{
+			name: "GoodAppRole",
+			params: []vault.Parameter{
+				vault.WithLogLevel(zerolog.Disabled),
+				vault.WithAddress(fake.server.URL),
+				vault.WithAppRoleID("role"),
+				vault.WithAppRoleSecretID("secret"),
+				vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := vault.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUnlockWallet(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeVault(t)
+
+	service, err := vault.New(ctx,
+		vault.WithLogLevel(zerolog.Disabled),
+		vault.WithAddress(fake.server.URL),
+		vault.WithToken("root"),
+		vault.WithWalletPaths([]string{"kv/dirk/wallets"}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, e2types.InitBLS())
+	store := scratch.New()
+	encryptor := keystorev4.New()
+	seed := make([]byte, 64)
+	unknownWallet, err := hd.CreateWallet(ctx, "HD wallet 1", []byte("not known"), store, encryptor, seed)
+	require.NoError(t, err)
+	knownWallet, err := hd.CreateWallet(ctx, "HD wallet 2", []byte("secret"), store, encryptor, seed)
+	require.NoError(t, err)
+
+	unlocked, err := service.UnlockWallet(ctx, unknownWallet)
+	require.NoError(t, err)
+	assert.False(t, unlocked)
+
+	unlocked, err = service.UnlockWallet(ctx, knownWallet)
+	require.NoError(t, err)
+	assert.True(t, unlocked)
+}
+
+func TestUnlockAccount(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeVault(t)
+
+	service, err := vault.New(ctx,
+		vault.WithLogLevel(zerolog.Disabled),
+		vault.WithAddress(fake.server.URL),
+		vault.WithAppRoleID("role"),
+		vault.WithAppRoleSecretID("secret"),
+		vault.WithAccountPaths([]string{"secret/data/dirk/accounts", "secret/data/dirk/unknown"}),
+		vault.WithAccountPassphrases([]string{"local"}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, e2types.InitBLS())
+	wallet := mock.NewWallet("Test wallet")
+
+	tests := []struct {
+		name    string
+		account *mock.Account
+		result  bool
+	}{
+		{
+			name:    "UnknownPassword",
+			account: mock.NewAccount("Account 1", []byte("unknown secret")),
+			result:  false,
+		},
+		{
+			name:    "Local",
+			account: mock.NewAccount("Account 1", []byte("local")),
+			result:  true,
+		},
+		{
+			name:    "Vault",
+			account: mock.NewAccount("Account 1", []byte("secret")),
+			result:  true,
+		},
+		{
+			name:    "VaultSecondValue",
+			account: mock.NewAccount("Account 1", []byte("secret2")),
+			result:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := service.UnlockAccount(ctx, wallet, test.account)
+			require.NoError(t, err)
+			assert.Equal(t, test.result, result)
+		})
+	}
+}
+
+func TestReauthenticate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := newFakeVault(t)
+
+	require.NoError(t, e2types.InitBLS())
+	wallet := mock.NewWallet("Test wallet")
+
+	// AppRole logs in again when its token is revoked.
+	appRoleService, err := vault.New(ctx,
+		vault.WithLogLevel(zerolog.Disabled),
+		vault.WithAddress(fake.server.URL),
+		vault.WithAppRoleID("role"),
+		vault.WithAppRoleSecretID("secret"),
+		vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+		vault.WithCacheDuration(0),
+	)
+	require.NoError(t, err)
+	fake.revoke("approle-1")
+	unlocked, err := appRoleService.UnlockAccount(ctx, wallet, mock.NewAccount("Account 1", []byte("secret")))
+	require.NoError(t, err)
+	assert.True(t, unlocked)
+
+	// A token file is read again when its token is revoked.
+	dir, err := ioutil.TempDir("", "vault")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("token1\n"), 0600))
+	fake.grant("token1")
+	tokenFileService, err := vault.New(ctx,
+		vault.WithLogLevel(zerolog.Disabled),
+		vault.WithAddress(fake.server.URL),
+		vault.WithTokenFile(tokenFile),
+		vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+		vault.WithCacheDuration(0),
+	)
+	require.NoError(t, err)
+	fake.revoke("token1")
+	fake.grant("token2")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("token2\n"), 0600))
+	unlocked, err = tokenFileService.UnlockAccount(ctx, wallet, mock.NewAccount("Account 1", []byte("secret")))
+	require.NoError(t, err)
+	assert.True(t, unlocked)
+
+	// Previously fetched passphrases are used if Vault cannot be reached.
+	cachedService, err := vault.New(ctx,
+		vault.WithLogLevel(zerolog.Disabled),
+		vault.WithAddress(fake.server.URL),
+		vault.WithToken("root"),
+		vault.WithAccountPaths([]string{"secret/data/dirk/accounts"}),
+		vault.WithCacheDuration(0),
+	)
+	require.NoError(t, err)
+	unlocked, err = cachedService.UnlockAccount(ctx, wallet, mock.NewAccount("Account 1", []byte("secret")))
+	require.NoError(t, err)
+	assert.True(t, unlocked)
+	fake.revoke("root")
+	unlocked, err = cachedService.UnlockAccount(ctx, wallet, mock.NewAccount("Account 1", []byte("secret")))
+	require.NoError(t, err)
+	assert.True(t, unlocked)
+}
@@ -20,7 +20,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"strconv"
 	"strings"
 
 	"github.com/attestantio/dirk/rules"
@@ -29,37 +28,6 @@ import (
 	"github.com/spf13/viper"
 )
 
-// SlashingProtection is the top-level structure for slashing protection data.
-type SlashingProtection struct {
-	Metadata *SlashingProtectionMetadata `json:"metadata"`
-	Data     []*SlashingProtectionData   `json:"data"`
-}
-
-// SlashingProtectionMetadata is the structure for slashing protection metadata.
-type SlashingProtectionMetadata struct {
-	InterchangeFormat        string `json:"interchange_format"`
-	InterchangeFormatVersion string `json:"interchange_format_version"`
-	GenesisValidatorsRoot    string `json:"genesis_validators_root"`
-}
-
-// SlashingProtectionData is the struture for slashing protection data.
-type SlashingProtectionData struct {
-	PublicKey          string                           `json:"pubkey"`
-	SignedBlocks       []*SlashingProtectionProposal    `json:"signed_blocks,omitempty"`
-	SignedAttestations []*SlashingProtectionAttestation `json:"signed_attestations,omitempty"`
-}
-
-// SlashingProtectionProposal is the structure for slashing protection proposal information.
-type SlashingProtectionProposal struct {
-	Slot string `json:"slot"`
-}
-
-// SlashingProtectionAttestation is the structure for slashing protection attestation information.
-type SlashingProtectionAttestation struct {
-	SourceEpoch string `json:"source_epoch"`
-	TargetEpoch string `json:"target_epoch"`
-}
-
 // exportSlashingProtection is a command to export the slashing protection database.
 func exportSlashingProtection(ctx context.Context) {
 	protection, err := fetchSlashingProtection(ctx)
@@ -86,59 +54,43 @@ func exportSlashingProtection(ctx context.Context) {
 }
 
 // fetchSlashingProtection obtains the slashing protection database.
-func fetchSlashingProtection(ctx context.Context) (*SlashingProtection, error) {
+func fetchSlashingProtection(ctx context.Context) (*rules.Interchange, error) {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
-	if viper.GetString("genesis-validators-root") == "" {
-		return nil, errors.New("genesis-validators-root is required for export")
-	}
-	// Confirm that the genesis validators root is of the appropriate format.
-	genesisValidatorsRoot, err := hex.DecodeString(strings.TrimPrefix(viper.GetString("genesis-validators-root"), "0x"))
+	genesisValidatorsRoot, err := configuredGenesisValidatorsRoot("genesis-validators-root")
 	if err != nil {
-		return nil, errors.Wrap(err, "genesis-validators-root is invalid")
+		return nil, err
 	}
-	if len(genesisValidatorsRoot) != 32 {
-		return nil, errors.New("genesis-validators-root must be 32 bytes")
+	if genesisValidatorsRoot == nil {
+		return nil, errors.New("genesis-validators-root is required for export")
 	}
 
-	rules, err := initRules(ctx)
+	rulesSvc, err := initRules(ctx, nil, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set up rules")
 	}
-	protection, err := rules.ExportSlashingProtection(ctx)
+	protection, err := rulesSvc.ExportSlashingProtection(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain slashing protection")
 	}
-	res := &SlashingProtection{
-		Metadata: &SlashingProtectionMetadata{
-			InterchangeFormat:        "complete",
-			InterchangeFormatVersion: "4",
-			GenesisValidatorsRoot:    fmt.Sprintf("%#x", genesisValidatorsRoot),
-		},
-		Data: make([]*SlashingProtectionData, 0),
+
+	return rules.NewInterchange(genesisValidatorsRoot, protection)
+}
+
+// configuredGenesisValidatorsRoot returns the genesis validators root from the given configuration key,
+// or nil if not configured.
+func configuredGenesisValidatorsRoot(key string) ([]byte, error) {
+	if viper.GetString(key) == "" {
+		return nil, nil
+	}
+	// Confirm that the genesis validators root is of the appropriate format.
+	genesisValidatorsRoot, err := hex.DecodeString(strings.TrimPrefix(viper.GetString(key), "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("%s is invalid", key))
 	}
-	for _, v := range protection {
-		data := &SlashingProtectionData{
-			PublicKey: fmt.Sprintf("%#x", v.PubKey),
-		}
-		if v.HighestProposedSlot != -1 {
-			data.SignedBlocks = []*SlashingProtectionProposal{
-				{
-					Slot: fmt.Sprintf("%d", v.HighestProposedSlot),
-				},
-			}
-		}
-		if v.HighestAttestedSourceEpoch != -1 {
-			data.SignedAttestations = []*SlashingProtectionAttestation{
-				{
-					SourceEpoch: fmt.Sprintf("%d", v.HighestAttestedSourceEpoch),
-					TargetEpoch: fmt.Sprintf("%d", v.HighestAttestedTargetEpoch),
-				},
-			}
-		}
-		res.Data = append(res.Data, data)
+	if len(genesisValidatorsRoot) != 32 {
+		return nil, fmt.Errorf("%s must be 32 bytes", key)
 	}
-
-	return res, nil
+	return genesisValidatorsRoot, nil
 }
 
 // importSlashingProtection is a command to import a slashing protection database.
@@ -153,7 +105,7 @@ func importSlashingProtection(ctx context.Context) {
 		os.Exit(1)
 	}
 
-	var protection SlashingProtection
+	var protection rules.Interchange
 	if err := json.Unmarshal(data, &protection); err != nil {
 		fmt.Printf("Failed to parse slashing protection file: %v\n", err)
 		os.Exit(1)
@@ -167,99 +119,104 @@ func importSlashingProtection(ctx context.Context) {
 }
 
 // storeSlashingProtection updates the slashing protection database.
-func storeSlashingProtection(ctx context.Context, protection *SlashingProtection) error {
+func storeSlashingProtection(ctx context.Context, protection *rules.Interchange) error {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
-	// Confirm format and metadata.
-	if protection == nil {
-		return fmt.Errorf("interchange format incorrect; expected complete, found %s", protection.Metadata.InterchangeFormat)
-	}
-	if protection.Metadata == nil {
-		return errors.New("no metadata in file")
+	genesisValidatorsRoot, err := configuredGenesisValidatorsRoot("genesis-validators-root")
+	if err != nil {
+		return err
 	}
-	if protection.Metadata.InterchangeFormat != "complete" {
-		return fmt.Errorf("interchange format incorrect; expected complete, found %s", protection.Metadata.InterchangeFormat)
+	if genesisValidatorsRoot == nil {
+		return errors.New("genesis-validators-root is required for import")
 	}
-	if protection.Metadata.InterchangeFormatVersion != "4" {
-		return fmt.Errorf("interchange format incorrect; expected 4, found %s", protection.Metadata.InterchangeFormatVersion)
+	protectionMap, err := protection.SlashingProtection(genesisValidatorsRoot)
+	if err != nil {
+		return err
 	}
-	if viper.GetString("genesis-validators-root") == "" {
-		return errors.New("genesis-validators-root is required for import")
+
+	rulesSvc, err := initRules(ctx, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to set up rules")
 	}
-	genesisValidatorsRoot, err := hex.DecodeString(strings.TrimPrefix(viper.GetString("genesis-validators-root"), "0x"))
+
+	return mergeSlashingProtection(ctx, rulesSvc, protectionMap)
+}
+
+// mergeSlashingProtection merges the supplied slashing protection data in to the database.
+// Existing entries are only replaced by entries that are at least as high for all values, so
+// high-water marks are never lowered.
+func mergeSlashingProtection(ctx context.Context, rulesSvc rules.Service, protection map[[48]byte]*rules.SlashingProtection) error {
+	retained, err := rulesSvc.MergeSlashingProtection(ctx, protection)
 	if err != nil {
-		return errors.Wrap(err, "genesis-validators-root is invalid")
+		return errors.Wrap(err, "failed to store slashing protection")
 	}
-	if len(genesisValidatorsRoot) != 32 {
-		return errors.New("genesis-validators-root must be 32 bytes")
+	for _, key := range retained {
+		fmt.Printf("Existing entry for public key %#x contains newer data; not importing\n", key)
 	}
-	if viper.GetString("genesis-validators-root") != protection.Metadata.GenesisValidatorsRoot {
-		return fmt.Errorf("genesis validators root incorrect; expected %s, found %s", viper.GetString("genesis-validators-root"), protection.Metadata.GenesisValidatorsRoot)
+
+	return nil
+}
+
+// backupSlashingProtection writes a snapshot of the slashing protection database of the running
+// instance to the configured backup path.
+func backupSlashingProtection(ctx context.Context, rulesSvc rules.Service) {
+	if viper.GetString("server.slashing-protection-backup-path") == "" {
+		log.Warn().Msg("No slashing protection backup path configured; not backing up")
+		return
 	}
+	path := resolvePath(viper.GetString("server.slashing-protection-backup-path"))
+	log.Info().Str("path", path).Msg("Backing up slashing protection")
 
-	rulesSvc, err := initRules(ctx)
+	// Write to a temporary file first, so that an existing backup is only replaced by a complete one.
+	tmpPath := fmt.Sprintf("%s.tmp", path)
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
 	if err != nil {
-		return errors.Wrap(err, "failed to set up rules")
+		log.Error().Err(err).Msg("Failed to create slashing protection backup file")
+		return
+	}
+	if err := rulesSvc.BackupSlashingProtection(ctx, f); err != nil {
+		f.Close()
+		log.Error().Err(err).Msg("Failed to back up slashing protection")
+		return
+	}
+	if err := f.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close slashing protection backup file")
+		return
 	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		log.Error().Err(err).Msg("Failed to move slashing protection backup in to place")
+		return
+	}
+	log.Info().Str("path", path).Msg("Backed up slashing protection")
+}
 
-	existingProtection, err := rulesSvc.ExportSlashingProtection(ctx)
+// restoreSlashingProtection is a command to restore slashing protection from a backup.
+func restoreSlashingProtection(ctx context.Context) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	if viper.GetString("slashing-protection-file") == "" {
+		fmt.Println("Slashing protection file required for restore")
+		os.Exit(1)
+	}
+	f, err := os.Open(viper.GetString("slashing-protection-file"))
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain existing protection")
+		fmt.Printf("Failed to open slashing protection file: %v\n", err)
+		os.Exit(1)
 	}
+	defer f.Close()
 
-	protectionMap := make(map[[48]byte]*rules.SlashingProtection)
-	for i := range protection.Data {
-		var key [48]byte
-		copy(key[:], protection.Data[i].PublicKey)
-		keyProtection := &rules.SlashingProtection{
-			HighestAttestedSourceEpoch: -1,
-			HighestAttestedTargetEpoch: -1,
-			HighestProposedSlot:        -1,
-		}
-		// We take the absolute highest source epoch and target epoch across all provided attestations.
-		for _, attestation := range protection.Data[i].SignedAttestations {
-			sourceEpoch, err := strconv.ParseInt(attestation.SourceEpoch, 10, 64)
-			if err != nil {
-				return errors.Wrap(err, "invalid attestation source epoch")
-			}
-			if sourceEpoch > keyProtection.HighestAttestedSourceEpoch {
-				keyProtection.HighestAttestedSourceEpoch = sourceEpoch
-			}
-			targetEpoch, err := strconv.ParseInt(attestation.TargetEpoch, 10, 64)
-			if err != nil {
-				return errors.Wrap(err, "invalid attestation target epoch")
-			}
-			if targetEpoch > keyProtection.HighestAttestedTargetEpoch {
-				keyProtection.HighestAttestedTargetEpoch = targetEpoch
-			}
-		}
-		// We take the absolute highest slot across all provided proposals.
-		for _, proposal := range protection.Data[i].SignedBlocks {
-			slot, err := strconv.ParseInt(proposal.Slot, 10, 64)
-			if err != nil {
-				return errors.Wrap(err, "invalid proposal slot")
-			}
-			if slot > keyProtection.HighestProposedSlot {
-				keyProtection.HighestProposedSlot = slot
-			}
-		}
-
-		existingKeyProtection, exists := existingProtection[key]
-		if exists {
-			// We already have an entry; only add this if it contains newer data.
-			if existingKeyProtection.HighestAttestedSourceEpoch <= keyProtection.HighestAttestedSourceEpoch &&
-				existingKeyProtection.HighestAttestedTargetEpoch <= keyProtection.HighestAttestedTargetEpoch &&
-				existingKeyProtection.HighestProposedSlot <= keyProtection.HighestProposedSlot {
-				protectionMap[key] = keyProtection
-			} else {
-				fmt.Printf("Existing entry for public key %#x contains newer data; not importing\n", key)
-			}
-		} else {
-			protectionMap[key] = keyProtection
-		}
+	rulesSvc, err := initRules(ctx, nil, nil)
+	if err != nil {
+		fmt.Printf("Failed to set up rules: %v\n", err)
+		os.Exit(1)
 	}
-	if err := rulesSvc.ImportSlashingProtection(ctx, protectionMap); err != nil {
-		return errors.Wrap(err, "failed to obtain slashing protection")
+	protection, err := rulesSvc.ParseSlashingProtectionBackup(ctx, f)
+	if err != nil {
+		fmt.Printf("Failed to read slashing protection backup: %v\n", err)
+		os.Exit(1)
+	}
+	if err := mergeSlashingProtection(ctx, rulesSvc, protection); err != nil {
+		fmt.Printf("Failed to restore slashing protection: %v\n", err)
+		os.Exit(1)
 	}
 
-	return errors.New("not implemented")
+	os.Exit(0)
 }
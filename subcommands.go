@@ -0,0 +1,368 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/dirk/rules"
+	adminhandler "github.com/attestantio/dirk/services/api/grpc/handlers/admin"
+	"github.com/attestantio/dirk/services/checker"
+	"github.com/attestantio/dirk/services/ruler"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wallet "github.com/wealdtech/go-eth2-wallet"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"github.com/wealdtech/go-majordomo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// accountActions are the actions on an account checked by the permissions check subcommand.
+var accountActions = []string{
+	ruler.ActionAccessAccount,
+	ruler.ActionSign,
+	ruler.ActionSignBeaconAttestation,
+	ruler.ActionSignBeaconProposal,
+	ruler.ActionSignRANDAOReveal,
+	ruler.ActionSignAggregateAndProof,
+	ruler.ActionSignAggregationSlot,
+	ruler.ActionSignSyncCommitteeMessage,
+	ruler.ActionSignSyncCommitteeSelectionProof,
+	ruler.ActionSignSyncCommitteeContributionAndProof,
+	ruler.ActionSignVoluntaryExit,
+	ruler.ActionSignBLSToExecutionChange,
+	ruler.ActionSignValidatorRegistration,
+	ruler.ActionSignDeposit,
+	ruler.ActionCreateAccount,
+	ruler.ActionLockWallet,
+	ruler.ActionUnlockWallet,
+	ruler.ActionLockAccount,
+	ruler.ActionUnlockAccount,
+	ruler.ActionPauseSigning,
+	ruler.ActionResumeSigning,
+	ruler.ActionExportAccount,
+	ruler.ActionRecoverAccount,
+}
+
+// runSubcommand runs the subcommand given by the command-line arguments and exits.
+func runSubcommand(ctx context.Context, majordomo majordomo.Service, args []string) {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	if err := e2types.InitBLS(); err != nil {
+		fmt.Printf("Failed to initialise BLS library: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) < 2 {
+		fmt.Println("Usage: dirk accounts list [path...] | permissions check <client> <account> | protection show <pubkey>")
+		os.Exit(1)
+	}
+
+	var err error
+	switch fmt.Sprintf("%s %s", args[0], args[1]) {
+	case "accounts list":
+		err = listAccounts(ctx, majordomo, args[2:])
+	case "permissions check":
+		err = checkPermissions(ctx, args[2:])
+	case "protection show":
+		err = showProtection(ctx, majordomo, args[2:])
+	default:
+		err = fmt.Errorf("unknown command %q", strings.Join(args[:2], " "))
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// listAccounts lists the accounts matching the given paths, or all accounts if no paths are given.
+func listAccounts(ctx context.Context, majordomo majordomo.Service, paths []string) error {
+	if viper.GetString("instance") != "" {
+		return listInstanceAccounts(ctx, majordomo, paths)
+	}
+
+	stores, err := initStores(ctx)
+	if err != nil {
+		return err
+	}
+	matchers, err := accountMatchers(paths)
+	if err != nil {
+		return err
+	}
+
+	accounts := make(map[string][]byte)
+	for _, store := range stores {
+		for wallet := range e2wallet.Wallets(e2wallet.WithStore(store)) {
+			for account := range wallet.Accounts(ctx) {
+				if !matchAccount(matchers, wallet.Name(), account.Name()) {
+					continue
+				}
+				var pubKey []byte
+				if provider, isProvider := account.(e2wtypes.AccountCompositePublicKeyProvider); isProvider {
+					pubKey = provider.CompositePublicKey().Marshal()
+				} else if provider, isProvider := account.(e2wtypes.AccountPublicKeyProvider); isProvider {
+					pubKey = provider.PublicKey().Marshal()
+				}
+				accounts[fmt.Sprintf("%s/%s", wallet.Name(), account.Name())] = pubKey
+			}
+		}
+	}
+	printAccounts(accounts)
+
+	return nil
+}
+
+// listInstanceAccounts lists the accounts matching the given paths from a running instance.
+func listInstanceAccounts(ctx context.Context, majordomo majordomo.Service, paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("paths are required to list accounts of a running instance")
+	}
+	conn, err := dialInstance(ctx, majordomo)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	res, err := pb.NewListerClient(conn).ListAccounts(ctx, &pb.ListAccountsRequest{Paths: paths})
+	if err != nil {
+		return errors.Wrap(err, "failed to list accounts")
+	}
+	if res.State != pb.ResponseState_SUCCEEDED {
+		return fmt.Errorf("request to list accounts %s", strings.ToLower(res.State.String()))
+	}
+
+	accounts := make(map[string][]byte)
+	for _, account := range res.Accounts {
+		accounts[account.Name] = account.PublicKey
+	}
+	for _, account := range res.DistributedAccounts {
+		accounts[account.Name] = account.CompositePublicKey
+	}
+	printAccounts(accounts)
+
+	return nil
+}
+
+// accountMatcher matches accounts against a path.
+type accountMatcher struct {
+	wallet  string
+	account *regexp.Regexp
+}
+
+// accountMatchers returns matchers for the given paths, in the same form as those used to list accounts.
+func accountMatchers(paths []string) ([]*accountMatcher, error) {
+	matchers := make([]*accountMatcher, 0, len(paths))
+	for _, path := range paths {
+		walletName, accountPath, err := e2wallet.WalletAndAccountNames(path)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid path %q", path))
+		}
+		if accountPath == "" {
+			accountPath = ".*"
+		}
+		accountRegex, err := regexp.Compile(fmt.Sprintf("^%s$", strings.TrimSuffix(strings.TrimPrefix(accountPath, "^"), "$")))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("invalid account in path %q", path))
+		}
+		matchers = append(matchers, &accountMatcher{
+			wallet:  walletName,
+			account: accountRegex,
+		})
+	}
+	return matchers, nil
+}
+
+// matchAccount returns true if the account matches any of the matchers, or if there are no matchers.
+func matchAccount(matchers []*accountMatcher, walletName string, accountName string) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+	for _, matcher := range matchers {
+		if matcher.wallet == walletName && matcher.account.MatchString(accountName) {
+			return true
+		}
+	}
+	return false
+}
+
+// printAccounts prints accounts and their public keys, ordered by name.
+func printAccounts(accounts map[string][]byte) {
+	names := make([]string, 0, len(accounts))
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s\t%#x\n", name, accounts[name])
+	}
+}
+
+// checkPermissions shows the operations that the configured permissions allow a client to carry out on an account.
+func checkPermissions(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: dirk permissions check <client> <account>")
+	}
+	client := args[0]
+	account := args[1]
+	if _, _, err := e2wallet.WalletAndAccountNames(account); err != nil {
+		return errors.Wrap(err, "invalid account")
+	}
+
+	// The fetcher is required for permissions given by public key.
+	stores, err := initStores(ctx)
+	if err != nil {
+		return err
+	}
+	fetcher, err := startFetcher(ctx, stores, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to set up fetcher")
+	}
+	checkerSvc, err := startChecker(ctx, nil, fetcher)
+	if err != nil {
+		return errors.Wrap(err, "failed to set up permissions")
+	}
+	credentials := &checker.Credentials{Client: client}
+	for _, action := range accountActions {
+		result := "denied"
+		if checkerSvc.Check(ctx, credentials, account, action) {
+			result = "allowed"
+		}
+		fmt.Printf("%s: %s\n", action, result)
+	}
+
+	return nil
+}
+
+// showProtection shows the slashing protection held for a public key.
+func showProtection(ctx context.Context, majordomo majordomo.Service, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: dirk protection show <pubkey>")
+	}
+	pubKey, err := hex.DecodeString(strings.TrimPrefix(args[0], "0x"))
+	if err != nil {
+		return errors.Wrap(err, "invalid public key")
+	}
+	if len(pubKey) != 48 {
+		return errors.New("public key must be 48 bytes")
+	}
+	var key [48]byte
+	copy(key[:], pubKey)
+
+	var protection map[[48]byte]*rules.SlashingProtection
+	if viper.GetString("instance") != "" {
+		protection, err = instanceProtection(ctx, majordomo)
+	} else {
+		var rulesSvc rules.Service
+		rulesSvc, err = initRules(ctx, nil, nil)
+		if err != nil {
+			return errors.Wrap(err, "failed to set up rules")
+		}
+		protection, err = rulesSvc.ExportSlashingProtection(ctx)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain slashing protection")
+	}
+
+	keyProtection, exists := protection[key]
+	if !exists {
+		return fmt.Errorf("no slashing protection for %#x", pubKey)
+	}
+	fmt.Printf("Highest proposed slot: %s\n", protectionValue(keyProtection.HighestProposedSlot))
+	fmt.Printf("Highest attested source epoch: %s\n", protectionValue(keyProtection.HighestAttestedSourceEpoch))
+	fmt.Printf("Highest attested target epoch: %s\n", protectionValue(keyProtection.HighestAttestedTargetEpoch))
+
+	return nil
+}
+
+// protectionValue returns a printable slashing protection value, where -1 means that there is no value.
+func protectionValue(value int64) string {
+	if value < 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%d", value)
+}
+
+// instanceProtection obtains the slashing protection from a running instance.
+func instanceProtection(ctx context.Context, majordomo majordomo.Service) (map[[48]byte]*rules.SlashingProtection, error) {
+	conn, err := dialInstance(ctx, majordomo)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	res := &wrappers.BytesValue{}
+	if err := conn.Invoke(ctx, adminhandler.ExportSlashingProtectionMethod, &empty.Empty{}, res); err != nil {
+		return nil, errors.Wrap(err, "failed to export slashing protection")
+	}
+	interchange := &rules.Interchange{}
+	if err := json.Unmarshal(res.Value, interchange); err != nil {
+		return nil, errors.Wrap(err, "failed to parse slashing protection")
+	}
+	if interchange.Metadata == nil {
+		return nil, errors.New("no metadata in slashing protection")
+	}
+	genesisValidatorsRoot, err := hex.DecodeString(strings.TrimPrefix(interchange.Metadata.GenesisValidatorsRoot, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid genesis validators root in slashing protection")
+	}
+
+	return interchange.SlashingProtection(genesisValidatorsRoot)
+}
+
+// dialInstance connects to the running instance given by the instance flag.  The instance is
+// accessed with the configured certificates, so the name of this server must be permitted.
+func dialInstance(ctx context.Context, majordomo majordomo.Service) (*grpc.ClientConn, error) {
+	certPEMBlock, keyPEMBlock, caPEMBlock, err := fetchCertificates(ctx, majordomo)
+	if err != nil {
+		return nil, err
+	}
+	clientCert, err := tls.X509KeyPair(certPEMBlock, keyPEMBlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to access client certificate/key")
+	}
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		MinVersion:   tls.VersionTLS13,
+	}
+	if len(caPEMBlock) > 0 {
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM(caPEMBlock) {
+			return nil, errors.New("failed to add CA certificate")
+		}
+		tlsCfg.RootCAs = cp
+	}
+
+	conn, err := grpc.DialContext(ctx, viper.GetString("instance"),
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to instance")
+	}
+	return conn, nil
+}
@@ -0,0 +1,73 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clock provides a fake clock for tests.
+package clock
+
+import (
+	"sync"
+	"time"
+
+	utilclock "github.com/attestantio/dirk/util/clock"
+)
+
+// Fake is a clock that only changes time when told to.
+type Fake struct {
+	mu        sync.Mutex
+	now       time.Time
+	chainTime utilclock.ChainTime
+}
+
+// NewFake creates a fake clock at the given time.
+func NewFake(chainTime utilclock.ChainTime, now time.Time) *Fake {
+	return &Fake{
+		now:       now,
+		chainTime: chainTime,
+	}
+}
+
+// NewFakeAtSlot creates a fake clock at the start of the given slot.
+func NewFakeAtSlot(chainTime utilclock.ChainTime, slot uint64) *Fake {
+	return NewFake(chainTime, chainTime.GenesisTime.Add(time.Duration(slot)*chainTime.SlotDuration))
+}
+
+// Now returns the current time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// CurrentSlot returns the current slot.
+func (f *Fake) CurrentSlot() uint64 {
+	return f.chainTime.Slot(f.Now())
+}
+
+// CurrentEpoch returns the current epoch.
+func (f *Fake) CurrentEpoch() uint64 {
+	return f.chainTime.Epoch(f.Now())
+}
+
+// Set sets the current time.
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	f.now = now
+	f.mu.Unlock()
+}
+
+// Advance moves the current time on by the given duration.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
@@ -288,6 +288,7 @@ func New(ctx context.Context, path string, id uint64, port uint32, peersMap map[
 
 	process, err := standardprocess.New(ctx,
 		standardprocess.WithChecker(checker),
+		standardprocess.WithRuler(ruler),
 		standardprocess.WithUnlocker(unlocker),
 		standardprocess.WithSender(sender),
 		standardprocess.WithEncryptor(keystorev4.New()),
@@ -13,11 +13,18 @@
 
 package mock
 
-import "github.com/attestantio/dirk/services/process"
+import (
+	"github.com/attestantio/dirk/services/consensus"
+	"github.com/attestantio/dirk/services/process"
+)
 
 // Processes is a map of local process services.
 var Processes map[uint64]process.Service
 
+// Consensuses is a map of local consensus services.
+var Consensuses map[uint64]consensus.Service
+
 func init() {
 	Processes = make(map[uint64]process.Service)
+	Consensuses = make(map[uint64]consensus.Service)
 }
@@ -0,0 +1,76 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clock provides the current time, and the slot and epoch of the chain derived from it.
+package clock
+
+import (
+	"time"
+)
+
+// Clock provides the current time, and the current slot and epoch of the chain.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// CurrentSlot returns the current slot.
+	CurrentSlot() uint64
+	// CurrentEpoch returns the current epoch.
+	CurrentEpoch() uint64
+}
+
+// ChainTime is the configuration of the chain from which slots and epochs are derived.
+type ChainTime struct {
+	GenesisTime   time.Time
+	SlotDuration  time.Duration
+	SlotsPerEpoch uint64
+}
+
+// Slot returns the slot at the given time.  Times before genesis are in slot 0.
+func (c *ChainTime) Slot(t time.Time) uint64 {
+	if t.Before(c.GenesisTime) {
+		return 0
+	}
+	return uint64(t.Sub(c.GenesisTime) / c.SlotDuration)
+}
+
+// Epoch returns the epoch at the given time.  Times before genesis are in epoch 0.
+func (c *ChainTime) Epoch(t time.Time) uint64 {
+	return c.Slot(t) / c.SlotsPerEpoch
+}
+
+// wallClock is a clock based on the system time.
+type wallClock struct {
+	chainTime ChainTime
+}
+
+// New creates a clock based on the system time.
+func New(chainTime ChainTime) Clock {
+	return &wallClock{
+		chainTime: chainTime,
+	}
+}
+
+// Now returns the current time.
+func (c *wallClock) Now() time.Time {
+	return time.Now()
+}
+
+// CurrentSlot returns the current slot.
+func (c *wallClock) CurrentSlot() uint64 {
+	return c.chainTime.Slot(time.Now())
+}
+
+// CurrentEpoch returns the current epoch.
+func (c *wallClock) CurrentEpoch() uint64 {
+	return c.chainTime.Epoch(time.Now())
+}
@@ -0,0 +1,80 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/dirk/util/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainTime(t *testing.T) {
+	genesisTime := time.Unix(1606824023, 0)
+	chainTime := &clock.ChainTime{
+		GenesisTime:   genesisTime,
+		SlotDuration:  12 * time.Second,
+		SlotsPerEpoch: 32,
+	}
+
+	tests := []struct {
+		name  string
+		time  time.Time
+		slot  uint64
+		epoch uint64
+	}{
+		{
+			name: "PreGenesis",
+			time: genesisTime.Add(-time.Hour),
+		},
+		{
+			name: "Genesis",
+			time: genesisTime,
+		},
+		{
+			name: "EndOfSlot0",
+			time: genesisTime.Add(12*time.Second - time.Nanosecond),
+		},
+		{
+			name: "Slot1",
+			time: genesisTime.Add(12 * time.Second),
+			slot: 1,
+		},
+		{
+			name:  "Epoch1",
+			time:  genesisTime.Add(32 * 12 * time.Second),
+			slot:  32,
+			epoch: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.slot, chainTime.Slot(test.time))
+			require.Equal(t, test.epoch, chainTime.Epoch(test.time))
+		})
+	}
+}
+
+func TestWallClock(t *testing.T) {
+	c := clock.New(clock.ChainTime{
+		GenesisTime:   time.Now().Add(-(32*12 + 6) * time.Second),
+		SlotDuration:  12 * time.Second,
+		SlotsPerEpoch: 32,
+	})
+	require.WithinDuration(t, time.Now(), c.Now(), time.Second)
+	require.Equal(t, uint64(32), c.CurrentSlot())
+	require.Equal(t, uint64(1), c.CurrentEpoch())
+}
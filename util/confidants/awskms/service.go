@@ -0,0 +1,110 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awskms
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/attestantio/dirk/util/confidants"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service returns values held in files encrypted with an Amazon KMS key.
+// This service handles URLs with the scheme "awskms".
+// A full URL is of the form "awskms://region/path/to/file".
+// region can be supplied at creation time if preferred, in which case URLs are of the form "awskms:///path/to/file".
+// If the key is asymmetric its ID must be supplied in the "key" query parameter.
+// Credentials are obtained from the standard AWS credential chain.
+type Service struct {
+	region string
+	client kmsiface.KMSAPI
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new Amazon KMS confidant.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "confidant").Str("impl", "awskms").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	s := &Service{
+		region: parameters.region,
+		client: parameters.client,
+	}
+
+	return s, nil
+}
+
+// SupportedURLSchemes provides the list of schemes supported by this confidant.
+func (s *Service) SupportedURLSchemes(ctx context.Context) ([]string, error) {
+	return []string{"awskms"}, nil
+}
+
+// Fetch fetches a value given its key.
+func (s *Service) Fetch(ctx context.Context, url *url.URL) ([]byte, error) {
+	region := url.Host
+	if region == "" {
+		region = s.region
+	}
+	if region == "" && s.client == nil {
+		return nil, errors.New("no region specified")
+	}
+
+	ciphertext, err := confidants.ReadCiphertext(url.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.client
+	if client == nil {
+		session, err := session.NewSessionWithOptions(session.Options{
+			Config:            *aws.NewConfig().WithRegion(region),
+			SharedConfigState: session.SharedConfigEnable,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initiate session with Amazon KMS")
+		}
+		client = kms.New(session)
+	}
+
+	input := &kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+	}
+	if keyID := url.Query().Get("key"); keyID != "" {
+		input.KeyId = aws.String(keyID)
+	}
+	output, err := client.DecryptWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt value")
+	}
+	log.Trace().Str("path", url.Path).Msg("Decrypted value")
+
+	return output.Plaintext, nil
+}
@@ -0,0 +1,129 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awskms_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/dirk/util/confidants/awskms"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/go-majordomo"
+)
+
+// fakeKMS decrypts ciphertext that is the plaintext prefixed with "encrypted:".
+type fakeKMS struct {
+	kmsiface.KMSAPI
+	keyID string
+}
+
+func (f *fakeKMS) DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	f.keyID = aws.StringValue(input.KeyId)
+	prefix := []byte("encrypted:")
+	if len(input.CiphertextBlob) < len(prefix) || string(input.CiphertextBlob[:len(prefix)]) != string(prefix) {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return &kms.DecryptOutput{Plaintext: input.CiphertextBlob[len(prefix):]}, nil
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	rawPath := filepath.Join(dir, "raw")
+	require.NoError(t, ioutil.WriteFile(rawPath, []byte("encrypted:secret"), 0600))
+	base64Path := filepath.Join(dir, "base64")
+	require.NoError(t, ioutil.WriteFile(base64Path, []byte(base64.StdEncoding.EncodeToString([]byte("encrypted:secret2"))+"\n"), 0600))
+	invalidPath := filepath.Join(dir, "invalid")
+	require.NoError(t, ioutil.WriteFile(invalidPath, []byte("bad"), 0600))
+
+	client := &fakeKMS{}
+	service, err := awskms.New(ctx,
+		awskms.WithLogLevel(zerolog.Disabled),
+		awskms.WithClient(client),
+	)
+	require.NoError(t, err)
+
+	schemes, err := service.SupportedURLSchemes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"awskms"}, schemes)
+
+	tests := []struct {
+		name  string
+		url   string
+		value []byte
+		keyID string
+		err   string
+		errIs error
+	}{
+		{
+			name:  "Missing",
+			url:   "awskms://eu-west-1" + filepath.Join(dir, "missing"),
+			errIs: majordomo.ErrNotFound,
+		},
+		{
+			name: "Invalid",
+			url:  "awskms://eu-west-1" + invalidPath,
+			err:  "failed to decrypt value: invalid ciphertext",
+		},
+		{
+			name:  "Raw",
+			url:   "awskms://eu-west-1" + rawPath,
+			value: []byte("secret"),
+		},
+		{
+			name:  "Base64",
+			url:   "awskms://" + base64Path,
+			value: []byte("secret2"),
+		},
+		{
+			name:  "KeyID",
+			url:   "awskms://eu-west-1" + rawPath + "?key=alias/dirk",
+			value: []byte("secret"),
+			keyID: "alias/dirk",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, err := url.Parse(test.url)
+			require.NoError(t, err)
+			value, err := service.Fetch(ctx, u)
+			switch {
+			case test.errIs != nil:
+				require.True(t, errors.Is(err, test.errIs))
+			case test.err != "":
+				require.EqualError(t, err, test.err)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, test.value, value)
+				assert.Equal(t, test.keyID, client.keyID)
+			}
+		})
+	}
+}
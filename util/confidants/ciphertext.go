@@ -0,0 +1,44 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confidants provides majordomo confidants that decrypt values held in files with cloud key management services.
+package confidants
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/go-majordomo"
+)
+
+// ReadCiphertext reads the ciphertext held in a file.  The file can hold either the raw ciphertext, or
+// the ciphertext encoded as base64 as output by the cloud providers' command-line tools.
+func ReadCiphertext(path string) ([]byte, error) {
+	if path == "" {
+		return nil, errors.New("no path specified")
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, majordomo.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "failed to read ciphertext")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err == nil {
+		return decoded, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,89 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpkms
+
+import (
+	"context"
+
+	"github.com/googleapis/gax-go/v2"
+	"github.com/rs/zerolog"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+// Decrypter is the interface for decrypting values with Google Cloud KMS.
+type Decrypter interface {
+	Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error)
+}
+
+type parameters struct {
+	logLevel        zerolog.Level
+	credentialsPath string
+	key             string
+	decrypter       Decrypter
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithCredentialsPath sets the path for the Google service account file.  If not set the application default
+// credentials are used.
+func WithCredentialsPath(credentialsPath string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.credentialsPath = credentialsPath
+	})
+}
+
+// WithKey sets the default key for decryption, of the form
+// "projects/<project>/locations/<location>/keyRings/<key ring>/cryptoKeys/<key>".
+func WithKey(key string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.key = key
+	})
+}
+
+// WithDecrypter sets the decrypter used to access Google Cloud KMS, in place of a client created for each request.
+func WithDecrypter(decrypter Decrypter) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.decrypter = decrypter
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,108 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpkms
+
+import (
+	"context"
+	"net/url"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"github.com/attestantio/dirk/util/confidants"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"google.golang.org/api/option"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+// Service returns values held in files encrypted with a Google Cloud KMS key.
+// This service handles URLs with the scheme "gcpkms".
+// A full URL is of the form "gcpkms:///path/to/file?key=projects/<project>/locations/<location>/keyRings/<key ring>/cryptoKeys/<key>".
+// The key can be supplied at creation time if preferred, in which case URLs are of the form "gcpkms:///path/to/file".
+type Service struct {
+	credentialsPath string
+	key             string
+	decrypter       Decrypter
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new Google Cloud KMS confidant.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "confidant").Str("impl", "gcpkms").Logger()
+	if parameters.logLevel != log.GetLevel() {
+		log = log.Level(parameters.logLevel)
+	}
+
+	s := &Service{
+		credentialsPath: parameters.credentialsPath,
+		key:             parameters.key,
+		decrypter:       parameters.decrypter,
+	}
+
+	return s, nil
+}
+
+// SupportedURLSchemes provides the list of schemes supported by this confidant.
+func (s *Service) SupportedURLSchemes(ctx context.Context) ([]string, error) {
+	return []string{"gcpkms"}, nil
+}
+
+// Fetch fetches a value given its key.
+func (s *Service) Fetch(ctx context.Context, url *url.URL) ([]byte, error) {
+	key := url.Query().Get("key")
+	if key == "" {
+		key = s.key
+	}
+	if key == "" {
+		return nil, errors.New("no key specified")
+	}
+
+	ciphertext, err := confidants.ReadCiphertext(url.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypter := s.decrypter
+	if decrypter == nil {
+		opts := make([]option.ClientOption, 0)
+		if s.credentialsPath != "" {
+			opts = append(opts, option.WithCredentialsFile(s.credentialsPath))
+		}
+		client, err := kms.NewKeyManagementClient(ctx, opts...)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create Google Cloud KMS client")
+		}
+		defer client.Close()
+		decrypter = client
+	}
+
+	resp, err := decrypter.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:       key,
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt value")
+	}
+	log.Trace().Str("path", url.Path).Msg("Decrypted value")
+
+	return resp.Plaintext, nil
+}
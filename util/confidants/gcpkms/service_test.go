@@ -0,0 +1,112 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gcpkms_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/dirk/util/confidants/gcpkms"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+// fakeDecrypter decrypts ciphertext that is the plaintext prefixed with the key name.
+type fakeDecrypter struct{}
+
+func (f *fakeDecrypter) Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error) {
+	prefix := []byte(req.Name + ":")
+	if len(req.Ciphertext) < len(prefix) || string(req.Ciphertext[:len(prefix)]) != string(prefix) {
+		return nil, errors.New("invalid ciphertext")
+	}
+	return &kmspb.DecryptResponse{Plaintext: req.Ciphertext[len(prefix):]}, nil
+}
+
+func TestFetch(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defaultKeyPath := filepath.Join(dir, "default")
+	require.NoError(t, ioutil.WriteFile(defaultKeyPath, []byte("default-key:secret"), 0600))
+	otherKeyPath := filepath.Join(dir, "other")
+	require.NoError(t, ioutil.WriteFile(otherKeyPath, []byte("other-key:secret2"), 0600))
+
+	noDefaultService, err := gcpkms.New(ctx,
+		gcpkms.WithLogLevel(zerolog.Disabled),
+		gcpkms.WithDecrypter(&fakeDecrypter{}),
+	)
+	require.NoError(t, err)
+	u, err := url.Parse("gcpkms://" + defaultKeyPath)
+	require.NoError(t, err)
+	_, err = noDefaultService.Fetch(ctx, u)
+	require.EqualError(t, err, "no key specified")
+
+	service, err := gcpkms.New(ctx,
+		gcpkms.WithLogLevel(zerolog.Disabled),
+		gcpkms.WithKey("default-key"),
+		gcpkms.WithDecrypter(&fakeDecrypter{}),
+	)
+	require.NoError(t, err)
+
+	schemes, err := service.SupportedURLSchemes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gcpkms"}, schemes)
+
+	tests := []struct {
+		name  string
+		url   string
+		value []byte
+		err   string
+	}{
+		{
+			name:  "DefaultKey",
+			url:   "gcpkms://" + defaultKeyPath,
+			value: []byte("secret"),
+		},
+		{
+			name:  "OtherKey",
+			url:   "gcpkms://" + otherKeyPath + "?key=other-key",
+			value: []byte("secret2"),
+		},
+		{
+			name: "WrongKey",
+			url:  "gcpkms://" + otherKeyPath,
+			err:  "failed to decrypt value: invalid ciphertext",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u, err := url.Parse(test.url)
+			require.NoError(t, err)
+			value, err := service.Fetch(ctx, u)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.value, value)
+			}
+		})
+	}
+}
@@ -17,22 +17,17 @@ import (
 	"github.com/rs/zerolog"
 )
 
-// JaegerLogger provides the Jaeger logging interface with a zerolog backend.
-type JaegerLogger struct {
+// OTelErrorHandler provides the OpenTelemetry error handler interface with a zerolog backend.
+type OTelErrorHandler struct {
 	log zerolog.Logger
 }
 
-// NewJaegerLogger creates a new Jaeger logger with a zerolog backend.
-func NewJaegerLogger(log zerolog.Logger) *JaegerLogger {
-	return &JaegerLogger{log: log}
+// NewOTelErrorHandler creates a new OpenTelemetry error handler with a zerolog backend.
+func NewOTelErrorHandler(log zerolog.Logger) *OTelErrorHandler {
+	return &OTelErrorHandler{log: log}
 }
 
-// Error logs an error.
-func (l *JaegerLogger) Error(msg string) {
-	l.log.Error().Msg(msg)
-}
-
-// Infof logs information.
-func (l *JaegerLogger) Infof(msg string, args ...interface{}) {
-	l.log.Info().Msgf(msg, args...)
+// Handle logs an error.
+func (h *OTelErrorHandler) Handle(err error) {
+	h.log.Warn().Err(err).Msg("Tracing error")
 }
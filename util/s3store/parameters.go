@@ -0,0 +1,85 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3store
+
+import (
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+)
+
+type parameters struct {
+	client     s3iface.S3API
+	bucket     string
+	prefix     string
+	passphrase []byte
+}
+
+// Parameter is the interface for store parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithClient sets the client used to access the object store.
+func WithClient(client s3iface.S3API) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.client = client
+	})
+}
+
+// WithBucket sets the bucket in which the wallets are held.
+func WithBucket(bucket string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bucket = bucket
+	})
+}
+
+// WithPrefix sets the prefix of the keys under which the wallets are held.
+func WithPrefix(prefix string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.prefix = prefix
+	})
+}
+
+// WithPassphrase sets the passphrase with which the objects are encrypted.
+// If not supplied the objects are not encrypted.
+func WithPassphrase(passphrase []byte) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.passphrase = passphrase
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.client == nil {
+		return nil, errors.New("no client specified")
+	}
+	if parameters.bucket == "" {
+		return nil, errors.New("no bucket specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,296 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/go-ecodec"
+)
+
+// Store is a wallet store held in an S3-compatible object store.
+// Wallets and accounts are cached in memory once read, so the object store is only consulted for data that has
+// not been seen before and for account indices, which can change as accounts are added.
+type Store struct {
+	client     s3iface.S3API
+	bucket     string
+	prefix     string
+	passphrase []byte
+	cacheMu    sync.RWMutex
+	cache      map[string][]byte
+}
+
+// New creates a new S3 wallet store.
+func New(params ...Parameter) (*Store, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	prefix := strings.Trim(parameters.prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &Store{
+		client:     parameters.client,
+		bucket:     parameters.bucket,
+		prefix:     prefix,
+		passphrase: parameters.passphrase,
+		cache:      make(map[string][]byte),
+	}, nil
+}
+
+// Name returns the name of this store.
+func (s *Store) Name() string {
+	return "s3"
+}
+
+// Location returns the location of this store.
+func (s *Store) Location() string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.prefix)
+}
+
+// StoreWallet stores wallet-level data.
+func (s *Store) StoreWallet(walletID uuid.UUID, walletName string, data []byte) error {
+	if err := s.put(s.walletHeaderKey(walletID), data, true); err != nil {
+		return errors.Wrap(err, "failed to store wallet")
+	}
+	return nil
+}
+
+// RetrieveWallets retrieves wallet-level data for all wallets.
+func (s *Store) RetrieveWallets() <-chan []byte {
+	ch := make(chan []byte, 1024)
+	go func() {
+		defer close(ch)
+		keys, err := s.list(s.prefix)
+		if err != nil {
+			return
+		}
+		for _, key := range keys {
+			parts := strings.Split(strings.TrimPrefix(key, s.prefix), "/")
+			if len(parts) != 2 || parts[0] != parts[1] {
+				// Not a wallet.
+				continue
+			}
+			data, err := s.get(key, true)
+			if err != nil {
+				continue
+			}
+			ch <- data
+		}
+	}()
+	return ch
+}
+
+// RetrieveWallet retrieves wallet-level data for a wallet with a given name.
+func (s *Store) RetrieveWallet(walletName string) ([]byte, error) {
+	for data := range s.RetrieveWallets() {
+		info := &struct {
+			Name string `json:"name"`
+		}{}
+		if err := json.Unmarshal(data, info); err == nil && info.Name == walletName {
+			return data, nil
+		}
+	}
+	return nil, errors.New("wallet not found")
+}
+
+// RetrieveWalletByID retrieves wallet-level data for a wallet with a given ID.
+func (s *Store) RetrieveWalletByID(walletID uuid.UUID) ([]byte, error) {
+	data, err := s.get(s.walletHeaderKey(walletID), true)
+	if err != nil {
+		return nil, errors.New("wallet not found")
+	}
+	return data, nil
+}
+
+// StoreAccount stores account-level data.
+func (s *Store) StoreAccount(walletID uuid.UUID, accountID uuid.UUID, data []byte) error {
+	if _, err := s.RetrieveWalletByID(walletID); err != nil {
+		return errors.New("unknown wallet")
+	}
+	if err := s.put(s.accountKey(walletID, accountID), data, true); err != nil {
+		return errors.Wrap(err, "failed to store account")
+	}
+	return nil
+}
+
+// RetrieveAccounts retrieves account-level data for all accounts in a wallet.
+func (s *Store) RetrieveAccounts(walletID uuid.UUID) <-chan []byte {
+	ch := make(chan []byte, 1024)
+	go func() {
+		defer close(ch)
+		keys, err := s.list(s.walletKey(walletID) + "/")
+		if err != nil {
+			return
+		}
+		for _, key := range keys {
+			if key == s.walletHeaderKey(walletID) || key == s.walletIndexKey(walletID) {
+				continue
+			}
+			data, err := s.get(key, true)
+			if err != nil {
+				continue
+			}
+			ch <- data
+		}
+	}()
+	return ch
+}
+
+// RetrieveAccount retrieves account-level data.
+func (s *Store) RetrieveAccount(walletID uuid.UUID, accountID uuid.UUID) ([]byte, error) {
+	data, err := s.get(s.accountKey(walletID, accountID), true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve account")
+	}
+	return data, nil
+}
+
+// StoreAccountsIndex stores the index of accounts for a wallet.
+func (s *Store) StoreAccountsIndex(walletID uuid.UUID, data []byte) error {
+	if err := s.put(s.walletIndexKey(walletID), data, false); err != nil {
+		return errors.Wrap(err, "failed to store wallet index")
+	}
+	return nil
+}
+
+// RetrieveAccountsIndex retrieves the index of accounts for a wallet.
+func (s *Store) RetrieveAccountsIndex(walletID uuid.UUID) ([]byte, error) {
+	data, err := s.get(s.walletIndexKey(walletID), false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to retrieve wallet index")
+	}
+	return data, nil
+}
+
+func (s *Store) walletKey(walletID uuid.UUID) string {
+	return fmt.Sprintf("%s%s", s.prefix, walletID.String())
+}
+
+func (s *Store) walletHeaderKey(walletID uuid.UUID) string {
+	return fmt.Sprintf("%s/%s", s.walletKey(walletID), walletID.String())
+}
+
+func (s *Store) accountKey(walletID uuid.UUID, accountID uuid.UUID) string {
+	return fmt.Sprintf("%s/%s", s.walletKey(walletID), accountID.String())
+}
+
+func (s *Store) walletIndexKey(walletID uuid.UUID) string {
+	return fmt.Sprintf("%s/index", s.walletKey(walletID))
+}
+
+// put encrypts and stores an object, caching it if requested.
+func (s *Store) put(key string, data []byte, cache bool) error {
+	encrypted, err := s.encrypt(data)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(encrypted),
+	}); err != nil {
+		return err
+	}
+	if cache {
+		s.cacheMu.Lock()
+		s.cache[key] = data
+		s.cacheMu.Unlock()
+	}
+	return nil
+}
+
+// get obtains and decrypts an object, using and populating the cache if requested.
+func (s *Store) get(key string, cache bool) ([]byte, error) {
+	if cache {
+		s.cacheMu.RLock()
+		data, exists := s.cache[key]
+		s.cacheMu.RUnlock()
+		if exists {
+			return data, nil
+		}
+	}
+
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	encrypted, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.decrypt(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache {
+		s.cacheMu.Lock()
+		s.cache[key] = data
+		s.cacheMu.Unlock()
+	}
+	return data, nil
+}
+
+// list lists the keys of all objects with the given prefix.
+func (s *Store) list(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	err := s.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, item := range page.Contents {
+			keys = append(keys, aws.StringValue(item.Key))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// encrypt encrypts data if the store has a passphrase.
+// Empty indices are not encrypted, as they are too short.
+func (s *Store) encrypt(data []byte) ([]byte, error) {
+	if len(s.passphrase) == 0 || len(data) < 16 {
+		return data, nil
+	}
+	return ecodec.Encrypt(data, s.passphrase)
+}
+
+// decrypt decrypts data if the store has a passphrase.
+func (s *Store) decrypt(data []byte) ([]byte, error) {
+	if len(s.passphrase) == 0 || len(data) < 16 {
+		return data, nil
+	}
+	return ecodec.Decrypt(data, s.passphrase)
+}
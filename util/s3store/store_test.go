@@ -0,0 +1,190 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3store_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/attestantio/dirk/util/s3store"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+	nd "github.com/wealdtech/go-eth2-wallet-nd/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestMain(m *testing.M) {
+	if err := e2types.InitBLS(); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// mockS3 is an in-memory S3 client.
+type mockS3 struct {
+	s3iface.S3API
+	mu      sync.Mutex
+	objects map[string][]byte
+	gets    int
+}
+
+func newMockS3() *mockS3 {
+	return &mockS3{objects: make(map[string][]byte)}
+}
+
+func (m *mockS3) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	data, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)] = data
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gets++
+	data, exists := m.objects[aws.StringValue(input.Bucket)+"/"+aws.StringValue(input.Key)]
+	if !exists {
+		return nil, errors.New("NoSuchKey")
+	}
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func (m *mockS3) ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error {
+	m.mu.Lock()
+	prefix := aws.StringValue(input.Bucket) + "/" + aws.StringValue(input.Prefix)
+	keys := make([]string, 0)
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, strings.TrimPrefix(key, aws.StringValue(input.Bucket)+"/"))
+		}
+	}
+	m.mu.Unlock()
+	sort.Strings(keys)
+	output := &s3.ListObjectsV2Output{}
+	for i := range keys {
+		output.Contents = append(output.Contents, &s3.Object{Key: aws.String(keys[i])})
+	}
+	fn(output, true)
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []s3store.Parameter
+		err    string
+	}{
+		{
+			name: "ClientMissing",
+			params: []s3store.Parameter{
+				s3store.WithBucket("bucket"),
+			},
+			err: "problem with parameters: no client specified",
+		},
+		{
+			name: "BucketMissing",
+			params: []s3store.Parameter{
+				s3store.WithClient(newMockS3()),
+			},
+			err: "problem with parameters: no bucket specified",
+		},
+		{
+			name: "Good",
+			params: []s3store.Parameter{
+				s3store.WithClient(newMockS3()),
+				s3store.WithBucket("bucket"),
+				s3store.WithPrefix("/dirk/wallets/"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, err := s3store.New(test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "s3://bucket/dirk/wallets/", store.Location())
+			}
+		})
+	}
+}
+
+func TestWallets(t *testing.T) {
+	ctx := context.Background()
+
+	for _, passphrase := range []string{"", "store secret"} {
+		client := newMockS3()
+		store, err := s3store.New(
+			s3store.WithClient(client),
+			s3store.WithBucket("bucket"),
+			s3store.WithPrefix("dirk"),
+			s3store.WithPassphrase([]byte(passphrase)),
+		)
+		require.NoError(t, err)
+
+		wallet, err := nd.CreateWallet(ctx, "Wallet", store, keystorev4.New())
+		require.NoError(t, err)
+		require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
+		account, err := wallet.(e2wtypes.WalletAccountCreator).CreateAccount(ctx, "Account", []byte("secret"))
+		require.NoError(t, err)
+		for key, data := range client.objects {
+			require.True(t, strings.HasPrefix(key, "bucket/dirk/"))
+			if passphrase != "" && len(data) > 2 {
+				require.NotContains(t, string(data), "Account")
+			}
+		}
+
+		// A fresh store on the same bucket sees the wallet and account.
+		store2, err := s3store.New(
+			s3store.WithClient(client),
+			s3store.WithBucket("bucket"),
+			s3store.WithPrefix("dirk"),
+			s3store.WithPassphrase([]byte(passphrase)),
+		)
+		require.NoError(t, err)
+		wallet2, err := nd.OpenWallet(ctx, "Wallet", store2, keystorev4.New())
+		require.NoError(t, err)
+		require.Equal(t, wallet.ID(), wallet2.ID())
+		account2, err := wallet2.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+		require.NoError(t, err)
+		require.Equal(t, account.PublicKey().Marshal(), account2.(e2wtypes.AccountPublicKeyProvider).PublicKey().Marshal())
+
+		// Accounts that have been read are served from the cache.
+		gets := client.gets
+		_, err = store2.RetrieveAccount(wallet.ID(), account.ID())
+		require.NoError(t, err)
+		require.Equal(t, gets, client.gets)
+
+		_, err = store2.RetrieveWallet("Unknown")
+		require.EqualError(t, err, "wallet not found")
+	}
+}
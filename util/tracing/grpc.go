@@ -0,0 +1,85 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tracing provides helpers to propagate trace context across gRPC calls.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataCarrier allows gRPC metadata to carry trace context.
+type MetadataCarrier metadata.MD
+
+// Get returns the first value for the given key.
+func (c MetadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Set sets the value for the given key.
+func (c MetadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys returns the keys in the metadata.
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// UnaryClientInterceptor starts a client span for outgoing requests and adds the trace context to
+// the request metadata, so that the server can continue the trace.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := otel.Tracer("attestantio.dirk.util.tracing").Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient))
+		defer span.End()
+
+		md, exists := metadata.FromOutgoingContext(ctx)
+		if exists {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		otel.GetTextMapPropagator().Inject(ctx, MetadataCarrier(md))
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return err
+	}
+}
+
+// Extract returns a context containing the trace context from the incoming request metadata, if present.
+func Extract(ctx context.Context) context.Context {
+	md, exists := metadata.FromIncomingContext(ctx)
+	if !exists {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, MetadataCarrier(md))
+}
@@ -0,0 +1,62 @@
+// Copyright © 2020 Attestant Limited.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/dirk/util/tracing"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestPropagation(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	}()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "test")
+	defer span.End()
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-request-id", "1")
+
+	// Capture the metadata sent by the client.
+	var sent metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+	require.NoError(t, tracing.UnaryClientInterceptor()(ctx, "/test/Method", nil, nil, nil, invoker))
+	require.Equal(t, []string{"1"}, sent.Get("x-request-id"))
+	require.NotEmpty(t, sent.Get("traceparent"))
+
+	// The server continues the trace.
+	serverCtx := tracing.Extract(metadata.NewIncomingContext(context.Background(), sent))
+	remote := trace.SpanContextFromContext(serverCtx)
+	require.True(t, remote.IsRemote())
+	require.Equal(t, span.SpanContext().TraceID(), remote.TraceID())
+
+	// Requests without trace context are untouched.
+	ctx = context.Background()
+	require.Equal(t, ctx, tracing.Extract(ctx))
+}